@@ -0,0 +1,405 @@
+// Copyright 2017 The Prometheus Authors
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/go-kit/log"
+	"github.com/go-kit/log/level"
+	"github.com/prometheus-community/jiralert/pkg/alertmanager"
+	"github.com/prometheus-community/jiralert/pkg/clientset"
+	"github.com/prometheus-community/jiralert/pkg/cluster"
+	"github.com/prometheus-community/jiralert/pkg/config"
+	"github.com/prometheus-community/jiralert/pkg/notify"
+	"github.com/prometheus-community/jiralert/pkg/store"
+	"github.com/prometheus-community/jiralert/pkg/template"
+	"github.com/prometheus-community/jiralert/pkg/tracing"
+	"github.com/prometheus-community/jiralert/pkg/webhook"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/propagation"
+	"go.opentelemetry.io/otel/trace"
+)
+
+// alertHandler handles Alertmanager webhook requests.
+type alertHandler struct {
+	logger   log.Logger
+	config   *config.Config
+	tmpl     *template.Template
+	auth     *webhook.Authenticator
+	verifier *webhook.SignatureVerifier
+	opts     notify.Options
+	// issues records the issues managed by notifications.
+	issues *notify.IssueLog
+	// notifications records the outcome of webhook requests.
+	notifications *notify.NotificationLog
+	// updates throttles updates of issues by receivers with a min_update_interval.
+	updates *notify.UpdateTracker
+	// locks serializes notifications of the same alert group.
+	locks *notify.GroupLocker
+	// searches caches the issues found for alert groups by receivers with a search_cache_ttl.
+	searches *notify.SearchCache
+	// transitions caches the workflow transitions of receivers with a transition_cache_ttl.
+	transitions *notify.TransitionCache
+	// users caches the Jira users of assignees, reporters and watchers.
+	users *notify.UserCache
+	// resolves delays the resolution of issues by receivers with an auto_resolve delay.
+	resolves *notify.ResolveScheduler
+	// flaps counts the reopens of issues by receivers with flap_detection.
+	flaps *notify.FlapTracker
+	// bulk gathers the issues created by receivers with a bulk_create_wait into bulk create requests.
+	bulk *notify.BulkCreator
+	// stale tracks when the issues of receivers with stale_issues were last notified firing.
+	stale *notify.StaleTracker
+	// store records the issues of alert groups, looked up before searching Jira, if configured.
+	store store.Store
+	// inFlight tracks the notifications being handled, rejecting more once its limit is reached.
+	inFlight *inFlightTracker
+	// Retry-After of webhook requests rejected as too many notifications are in flight.
+	retryAfter time.Duration
+	// cluster shards alert groups across replicas, if configured.
+	cluster *cluster.Cluster
+	// tenant is the name of the tenant of the configuration, if loaded from the tenants directory.
+	tenant string
+	// Maximum accepted size of webhook request bodies, in bytes.
+	maxRequestSize int64
+	decodeOpts     alertmanager.DecodeOptions
+	// Deadline for handling a notification, on top of the webhook request being canceled. Zero means none.
+	timeout time.Duration
+	// requestID is the ID of the request handled by copies of the handler made with forRequest, and start the time it
+	// was received.
+	requestID string
+	start     time.Time
+}
+
+// HandlerFunc returns the HTTP handler for webhook payloads of the given version, or of any supported version if
+// empty. Payloads are converted to the current alertmanager.Data before being passed to the notify pipeline.
+// Payloads of other senders, such as Grafana, are accepted with the format query parameter, e.g. ?format=grafana.
+func (h *alertHandler) HandlerFunc(version string) func(http.ResponseWriter, *http.Request) {
+	return h.decodingHandlerFunc(func(req *http.Request, body []byte) (*alertmanager.Data, error) {
+		// https://godoc.org/github.com/prometheus/alertmanager/template#Data
+		return alertmanager.DecodeFormat(body, req.URL.Query().Get("format"), version, h.decodeOpts)
+	})
+}
+
+// FormatHandlerFunc returns the HTTP handler for webhook payloads of the given format, see alertmanager.Formats.
+func (h *alertHandler) FormatHandlerFunc(format string) func(http.ResponseWriter, *http.Request) {
+	return h.decodingHandlerFunc(func(_ *http.Request, body []byte) (*alertmanager.Data, error) {
+		return alertmanager.DecodeFormat(body, format, "", h.decodeOpts)
+	})
+}
+
+// decodeFunc converts the body of an authenticated webhook request into the notification to handle.
+type decodeFunc func(req *http.Request, body []byte) (*alertmanager.Data, error)
+
+func (h *alertHandler) decodingHandlerFunc(decode decodeFunc) func(http.ResponseWriter, *http.Request) {
+	return func(w http.ResponseWriter, req *http.Request) {
+		id := newRequestID(req.Header.Get(requestIDHeader))
+		// Forwarded requests keep the ID.
+		req.Header.Set(requestIDHeader, id)
+		w.Header().Set(requestIDHeader, id)
+		h := h.forRequest(id)
+		level.Debug(h.logger).Log("msg", "handling webhook request", "path", req.URL.Path)
+		defer func() { _ = req.Body.Close() }()
+
+		// Continue the trace of the caller, if any, e.g. a proxy in front of JIRAlert.
+		ctx := otel.GetTextMapPropagator().Extract(req.Context(), propagation.HeaderCarrier(req.Header))
+		ctx, span := tracing.Tracer().Start(ctx, "POST "+req.URL.Path, trace.WithSpanKind(trace.SpanKindServer), trace.WithAttributes(attribute.String("jiralert.request_id", id)))
+		defer span.End()
+
+		if h.auth != nil {
+			if err := h.auth.Authenticate(req); err != nil {
+				w.Header().Set("WWW-Authenticate", h.auth.Challenge())
+				h.reject(ctx, w, http.StatusUnauthorized, err, unknownReceiver, &alertmanager.Data{})
+				return
+			}
+		}
+
+		body, err := io.ReadAll(http.MaxBytesReader(w, req.Body, h.maxRequestSize))
+		if err != nil {
+			status := http.StatusBadRequest
+			var maxErr *http.MaxBytesError
+			if errors.As(err, &maxErr) {
+				status = http.StatusRequestEntityTooLarge
+			}
+			h.reject(ctx, w, status, err, unknownReceiver, &alertmanager.Data{})
+			return
+		}
+		if h.verifier != nil {
+			if err := h.verifier.Verify(req.Header, body); err != nil {
+				h.reject(ctx, w, http.StatusUnauthorized, err, unknownReceiver, &alertmanager.Data{})
+				return
+			}
+		}
+
+		data, err := decode(req, body)
+		if err != nil {
+			h.reject(ctx, w, http.StatusBadRequest, err, unknownReceiver, &alertmanager.Data{})
+			return
+		}
+
+		span.SetAttributes(
+			attribute.String("jiralert.receiver", data.Receiver),
+			attribute.String("jiralert.group_key", data.GroupKey),
+			attribute.Int("jiralert.alerts", len(data.Alerts)),
+		)
+
+		// Stop talking to Jira once Alertmanager gives up on the webhook request, or the deadline passes.
+		if h.timeout > 0 {
+			var cancel context.CancelFunc
+			ctx, cancel = context.WithTimeout(ctx, h.timeout)
+			defer cancel()
+		}
+		dryRun := req.URL.Query().Get("dry_run") == "true"
+		var at time.Time
+		if dryRun {
+			if at, err = parseAt(req); err != nil {
+				h.fail(ctx, w, http.StatusBadRequest, err, data.Receiver, data)
+				return
+			}
+		}
+		if h.cluster != nil && !dryRun && req.Header.Get(cluster.ForwardedHeader) == "" {
+			if owner := h.cluster.Owner(data.Receiver, data.GroupKey); owner != h.cluster.Self() && h.forward(ctx, w, req, owner, body, data) {
+				return
+			}
+		}
+		h.notify(ctx, w, data, dryRun, at)
+	}
+}
+
+// parseAt returns the time given with the at query parameter, in RFC 3339, which dry runs and renders handle
+// notifications at, e.g. to preview schedules. It returns the zero time if unset.
+func parseAt(req *http.Request) (time.Time, error) {
+	at := req.URL.Query().Get("at")
+	if at == "" {
+		return time.Time{}, nil
+	}
+	t, err := time.Parse(time.RFC3339, at)
+	if err != nil {
+		return time.Time{}, fmt.Errorf("bad at parameter: %w", err)
+	}
+	return t, nil
+}
+
+// forward passes the webhook request on to the replica owning the alert group, relaying its response. It returns
+// false if the owner is unreachable, in which case the notification should be handled locally rather than lost. If
+// forwarding failed otherwise, e.g. timed out, the owner may still handle it, so the request is failed with 503 for
+// Alertmanager to retry rather than risking duplicate issues.
+func (h *alertHandler) forward(ctx context.Context, w http.ResponseWriter, req *http.Request, owner string, body []byte, data *alertmanager.Data) bool {
+	resp, err := h.cluster.Forward(ctx, owner, req, body)
+	if err != nil {
+		if cluster.Unreachable(err) {
+			level.Warn(h.logger).Log("msg", "failed to forward notification to the owner of its alert group, handling it locally", "owner", owner, "err", err)
+			return false
+		}
+		setRetryAfter(w, h.retryAfter)
+		h.reject(ctx, w, http.StatusServiceUnavailable, fmt.Errorf("forward notification to %s: %w", owner, err), data.Receiver, data)
+		return true
+	}
+	defer resp.Body.Close()
+	level.Debug(h.logger).Log("msg", "forwarded notification", "owner", owner, "status", resp.StatusCode)
+	trace.SpanFromContext(ctx).SetAttributes(attribute.String("jiralert.forwarded_to", owner), attribute.Int("http.status_code", resp.StatusCode))
+	if ct := resp.Header.Get("Content-Type"); ct != "" {
+		w.Header().Set("Content-Type", ct)
+	}
+	w.WriteHeader(resp.StatusCode)
+	_, _ = io.Copy(w, resp.Body)
+	return true
+}
+
+// notify handles the notification, or only reports what it would do to Jira if dryRun is set, at the given time if
+// not zero.
+func (h *alertHandler) notify(ctx context.Context, w http.ResponseWriter, data *alertmanager.Data, dryRun bool, at time.Time) {
+	// Dry runs are not recorded.
+	reject := h.reject
+	if dryRun {
+		reject = h.fail
+	}
+	conf := h.receiverConfig(data.Receiver)
+	if conf == nil {
+		reject(ctx, w, http.StatusNotFound, fmt.Errorf("receiver missing: %s", data.Receiver), unknownReceiver, data)
+		return
+	}
+	level.Debug(h.logger).Log("msg", "  matched receiver", "receiver", conf.Name)
+
+	// Dry runs are previews requested by people, rather than Alertmanager retrying during an outage.
+	if h.inFlight != nil && !dryRun {
+		done, ok := h.inFlight.start(time.Now())
+		if !ok {
+			// Alertmanager retries on 503, with its own backoff, while the notifications in flight drain.
+			setRetryAfter(w, h.retryAfter)
+			reject(ctx, w, http.StatusServiceUnavailable, fmt.Errorf("too many notifications in flight (%d), try again later", h.inFlight.limit), conf.Name, data)
+			return
+		}
+		defer done()
+	}
+
+	// TODO: Consider reusing notifiers or just jira clients to reuse connections.
+	client, err := clientset.New(conf)
+	if err != nil {
+		reject(ctx, w, http.StatusInternalServerError, err, conf.Name, data)
+		return
+	}
+
+	receiver := h.newReceiver(conf, client)
+	if dryRun {
+		if !at.IsZero() {
+			receiver.At(at)
+		}
+		res, retry, err := receiver.DryRun(ctx, data, h.opts)
+		if err != nil {
+			h.fail(ctx, w, notifyErrorStatus(retry), err, conf.Name, data)
+			return
+		}
+		writeJSON(w, http.StatusOK, res)
+		return
+	}
+
+	if conf.FanOut != nil {
+		h.fanOut(ctx, w, receiver, conf, data)
+		return
+	}
+
+	n, retry, err := receiver.NotifyOutcome(ctx, data, h.opts)
+	observeNotification(ctx, conf.Name, n.Action, h.start)
+	if err != nil {
+		h.fail(ctx, w, notifyErrorStatus(retry), err, conf.Name, data)
+		return
+	}
+	res := newNotifyResponse(n)
+	res.RequestID = h.requestID
+	writeJSON(w, http.StatusOK, res)
+	requestTotal.WithLabelValues(conf.Name, "200").Inc()
+	lastNotifySuccess.WithLabelValues(conf.Name).SetToCurrentTime()
+}
+
+// notifyResponse is the body of the responses of handled notifications, telling what was done.
+type notifyResponse struct {
+	Receiver string `json:"receiver"`
+	// Action is the action taken on the issue, or none or suppressed.
+	Action    string `json:"action"`
+	IssueKey  string `json:"issue_key,omitempty"`
+	RequestID string `json:"request_id,omitempty"`
+	// FanOut are the outcomes of the receivers of the fan_out configuration.
+	FanOut []notifyResponse `json:"fan_out,omitempty"`
+}
+
+func newNotifyResponse(n notify.Notification) notifyResponse {
+	return notifyResponse{Receiver: n.Receiver, Action: n.Action, IssueKey: n.IssueKey}
+}
+
+// fanOut notifies the receiver and the receivers of its fan_out configuration, failing the request if any of them
+// failed.
+func (h *alertHandler) fanOut(ctx context.Context, w http.ResponseWriter, receiver *notify.Receiver, conf *config.ReceiverConfig, data *alertmanager.Data) {
+	targets := make([]*notify.Receiver, 0, len(conf.FanOut.Receivers))
+	for _, name := range conf.FanOut.Receivers {
+		// Fan-out receivers are validated when loading the configuration.
+		tc := h.receiverConfig(name)
+		client, err := clientset.New(tc)
+		if err != nil {
+			h.reject(ctx, w, http.StatusInternalServerError, err, tc.Name, data)
+			return
+		}
+		targets = append(targets, h.newReceiver(tc, client))
+	}
+
+	results, retry, err := receiver.FanOut(ctx, targets, data, h.opts)
+	// The receiver comes first, then the receivers of its fan_out configuration.
+	observeNotification(ctx, conf.Name, results[0].Action, h.start)
+	for _, n := range results {
+		if n.Action != notify.ActionFailed {
+			lastNotifySuccess.WithLabelValues(n.Receiver).SetToCurrentTime()
+		}
+	}
+	if err != nil {
+		h.fail(ctx, w, notifyErrorStatus(retry), err, conf.Name, data)
+		return
+	}
+	res := newNotifyResponse(results[0])
+	res.RequestID = h.requestID
+	for _, n := range results[1:] {
+		res.FanOut = append(res.FanOut, newNotifyResponse(n))
+	}
+	writeJSON(w, http.StatusOK, res)
+	requestTotal.WithLabelValues(conf.Name, "200").Inc()
+}
+
+// receiverConfig returns the configuration of the named receiver, or nil if missing. The receivers of tenants are
+// named <tenant>/<receiver>, keeping their metrics, issue logs and other state apart from those of other tenants.
+func (h *alertHandler) receiverConfig(name string) *config.ReceiverConfig {
+	conf := h.config.ReceiverByName(name)
+	if conf == nil || h.tenant == "" {
+		return conf
+	}
+	tc := *conf
+	tc.Name = h.tenant + "/" + conf.Name
+	return &tc
+}
+
+// newReceiver creates the receiver handling notifications with the given configuration.
+func (h *alertHandler) newReceiver(conf *config.ReceiverConfig, client *clientset.Client) *notify.Receiver {
+	return notify.NewReceiver(h.logger, conf, h.tmpl, client).WithIssueLog(h.issues).WithNotificationLog(h.notifications).WithUpdateTracker(h.updates).WithGroupLocker(h.locks).WithSearchCache(h.searches).WithTransitionCache(h.transitions).WithUserCache(h.users).WithResolveScheduler(h.resolves).WithFlapTracker(h.flaps).WithBulkCreator(h.bulk).WithStaleTracker(h.stale).WithStore(h.store)
+}
+
+// fail responds with the error, also recording it on the span of the request.
+func (h *alertHandler) fail(ctx context.Context, w http.ResponseWriter, status int, err error, receiver string, data *alertmanager.Data) {
+	span := trace.SpanFromContext(ctx)
+	span.SetAttributes(attribute.Int("http.status_code", status))
+	span.RecordError(err)
+	span.SetStatus(codes.Error, err.Error())
+	errorHandler(w, status, err, receiver, data, h.requestID, h.logger)
+}
+
+// reject fails a request before it reaches the notify pipeline, recording it as a failed notification.
+func (h *alertHandler) reject(ctx context.Context, w http.ResponseWriter, status int, err error, receiver string, data *alertmanager.Data) {
+	if h.notifications != nil {
+		h.notifications.Record(notify.Notification{
+			Time:     time.Now(),
+			Receiver: receiver,
+			GroupKey: data.GroupKey,
+			Status:   data.Status,
+			Alerts:   len(data.Alerts),
+			Action:   notify.ActionFailed,
+			Error:    err.Error(),
+		})
+	}
+	h.fail(ctx, w, status, err, receiver, data)
+}
+
+// setRetryAfter tells the client to retry the request after d, rounded up to whole seconds, and at least 1s, as a
+// Retry-After of 0 would have clients retry right away.
+func setRetryAfter(w http.ResponseWriter, d time.Duration) {
+	seconds := int((d + time.Second - 1) / time.Second)
+	if seconds < 1 {
+		seconds = 1
+	}
+	w.Header().Set("Retry-After", strconv.Itoa(seconds))
+}
+
+func notifyErrorStatus(retry bool) int {
+	if retry {
+		// Instruct Alertmanager to retry.
+		return http.StatusServiceUnavailable
+	}
+	// Inaccurate, just letting Alertmanager know that it should not retry.
+	return http.StatusBadRequest
+}
@@ -0,0 +1,308 @@
+// Copyright 2017 The Prometheus Authors
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+package main
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/go-kit/log"
+	"github.com/prometheus-community/jiralert/pkg/cluster"
+	"github.com/prometheus-community/jiralert/pkg/config"
+	"github.com/prometheus-community/jiralert/pkg/notify"
+	"github.com/prometheus-community/jiralert/pkg/webhook"
+	"github.com/stretchr/testify/require"
+)
+
+const testPayload = `{"version":"4","receiver":"jira","status":"firing","groupKey":"{}:{alertname=\"Test\"}","alerts":[{"status":"firing","labels":{"alertname":"Test"}}],"groupLabels":{"alertname":"Test"}}`
+
+// newTestAlertHandler returns a webhook handler without receivers, failing notifications which reach the notify
+// pipeline with 404.
+func newTestAlertHandler() *alertHandler {
+	return &alertHandler{
+		logger:         log.NewNopLogger(),
+		config:         &config.Config{},
+		maxRequestSize: 1 << 20,
+		retryAfter:     30 * time.Second,
+	}
+}
+
+// errorResponse decodes the body of a failed webhook request.
+func errorResponse(t *testing.T, rec *httptest.ResponseRecorder) (resp struct {
+	Status    int
+	Message   string
+	RequestID string
+}) {
+	t.Helper()
+	require.NoError(t, json.Unmarshal(rec.Body.Bytes(), &resp))
+	return resp
+}
+
+// ownedByPeer returns a cluster in which the test payload is owned by peer rather than by this replica.
+func ownedByPeer(t *testing.T, peer string, timeout time.Duration) *cluster.Cluster {
+	t.Helper()
+	for i := 0; i < 100; i++ {
+		self := "http://jiralert-" + strings.Repeat("0", i+1) + ":9097"
+		d := config.Duration(timeout)
+		c := cluster.New(&config.ClusterConfig{Peers: []string{self, peer}, Self: self, ForwardTimeout: &d})
+		if c.Owner("jira", `{}:{alertname="Test"}`) == peer {
+			return c
+		}
+	}
+	t.Fatal("no cluster with the test payload owned by the peer")
+	return nil
+}
+
+func TestForwardSlowOwner(t *testing.T) {
+	release := make(chan struct{})
+	owner := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		<-release
+	}))
+	defer owner.Close()
+	defer close(release)
+
+	h := newTestAlertHandler()
+	h.cluster = ownedByPeer(t, owner.URL, 50*time.Millisecond)
+	rec := httptest.NewRecorder()
+	h.HandlerFunc("")(rec, httptest.NewRequest(http.MethodPost, "/alert", strings.NewReader(testPayload)))
+
+	// The owner may still handle the notification, so it is not handled locally, which would fail with 404.
+	require.Equal(t, http.StatusServiceUnavailable, rec.Code)
+	require.Equal(t, "30", rec.Header().Get("Retry-After"))
+	require.Contains(t, errorResponse(t, rec).Message, "forward notification to "+owner.URL)
+}
+
+func TestForwardUnreachableOwner(t *testing.T) {
+	owner := httptest.NewServer(http.NotFoundHandler())
+	owner.Close()
+
+	h := newTestAlertHandler()
+	h.cluster = ownedByPeer(t, owner.URL, time.Second)
+	rec := httptest.NewRecorder()
+	h.HandlerFunc("")(rec, httptest.NewRequest(http.MethodPost, "/alert", strings.NewReader(testPayload)))
+
+	// Handled locally.
+	require.Equal(t, http.StatusNotFound, rec.Code)
+	require.Equal(t, "receiver missing: jira", errorResponse(t, rec).Message)
+}
+
+func TestForwardOwner(t *testing.T) {
+	owner := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		require.NotEmpty(t, r.Header.Get(cluster.ForwardedHeader))
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(http.StatusOK)
+		_, _ = w.Write([]byte(`{"receiver":"jira","action":"created","issue_key":"ABC-1"}`))
+	}))
+	defer owner.Close()
+
+	h := newTestAlertHandler()
+	h.cluster = ownedByPeer(t, owner.URL, time.Second)
+	rec := httptest.NewRecorder()
+	h.HandlerFunc("")(rec, httptest.NewRequest(http.MethodPost, "/alert", strings.NewReader(testPayload)))
+
+	require.Equal(t, http.StatusOK, rec.Code)
+	require.JSONEq(t, `{"receiver":"jira","action":"created","issue_key":"ABC-1"}`, rec.Body.String())
+}
+
+func TestWebhookHandlerChecks(t *testing.T) {
+	signature := &config.WebhookSignatureConfig{Secret: "s3cr3t", Header: "X-Signature", Algorithm: "sha256"}
+	verifier, err := webhook.NewSignatureVerifier(signature)
+	require.NoError(t, err)
+	h := newTestAlertHandler()
+	h.auth = webhook.NewAuthenticator(&config.WebhookAuthConfig{BearerToken: "t0k3n"})
+	h.verifier = verifier
+	h.maxRequestSize = int64(len(testPayload))
+	oversized := testPayload + " "
+
+	for _, tc := range []struct {
+		name      string
+		token     string
+		body      string
+		sign      bool
+		status    int
+		message   string
+		challenge string
+	}{
+		// Credentials are checked before reading the body.
+		{name: "missing credentials", body: oversized, sign: true, status: http.StatusUnauthorized, message: "missing bearer token", challenge: "Bearer"},
+		{name: "wrong credentials", token: "wrong", body: testPayload, sign: true, status: http.StatusUnauthorized, message: "invalid bearer token", challenge: "Bearer"},
+		// The size is checked before the signature, which requires the whole body.
+		{name: "too large", token: "t0k3n", body: oversized, status: http.StatusRequestEntityTooLarge, message: "http: request body too large"},
+		{name: "missing signature", token: "t0k3n", body: testPayload, status: http.StatusUnauthorized, message: "missing signature header X-Signature"},
+		// Only verified payloads are decoded.
+		{name: "bad payload", token: "t0k3n", body: `{"version":`, sign: true, status: http.StatusBadRequest},
+		{name: "unknown receiver", token: "t0k3n", body: testPayload, sign: true, status: http.StatusNotFound, message: "receiver missing: jira"},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			req := httptest.NewRequest(http.MethodPost, "/alert", strings.NewReader(tc.body))
+			if tc.token != "" {
+				req.Header.Set("Authorization", "Bearer "+tc.token)
+			}
+			if tc.sign {
+				verifier.Sign(req.Header, []byte(tc.body))
+			}
+			rec := httptest.NewRecorder()
+			h.HandlerFunc("")(rec, req)
+
+			require.Equal(t, tc.status, rec.Code, rec.Body.String())
+			resp := errorResponse(t, rec)
+			require.Equal(t, tc.status, resp.Status)
+			if tc.message != "" {
+				require.Equal(t, tc.message, resp.Message)
+			}
+			require.NotEmpty(t, resp.RequestID)
+			require.Equal(t, resp.RequestID, rec.Header().Get(requestIDHeader))
+			require.Equal(t, tc.challenge, rec.Header().Get("WWW-Authenticate"))
+		})
+	}
+}
+
+// fakeJira serves the Jira API requests of creating an issue for an alert group, recording the writes.
+type fakeJira struct {
+	*httptest.Server
+	mtx    sync.Mutex
+	writes []string
+}
+
+func newFakeJira(t *testing.T) *fakeJira {
+	f := &fakeJira{}
+	f.Server = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		switch {
+		case r.URL.Path == "/rest/api/2/search":
+			_, _ = w.Write([]byte(`{"issues":[],"total":0}`))
+		case r.Method == http.MethodPost && r.URL.Path == "/rest/api/2/issue":
+			f.mtx.Lock()
+			f.writes = append(f.writes, r.Method+" "+r.URL.Path)
+			f.mtx.Unlock()
+			w.WriteHeader(http.StatusCreated)
+			_, _ = w.Write([]byte(`{"id":"10000","key":"SRE-1"}`))
+		default:
+			t.Errorf("unexpected Jira request %s %s", r.Method, r.URL)
+			w.WriteHeader(http.StatusNotFound)
+		}
+	}))
+	return f
+}
+
+func (f *fakeJira) Writes() []string {
+	f.mtx.Lock()
+	defer f.mtx.Unlock()
+	return append([]string{}, f.writes...)
+}
+
+func testJiraConfig(apiURL string) string {
+	return `
+defaults:
+  api_url: ` + apiURL + `
+  user: jiralert
+  password: JIRAlert
+  issue_type: Bug
+  summary: '{{ .GroupLabels.alertname }}'
+  reopen_state: "To Do"
+  reopen_duration: 0h
+receivers:
+  - name: 'jira'
+    project: SRE
+template: jiralert.tmpl
+`
+}
+
+func TestWebhookHandlerNotify(t *testing.T) {
+	jira := newFakeJira(t)
+	defer jira.Close()
+	h := newTestConfigAlertHandler(t, testJiraConfig(jira.URL))
+
+	// Dry runs only read from Jira, reporting the writes they would do.
+	req := httptest.NewRequest(http.MethodPost, "/alert?dry_run=true", strings.NewReader(testPayload))
+	rec := httptest.NewRecorder()
+	h.HandlerFunc("")(rec, req)
+	require.Equal(t, http.StatusOK, rec.Code, rec.Body.String())
+	var dryRun notify.DryRunResult
+	require.NoError(t, json.Unmarshal(rec.Body.Bytes(), &dryRun))
+	require.Len(t, dryRun.Operations, 1)
+	require.Equal(t, "Issue.Create", dryRun.Operations[0].API)
+	require.Empty(t, jira.Writes())
+
+	// A request ID given by the caller is kept.
+	req = httptest.NewRequest(http.MethodPost, "/alert", strings.NewReader(testPayload))
+	req.Header.Set(requestIDHeader, "req-1")
+	rec = httptest.NewRecorder()
+	h.HandlerFunc("")(rec, req)
+	require.Equal(t, http.StatusOK, rec.Code, rec.Body.String())
+	require.Equal(t, "req-1", rec.Header().Get(requestIDHeader))
+	require.JSONEq(t, `{"receiver":"jira","action":"created","issue_key":"SRE-1","request_id":"req-1"}`, rec.Body.String())
+	require.Equal(t, []string{"POST /rest/api/2/issue"}, jira.Writes())
+}
+
+func TestTenantHandlers(t *testing.T) {
+	jira := newFakeJira(t)
+	defer jira.Close()
+	tenant := newTestConfigAlertHandler(t, testJiraConfig(jira.URL))
+	tenant.tenant = "team-a"
+	tenants := map[string]*alertHandler{"team-a": tenant}
+	alerts := tenantHandlerFunc(newTestAlertHandler(), tenants)
+	paths := tenantPathHandlerFunc(tenants)
+
+	for _, tc := range []struct {
+		name    string
+		handler func(http.ResponseWriter, *http.Request)
+		path    string
+		tenant  string
+		status  int
+		body    string
+	}{
+		{name: "main configuration", handler: alerts, path: "/alert", status: http.StatusNotFound},
+		{name: "tenant header", handler: alerts, path: "/alert", tenant: "team-a", status: http.StatusOK, body: `{"receiver":"team-a/jira","action":"created","issue_key":"SRE-1"}`},
+		{name: "unknown tenant header", handler: alerts, path: "/alert", tenant: "team-b", status: http.StatusNotFound, body: "unknown tenant: team-b\n"},
+		{name: "tenant path", handler: paths, path: "/alert/team-a", status: http.StatusOK, body: `{"receiver":"team-a/jira","action":"created","issue_key":"SRE-1"}`},
+		{name: "unknown tenant path", handler: paths, path: "/alert/team-b", status: http.StatusNotFound, body: "unknown tenant: team-b\n"},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			req := httptest.NewRequest(http.MethodPost, tc.path, strings.NewReader(testPayload))
+			req.Header.Set(requestIDHeader, "req-1")
+			if tc.tenant != "" {
+				req.Header.Set(tenantHeader, tc.tenant)
+			}
+			rec := httptest.NewRecorder()
+			tc.handler(rec, req)
+			require.Equal(t, tc.status, rec.Code, rec.Body.String())
+			switch {
+			case tc.status == http.StatusOK:
+				var resp notifyResponse
+				require.NoError(t, json.Unmarshal(rec.Body.Bytes(), &resp))
+				resp.RequestID = ""
+				body, err := json.Marshal(resp)
+				require.NoError(t, err)
+				require.JSONEq(t, tc.body, string(body))
+			case tc.body != "":
+				require.Equal(t, tc.body, rec.Body.String())
+			}
+		})
+	}
+}
+
+func TestNewRequestID(t *testing.T) {
+	require.Equal(t, "req-1", newRequestID("req-1"))
+	for _, given := range []string{"", "req 1", "req\n1", strings.Repeat("a", maxRequestIDLength+1)} {
+		id := newRequestID(given)
+		require.NotEqual(t, given, id)
+		require.Len(t, id, 32)
+	}
+	require.NotEqual(t, newRequestID(""), newRequestID(""))
+}
@@ -0,0 +1,331 @@
+// Copyright 2017 The Prometheus Authors
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package main
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/go-kit/log"
+	"github.com/prometheus-community/jiralert/pkg/alertmanager"
+	"github.com/prometheus-community/jiralert/pkg/clientset"
+	"github.com/prometheus-community/jiralert/pkg/config"
+	"github.com/prometheus-community/jiralert/pkg/notify"
+	"github.com/prometheus-community/jiralert/pkg/template"
+	"github.com/prometheus/common/expfmt"
+)
+
+const receiversAPIPrefix = "/api/v1/receivers/"
+
+// ReceiverAPIHandlerFunc is the HTTP handler for `/api/v1/receivers/{name}/effective-config`. It outputs the
+// behavioral settings of the named receiver, after merging command line flags, defaults and receiver overrides.
+// Receivers of tenants are named <tenant>/<receiver>, like in metrics. Like notifications, the settings apply the
+// presets matching the common labels given with the label query parameter (name=value, repeated) and the schedule
+// active now, or at the time given with the at query parameter.
+func ReceiverAPIHandlerFunc(alerts *alertHandler, tenants map[string]*alertHandler) func(http.ResponseWriter, *http.Request) {
+	return func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != "GET" {
+			w.WriteHeader(http.StatusBadRequest)
+			_, _ = w.Write([]byte("only GET allowed"))
+			return
+		}
+
+		path := strings.TrimPrefix(r.URL.Path, receiversAPIPrefix)
+		name := strings.TrimSuffix(path, "/effective-config")
+		if name == path || name == "" {
+			http.NotFound(w, r)
+			return
+		}
+		h := alerts
+		if tenant, receiver, ok := strings.Cut(name, "/"); ok && tenants[tenant] != nil {
+			h, name = tenants[tenant], receiver
+		}
+		conf := h.receiverConfig(name)
+		if conf == nil {
+			http.Error(w, "receiver missing: "+name, http.StatusNotFound)
+			return
+		}
+		commonLabels, err := parseLabels(r.URL.Query()["label"])
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+		at, err := parseAt(r)
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+
+		receiver := notify.NewReceiver(h.logger, conf, h.tmpl, nil)
+		if !at.IsZero() {
+			receiver.At(at)
+		}
+		writeJSON(w, http.StatusOK, receiver.EffectiveSettings(commonLabels, h.opts))
+	}
+}
+
+// parseLabels parses labels given as name=value.
+func parseLabels(labels []string) (alertmanager.KV, error) {
+	kv := alertmanager.KV{}
+	for _, l := range labels {
+		k, v, ok := strings.Cut(l, "=")
+		if !ok || k == "" {
+			return nil, fmt.Errorf("label must be given as name=value: %s", l)
+		}
+		kv[k] = v
+	}
+	return kv, nil
+}
+
+// RenderHandlerFunc is the HTTP handler for `/render`. It renders the issue the receiver named by the `receiver`
+// query parameter (default: the payload's receiver) would create for the posted webhook payload, without calling
+// Jira. Payloads larger than maxRequestSize are rejected with 413, like webhook requests.
+func RenderHandlerFunc(config *config.Config, tmpl *template.Template, opts notify.Options, maxRequestSize int64, logger log.Logger) func(http.ResponseWriter, *http.Request) {
+	return func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != "POST" {
+			w.WriteHeader(http.StatusBadRequest)
+			_, _ = w.Write([]byte("only POST allowed"))
+			return
+		}
+
+		body, err := io.ReadAll(http.MaxBytesReader(w, r.Body, maxRequestSize))
+		if err != nil {
+			status := http.StatusBadRequest
+			var maxErr *http.MaxBytesError
+			if errors.As(err, &maxErr) {
+				status = http.StatusRequestEntityTooLarge
+			}
+			http.Error(w, err.Error(), status)
+			return
+		}
+		data, err := alertmanager.Decode(body, "")
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+		if name := r.URL.Query().Get("receiver"); name != "" {
+			data.Receiver = name
+		}
+		conf := config.ReceiverByName(data.Receiver)
+		if conf == nil {
+			http.Error(w, "receiver missing: "+data.Receiver, http.StatusNotFound)
+			return
+		}
+
+		at, err := parseAt(r)
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+		receiver := notify.NewReceiver(logger, conf, tmpl, nil)
+		if !at.IsZero() {
+			receiver.At(at)
+		}
+		res, err := receiver.Render(data, opts)
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusUnprocessableEntity)
+			return
+		}
+		writeJSON(w, http.StatusOK, res)
+	}
+}
+
+// IssuesHandlerFunc is the HTTP handler for `/api/v1/issues`. Given the `receiver` query parameter and either
+// `label=name=value` parameters or a `hash` of the group labels, it looks up the Jira issue notifications for the
+// alert group would update. Otherwise it lists the recently managed issues, optionally of the given receiver only.
+func IssuesHandlerFunc(config *config.Config, tmpl *template.Template, opts notify.Options, issues *notify.IssueLog, logger log.Logger) func(http.ResponseWriter, *http.Request) {
+	return func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != "GET" {
+			w.WriteHeader(http.StatusBadRequest)
+			_, _ = w.Write([]byte("only GET allowed"))
+			return
+		}
+
+		query := r.URL.Query()
+		name, hash := query.Get("receiver"), query.Get("hash")
+		if len(query["label"]) == 0 && hash == "" {
+			writeJSON(w, http.StatusOK, struct {
+				Issues []notify.ManagedIssue `json:"issues"`
+			}{Issues: issues.List(name)})
+			return
+		}
+
+		groupLabels, err := parseLabels(query["label"])
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+		if name == "" {
+			http.Error(w, "receiver required", http.StatusBadRequest)
+			return
+		}
+		conf := config.ReceiverByName(name)
+		if conf == nil {
+			http.Error(w, "receiver missing: "+name, http.StatusNotFound)
+			return
+		}
+		client, err := clientset.New(conf)
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusInternalServerError)
+			return
+		}
+
+		res, retry, err := notify.NewReceiver(logger, conf, tmpl, client).Lookup(r.Context(), groupLabels, hash, opts)
+		if err != nil {
+			status := http.StatusUnprocessableEntity
+			if retry {
+				status = http.StatusServiceUnavailable
+			}
+			http.Error(w, err.Error(), status)
+			return
+		}
+		writeJSON(w, http.StatusOK, res)
+	}
+}
+
+// IssueMappingHandlerFunc is the HTTP handler for `/api/v1/issues/mapping`. It exposes the recently managed issues
+// with the group labels of their alert groups in the Prometheus text format, to be scraped for joining alerts with
+// their issues.
+func IssueMappingHandlerFunc(issues *notify.IssueLog) func(http.ResponseWriter, *http.Request) {
+	return func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != "GET" {
+			w.WriteHeader(http.StatusBadRequest)
+			_, _ = w.Write([]byte("only GET allowed"))
+			return
+		}
+
+		var buf bytes.Buffer
+		if err := issues.WriteMapping(&buf); err != nil {
+			http.Error(w, err.Error(), http.StatusInternalServerError)
+			return
+		}
+		w.Header().Set("Content-Type", string(expfmt.FmtText))
+		_, _ = buf.WriteTo(w)
+	}
+}
+
+// configChecker validates the configuration against Jira for `/-/check-config`, caching the outcome so that
+// frequent or unauthenticated requests don't load Jira. It is kept across reloads, but the outcome only applies to
+// the configuration and templates it was checked with: as templates may change without the configuration, every
+// reload, loading both again, invalidates it.
+type configChecker struct {
+	cacheFor time.Duration
+	logger   log.Logger
+
+	mtx       sync.Mutex
+	config    *config.Config
+	tmpl      *template.Template
+	checkedAt time.Time
+	results   []*notify.ValidationResult
+	ok        bool
+}
+
+// check returns the cached outcome for config and tmpl if it didn't expire, validating all receivers of config
+// otherwise. Concurrent calls wait for the same check.
+func (c *configChecker) check(config *config.Config, tmpl *template.Template) ([]*notify.ValidationResult, bool) {
+	c.mtx.Lock()
+	defer c.mtx.Unlock()
+	if c.results != nil && c.config == config && c.tmpl == tmpl && time.Since(c.checkedAt) < c.cacheFor {
+		return c.results, c.ok
+	}
+
+	// Checks are not tied to the request, whose outcome is cached for other requests.
+	c.results, c.ok = validateReceivers(context.Background(), config, tmpl, true, *validateFields, c.logger)
+	c.config, c.tmpl, c.checkedAt = config, tmpl, time.Now()
+	return c.results, c.ok
+}
+
+// CheckConfigHandlerFunc is the HTTP handler for `/-/check-config`. It validates the configuration of all receivers
+// against Jira, and their fields with --validate-fields, responding with 422 if any problem is found.
+func CheckConfigHandlerFunc(checker *configChecker, config *config.Config, tmpl *template.Template) func(http.ResponseWriter, *http.Request) {
+	return func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != "GET" {
+			w.WriteHeader(http.StatusBadRequest)
+			_, _ = w.Write([]byte("only GET allowed"))
+			return
+		}
+
+		results, ok := checker.check(config, tmpl)
+		status := http.StatusOK
+		if !ok {
+			status = http.StatusUnprocessableEntity
+		}
+		writeJSON(w, status, results)
+	}
+}
+
+// validateReceivers validates the configuration of all receivers against Jira, and their fields if fields is true,
+// returning false if any problem is found.
+func validateReceivers(ctx context.Context, config *config.Config, tmpl *template.Template, settings, fields bool, logger log.Logger) ([]*notify.ValidationResult, bool) {
+	ok := true
+	results := make([]*notify.ValidationResult, 0, len(config.Receivers))
+	for _, conf := range config.Receivers {
+		res := &notify.ValidationResult{Receiver: conf.Name, Problems: []string{}}
+		client, err := clientset.New(conf)
+		if err != nil {
+			res.Error = err.Error()
+		} else {
+			r := notify.NewReceiver(logger, conf, tmpl, client)
+			if settings {
+				res = r.Validate(ctx)
+			}
+			if fields && res.Error == "" {
+				f := r.ValidateFields(ctx)
+				// Both report missing projects and issue types.
+				seen := make(map[string]bool, len(res.Problems))
+				for _, p := range res.Problems {
+					seen[p] = true
+				}
+				for _, p := range f.Problems {
+					if !seen[p] {
+						res.Problems = append(res.Problems, p)
+					}
+				}
+				res.Error, res.Schema = f.Error, f.Schema
+			}
+		}
+		ok = ok && res.OK()
+		results = append(results, res)
+	}
+	return results, ok
+}
+
+// DebugJiraHandlerFunc is the HTTP handler for `/debug/jira`, listing the recent failed Jira requests by receiver,
+// most recent first, with their request and response bodies. The receiver query parameter selects one receiver.
+func DebugJiraHandlerFunc(failures *clientset.FailureLog) func(http.ResponseWriter, *http.Request) {
+	return func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != "GET" {
+			w.WriteHeader(http.StatusBadRequest)
+			_, _ = w.Write([]byte("only GET allowed"))
+			return
+		}
+		writeJSON(w, http.StatusOK, struct {
+			Receivers map[string][]clientset.Failure `json:"receivers"`
+		}{Receivers: failures.List(r.URL.Query().Get("receiver"))})
+	}
+}
+
+func writeJSON(w http.ResponseWriter, status int, v interface{}) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	_ = json.NewEncoder(w).Encode(v)
+}
@@ -0,0 +1,145 @@
+// Copyright 2017 The Prometheus Authors
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+package main
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/go-kit/log"
+	"github.com/prometheus-community/jiralert/pkg/config"
+	"github.com/prometheus-community/jiralert/pkg/notify"
+	"github.com/prometheus-community/jiralert/pkg/template"
+	"github.com/stretchr/testify/require"
+)
+
+const testConfig = `
+defaults:
+  api_url: https://jiralert.atlassian.net
+  user: jiralert
+  password: JIRAlert
+  issue_type: Task
+  summary: summary
+  reopen_state: "To Do"
+  reopen_duration: 0h
+receivers:
+  - name: 'jira-sre'
+    project: SRE
+    presets:
+      - matchers: ['severity="critical"']
+        issue_type: Bug
+    schedules:
+      - name: nights
+        windows: [{times: [{start_time: "20:00", end_time: "24:00"}]}]
+        project: NOC
+template: jiralert.tmpl
+`
+
+// newTestConfigAlertHandler returns a webhook handler of the given configuration.
+func newTestConfigAlertHandler(t *testing.T, conf string) *alertHandler {
+	t.Helper()
+	cfg, err := config.Load(conf)
+	require.NoError(t, err)
+	h := newTestAlertHandler()
+	h.config = cfg
+	h.tmpl = template.SimpleTemplate()
+	h.opts = notify.Options{HashJiraLabel: true, MaxDescriptionLength: 32768}
+	return h
+}
+
+func TestReceiverAPIHandler(t *testing.T) {
+	tenant := newTestConfigAlertHandler(t, testConfig)
+	tenant.tenant = "team-a"
+	handler := ReceiverAPIHandlerFunc(newTestConfigAlertHandler(t, testConfig), map[string]*alertHandler{"team-a": tenant})
+
+	for _, tc := range []struct {
+		name      string
+		method    string
+		target    string
+		status    int
+		receiver  string
+		project   string
+		issueType string
+		schedule  string
+	}{
+		{name: "receiver", target: "/api/v1/receivers/jira-sre/effective-config?at=2023-03-06T12:00:00Z", status: http.StatusOK, receiver: "jira-sre", project: "SRE", issueType: "Task"},
+		{name: "preset", target: "/api/v1/receivers/jira-sre/effective-config?at=2023-03-06T12:00:00Z&label=severity=critical", status: http.StatusOK, receiver: "jira-sre", project: "SRE", issueType: "Bug"},
+		{name: "schedule", target: "/api/v1/receivers/jira-sre/effective-config?at=2023-03-06T22:00:00Z", status: http.StatusOK, receiver: "jira-sre", project: "NOC", issueType: "Task", schedule: "nights"},
+		{name: "tenant receiver", target: "/api/v1/receivers/team-a/jira-sre/effective-config?at=2023-03-06T12:00:00Z", status: http.StatusOK, receiver: "team-a/jira-sre", project: "SRE", issueType: "Task"},
+		{name: "unknown receiver", target: "/api/v1/receivers/jira-other/effective-config", status: http.StatusNotFound},
+		{name: "unknown tenant", target: "/api/v1/receivers/team-b/jira-sre/effective-config", status: http.StatusNotFound},
+		{name: "unknown endpoint", target: "/api/v1/receivers/jira-sre", status: http.StatusNotFound},
+		{name: "bad label", target: "/api/v1/receivers/jira-sre/effective-config?label=severity", status: http.StatusBadRequest},
+		{name: "bad time", target: "/api/v1/receivers/jira-sre/effective-config?at=tonight", status: http.StatusBadRequest},
+		{name: "post", method: http.MethodPost, target: "/api/v1/receivers/jira-sre/effective-config", status: http.StatusBadRequest},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			method := tc.method
+			if method == "" {
+				method = http.MethodGet
+			}
+			rec := httptest.NewRecorder()
+			handler(rec, httptest.NewRequest(method, tc.target, nil))
+			require.Equal(t, tc.status, rec.Code, rec.Body.String())
+			if tc.status != http.StatusOK {
+				return
+			}
+			var s notify.EffectiveSettings
+			require.NoError(t, json.Unmarshal(rec.Body.Bytes(), &s))
+			require.Equal(t, tc.receiver, s.Receiver)
+			require.Equal(t, tc.project, s.Project)
+			require.Equal(t, tc.issueType, s.IssueType)
+			require.Equal(t, tc.schedule, s.Schedule)
+			require.Equal(t, 32768, s.MaxDescriptionLength)
+		})
+	}
+}
+
+func TestConfigCheckerCache(t *testing.T) {
+	var requests int32
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		atomic.AddInt32(&requests, 1)
+		w.WriteHeader(http.StatusNotFound)
+	}))
+	defer srv.Close()
+
+	cfg, err := config.Load(testJiraConfig(srv.URL))
+	require.NoError(t, err)
+	tmpl := template.SimpleTemplate()
+	c := &configChecker{cacheFor: time.Minute, logger: log.NewNopLogger()}
+
+	checked := func() bool {
+		before := atomic.LoadInt32(&requests)
+		results, _ := c.check(cfg, tmpl)
+		require.NotEmpty(t, results)
+		return atomic.LoadInt32(&requests) != before
+	}
+	require.True(t, checked())
+	require.False(t, checked(), "outcome should be cached")
+
+	// A reload loads the templates again, even if the configuration is unchanged.
+	tmpl = template.SimpleTemplate()
+	require.True(t, checked(), "reloaded templates should invalidate the outcome")
+	require.False(t, checked(), "outcome should be cached")
+
+	cfg, err = config.Load(testJiraConfig(srv.URL))
+	require.NoError(t, err)
+	require.True(t, checked(), "reloaded configuration should invalidate the outcome")
+
+	c.cacheFor = 0
+	require.True(t, checked(), "expired outcome should not be reused")
+}
@@ -0,0 +1,100 @@
+// Copyright 2017 The Prometheus Authors
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package main
+
+import (
+	"sync"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+// inFlightTracker tracks the notifications being handled. Webhook requests are handled synchronously, so during Jira
+// outages notifications pile up here, waiting on Jira, rate limits or the lock of their alert group, each holding its
+// request and payload in memory.
+type inFlightTracker struct {
+	mtx sync.Mutex
+	// limit is the maximum number of notifications in flight, or 0 for no limit.
+	limit   int
+	next    uint64
+	started map[uint64]time.Time
+}
+
+func newInFlightTracker(limit int) *inFlightTracker {
+	return &inFlightTracker{limit: limit, started: map[uint64]time.Time{}}
+}
+
+// start records a notification started at now, returning the function recording that it finished, or false if the
+// limit of notifications in flight is reached.
+func (t *inFlightTracker) start(now time.Time) (func(), bool) {
+	t.mtx.Lock()
+	defer t.mtx.Unlock()
+	if t.limit > 0 && len(t.started) >= t.limit {
+		return nil, false
+	}
+	id := t.next
+	t.next++
+	t.started[id] = now
+	return func() {
+		t.mtx.Lock()
+		defer t.mtx.Unlock()
+		delete(t.started, id)
+	}, true
+}
+
+// depth returns the number of notifications in flight and how long ago the oldest of them started.
+func (t *inFlightTracker) depth(now time.Time) (int, time.Duration) {
+	t.mtx.Lock()
+	defer t.mtx.Unlock()
+	var age time.Duration
+	for _, started := range t.started {
+		if d := now.Sub(started); d > age {
+			age = d
+		}
+	}
+	return len(t.started), age
+}
+
+var (
+	inFlightDesc = prometheus.NewDesc(
+		"jiralert_notifications_in_flight",
+		"Notifications being handled.",
+		nil, nil,
+	)
+	inFlightAgeDesc = prometheus.NewDesc(
+		"jiralert_notifications_in_flight_oldest_age_seconds",
+		"How long ago the oldest notification being handled started, or 0 if none.",
+		nil, nil,
+	)
+	inFlightLimitDesc = prometheus.NewDesc(
+		"jiralert_notifications_in_flight_limit",
+		"Maximum number of notifications handled at once before rejecting webhook requests, or 0 if unlimited.",
+		nil, nil,
+	)
+)
+
+// Describe implements prometheus.Collector.
+func (t *inFlightTracker) Describe(ch chan<- *prometheus.Desc) {
+	ch <- inFlightDesc
+	ch <- inFlightAgeDesc
+	ch <- inFlightLimitDesc
+}
+
+// Collect implements prometheus.Collector.
+func (t *inFlightTracker) Collect(ch chan<- prometheus.Metric) {
+	n, age := t.depth(time.Now())
+	ch <- prometheus.MustNewConstMetric(inFlightDesc, prometheus.GaugeValue, float64(n))
+	ch <- prometheus.MustNewConstMetric(inFlightAgeDesc, prometheus.GaugeValue, age.Seconds())
+	ch <- prometheus.MustNewConstMetric(inFlightLimitDesc, prometheus.GaugeValue, float64(t.limit))
+}
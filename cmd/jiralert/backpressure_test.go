@@ -0,0 +1,85 @@
+// Copyright 2017 The Prometheus Authors
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+package main
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestInFlightTracker(t *testing.T) {
+	tr := newInFlightTracker(2)
+	now := time.Now()
+	done1, ok := tr.start(now.Add(-time.Minute))
+	require.True(t, ok)
+	_, ok = tr.start(now)
+	require.True(t, ok)
+	_, ok = tr.start(now)
+	require.False(t, ok)
+
+	n, age := tr.depth(now)
+	require.Equal(t, 2, n)
+	require.Equal(t, time.Minute, age)
+
+	done1()
+	n, age = tr.depth(now)
+	require.Equal(t, 1, n)
+	require.Equal(t, time.Duration(0), age)
+	_, ok = tr.start(now)
+	require.True(t, ok)
+}
+
+func TestBackpressure(t *testing.T) {
+	jira := newFakeJira(t)
+	defer jira.Close()
+	h := newTestConfigAlertHandler(t, testJiraConfig(jira.URL))
+	h.inFlight = newInFlightTracker(1)
+	done, ok := h.inFlight.start(time.Now())
+	require.True(t, ok)
+
+	for _, tc := range []struct {
+		retryAfter time.Duration
+		expected   string
+	}{
+		{retryAfter: 30 * time.Second, expected: "30"},
+		{retryAfter: 1500 * time.Millisecond, expected: "2"},
+		// Sub-second durations do not make clients retry right away.
+		{retryAfter: 500 * time.Millisecond, expected: "1"},
+		{retryAfter: 0, expected: "1"},
+	} {
+		h.retryAfter = tc.retryAfter
+		rec := httptest.NewRecorder()
+		h.HandlerFunc("")(rec, httptest.NewRequest(http.MethodPost, "/alert", strings.NewReader(testPayload)))
+		require.Equal(t, http.StatusServiceUnavailable, rec.Code, rec.Body.String())
+		require.Equal(t, tc.expected, rec.Header().Get("Retry-After"), tc.retryAfter.String())
+		require.Equal(t, "too many notifications in flight (1), try again later", errorResponse(t, rec).Message)
+	}
+
+	// Dry runs are not limited.
+	rec := httptest.NewRecorder()
+	h.HandlerFunc("")(rec, httptest.NewRequest(http.MethodPost, "/alert?dry_run=true", strings.NewReader(testPayload)))
+	require.Equal(t, http.StatusOK, rec.Code, rec.Body.String())
+
+	done()
+	rec = httptest.NewRecorder()
+	h.HandlerFunc("")(rec, httptest.NewRequest(http.MethodPost, "/alert", strings.NewReader(testPayload)))
+	require.Equal(t, http.StatusOK, rec.Code, rec.Body.String())
+	require.Empty(t, rec.Header().Get("Retry-After"))
+	n, _ := h.inFlight.depth(time.Now())
+	require.Equal(t, 0, n)
+}
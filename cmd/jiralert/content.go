@@ -14,11 +14,17 @@
 package main
 
 import (
+	"encoding/json"
 	"fmt"
 	"html/template"
 	"net/http"
+	"strings"
 
 	"github.com/prometheus-community/jiralert/pkg/config"
+	"github.com/prometheus-community/jiralert/pkg/notify"
+	"github.com/prometheus/client_golang/prometheus"
+	dto "github.com/prometheus/client_model/go"
+	yaml "gopkg.in/yaml.v3"
 )
 
 const (
@@ -41,11 +47,16 @@ const (
           h1, h2 { font-weight: 500; }
           a { color: #337ab7; }
           a:hover, a:focus { color: #23527c; }
+          table { border-collapse: collapse; }
+          th, td { padding: 5px 10px; border-bottom: 1px solid #ddd; text-align: left; vertical-align: top; }
+          .failed { color: #a94442; }
         </style>
       </head>
       <body>
         <div class="navbar">
           <div class="navbar-header"><a href="/">JIRAlert</a></div>
+          <div><a href="/status">Status</a></div>
+          <div><a href="/receivers">Receivers</a></div>
           <div><a href="/config">Configuration</a></div>
           <div><a href="/metrics">Metrics</a></div>
           <div><a href="/debug/pprof">Profiling</a></div>
@@ -67,6 +78,66 @@ const (
       <pre>{{ .Config }}</pre>
     {{- end }}
 
+    {{ define "content.status" -}}
+      <h2>Recent notifications{{ if .Receiver }} of {{ .Receiver }}{{ end }}</h2>
+      {{- if .Notifications }}
+      <table>
+        <tr><th>Time</th><th>Receiver</th><th>Group key</th><th>Status</th><th>Alerts</th><th>Action</th><th>Issue</th><th>Error</th></tr>
+        {{- range .Notifications }}
+        <tr>
+          <td>{{ .Time.Format "2006-01-02 15:04:05 MST" }}</td>
+          <td>{{ .Receiver }}</td>
+          <td>{{ .GroupKey }}</td>
+          <td>{{ .Status }}</td>
+          <td>{{ .Alerts }}</td>
+          <td{{ if .Error }} class="failed"{{ end }}>{{ .Action }}</td>
+          <td>{{ if .IssueKey }}<a href="{{ .IssueURL }}">{{ .IssueKey }}</a>{{ end }}</td>
+          <td class="failed">{{ .Error }}</td>
+        </tr>
+        {{- end }}
+      </table>
+      {{- else }}
+      <p>No notifications were processed yet.</p>
+      {{- end }}
+    {{- end }}
+
+    {{ define "content.receivers" -}}
+      <h2>Receivers</h2>
+      <table>
+        <tr><th>Name</th><th>Jira</th><th>Project</th><th>Issue type</th></tr>
+        {{- range .Receivers }}
+        <tr>
+          <td><a href="/receivers/{{ .Name }}">{{ .Name }}</a></td>
+          <td>{{ .APIURL }}</td>
+          <td>{{ .Project }}</td>
+          <td>{{ .IssueType }}</td>
+        </tr>
+        {{- end }}
+      </table>
+    {{- end }}
+
+    {{ define "content.receiver" -}}
+      <h2>Receiver {{ .Receiver }}</h2>
+      <p>
+        <a href="/status?receiver={{ .Receiver }}">Recent notifications</a> |
+        <a href="/api/v1/issues?receiver={{ .Receiver }}">Recent issues</a> |
+        <a href="/api/v1/receivers/{{ .Receiver }}/effective-config">Effective settings (JSON)</a>
+      </p>
+      <h2>Effective settings</h2>
+      <p>Command line flags, configuration defaults and receiver overrides merged.</p>
+      <pre>{{ .Settings }}</pre>
+      <h2>Configuration</h2>
+      <p>The receiver configuration with defaults applied. Secrets are redacted.</p>
+      <pre>{{ .Config }}</pre>
+      <h2>Metrics</h2>
+      {{- if .Metrics }}
+      <pre>{{ range .Metrics }}{{ . }}
+{{ end }}</pre>
+      {{- else }}
+      <p>No metrics were recorded for this receiver yet.</p>
+      {{- end }}
+    {{- end }}
+
     {{ define "content.error" -}}
       <h2>Error</h2>
       <pre>{{ .Err }}</pre>
@@ -77,17 +148,33 @@ const (
 type tdata struct {
 	DocsURL string
 
-	// `/config` only
+	// `/config` and `/receivers/{name}` only
 	Config string
 
+	// `/status` and `/receivers/{name}` only
+	Receiver string
+
+	// `/status` only
+	Notifications []notify.Notification
+
+	// `/receivers` only
+	Receivers []*config.ReceiverConfig
+
+	// `/receivers/{name}` only
+	Settings string
+	Metrics  []string
+
 	// `/error` only
 	Err error
 }
 
 var (
-	allTemplates   = template.Must(template.New("").Parse(templates))
-	homeTemplate   = pageTemplate("home")
-	configTemplate = pageTemplate("config")
+	allTemplates      = template.Must(template.New("").Parse(templates))
+	homeTemplate      = pageTemplate("home")
+	configTemplate    = pageTemplate("config")
+	statusTemplate    = pageTemplate("status")
+	receiversTemplate = pageTemplate("receivers")
+	receiverTemplate  = pageTemplate("receiver")
 	// errorTemplate  = pageTemplate("error")
 )
 
@@ -130,3 +217,129 @@ func ConfigHandlerFunc(config *config.Config) func(http.ResponseWriter, *http.Re
 		}
 	}
 }
+
+// StatusHandlerFunc is the HTTP handler for the `/status` page. It lists the most recently processed notifications
+// and their outcomes, of the receiver given by the `receiver` query parameter if set.
+func StatusHandlerFunc(notifications *notify.NotificationLog) func(http.ResponseWriter, *http.Request) {
+	return func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != "GET" {
+			w.WriteHeader(http.StatusBadRequest)
+			_, _ = w.Write([]byte("only GET allowed"))
+			return
+		}
+
+		receiver := r.URL.Query().Get("receiver")
+		list := notifications.List()
+		if receiver != "" {
+			filtered := list[:0]
+			for _, n := range list {
+				if n.Receiver == receiver {
+					filtered = append(filtered, n)
+				}
+			}
+			list = filtered
+		}
+
+		if err := statusTemplate.Execute(w, &tdata{
+			DocsURL:       docsURL,
+			Receiver:      receiver,
+			Notifications: list,
+		}); err != nil {
+			w.WriteHeader(500)
+		}
+	}
+}
+
+// ReceiversHandlerFunc is the HTTP handler for the `/receivers` page listing all receivers, and the
+// `/receivers/{name}` pages. The latter show the effective settings and configuration of the receiver, and its
+// metrics.
+func ReceiversHandlerFunc(config *config.Config, opts notify.Options) func(http.ResponseWriter, *http.Request) {
+	return func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != "GET" {
+			w.WriteHeader(http.StatusBadRequest)
+			_, _ = w.Write([]byte("only GET allowed"))
+			return
+		}
+
+		name := strings.Trim(strings.TrimPrefix(r.URL.Path, "/receivers"), "/")
+		if name == "" {
+			if err := receiversTemplate.Execute(w, &tdata{
+				DocsURL:   docsURL,
+				Receivers: config.Receivers,
+			}); err != nil {
+				w.WriteHeader(500)
+			}
+			return
+		}
+
+		conf := config.ReceiverByName(name)
+		if conf == nil {
+			http.Error(w, "receiver missing: "+name, http.StatusNotFound)
+			return
+		}
+		settings, err := json.MarshalIndent(notify.NewEffectiveSettings(conf, opts), "", "  ")
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusInternalServerError)
+			return
+		}
+		// Secrets marshal as <secret>.
+		receiverConfig, err := yaml.Marshal(conf)
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusInternalServerError)
+			return
+		}
+		metrics, err := receiverMetrics(prometheus.DefaultGatherer, name)
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusInternalServerError)
+			return
+		}
+
+		if err := receiverTemplate.Execute(w, &tdata{
+			DocsURL:  docsURL,
+			Receiver: name,
+			Settings: string(settings),
+			Config:   string(receiverConfig),
+			Metrics:  metrics,
+		}); err != nil {
+			w.WriteHeader(500)
+		}
+	}
+}
+
+// receiverMetrics returns the samples of the counters and gauges labeled with the given receiver, and the count and
+// sum of its histograms, in the text exposition format.
+func receiverMetrics(g prometheus.Gatherer, receiver string) ([]string, error) {
+	families, err := g.Gather()
+	if err != nil {
+		return nil, err
+	}
+	var samples []string
+	for _, f := range families {
+		for _, m := range f.GetMetric() {
+			var labels []string
+			matches := false
+			for _, l := range m.GetLabel() {
+				if l.GetName() == "receiver" {
+					matches = l.GetValue() == receiver
+				}
+				labels = append(labels, fmt.Sprintf("%s=%q", l.GetName(), l.GetValue()))
+			}
+			if !matches {
+				continue
+			}
+			series := "{" + strings.Join(labels, ",") + "}"
+			switch f.GetType() {
+			case dto.MetricType_COUNTER:
+				samples = append(samples, fmt.Sprintf("%s%s %g", f.GetName(), series, m.GetCounter().GetValue()))
+			case dto.MetricType_GAUGE:
+				samples = append(samples, fmt.Sprintf("%s%s %g", f.GetName(), series, m.GetGauge().GetValue()))
+			case dto.MetricType_HISTOGRAM:
+				samples = append(samples,
+					fmt.Sprintf("%s_count%s %d", f.GetName(), series, m.GetHistogram().GetSampleCount()),
+					fmt.Sprintf("%s_sum%s %g", f.GetName(), series, m.GetHistogram().GetSampleSum()),
+				)
+			}
+		}
+	}
+	return samples, nil
+}
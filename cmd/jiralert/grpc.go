@@ -0,0 +1,185 @@
+// Copyright 2017 The Prometheus Authors
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package main
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/prometheus-community/jiralert/pkg/alertmanager"
+	"github.com/prometheus-community/jiralert/pkg/alertpb"
+	"github.com/prometheus-community/jiralert/pkg/cluster"
+	"github.com/prometheus-community/jiralert/pkg/tracing"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/trace"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+)
+
+// grpcNotifier serves the gRPC API with the webhook handlers of the current configuration, so that notifications
+// received over gRPC are authenticated, sharded, handled, recorded and counted like webhook requests.
+type grpcNotifier struct {
+	alertpb.UnimplementedNotifierServer
+	s *server
+}
+
+// Notify implements alertpb.NotifierServer.
+func (g *grpcNotifier) Notify(ctx context.Context, in *alertpb.NotifyRequest) (*alertpb.NotifyResponse, error) {
+	md, _ := metadata.FromIncomingContext(ctx)
+	current := g.s.current.Load()
+	h, path := current.alerts, "/alert"
+	// Like for webhook requests, the tenant is ignored unless tenants are configured.
+	if tenant := firstValue(md, tenantHeader); tenant != "" && current.tenants != nil {
+		if h = current.tenants[tenant]; h == nil {
+			return nil, status.Errorf(codes.NotFound, "unknown tenant: %s", tenant)
+		}
+		path += "/" + tenant
+	}
+
+	id := newRequestID(firstValue(md, requestIDHeader))
+	_ = grpc.SetHeader(ctx, metadata.Pairs(requestIDHeader, id))
+	h = h.forRequest(id)
+
+	ctx, span := tracing.Tracer().Start(ctx, "/jiralert.v1.Notifier/Notify", trace.WithSpanKind(trace.SpanKindServer), trace.WithAttributes(attribute.String("jiralert.request_id", id)))
+	defer span.End()
+
+	// The webhook credentials are expected in the authorization metadata.
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, path, nil)
+	if err != nil {
+		return nil, status.Error(codes.Internal, err.Error())
+	}
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set(requestIDHeader, id)
+	for _, v := range md.Get("authorization") {
+		req.Header.Add("Authorization", v)
+	}
+
+	rec := &responseRecorder{header: http.Header{}, status: http.StatusOK}
+	if h.auth == nil && h.verifier != nil {
+		// Messages carry no signature, so signed webhooks would be open to anyone over gRPC.
+		h.reject(ctx, rec, http.StatusUnauthorized, errGRPCUnauthenticated, unknownReceiver, &alertmanager.Data{})
+		return rec.result()
+	}
+	if h.auth != nil {
+		if err := h.auth.Authenticate(req); err != nil {
+			h.reject(ctx, rec, http.StatusUnauthorized, err, unknownReceiver, &alertmanager.Data{})
+			return rec.result()
+		}
+	}
+	if in.GetData() == nil {
+		h.reject(ctx, rec, http.StatusBadRequest, errors.New("missing data"), unknownReceiver, &alertmanager.Data{})
+		return rec.result()
+	}
+	data := alertpb.ToData(in.GetData())
+
+	if h.timeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, h.timeout)
+		defer cancel()
+	}
+	if h.cluster != nil && firstValue(md, cluster.ForwardedHeader) == "" {
+		// Forwarded to the webhook endpoint of the owner, which all replicas serve.
+		if owner := h.cluster.Owner(data.Receiver, data.GroupKey); owner != h.cluster.Self() {
+			body, err := json.Marshal(data)
+			if err != nil {
+				return nil, status.Error(codes.Internal, err.Error())
+			}
+			// The owner verifies the signature of the body, which this replica re-encoded.
+			if h.verifier != nil {
+				h.verifier.Sign(req.Header, body)
+			}
+			if h.forward(ctx, rec, req, owner, body, data) {
+				return rec.result()
+			}
+		}
+	}
+	h.notify(ctx, rec, data, false, time.Time{})
+	return rec.result()
+}
+
+// errGRPCUnauthenticated rejects gRPC notifications to configurations which only authenticate webhook requests by
+// their webhook_signature.
+var errGRPCUnauthenticated = errors.New("webhook_signature does not apply to gRPC notifications, configure webhook_auth to authenticate them")
+
+// checkGRPCAuth returns an error if the configuration or one of the tenants only authenticates webhook requests by
+// their webhook_signature, which leaves gRPC notifications unauthenticated.
+func checkGRPCAuth(hs *handlers) error {
+	if hs.alerts.auth == nil && hs.alerts.verifier != nil {
+		return errGRPCUnauthenticated
+	}
+	for tenant, h := range hs.tenants {
+		if h.auth == nil && h.verifier != nil {
+			return fmt.Errorf("tenant %s: %w", tenant, errGRPCUnauthenticated)
+		}
+	}
+	return nil
+}
+
+func firstValue(md metadata.MD, key string) string {
+	if v := md.Get(key); len(v) > 0 {
+		return v[0]
+	}
+	return ""
+}
+
+// responseRecorder records the response of the webhook handler to a notification received over gRPC.
+type responseRecorder struct {
+	header http.Header
+	status int
+	body   bytes.Buffer
+}
+
+func (r *responseRecorder) Header() http.Header { return r.header }
+
+func (r *responseRecorder) Write(b []byte) (int, error) { return r.body.Write(b) }
+
+func (r *responseRecorder) WriteHeader(status int) { r.status = status }
+
+// result converts the recorded response into the result of the gRPC call.
+func (r *responseRecorder) result() (*alertpb.NotifyResponse, error) {
+	if r.status < 300 {
+		return &alertpb.NotifyResponse{}, nil
+	}
+	msg := strings.TrimSpace(r.body.String())
+	var resp struct{ Message string }
+	if err := json.Unmarshal(r.body.Bytes(), &resp); err == nil && resp.Message != "" {
+		msg = resp.Message
+	}
+	return nil, status.Error(grpcCode(r.status), msg)
+}
+
+// grpcCode returns the gRPC code of the HTTP status of a failed notification. Like Alertmanager does for 5xx webhook
+// responses, clients should retry UNAVAILABLE and INTERNAL failures.
+func grpcCode(status int) codes.Code {
+	switch status {
+	case http.StatusBadRequest, http.StatusRequestEntityTooLarge:
+		return codes.InvalidArgument
+	case http.StatusUnauthorized:
+		return codes.Unauthenticated
+	case http.StatusNotFound:
+		return codes.NotFound
+	case http.StatusServiceUnavailable, http.StatusGatewayTimeout:
+		return codes.Unavailable
+	default:
+		return codes.Internal
+	}
+}
@@ -0,0 +1,125 @@
+// Copyright 2017 The Prometheus Authors
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/prometheus-community/jiralert/pkg/alertmanager"
+	"github.com/prometheus-community/jiralert/pkg/alertpb"
+	"github.com/prometheus-community/jiralert/pkg/cluster"
+	"github.com/prometheus-community/jiralert/pkg/config"
+	"github.com/prometheus-community/jiralert/pkg/webhook"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+)
+
+// testNotifyRequest is the gRPC notification of testPayload.
+func testNotifyRequest(t *testing.T) *alertpb.NotifyRequest {
+	t.Helper()
+	var data alertmanager.Data
+	require.NoError(t, json.Unmarshal([]byte(testPayload), &data))
+	return &alertpb.NotifyRequest{Data: alertpb.FromData(&data)}
+}
+
+// newTestGRPCNotifier returns the gRPC notifier serving the given webhook handlers.
+func newTestGRPCNotifier(alerts *alertHandler, tenants map[string]*alertHandler) *grpcNotifier {
+	s := &server{}
+	s.current.Store(&handlers{alerts: alerts, tenants: tenants})
+	return &grpcNotifier{s: s}
+}
+
+func TestGRPCTenants(t *testing.T) {
+	jira := newFakeJira(t)
+	defer jira.Close()
+	tenant := newTestConfigAlertHandler(t, testJiraConfig(jira.URL))
+	tenant.tenant = "team-a"
+
+	for _, tc := range []struct {
+		name    string
+		alerts  *alertHandler
+		tenants map[string]*alertHandler
+		tenant  string
+		code    codes.Code
+		message string
+	}{
+		{name: "main configuration", alerts: newTestAlertHandler(), tenants: map[string]*alertHandler{"team-a": tenant}, code: codes.NotFound, message: "receiver missing: jira"},
+		{name: "tenant", alerts: newTestAlertHandler(), tenants: map[string]*alertHandler{"team-a": tenant}, tenant: "team-a", code: codes.OK},
+		{name: "unknown tenant", alerts: newTestAlertHandler(), tenants: map[string]*alertHandler{"team-a": tenant}, tenant: "team-b", code: codes.NotFound, message: "unknown tenant: team-b"},
+		// Like webhook requests, the tenant is ignored without tenants.
+		{name: "no tenants", alerts: newTestConfigAlertHandler(t, testJiraConfig(jira.URL)), tenant: "team-a", code: codes.OK},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			ctx := context.Background()
+			if tc.tenant != "" {
+				ctx = metadata.NewIncomingContext(ctx, metadata.Pairs(tenantHeader, tc.tenant))
+			}
+			_, err := newTestGRPCNotifier(tc.alerts, tc.tenants).Notify(ctx, testNotifyRequest(t))
+			require.Equal(t, tc.code, status.Code(err), err)
+			if tc.message != "" {
+				require.Equal(t, tc.message, status.Convert(err).Message())
+			}
+		})
+	}
+}
+
+func TestGRPCForwardSigned(t *testing.T) {
+	verifier, err := webhook.NewSignatureVerifier(&config.WebhookSignatureConfig{Secret: "s3cr3t", Header: "X-Signature", Algorithm: "sha256", TimestampHeader: "X-Signature-Timestamp"})
+	require.NoError(t, err)
+
+	// The owner authenticates the forwarded notification and verifies the signature of the body re-encoded as JSON.
+	owner := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, err := io.ReadAll(r.Body)
+		require.NoError(t, err)
+		require.Equal(t, "/alert/team-a", r.URL.Path)
+		require.Equal(t, "Bearer t0k3n", r.Header.Get("Authorization"))
+		require.NotEmpty(t, r.Header.Get(cluster.ForwardedHeader))
+		require.NoError(t, verifier.Verify(r.Header, body))
+		var data alertmanager.Data
+		require.NoError(t, json.Unmarshal(body, &data))
+		require.Equal(t, "jira", data.Receiver)
+		require.Equal(t, `{}:{alertname="Test"}`, data.GroupKey)
+		writeJSON(w, http.StatusOK, notifyResponse{Receiver: "team-a/jira", Action: "created"})
+	}))
+	defer owner.Close()
+
+	tenant := newTestAlertHandler()
+	tenant.tenant = "team-a"
+	tenant.auth = webhook.NewAuthenticator(&config.WebhookAuthConfig{BearerToken: "t0k3n"})
+	tenant.verifier = verifier
+	tenant.cluster = ownedByPeer(t, owner.URL, time.Second)
+	g := newTestGRPCNotifier(newTestAlertHandler(), map[string]*alertHandler{"team-a": tenant})
+
+	ctx := metadata.NewIncomingContext(context.Background(), metadata.Pairs(tenantHeader, "team-a", "authorization", "Bearer t0k3n"))
+	_, err = g.Notify(ctx, testNotifyRequest(t))
+	require.NoError(t, err)
+
+	ctx = metadata.NewIncomingContext(context.Background(), metadata.Pairs(tenantHeader, "team-a", "authorization", "Bearer wrong"))
+	_, err = g.Notify(ctx, testNotifyRequest(t))
+	require.Equal(t, codes.Unauthenticated, status.Code(err))
+
+	// Signatures alone do not authenticate gRPC notifications.
+	tenant.auth = nil
+	ctx = metadata.NewIncomingContext(context.Background(), metadata.Pairs(tenantHeader, "team-a"))
+	_, err = g.Notify(ctx, testNotifyRequest(t))
+	require.Equal(t, codes.Unauthenticated, status.Code(err))
+	require.Equal(t, errGRPCUnauthenticated.Error(), status.Convert(err).Message())
+}
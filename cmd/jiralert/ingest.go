@@ -0,0 +1,52 @@
+// Copyright 2017 The Prometheus Authors
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package main
+
+import (
+	"net/http"
+	"strings"
+
+	"github.com/prometheus-community/jiralert/pkg/alertmanager"
+	"github.com/prometheus-community/jiralert/pkg/config"
+)
+
+// IngestHandlerFunc returns the HTTP handler of /ingest/<name>, converting arbitrary JSON payloads into notifications
+// with the mapping template of the ingest endpoint.
+func (h *alertHandler) IngestHandlerFunc() func(http.ResponseWriter, *http.Request) {
+	return func(w http.ResponseWriter, req *http.Request) {
+		name := strings.TrimPrefix(req.URL.Path, "/ingest/")
+		ic := h.config.IngestByName(name)
+		if ic == nil {
+			http.Error(w, "unknown ingest endpoint: "+name, http.StatusNotFound)
+			return
+		}
+		h.decodingHandlerFunc(h.ingestDecoder(ic))(w, req)
+	}
+}
+
+// ingestDecoder renders the notification of the payload with the mapping template of the ingest endpoint.
+func (h *alertHandler) ingestDecoder(ic *config.IngestConfig) decodeFunc {
+	return func(_ *http.Request, body []byte) (*alertmanager.Data, error) {
+		data, err := alertmanager.DecodeMapped(body, func(payload interface{}) (string, error) {
+			return h.tmpl.Execute(ic.Template, payload)
+		})
+		if err != nil {
+			return nil, err
+		}
+		if data.Receiver == "" {
+			data.Receiver = ic.Receiver
+		}
+		return data, nil
+	}
+}
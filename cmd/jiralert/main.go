@@ -14,25 +14,38 @@
 package main
 
 import (
+	"context"
+	"crypto/tls"
 	"encoding/json"
+	"errors"
 	"flag"
 	"fmt"
+	"net"
 	"net/http"
 	"os"
+	"os/signal"
 	"runtime"
+	"sort"
 	"strconv"
+	"syscall"
+	"time"
+	// Embed the time zone database for default_timezone, as the Docker image lacks one.
+	_ "time/tzdata"
 
-	"github.com/andygrunwald/go-jira"
 	"github.com/go-kit/log"
 	"github.com/go-kit/log/level"
 	"github.com/prometheus-community/jiralert/pkg/alertmanager"
-	"github.com/prometheus-community/jiralert/pkg/config"
+	"github.com/prometheus-community/jiralert/pkg/alertpb"
+	"github.com/prometheus-community/jiralert/pkg/clientset"
 	"github.com/prometheus-community/jiralert/pkg/notify"
-	"github.com/prometheus-community/jiralert/pkg/template"
+	"github.com/prometheus-community/jiralert/pkg/store"
+	"github.com/prometheus-community/jiralert/pkg/tracing"
+	"github.com/prometheus-community/jiralert/pkg/web"
+	"github.com/prometheus/client_golang/prometheus"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/credentials"
 
 	_ "net/http/pprof"
-
-	"github.com/prometheus/client_golang/prometheus/promhttp"
 )
 
 const (
@@ -40,19 +53,48 @@ const (
 	logFormatLogfmt             = "logfmt"
 	logFormatJSON               = "json"
 	defaultMaxDescriptionLength = 32767 // https://jira.atlassian.com/browse/JRASERVER-64351
+	defaultMaxRequestSize       = 10 << 20
+	recentIssuesLimit           = 100
+	recentNotificationsLimit    = 100
 )
 
 var (
-	listenAddress = flag.String("listen-address", ":9097", "The address to listen on for HTTP requests.")
-	configFile    = flag.String("config", "config/jiralert.yml", "The JIRAlert configuration file")
-	logLevel      = flag.String("log.level", "info", "Log filtering level (debug, info, warn, error)")
-	logFormat     = flag.String("log.format", logFormatLogfmt, "Log format to use ("+logFormatLogfmt+", "+logFormatJSON+")")
-	hashJiraLabel = flag.Bool("hash-jira-label", false, "if enabled: renames ALERT{...} to JIRALERT{...}; also hashes the key-value pairs inside of JIRALERT{...} in the created jira issue labels"+
+	listenAddress      = flag.String("listen-address", ":9097", "The address to listen on for HTTP requests.")
+	configFile         = flag.String("config", "config/jiralert.yml", "The JIRAlert configuration file, or a directory of *.yml configuration files to merge.")
+	configWatch        = flag.Bool("config.watch", false, "Reload the configuration whenever the configuration, template, secret or TLS files it is loaded from change, e.g. when Kubernetes updates a mounted ConfigMap or Secret.")
+	grpcListenAddress  = flag.String("grpc-listen-address", "", "Optional address to serve the gRPC API on, handling notifications like the /alert webhook. See pkg/alertpb/alert.proto.")
+	adminListenAddress = flag.String("admin.listen-address", "", "Optional address to serve /metrics, /config, /debug/pprof and other operational endpoints on, separately from the /alert webhook.")
+	webConfigFile      = flag.String("web.config.file", "", "Path to a web configuration file enabling TLS, in the format of the Prometheus exporter-toolkit.")
+	logLevel           = flag.String("log.level", "info", "Log filtering level (debug, info, warn, error)")
+	logFormat          = flag.String("log.format", logFormatLogfmt, "Log format to use ("+logFormatLogfmt+", "+logFormatJSON+")")
+	hashJiraLabel      = flag.Bool("hash-jira-label", false, "if enabled: renames ALERT{...} to JIRALERT{...}; also hashes the key-value pairs inside of JIRALERT{...} in the created jira issue labels"+
 		"- this ensures that the label text does not overflow the allowed length in jira (255)")
 	updateSummary        = flag.Bool("update-summary", true, "When false, jiralert does not update the summary of the existing jira issue, even when changes are spotted.")
 	updateDescription    = flag.Bool("update-description", true, "When false, jiralert does not update the description of the existing jira issue, even when changes are spotted.")
 	reopenTickets        = flag.Bool("reopen-tickets", true, "When false, jiralert does not reopen tickets.")
 	maxDescriptionLength = flag.Int("max-description-length", defaultMaxDescriptionLength, "Maximum length of Descriptions. Truncate to this size avoid server errors.")
+	maxRequestSize       = flag.Int64("max-request-size", defaultMaxRequestSize, "Maximum size of webhook request bodies, in bytes. Larger requests are rejected with 413.")
+	strictDecoding       = flag.Bool("strict-decoding", false, "Reject webhook payloads with unknown fields, or lacking the version, receiver or groupLabels fields.")
+	shutdownTimeout      = flag.Duration("shutdown-timeout", 30*time.Second, "How long to wait for in-flight notifications to finish when shutting down.")
+	validate             = flag.Bool("validate", false, "Validate the configuration of all receivers against Jira at startup (projects, issue types, priorities, components and transitions), exiting if any problem is found.")
+	validateFields       = flag.Bool("validate-fields", false, "Validate the fields set by each receiver against the create metadata of its project and issue type at startup, exiting if a configured field cannot be set or a field Jira requires is not set, and printing the field schema of the issue type.")
+	readyCheckJira       = flag.Bool("ready.check-jira", false, "Make /-/ready check that every receiver can reach Jira with its credentials, failing with 503 otherwise.")
+	readyCacheDuration   = flag.Duration("ready.cache-duration", 30*time.Second, "How long the outcome of the /-/ready Jira checks is reused for, to limit the load of frequent probes on Jira.")
+	checkConfigCache     = flag.Duration("check-config.cache-duration", 5*time.Minute, "How long the outcome of the /-/check-config Jira checks is reused for until the next reload, to limit the load of frequent requests on Jira.")
+	enableLifecycle      = flag.Bool("web.enable-lifecycle", false, "Serve /-/reload, reloading the configuration on POST or PUT requests. Like in Prometheus, it is disabled by default, as it is not authenticated and is served on the webhook listener unless --admin.listen-address is set.")
+	issueMapping         = flag.Bool("web.issue-mapping", false, "Serve /api/v1/issues/mapping, exposing the recently managed issues with the group labels of their alert groups in the Prometheus text format, for recording rules joining alerts with issues.")
+	tenantsDir           = flag.String("tenants.dir", "", "Optional directory of <tenant>.yml configuration files, one per tenant. Webhooks of a tenant are sent to /alert/<tenant>, or to /alert with the "+tenantHeader+" header, and handled with its configuration and Jira clients.")
+	maxInFlight          = flag.Int("backpressure.max-in-flight", 0, "Maximum number of notifications handled at once, e.g. piling up while Jira is down or slow. Further webhook requests are rejected with 503 and a Retry-After header, so that Alertmanager retries them later instead of JIRAlert holding them in memory. 0 means no limit.")
+	retryAfter           = flag.Duration("backpressure.retry-after", 30*time.Second, "Retry-After of webhook requests rejected due to --backpressure.max-in-flight, rounded up to whole seconds (at least 1s).")
+	staleCheckInterval   = flag.Duration("stale-issues.check-interval", 10*time.Minute, "How often to close the issues of receivers with stale_issues whose alert groups were not notified for their after duration.")
+	reconcileInterval    = flag.Duration("reconcile.interval", 5*time.Minute, "How often to reconcile the issues of receivers with an alertmanager_url with the alert groups firing in Alertmanager.")
+	storeBackend         = flag.String("store.backend", "", "Optional store recording the issue of each alert group, looked up by key before searching Jira: "+store.BackendMemory+", "+store.BackendBolt+" to keep the records across restarts in --store.path, or "+store.BackendRedis+" to share them, and lock alert groups, across replicas.")
+	storePath            = flag.String("store.path", "data/jiralert.db", "Path of the BoltDB file of the "+store.BackendBolt+" store.")
+	storeRedisURL        = flag.String("store.redis-url", "redis://localhost:6379/0", "URL of the Redis server of the "+store.BackendRedis+" store, e.g. redis://:password@host:6379/0, or rediss:// for TLS.")
+	storeRedisPrefix     = flag.String("store.redis-key-prefix", "jiralert:", "Prefix of the keys of the "+store.BackendRedis+" store, e.g. to share a Redis server.")
+	storeRetention       = flag.Duration("store.retention", 30*24*time.Hour, "How long the store keeps the record of an alert group once it was notified resolved, after which notifications search Jira for its issue again. 0 keeps records forever.")
+	notifyTimeout        = flag.Duration("notify-timeout", 0, "Deadline for handling a single notification, including all Jira requests. 0 means no deadline other than Alertmanager's webhook timeout.")
+	debugJiraFailures    = flag.Int("debug.jira-failures", 10, "Number of failed Jira requests kept per receiver, with their request and response bodies, and served on /debug/jira. 0 disables it.")
 
 	// Version is the build version, set by make to latest git tag/hash via `-ldflags "-X main.Version=$(VERSION)"`.
 	Version = "<local build>"
@@ -68,6 +110,7 @@ func main() {
 
 	var logger = setupLogger(*logLevel, *logFormat)
 	level.Info(logger).Log("msg", "starting JIRAlert", "version", Version)
+	setBuildInfo(Version)
 
 	if !*hashJiraLabel {
 		level.Warn(logger).Log("msg", "Using deprecated jira label generation - "+
@@ -75,108 +118,263 @@ func main() {
 			"and try -hash-jira-label")
 	}
 
-	config, _, err := config.LoadFile(*configFile, logger)
+	config, content, tmpl, err := loadConfig(*configFile, logger)
 	if err != nil {
 		level.Error(logger).Log("msg", "error loading configuration", "path", *configFile, "err", err)
 		os.Exit(1)
 	}
 
-	tmpl, err := template.LoadTemplate(config.Template, logger)
-	if err != nil {
-		level.Error(logger).Log("msg", "error loading templates", "path", config.Template, "err", err)
-		os.Exit(1)
+	shutdownTracing := func(context.Context) error { return nil }
+	if config.Tracing != nil {
+		shutdownTracing, err = tracing.Install(config.Tracing, Version)
+		if err != nil {
+			level.Error(logger).Log("msg", "error setting up tracing", "err", err)
+			os.Exit(1)
+		}
+		level.Info(logger).Log("msg", "exporting traces", "endpoint", config.Tracing.Endpoint)
 	}
 
-	http.HandleFunc("/alert", func(w http.ResponseWriter, req *http.Request) {
-		level.Debug(logger).Log("msg", "handling /alert webhook request")
-		defer func() { _ = req.Body.Close() }()
-
-		// https://godoc.org/github.com/prometheus/alertmanager/template#Data
-		data := alertmanager.Data{}
-		if err := json.NewDecoder(req.Body).Decode(&data); err != nil {
-			errorHandler(w, http.StatusBadRequest, err, unknownReceiver, &data, logger)
-			return
-		}
+	var failures *clientset.FailureLog
+	if *debugJiraFailures > 0 {
+		failures = clientset.NewFailureLog(*debugJiraFailures)
+		clientset.RecordFailures(failures)
+	}
 
-		conf := config.ReceiverByName(data.Receiver)
-		if conf == nil {
-			errorHandler(w, http.StatusNotFound, fmt.Errorf("receiver missing: %s", data.Receiver), unknownReceiver, &data, logger)
-			return
-		}
-		level.Debug(logger).Log("msg", "  matched receiver", "receiver", conf.Name)
-
-		// TODO: Consider reusing notifiers or just jira clients to reuse connections.
-		var client *jira.Client
-		var err error
-		if conf.User != "" && conf.Password != "" {
-			tp := jira.BasicAuthTransport{
-				Username: conf.User,
-				Password: string(conf.Password),
+	if *validate || *validateFields {
+		results, ok := validateReceivers(context.Background(), config, tmpl, *validate, *validateFields, logger)
+		for _, res := range results {
+			if res.Error != "" {
+				level.Error(logger).Log("msg", "error validating receiver", "receiver", res.Receiver, "err", res.Error)
 			}
-			client, err = jira.NewClient(tp.Client(), conf.APIURL)
-		} else if conf.PersonalAccessToken != "" {
-			tp := jira.PATAuthTransport{
-				Token: string(conf.PersonalAccessToken),
+			for _, problem := range res.Problems {
+				level.Error(logger).Log("msg", "invalid receiver configuration", "receiver", res.Receiver, "problem", problem)
 			}
-			client, err = jira.NewClient(tp.Client(), conf.APIURL)
+			for _, f := range res.Schema {
+				level.Info(logger).Log("msg", "field of issue type", "receiver", res.Receiver, "key", f.Key, "name", f.Name, "type", f.Type, "required", f.Required)
+			}
+		}
+		if !ok {
+			os.Exit(1)
 		}
+		level.Info(logger).Log("msg", "receiver configuration validated against Jira")
+	}
 
-		if err != nil {
-			errorHandler(w, http.StatusInternalServerError, err, conf.Name, &data, logger)
-			return
+	notifyOptions := notify.Options{
+		HashJiraLabel:        *hashJiraLabel,
+		UpdateSummary:        *updateSummary,
+		UpdateDescription:    *updateDescription,
+		ReopenTickets:        *reopenTickets,
+		MaxDescriptionLength: *maxDescriptionLength,
+	}
+
+	s := &server{
+		logger:        logger,
+		opts:          notifyOptions,
+		separateAdmin: *adminListenAddress != "",
+		issues:        notify.NewIssueLog(recentIssuesLimit),
+		notifications: notify.NewNotificationLog(recentNotificationsLimit),
+		updates:       notify.NewUpdateTracker(),
+		locks:         notify.NewGroupLocker(),
+		searches:      notify.NewSearchCache(),
+		transitions:   notify.NewTransitionCache(),
+		users:         notify.NewUserCache(),
+		resolves:      notify.NewResolveScheduler(*notifyTimeout),
+		flaps:         notify.NewFlapTracker(),
+		bulk:          notify.NewBulkCreator(),
+		stale:         notify.NewStaleTracker(),
+		inFlight:      newInFlightTracker(*maxInFlight),
+		failures:      failures,
+		configCheck:   &configChecker{cacheFor: *checkConfigCache, logger: logger},
+	}
+	if *storeBackend != "" {
+		if s.store, err = store.Open(*storeBackend, store.Options{Path: *storePath, RedisURL: *storeRedisURL, KeyPrefix: *storeRedisPrefix, Retention: *storeRetention}); err != nil {
+			level.Error(logger).Log("msg", "error opening store", "backend", *storeBackend, "err", err)
+			os.Exit(1)
 		}
+		defer s.store.Close()
+		level.Info(logger).Log("msg", "recording the issues of alert groups in store", "backend", *storeBackend)
+	}
+	prometheus.MustRegister(s.issues, s.inFlight)
+	if err := s.apply(config, content, tmpl); err != nil {
+		level.Error(logger).Log("msg", "error loading configuration", "path", *configFile, "err", err)
+		os.Exit(1)
+	}
+	markConfigLoaded()
+	s.restoreResolves(context.Background())
+
+	if os.Getenv("PORT") != "" {
+		*listenAddress = ":" + os.Getenv("PORT")
+	}
 
-		if retry, err := notify.NewReceiver(logger, conf, tmpl, client.Issue).Notify(&data, *hashJiraLabel, *updateSummary, *updateDescription, *reopenTickets, *maxDescriptionLength); err != nil {
-			var status int
-			if retry {
-				// Instruct Alertmanager to retry.
-				status = http.StatusServiceUnavailable
-			} else {
-				// Inaccurate, just letting Alertmanager know that it should not retry.
-				status = http.StatusBadRequest
+	servers := []*http.Server{{Addr: *listenAddress, Handler: s.alertHandler()}}
+	if *adminListenAddress != "" {
+		servers = append(servers, &http.Server{Addr: *adminListenAddress, Handler: s.adminHandler()})
+	}
+	var tlsConfig *tls.Config
+	if *webConfigFile != "" {
+		webConfig, err := web.LoadConfig(*webConfigFile)
+		if err != nil {
+			level.Error(logger).Log("msg", "error loading web configuration", "path", *webConfigFile, "err", err)
+			os.Exit(1)
+		}
+		if webConfig.TLSServerConfig != nil {
+			tlsConfig, err = webConfig.TLSServerConfig.TLSConfig()
+			if err != nil {
+				level.Error(logger).Log("msg", "error configuring TLS", "path", *webConfigFile, "err", err)
+				os.Exit(1)
+			}
+			for _, srv := range servers {
+				srv.TLSConfig = tlsConfig
 			}
-			errorHandler(w, status, err, conf.Name, &data, logger)
-			return
 		}
-		requestTotal.WithLabelValues(conf.Name, "200").Inc()
+	}
 
-	})
+	watchCtx, stopWatch := context.WithCancel(context.Background())
+	defer stopWatch()
+	if *configWatch {
+		go func() {
+			if err := s.watch(watchCtx); err != nil {
+				level.Error(logger).Log("msg", "error watching configuration files, reload with SIGHUP instead", "err", err)
+			}
+		}()
+	}
 
-	http.HandleFunc("/", HomeHandlerFunc())
-	http.HandleFunc("/config", ConfigHandlerFunc(config))
-	http.HandleFunc("/healthz", func(w http.ResponseWriter, r *http.Request) { http.Error(w, "OK", http.StatusOK) })
-	http.Handle("/metrics", promhttp.Handler())
+	go s.closeStale(watchCtx, *staleCheckInterval)
+	go s.reconcile(watchCtx, *reconcileInterval)
+	go s.pruneStore(watchCtx, storePruneInterval)
 
-	if os.Getenv("PORT") != "" {
-		*listenAddress = ":" + os.Getenv("PORT")
+	srvErr := make(chan error, len(servers)+1)
+	var grpcServer *grpc.Server
+	if *grpcListenAddress != "" {
+		if err := checkGRPCAuth(s.current.Load()); err != nil {
+			level.Error(logger).Log("msg", "refusing to serve unauthenticated gRPC requests", "address", *grpcListenAddress, "err", err)
+			os.Exit(1)
+		}
+		var opts []grpc.ServerOption
+		if tlsConfig != nil {
+			opts = append(opts, grpc.Creds(credentials.NewTLS(tlsConfig)))
+		}
+		grpcServer = grpc.NewServer(opts...)
+		alertpb.RegisterNotifierServer(grpcServer, &grpcNotifier{s: s})
+		lis, err := net.Listen("tcp", *grpcListenAddress)
+		if err != nil {
+			level.Error(logger).Log("msg", "failed to listen for gRPC requests", "address", *grpcListenAddress, "err", err)
+			os.Exit(1)
+		}
+		go func() {
+			level.Info(logger).Log("msg", "listening for gRPC requests", "address", *grpcListenAddress, "tls", tlsConfig != nil)
+			srvErr <- grpcServer.Serve(lis)
+		}()
+	}
+	for _, srv := range servers {
+		go func(srv *http.Server) {
+			level.Info(logger).Log("msg", "listening", "address", srv.Addr, "tls", srv.TLSConfig != nil)
+			if srv.TLSConfig != nil {
+				// The certificate is provided by the TLS configuration.
+				srvErr <- srv.ListenAndServeTLS("", "")
+				return
+			}
+			srvErr <- srv.ListenAndServe()
+		}(srv)
 	}
 
-	level.Info(logger).Log("msg", "listening", "address", *listenAddress)
-	err = http.ListenAndServe(*listenAddress, nil)
-	if err != nil {
-		level.Error(logger).Log("msg", "failed to start HTTP server", "address", *listenAddress)
-		os.Exit(1)
+	term := make(chan os.Signal, 1)
+	signal.Notify(term, os.Interrupt, syscall.SIGTERM)
+	hup := make(chan os.Signal, 1)
+	signal.Notify(hup, syscall.SIGHUP)
+wait:
+	for {
+		select {
+		case err := <-srvErr:
+			level.Error(logger).Log("msg", "failed to start HTTP server", "err", err)
+			os.Exit(1)
+		case <-hup:
+			_ = s.reload(reloadTriggerSignal)
+		case sig := <-term:
+			level.Info(logger).Log("msg", "received signal, shutting down", "signal", sig, "timeout", *shutdownTimeout)
+			break wait
+		}
 	}
+
+	// Stop accepting webhooks and let in-flight notifications finish, so that alerts are not lost on restarts.
+	ctx, cancel := context.WithTimeout(context.Background(), *shutdownTimeout)
+	defer cancel()
+	if grpcServer != nil {
+		stopped := make(chan struct{})
+		go func() {
+			grpcServer.GracefulStop()
+			close(stopped)
+		}()
+		select {
+		case <-stopped:
+		case <-ctx.Done():
+			grpcServer.Stop()
+			level.Error(logger).Log("msg", "in-flight gRPC requests did not finish in time", "address", *grpcListenAddress)
+			os.Exit(1)
+		}
+	}
+	for _, srv := range servers {
+		if err := srv.Shutdown(ctx); err != nil {
+			level.Error(logger).Log("msg", "in-flight requests did not finish in time", "address", srv.Addr, "err", err)
+			os.Exit(1)
+		}
+	}
+	if err := s.resolves.Stop(ctx); err != nil {
+		level.Warn(logger).Log("msg", "delayed resolutions did not finish in time", "err", err)
+	}
+	if err := shutdownTracing(ctx); err != nil {
+		level.Warn(logger).Log("msg", "error flushing traces", "err", err)
+	}
+	level.Info(logger).Log("msg", "shutdown complete")
 }
 
-func errorHandler(w http.ResponseWriter, status int, err error, receiver string, data *alertmanager.Data, logger log.Logger) {
+func healthzHandler(w http.ResponseWriter, _ *http.Request) {
+	http.Error(w, "OK", http.StatusOK)
+}
+
+func errorHandler(w http.ResponseWriter, status int, err error, receiver string, data *alertmanager.Data, requestID string, logger log.Logger) {
 	w.WriteHeader(status)
 
 	response := struct {
 		Error   bool
 		Status  int
 		Message string
+		// RequestID is the ID of the request, also returned in the X-Request-ID header.
+		RequestID string `json:",omitempty"`
+		// Field is the invalid payload field, for payloads rejected by strict decoding.
+		Field string `json:",omitempty"`
+		// JiraErrors are the errors of the failed Jira request by field, e.g. the custom fields Jira rejected.
+		JiraErrors map[string]string `json:",omitempty"`
 	}{
-		true,
-		status,
-		err.Error(),
+		Error:     true,
+		Status:    status,
+		Message:   err.Error(),
+		RequestID: requestID,
+	}
+	var verr *alertmanager.ValidationError
+	if errors.As(err, &verr) {
+		response.Field = verr.Field
+	}
+	var jerr *notify.JiraError
+	if errors.As(err, &jerr) {
+		response.JiraErrors = jerr.Fields
 	}
 	// JSON response
 	bytes, _ := json.Marshal(response)
 	json := string(bytes[:])
 	fmt.Fprint(w, json)
 
-	level.Error(logger).Log("msg", "error handling request", "statusCode", status, "statusText", http.StatusText(status), "err", err, "receiver", receiver, "groupLabels", data.GroupLabels)
+	keyvals := []interface{}{"msg", "error handling request", "statusCode", status, "statusText", http.StatusText(status), "err", err, "receiver", receiver, "groupLabels", data.GroupLabels}
+	// Field errors are logged one per key, so that the rejected fields stand out of the body quoted in err.
+	fields := make([]string, 0, len(response.JiraErrors))
+	for field := range response.JiraErrors {
+		fields = append(fields, field)
+	}
+	sort.Strings(fields)
+	for _, field := range fields {
+		keyvals = append(keyvals, "jiraError."+field, response.JiraErrors[field])
+	}
+	level.Error(logger).Log(keyvals...)
 	requestTotal.WithLabelValues(receiver, strconv.FormatInt(int64(status), 10)).Inc()
 }
 
@@ -0,0 +1,121 @@
+// Copyright 2017 The Prometheus Authors
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package main
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"sync"
+	"time"
+
+	"github.com/go-kit/log"
+	"github.com/go-kit/log/level"
+	"github.com/prometheus-community/jiralert/pkg/clientset"
+	"github.com/prometheus-community/jiralert/pkg/config"
+)
+
+// receiverStatus is the outcome of checking a receiver's connectivity to Jira.
+type receiverStatus struct {
+	Receiver string `json:"receiver"`
+	APIURL   string `json:"api_url"`
+	OK       bool   `json:"ok"`
+	Error    string `json:"error,omitempty"`
+}
+
+type readiness struct {
+	Ready     bool             `json:"ready"`
+	CheckedAt *time.Time       `json:"checked_at,omitempty"`
+	Receivers []receiverStatus `json:"receivers"`
+}
+
+// readinessChecker checks that all receivers can reach Jira, caching the outcome so that frequent probes don't
+// load Jira.
+type readinessChecker struct {
+	config   *config.Config
+	cacheFor time.Duration
+	logger   log.Logger
+
+	mtx  sync.Mutex
+	last *readiness
+}
+
+// check returns the cached readiness, checking all receivers concurrently if it expired. Concurrent calls wait for
+// the same check.
+func (c *readinessChecker) check() *readiness {
+	c.mtx.Lock()
+	defer c.mtx.Unlock()
+	if c.last != nil && time.Since(*c.last.CheckedAt) < c.cacheFor {
+		return c.last
+	}
+
+	// Checks are not tied to the probe request, whose outcome is cached for other probes.
+	ctx := context.Background()
+	res := &readiness{Ready: true, Receivers: make([]receiverStatus, len(c.config.Receivers))}
+	var wg sync.WaitGroup
+	for i, conf := range c.config.Receivers {
+		wg.Add(1)
+		go func(i int, conf *config.ReceiverConfig) {
+			defer wg.Done()
+			status := receiverStatus{Receiver: conf.Name, APIURL: conf.APIURL, OK: true}
+			if err := ping(ctx, conf); err != nil {
+				level.Warn(c.logger).Log("msg", "Jira unreachable", "receiver", conf.Name, "api_url", conf.APIURL, "err", err)
+				status.OK, status.Error = false, err.Error()
+			}
+			res.Receivers[i] = status
+		}(i, conf)
+	}
+	wg.Wait()
+
+	for _, status := range res.Receivers {
+		res.Ready = res.Ready && status.OK
+	}
+	now := time.Now()
+	res.CheckedAt = &now
+	c.last = res
+	return res
+}
+
+func ping(ctx context.Context, conf *config.ReceiverConfig) error {
+	client, err := clientset.New(conf)
+	if err != nil {
+		return err
+	}
+	resp, err := client.PingWithContext(ctx)
+	if err != nil {
+		if resp != nil {
+			return fmt.Errorf("GET myself: %s", resp.Status)
+		}
+		return err
+	}
+	return nil
+}
+
+// ReadyHandlerFunc is the HTTP handler for `/-/ready`. If checker is nil, JIRAlert is ready as soon as it serves
+// requests. Otherwise it reports whether each receiver can reach Jira, responding with 503 unless all can.
+func ReadyHandlerFunc(checker *readinessChecker) func(http.ResponseWriter, *http.Request) {
+	return func(w http.ResponseWriter, r *http.Request) {
+		if checker == nil {
+			writeJSON(w, http.StatusOK, &readiness{Ready: true, Receivers: []receiverStatus{}})
+			return
+		}
+
+		res := checker.check()
+		status := http.StatusOK
+		if !res.Ready {
+			status = http.StatusServiceUnavailable
+		}
+		writeJSON(w, status, res)
+	}
+}
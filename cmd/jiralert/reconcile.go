@@ -0,0 +1,78 @@
+// Copyright 2017 The Prometheus Authors
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package main
+
+import (
+	"context"
+	"net/http"
+	"time"
+
+	"github.com/go-kit/log/level"
+	"github.com/prometheus-community/jiralert/pkg/alertmanager"
+	"github.com/prometheus-community/jiralert/pkg/clientset"
+)
+
+// reconcile reconciles the issues of the receivers of the current configuration and of the tenants with the alert
+// groups firing in Alertmanager every interval, until ctx is done. A zero interval disables reconciliation.
+func (s *server) reconcile(ctx context.Context, interval time.Duration) {
+	if interval <= 0 {
+		return
+	}
+	client := &http.Client{Timeout: interval}
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			h := s.current.Load()
+			h.alerts.reconcile(ctx, client)
+			for _, tenant := range h.tenants {
+				tenant.reconcile(ctx, client)
+			}
+		}
+	}
+}
+
+// reconcile reconciles the issues of the receivers with an alertmanager_url. With a cluster, each receiver is
+// reconciled by a single replica.
+func (h *alertHandler) reconcile(ctx context.Context, amClient *http.Client) {
+	for _, rc := range h.config.Receivers {
+		if rc.AlertmanagerURL == "" {
+			continue
+		}
+		if h.cluster != nil && h.cluster.Owner(rc.Name, "") != h.cluster.Self() {
+			continue
+		}
+		conf := h.receiverConfig(rc.Name)
+		fetched := time.Now()
+		// Alertmanager knows the receiver by its name in the configuration, without the tenant.
+		groups, err := alertmanager.FetchGroups(ctx, amClient, rc.AlertmanagerURL, rc.Name)
+		if err != nil {
+			level.Error(h.logger).Log("msg", "failed to fetch firing alert groups from Alertmanager", "receiver", conf.Name, "url", rc.AlertmanagerURL, "err", err)
+			continue
+		}
+		client, err := clientset.New(conf)
+		if err != nil {
+			level.Error(h.logger).Log("msg", "failed to create Jira client to reconcile issues", "receiver", conf.Name, "err", err)
+			continue
+		}
+		notified, err := h.newReceiver(conf, client).Reconcile(ctx, groups, fetched, h.opts)
+		if err != nil {
+			level.Error(h.logger).Log("msg", "failed to reconcile issues", "receiver", conf.Name, "err", err)
+		}
+		level.Debug(h.logger).Log("msg", "reconciled issues with Alertmanager", "receiver", conf.Name, "firing", len(groups), "notified", notified)
+	}
+}
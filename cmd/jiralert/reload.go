@@ -0,0 +1,280 @@
+// Copyright 2017 The Prometheus Authors
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package main
+
+import (
+	"fmt"
+	"net/http"
+	"reflect"
+	"strings"
+	"sync"
+	"sync/atomic"
+
+	"github.com/go-kit/log"
+	"github.com/go-kit/log/level"
+	"github.com/pkg/errors"
+	"github.com/prometheus-community/jiralert/pkg/alertmanager"
+	"github.com/prometheus-community/jiralert/pkg/clientset"
+	"github.com/prometheus-community/jiralert/pkg/cluster"
+	"github.com/prometheus-community/jiralert/pkg/config"
+	"github.com/prometheus-community/jiralert/pkg/notify"
+	"github.com/prometheus-community/jiralert/pkg/store"
+	"github.com/prometheus-community/jiralert/pkg/template"
+	"github.com/prometheus-community/jiralert/pkg/webhook"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/promhttp"
+)
+
+// server serves the webhook and operational endpoints of the current configuration. Reloading the configuration
+// replaces the handlers, while the recent issues and notifications and the other state of notifications are kept.
+type server struct {
+	logger log.Logger
+	opts   notify.Options
+	// separateAdmin serves the operational endpoints on their own listener.
+	separateAdmin bool
+
+	issues        *notify.IssueLog
+	notifications *notify.NotificationLog
+	updates       *notify.UpdateTracker
+	locks         *notify.GroupLocker
+	searches      *notify.SearchCache
+	transitions   *notify.TransitionCache
+	users         *notify.UserCache
+	resolves      *notify.ResolveScheduler
+	flaps         *notify.FlapTracker
+	bulk          *notify.BulkCreator
+	stale         *notify.StaleTracker
+	store         store.Store
+	inFlight      *inFlightTracker
+	failures      *clientset.FailureLog
+	configCheck   *configChecker
+
+	// mtx serializes reloads.
+	mtx     sync.Mutex
+	current atomic.Pointer[handlers]
+}
+
+// handlers are built from one configuration.
+type handlers struct {
+	config *config.Config
+	alert  http.Handler
+	admin  http.Handler
+	// alerts and tenants also handle notifications received over gRPC.
+	alerts  *alertHandler
+	tenants map[string]*alertHandler
+}
+
+// loadConfig loads the configuration file and the templates it references.
+func loadConfig(path string, logger log.Logger) (*config.Config, []byte, *template.Template, error) {
+	conf, content, err := config.LoadFile(path, logger)
+	if err != nil {
+		return nil, nil, nil, errors.Wrapf(err, "load configuration %s", path)
+	}
+	tmpl, err := template.LoadTemplates(conf.TemplateFiles(), conf.TemplateHTTP, conf.Maps, logger)
+	if err != nil {
+		return nil, nil, nil, errors.Wrapf(err, "load templates %s", strings.Join(conf.TemplateFiles(), ","))
+	}
+	return conf, content, tmpl, nil
+}
+
+// apply builds the handlers of the configuration and starts serving them.
+func (s *server) apply(conf *config.Config, content []byte, tmpl *template.Template) error {
+	var peers *cluster.Cluster
+	if conf.Cluster != nil {
+		peers = cluster.New(conf.Cluster)
+		level.Info(s.logger).Log("msg", "sharding alert groups across replicas", "self", conf.Cluster.Self, "peers", strings.Join(conf.Cluster.Peers, ","))
+	}
+	var checker *readinessChecker
+	if *readyCheckJira {
+		checker = &readinessChecker{config: conf, cacheFor: *readyCacheDuration, logger: s.logger}
+	}
+
+	alerts, err := s.newAlertHandler(conf, tmpl, peers, "")
+	if err != nil {
+		return err
+	}
+	var tenants map[string]*alertHandler
+	if *tenantsDir != "" {
+		if tenants, err = s.loadTenants(*tenantsDir, peers); err != nil {
+			return err
+		}
+	}
+
+	// Operational endpoints are served along with the webhook unless a separate admin listener is configured.
+	alertMux := http.NewServeMux()
+	adminMux := alertMux
+	if s.separateAdmin {
+		adminMux = http.NewServeMux()
+		alertMux.HandleFunc("/healthz", healthzHandler)
+		alertMux.HandleFunc("/-/healthy", healthzHandler)
+		alertMux.HandleFunc("/-/ready", ReadyHandlerFunc(checker))
+	}
+
+	// The unversioned endpoint detects the payload version.
+	alertMux.HandleFunc("/alert", tenantHandlerFunc(alerts, tenants))
+	for _, version := range alertmanager.WebhookVersions() {
+		alertMux.HandleFunc("/alert/v"+version, alerts.HandlerFunc(version))
+	}
+	if tenants != nil {
+		alertMux.HandleFunc("/alert/", tenantPathHandlerFunc(tenants))
+	}
+	for _, format := range alertmanager.Formats() {
+		alertMux.HandleFunc("/"+format, alerts.FormatHandlerFunc(format))
+	}
+	if len(conf.Ingest) > 0 {
+		alertMux.HandleFunc("/ingest/", alerts.IngestHandlerFunc())
+	}
+
+	adminMux.HandleFunc("/", HomeHandlerFunc())
+	adminMux.HandleFunc("/config", ConfigHandlerFunc(conf))
+	adminMux.HandleFunc("/status", StatusHandlerFunc(s.notifications))
+	adminMux.HandleFunc("/receivers", ReceiversHandlerFunc(conf, s.opts))
+	adminMux.HandleFunc("/receivers/", ReceiversHandlerFunc(conf, s.opts))
+	adminMux.HandleFunc("/api/v1/receivers/", ReceiverAPIHandlerFunc(alerts, tenants))
+	adminMux.HandleFunc("/api/v1/issues", IssuesHandlerFunc(conf, tmpl, s.opts, s.issues, s.logger))
+	if *issueMapping {
+		adminMux.HandleFunc("/api/v1/issues/mapping", IssueMappingHandlerFunc(s.issues))
+	}
+	adminMux.HandleFunc("/-/check-config", CheckConfigHandlerFunc(s.configCheck, conf, tmpl))
+	adminMux.HandleFunc("/render", RenderHandlerFunc(conf, tmpl, s.opts, *maxRequestSize, s.logger))
+	adminMux.HandleFunc("/healthz", healthzHandler)
+	adminMux.HandleFunc("/-/healthy", healthzHandler)
+	adminMux.HandleFunc("/-/ready", ReadyHandlerFunc(checker))
+	if *enableLifecycle {
+		adminMux.HandleFunc("/-/reload", s.ReloadHandlerFunc())
+	}
+	// Exemplars of jiralert_notification_duration_seconds are only exposed in the OpenMetrics format.
+	adminMux.Handle("/metrics", promhttp.InstrumentMetricHandler(prometheus.DefaultRegisterer, promhttp.HandlerFor(prometheus.DefaultGatherer, promhttp.HandlerOpts{EnableOpenMetrics: true})))
+	// Registered on the default mux by net/http/pprof.
+	adminMux.Handle("/debug/pprof/", http.DefaultServeMux)
+	if s.failures != nil {
+		adminMux.HandleFunc("/debug/jira", DebugJiraHandlerFunc(s.failures))
+	}
+
+	if prev := s.current.Load(); prev != nil && !reflect.DeepEqual(prev.config.Tracing, conf.Tracing) {
+		level.Warn(s.logger).Log("msg", "changes of the tracing configuration require a restart")
+	}
+	s.current.Store(&handlers{config: conf, alert: alertMux, admin: adminMux, alerts: alerts, tenants: tenants})
+	setConfigHash(content)
+	return nil
+}
+
+// newAlertHandler builds the webhook handler of the configuration, of the given tenant if not empty.
+func (s *server) newAlertHandler(conf *config.Config, tmpl *template.Template, peers *cluster.Cluster, tenant string) (*alertHandler, error) {
+	var verifier *webhook.SignatureVerifier
+	if conf.WebhookSignature != nil {
+		var err error
+		verifier, err = webhook.NewSignatureVerifier(conf.WebhookSignature)
+		if err != nil {
+			return nil, errors.Wrap(err, "create webhook signature verifier")
+		}
+	}
+	var auth *webhook.Authenticator
+	if conf.WebhookAuth != nil {
+		auth = webhook.NewAuthenticator(conf.WebhookAuth)
+	}
+	return &alertHandler{
+		logger:   s.logger,
+		config:   conf,
+		tmpl:     tmpl,
+		auth:     auth,
+		verifier: verifier,
+		opts:     s.opts,
+		issues:   s.issues,
+		tenant:   tenant,
+		timeout:  *notifyTimeout,
+
+		notifications: s.notifications,
+		updates:       s.updates,
+		locks:         s.locks,
+		searches:      s.searches,
+		transitions:   s.transitions,
+		users:         s.users,
+		resolves:      s.resolves,
+		flaps:         s.flaps,
+		bulk:          s.bulk,
+		stale:         s.stale,
+		store:         s.store,
+		inFlight:      s.inFlight,
+		retryAfter:    *retryAfter,
+		cluster:       peers,
+
+		maxRequestSize: *maxRequestSize,
+		decodeOpts:     alertmanager.DecodeOptions{Strict: *strictDecoding},
+	}, nil
+}
+
+// What triggered a reload, for the jiralert_config_reloads_total and jiralert_config_reload_failures_total metrics.
+const (
+	reloadTriggerSignal = "signal"
+	reloadTriggerAPI    = "api"
+	reloadTriggerWatch  = "watch"
+)
+
+// reload loads the configuration file again, and serves it if valid. The current configuration is kept otherwise.
+func (s *server) reload(trigger string) error {
+	s.mtx.Lock()
+	defer s.mtx.Unlock()
+
+	configReloads.WithLabelValues(trigger).Inc()
+	conf, content, tmpl, err := loadConfig(*configFile, s.logger)
+	if err == nil {
+		err = s.apply(conf, content, tmpl)
+	}
+	if err != nil {
+		level.Error(s.logger).Log("msg", "error reloading configuration", "path", *configFile, "trigger", trigger, "err", err)
+		configReloadFailures.WithLabelValues(trigger).Inc()
+		configReloadSuccessful.Set(0)
+		return err
+	}
+	level.Info(s.logger).Log("msg", "configuration reloaded", "path", *configFile, "trigger", trigger)
+	configReloadSuccessful.Set(1)
+	configReloadSuccessTime.SetToCurrentTime()
+	return nil
+}
+
+// alertHandler returns the handler of the webhook listener.
+func (s *server) alertHandler() http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		s.current.Load().alert.ServeHTTP(w, req)
+	})
+}
+
+// adminHandler returns the handler of the admin listener, if separate.
+func (s *server) adminHandler() http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		s.current.Load().admin.ServeHTTP(w, req)
+	})
+}
+
+// ReloadHandlerFunc reloads the configuration on POST or PUT requests, like Prometheus' and Alertmanager's
+// /-/reload, failing with 500 if the configuration is invalid.
+func (s *server) ReloadHandlerFunc() func(http.ResponseWriter, *http.Request) {
+	return func(w http.ResponseWriter, req *http.Request) {
+		if req.Method != http.MethodPost && req.Method != http.MethodPut {
+			w.Header().Set("Allow", "POST, PUT")
+			http.Error(w, "Only POST or PUT requests allowed", http.StatusMethodNotAllowed)
+			return
+		}
+		if err := s.reload(reloadTriggerAPI); err != nil {
+			http.Error(w, fmt.Sprintf("failed to reload config: %s", err), http.StatusInternalServerError)
+		}
+	}
+}
+
+// markConfigLoaded sets the reload metrics for the configuration loaded at startup.
+func markConfigLoaded() {
+	configReloadSuccessful.Set(1)
+	configReloadSuccessTime.SetToCurrentTime()
+}
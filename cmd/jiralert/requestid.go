@@ -0,0 +1,66 @@
+// Copyright 2017 The Prometheus Authors
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package main
+
+import (
+	"crypto/rand"
+	"encoding/hex"
+	"strconv"
+	"time"
+
+	"github.com/go-kit/log"
+)
+
+// requestIDHeader carries the ID of webhook requests, which is logged with every line about the request and returned
+// in the response, so that the notifications Alertmanager retries can be found in the logs. The ID given by the
+// caller, e.g. a proxy or the replica forwarding the request, is kept.
+const requestIDHeader = "X-Request-ID"
+
+// maxRequestIDLength bounds the length of the request IDs given by callers, as they end up in every log line.
+const maxRequestIDLength = 128
+
+// newRequestID returns the given request ID if valid, or a random one otherwise.
+func newRequestID(given string) string {
+	if validRequestID(given) {
+		return given
+	}
+	b := make([]byte, 16)
+	if _, err := rand.Read(b); err != nil {
+		// Unique enough to find the request in the logs.
+		return strconv.FormatInt(time.Now().UnixNano(), 36)
+	}
+	return hex.EncodeToString(b)
+}
+
+func validRequestID(id string) bool {
+	if id == "" || len(id) > maxRequestIDLength {
+		return false
+	}
+	for _, c := range id {
+		if c < '!' || c > '~' {
+			return false
+		}
+	}
+	return true
+}
+
+// forRequest returns a copy of the handler for the request with the given ID, received now, logging the ID with
+// every line.
+func (h *alertHandler) forRequest(id string) *alertHandler {
+	hc := *h
+	hc.requestID = id
+	hc.start = time.Now()
+	hc.logger = log.With(h.logger, "request_id", id)
+	return &hc
+}
@@ -0,0 +1,78 @@
+// Copyright 2017 The Prometheus Authors
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package main
+
+import (
+	"context"
+	"strings"
+
+	"github.com/go-kit/log/level"
+	"github.com/prometheus-community/jiralert/pkg/clientset"
+	"github.com/prometheus-community/jiralert/pkg/config"
+)
+
+// restoreResolves schedules again the resolutions pending in the store, e.g. when JIRAlert last stopped. Those of
+// receivers that no longer exist are dropped.
+func (s *server) restoreResolves(ctx context.Context) {
+	if s.store == nil {
+		return
+	}
+	pending, err := s.store.Resolves(ctx)
+	if err != nil {
+		level.Error(s.logger).Log("msg", "failed to read pending resolutions from store", "err", err)
+		return
+	}
+
+	h := s.current.Load()
+	restored := 0
+	for group, res := range pending {
+		handler, conf := h.receiverHandler(res.Receiver)
+		if conf == nil {
+			level.Warn(s.logger).Log("msg", "dropping pending resolution of missing receiver", "receiver", res.Receiver, "group", group)
+			if err := s.store.DeleteResolve(ctx, group); err != nil {
+				level.Warn(s.logger).Log("msg", "failed to delete pending resolution from store", "group", group, "err", err)
+			}
+			continue
+		}
+		client, err := clientset.New(conf)
+		if err != nil {
+			level.Error(s.logger).Log("msg", "failed to create Jira client to restore pending resolution", "receiver", conf.Name, "err", err)
+			continue
+		}
+		if err := handler.newReceiver(conf, client).RestoreResolve(group, res, s.opts); err != nil {
+			level.Error(s.logger).Log("msg", "failed to restore pending resolution", "receiver", conf.Name, "group", group, "err", err)
+			continue
+		}
+		restored++
+	}
+	if restored > 0 {
+		level.Info(s.logger).Log("msg", "scheduled pending resolutions from store", "count", restored)
+	}
+}
+
+// receiverHandler returns the handler and configuration of the named receiver, prefixed with "<tenant>/" for the
+// receivers of tenants, or a nil configuration if it is missing.
+func (h *handlers) receiverHandler(name string) (*alertHandler, *config.ReceiverConfig) {
+	if conf := h.alerts.receiverConfig(name); conf != nil {
+		return h.alerts, conf
+	}
+	if tenant, receiver, ok := strings.Cut(name, "/"); ok {
+		if th, ok := h.tenants[tenant]; ok {
+			if conf := th.receiverConfig(receiver); conf != nil {
+				return th, conf
+			}
+		}
+	}
+	return nil, nil
+}
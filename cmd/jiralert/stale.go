@@ -0,0 +1,66 @@
+// Copyright 2017 The Prometheus Authors
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package main
+
+import (
+	"context"
+	"time"
+
+	"github.com/go-kit/log/level"
+	"github.com/prometheus-community/jiralert/pkg/clientset"
+)
+
+// closeStale closes the stale issues of the receivers of the current configuration and of the tenants every
+// interval, until ctx is done. A zero interval disables closing stale issues.
+func (s *server) closeStale(ctx context.Context, interval time.Duration) {
+	if interval <= 0 {
+		return
+	}
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			h := s.current.Load()
+			h.alerts.closeStale(ctx)
+			for _, tenant := range h.tenants {
+				tenant.closeStale(ctx)
+			}
+		}
+	}
+}
+
+// closeStale closes the stale issues of the receivers with stale_issues.
+func (h *alertHandler) closeStale(ctx context.Context) {
+	for _, rc := range h.config.Receivers {
+		if rc.StaleIssues == nil {
+			continue
+		}
+		conf := h.receiverConfig(rc.Name)
+		client, err := clientset.New(conf)
+		if err != nil {
+			level.Error(h.logger).Log("msg", "failed to create Jira client to close stale issues", "receiver", conf.Name, "err", err)
+			continue
+		}
+		closed, err := h.newReceiver(conf, client).CloseStale(ctx)
+		if err != nil {
+			level.Error(h.logger).Log("msg", "failed to close stale issues", "receiver", conf.Name, "err", err)
+		}
+		if closed > 0 {
+			level.Info(h.logger).Log("msg", "closed stale issues", "receiver", conf.Name, "count", closed)
+		}
+	}
+}
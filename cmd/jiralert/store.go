@@ -0,0 +1,50 @@
+// Copyright 2017 The Prometheus Authors
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package main
+
+import (
+	"context"
+	"time"
+
+	"github.com/go-kit/log/level"
+	"github.com/prometheus-community/jiralert/pkg/store"
+)
+
+// storePruneInterval is how often the expired records are deleted from stores which don't expire them by themselves.
+const storePruneInterval = time.Hour
+
+// pruneStore deletes the expired records from the store every interval, until ctx is done. Stores expiring records by
+// themselves are left alone.
+func (s *server) pruneStore(ctx context.Context, interval time.Duration) {
+	pruner, ok := s.store.(store.Pruner)
+	if !ok || *storeRetention <= 0 {
+		return
+	}
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			pruned, err := pruner.Prune(ctx)
+			if err != nil {
+				level.Warn(s.logger).Log("msg", "failed to prune expired records from store", "err", err)
+			}
+			if pruned > 0 {
+				level.Debug(s.logger).Log("msg", "pruned expired records from store", "count", pruned)
+			}
+		}
+	}
+}
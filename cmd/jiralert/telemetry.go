@@ -13,7 +13,16 @@
 
 package main
 
-import "github.com/prometheus/client_golang/prometheus"
+import (
+	"context"
+	"crypto/md5"
+	"encoding/binary"
+	"runtime"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"go.opentelemetry.io/otel/trace"
+)
 
 var (
 	requestTotal = prometheus.NewCounterVec(
@@ -23,8 +32,88 @@ var (
 		},
 		[]string{"receiver", "code"},
 	)
+	lastNotifySuccess = prometheus.NewGaugeVec(
+		prometheus.GaugeOpts{
+			Name: "jiralert_last_notify_success_timestamp_seconds",
+			Help: "Timestamp of the last notification successfully handled, by receiver.",
+		},
+		[]string{"receiver"},
+	)
+	configHash = prometheus.NewGauge(
+		prometheus.GaugeOpts{
+			Name: "jiralert_config_hash",
+			Help: "Hash of the loaded configuration file.",
+		},
+	)
+	configReloadSuccessful = prometheus.NewGauge(
+		prometheus.GaugeOpts{
+			Name: "jiralert_config_last_reload_successful",
+			Help: "Whether the last configuration reload attempt was successful.",
+		},
+	)
+	configReloadSuccessTime = prometheus.NewGauge(
+		prometheus.GaugeOpts{
+			Name: "jiralert_config_last_reload_success_timestamp_seconds",
+			Help: "Timestamp of the last successful configuration reload.",
+		},
+	)
+	configReloads = prometheus.NewCounterVec(
+		prometheus.CounterOpts{
+			Name: "jiralert_config_reloads_total",
+			Help: "Configuration reload attempts, by trigger (signal, api or watch).",
+		},
+		[]string{"trigger"},
+	)
+	configReloadFailures = prometheus.NewCounterVec(
+		prometheus.CounterOpts{
+			Name: "jiralert_config_reload_failures_total",
+			Help: "Failed configuration reload attempts, by trigger (signal, api or watch).",
+		},
+		[]string{"trigger"},
+	)
+	notificationDuration = prometheus.NewHistogramVec(
+		prometheus.HistogramOpts{
+			Name:    "jiralert_notification_duration_seconds",
+			Help:    "Duration of handling notifications, from receiving them to the last Jira request, by receiver and action taken on the issue (created, matched, reopened, resolved, none, suppressed or failed).",
+			Buckets: []float64{.1, .25, .5, 1, 2.5, 5, 10, 30, 60, 120},
+		},
+		[]string{"receiver", "action"},
+	)
+	buildInfo = prometheus.NewGaugeVec(
+		prometheus.GaugeOpts{
+			Name: "jiralert_build_info",
+			Help: "A metric with a constant '1' value labeled by the version and Go version JIRAlert was built with.",
+		},
+		[]string{"version", "goversion"},
+	)
 )
 
 func init() {
-	prometheus.MustRegister(requestTotal)
+	prometheus.MustRegister(requestTotal, lastNotifySuccess, configHash, configReloadSuccessful, configReloadSuccessTime, configReloads, configReloadFailures, notificationDuration, buildInfo)
+}
+
+// observeNotification records the duration of the notification handled by the receiver since start, with the ID of
+// its trace as exemplar if sampled.
+func observeNotification(ctx context.Context, receiver, action string, start time.Time) {
+	obs := notificationDuration.WithLabelValues(receiver, action)
+	d := time.Since(start).Seconds()
+	if sc := trace.SpanContextFromContext(ctx); sc.IsSampled() {
+		obs.(prometheus.ExemplarObserver).ObserveWithExemplar(d, prometheus.Labels{"trace_id": sc.TraceID().String()})
+		return
+	}
+	obs.Observe(d)
+}
+
+// setBuildInfo sets jiralert_build_info for the given version.
+func setBuildInfo(version string) {
+	buildInfo.WithLabelValues(version, runtime.Version()).Set(1)
+}
+
+// setConfigHash sets jiralert_config_hash to the first 48 bits of the MD5 hash of the configuration, which a float64
+// represents exactly, as Alertmanager does for alertmanager_config_hash.
+func setConfigHash(content []byte) {
+	sum := md5.Sum(content)
+	b := make([]byte, 8)
+	copy(b[2:], sum[:6])
+	configHash.Set(float64(binary.BigEndian.Uint64(b)))
 }
@@ -0,0 +1,107 @@
+// Copyright 2017 The Prometheus Authors
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package main
+
+import (
+	"fmt"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/go-kit/log/level"
+	"github.com/pkg/errors"
+	"github.com/prometheus-community/jiralert/pkg/alertmanager"
+	"github.com/prometheus-community/jiralert/pkg/cluster"
+)
+
+// tenantHeader selects the tenant of webhook requests to /alert, like in Cortex and Mimir.
+const tenantHeader = "X-Scope-OrgID"
+
+// loadTenants loads the configuration of each tenant from the <tenant>.yml files of dir, building their webhook
+// handlers.
+func (s *server) loadTenants(dir string, peers *cluster.Cluster) (map[string]*alertHandler, error) {
+	paths, err := filepath.Glob(filepath.Join(dir, "*.yml"))
+	if err != nil {
+		return nil, errors.Wrapf(err, "list tenant configurations in %s", dir)
+	}
+	if len(paths) == 0 {
+		if _, err := os.Stat(dir); err != nil {
+			return nil, errors.Wrap(err, "list tenant configurations")
+		}
+	}
+
+	tenants := make(map[string]*alertHandler, len(paths))
+	for _, path := range paths {
+		tenant := strings.TrimSuffix(filepath.Base(path), ".yml")
+		if err := checkTenant(tenant); err != nil {
+			return nil, errors.Wrapf(err, "bad tenant configuration %s", path)
+		}
+		conf, _, tmpl, err := loadConfig(path, s.logger)
+		if err != nil {
+			return nil, errors.Wrapf(err, "tenant %s", tenant)
+		}
+		if tenants[tenant], err = s.newAlertHandler(conf, tmpl, peers, tenant); err != nil {
+			return nil, errors.Wrapf(err, "tenant %s", tenant)
+		}
+	}
+	level.Info(s.logger).Log("msg", "loaded tenant configurations", "dir", dir, "tenants", len(tenants))
+	return tenants, nil
+}
+
+// checkTenant returns an error if the tenant name cannot be told apart from the other webhook endpoints.
+func checkTenant(tenant string) error {
+	if tenant == "" {
+		return errors.New("tenant name cannot be empty")
+	}
+	for _, version := range alertmanager.WebhookVersions() {
+		if tenant == "v"+version {
+			return fmt.Errorf("tenant name %q is reserved for the webhook endpoint of version %s", tenant, version)
+		}
+	}
+	return nil
+}
+
+// tenantHandlerFunc returns the handler of /alert, passing requests with the tenant header to the handler of the
+// tenant, and the others to the handler of the main configuration.
+func tenantHandlerFunc(alerts *alertHandler, tenants map[string]*alertHandler) func(http.ResponseWriter, *http.Request) {
+	handler := alerts.HandlerFunc("")
+	if tenants == nil {
+		return handler
+	}
+	return func(w http.ResponseWriter, req *http.Request) {
+		tenant := req.Header.Get(tenantHeader)
+		if tenant == "" {
+			handler(w, req)
+			return
+		}
+		serveTenant(w, req, tenants, tenant)
+	}
+}
+
+// tenantPathHandlerFunc returns the handler of /alert/<tenant>.
+func tenantPathHandlerFunc(tenants map[string]*alertHandler) func(http.ResponseWriter, *http.Request) {
+	return func(w http.ResponseWriter, req *http.Request) {
+		serveTenant(w, req, tenants, strings.TrimPrefix(req.URL.Path, "/alert/"))
+	}
+}
+
+func serveTenant(w http.ResponseWriter, req *http.Request, tenants map[string]*alertHandler, tenant string) {
+	h, ok := tenants[tenant]
+	if !ok {
+		http.Error(w, "unknown tenant: "+tenant, http.StatusNotFound)
+		return
+	}
+	h.HandlerFunc("")(w, req)
+}
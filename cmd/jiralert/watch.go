@@ -0,0 +1,144 @@
+// Copyright 2017 The Prometheus Authors
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package main
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/fsnotify/fsnotify"
+	"github.com/go-kit/log/level"
+	"github.com/pkg/errors"
+	"github.com/prometheus-community/jiralert/pkg/config"
+)
+
+// watchDebounce batches the events of one change, e.g. an editor writing a file in several steps, into one reload.
+const watchDebounce = time.Second
+
+// watchedPatterns returns the glob patterns of the files the configuration is loaded from: the configuration file,
+// or the *.yml files of the configuration directory, the template files and the secret and TLS files of receivers.
+func watchedPatterns(path string, conf *config.Config) []string {
+	patterns := []string{path}
+	if info, err := os.Stat(path); err == nil && info.IsDir() {
+		patterns = []string{filepath.Join(path, "*.yml")}
+	}
+	patterns = append(patterns, conf.TemplateFiles()...)
+	if *tenantsDir != "" {
+		patterns = append(patterns, filepath.Join(*tenantsDir, "*.yml"))
+	}
+	for _, rc := range append([]*config.ReceiverConfig{conf.Defaults}, conf.Receivers...) {
+		if rc == nil {
+			continue
+		}
+		patterns = append(patterns, rc.PasswordFile, rc.PersonalAccessTokenFile, rc.APITokenFile)
+		if rc.TLSConfig != nil {
+			patterns = append(patterns, rc.TLSConfig.CAFile, rc.TLSConfig.CertFile, rc.TLSConfig.KeyFile)
+		}
+	}
+
+	res := patterns[:0]
+	for _, p := range patterns {
+		if p != "" {
+			res = append(res, filepath.Clean(p))
+		}
+	}
+	return res
+}
+
+// fileWatcher watches the directories of the configuration files rather than the files themselves, as files replaced
+// by editors, or by Kubernetes updating a mounted ConfigMap or Secret, are new files the old watches don't cover.
+type fileWatcher struct {
+	watcher  *fsnotify.Watcher
+	dirs     map[string]struct{}
+	patterns []string
+}
+
+// update watches the directories of the given patterns, and stops watching the others.
+func (fw *fileWatcher) update(patterns []string) {
+	dirs := map[string]struct{}{}
+	for _, p := range patterns {
+		dirs[filepath.Dir(p)] = struct{}{}
+	}
+	for dir := range fw.dirs {
+		if _, ok := dirs[dir]; !ok {
+			_ = fw.watcher.Remove(dir)
+			delete(fw.dirs, dir)
+		}
+	}
+	for dir := range dirs {
+		if _, ok := fw.dirs[dir]; ok {
+			continue
+		}
+		// Directories which fail to be watched, e.g. as they don't exist yet, are retried on the next reload.
+		if err := fw.watcher.Add(dir); err == nil {
+			fw.dirs[dir] = struct{}{}
+		}
+	}
+	fw.patterns = patterns
+}
+
+// matches reports whether the event affects the configuration.
+func (fw *fileWatcher) matches(event fsnotify.Event) bool {
+	if event.Op == fsnotify.Chmod {
+		return false
+	}
+	name := filepath.Clean(event.Name)
+	// Kubernetes updates volumes by swapping the ..data symlink the files link to.
+	if strings.HasPrefix(filepath.Base(name), "..") {
+		return true
+	}
+	for _, p := range fw.patterns {
+		if ok, _ := filepath.Match(p, name); ok {
+			return true
+		}
+	}
+	return false
+}
+
+// watch reloads the configuration whenever the files it is loaded from change, until ctx is done. Invalid changes
+// are reported like failed reloads, and the current configuration is kept.
+func (s *server) watch(ctx context.Context) error {
+	watcher, err := fsnotify.NewWatcher()
+	if err != nil {
+		return errors.Wrap(err, "create file watcher")
+	}
+	defer watcher.Close()
+
+	fw := &fileWatcher{watcher: watcher, dirs: map[string]struct{}{}}
+	fw.update(watchedPatterns(*configFile, s.current.Load().config))
+	level.Info(s.logger).Log("msg", "watching configuration files for changes", "patterns", strings.Join(fw.patterns, ","))
+
+	var debounce <-chan time.Time
+	for {
+		select {
+		case <-ctx.Done():
+			return nil
+		case event := <-watcher.Events:
+			if fw.matches(event) {
+				level.Debug(s.logger).Log("msg", "configuration file changed", "path", event.Name, "op", event.Op)
+				debounce = time.After(watchDebounce)
+			}
+		case err := <-watcher.Errors:
+			level.Warn(s.logger).Log("msg", "error watching configuration files", "err", err)
+		case <-debounce:
+			debounce = nil
+			if s.reload(reloadTriggerWatch) == nil {
+				fw.update(watchedPatterns(*configFile, s.current.Load().config))
+			}
+		}
+	}
+}
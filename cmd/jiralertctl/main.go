@@ -0,0 +1,291 @@
+// Copyright 2017 The Prometheus Authors
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Command jiralertctl checks JIRAlert configurations, renders templates and sends test alerts, e.g. to gate
+// configuration changes in CI pipelines.
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"flag"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"strings"
+	"time"
+	// Embed the time zone database for default_timezone, as the Docker image lacks one.
+	_ "time/tzdata"
+
+	"github.com/go-kit/log"
+	"github.com/go-kit/log/level"
+	"github.com/pkg/errors"
+	"github.com/prometheus-community/jiralert/pkg/alertmanager"
+	"github.com/prometheus-community/jiralert/pkg/config"
+	"github.com/prometheus-community/jiralert/pkg/notify"
+	"github.com/prometheus-community/jiralert/pkg/template"
+)
+
+const (
+	defaultMaxDescriptionLength = 32767 // https://jira.atlassian.com/browse/JRASERVER-64351
+	checkAlertName              = "JiralertCheckConfig"
+)
+
+const usage = `Usage: jiralertctl <command> [flags]
+
+Commands:
+  check-config  Validate the configuration file and render each receiver's templates against a synthetic alert.
+  render        Render the issue a receiver would create for a webhook payload read from a file.
+  send          Send a synthetic alert to a running JIRAlert.
+
+Run jiralertctl <command> -help for the flags of a command.
+`
+
+func main() {
+	if len(os.Args) < 2 {
+		fmt.Fprint(os.Stderr, usage)
+		os.Exit(2)
+	}
+
+	var err error
+	switch cmd, args := os.Args[1], os.Args[2:]; cmd {
+	case "check-config":
+		err = checkConfig(args)
+	case "render":
+		err = render(args)
+	case "send":
+		err = send(args)
+	case "help", "-h", "-help", "--help":
+		fmt.Fprint(os.Stdout, usage)
+		return
+	default:
+		fmt.Fprintf(os.Stderr, "unknown command %q\n\n%s", cmd, usage)
+		os.Exit(2)
+	}
+	if err != nil {
+		fmt.Fprintln(os.Stderr, "error:", err)
+		os.Exit(1)
+	}
+}
+
+// templateFlags are the flags of commands rendering templates, matching the jiralert flags of the same name.
+type templateFlags struct {
+	configFile           *string
+	hashJiraLabel        *bool
+	maxDescriptionLength *int
+}
+
+func addTemplateFlags(fs *flag.FlagSet) templateFlags {
+	return templateFlags{
+		configFile:           fs.String("config", "config/jiralert.yml", "The JIRAlert configuration file, or a directory of *.yml configuration files to merge."),
+		hashJiraLabel:        fs.Bool("hash-jira-label", false, "Render JIRALERT{...} hash labels, as jiralert does with -hash-jira-label."),
+		maxDescriptionLength: fs.Int("max-description-length", defaultMaxDescriptionLength, "Maximum length of descriptions."),
+	}
+}
+
+// load loads the configuration and templates.
+func (f templateFlags) load(logger log.Logger) (*config.Config, *template.Template, notify.Options, error) {
+	conf, _, err := config.LoadFile(*f.configFile, logger)
+	if err != nil {
+		return nil, nil, notify.Options{}, errors.Wrapf(err, "load configuration %s", *f.configFile)
+	}
+	tmpl, err := template.LoadTemplates(conf.TemplateFiles(), conf.TemplateHTTP, conf.Maps, logger)
+	if err != nil {
+		return nil, nil, notify.Options{}, errors.Wrapf(err, "load templates %s", strings.Join(conf.TemplateFiles(), ", "))
+	}
+	return conf, tmpl, notify.Options{HashJiraLabel: *f.hashJiraLabel, MaxDescriptionLength: *f.maxDescriptionLength}, nil
+}
+
+func checkConfig(args []string) error {
+	fs := flag.NewFlagSet("check-config", flag.ExitOnError)
+	tf := addTemplateFlags(fs)
+	_ = fs.Parse(args)
+
+	logger := newLogger()
+	conf, tmpl, opts, err := tf.load(logger)
+	if err != nil {
+		return err
+	}
+
+	failed := 0
+	for _, rc := range conf.Receivers {
+		data := syntheticData(rc.Name, alertmanager.AlertFiring, alertmanager.KV{"alertname": checkAlertName}, nil)
+		if _, err := notify.NewReceiver(logger, rc, tmpl, nil).Render(data, opts); err != nil {
+			fmt.Fprintf(os.Stderr, "receiver %q: %v\n", rc.Name, err)
+			failed++
+		}
+	}
+	if failed > 0 {
+		return errors.Errorf("%d of %d receivers failed to render", failed, len(conf.Receivers))
+	}
+	fmt.Fprintf(os.Stdout, "%s: %d receivers OK\n", *tf.configFile, len(conf.Receivers))
+	return nil
+}
+
+func render(args []string) error {
+	fs := flag.NewFlagSet("render", flag.ExitOnError)
+	tf := addTemplateFlags(fs)
+	payloadFile := fs.String("payload", "", "File holding the webhook payload to render, - for stdin.")
+	receiver := fs.String("receiver", "", "Receiver to render the payload with, instead of the payload's receiver.")
+	_ = fs.Parse(args)
+
+	if *payloadFile == "" {
+		return errors.New("-payload is required")
+	}
+	var body []byte
+	var err error
+	if *payloadFile == "-" {
+		body, err = io.ReadAll(os.Stdin)
+	} else {
+		body, err = os.ReadFile(*payloadFile)
+	}
+	if err != nil {
+		return errors.Wrap(err, "read payload")
+	}
+	data, err := alertmanager.Decode(body, "")
+	if err != nil {
+		return err
+	}
+	if *receiver != "" {
+		data.Receiver = *receiver
+	}
+
+	logger := newLogger()
+	conf, tmpl, opts, err := tf.load(logger)
+	if err != nil {
+		return err
+	}
+	rc := conf.ReceiverByName(data.Receiver)
+	if rc == nil {
+		return errors.Errorf("receiver missing: %s", data.Receiver)
+	}
+	res, err := notify.NewReceiver(logger, rc, tmpl, nil).Render(data, opts)
+	if err != nil {
+		return err
+	}
+	enc := json.NewEncoder(os.Stdout)
+	enc.SetIndent("", "  ")
+	return enc.Encode(res)
+}
+
+// labelsFlag collects repeated name=value flags.
+type labelsFlag alertmanager.KV
+
+func (l labelsFlag) String() string {
+	return formatLabels(alertmanager.KV(l))
+}
+
+func (l labelsFlag) Set(s string) error {
+	name, value, ok := strings.Cut(s, "=")
+	if !ok || name == "" {
+		return errors.Errorf("expected name=value, got %q", s)
+	}
+	l[name] = value
+	return nil
+}
+
+func send(args []string) error {
+	fs := flag.NewFlagSet("send", flag.ExitOnError)
+	url := fs.String("url", "http://localhost:9097", "Base URL of the JIRAlert instance.")
+	receiver := fs.String("receiver", "", "Receiver to send the alert to.")
+	resolved := fs.Bool("resolved", false, "Send the alert as resolved instead of firing.")
+	dryRun := fs.Bool("dry-run", false, "Only report what JIRAlert would do to Jira.")
+	bearerToken := fs.String("bearer-token", "", "Bearer token authenticating the webhook request, if webhook_auth is configured.")
+	timeout := fs.Duration("timeout", 30*time.Second, "Timeout of the webhook request.")
+	labels := labelsFlag{"alertname": "JiralertTest"}
+	fs.Var(labels, "label", "Group label of the alert, in the form name=value. Repeatable.")
+	annotations := labelsFlag{}
+	fs.Var(annotations, "annotation", "Annotation of the alert, in the form name=value. Repeatable.")
+	_ = fs.Parse(args)
+
+	if *receiver == "" {
+		return errors.New("-receiver is required")
+	}
+	status := alertmanager.AlertFiring
+	if *resolved {
+		status = alertmanager.AlertResolved
+	}
+	body, err := json.Marshal(syntheticData(*receiver, status, alertmanager.KV(labels), alertmanager.KV(annotations)))
+	if err != nil {
+		return err
+	}
+
+	target := strings.TrimSuffix(*url, "/") + "/alert"
+	if *dryRun {
+		target += "?dry_run=true"
+	}
+	req, err := http.NewRequest(http.MethodPost, target, bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	if *bearerToken != "" {
+		req.Header.Set("Authorization", "Bearer "+*bearerToken)
+	}
+	resp, err := (&http.Client{Timeout: *timeout}).Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	respBody, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return errors.Wrap(err, "read response")
+	}
+	if len(respBody) > 0 {
+		fmt.Fprintln(os.Stdout, strings.TrimSpace(string(respBody)))
+	}
+	if resp.StatusCode/100 != 2 {
+		return errors.Errorf("JIRAlert returned status %s", resp.Status)
+	}
+	return nil
+}
+
+// syntheticData returns a notification for a single alert with the given labels, all of which are group labels.
+func syntheticData(receiver, status string, labels, annotations alertmanager.KV) *alertmanager.Data {
+	if annotations == nil {
+		annotations = alertmanager.KV{}
+	}
+	alert := alertmanager.Alert{
+		Status:      status,
+		Labels:      labels,
+		Annotations: annotations,
+		StartsAt:    time.Now().Add(-time.Minute).UTC(),
+	}
+	if status == alertmanager.AlertResolved {
+		alert.EndsAt = time.Now().UTC()
+	}
+	return &alertmanager.Data{
+		Version:           "4",
+		GroupKey:          "{}:" + formatLabels(labels),
+		Receiver:          receiver,
+		Status:            status,
+		Alerts:            alertmanager.Alerts{alert},
+		GroupLabels:       labels,
+		CommonLabels:      labels,
+		CommonAnnotations: annotations,
+	}
+}
+
+// formatLabels formats labels the way Alertmanager does in group keys, e.g. {alertname="Test"}.
+func formatLabels(kv alertmanager.KV) string {
+	pairs := make([]string, 0, len(kv))
+	for _, p := range kv.SortedPairs() {
+		pairs = append(pairs, fmt.Sprintf("%s=%q", p.Name, p.Value))
+	}
+	return "{" + strings.Join(pairs, ",") + "}"
+}
+
+func newLogger() log.Logger {
+	return level.NewFilter(log.NewLogfmtLogger(log.NewSyncWriter(os.Stderr)), level.AllowWarn())
+}
@@ -61,23 +61,21 @@ func (ps Pairs) Values() []string {
 // KV is a set of key/value string pairs.
 type KV map[string]string
 
-// SortedPairs returns a sorted list of key/value pairs.
+// SortedPairs returns a sorted list of key/value pairs, with the alert name first.
 func (kv KV) SortedPairs() Pairs {
-	var (
-		pairs     = make([]Pair, 0, len(kv))
-		keys      = make([]string, 0, len(kv))
-		sortStart = 0
-	)
+	keys := make([]string, 0, len(kv))
 	for k := range kv {
-		if k == AlertNameLabel {
-			keys = append([]string{k}, keys...)
-			sortStart = 1
-		} else {
-			keys = append(keys, k)
-		}
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	if _, ok := kv[AlertNameLabel]; ok {
+		// Move the alert name in front, rather than prepending it, which copies the keys for every alert.
+		i := sort.SearchStrings(keys, AlertNameLabel)
+		copy(keys[1:i+1], keys[:i])
+		keys[0] = AlertNameLabel
 	}
-	sort.Strings(keys[sortStart:])
 
+	pairs := make([]Pair, 0, len(keys))
 	for _, k := range keys {
 		pairs = append(pairs, Pair{k, kv[k]})
 	}
@@ -118,6 +116,9 @@ type Data struct {
 	// The protocol version.
 	Version  string `json:"version"`
 	GroupKey string `json:"groupKey"`
+	// Number of alerts left out of Alerts by the max_alerts setting of the webhook configuration, and in the data
+	// passed to templates, by the max_alerts_in_description and alert_order limits of the receiver.
+	TruncatedAlerts uint64 `json:"truncatedAlerts"`
 
 	Receiver string `json:"receiver"`
 	Status   string `json:"status"`
@@ -146,11 +147,30 @@ type Alerts []Alert
 
 // Firing returns the subset of alerts that are firing.
 func (as Alerts) Firing() []Alert {
-	var res []Alert
-	for _, a := range as {
-		if a.Status == AlertFiring {
-			res = append(res, a)
+	n := 0
+	for i := range as {
+		if as[i].Status == AlertFiring {
+			n++
+		}
+	}
+	if n == 0 {
+		return nil
+	}
+	res := make([]Alert, 0, n)
+	for i := range as {
+		if as[i].Status == AlertFiring {
+			res = append(res, as[i])
 		}
 	}
 	return res
 }
+
+// HasFiring reports whether any of the alerts is firing, without copying them like Firing.
+func (as Alerts) HasFiring() bool {
+	for i := range as {
+		if as[i].Status == AlertFiring {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,35 @@
+// Copyright 2017 The Prometheus Authors
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+package alertmanager
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestSortedPairs(t *testing.T) {
+	require.Equal(t, Pairs{{"alertname", "HighLatency"}, {"a", "1"}, {"b", "2"}, {"z", "3"}}, KV{"z": "3", "b": "2", "alertname": "HighLatency", "a": "1"}.SortedPairs())
+	require.Equal(t, Pairs{{"a", "1"}, {"b", "2"}}, KV{"b": "2", "a": "1"}.SortedPairs())
+	require.Empty(t, KV{}.SortedPairs())
+}
+
+func TestFiring(t *testing.T) {
+	alerts := Alerts{{Status: AlertResolved, Fingerprint: "1"}, {Status: AlertFiring, Fingerprint: "2"}}
+	require.True(t, alerts.HasFiring())
+	require.Equal(t, []Alert{alerts[1]}, alerts.Firing())
+
+	alerts = alerts[:1]
+	require.False(t, alerts.HasFiring())
+	require.Empty(t, alerts.Firing())
+}
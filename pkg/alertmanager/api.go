@@ -0,0 +1,195 @@
+// Copyright 2017 The Prometheus Authors
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package alertmanager
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"regexp"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/pkg/errors"
+)
+
+// apiAlertGroup is an alert group as listed by Alertmanager's /api/v2/alerts/groups.
+type apiAlertGroup struct {
+	Labels   KV `json:"labels"`
+	Receiver struct {
+		Name string `json:"name"`
+	} `json:"receiver"`
+	Alerts []struct {
+		Labels       KV        `json:"labels"`
+		Annotations  KV        `json:"annotations"`
+		StartsAt     time.Time `json:"startsAt"`
+		EndsAt       time.Time `json:"endsAt"`
+		GeneratorURL string    `json:"generatorURL"`
+		Fingerprint  string    `json:"fingerprint"`
+	} `json:"alerts"`
+}
+
+// FetchGroups returns the alert groups of the receiver firing in the Alertmanager at baseURL, in the form of their
+// notifications, through its v2 API. Silenced and inhibited alerts are left out, as they are not notified either.
+// The group keys of the notifications are not known and left empty.
+func FetchGroups(ctx context.Context, client *http.Client, baseURL, receiver string) ([]*Data, error) {
+	query := url.Values{
+		"receiver":  {"^" + regexp.QuoteMeta(receiver) + "$"},
+		"active":    {"true"},
+		"silenced":  {"false"},
+		"inhibited": {"false"},
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, strings.TrimSuffix(baseURL, "/")+"/api/v2/alerts/groups?"+query.Encode(), nil)
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("Accept", "application/json")
+	resp, err := client.Do(req)
+	if err != nil {
+		return nil, errors.Wrap(err, "list alert groups")
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		body, _ := io.ReadAll(io.LimitReader(resp.Body, 1024))
+		return nil, fmt.Errorf("list alert groups: unexpected status %s: %s", resp.Status, strings.TrimSpace(string(body)))
+	}
+	var groups []apiAlertGroup
+	if err := json.NewDecoder(resp.Body).Decode(&groups); err != nil {
+		return nil, errors.Wrap(err, "decode alert groups")
+	}
+
+	var res []*Data
+	for _, g := range groups {
+		if g.Receiver.Name != receiver || len(g.Alerts) == 0 {
+			continue
+		}
+		data := &Data{
+			Version:     WebhookVersion4,
+			Receiver:    receiver,
+			Status:      AlertFiring,
+			GroupLabels: g.Labels,
+			ExternalURL: baseURL,
+		}
+		labels := make([]KV, 0, len(g.Alerts))
+		annotations := make([]KV, 0, len(g.Alerts))
+		for _, a := range g.Alerts {
+			data.Alerts = append(data.Alerts, Alert{
+				Status:       AlertFiring,
+				Labels:       a.Labels,
+				Annotations:  a.Annotations,
+				StartsAt:     a.StartsAt,
+				EndsAt:       a.EndsAt,
+				GeneratorURL: a.GeneratorURL,
+				Fingerprint:  a.Fingerprint,
+			})
+			labels = append(labels, a.Labels)
+			annotations = append(annotations, a.Annotations)
+		}
+		data.CommonLabels, data.CommonAnnotations = common(labels), common(annotations)
+		res = append(res, data)
+	}
+	return res, nil
+}
+
+// Silence is a silence to create in Alertmanager, matching alerts with all of its labels.
+type Silence struct {
+	Labels    KV
+	StartsAt  time.Time
+	EndsAt    time.Time
+	CreatedBy string
+	Comment   string
+}
+
+// apiMatcher is a matcher of a silence in Alertmanager's v2 API.
+type apiMatcher struct {
+	Name    string `json:"name"`
+	Value   string `json:"value"`
+	IsRegex bool   `json:"isRegex"`
+	IsEqual bool   `json:"isEqual"`
+}
+
+// CreateSilence creates the silence in the Alertmanager at baseURL through its v2 API, returning its ID.
+func CreateSilence(ctx context.Context, client *http.Client, baseURL string, s Silence) (string, error) {
+	if len(s.Labels) == 0 {
+		return "", errors.New("silence without labels")
+	}
+	names := make([]string, 0, len(s.Labels))
+	for name := range s.Labels {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	matchers := make([]apiMatcher, 0, len(names))
+	for _, name := range names {
+		matchers = append(matchers, apiMatcher{Name: name, Value: s.Labels[name], IsEqual: true})
+	}
+	body, err := json.Marshal(struct {
+		Matchers  []apiMatcher `json:"matchers"`
+		StartsAt  time.Time    `json:"startsAt"`
+		EndsAt    time.Time    `json:"endsAt"`
+		CreatedBy string       `json:"createdBy"`
+		Comment   string       `json:"comment"`
+	}{matchers, s.StartsAt, s.EndsAt, s.CreatedBy, s.Comment})
+	if err != nil {
+		return "", err
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, strings.TrimSuffix(baseURL, "/")+"/api/v2/silences", bytes.NewReader(body))
+	if err != nil {
+		return "", err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("Accept", "application/json")
+	resp, err := client.Do(req)
+	if err != nil {
+		return "", errors.Wrap(err, "create silence")
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		body, _ := io.ReadAll(io.LimitReader(resp.Body, 1024))
+		return "", fmt.Errorf("create silence: unexpected status %s: %s", resp.Status, strings.TrimSpace(string(body)))
+	}
+	var created struct {
+		SilenceID string `json:"silenceID"`
+	}
+	if err := json.NewDecoder(resp.Body).Decode(&created); err != nil {
+		return "", errors.Wrap(err, "decode created silence")
+	}
+	return created.SilenceID, nil
+}
+
+// common returns the pairs all kvs have in common, like the common labels and annotations of notifications.
+func common(kvs []KV) KV {
+	res := KV{}
+	if len(kvs) == 0 {
+		return res
+	}
+	for k, v := range kvs[0] {
+		shared := true
+		for _, kv := range kvs[1:] {
+			if other, ok := kv[k]; !ok || other != v {
+				shared = false
+				break
+			}
+		}
+		if shared {
+			res[k] = v
+		}
+	}
+	return res
+}
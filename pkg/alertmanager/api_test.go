@@ -0,0 +1,103 @@
+// Copyright 2017 The Prometheus Authors
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+package alertmanager
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestFetchGroups(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		if req.URL.Path != "/api/v2/alerts/groups" {
+			http.NotFound(w, req)
+			return
+		}
+		require.Equal(t, `^jira\.ab$`, req.URL.Query().Get("receiver"))
+		require.Equal(t, "false", req.URL.Query().Get("silenced"))
+		_, _ = w.Write([]byte(`[
+  {"labels": {"alertname": "Down"}, "receiver": {"name": "jira.ab"}, "alerts": [
+    {"labels": {"alertname": "Down", "instance": "a"}, "annotations": {"summary": "down"}, "startsAt": "2023-05-01T10:00:00Z", "fingerprint": "1"},
+    {"labels": {"alertname": "Down", "instance": "b"}, "annotations": {"summary": "down"}, "startsAt": "2023-05-01T10:05:00Z", "fingerprint": "2"}
+  ]},
+  {"labels": {"alertname": "Other"}, "receiver": {"name": "jira.abc"}, "alerts": [{"labels": {"alertname": "Other"}}]},
+  {"labels": {"alertname": "Empty"}, "receiver": {"name": "jira.ab"}, "alerts": []}
+]`))
+	}))
+	defer srv.Close()
+
+	groups, err := FetchGroups(context.Background(), srv.Client(), srv.URL+"/", "jira.ab")
+	require.NoError(t, err)
+	require.Len(t, groups, 1)
+	require.Equal(t, &Data{
+		Version:  WebhookVersion4,
+		Receiver: "jira.ab",
+		Status:   AlertFiring,
+		Alerts: Alerts{
+			{Status: AlertFiring, Labels: KV{"alertname": "Down", "instance": "a"}, Annotations: KV{"summary": "down"}, StartsAt: time.Date(2023, 5, 1, 10, 0, 0, 0, time.UTC), Fingerprint: "1"},
+			{Status: AlertFiring, Labels: KV{"alertname": "Down", "instance": "b"}, Annotations: KV{"summary": "down"}, StartsAt: time.Date(2023, 5, 1, 10, 5, 0, 0, time.UTC), Fingerprint: "2"},
+		},
+		GroupLabels:       KV{"alertname": "Down"},
+		CommonLabels:      KV{"alertname": "Down"},
+		CommonAnnotations: KV{"summary": "down"},
+		ExternalURL:       srv.URL + "/",
+	}, groups[0])
+
+	_, err = FetchGroups(context.Background(), srv.Client(), srv.URL+"/missing", "jira.ab")
+	require.Error(t, err)
+}
+
+func TestCreateSilence(t *testing.T) {
+	var got map[string]interface{}
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		if req.URL.Path != "/api/v2/silences" || req.Method != http.MethodPost {
+			http.NotFound(w, req)
+			return
+		}
+		require.NoError(t, json.NewDecoder(req.Body).Decode(&got))
+		_, _ = w.Write([]byte(`{"silenceID": "abc"}`))
+	}))
+	defer srv.Close()
+
+	start := time.Date(2023, 5, 1, 10, 0, 0, 0, time.UTC)
+	id, err := CreateSilence(context.Background(), srv.Client(), srv.URL, Silence{
+		Labels:    KV{"alertname": "Down", "env": "prod"},
+		StartsAt:  start,
+		EndsAt:    start.Add(time.Hour),
+		CreatedBy: "jiralert",
+		Comment:   "won't fix",
+	})
+	require.NoError(t, err)
+	require.Equal(t, "abc", id)
+	require.Equal(t, map[string]interface{}{
+		"matchers": []interface{}{
+			map[string]interface{}{"name": "alertname", "value": "Down", "isRegex": false, "isEqual": true},
+			map[string]interface{}{"name": "env", "value": "prod", "isRegex": false, "isEqual": true},
+		},
+		"startsAt":  "2023-05-01T10:00:00Z",
+		"endsAt":    "2023-05-01T11:00:00Z",
+		"createdBy": "jiralert",
+		"comment":   "won't fix",
+	}, got)
+
+	_, err = CreateSilence(context.Background(), srv.Client(), srv.URL+"/missing", Silence{Labels: KV{"alertname": "Down"}})
+	require.Error(t, err)
+	_, err = CreateSilence(context.Background(), srv.Client(), srv.URL, Silence{})
+	require.Error(t, err)
+}
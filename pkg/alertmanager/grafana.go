@@ -0,0 +1,125 @@
+// Copyright 2017 The Prometheus Authors
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package alertmanager
+
+import (
+	"bytes"
+	"encoding/json"
+	"time"
+)
+
+// FormatGrafana is the format of the webhook payloads of Grafana Alerting contact points.
+const FormatGrafana = "grafana"
+
+// grafanaData is the webhook payload of Grafana Alerting, see
+// https://grafana.com/docs/grafana/latest/alerting/configure-notifications/manage-contact-points/integrations/webhook-notifier/.
+// It extends Alertmanager's with fields summarizing the notification, which JIRAlert doesn't use.
+type grafanaData struct {
+	Receiver          string         `json:"receiver"`
+	Status            string         `json:"status"`
+	OrgID             int64          `json:"orgId"`
+	Alerts            []grafanaAlert `json:"alerts"`
+	GroupLabels       KV             `json:"groupLabels"`
+	CommonLabels      KV             `json:"commonLabels"`
+	CommonAnnotations KV             `json:"commonAnnotations"`
+	ExternalURL       string         `json:"externalURL"`
+	Version           string         `json:"version"`
+	GroupKey          string         `json:"groupKey"`
+	TruncatedAlerts   uint64         `json:"truncatedAlerts"`
+	Title             string         `json:"title"`
+	State             string         `json:"state"`
+	Message           string         `json:"message"`
+}
+
+type grafanaAlert struct {
+	Status       string             `json:"status"`
+	Labels       KV                 `json:"labels"`
+	Annotations  KV                 `json:"annotations"`
+	StartsAt     time.Time          `json:"startsAt"`
+	EndsAt       time.Time          `json:"endsAt"`
+	GeneratorURL string             `json:"generatorURL"`
+	Fingerprint  string             `json:"fingerprint"`
+	SilenceURL   string             `json:"silenceURL"`
+	DashboardURL string             `json:"dashboardURL"`
+	PanelURL     string             `json:"panelURL"`
+	ImageURL     string             `json:"imageURL"`
+	Values       map[string]float64 `json:"values"`
+	ValueString  string             `json:"valueString"`
+}
+
+// grafanaAnnotations returns the fields of Grafana alerts passed on to templates as annotations of the same name,
+// e.g. {{ .Annotations.dashboardURL }}.
+func (a grafanaAlert) grafanaAnnotations() KV {
+	return KV{
+		"silenceURL":   a.SilenceURL,
+		"dashboardURL": a.DashboardURL,
+		"panelURL":     a.PanelURL,
+		"imageURL":     a.ImageURL,
+		"valueString":  a.ValueString,
+	}
+}
+
+func decodeGrafana(body []byte, opts DecodeOptions) (*Data, error) {
+	var payload grafanaData
+	if opts.Strict {
+		dec := json.NewDecoder(bytes.NewReader(body))
+		dec.DisallowUnknownFields()
+		if err := dec.Decode(&payload); err != nil {
+			return nil, err
+		}
+		switch {
+		case payload.Receiver == "":
+			return nil, &ValidationError{Field: "receiver", Message: "missing"}
+		case payload.GroupLabels == nil:
+			return nil, &ValidationError{Field: "groupLabels", Message: "missing"}
+		}
+	} else if err := json.Unmarshal(body, &payload); err != nil {
+		return nil, err
+	}
+
+	data := &Data{
+		Version:           WebhookVersion4,
+		GroupKey:          payload.GroupKey,
+		TruncatedAlerts:   payload.TruncatedAlerts,
+		Receiver:          payload.Receiver,
+		Status:            payload.Status,
+		Alerts:            make(Alerts, 0, len(payload.Alerts)),
+		GroupLabels:       payload.GroupLabels,
+		CommonLabels:      payload.CommonLabels,
+		CommonAnnotations: payload.CommonAnnotations,
+		ExternalURL:       payload.ExternalURL,
+	}
+	for _, a := range payload.Alerts {
+		annotations := KV{}
+		for k, v := range a.grafanaAnnotations() {
+			if v != "" {
+				annotations[k] = v
+			}
+		}
+		// Annotations of the alert rule take precedence.
+		for k, v := range a.Annotations {
+			annotations[k] = v
+		}
+		data.Alerts = append(data.Alerts, Alert{
+			Status:       a.Status,
+			Labels:       a.Labels,
+			Annotations:  annotations,
+			StartsAt:     a.StartsAt,
+			EndsAt:       a.EndsAt,
+			GeneratorURL: a.GeneratorURL,
+			Fingerprint:  a.Fingerprint,
+		})
+	}
+	return data, nil
+}
@@ -0,0 +1,94 @@
+// Copyright 2017 The Prometheus Authors
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+package alertmanager
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+const grafanaPayload = `{
+  "receiver": "jira-ab",
+  "status": "firing",
+  "orgId": 1,
+  "alerts": [
+    {
+      "status": "firing",
+      "labels": {"alertname": "HighCPU", "instance": "a"},
+      "annotations": {"summary": "CPU is high", "panelURL": "http://rule/panel"},
+      "startsAt": "2023-05-01T10:00:00Z",
+      "endsAt": "0001-01-01T00:00:00Z",
+      "generatorURL": "http://grafana/alerting/grafana/abc/view",
+      "fingerprint": "c6eadffa33fcdf37",
+      "silenceURL": "http://grafana/alerting/silence/new",
+      "dashboardURL": "http://grafana/d/xyz",
+      "panelURL": "http://grafana/d/xyz?viewPanel=1",
+      "values": {"B": 97.5},
+      "valueString": "[ var='B' labels={instance=a} value=97.5 ]"
+    }
+  ],
+  "groupLabels": {"alertname": "HighCPU"},
+  "commonLabels": {"alertname": "HighCPU", "instance": "a"},
+  "commonAnnotations": {"summary": "CPU is high"},
+  "externalURL": "http://grafana/",
+  "version": "1",
+  "groupKey": "{}:{alertname=\"HighCPU\"}",
+  "truncatedAlerts": 0,
+  "title": "[FIRING:1] HighCPU",
+  "state": "alerting",
+  "message": "**Firing**"
+}`
+
+func TestDecodeGrafana(t *testing.T) {
+	for _, strict := range []bool{false, true} {
+		data, err := DecodeFormat([]byte(grafanaPayload), FormatGrafana, "", DecodeOptions{Strict: strict})
+		require.NoError(t, err)
+		require.Equal(t, &Data{
+			Version:  WebhookVersion4,
+			GroupKey: `{}:{alertname="HighCPU"}`,
+			Receiver: "jira-ab",
+			Status:   AlertFiring,
+			Alerts: Alerts{{
+				Status: AlertFiring,
+				Labels: KV{"alertname": "HighCPU", "instance": "a"},
+				Annotations: KV{
+					"summary":      "CPU is high",
+					"silenceURL":   "http://grafana/alerting/silence/new",
+					"dashboardURL": "http://grafana/d/xyz",
+					// Annotations of the rule take precedence.
+					"panelURL":    "http://rule/panel",
+					"valueString": "[ var='B' labels={instance=a} value=97.5 ]",
+				},
+				StartsAt:     time.Date(2023, 5, 1, 10, 0, 0, 0, time.UTC),
+				EndsAt:       time.Time{},
+				GeneratorURL: "http://grafana/alerting/grafana/abc/view",
+				Fingerprint:  "c6eadffa33fcdf37",
+			}},
+			GroupLabels:       KV{"alertname": "HighCPU"},
+			CommonLabels:      KV{"alertname": "HighCPU", "instance": "a"},
+			CommonAnnotations: KV{"summary": "CPU is high"},
+			ExternalURL:       "http://grafana/",
+		}, data)
+	}
+
+	_, err := DecodeFormat([]byte(`{"receiver":"jira-ab","groupLabels":{},"foo":1}`), FormatGrafana, "", DecodeOptions{Strict: true})
+	require.EqualError(t, err, `json: unknown field "foo"`)
+
+	_, err = DecodeFormat([]byte(grafanaPayload), FormatGrafana, WebhookVersion4, DecodeOptions{})
+	require.EqualError(t, err, `webhook format "grafana" has no version "4"`)
+
+	_, err = DecodeFormat([]byte(grafanaPayload), "opsgenie", "", DecodeOptions{})
+	require.EqualError(t, err, `unsupported webhook format "opsgenie"`)
+}
@@ -0,0 +1,50 @@
+// Copyright 2017 The Prometheus Authors
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package alertmanager
+
+import (
+	"encoding/json"
+	"fmt"
+)
+
+// DecodeMapped converts an arbitrary JSON payload into Data, with render mapping the parsed payload to the JSON of
+// an Alertmanager webhook payload. Alerts without status are firing, and the status of the notification defaults to
+// firing if any alert is.
+func DecodeMapped(body []byte, render func(payload interface{}) (string, error)) (*Data, error) {
+	var payload interface{}
+	if err := json.Unmarshal(body, &payload); err != nil {
+		return nil, err
+	}
+	mapped, err := render(payload)
+	if err != nil {
+		return nil, fmt.Errorf("render mapping template: %w", err)
+	}
+	data, err := decodeV4([]byte(mapped), DecodeOptions{})
+	if err != nil {
+		return nil, fmt.Errorf("decode rendered mapping: %w", err)
+	}
+
+	for i := range data.Alerts {
+		if data.Alerts[i].Status == "" {
+			data.Alerts[i].Status = AlertFiring
+		}
+	}
+	if data.Status == "" {
+		data.Status = AlertResolved
+		if data.Alerts.HasFiring() {
+			data.Status = AlertFiring
+		}
+	}
+	return data, nil
+}
@@ -0,0 +1,48 @@
+// Copyright 2017 The Prometheus Authors
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+package alertmanager
+
+import (
+	"errors"
+	"fmt"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestDecodeMapped(t *testing.T) {
+	render := func(payload interface{}) (string, error) {
+		p := payload.(map[string]interface{})
+		return fmt.Sprintf(`{"groupLabels":{"alertname":%q},"alerts":[{"labels":{"alertname":%q}},{"status":"resolved"}]}`, p["name"], p["name"]), nil
+	}
+	data, err := DecodeMapped([]byte(`{"name":"DiskFull"}`), render)
+	require.NoError(t, err)
+	require.Equal(t, &Data{
+		Version:     WebhookVersion4,
+		Status:      AlertFiring,
+		GroupLabels: KV{"alertname": "DiskFull"},
+		Alerts: Alerts{
+			{Status: AlertFiring, Labels: KV{"alertname": "DiskFull"}},
+			{Status: AlertResolved},
+		},
+	}, data)
+
+	_, err = DecodeMapped([]byte(`{`), render)
+	require.Error(t, err)
+
+	_, err = DecodeMapped([]byte(`{}`), func(interface{}) (string, error) { return "", errors.New("missing key") })
+	require.EqualError(t, err, "render mapping template: missing key")
+
+	_, err = DecodeMapped([]byte(`{}`), func(interface{}) (string, error) { return "{", nil })
+	require.EqualError(t, err, "decode rendered mapping: unexpected end of JSON input")
+}
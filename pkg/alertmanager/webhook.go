@@ -0,0 +1,145 @@
+// Copyright 2017 The Prometheus Authors
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package alertmanager
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"sort"
+)
+
+// WebhookVersion4 is the current Alertmanager webhook payload version, the one Data represents.
+const WebhookVersion4 = "4"
+
+// converters decode a webhook payload of a given version, converting it into Data. Supporting a new webhook format
+// revision only requires registering its converter here.
+var converters = map[string]func(body []byte, opts DecodeOptions) (*Data, error){
+	WebhookVersion4: decodeV4,
+}
+
+// formats decode webhook payloads of senders other than Alertmanager, converting them into Data.
+var formats = map[string]func(body []byte, opts DecodeOptions) (*Data, error){
+	FormatGrafana: decodeGrafana,
+}
+
+// DecodeOptions control how webhook payloads are decoded.
+type DecodeOptions struct {
+	// Strict rejects payloads with fields unknown to JIRAlert, or lacking the version, receiver or groupLabels
+	// fields.
+	Strict bool
+}
+
+// ValidationError is returned for payloads rejected by strict decoding.
+type ValidationError struct {
+	Field   string
+	Message string
+}
+
+func (e *ValidationError) Error() string {
+	return fmt.Sprintf("invalid %s field: %s", e.Field, e.Message)
+}
+
+// WebhookVersions returns the supported webhook payload versions.
+func WebhookVersions() []string {
+	versions := make([]string, 0, len(converters))
+	for v := range converters {
+		versions = append(versions, v)
+	}
+	sort.Strings(versions)
+	return versions
+}
+
+// Decode parses a webhook payload of the given version into Data. If version is empty, it is detected from the
+// payload's version field, defaulting to the current version.
+func Decode(body []byte, version string) (*Data, error) {
+	return DecodeWithOptions(body, version, DecodeOptions{})
+}
+
+// DecodeWithOptions is like Decode, with the given options.
+func DecodeWithOptions(body []byte, version string, opts DecodeOptions) (*Data, error) {
+	if version == "" {
+		var v struct {
+			Version string `json:"version"`
+		}
+		if err := json.Unmarshal(body, &v); err != nil {
+			return nil, err
+		}
+		version = v.Version
+		if version == "" {
+			if opts.Strict {
+				return nil, &ValidationError{Field: "version", Message: "missing"}
+			}
+			version = WebhookVersion4
+		}
+	}
+
+	convert, ok := converters[version]
+	if !ok {
+		return nil, fmt.Errorf("unsupported webhook version %q", version)
+	}
+	return convert(body, opts)
+}
+
+// Formats returns the supported webhook payload formats other than Alertmanager's.
+func Formats() []string {
+	res := make([]string, 0, len(formats))
+	for f := range formats {
+		res = append(res, f)
+	}
+	sort.Strings(res)
+	return res
+}
+
+// DecodeFormat parses a webhook payload of the given format into Data. Payloads of Alertmanager, the empty format,
+// are decoded like DecodeWithOptions does with the given version, while other formats have no versions.
+func DecodeFormat(body []byte, format, version string, opts DecodeOptions) (*Data, error) {
+	if format == "" {
+		return DecodeWithOptions(body, version, opts)
+	}
+	decode, ok := formats[format]
+	if !ok {
+		return nil, fmt.Errorf("unsupported webhook format %q", format)
+	}
+	if version != "" {
+		return nil, fmt.Errorf("webhook format %q has no version %q", format, version)
+	}
+	return decode(body, opts)
+}
+
+func decodeV4(body []byte, opts DecodeOptions) (*Data, error) {
+	data := &Data{}
+	if opts.Strict {
+		dec := json.NewDecoder(bytes.NewReader(body))
+		dec.DisallowUnknownFields()
+		if err := dec.Decode(data); err != nil {
+			return nil, err
+		}
+		switch {
+		case data.Version == "":
+			return nil, &ValidationError{Field: "version", Message: "missing"}
+		case data.Receiver == "":
+			return nil, &ValidationError{Field: "receiver", Message: "missing"}
+		case data.GroupLabels == nil:
+			return nil, &ValidationError{Field: "groupLabels", Message: "missing"}
+		}
+	} else if err := json.Unmarshal(body, data); err != nil {
+		return nil, err
+	}
+	if data.Version != "" && data.Version != WebhookVersion4 {
+		return nil, fmt.Errorf("webhook version %q does not match expected version %q", data.Version, WebhookVersion4)
+	}
+	data.Version = WebhookVersion4
+	return data, nil
+}
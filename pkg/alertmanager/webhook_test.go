@@ -0,0 +1,63 @@
+// Copyright 2017 The Prometheus Authors
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+package alertmanager
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestDecode(t *testing.T) {
+	data, err := Decode([]byte(`{"version":"4","receiver":"jira-ab","groupLabels":{"a":"b"}}`), "")
+	require.NoError(t, err)
+	require.Equal(t, &Data{Version: WebhookVersion4, Receiver: "jira-ab", GroupLabels: KV{"a": "b"}}, data)
+
+	// Payloads without version are treated as the current version.
+	data, err = Decode([]byte(`{"receiver":"jira-ab"}`), WebhookVersion4)
+	require.NoError(t, err)
+	require.Equal(t, WebhookVersion4, data.Version)
+
+	_, err = Decode([]byte(`{"version":"5","receiver":"jira-ab"}`), "")
+	require.EqualError(t, err, `unsupported webhook version "5"`)
+
+	_, err = Decode([]byte(`{"version":"5","receiver":"jira-ab"}`), WebhookVersion4)
+	require.EqualError(t, err, `webhook version "5" does not match expected version "4"`)
+
+	_, err = Decode([]byte(`{`), "")
+	require.Error(t, err)
+}
+
+func TestDecodeStrict(t *testing.T) {
+	opts := DecodeOptions{Strict: true}
+	data, err := DecodeWithOptions([]byte(`{"version":"4","receiver":"jira-ab","groupLabels":{},"truncatedAlerts":2}`), "", opts)
+	require.NoError(t, err)
+	require.Equal(t, &Data{Version: WebhookVersion4, Receiver: "jira-ab", GroupLabels: KV{}, TruncatedAlerts: 2}, data)
+
+	_, err = DecodeWithOptions([]byte(`{"version":"4","receiver":"jira-ab","groupLabels":{},"groupKeys":"x"}`), "", opts)
+	require.EqualError(t, err, `json: unknown field "groupKeys"`)
+
+	_, err = DecodeWithOptions([]byte(`{"receiver":"jira-ab","groupLabels":{}}`), "", opts)
+	require.EqualError(t, err, "invalid version field: missing")
+
+	_, err = DecodeWithOptions([]byte(`{"receiver":"jira-ab","groupLabels":{}}`), WebhookVersion4, opts)
+	require.EqualError(t, err, "invalid version field: missing")
+
+	_, err = DecodeWithOptions([]byte(`{"version":"4","groupLabels":{}}`), "", opts)
+	require.EqualError(t, err, "invalid receiver field: missing")
+
+	_, err = DecodeWithOptions([]byte(`{"version":"4","receiver":"jira-ab"}`), "", opts)
+	var verr *ValidationError
+	require.ErrorAs(t, err, &verr)
+	require.Equal(t, "groupLabels", verr.Field)
+}
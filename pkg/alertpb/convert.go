@@ -0,0 +1,98 @@
+// Copyright 2017 The Prometheus Authors
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package alertpb defines the gRPC API of JIRAlert, handling notifications like the /alert webhook endpoint.
+package alertpb
+
+//go:generate protoc --go_out=. --go_opt=paths=source_relative --go-grpc_out=. --go-grpc_opt=paths=source_relative alert.proto
+
+import (
+	"time"
+
+	"github.com/prometheus-community/jiralert/pkg/alertmanager"
+	"google.golang.org/protobuf/types/known/timestamppb"
+)
+
+// ToData converts the notification into alertmanager.Data. Missing times are zero, like in webhook payloads of firing
+// alerts.
+func ToData(d *Data) *alertmanager.Data {
+	data := &alertmanager.Data{
+		Version:           d.GetVersion(),
+		GroupKey:          d.GetGroupKey(),
+		TruncatedAlerts:   d.GetTruncatedAlerts(),
+		Receiver:          d.GetReceiver(),
+		Status:            d.GetStatus(),
+		Alerts:            make(alertmanager.Alerts, 0, len(d.GetAlerts())),
+		GroupLabels:       alertmanager.KV(d.GetGroupLabels()),
+		CommonLabels:      alertmanager.KV(d.GetCommonLabels()),
+		CommonAnnotations: alertmanager.KV(d.GetCommonAnnotations()),
+		ExternalURL:       d.GetExternalUrl(),
+	}
+	if data.Version == "" {
+		data.Version = alertmanager.WebhookVersion4
+	}
+	for _, a := range d.GetAlerts() {
+		data.Alerts = append(data.Alerts, alertmanager.Alert{
+			Status:       a.GetStatus(),
+			Labels:       alertmanager.KV(a.GetLabels()),
+			Annotations:  alertmanager.KV(a.GetAnnotations()),
+			StartsAt:     toTime(a.GetStartsAt()),
+			EndsAt:       toTime(a.GetEndsAt()),
+			GeneratorURL: a.GetGeneratorUrl(),
+			Fingerprint:  a.GetFingerprint(),
+		})
+	}
+	return data
+}
+
+// FromData converts alertmanager.Data into a notification, e.g. to forward webhook payloads over gRPC.
+func FromData(data *alertmanager.Data) *Data {
+	d := &Data{
+		Version:           data.Version,
+		GroupKey:          data.GroupKey,
+		TruncatedAlerts:   data.TruncatedAlerts,
+		Receiver:          data.Receiver,
+		Status:            data.Status,
+		Alerts:            make([]*Alert, 0, len(data.Alerts)),
+		GroupLabels:       data.GroupLabels,
+		CommonLabels:      data.CommonLabels,
+		CommonAnnotations: data.CommonAnnotations,
+		ExternalUrl:       data.ExternalURL,
+	}
+	for _, a := range data.Alerts {
+		d.Alerts = append(d.Alerts, &Alert{
+			Status:       a.Status,
+			Labels:       a.Labels,
+			Annotations:  a.Annotations,
+			StartsAt:     fromTime(a.StartsAt),
+			EndsAt:       fromTime(a.EndsAt),
+			GeneratorUrl: a.GeneratorURL,
+			Fingerprint:  a.Fingerprint,
+		})
+	}
+	return d
+}
+
+func toTime(ts *timestamppb.Timestamp) time.Time {
+	if ts == nil {
+		return time.Time{}
+	}
+	return ts.AsTime()
+}
+
+func fromTime(t time.Time) *timestamppb.Timestamp {
+	if t.IsZero() {
+		return nil
+	}
+	return timestamppb.New(t)
+}
@@ -0,0 +1,49 @@
+// Copyright 2017 The Prometheus Authors
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+package alertpb
+
+import (
+	"testing"
+	"time"
+
+	"github.com/prometheus-community/jiralert/pkg/alertmanager"
+	"github.com/stretchr/testify/require"
+)
+
+func TestConvert(t *testing.T) {
+	data := &alertmanager.Data{
+		Version:         alertmanager.WebhookVersion4,
+		GroupKey:        `{}:{alertname="HighCPU"}`,
+		TruncatedAlerts: 1,
+		Receiver:        "jira-ab",
+		Status:          alertmanager.AlertFiring,
+		Alerts: alertmanager.Alerts{{
+			Status:       alertmanager.AlertFiring,
+			Labels:       alertmanager.KV{"alertname": "HighCPU"},
+			Annotations:  alertmanager.KV{"summary": "CPU is high"},
+			StartsAt:     time.Date(2023, 5, 1, 10, 0, 0, 0, time.UTC),
+			GeneratorURL: "http://prometheus/graph",
+			Fingerprint:  "c6eadffa33fcdf37",
+		}},
+		GroupLabels:       alertmanager.KV{"alertname": "HighCPU"},
+		CommonLabels:      alertmanager.KV{"alertname": "HighCPU"},
+		CommonAnnotations: alertmanager.KV{"summary": "CPU is high"},
+		ExternalURL:       "http://alertmanager",
+	}
+	d := FromData(data)
+	require.Nil(t, d.Alerts[0].EndsAt)
+	require.Equal(t, data, ToData(d))
+
+	// Notifications without version are of the current version.
+	require.Equal(t, alertmanager.WebhookVersion4, ToData(&Data{Receiver: "jira-ab"}).Version)
+}
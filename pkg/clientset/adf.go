@@ -0,0 +1,156 @@
+// Copyright 2017 The Prometheus Authors
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package clientset
+
+import (
+	"bytes"
+	"encoding/json"
+	"io"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"github.com/prometheus-community/jiralert/pkg/markup"
+)
+
+// adfFields are the issue fields holding ADF documents in the REST API v3.
+var adfFields = []string{"description", "environment"}
+
+// adfTransport lets the go-jira client, which speaks the REST API v2, talk to the REST API v3: request paths are
+// rewritten, and rich text fields are converted from wiki markup to ADF in requests and back in responses.
+type adfTransport struct {
+	next http.RoundTripper
+}
+
+func (t *adfTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	if !strings.Contains(req.URL.Path, "/rest/api/2/") {
+		return t.next.RoundTrip(req)
+	}
+
+	req = req.Clone(req.Context())
+	req.URL.Path = strings.Replace(req.URL.Path, "/rest/api/2/", "/rest/api/3/", 1)
+	if req.URL.RawPath != "" {
+		req.URL.RawPath = strings.Replace(req.URL.RawPath, "/rest/api/2/", "/rest/api/3/", 1)
+	}
+	if req.Body != nil {
+		body, err := rewriteJSON(req.Body, wikiToADF)
+		if err != nil {
+			return nil, err
+		}
+		req.Body = io.NopCloser(bytes.NewReader(body))
+		req.GetBody = func() (io.ReadCloser, error) { return io.NopCloser(bytes.NewReader(body)), nil }
+		req.ContentLength = int64(len(body))
+	}
+
+	resp, err := t.next.RoundTrip(req)
+	if err != nil || resp.Body == nil || !strings.HasPrefix(resp.Header.Get("Content-Type"), "application/json") {
+		return resp, err
+	}
+	body, err := rewriteJSON(resp.Body, adfToWiki)
+	if err != nil {
+		return nil, err
+	}
+	resp.Body = io.NopCloser(bytes.NewReader(body))
+	resp.ContentLength = int64(len(body))
+	resp.Header.Set("Content-Length", strconv.Itoa(len(body)))
+	return resp, nil
+}
+
+// rewriteJSON reads and closes r, applying convert to its content if it is a JSON object.
+func rewriteJSON(r io.ReadCloser, convert func(map[string]interface{})) ([]byte, error) {
+	defer r.Close()
+	b, err := io.ReadAll(r)
+	if err != nil {
+		return nil, err
+	}
+	var obj map[string]interface{}
+	dec := json.NewDecoder(bytes.NewReader(b))
+	dec.UseNumber()
+	if err := dec.Decode(&obj); err != nil {
+		return b, nil
+	}
+	convert(obj)
+	return json.Marshal(obj)
+}
+
+// wikiToADF converts the rich text fields of an issue, bulk issue or comment request to ADF.
+func wikiToADF(obj map[string]interface{}) {
+	if updates, ok := obj["issueUpdates"].([]interface{}); ok {
+		for _, update := range updates {
+			if m, ok := update.(map[string]interface{}); ok {
+				wikiToADF(m)
+			}
+		}
+	}
+	convert := func(m map[string]interface{}, key string) {
+		if s, ok := m[key].(string); ok {
+			if s == "" {
+				m[key] = nil
+				return
+			}
+			m[key] = markup.WikiToADF(s)
+		}
+	}
+	if fields, ok := obj["fields"].(map[string]interface{}); ok {
+		for _, f := range adfFields {
+			convert(fields, f)
+		}
+	}
+	convert(obj, "body")
+}
+
+// adfToWiki converts the rich text fields of issue, search and comment responses to wiki markup.
+func adfToWiki(obj map[string]interface{}) {
+	if issues, ok := obj["issues"].([]interface{}); ok {
+		for _, issue := range issues {
+			if m, ok := issue.(map[string]interface{}); ok {
+				adfToWiki(m)
+			}
+		}
+	}
+	if fields, ok := obj["fields"].(map[string]interface{}); ok {
+		for _, f := range adfFields {
+			if v, ok := fields[f]; ok {
+				fields[f] = toWiki(v)
+			}
+		}
+		if comments, ok := fields["comment"].(map[string]interface{}); ok {
+			if list, ok := comments["comments"].([]interface{}); ok {
+				for _, c := range list {
+					if m, ok := c.(map[string]interface{}); ok {
+						adfToWiki(m)
+					}
+				}
+			}
+		}
+	}
+	if _, ok := obj["body"]; ok {
+		obj["body"] = toWiki(obj["body"])
+	}
+}
+
+func toWiki(v interface{}) interface{} {
+	if _, ok := v.(map[string]interface{}); !ok {
+		return v
+	}
+	b, err := json.Marshal(v)
+	if err != nil {
+		return v
+	}
+	var doc markup.Node
+	if err := json.Unmarshal(b, &doc); err != nil {
+		return v
+	}
+	return markup.ADFToWiki(&doc)
+}
@@ -0,0 +1,59 @@
+// Copyright 2017 The Prometheus Authors
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+package clientset
+
+import (
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/andygrunwald/go-jira"
+	"github.com/stretchr/testify/require"
+)
+
+func TestADFTransport(t *testing.T) {
+	adf := `{"type":"doc","version":1,"content":[{"type":"paragraph","content":[{"type":"text","text":"down","marks":[{"type":"strong"}]}]}]}`
+
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		switch req.URL.Path {
+		case "/rest/api/3/issue/ABC-1/comment":
+			b, err := io.ReadAll(req.Body)
+			require.NoError(t, err)
+			var c map[string]json.RawMessage
+			require.NoError(t, json.Unmarshal(b, &c))
+			require.JSONEq(t, adf, string(c["body"]))
+			_, _ = w.Write([]byte(`{"id":"1","body":` + adf + `}`))
+		case "/rest/api/3/search":
+			_, _ = w.Write([]byte(`{"issues":[{"key":"ABC-1","fields":{"description":` + adf + `,"comment":{"comments":[{"body":` + adf + `}]}}}]}`))
+		default:
+			t.Errorf("unexpected request to %s", req.URL.Path)
+		}
+	}))
+	defer srv.Close()
+
+	client, err := jira.NewClient(&http.Client{Transport: &adfTransport{next: http.DefaultTransport}}, srv.URL)
+	require.NoError(t, err)
+
+	comment, _, err := client.Issue.AddComment("ABC-1", &jira.Comment{Body: "*down*"})
+	require.NoError(t, err)
+	require.Equal(t, "*down*", comment.Body)
+
+	issues, _, err := client.Issue.Search("labels=x", nil)
+	require.NoError(t, err)
+	require.Len(t, issues, 1)
+	require.Equal(t, "*down*", issues[0].Fields.Description)
+	require.Equal(t, "*down*", issues[0].Fields.Comments.Comments[0].Body)
+}
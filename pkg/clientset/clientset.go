@@ -0,0 +1,222 @@
+// Copyright 2017 The Prometheus Authors
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package clientset builds the Jira API clients used by receivers.
+package clientset
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/url"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/andygrunwald/go-jira"
+	"github.com/pkg/errors"
+	"github.com/prometheus-community/jiralert/pkg/config"
+	"github.com/prometheus-community/jiralert/pkg/secrets"
+)
+
+// Client is the Jira API surface used to manage issues: the go-jira issue service, plus the few calls JIRAlert
+// needs from other services.
+type Client struct {
+	*jira.IssueService
+
+	jira *jira.Client
+}
+
+// New creates a Client for the Jira instance and credentials configured in the given receiver.
+func New(c *config.ReceiverConfig) (*Client, error) {
+	base, err := baseTransport(c)
+	if err != nil {
+		return nil, err
+	}
+	var transport http.RoundTripper = &propagationTransport{next: base}
+	if l := failures.Load(); l != nil {
+		transport = &failureTransport{receiver: c.Name, log: l, next: transport}
+	}
+	if c.RateLimit != nil {
+		transport = &rateLimitTransport{limiter: getLimiter(c.APIURL, *c.RateLimit), next: transport}
+	}
+
+	var httpClient *http.Client
+	switch {
+	case c.User != "" && c.Password != "":
+		tp := jira.BasicAuthTransport{
+			Username:  c.User,
+			Password:  string(c.Password),
+			Transport: transport,
+		}
+		httpClient = tp.Client()
+	case c.User != "" && c.APIToken != "":
+		tp := jira.BasicAuthTransport{
+			Username:  c.User,
+			Password:  string(c.APIToken),
+			Transport: transport,
+		}
+		httpClient = tp.Client()
+	case c.User != "" && c.PasswordFile != "":
+		httpClient = &http.Client{Transport: &basicAuthFileTransport{user: c.User, password: secretFile(c.PasswordFile), next: transport}}
+	case c.User != "" && c.APITokenFile != "":
+		httpClient = &http.Client{Transport: &basicAuthFileTransport{user: c.User, password: secretFile(c.APITokenFile), next: transport}}
+	case c.User != "" && c.PasswordRef != nil:
+		httpClient = &http.Client{Transport: &basicAuthFileTransport{user: c.User, password: secrets.DefaultResolver().Func(*c.PasswordRef), next: transport}}
+	case c.User != "" && c.APITokenRef != nil:
+		httpClient = &http.Client{Transport: &basicAuthFileTransport{user: c.User, password: secrets.DefaultResolver().Func(*c.APITokenRef), next: transport}}
+	case c.PersonalAccessTokenFile != "":
+		httpClient = &http.Client{Transport: &bearerFileTransport{token: secretFile(c.PersonalAccessTokenFile), next: transport}}
+	case c.PersonalAccessTokenRef != nil:
+		httpClient = &http.Client{Transport: &bearerFileTransport{token: secrets.DefaultResolver().Func(*c.PersonalAccessTokenRef), next: transport}}
+	case c.PersonalAccessToken != "":
+		tp := jira.PATAuthTransport{
+			Token:     string(c.PersonalAccessToken),
+			Transport: transport,
+		}
+		httpClient = tp.Client()
+	case c.OAuth != nil:
+		httpClient = &http.Client{Transport: &oauthTransport{
+			source: getTokenSource(c.OAuth, base),
+			next:   transport,
+		}}
+	case c.Connect != nil:
+		u, err := url.Parse(c.APIURL)
+		if err != nil {
+			return nil, err
+		}
+		httpClient = &http.Client{Transport: &connectTransport{
+			key:      c.Connect.Key,
+			secret:   []byte(c.Connect.SharedSecret),
+			basePath: strings.TrimSuffix(u.Path, "/"),
+			next:     transport,
+			timeNow:  time.Now,
+		}}
+	default:
+		return nil, errors.Errorf("no authentication configured for receiver %q", c.Name)
+	}
+
+	// Requests are rewritten for API version 3 before being authenticated, as Connect JWTs are bound to the path.
+	if c.APIVersion == config.APIVersion3 {
+		httpClient.Transport = &adfTransport{next: httpClient.Transport}
+	}
+
+	httpClient.Timeout = defaultTimeout
+	if c.Timeout != nil {
+		httpClient.Timeout = time.Duration(*c.Timeout)
+	}
+
+	client, err := jira.NewClient(httpClient, c.APIURL)
+	if err != nil {
+		return nil, err
+	}
+	return &Client{IssueService: client.Issue, jira: client}, nil
+}
+
+// CreateRequestWithContext creates a Jira Service Management customer request.
+func (c *Client) CreateRequestWithContext(ctx context.Context, request *jira.Request) (*jira.Request, *jira.Response, error) {
+	return c.jira.Request.CreateWithContext(ctx, "", nil, request)
+}
+
+// MaxBulkCreate is the maximum number of issues Jira creates in one bulk create request.
+const MaxBulkCreate = 50
+
+// BulkCreateError is the reason Jira failed to create one of the issues of a bulk create request.
+type BulkCreateError struct {
+	Status              int        `json:"status"`
+	ElementErrors       jira.Error `json:"elementErrors"`
+	FailedElementNumber int        `json:"failedElementNumber"`
+}
+
+func (e *BulkCreateError) Error() string {
+	msgs := append([]string{}, e.ElementErrors.ErrorMessages...)
+	for field, msg := range e.ElementErrors.Errors {
+		msgs = append(msgs, field+": "+msg)
+	}
+	sort.Strings(msgs)
+	return fmt.Sprintf("status %d: %s", e.Status, strings.Join(msgs, ", "))
+}
+
+// BulkCreateWithContext creates up to MaxBulkCreate issues in one request. Jira creates the issues it can and reports
+// the others, so it returns the created issues and the errors of the others, in the order of the given issues: for
+// each issue, either the created issue or a *BulkCreateError is set. The error is only set if the whole request
+// failed, in which case the response body is left for the caller to read, like CreateWithContext does.
+func (c *Client) BulkCreateWithContext(ctx context.Context, issues []*jira.Issue) ([]*jira.Issue, []error, *jira.Response, error) {
+	body := struct {
+		IssueUpdates []*jira.Issue `json:"issueUpdates"`
+	}{IssueUpdates: issues}
+	req, err := c.jira.NewRequestWithContext(ctx, http.MethodPost, "rest/api/2/issue/bulk", body)
+	if err != nil {
+		return nil, nil, nil, err
+	}
+	var res struct {
+		Issues []*jira.Issue      `json:"issues"`
+		Errors []*BulkCreateError `json:"errors"`
+	}
+	resp, err := c.jira.Do(req, &res)
+	if err != nil {
+		return nil, nil, resp, err
+	}
+
+	created := make([]*jira.Issue, len(issues))
+	errs := make([]error, len(issues))
+	for _, e := range res.Errors {
+		if e.FailedElementNumber >= 0 && e.FailedElementNumber < len(issues) {
+			errs[e.FailedElementNumber] = e
+		}
+	}
+	// Created issues are listed in order, leaving out the failed ones.
+	next := 0
+	for i := range issues {
+		if errs[i] != nil {
+			continue
+		}
+		if next >= len(res.Issues) {
+			errs[i] = errors.New("missing from the bulk create response")
+			continue
+		}
+		created[i] = res.Issues[next]
+		next++
+	}
+	return created, errs, resp, nil
+}
+
+// FindUsersWithContext searches the users whose email address, display name or username matches query. Jira Cloud
+// searches by query, while Jira Server and Data Center reject the query parameter and search by username.
+func (c *Client) FindUsersWithContext(ctx context.Context, query string) ([]jira.User, *jira.Response, error) {
+	users, resp, err := c.findUsers(ctx, url.Values{"query": {query}, "maxResults": {"10"}})
+	if resp != nil && resp.StatusCode == http.StatusBadRequest {
+		users, resp, err = c.findUsers(ctx, url.Values{"username": {query}, "maxResults": {"10"}})
+	}
+	return users, resp, err
+}
+
+func (c *Client) findUsers(ctx context.Context, params url.Values) ([]jira.User, *jira.Response, error) {
+	req, err := c.jira.NewRequestWithContext(ctx, http.MethodGet, "rest/api/2/user/search?"+params.Encode(), nil)
+	if err != nil {
+		return nil, nil, err
+	}
+	var users []jira.User
+	resp, err := c.jira.Do(req, &users)
+	if err != nil {
+		return nil, resp, jira.NewJiraError(resp, err)
+	}
+	return users, resp, nil
+}
+
+// PingWithContext verifies that Jira is reachable and accepts the configured credentials, by fetching the
+// authenticated user.
+func (c *Client) PingWithContext(ctx context.Context) (*jira.Response, error) {
+	_, resp, err := c.jira.User.GetSelfWithContext(ctx)
+	return resp, err
+}
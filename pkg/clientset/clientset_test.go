@@ -0,0 +1,101 @@
+// Copyright 2017 The Prometheus Authors
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+package clientset
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/andygrunwald/go-jira"
+	"github.com/stretchr/testify/require"
+)
+
+func TestBulkCreate(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		require.Equal(t, "/rest/api/3/issue/bulk", req.URL.Path)
+		var body struct {
+			IssueUpdates []struct {
+				Fields map[string]json.RawMessage `json:"fields"`
+			} `json:"issueUpdates"`
+		}
+		require.NoError(t, json.NewDecoder(req.Body).Decode(&body))
+		require.Len(t, body.IssueUpdates, 3)
+		// Descriptions are converted to ADF like those of single issues.
+		require.JSONEq(t, `{"type":"doc","version":1,"content":[{"type":"paragraph","content":[{"type":"text","text":"down"}]}]}`, string(body.IssueUpdates[0].Fields["description"]))
+
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(http.StatusCreated)
+		_, _ = w.Write([]byte(`{
+			"issues": [{"id": "1", "key": "ABC-1"}, {"id": "3", "key": "ABC-3"}],
+			"errors": [{"status": 400, "elementErrors": {"errors": {"priority": "invalid priority"}}, "failedElementNumber": 1}]
+		}`))
+	}))
+	defer srv.Close()
+
+	jc, err := jira.NewClient(&http.Client{Transport: &adfTransport{next: http.DefaultTransport}}, srv.URL)
+	require.NoError(t, err)
+	client := &Client{IssueService: jc.Issue, jira: jc}
+
+	issues := []*jira.Issue{
+		{Fields: &jira.IssueFields{Summary: "first", Description: "down"}},
+		{Fields: &jira.IssueFields{Summary: "second"}},
+		{Fields: &jira.IssueFields{Summary: "third"}},
+	}
+	created, errs, _, err := client.BulkCreateWithContext(context.Background(), issues)
+	require.NoError(t, err)
+	require.Equal(t, "ABC-1", created[0].Key)
+	require.Nil(t, created[1])
+	require.Equal(t, "ABC-3", created[2].Key)
+	require.NoError(t, errs[0])
+	require.EqualError(t, errs[1], "status 400: priority: invalid priority")
+	require.NoError(t, errs[2])
+}
+
+func TestFindUsers(t *testing.T) {
+	for _, tc := range []struct {
+		name   string
+		server bool
+	}{
+		{name: "cloud"},
+		{name: "server", server: true},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+				require.Equal(t, "/rest/api/2/user/search", req.URL.Path)
+				q := req.URL.Query()
+				if tc.server && q.Get("username") == "" {
+					w.WriteHeader(http.StatusBadRequest)
+					_, _ = w.Write([]byte(`{"errorMessages": ["The username query parameter was not provided"]}`))
+					return
+				}
+				if !tc.server {
+					require.Equal(t, "jane+oncall@example.com", q.Get("query"))
+				}
+				w.Header().Set("Content-Type", "application/json")
+				_, _ = w.Write([]byte(`[{"accountId": "5b10ac8d82e05b22cc7d4ef5", "emailAddress": "jane+oncall@example.com"}]`))
+			}))
+			defer srv.Close()
+
+			jc, err := jira.NewClient(nil, srv.URL)
+			require.NoError(t, err)
+			client := &Client{IssueService: jc.Issue, jira: jc}
+
+			users, _, err := client.FindUsersWithContext(context.Background(), "jane+oncall@example.com")
+			require.NoError(t, err)
+			require.Equal(t, []jira.User{{AccountID: "5b10ac8d82e05b22cc7d4ef5", EmailAddress: "jane+oncall@example.com"}}, users)
+		})
+	}
+}
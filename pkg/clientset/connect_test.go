@@ -0,0 +1,91 @@
+// Copyright 2017 The Prometheus Authors
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+package clientset
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/golang-jwt/jwt/v4"
+	"github.com/prometheus-community/jiralert/pkg/config"
+	"github.com/stretchr/testify/require"
+)
+
+func TestQueryStringHash(t *testing.T) {
+	query := url.Values{
+		"jql":        {"project = ABC"},
+		"fields":     {"summary", "labels"},
+		"jwt":        {"ignored"},
+		"maxResults": {"2"},
+	}
+	sum := sha256.Sum256([]byte("GET&/rest/api/2/search&fields=labels,summary&jql=project%20%3D%20ABC&maxResults=2"))
+	require.Equal(t, hex.EncodeToString(sum[:]), queryStringHash("get", "/rest/api/2/search/", query))
+}
+
+func TestConnectTransport(t *testing.T) {
+	now := time.Now()
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		raw := strings.TrimPrefix(req.Header.Get("Authorization"), "JWT ")
+		claims := &connectClaims{}
+		_, err := jwt.ParseWithClaims(raw, claims, func(*jwt.Token) (interface{}, error) { return []byte("s3cr3t"), nil })
+		require.NoError(t, err)
+		require.Equal(t, "jiralert", claims.Issuer)
+		require.Equal(t, queryStringHash("GET", "/rest/api/2/issue/ABC-1", url.Values{}), claims.QSH)
+	}))
+	defer srv.Close()
+
+	client := &http.Client{Transport: &connectTransport{
+		key:      "jiralert",
+		secret:   []byte("s3cr3t"),
+		basePath: "/jira",
+		next:     http.DefaultTransport,
+		timeNow:  func() time.Time { return now },
+	}}
+	resp, err := client.Get(srv.URL + "/jira/rest/api/2/issue/ABC-1")
+	require.NoError(t, err)
+	resp.Body.Close()
+	require.Equal(t, http.StatusOK, resp.StatusCode)
+}
+
+func TestConnectAPIVersion3(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		require.Equal(t, "/jira/rest/api/3/issue/ABC-1", req.URL.Path)
+		raw := strings.TrimPrefix(req.Header.Get("Authorization"), "JWT ")
+		claims := &connectClaims{}
+		_, err := jwt.ParseWithClaims(raw, claims, func(*jwt.Token) (interface{}, error) { return []byte("s3cr3t"), nil })
+		require.NoError(t, err)
+		// The JWT is bound to the path Jira receives.
+		require.Equal(t, queryStringHash("GET", "/rest/api/3/issue/ABC-1", url.Values{}), claims.QSH)
+		w.Header().Set("Content-Type", "application/json")
+		_, _ = w.Write([]byte(`{"key":"ABC-1"}`))
+	}))
+	defer srv.Close()
+
+	client, err := New(&config.ReceiverConfig{
+		Name:       "jira",
+		APIURL:     srv.URL + "/jira",
+		Connect:    &config.ConnectConfig{Key: "jiralert", SharedSecret: "s3cr3t"},
+		APIVersion: config.APIVersion3,
+	})
+	require.NoError(t, err)
+	issue, _, err := client.GetWithContext(context.Background(), "ABC-1", nil)
+	require.NoError(t, err)
+	require.Equal(t, "ABC-1", issue.Key)
+}
@@ -0,0 +1,184 @@
+// Copyright 2017 The Prometheus Authors
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package clientset
+
+import (
+	"bytes"
+	"encoding/json"
+	"io"
+	"net/http"
+	"regexp"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+// maxFailureBodySize is the size above which the bodies of failed requests and their responses are truncated.
+const maxFailureBodySize = 16 << 10
+
+// sensitiveKey matches the keys of the JSON values redacted from the bodies of failed requests.
+var sensitiveKey = regexp.MustCompile(`(?i)password|secret|token`)
+
+// Failure is a Jira request which failed, with Jira responding with an error status or without a response.
+type Failure struct {
+	Time     time.Time `json:"time"`
+	Receiver string    `json:"receiver"`
+	Method   string    `json:"method"`
+	URL      string    `json:"url"`
+	// RequestBody is the body of the request, with the values of keys like password, secret or token redacted.
+	RequestBody  string `json:"request_body,omitempty"`
+	StatusCode   int    `json:"status_code,omitempty"`
+	Status       string `json:"status,omitempty"`
+	ResponseBody string `json:"response_body,omitempty"`
+	// Error is the error of requests without a response.
+	Error string `json:"error,omitempty"`
+}
+
+// FailureLog keeps the most recent failed requests of each receiver in memory, for debugging field errors without
+// raising the log level and replaying alerts.
+type FailureLog struct {
+	mtx      sync.Mutex
+	size     int
+	failures map[string][]Failure
+}
+
+// NewFailureLog creates a FailureLog keeping up to size failures per receiver.
+func NewFailureLog(size int) *FailureLog {
+	return &FailureLog{size: size, failures: map[string][]Failure{}}
+}
+
+// Record adds the failure, dropping the oldest failure of its receiver if full.
+func (l *FailureLog) Record(f Failure) {
+	l.mtx.Lock()
+	defer l.mtx.Unlock()
+	failures := append(l.failures[f.Receiver], f)
+	if len(failures) > l.size {
+		failures = failures[len(failures)-l.size:]
+	}
+	l.failures[f.Receiver] = failures
+}
+
+// List returns the failures of the given receiver, or all receivers if empty, by receiver, most recent first.
+func (l *FailureLog) List(receiver string) map[string][]Failure {
+	l.mtx.Lock()
+	defer l.mtx.Unlock()
+	res := map[string][]Failure{}
+	for name, failures := range l.failures {
+		if receiver != "" && name != receiver {
+			continue
+		}
+		list := make([]Failure, 0, len(failures))
+		for i := len(failures) - 1; i >= 0; i-- {
+			list = append(list, failures[i])
+		}
+		res[name] = list
+	}
+	return res
+}
+
+// failures records the failed requests of the clients created afterwards, if set.
+var failures atomic.Pointer[FailureLog]
+
+// RecordFailures makes the clients created afterwards record their failed requests in l, or stop recording them if
+// nil.
+func RecordFailures(l *FailureLog) {
+	failures.Store(l)
+}
+
+// failureTransport records the failed requests of a receiver.
+type failureTransport struct {
+	receiver string
+	log      *FailureLog
+	next     http.RoundTripper
+}
+
+func (t *failureTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	resp, err := t.next.RoundTrip(req)
+	if err == nil && resp.StatusCode < 400 {
+		return resp, nil
+	}
+
+	f := Failure{Time: time.Now(), Receiver: t.receiver, Method: req.Method, URL: req.URL.String()}
+	if req.GetBody != nil {
+		if body, err := req.GetBody(); err == nil {
+			b, _ := io.ReadAll(io.LimitReader(body, maxFailureBodySize+1))
+			_ = body.Close()
+			f.RequestBody = truncateBody(redactJSON(b))
+		}
+	}
+	if err != nil {
+		f.Error = err.Error()
+		t.log.Record(f)
+		return resp, err
+	}
+	f.StatusCode, f.Status = resp.StatusCode, resp.Status
+	if resp.Body != nil {
+		// The response is read again by the caller.
+		b, _ := io.ReadAll(resp.Body)
+		_ = resp.Body.Close()
+		resp.Body = io.NopCloser(bytes.NewReader(b))
+		f.ResponseBody = truncateBody(b)
+	}
+	t.log.Record(f)
+	return resp, nil
+}
+
+// redactJSON replaces the values of the sensitive keys of the JSON object or array b, if any, keeping other bodies as
+// they are.
+func redactJSON(b []byte) []byte {
+	var v interface{}
+	dec := json.NewDecoder(bytes.NewReader(b))
+	dec.UseNumber()
+	if dec.Decode(&v) != nil {
+		return b
+	}
+	if !redact(v) {
+		return b
+	}
+	var buf bytes.Buffer
+	enc := json.NewEncoder(&buf)
+	enc.SetEscapeHTML(false)
+	if enc.Encode(v) != nil {
+		return b
+	}
+	return bytes.TrimSuffix(buf.Bytes(), []byte("\n"))
+}
+
+// redact replaces the values of the sensitive keys of v, reporting whether it replaced any.
+func redact(v interface{}) bool {
+	redacted := false
+	switch v := v.(type) {
+	case map[string]interface{}:
+		for k, value := range v {
+			if sensitiveKey.MatchString(k) {
+				v[k] = "<redacted>"
+				redacted = true
+				continue
+			}
+			redacted = redact(value) || redacted
+		}
+	case []interface{}:
+		for _, value := range v {
+			redacted = redact(value) || redacted
+		}
+	}
+	return redacted
+}
+
+func truncateBody(b []byte) string {
+	if len(b) > maxFailureBodySize {
+		return string(b[:maxFailureBodySize]) + "... (truncated)"
+	}
+	return string(b)
+}
@@ -0,0 +1,96 @@
+// Copyright 2017 The Prometheus Authors
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+package clientset
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/andygrunwald/go-jira"
+	"github.com/prometheus-community/jiralert/pkg/config"
+	"github.com/stretchr/testify/require"
+)
+
+func TestFailureTransport(t *testing.T) {
+	const errBody = `{"errorMessages":[],"errors":{"customfield_10010":"Option id 'null' is not valid"}}`
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		if req.URL.Path == "/rest/api/2/issue/OK-1" {
+			w.Header().Set("Content-Type", "application/json")
+			_, _ = w.Write([]byte(`{"key":"OK-1"}`))
+			return
+		}
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(http.StatusBadRequest)
+		_, _ = w.Write([]byte(errBody))
+	}))
+	defer srv.Close()
+
+	l := NewFailureLog(2)
+	RecordFailures(l)
+	defer RecordFailures(nil)
+	client, err := New(&config.ReceiverConfig{Name: "jira-sre", APIURL: srv.URL, User: "jiralert", Password: "JIRAlert"})
+	require.NoError(t, err)
+
+	_, _, err = client.GetWithContext(context.Background(), "OK-1", nil)
+	require.NoError(t, err)
+	require.Empty(t, l.List(""))
+
+	issue := &jira.Issue{Fields: &jira.IssueFields{
+		Summary:  "summary",
+		Unknowns: map[string]interface{}{"customfield_10010": "value", "api_token": "s3cr3t"},
+	}}
+	_, resp, err := client.CreateWithContext(context.Background(), issue)
+	require.Error(t, err)
+	// The response body is left for the caller to read.
+	body, err := io.ReadAll(resp.Body)
+	require.NoError(t, err)
+	require.Equal(t, errBody, string(body))
+
+	failures := l.List("jira-sre")["jira-sre"]
+	require.Len(t, failures, 1)
+	f := failures[0]
+	require.Equal(t, "jira-sre", f.Receiver)
+	require.Equal(t, http.MethodPost, f.Method)
+	require.Equal(t, srv.URL+"/rest/api/2/issue", f.URL)
+	require.Equal(t, http.StatusBadRequest, f.StatusCode)
+	require.Equal(t, errBody, f.ResponseBody)
+	require.Contains(t, f.RequestBody, `"customfield_10010":"value"`)
+	require.Contains(t, f.RequestBody, `"api_token":"<redacted>"`)
+	require.NotContains(t, f.RequestBody, "s3cr3t")
+}
+
+func TestFailureLog(t *testing.T) {
+	l := NewFailureLog(2)
+	for i := 0; i < 3; i++ {
+		l.Record(Failure{Receiver: "a", URL: fmt.Sprint(i)})
+	}
+	l.Record(Failure{Receiver: "b", URL: "b"})
+
+	require.Equal(t, map[string][]Failure{
+		"a": {{Receiver: "a", URL: "2"}, {Receiver: "a", URL: "1"}},
+		"b": {{Receiver: "b", URL: "b"}},
+	}, l.List(""))
+	require.Equal(t, map[string][]Failure{"b": {{Receiver: "b", URL: "b"}}}, l.List("b"))
+}
+
+func TestTruncateBody(t *testing.T) {
+	long := strings.Repeat("a", maxFailureBodySize+1)
+	require.Equal(t, strings.Repeat("a", maxFailureBodySize)+"... (truncated)", truncateBody([]byte(long)))
+	require.Equal(t, "not json", string(redactJSON([]byte("not json"))))
+	require.Equal(t, `{"a":[{"password":"<redacted>"}]}`, string(redactJSON([]byte(`{"a":[{"password":"x"}]}`))))
+}
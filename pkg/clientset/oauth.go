@@ -0,0 +1,167 @@
+// Copyright 2017 The Prometheus Authors
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package clientset
+
+import (
+	"context"
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/url"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/pkg/errors"
+	"github.com/prometheus-community/jiralert/pkg/config"
+)
+
+// tokenExpiryDelta is how long before their expiry access tokens are renewed.
+const tokenExpiryDelta = time.Minute
+
+// defaultTokenLifetime is the assumed lifetime in seconds of access tokens returned without expires_in.
+const defaultTokenLifetime = 3600
+
+// tokenSources are shared by all clients using the same OAuth app and grant, and the same transport, so that access
+// tokens are reused across notifications.
+var (
+	tokenSourcesMtx sync.Mutex
+	tokenSources    = map[tokenSourceKey]*tokenSource{}
+)
+
+type tokenSourceKey struct {
+	tokenURL, clientID, clientSecret, refreshToken, scopes string
+	// Transports are cached by configuration, so receivers with the same tls_config share the token source.
+	transport http.RoundTripper
+}
+
+// tokenSource obtains OAuth 2.0 access tokens, renewing them when they expire.
+type tokenSource struct {
+	conf   config.OAuthConfig
+	client *http.Client
+
+	mtx          sync.Mutex
+	accessToken  string
+	refreshToken string
+	expiry       time.Time
+
+	timeNow func() time.Time
+}
+
+// getTokenSource returns the token source of the OAuth configuration, requesting tokens with transport, which is the
+// base transport of the receiver so that its tls_config and the proxy settings apply to the token URL too.
+func getTokenSource(c *config.OAuthConfig, transport http.RoundTripper) *tokenSource {
+	key := tokenSourceKey{
+		tokenURL:     c.TokenURL,
+		clientID:     c.ClientID,
+		clientSecret: string(c.ClientSecret),
+		refreshToken: string(c.RefreshToken),
+		scopes:       strings.Join(c.Scopes, " "),
+		transport:    transport,
+	}
+
+	tokenSourcesMtx.Lock()
+	defer tokenSourcesMtx.Unlock()
+	if ts, ok := tokenSources[key]; ok {
+		return ts
+	}
+	ts := &tokenSource{
+		conf:         *c,
+		client:       &http.Client{Transport: transport, Timeout: time.Minute},
+		refreshToken: string(c.RefreshToken),
+		timeNow:      time.Now,
+	}
+	tokenSources[key] = ts
+	return ts
+}
+
+// token returns a valid access token, requesting a new one if needed until ctx is done.
+func (ts *tokenSource) token(ctx context.Context) (string, error) {
+	ts.mtx.Lock()
+	defer ts.mtx.Unlock()
+	if ts.accessToken != "" && ts.timeNow().Add(tokenExpiryDelta).Before(ts.expiry) {
+		return ts.accessToken, nil
+	}
+
+	form := url.Values{
+		"client_id":     {ts.conf.ClientID},
+		"client_secret": {string(ts.conf.ClientSecret)},
+	}
+	if ts.refreshToken != "" {
+		form.Set("grant_type", "refresh_token")
+		form.Set("refresh_token", ts.refreshToken)
+	} else {
+		form.Set("grant_type", "client_credentials")
+	}
+	if len(ts.conf.Scopes) > 0 {
+		form.Set("scope", strings.Join(ts.conf.Scopes, " "))
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, ts.conf.TokenURL, strings.NewReader(form.Encode()))
+	if err != nil {
+		return "", errors.Wrap(err, "create OAuth token request")
+	}
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	resp, err := ts.client.Do(req)
+	if err != nil {
+		return "", errors.Wrap(err, "request OAuth token")
+	}
+	defer resp.Body.Close()
+	body, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return "", errors.Wrap(err, "read OAuth token response")
+	}
+	if resp.StatusCode != http.StatusOK {
+		return "", errors.Errorf("request OAuth token: status %d: %s", resp.StatusCode, body)
+	}
+
+	var tok struct {
+		AccessToken  string `json:"access_token"`
+		RefreshToken string `json:"refresh_token"`
+		ExpiresIn    int64  `json:"expires_in"`
+	}
+	if err := json.Unmarshal(body, &tok); err != nil {
+		return "", errors.Wrap(err, "decode OAuth token response")
+	}
+	if tok.AccessToken == "" {
+		return "", errors.New("OAuth token response has no access_token")
+	}
+
+	ts.accessToken = tok.AccessToken
+	if tok.ExpiresIn <= 0 {
+		tok.ExpiresIn = defaultTokenLifetime
+	}
+	ts.expiry = ts.timeNow().Add(time.Duration(tok.ExpiresIn) * time.Second)
+	// Refresh tokens may be rotated, in which case the previous one is invalidated.
+	if tok.RefreshToken != "" && ts.refreshToken != "" {
+		ts.refreshToken = tok.RefreshToken
+	}
+	return ts.accessToken, nil
+}
+
+// oauthTransport authenticates requests with access tokens from a tokenSource.
+type oauthTransport struct {
+	source *tokenSource
+	next   http.RoundTripper
+}
+
+func (t *oauthTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	token, err := t.source.token(req.Context())
+	if err != nil {
+		return nil, err
+	}
+	req = req.Clone(req.Context())
+	req.Header.Set("Authorization", "Bearer "+token)
+	return t.next.RoundTrip(req)
+}
@@ -0,0 +1,104 @@
+// Copyright 2017 The Prometheus Authors
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+package clientset
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/prometheus-community/jiralert/pkg/config"
+	"github.com/stretchr/testify/require"
+)
+
+func TestTokenSource(t *testing.T) {
+	var requests []string
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		require.NoError(t, req.ParseForm())
+		require.Equal(t, "id", req.PostForm.Get("client_id"))
+		require.Equal(t, "secret", req.PostForm.Get("client_secret"))
+		require.Equal(t, "read:jira-work write:jira-work", req.PostForm.Get("scope"))
+		requests = append(requests, req.PostForm.Get("grant_type")+" "+req.PostForm.Get("refresh_token"))
+
+		w.Header().Set("Content-Type", "application/json")
+		fmt.Fprintf(w, `{"access_token":"access%d","refresh_token":"refresh%d","expires_in":3600}`, len(requests), len(requests))
+	}))
+	defer srv.Close()
+
+	now := time.Now()
+	ts := getTokenSource(&config.OAuthConfig{
+		ClientID:     "id",
+		ClientSecret: "secret",
+		TokenURL:     srv.URL,
+		Scopes:       []string{"read:jira-work", "write:jira-work"},
+		RefreshToken: "refresh0",
+	}, http.DefaultTransport)
+	ts.timeNow = func() time.Time { return now }
+
+	token, err := ts.token(context.Background())
+	require.NoError(t, err)
+	require.Equal(t, "access1", token)
+
+	// Cached until shortly before expiry.
+	now = now.Add(58 * time.Minute)
+	token, err = ts.token(context.Background())
+	require.NoError(t, err)
+	require.Equal(t, "access1", token)
+
+	// Renewed with the rotated refresh token.
+	now = now.Add(time.Minute)
+	token, err = ts.token(context.Background())
+	require.NoError(t, err)
+	require.Equal(t, "access2", token)
+
+	require.Equal(t, []string{"refresh_token refresh0", "refresh_token refresh1"}, requests)
+}
+
+func TestOAuthTransport(t *testing.T) {
+	srv := httptest.NewTLSServer(http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		if req.URL.Path == "/token" {
+			w.Header().Set("Content-Type", "application/json")
+			fmt.Fprint(w, `{"access_token":"tls-token","expires_in":3600}`)
+			return
+		}
+		require.Equal(t, "Bearer tls-token", req.Header.Get("Authorization"))
+		w.Header().Set("Content-Type", "application/json")
+		fmt.Fprint(w, `{"name":"jiralert"}`)
+	}))
+	defer srv.Close()
+
+	// The tls_config of the receiver applies to the token URL too.
+	client, err := New(&config.ReceiverConfig{
+		Name:      "oauth",
+		APIURL:    srv.URL,
+		TLSConfig: &config.TLSConfig{InsecureSkipVerify: true},
+		OAuth: &config.OAuthConfig{
+			ClientID:     "tls-id",
+			ClientSecret: "secret",
+			TokenURL:     srv.URL + "/token",
+		},
+	})
+	require.NoError(t, err)
+	_, err = client.PingWithContext(context.Background())
+	require.NoError(t, err)
+
+	// Tokens are requested with the context of the request.
+	ts := getTokenSource(&config.OAuthConfig{ClientID: "other-id", ClientSecret: "secret", TokenURL: srv.URL + "/token"}, srv.Client().Transport)
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	_, err = ts.token(ctx)
+	require.ErrorIs(t, err, context.Canceled)
+}
@@ -0,0 +1,119 @@
+// Copyright 2017 The Prometheus Authors
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package clientset
+
+import (
+	"context"
+	"net/http"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/prometheus-community/jiralert/pkg/config"
+)
+
+// limiters are shared by all clients of the same Jira instance, so that the configured rate applies across
+// receivers and concurrent notifications.
+var (
+	limitersMtx sync.Mutex
+	limiters    = map[string]*limiter{}
+)
+
+// getLimiter returns the limiter of the Jira instance at apiURL, replacing it if its settings changed.
+func getLimiter(apiURL string, c config.RateLimitConfig) *limiter {
+	if c.Burst <= 0 {
+		c.Burst = 1
+	}
+	key := strings.TrimSuffix(apiURL, "/")
+
+	limitersMtx.Lock()
+	defer limitersMtx.Unlock()
+	if l, ok := limiters[key]; ok && l.conf == c {
+		return l
+	}
+	l := newLimiter(c, time.Now)
+	limiters[key] = l
+	return l
+}
+
+// limiter is a token bucket holding up to Burst tokens, refilled at RequestsPerSecond.
+type limiter struct {
+	conf config.RateLimitConfig
+
+	mtx    sync.Mutex
+	tokens float64
+	last   time.Time
+
+	timeNow func() time.Time
+}
+
+func newLimiter(c config.RateLimitConfig, timeNow func() time.Time) *limiter {
+	return &limiter{conf: c, tokens: float64(c.Burst), last: timeNow(), timeNow: timeNow}
+}
+
+// reserve takes a token, returning how long to wait before it may be used.
+func (l *limiter) reserve() time.Duration {
+	l.mtx.Lock()
+	defer l.mtx.Unlock()
+	now := l.timeNow()
+	if now.After(l.last) {
+		l.tokens += now.Sub(l.last).Seconds() * l.conf.RequestsPerSecond
+		if l.tokens > float64(l.conf.Burst) {
+			l.tokens = float64(l.conf.Burst)
+		}
+		l.last = now
+	}
+	l.tokens--
+	if l.tokens >= 0 {
+		return 0
+	}
+	return time.Duration(-l.tokens / l.conf.RequestsPerSecond * float64(time.Second))
+}
+
+// cancel returns a token taken by reserve and not used.
+func (l *limiter) cancel() {
+	l.mtx.Lock()
+	defer l.mtx.Unlock()
+	l.tokens++
+}
+
+// wait blocks until a request may be sent, or ctx is done.
+func (l *limiter) wait(ctx context.Context) error {
+	d := l.reserve()
+	if d == 0 {
+		return nil
+	}
+	t := time.NewTimer(d)
+	defer t.Stop()
+	select {
+	case <-t.C:
+		return nil
+	case <-ctx.Done():
+		l.cancel()
+		return ctx.Err()
+	}
+}
+
+// rateLimitTransport delays requests exceeding the rate limit of their Jira instance.
+type rateLimitTransport struct {
+	limiter *limiter
+	next    http.RoundTripper
+}
+
+func (t *rateLimitTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	if err := t.limiter.wait(req.Context()); err != nil {
+		return nil, err
+	}
+	return t.next.RoundTrip(req)
+}
@@ -0,0 +1,61 @@
+// Copyright 2017 The Prometheus Authors
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+package clientset
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/prometheus-community/jiralert/pkg/config"
+	"github.com/stretchr/testify/require"
+)
+
+func TestLimiter(t *testing.T) {
+	now := time.Unix(0, 0)
+	l := newLimiter(config.RateLimitConfig{RequestsPerSecond: 2, Burst: 3}, func() time.Time { return now })
+
+	// The burst is available at once, further requests wait for tokens to be refilled.
+	for i := 0; i < 3; i++ {
+		require.Equal(t, time.Duration(0), l.reserve())
+	}
+	require.Equal(t, 500*time.Millisecond, l.reserve())
+	require.Equal(t, time.Second, l.reserve())
+
+	// Tokens are refilled at the configured rate, up to the burst.
+	now = now.Add(time.Hour)
+	for i := 0; i < 3; i++ {
+		require.Equal(t, time.Duration(0), l.reserve())
+	}
+	require.Equal(t, 500*time.Millisecond, l.reserve())
+}
+
+func TestLimiterWait(t *testing.T) {
+	l := newLimiter(config.RateLimitConfig{RequestsPerSecond: 0.001, Burst: 1}, time.Now)
+	require.NoError(t, l.wait(context.Background()))
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+	defer cancel()
+	require.Equal(t, context.DeadlineExceeded, l.wait(ctx))
+	// The token of the canceled request is given back.
+	require.InDelta(t, 0, l.tokens, 0.01)
+}
+
+func TestGetLimiter(t *testing.T) {
+	c := config.RateLimitConfig{RequestsPerSecond: 10}
+	l := getLimiter("https://jiralert.atlassian.net/", c)
+	require.Same(t, l, getLimiter("https://jiralert.atlassian.net", c))
+	require.Equal(t, 1, l.conf.Burst)
+	require.NotSame(t, l, getLimiter("https://jira.example.com", c))
+	require.NotSame(t, l, getLimiter("https://jiralert.atlassian.net", config.RateLimitConfig{RequestsPerSecond: 5}))
+}
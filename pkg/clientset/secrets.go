@@ -0,0 +1,68 @@
+// Copyright 2017 The Prometheus Authors
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package clientset
+
+import (
+	"net/http"
+	"os"
+	"strings"
+
+	"github.com/pkg/errors"
+)
+
+// secretFile returns a function reading the secret held in the given file. The file is read on every call, so
+// that rotated secrets, e.g. updated Kubernetes secrets, are picked up.
+func secretFile(path string) func() (string, error) {
+	return func() (string, error) {
+		b, err := os.ReadFile(path)
+		if err != nil {
+			return "", errors.Wrap(err, "read secret file")
+		}
+		return strings.TrimSpace(string(b)), nil
+	}
+}
+
+// basicAuthFileTransport sets basic authentication, with the password read from a file or secret provider on each
+// request.
+type basicAuthFileTransport struct {
+	user     string
+	password func() (string, error)
+	next     http.RoundTripper
+}
+
+func (t *basicAuthFileTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	password, err := t.password()
+	if err != nil {
+		return nil, err
+	}
+	req = req.Clone(req.Context())
+	req.SetBasicAuth(t.user, password)
+	return t.next.RoundTrip(req)
+}
+
+// bearerFileTransport sets a bearer token read from a file or secret provider on each request.
+type bearerFileTransport struct {
+	token func() (string, error)
+	next  http.RoundTripper
+}
+
+func (t *bearerFileTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	token, err := t.token()
+	if err != nil {
+		return nil, err
+	}
+	req = req.Clone(req.Context())
+	req.Header.Set("Authorization", "Bearer "+token)
+	return t.next.RoundTrip(req)
+}
@@ -0,0 +1,52 @@
+// Copyright 2017 The Prometheus Authors
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+package clientset
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestBasicAuthFileTransport(t *testing.T) {
+	file := filepath.Join(t.TempDir(), "password")
+	require.NoError(t, os.WriteFile(file, []byte("first\n"), 0o600))
+
+	var passwords []string
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		user, password, ok := req.BasicAuth()
+		require.True(t, ok)
+		require.Equal(t, "jiralert", user)
+		passwords = append(passwords, password)
+	}))
+	defer srv.Close()
+
+	client := &http.Client{Transport: &basicAuthFileTransport{user: "jiralert", password: secretFile(file), next: http.DefaultTransport}}
+	for _, rotated := range []string{"second", ""} {
+		resp, err := client.Get(srv.URL)
+		require.NoError(t, err)
+		resp.Body.Close()
+		if rotated != "" {
+			require.NoError(t, os.WriteFile(file, []byte(rotated), 0o600))
+		}
+	}
+	require.Equal(t, []string{"first", "second"}, passwords)
+
+	require.NoError(t, os.Remove(file))
+	_, err := client.Get(srv.URL)
+	require.Error(t, err)
+}
@@ -0,0 +1,123 @@
+// Copyright 2017 The Prometheus Authors
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package clientset
+
+import (
+	"crypto/tls"
+	"crypto/x509"
+	"net/http"
+	"os"
+	"sync"
+	"time"
+
+	"github.com/pkg/errors"
+	"github.com/prometheus-community/jiralert/pkg/config"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/propagation"
+)
+
+// defaultTimeout is the timeout of requests to Jira unless configured otherwise.
+const defaultTimeout = time.Minute
+
+// transports are shared by all clients with the same connection settings, so that connections are reused across
+// notifications.
+var (
+	transportsMtx sync.Mutex
+	transports    = map[transportKey]*http.Transport{}
+)
+
+type transportKey struct {
+	tls                 config.TLSConfig
+	idleConnTimeout     time.Duration
+	maxIdleConnsPerHost int
+}
+
+// baseTransport returns the transport for connections to Jira with the TLS and keep-alive settings of the given
+// receiver.
+func baseTransport(c *config.ReceiverConfig) (http.RoundTripper, error) {
+	if c.TLSConfig == nil && c.IdleConnTimeout == nil && c.MaxIdleConnsPerHost == nil {
+		return http.DefaultTransport, nil
+	}
+	t := http.DefaultTransport.(*http.Transport).Clone()
+	if c.IdleConnTimeout != nil {
+		t.IdleConnTimeout = time.Duration(*c.IdleConnTimeout)
+	}
+	if c.MaxIdleConnsPerHost != nil {
+		t.MaxIdleConnsPerHost = *c.MaxIdleConnsPerHost
+	}
+	key := transportKey{idleConnTimeout: t.IdleConnTimeout, maxIdleConnsPerHost: t.MaxIdleConnsPerHost}
+	if c.TLSConfig != nil {
+		key.tls = *c.TLSConfig
+	}
+
+	transportsMtx.Lock()
+	defer transportsMtx.Unlock()
+	if cached, ok := transports[key]; ok {
+		return cached, nil
+	}
+	if c.TLSConfig != nil {
+		tlsConfig, err := newTLSConfig(c.TLSConfig)
+		if err != nil {
+			return nil, errors.Wrapf(err, "bad tls_config in receiver %q", c.Name)
+		}
+		t.TLSClientConfig = tlsConfig
+	}
+	transports[key] = t
+	return t, nil
+}
+
+func newTLSConfig(c *config.TLSConfig) (*tls.Config, error) {
+	tlsConfig := &tls.Config{
+		InsecureSkipVerify: c.InsecureSkipVerify,
+		MinVersion:         config.TLSVersions[c.MinVersion],
+	}
+	if c.CAFile != "" {
+		b, err := os.ReadFile(c.CAFile)
+		if err != nil {
+			return nil, errors.Wrap(err, "read CA file")
+		}
+		pool := x509.NewCertPool()
+		if !pool.AppendCertsFromPEM(b) {
+			return nil, errors.Errorf("no certificates found in CA file %s", c.CAFile)
+		}
+		tlsConfig.RootCAs = pool
+	}
+	if c.CertFile != "" {
+		// Fail early on unusable certificates, then load them on each handshake so that renewed ones are picked up.
+		if _, err := tls.LoadX509KeyPair(c.CertFile, c.KeyFile); err != nil {
+			return nil, errors.Wrap(err, "load client certificate")
+		}
+		certFile, keyFile := c.CertFile, c.KeyFile
+		tlsConfig.GetClientCertificate = func(*tls.CertificateRequestInfo) (*tls.Certificate, error) {
+			cert, err := tls.LoadX509KeyPair(certFile, keyFile)
+			if err != nil {
+				return nil, errors.Wrap(err, "load client certificate")
+			}
+			return &cert, nil
+		}
+	}
+	return tlsConfig, nil
+}
+
+// propagationTransport adds the trace context of requests to their headers, so that traces of the webhook requests
+// causing them can be correlated with Jira's.
+type propagationTransport struct {
+	next http.RoundTripper
+}
+
+func (t *propagationTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	req = req.Clone(req.Context())
+	otel.GetTextMapPropagator().Inject(req.Context(), propagation.HeaderCarrier(req.Header))
+	return t.next.RoundTrip(req)
+}
@@ -0,0 +1,135 @@
+// Copyright 2017 The Prometheus Authors
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+package clientset
+
+import (
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/tls"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/pem"
+	"math/big"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/prometheus-community/jiralert/pkg/config"
+	"github.com/stretchr/testify/require"
+)
+
+// writeClientCert writes a self-signed client certificate and its key to dir.
+func writeClientCert(t *testing.T, dir string) (certFile, keyFile string) {
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	require.NoError(t, err)
+	tmpl := &x509.Certificate{
+		SerialNumber: big.NewInt(1),
+		Subject:      pkix.Name{CommonName: "jiralert"},
+		NotBefore:    time.Now().Add(-time.Hour),
+		NotAfter:     time.Now().Add(time.Hour),
+		ExtKeyUsage:  []x509.ExtKeyUsage{x509.ExtKeyUsageClientAuth},
+	}
+	der, err := x509.CreateCertificate(rand.Reader, tmpl, tmpl, &key.PublicKey, key)
+	require.NoError(t, err)
+	keyDER, err := x509.MarshalECPrivateKey(key)
+	require.NoError(t, err)
+
+	certFile, keyFile = filepath.Join(dir, "client.crt"), filepath.Join(dir, "client.key")
+	require.NoError(t, os.WriteFile(certFile, pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: der}), 0o600))
+	require.NoError(t, os.WriteFile(keyFile, pem.EncodeToMemory(&pem.Block{Type: "EC PRIVATE KEY", Bytes: keyDER}), 0o600))
+	return certFile, keyFile
+}
+
+func TestBaseTransport(t *testing.T) {
+	srv := httptest.NewUnstartedServer(http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		if len(req.TLS.PeerCertificates) == 0 || req.TLS.PeerCertificates[0].Subject.CommonName != "jiralert" {
+			w.WriteHeader(http.StatusUnauthorized)
+			return
+		}
+		w.WriteHeader(http.StatusNoContent)
+	}))
+	srv.TLS = &tls.Config{ClientAuth: tls.RequestClientCert}
+	srv.StartTLS()
+	defer srv.Close()
+
+	dir := t.TempDir()
+	caFile := filepath.Join(dir, "ca.crt")
+	require.NoError(t, os.WriteFile(caFile, pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: srv.Certificate().Raw}), 0o600))
+	certFile, keyFile := writeClientCert(t, dir)
+
+	get := func(c *config.TLSConfig) (*http.Response, error) {
+		transport, err := baseTransport(&config.ReceiverConfig{Name: "jira", TLSConfig: c})
+		require.NoError(t, err)
+		return (&http.Client{Transport: transport}).Get(srv.URL)
+	}
+
+	// The server certificate is not trusted by the system roots.
+	_, err := get(nil)
+	require.Error(t, err)
+
+	resp, err := get(&config.TLSConfig{CAFile: caFile})
+	require.NoError(t, err)
+	require.Equal(t, http.StatusUnauthorized, resp.StatusCode)
+
+	resp, err = get(&config.TLSConfig{CAFile: caFile, CertFile: certFile, KeyFile: keyFile, MinVersion: "TLS12"})
+	require.NoError(t, err)
+	require.Equal(t, http.StatusNoContent, resp.StatusCode)
+
+	resp, err = get(&config.TLSConfig{InsecureSkipVerify: true})
+	require.NoError(t, err)
+	require.Equal(t, http.StatusUnauthorized, resp.StatusCode)
+
+	// Transports are shared between clients with the same settings.
+	t1, err := baseTransport(&config.ReceiverConfig{TLSConfig: &config.TLSConfig{CAFile: caFile}})
+	require.NoError(t, err)
+	t2, err := baseTransport(&config.ReceiverConfig{TLSConfig: &config.TLSConfig{CAFile: caFile}})
+	require.NoError(t, err)
+	require.Same(t, t1, t2)
+
+	_, err = baseTransport(&config.ReceiverConfig{TLSConfig: &config.TLSConfig{CAFile: filepath.Join(dir, "missing.crt")}})
+	require.Error(t, err)
+}
+
+func TestBaseTransportKeepAlive(t *testing.T) {
+	transport, err := baseTransport(&config.ReceiverConfig{})
+	require.NoError(t, err)
+	require.Same(t, http.DefaultTransport, transport)
+
+	idle := config.Duration(30 * time.Second)
+	conns := 10
+	transport, err = baseTransport(&config.ReceiverConfig{IdleConnTimeout: &idle, MaxIdleConnsPerHost: &conns})
+	require.NoError(t, err)
+	require.Equal(t, 30*time.Second, transport.(*http.Transport).IdleConnTimeout)
+	require.Equal(t, 10, transport.(*http.Transport).MaxIdleConnsPerHost)
+}
+
+func TestClientTimeout(t *testing.T) {
+	release := make(chan struct{})
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		<-release
+	}))
+	defer srv.Close()
+	defer close(release)
+
+	timeout := config.Duration(50 * time.Millisecond)
+	client, err := New(&config.ReceiverConfig{Name: "jira", APIURL: srv.URL, User: "jiralert", Password: "JIRAlert", Timeout: &timeout})
+	require.NoError(t, err)
+
+	_, _, err = client.Get("ABC-1", nil)
+	require.Error(t, err)
+	require.Contains(t, err.Error(), "Client.Timeout exceeded")
+}
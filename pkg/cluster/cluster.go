@@ -0,0 +1,108 @@
+// Copyright 2017 The Prometheus Authors
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package cluster shards alert groups across JIRAlert replicas with rendezvous hashing, so that all replicas agree
+// on the owner of each alert group without coordinating, and only the owner writes its issues to Jira.
+package cluster
+
+import (
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"encoding/binary"
+	"errors"
+	"net"
+	"net/http"
+	"strings"
+	"syscall"
+	"time"
+
+	"github.com/prometheus-community/jiralert/pkg/config"
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+// ForwardedHeader marks webhook requests forwarded by another replica, which are handled rather than forwarded
+// again, even if the replicas disagree on the peers.
+const ForwardedHeader = "X-Jiralert-Forwarded-By"
+
+var forwardedTotal = prometheus.NewCounterVec(
+	prometheus.CounterOpts{
+		Name: "jiralert_cluster_forwarded_total",
+		Help: "Notifications forwarded to the replica owning their alert group, by peer and result.",
+	},
+	[]string{"peer", "result"},
+)
+
+func init() {
+	prometheus.MustRegister(forwardedTotal)
+}
+
+// Cluster assigns alert groups to the configured peers.
+type Cluster struct {
+	conf   *config.ClusterConfig
+	client *http.Client
+}
+
+// New creates a Cluster for the validated configuration.
+func New(c *config.ClusterConfig) *Cluster {
+	return &Cluster{conf: c, client: &http.Client{Timeout: time.Duration(*c.ForwardTimeout)}}
+}
+
+// Self returns the URL of this replica.
+func (c *Cluster) Self() string {
+	return c.conf.Self
+}
+
+// Owner returns the peer owning the alert group with the given receiver and group key. Adding or removing a peer
+// only moves the alert groups it owns.
+func (c *Cluster) Owner(receiver, groupKey string) string {
+	var owner string
+	var max uint64
+	for _, p := range c.conf.Peers {
+		sum := sha256.Sum256([]byte(p + "\x00" + receiver + "\x00" + groupKey))
+		if w := binary.BigEndian.Uint64(sum[:8]); owner == "" || w > max {
+			owner, max = p, w
+		}
+	}
+	return owner
+}
+
+// Forward sends the webhook request, whose body was already read, to the same path of peer, with the same headers
+// so that it passes authentication. The caller must close the body of the response.
+func (c *Cluster) Forward(ctx context.Context, peer string, req *http.Request, body []byte) (*http.Response, error) {
+	target := strings.TrimSuffix(peer, "/") + req.URL.RequestURI()
+	fwd, err := http.NewRequestWithContext(ctx, req.Method, target, bytes.NewReader(body))
+	if err != nil {
+		return nil, err
+	}
+	fwd.Header = req.Header.Clone()
+	fwd.Header.Set(ForwardedHeader, c.conf.Self)
+	resp, err := c.client.Do(fwd)
+	if err != nil {
+		forwardedTotal.WithLabelValues(peer, "failed").Inc()
+		return nil, err
+	}
+	forwardedTotal.WithLabelValues(peer, "forwarded").Inc()
+	return resp, nil
+}
+
+// Unreachable returns true if err, as returned by Forward, means that the peer could not be connected to. The request
+// was then never sent, so it can be handled by another replica without risking it being handled twice. Any other
+// error, such as a timeout waiting for the response, leaves it unknown whether the peer handled the request.
+func Unreachable(err error) bool {
+	var opErr *net.OpError
+	if errors.As(err, &opErr) && opErr.Op == "dial" {
+		return true
+	}
+	return errors.Is(err, syscall.ECONNREFUSED)
+}
@@ -0,0 +1,100 @@
+// Copyright 2017 The Prometheus Authors
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+package cluster
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/prometheus-community/jiralert/pkg/config"
+	"github.com/stretchr/testify/require"
+)
+
+func newTestCluster(self string, peers ...string) *Cluster {
+	timeout := config.Duration(time.Second)
+	return New(&config.ClusterConfig{Peers: peers, Self: self, ForwardTimeout: &timeout})
+}
+
+func TestOwner(t *testing.T) {
+	peers := []string{"http://jiralert-0:9097", "http://jiralert-1:9097", "http://jiralert-2:9097"}
+	a := newTestCluster(peers[0], peers...)
+	// Replicas agree regardless of the order of peers.
+	b := newTestCluster(peers[1], peers[2], peers[1], peers[0])
+	// Removing a peer only moves the alert groups it owned.
+	c := newTestCluster(peers[0], peers[:2]...)
+
+	owned := map[string]int{}
+	for i := 0; i < 3000; i++ {
+		key := fmt.Sprintf(`{}:{alertname="Alert%d"}`, i)
+		owner := a.Owner("jira", key)
+		owned[owner]++
+		require.Equal(t, owner, b.Owner("jira", key))
+		if owner != peers[2] {
+			require.Equal(t, owner, c.Owner("jira", key))
+		}
+	}
+	for _, p := range peers {
+		require.InDelta(t, 1000, owned[p], 150, p)
+	}
+}
+
+func TestForward(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, _ := io.ReadAll(r.Body)
+		require.Equal(t, "/alert", r.URL.Path)
+		require.Equal(t, "dry_run=false", r.URL.RawQuery)
+		require.Equal(t, "Bearer t0k3n", r.Header.Get("Authorization"))
+		require.Equal(t, "http://jiralert-0:9097", r.Header.Get(ForwardedHeader))
+		w.WriteHeader(http.StatusAccepted)
+		_, _ = w.Write(body)
+	}))
+	defer srv.Close()
+
+	c := newTestCluster("http://jiralert-0:9097", "http://jiralert-0:9097", srv.URL+"/")
+	req := httptest.NewRequest(http.MethodPost, "/alert?dry_run=false", nil)
+	req.Header.Set("Authorization", "Bearer t0k3n")
+	resp, err := c.Forward(context.Background(), srv.URL+"/", req, []byte(`{"version":"4"}`))
+	require.NoError(t, err)
+	defer resp.Body.Close()
+	body, err := io.ReadAll(resp.Body)
+	require.NoError(t, err)
+	require.Equal(t, http.StatusAccepted, resp.StatusCode)
+	require.Equal(t, `{"version":"4"}`, string(body))
+
+	srv.Close()
+	_, err = c.Forward(context.Background(), srv.URL, req, nil)
+	require.Error(t, err)
+	require.True(t, Unreachable(err))
+}
+
+func TestForwardSlowPeer(t *testing.T) {
+	release := make(chan struct{})
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		<-release
+	}))
+	defer srv.Close()
+	defer close(release)
+
+	timeout := config.Duration(50 * time.Millisecond)
+	c := New(&config.ClusterConfig{Peers: []string{"http://jiralert-0:9097", srv.URL}, Self: "http://jiralert-0:9097", ForwardTimeout: &timeout})
+	req := httptest.NewRequest(http.MethodPost, "/alert", nil)
+	_, err := c.Forward(context.Background(), srv.URL, req, []byte(`{"version":"4"}`))
+	require.Error(t, err)
+	// The peer may still handle the request.
+	require.False(t, Unreachable(err))
+}
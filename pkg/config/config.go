@@ -14,10 +14,13 @@
 package config
 
 import (
+	"crypto/tls"
+	"encoding/json"
 	"fmt"
 	"net/url"
 	"os"
 	"path/filepath"
+	"reflect"
 	"regexp"
 	"strconv"
 	"strings"
@@ -30,6 +33,19 @@ import (
 	yaml "gopkg.in/yaml.v3"
 )
 
+const (
+	// DefaultWebhookSignatureHeader is the header read for webhook signatures when none is configured.
+	DefaultWebhookSignatureHeader = "X-Jiralert-Signature"
+	// DefaultWebhookSignatureTolerance is the maximum accepted clock skew for timestamped webhook signatures.
+	DefaultWebhookSignatureTolerance = 5 * time.Minute
+	// DefaultTemplateHTTPTimeout is the timeout of template HTTP lookups when none is configured.
+	DefaultTemplateHTTPTimeout = 5 * time.Second
+	// DefaultTemplateHTTPCacheTTL is how long template HTTP lookups are cached when not configured.
+	DefaultTemplateHTTPCacheTTL = 5 * time.Minute
+	// DefaultClusterForwardTimeout is the timeout of notifications forwarded to other replicas when none is configured.
+	DefaultClusterForwardTimeout = 30 * time.Second
+)
+
 // Secret is a string that must not be revealed on marshaling.
 type Secret string
 
@@ -41,6 +57,14 @@ func (s Secret) MarshalYAML() (interface{}, error) {
 	return nil, nil
 }
 
+// MarshalJSON implements the json.Marshaler interface.
+func (s Secret) MarshalJSON() ([]byte, error) {
+	if s != "" {
+		return json.Marshal("<secret>")
+	}
+	return json.Marshal("")
+}
+
 // UnmarshalYAML implements the yaml.Unmarshaler interface for Secrets.
 func (s *Secret) UnmarshalYAML(unmarshal func(interface{}) error) error {
 	type plain Secret
@@ -57,15 +81,21 @@ func Load(s string) (*Config, error) {
 	return cfg, nil
 }
 
-// LoadFile parses the given YAML file into a Config.
+// LoadFile parses the given YAML file into a Config. If filename is a directory, the *.yml files it contains are
+// merged into one configuration, see mergeFiles.
 func LoadFile(filename string, logger log.Logger) (*Config, []byte, error) {
 	level.Info(logger).Log("msg", "loading configuration", "path", filename)
-	content, err := os.ReadFile(filename)
+	info, err := os.Stat(filename)
 	if err != nil {
 		return nil, nil, err
 	}
 
-	content, err = substituteEnvVars(content, logger)
+	var content []byte
+	if info.IsDir() {
+		content, err = mergeFiles(filename, logger)
+	} else {
+		content, err = readFile(filename, logger)
+	}
 	if err != nil {
 		return nil, nil, err
 	}
@@ -75,10 +105,102 @@ func LoadFile(filename string, logger log.Logger) (*Config, []byte, error) {
 		return nil, nil, err
 	}
 
-	resolveFilepaths(filepath.Dir(filename), cfg, logger)
+	baseDir := filepath.Dir(filename)
+	if info.IsDir() {
+		baseDir = filename
+	}
+	resolveFilepaths(baseDir, cfg, logger)
 	return cfg, content, nil
 }
 
+// readFile reads the configuration file, substituting env variables.
+func readFile(filename string, logger log.Logger) ([]byte, error) {
+	content, err := os.ReadFile(filename)
+	if err != nil {
+		return nil, err
+	}
+	return substituteEnvVars(content, logger)
+}
+
+// mergeFiles merges the *.yml files of the directory, in lexical order, into one configuration, so that e.g. the
+// defaults and the receivers of each team can live in files of their own. The receivers of all files are kept, while
+// other top-level keys may only be set in one file. Receiver names must be unique across files.
+func mergeFiles(dir string, logger log.Logger) ([]byte, error) {
+	paths, err := filepath.Glob(filepath.Join(dir, "*.yml"))
+	if err != nil {
+		return nil, err
+	}
+	if len(paths) == 0 {
+		return nil, fmt.Errorf("no *.yml configuration files in directory %s", dir)
+	}
+
+	merged := &yaml.Node{Kind: yaml.MappingNode}
+	var receivers *yaml.Node
+	keyFiles := map[string]string{}
+	receiverFiles := map[string]string{}
+	for _, path := range paths {
+		content, err := readFile(path, logger)
+		if err != nil {
+			return nil, err
+		}
+		var doc yaml.Node
+		if err := yaml.Unmarshal(content, &doc); err != nil {
+			return nil, fmt.Errorf("%s: %s", path, err)
+		}
+		if len(doc.Content) == 0 {
+			// Empty file.
+			continue
+		}
+		root := doc.Content[0]
+		if root.Kind != yaml.MappingNode {
+			return nil, fmt.Errorf("%s: configuration must be a mapping", path)
+		}
+		for i := 0; i+1 < len(root.Content); i += 2 {
+			key, value := root.Content[i], root.Content[i+1]
+			if key.Value != "receivers" {
+				if prev, ok := keyFiles[key.Value]; ok {
+					return nil, fmt.Errorf("%q is set in both %s and %s", key.Value, prev, path)
+				}
+				keyFiles[key.Value] = path
+				merged.Content = append(merged.Content, key, value)
+				continue
+			}
+			if value.Kind != yaml.SequenceNode {
+				return nil, fmt.Errorf("%s: receivers must be a list", path)
+			}
+			for _, rc := range value.Content {
+				if name := receiverName(rc); name != "" {
+					if prev, ok := receiverFiles[name]; ok {
+						return nil, fmt.Errorf("duplicate receiver %q in %s and %s", name, prev, path)
+					}
+					receiverFiles[name] = path
+				}
+			}
+			if receivers == nil {
+				receivers = value
+				merged.Content = append(merged.Content, key, value)
+				continue
+			}
+			receivers.Content = append(receivers.Content, value.Content...)
+		}
+		level.Debug(logger).Log("msg", "merged configuration file", "path", path)
+	}
+	return yaml.Marshal(merged)
+}
+
+// receiverName returns the name of the receiver node, if any.
+func receiverName(rc *yaml.Node) string {
+	if rc.Kind != yaml.MappingNode {
+		return ""
+	}
+	for i := 0; i+1 < len(rc.Content); i += 2 {
+		if rc.Content[i].Value == "name" {
+			return rc.Content[i+1].Value
+		}
+	}
+	return ""
+}
+
 // expand env variables $(var) from the config file
 // taken from https://github.dev/thanos-io/thanos/blob/296c4ab4baf2c8dd6abdf2649b0660ac77505e63/pkg/reloader/reloader.go#L445-L462 by https://github.com/fabxc
 func substituteEnvVars(b []byte, logger log.Logger) (r []byte, err error) {
@@ -113,11 +235,847 @@ func resolveFilepaths(baseDir string, cfg *Config, logger log.Logger) {
 	}
 
 	cfg.Template = join(cfg.Template)
+	for i, pattern := range cfg.Templates {
+		cfg.Templates[i] = join(pattern)
+	}
+	for _, rc := range append([]*ReceiverConfig{cfg.Defaults}, cfg.Receivers...) {
+		if rc == nil {
+			continue
+		}
+		rc.PasswordFile = join(rc.PasswordFile)
+		rc.PersonalAccessTokenFile = join(rc.PersonalAccessTokenFile)
+		rc.APITokenFile = join(rc.APITokenFile)
+		if rc.TLSConfig != nil {
+			rc.TLSConfig.CAFile = join(rc.TLSConfig.CAFile)
+			rc.TLSConfig.CertFile = join(rc.TLSConfig.CertFile)
+			rc.TLSConfig.KeyFile = join(rc.TLSConfig.KeyFile)
+		}
+	}
+}
+
+// TLSVersions maps the accepted values of TLSConfig.MinVersion to TLS versions.
+var TLSVersions = map[string]uint16{
+	"TLS10": tls.VersionTLS10,
+	"TLS11": tls.VersionTLS11,
+	"TLS12": tls.VersionTLS12,
+	"TLS13": tls.VersionTLS13,
+}
+
+// TLSConfig configures the TLS connections to Jira.
+type TLSConfig struct {
+	// CA certificates used to verify the server certificate. Optional (default: system roots).
+	CAFile string `yaml:"ca_file" json:"ca_file"`
+	// Client certificate and key, for mutual TLS. Optional.
+	CertFile string `yaml:"cert_file" json:"cert_file"`
+	KeyFile  string `yaml:"key_file" json:"key_file"`
+	// Disable verification of the server certificate.
+	InsecureSkipVerify bool `yaml:"insecure_skip_verify" json:"insecure_skip_verify"`
+	// Minimum TLS version, one of TLS10, TLS11, TLS12 or TLS13. Optional (default: TLS12).
+	MinVersion string `yaml:"min_version" json:"min_version"`
+}
+
+func checkTLSConfig(c *TLSConfig) error {
+	if c == nil {
+		return nil
+	}
+	if (c.CertFile == "") != (c.KeyFile == "") {
+		return fmt.Errorf("cert_file and key_file must be set together")
+	}
+	if _, ok := TLSVersions[c.MinVersion]; !ok && c.MinVersion != "" {
+		return fmt.Errorf("unknown min_version %q", c.MinVersion)
+	}
+	return nil
+}
+
+// DefaultOAuthTokenURL is the token endpoint of Atlassian's authorization server.
+const DefaultOAuthTokenURL = "https://auth.atlassian.com/oauth/token"
+
+// Supported Jira REST API versions, see ReceiverConfig.APIVersion.
+const (
+	APIVersion2 = 2
+	APIVersion3 = 3
+)
+
+// AllProjects, as an entry of ReceiverConfig.OtherProjects, searches issues in all projects.
+const AllProjects = "*"
+
+// Description renderers, see ReceiverConfig.Renderer.
+const (
+	RendererWiki     = "wiki"
+	RendererMarkdown = "markdown"
+	RendererPlain    = "plain"
+)
+
+// Types of rendered field values, see ReceiverConfig.FieldTypes.
+const (
+	FieldTypeString = "string"
+	FieldTypeNumber = "number"
+	FieldTypeJSON   = "json"
+)
+
+// Comment overflow modes, see ReceiverConfig.CommentOverflow.
+const (
+	CommentOverflowSplit    = "split"
+	CommentOverflowTruncate = "truncate"
+
+	// minCommentLength leaves room for the part header or truncation note.
+	minCommentLength = 100
+)
+
+// Identity strategies, see IdentityConfig.
+const (
+	IdentityHash  = "hash"
+	IdentityLabel = "label"
+	IdentityField = "field"
+)
+
+// IdentityConfig selects how the Jira issues of an alert group are identified.
+type IdentityConfig struct {
+	// One of hash (JIRALERT{...} label), label (legacy ALERT{...} label) or field (hash in a custom field). There is
+	// no issue property strategy, as JQL only searches issue properties indexed by a Jira app.
+	// Optional (default: hash or label, depending on -hash-jira-label).
+	Strategy string `yaml:"strategy" json:"strategy"`
+	// Custom field holding the identity, for the field strategy.
+	Field string `yaml:"field" json:"field"`
+	// Strategy previously in use. Issues identified by it are still found, and given the labels and fields of
+	// Strategy, easing migrations. Optional.
+	MigrateFrom string `yaml:"migrate_from" json:"migrate_from"`
+	// Flag to identify new issues using both strategies while migrating.
+	DualWrite *bool `yaml:"dual_write" json:"dual_write"`
+}
+
+// RateLimitConfig configures the rate of requests to a Jira instance.
+type RateLimitConfig struct {
+	// Sustained number of requests per second.
+	RequestsPerSecond float64 `yaml:"requests_per_second" json:"requests_per_second"`
+	// Number of requests that may be sent at once above the sustained rate. Optional (default: 1).
+	Burst int `yaml:"burst" json:"burst"`
+}
+
+// DefaultRetryStatusCodes are the status codes of the Jira responses retried by default: rate limiting, and Jira, or
+// a proxy in front of it, being unavailable.
+var DefaultRetryStatusCodes = []int{429, 500, 502, 503, 504}
+
+// RetryConfig classifies the errors of Jira requests as retriable, failing notifications with a 5xx status so that
+// Alertmanager sends them again, or not.
+type RetryConfig struct {
+	// Status codes of the Jira responses to retry on. Optional (default: 429, 500, 502, 503 and 504).
+	StatusCodes []int `yaml:"status_codes" json:"status_codes"`
+	// Flag to retry on network errors, e.g. timeouts and refused connections. Optional (default: true).
+	NetworkErrors *bool `yaml:"network_errors" json:"network_errors"`
+}
+
+// checkRetry validates the retry settings, if any, and sets their defaults.
+func checkRetry(c *RetryConfig) error {
+	if c == nil {
+		return nil
+	}
+	for _, code := range c.StatusCodes {
+		switch {
+		case code < 400 || code > 599:
+			return fmt.Errorf("status code %d is not an error status code", code)
+		case code == 400 || code == 401 || code == 403:
+			return fmt.Errorf("status code %d cannot be retried, as Jira rejects the same request again", code)
+		}
+	}
+	if c.StatusCodes == nil {
+		c.StatusCodes = DefaultRetryStatusCodes
+	}
+	if c.NetworkErrors == nil {
+		networkErrors := true
+		c.NetworkErrors = &networkErrors
+	}
+	return nil
+}
+
+// Keys alerts are sorted by with alert_order.
+const (
+	AlertOrderStartsAt = "starts_at"
+	AlertOrderSeverity = "severity"
+	AlertOrderLabels   = "labels"
+)
+
+// DefaultSeverities are the values of the severity label alerts are sorted by, from the most severe.
+var DefaultSeverities = []string{"critical", "error", "warning", "info"}
+
+// AlertOrderConfig sorts the alerts of notifications, and limits the number of alerts passed to templates, so that
+// templates list alerts in a deterministic order and don't grow with huge alert groups.
+type AlertOrderConfig struct {
+	// Keys to sort alerts by, in order of precedence: starts_at (oldest first), severity (most severe first) or labels
+	// (by their sorted label pairs). Prefix a key with - to reverse its order. Optional (default: [severity, starts_at,
+	// labels]).
+	SortBy []string `yaml:"sort_by" json:"sort_by"`
+	// Label holding the severity of alerts. Optional (default: severity).
+	SeverityLabel string `yaml:"severity_label" json:"severity_label"`
+	// Values of the severity label, from the most severe. Alerts with other values come last. Optional (default:
+	// critical, error, warning and info).
+	Severities []string `yaml:"severities" json:"severities"`
+	// Maximum number of alerts passed to templates. The others are left out of .Alerts and added to
+	// .TruncatedAlerts. Optional (default: all alerts).
+	Limit int `yaml:"limit" json:"limit"`
+}
+
+// checkAlertOrder validates the alert order settings, if any, and sets their defaults.
+func checkAlertOrder(c *AlertOrderConfig) error {
+	if c == nil {
+		return nil
+	}
+	for _, key := range c.SortBy {
+		switch strings.TrimPrefix(key, "-") {
+		case AlertOrderStartsAt, AlertOrderSeverity, AlertOrderLabels:
+		default:
+			return fmt.Errorf("unknown sort key %q, must be one of %s, %s or %s, optionally prefixed with -", key, AlertOrderStartsAt, AlertOrderSeverity, AlertOrderLabels)
+		}
+	}
+	if c.Limit < 0 {
+		return fmt.Errorf("limit must not be negative")
+	}
+	if c.SortBy == nil {
+		c.SortBy = []string{AlertOrderSeverity, AlertOrderStartsAt, AlertOrderLabels}
+	}
+	if c.SeverityLabel == "" {
+		c.SeverityLabel = "severity"
+	}
+	if c.Severities == nil {
+		c.Severities = DefaultSeverities
+	}
+	return nil
+}
+
+// ServiceDeskConfig configures the creation of Jira Service Management customer requests instead of plain issues.
+type ServiceDeskConfig struct {
+	ServiceDeskID string `yaml:"service_desk_id" json:"service_desk_id"`
+	RequestTypeID string `yaml:"request_type_id" json:"request_type_id"`
+	// Templated request field values, keyed by field ID. The rendered summary and description are used for the
+	// summary and description fields unless set here.
+	RequestFieldValues map[string]string `yaml:"request_field_values" json:"request_field_values"`
+}
+
+// FanOutConfig sends the alert groups of a receiver to other receivers too, e.g. to create issues in both the SRE
+// project and the project of the service's owners.
+type FanOutConfig struct {
+	// Names of the other receivers notified. Their own fan_out settings are not followed.
+	Receivers []string `yaml:"receivers" json:"receivers"`
+	// Name of the issue link type linking newly created issues of the other receivers with the receiver's issue, if
+	// in the same Jira instance, e.g. Relates. Optional (default: no links).
+	LinkType string `yaml:"link_type" json:"link_type"`
+}
+
+// FlapDetectionConfig marks issues reopened too often, so that noisy alerts stand out in Jira.
+type FlapDetectionConfig struct {
+	// An issue is flapping once it is reopened more than this many times within the window.
+	Reopens int      `yaml:"reopens" json:"reopens"`
+	Window  Duration `yaml:"window" json:"window"`
+	// Template of the comment added to flapping issues. Optional (default: a comment stating the number of reopens).
+	Comment string `yaml:"comment" json:"comment"`
+	// Template of the priority flapping issues are raised to. Optional (default: keep the priority).
+	Priority string `yaml:"priority" json:"priority"`
+	// Label added to flapping issues. Optional (default: none).
+	Label string `yaml:"label" json:"label"`
+}
+
+// checkFlapDetection validates the flap_detection settings, if any.
+func checkFlapDetection(c *FlapDetectionConfig) error {
+	if c == nil {
+		return nil
+	}
+	if c.Reopens <= 0 {
+		return fmt.Errorf("reopens must be positive")
+	}
+	if c.Window <= 0 {
+		return fmt.Errorf("window must be positive")
+	}
+	if strings.ContainsAny(c.Label, " \t\n") {
+		return fmt.Errorf("label %q cannot contain whitespace", c.Label)
+	}
+	return nil
+}
+
+// StaleIssuesConfig closes the open issues of alert groups no longer notified firing, e.g. as Alertmanager never sent
+// their resolved notification.
+type StaleIssuesConfig struct {
+	// Issues are stale once their alert group was not notified firing for this long, which must exceed the
+	// repeat_interval of Alertmanager.
+	After Duration `yaml:"after" json:"after"`
+	// State to transition stale issues into.
+	State string `yaml:"state" json:"state"`
+	// Comment added to stale issues before closing them. Optional (default: a comment stating how long the alert group
+	// was not notified).
+	Comment string `yaml:"comment" json:"comment"`
+}
+
+// checkStaleIssues validates the stale_issues settings, if any.
+func checkStaleIssues(c *StaleIssuesConfig) error {
+	if c == nil {
+		return nil
+	}
+	if c.After <= 0 {
+		return fmt.Errorf("after must be positive")
+	}
+	if c.State == "" {
+		return fmt.Errorf("state cannot be empty")
+	}
+	return nil
+}
+
+// RunbookLinkConfig adds the runbook of alert groups to their issues as a remote link, rather than only mentioning it
+// in descriptions.
+type RunbookLinkConfig struct {
+	// Annotation holding the URL of the runbook. Optional (default: runbook_url).
+	Annotation string `yaml:"annotation" json:"annotation"`
+	// Template of the title of the link. Optional (default: Runbook).
+	Title string `yaml:"title" json:"title"`
+	// URL of a 16x16 icon shown next to the link. Optional.
+	IconURL string `yaml:"icon_url" json:"icon_url"`
+}
+
+// checkRunbookLink validates the runbook_link settings, if any, and sets their defaults.
+func checkRunbookLink(c *RunbookLinkConfig) error {
+	if c == nil {
+		return nil
+	}
+	if c.IconURL != "" {
+		if u, err := url.Parse(c.IconURL); err != nil || (u.Scheme != "http" && u.Scheme != "https") {
+			return fmt.Errorf("icon_url %q must be an http or https URL", c.IconURL)
+		}
+	}
+	if c.Annotation == "" {
+		c.Annotation = "runbook_url"
+	}
+	if c.Title == "" {
+		c.Title = "Runbook"
+	}
+	return nil
+}
+
+// AlertmanagerLinkConfig adds to issues a remote link to the alerts of their alert group in the Alertmanager UI,
+// where they can also be silenced.
+type AlertmanagerLinkConfig struct {
+	// Template of the URL of the link. Optional (default: the alerts with the group labels at the external URL of
+	// the Alertmanager).
+	URL string `yaml:"url" json:"url"`
+	// Template of the title of the link. Optional (default: Alertmanager).
+	Title string `yaml:"title" json:"title"`
+	// URL of a 16x16 icon shown next to the link. Optional.
+	IconURL string `yaml:"icon_url" json:"icon_url"`
+}
+
+// checkAlertmanagerLink validates the alertmanager_link settings, if any, and sets their defaults.
+func checkAlertmanagerLink(c *AlertmanagerLinkConfig) error {
+	if c == nil {
+		return nil
+	}
+	if c.IconURL != "" {
+		if u, err := url.Parse(c.IconURL); err != nil || (u.Scheme != "http" && u.Scheme != "https") {
+			return fmt.Errorf("icon_url %q must be an http or https URL", c.IconURL)
+		}
+	}
+	if c.URL == "" {
+		c.URL = "{{ alertsURL .ExternalURL .GroupLabels }}"
+	}
+	if c.Title == "" {
+		c.Title = "Alertmanager"
+	}
+	return nil
+}
+
+// GraphConfig attaches to created issues an image of a graph of the alert group around its firing time, e.g. of a
+// Grafana panel rendered by Grafana's render API.
+type GraphConfig struct {
+	// Template of the URL of the image, e.g. https://grafana.example.com/render/d-solo/<uid>/<slug>?panelId=2. Its
+	// from, to, width and height query parameters are set to the time range and size of the graph.
+	URL string `yaml:"url" json:"url"`
+	// How long before the first firing alert started the graph begins. Optional (default: 1h).
+	Before *Duration `yaml:"before" json:"before"`
+	// Size of the image, in pixels. Optional (default: 800x400).
+	Width  int `yaml:"width" json:"width"`
+	Height int `yaml:"height" json:"height"`
+	// Name of the attachment. Optional (default: graph.png).
+	Filename string `yaml:"filename" json:"filename"`
+	// Timeout of rendering the image. Optional (default: 30s).
+	Timeout *Duration         `yaml:"timeout" json:"timeout"`
+	Headers map[string]Secret `yaml:"headers" json:"headers"`
+}
+
+// checkGraph validates the graph settings, if any, and sets their defaults.
+func checkGraph(c *GraphConfig) error {
+	if c == nil {
+		return nil
+	}
+	if c.URL == "" {
+		return fmt.Errorf("url cannot be empty")
+	}
+	if c.Width < 0 || c.Height < 0 {
+		return fmt.Errorf("width and height cannot be negative")
+	}
+	if c.Before == nil {
+		before := Duration(time.Hour)
+		c.Before = &before
+	}
+	if c.Width == 0 {
+		c.Width = 800
+	}
+	if c.Height == 0 {
+		c.Height = 400
+	}
+	if c.Filename == "" {
+		c.Filename = "graph.png"
+	}
+	if c.Timeout == nil {
+		timeout := Duration(30 * time.Second)
+		c.Timeout = &timeout
+	}
+	return nil
+}
+
+// WontFixSilenceConfig silences in Alertmanager the alert groups still firing once their issue was resolved as won't
+// fix.
+type WontFixSilenceConfig struct {
+	// How long alert groups are silenced for.
+	Duration Duration `yaml:"duration" json:"duration"`
+	// Creator of the silences. Optional (default: jiralert).
+	CreatedBy string `yaml:"created_by" json:"created_by"`
+}
+
+// checkWontFixSilence validates the wont_fix_silence settings, if any.
+func checkWontFixSilence(c *WontFixSilenceConfig) error {
+	if c == nil {
+		return nil
+	}
+	if c.Duration <= 0 {
+		return fmt.Errorf("duration must be positive")
+	}
+	if c.CreatedBy == "" {
+		c.CreatedBy = "jiralert"
+	}
+	return nil
+}
+
+// EscalationConfig raises the priority of issues while their alert group keeps firing.
+type EscalationConfig struct {
+	// Priorities from lowest to highest, e.g. [Low, Medium, High, Highest].
+	Priorities []string `yaml:"priorities" json:"priorities"`
+	// Open issues are raised one priority above that of the receiver for each of these durations their alert group has
+	// been firing for, since its earliest firing alert started, e.g. [4h, 24h]. Optional.
+	After []Duration `yaml:"after" json:"after"`
+	// Flag to raise issues one priority once they start flapping, unless flap_detection sets a priority. Optional
+	// (default: false).
+	Flapping bool `yaml:"flapping" json:"flapping"`
+	// Template of the comment added to escalated issues. Optional (default: a comment stating the new priority).
+	Comment string `yaml:"comment" json:"comment"`
+}
+
+// checkEscalation validates the escalation settings, if any.
+func checkEscalation(c *EscalationConfig) error {
+	if c == nil {
+		return nil
+	}
+	if len(c.Priorities) < 2 {
+		return fmt.Errorf("at least two priorities are required")
+	}
+	seen := map[string]struct{}{}
+	for _, p := range c.Priorities {
+		if _, ok := seen[p]; ok {
+			return fmt.Errorf("duplicate priority %q", p)
+		}
+		seen[p] = struct{}{}
+	}
+	if len(c.After) == 0 && !c.Flapping {
+		return fmt.Errorf("after or flapping is required")
+	}
+	for _, d := range c.After {
+		if d <= 0 {
+			return fmt.Errorf("after durations must be positive")
+		}
+	}
+	return nil
+}
+
+// PresetConfig overrides issue settings of a receiver for the notifications matching its matchers, e.g. to create
+// Bugs for critical alerts and Tasks for warnings.
+type PresetConfig struct {
+	// Matchers all the common labels of a notification must match, e.g. severity="critical" or team=~"db|storage".
+	Matchers []Matcher `yaml:"matchers" json:"matchers"`
+	// Templates of the issue type and priority, replacing those of the receiver if set.
+	IssueType string `yaml:"issue_type" json:"issue_type"`
+	Priority  string `yaml:"priority" json:"priority"`
+	// Labels replacing the static_labels of the receiver, if set.
+	StaticLabels []string `yaml:"static_labels" json:"static_labels"`
+	// Fields set on top of those of the receiver, replacing fields of the same name.
+	Fields map[string]interface{} `yaml:"fields" json:"fields"`
+}
+
+// Matches reports whether the labels match all matchers of the preset.
+func (p *PresetConfig) Matches(labels map[string]string) bool {
+	for _, m := range p.Matchers {
+		if !m.Matches(labels[m.Name]) {
+			return false
+		}
+	}
+	return true
+}
+
+// checkPresets validates the presets settings.
+func checkPresets(presets []*PresetConfig) error {
+	for i, p := range presets {
+		if len(p.Matchers) == 0 {
+			return fmt.Errorf("preset %d has no matchers", i)
+		}
+		for _, l := range p.StaticLabels {
+			if strings.ContainsAny(l, " \t\n") {
+				return fmt.Errorf("label %q of preset %d cannot contain whitespace", l, i)
+			}
+		}
+	}
+	return nil
+}
+
+// ScheduleConfig overrides the project or priority of a receiver within weekly time windows, e.g. to file alerts
+// firing out of business hours into the project of the NOC.
+type ScheduleConfig struct {
+	Name string `yaml:"name" json:"name"`
+	// IANA time zone of the windows, e.g. Europe/Berlin. Optional (default: UTC).
+	TimeZone string `yaml:"time_zone" json:"time_zone"`
+	// The schedule is active within any of these windows.
+	Windows []*ScheduleWindow `yaml:"windows" json:"windows"`
+	// Templates of the project and priority, replacing those of the receiver while the schedule is active.
+	Project  string `yaml:"project" json:"project"`
+	Priority string `yaml:"priority" json:"priority"`
+
+	location *time.Location
+}
+
+// UnmarshalYAML implements the yaml.Unmarshaler interface.
+func (s *ScheduleConfig) UnmarshalYAML(unmarshal func(interface{}) error) error {
+	type plain ScheduleConfig
+	if err := unmarshal((*plain)(s)); err != nil {
+		return err
+	}
+	loc, err := time.LoadLocation(s.TimeZone)
+	if err != nil {
+		return fmt.Errorf("unknown time zone %q of schedule %q", s.TimeZone, s.Name)
+	}
+	s.location = loc
+	return nil
+}
+
+// Active reports whether t is within one of the windows of the schedule.
+func (s *ScheduleConfig) Active(t time.Time) bool {
+	return inWindows(s.Windows, s.location, t)
+}
+
+// MaintenanceWindowConfig suppresses the creation and reopening of issues, e.g. during planned maintenance. Resolved
+// notifications are still handled.
+type MaintenanceWindowConfig struct {
+	Name string `yaml:"name" json:"name"`
+	// Matchers all the common labels of a notification must match, e.g. cluster="eu-1". Optional (default: all
+	// notifications).
+	Matchers []Matcher `yaml:"matchers" json:"matchers"`
+	// Start and end times of a one-off window, in RFC 3339. Optional (default: unbounded).
+	Start *time.Time `yaml:"start" json:"start,omitempty"`
+	End   *time.Time `yaml:"end" json:"end,omitempty"`
+	// IANA time zone of the weekly windows. Optional (default: UTC).
+	TimeZone string `yaml:"time_zone" json:"time_zone"`
+	// Weekly windows, e.g. every Sunday night. Optional (default: any time between start and end).
+	Windows []*ScheduleWindow `yaml:"windows" json:"windows"`
+
+	location *time.Location
+}
+
+// UnmarshalYAML implements the yaml.Unmarshaler interface.
+func (mw *MaintenanceWindowConfig) UnmarshalYAML(unmarshal func(interface{}) error) error {
+	type plain MaintenanceWindowConfig
+	if err := unmarshal((*plain)(mw)); err != nil {
+		return err
+	}
+	loc, err := time.LoadLocation(mw.TimeZone)
+	if err != nil {
+		return fmt.Errorf("unknown time zone %q of maintenance window %q", mw.TimeZone, mw.Name)
+	}
+	mw.location = loc
+	return nil
+}
+
+// Active reports whether the maintenance window applies to a notification with the given common labels at t.
+func (mw *MaintenanceWindowConfig) Active(labels map[string]string, t time.Time) bool {
+	if (mw.Start != nil && t.Before(*mw.Start)) || (mw.End != nil && !t.Before(*mw.End)) {
+		return false
+	}
+	if len(mw.Windows) > 0 && !inWindows(mw.Windows, mw.location, t) {
+		return false
+	}
+	for _, m := range mw.Matchers {
+		if !m.Matches(labels[m.Name]) {
+			return false
+		}
+	}
+	return true
+}
+
+// checkMaintenanceWindows validates the maintenance_windows settings.
+func checkMaintenanceWindows(windows []*MaintenanceWindowConfig) error {
+	names := map[string]struct{}{}
+	for _, mw := range windows {
+		if mw.Name == "" {
+			return fmt.Errorf("maintenance window names cannot be empty")
+		}
+		if _, ok := names[mw.Name]; ok {
+			return fmt.Errorf("duplicate maintenance window %q", mw.Name)
+		}
+		names[mw.Name] = struct{}{}
+		if mw.Start == nil && mw.End == nil && len(mw.Windows) == 0 {
+			return fmt.Errorf("maintenance window %q needs a start, an end or windows", mw.Name)
+		}
+		if mw.Start != nil && mw.End != nil && !mw.Start.Before(*mw.End) {
+			return fmt.Errorf("start of maintenance window %q must be before its end", mw.Name)
+		}
+	}
+	return nil
+}
+
+// inWindows reports whether t, in the given location, is within one of the windows.
+func inWindows(windows []*ScheduleWindow, loc *time.Location, t time.Time) bool {
+	if loc != nil {
+		t = t.In(loc)
+	}
+	for _, w := range windows {
+		if w.contains(t) {
+			return true
+		}
+	}
+	return false
+}
+
+// ScheduleWindow is a weekly time window, like Alertmanager's time intervals.
+type ScheduleWindow struct {
+	// Days of the week, or inclusive ranges of them, e.g. [monday:friday, sunday]. Optional (default: every day).
+	Weekdays []string `yaml:"weekdays" json:"weekdays"`
+	// Times of the day, from start_time included to end_time excluded, e.g. {start_time: "18:00", end_time: "24:00"}.
+	// Optional (default: the whole day).
+	Times []TimeRange `yaml:"times" json:"times"`
+
+	days    [7]bool
+	minutes [][2]int
+}
+
+// TimeRange is a range of times of the day, in HH:MM.
+type TimeRange struct {
+	StartTime string `yaml:"start_time" json:"start_time"`
+	EndTime   string `yaml:"end_time" json:"end_time"`
+}
+
+var weekdays = map[string]time.Weekday{
+	"sunday": time.Sunday, "monday": time.Monday, "tuesday": time.Tuesday, "wednesday": time.Wednesday,
+	"thursday": time.Thursday, "friday": time.Friday, "saturday": time.Saturday,
+}
+
+var timeOfDayRE = regexp.MustCompile(`^([01]?[0-9]|2[0-4]):([0-5][0-9])$`)
+
+// parseTimeOfDay returns the minutes since midnight of a HH:MM time, up to 24:00.
+func parseTimeOfDay(s string) (int, error) {
+	m := timeOfDayRE.FindStringSubmatch(s)
+	if m == nil {
+		return 0, fmt.Errorf("bad time of day %q, expected HH:MM", s)
+	}
+	h, _ := strconv.Atoi(m[1])
+	min, _ := strconv.Atoi(m[2])
+	if h == 24 && min != 0 {
+		return 0, fmt.Errorf("bad time of day %q, expected HH:MM", s)
+	}
+	return h*60 + min, nil
+}
+
+// UnmarshalYAML implements the yaml.Unmarshaler interface.
+func (w *ScheduleWindow) UnmarshalYAML(unmarshal func(interface{}) error) error {
+	type plain ScheduleWindow
+	if err := unmarshal((*plain)(w)); err != nil {
+		return err
+	}
+	if len(w.Weekdays) == 0 {
+		w.days = [7]bool{true, true, true, true, true, true, true}
+	}
+	for _, d := range w.Weekdays {
+		from, to, _ := strings.Cut(strings.ToLower(d), ":")
+		if to == "" {
+			to = from
+		}
+		first, ok := weekdays[from]
+		last, ok2 := weekdays[to]
+		if !ok || !ok2 {
+			return fmt.Errorf("bad weekday %q, expected a day of the week or a range like monday:friday", d)
+		}
+		for day := first; ; day = (day + 1) % 7 {
+			w.days[day] = true
+			if day == last {
+				break
+			}
+		}
+	}
+	for _, r := range w.Times {
+		start, err := parseTimeOfDay(r.StartTime)
+		if err != nil {
+			return err
+		}
+		end, err := parseTimeOfDay(r.EndTime)
+		if err != nil {
+			return err
+		}
+		if start >= end {
+			return fmt.Errorf("start_time %s must be before end_time %s", r.StartTime, r.EndTime)
+		}
+		w.minutes = append(w.minutes, [2]int{start, end})
+	}
+	return nil
+}
+
+func (w *ScheduleWindow) contains(t time.Time) bool {
+	if !w.days[t.Weekday()] {
+		return false
+	}
+	if len(w.minutes) == 0 {
+		return true
+	}
+	m := t.Hour()*60 + t.Minute()
+	for _, r := range w.minutes {
+		if m >= r[0] && m < r[1] {
+			return true
+		}
+	}
+	return false
+}
+
+// checkSchedules validates the schedules settings.
+func checkSchedules(schedules []*ScheduleConfig) error {
+	names := map[string]struct{}{}
+	for _, s := range schedules {
+		if s.Name == "" {
+			return fmt.Errorf("schedule names cannot be empty")
+		}
+		if _, ok := names[s.Name]; ok {
+			return fmt.Errorf("duplicate schedule %q", s.Name)
+		}
+		names[s.Name] = struct{}{}
+		if len(s.Windows) == 0 {
+			return fmt.Errorf("schedule %q has no windows", s.Name)
+		}
+		if s.Project == "" && s.Priority == "" {
+			return fmt.Errorf("schedule %q overrides neither project nor priority", s.Name)
+		}
+	}
+	return nil
+}
+
+// ManagedFieldsConfig names the custom fields JIRAlert keeps up to date with the firing history of the alert group of
+// each issue. All of them are optional.
+type ManagedFieldsConfig struct {
+	// Number field counting the alerts of the group which fired.
+	FireCount string `yaml:"fire_count" json:"fire_count"`
+	// Date time fields holding when an alert of the group first fired, and when one was last seen firing.
+	FirstSeen string `yaml:"first_seen" json:"first_seen"`
+	LastSeen  string `yaml:"last_seen" json:"last_seen"`
+	// Number field accumulating how long the resolved alerts of the group fired, in seconds.
+	FiringDuration string `yaml:"firing_duration" json:"firing_duration"`
+}
+
+// IDs returns the IDs of the managed fields, in the order of their settings.
+func (c *ManagedFieldsConfig) IDs() []string {
+	var ids []string
+	for _, id := range []string{c.FireCount, c.FirstSeen, c.LastSeen, c.FiringDuration} {
+		if id != "" {
+			ids = append(ids, id)
+		}
+	}
+	return ids
+}
+
+// checkManagedFields validates the managed_fields settings, if any.
+func checkManagedFields(c *ManagedFieldsConfig) error {
+	if c == nil {
+		return nil
+	}
+	ids := c.IDs()
+	if len(ids) == 0 {
+		return fmt.Errorf("no field configured")
+	}
+	seen := map[string]bool{}
+	for _, id := range ids {
+		if !strings.HasPrefix(id, "customfield_") {
+			return fmt.Errorf("%q is not a custom field ID", id)
+		}
+		if seen[id] {
+			return fmt.Errorf("field %q is used more than once", id)
+		}
+		seen[id] = true
+	}
+	return nil
+}
+
+// Secret providers, see SecretRef.
+const (
+	SecretProviderEnv   = "env"
+	SecretProviderFile  = "file"
+	SecretProviderVault = "vault"
+	SecretProviderAWS   = "aws"
+)
+
+// SecretRef references a secret held by a secret provider. Referenced secrets are resolved when first used and
+// renewed after RefreshInterval.
+type SecretRef struct {
+	// One of env, file, vault (HashiCorp Vault, addressed by VAULT_ADDR and VAULT_TOKEN) or aws (AWS Secrets
+	// Manager, using the credentials and region from the AWS_* environment variables).
+	Provider string `yaml:"provider" json:"provider"`
+	// Environment variable, file path, Vault secret path (e.g. secret/data/jiralert) or AWS secret ID.
+	Path string `yaml:"path" json:"path"`
+	// Field holding the value in secrets holding JSON objects, like Vault secrets. Optional for env, file and aws.
+	Key string `yaml:"key" json:"key,omitempty"`
+	// How long resolved values are cached. Optional (default: 5m, or the lease duration of Vault secrets).
+	RefreshInterval *Duration `yaml:"refresh_interval" json:"refresh_interval,omitempty"`
+}
+
+func checkSecretRef(ref *SecretRef) error {
+	switch ref.Provider {
+	case SecretProviderEnv, SecretProviderFile, SecretProviderAWS:
+	case SecretProviderVault:
+		if ref.Key == "" {
+			return fmt.Errorf("vault secrets require 'key'")
+		}
+	default:
+		return fmt.Errorf("unknown provider %q", ref.Provider)
+	}
+	if ref.Path == "" {
+		return fmt.Errorf("missing path")
+	}
+	return nil
+}
+
+// OAuthConfig configures OAuth 2.0 authentication, e.g. of an Atlassian OAuth 2.0 (3LO) app. The API URL of
+// receivers using it is usually https://api.atlassian.com/ex/jira/<cloud ID>.
+type OAuthConfig struct {
+	ClientID     string   `yaml:"client_id" json:"client_id"`
+	ClientSecret Secret   `yaml:"client_secret" json:"client_secret"`
+	TokenURL     string   `yaml:"token_url" json:"token_url"`
+	Scopes       []string `yaml:"scopes" json:"scopes"`
+	// Refresh token obtained when authorizing the app. When set, access tokens are requested with the
+	// refresh_token grant, otherwise with the client_credentials grant.
+	RefreshToken Secret `yaml:"refresh_token" json:"refresh_token"`
+}
+
+// ConnectConfig configures authentication as an Atlassian Connect app, with JWTs signed using the shared secret
+// the app received when it was installed on the Jira instance.
+type ConnectConfig struct {
+	// App key, used as the issuer of the JWTs.
+	Key          string `yaml:"key" json:"key"`
+	SharedSecret Secret `yaml:"shared_secret" json:"shared_secret"`
 }
 
 // AutoResolve is the struct used for defining jira resolution state when alert is resolved.
 type AutoResolve struct {
 	State string `yaml:"state" json:"state"`
+	// How long the alert group must stay resolved before its issue is resolved, so that flapping alerts don't move
+	// the issue back and forth. Optional (default: 0, resolve right away).
+	Delay Duration `yaml:"delay" json:"delay"`
+	// Flag to log how long the alert group fired as work on the issue when resolving it, e.g. for incident reports.
+	Worklog bool `yaml:"worklog" json:"worklog"`
 }
 
 // ReceiverConfig is the configuration for one receiver. It has a unique name and includes API access fields (url and
@@ -130,6 +1088,40 @@ type ReceiverConfig struct {
 	User                string `yaml:"user" json:"user"`
 	Password            Secret `yaml:"password" json:"password"`
 	PersonalAccessToken Secret `yaml:"personal_access_token" json:"personal_access_token"`
+	// Jira Cloud API token, used with the account email as user.
+	APIToken Secret `yaml:"api_token" json:"api_token"`
+	// Files holding the password, personal access token or API token, read on each request so that rotated
+	// credentials are picked up without a restart. Alternatives to the fields above.
+	PasswordFile            string `yaml:"password_file" json:"password_file"`
+	PersonalAccessTokenFile string `yaml:"personal_access_token_file" json:"personal_access_token_file"`
+	APITokenFile            string `yaml:"api_token_file" json:"api_token_file"`
+	// References to secrets held by a secret provider, set by giving password, personal_access_token or
+	// api_token as a mapping, e.g. password: {provider: vault, path: secret/data/jiralert, key: password}.
+	PasswordRef            *SecretRef `yaml:"-" json:"password_ref,omitempty"`
+	PersonalAccessTokenRef *SecretRef `yaml:"-" json:"personal_access_token_ref,omitempty"`
+	APITokenRef            *SecretRef `yaml:"-" json:"api_token_ref,omitempty"`
+	// Authenticate as an OAuth 2.0 app.
+	OAuth *OAuthConfig `yaml:"oauth" json:"oauth"`
+	// Authenticate as an installed Atlassian Connect app.
+	Connect *ConnectConfig `yaml:"connect" json:"connect"`
+	// Jira REST API version, 2 or 3. With 3, descriptions and comments are sent as ADF. Optional (default: 2).
+	APIVersion int `yaml:"api_version" json:"api_version"`
+	// TLS settings of connections to Jira. Optional (default: verify against the system roots).
+	TLSConfig *TLSConfig `yaml:"tls_config" json:"tls_config"`
+	// Timeout of requests to Jira, including reading the response. Optional (default: 1m).
+	Timeout *Duration `yaml:"timeout" json:"timeout"`
+	// How long idle keep-alive connections to Jira are kept open. Optional (default: 90s).
+	IdleConnTimeout *Duration `yaml:"idle_conn_timeout" json:"idle_conn_timeout"`
+	// Maximum number of idle keep-alive connections kept open to Jira. Optional (default: 2).
+	MaxIdleConnsPerHost *int `yaml:"max_idle_conns_per_host" json:"max_idle_conns_per_host"`
+	// Rate limit of requests to Jira, shared by all receivers with the same api_url. Optional (default: unlimited).
+	RateLimit *RateLimitConfig `yaml:"rate_limit" json:"rate_limit"`
+	// Settings classifying the errors of Jira requests as retriable. Optional (default: retry on 429, 500, 502, 503
+	// and 504 responses and on network errors).
+	Retry *RetryConfig `yaml:"retry" json:"retry"`
+	// Order of the alerts of notifications, and maximum number of alerts passed to templates. Optional (default: the
+	// order of Alertmanager, all alerts).
+	AlertOrder *AlertOrderConfig `yaml:"alert_order" json:"alert_order"`
 
 	// Required issue fields
 	Project        string    `yaml:"project" json:"project"`
@@ -138,32 +1130,177 @@ type ReceiverConfig struct {
 	Summary        string    `yaml:"summary" json:"summary"`
 	ReopenState    string    `yaml:"reopen_state" json:"reopen_state"`
 	ReopenDuration *Duration `yaml:"reopen_duration" json:"reopen_duration"`
+	// Project keys the rendered project must be one of, e.g. for templated projects. Optional (default: any key).
+	AllowedProjects []string `yaml:"allowed_projects" json:"allowed_projects"`
+	// Project of the alert groups whose rendered project is not a valid project key, e.g. as it rendered empty, or is
+	// not one of the allowed_projects. Optional (default: such notifications fail).
+	FallbackProject string `yaml:"fallback_project" json:"fallback_project"`
+	// Issue type of the issues Jira rejects the rendered issue type of, e.g. as the project does not have it. The
+	// issues are created again with this type, and commented. Optional (default: such notifications fail).
+	FallbackIssueType string `yaml:"fallback_issue_type" json:"fallback_issue_type"`
 
 	// Optional issue fields
-	Priority          string                 `yaml:"priority" json:"priority"`
-	Description       string                 `yaml:"description" json:"description"`
+	Priority    string `yaml:"priority" json:"priority"`
+	Description string `yaml:"description" json:"description"`
+	// Templated prefixes of the summary while alerts of the group fire and once all are resolved, e.g.
+	// "[FIRING:{{ len .Alerts.Firing }}] " and "[RESOLVED] ". Unlike the rest of the summary, they are also updated
+	// when reopening and resolving issues if update_summary is disabled.
+	SummaryFiringPrefix   string `yaml:"summary_firing_prefix" json:"summary_firing_prefix"`
+	SummaryResolvedPrefix string `yaml:"summary_resolved_prefix" json:"summary_resolved_prefix"`
+	// Markup dialect the description template is written in: wiki (Jira wiki markup), markdown or plain.
+	// Optional (default: wiki).
+	Renderer          string                 `yaml:"renderer" json:"renderer"`
 	WontFixResolution string                 `yaml:"wont_fix_resolution" json:"wont_fix_resolution"`
 	Fields            map[string]interface{} `yaml:"fields" json:"fields"`
-	Components        []string               `yaml:"components" json:"components"`
-	StaticLabels      []string               `yaml:"static_labels" json:"static_labels"`
+	// Settings adding the runbook of the alert group to issues as a remote link. Optional (default: no link).
+	RunbookLink *RunbookLinkConfig `yaml:"runbook_link" json:"runbook_link"`
+	// Settings adding the alerts of the group in the Alertmanager UI to issues as a remote link. Optional (default: no
+	// link).
+	AlertmanagerLink *AlertmanagerLinkConfig `yaml:"alertmanager_link" json:"alertmanager_link"`
+	// Settings attaching a graph of the alert group to created issues. Optional (default: no graph).
+	Graph *GraphConfig `yaml:"graph" json:"graph"`
+	// Settings silencing in Alertmanager the alert groups of issues resolved as won't fix, which requires the
+	// alertmanager_url. Optional (default: no silences).
+	WontFixSilence *WontFixSilenceConfig `yaml:"wont_fix_silence" json:"wont_fix_silence"`
+	// Types the rendered values of fields are converted to, by field: string (default), number, or json for
+	// templates emitting JSON arrays or objects. Optional.
+	FieldTypes   map[string]string `yaml:"field_types" json:"field_types"`
+	Components   []string          `yaml:"components" json:"components"`
+	Environment  string            `yaml:"environment" json:"environment"`
+	StaticLabels []string          `yaml:"static_labels" json:"static_labels"`
+
+	// Templates of the assignee, reporter and watchers of created issues: email addresses or usernames, resolved to
+	// Jira users, e.g. to their account IDs on Jira Cloud, with the user search API. Users which are not found are
+	// logged and left out. Optional.
+	Assignee string   `yaml:"assignee" json:"assignee"`
+	Reporter string   `yaml:"reporter" json:"reporter"`
+	Watchers []string `yaml:"watchers" json:"watchers"`
+
+	// Existing issues with any of these labels or statuses are left alone: not updated, reopened nor resolved, e.g.
+	// so that people can take over an issue. Optional.
+	IgnoreLabels   []string `yaml:"ignore_labels" json:"ignore_labels"`
+	IgnoreStatuses []string `yaml:"ignore_statuses" json:"ignore_statuses"`
+
+	// Jira Service Management settings. Optional (default: create plain issues).
+	ServiceDesk *ServiceDeskConfig `yaml:"service_desk" json:"service_desk"`
+
+	// Other receivers notified of the same alert groups. Optional.
+	FanOut *FanOutConfig `yaml:"fan_out" json:"fan_out"`
+	// URL of the Alertmanager notifying the receiver, whose API is polled for the alert groups firing for it, to
+	// create their missing issues and resolve those of alert groups no longer firing. Optional (default: rely on
+	// webhooks only).
+	AlertmanagerURL string `yaml:"alertmanager_url" json:"alertmanager_url"`
+	// URL of the Prometheus server the query and queryRange template functions query, e.g. to include the current
+	// value of the alerting expression in descriptions. Optional (default: the functions fail).
+	PrometheusURL string `yaml:"prometheus_url" json:"prometheus_url"`
+
+	// Group identity settings
+	Identity *IdentityConfig `yaml:"identity" json:"identity"`
 
 	// Label copy settings
 	AddGroupLabels *bool `yaml:"add_group_labels" json:"add_group_labels"`
+	// Only group labels with names matching any of these are copied (default: all).
+	LabelInclude []Regexp `yaml:"label_include" json:"label_include"`
+	// Group labels with names matching any of these are not copied.
+	LabelExclude []Regexp `yaml:"label_exclude" json:"label_exclude"`
+	// Go template rendering each copied label, executed with .Name and .Value. Optional (default: name="value").
+	LabelFormat string `yaml:"label_format" json:"label_format"`
+	// Flag to keep copied group labels in sync on existing issues, not only at creation.
+	SyncGroupLabels *bool `yaml:"sync_group_labels" json:"sync_group_labels"`
+	// Flag to keep a jiralert:firing or jiralert:resolved label on issues, matching the state of their alert group on
+	// every notification, so that JQL can select the issues of firing alerts.
+	StatusLabel *bool `yaml:"status_label" json:"status_label"`
 
 	// Flag to enable updates in comments.
 	UpdateInComment *bool `yaml:"update_in_comment" json:"update_in_comment"`
+	// Maximum length of a single comment. Optional (default: 32767, Jira's limit).
+	MaxCommentLength *int `yaml:"max_comment_length" json:"max_comment_length"`
+	// What to do with comments exceeding MaxCommentLength: split them into numbered parts or truncate them.
+	// Optional (default: split).
+	CommentOverflow string `yaml:"comment_overflow" json:"comment_overflow"`
+
+	// Flag to keep the environment field up to date on existing issues.
+	UpdateEnvironment *bool `yaml:"update_environment" json:"update_environment"`
+	// Minimum time between updates of the summary, description, environment and comments of an issue, to avoid
+	// editing it on every repeated notification. Optional (default: 0, no limit).
+	MinUpdateInterval *Duration `yaml:"min_update_interval" json:"min_update_interval"`
+	// How long to remember which issue, if any, the search for an alert group found, fetching the issue by key or
+	// skipping Jira on repeated notifications instead of searching again. Optional (default: 0, always search).
+	SearchCacheTTL *Duration `yaml:"search_cache_ttl" json:"search_cache_ttl"`
+	// How long to remember the workflow transitions available from a status, by project and issue type, instead of
+	// fetching them before every reopen and resolve. Optional (default: 0, always fetch).
+	TransitionCacheTTL *Duration `yaml:"transition_cache_ttl" json:"transition_cache_ttl"`
+	// How long to gather the issues to create, e.g. during alert storms, into one request to Jira's bulk create API of
+	// up to 50 issues. Optional (default: 0, create issues one by one).
+	BulkCreateWait *Duration `yaml:"bulk_create_wait" json:"bulk_create_wait"`
+	// Flag to set the priority of existing issues without one, e.g. created before the priority was configured.
+	SetMissingPriority *bool `yaml:"set_missing_priority" json:"set_missing_priority"`
+	// Flag to stop updating the summary, description and environment of issues once they are in progress, so that
+	// edits of the people working on them are not overwritten. Reopening and resolving still apply.
+	FreezeInProgress *bool `yaml:"freeze_in_progress" json:"freeze_in_progress"`
+	// Flag to wrap descriptions in {jiralert:start} and {jiralert:end} markers and, on update, only replace the text
+	// between them, keeping what people wrote around it.
+	ManagedDescription *bool `yaml:"managed_description" json:"managed_description"`
+	// Flag to comment on issues when Alertmanager left alerts out of the notification, as the alert group exceeded
+	// the max_alerts of the webhook configuration, so that responders know the issue is incomplete.
+	TruncatedAlertsComment *bool `yaml:"truncated_alerts_comment" json:"truncated_alerts_comment"`
+	// Maximum number of alerts passed to the description template, firing alerts first. The others are left out like
+	// alerts Alertmanager left out due to max_alerts, bounding the time and memory rendering descriptions of huge alert
+	// groups takes. Optional (default: all alerts).
+	MaxAlertsInDescription *int `yaml:"max_alerts_in_description" json:"max_alerts_in_description"`
+
+	// Overrides of the corresponding command line flags. Optional (default: flag value).
+	HashJiraLabel        *bool `yaml:"hash_jira_label" json:"hash_jira_label"`
+	UpdateSummary        *bool `yaml:"update_summary" json:"update_summary"`
+	UpdateDescription    *bool `yaml:"update_description" json:"update_description"`
+	ReopenTickets        *bool `yaml:"reopen_tickets" json:"reopen_tickets"`
+	MaxDescriptionLength *int  `yaml:"max_description_length" json:"max_description_length"`
 
 	// Flag to auto-resolve opened issue when the alert is resolved.
 	AutoResolve *AutoResolve `yaml:"auto_resolve" json:"auto_resolve"`
 
+	// Overrides of the issue type, priority, static labels and fields for notifications matching the matchers of a
+	// preset. The first matching preset applies. Optional.
+	Presets []*PresetConfig `yaml:"presets" json:"presets"`
+	// Overrides of the project and priority within weekly time windows. The first active schedule applies, after
+	// presets. Optional.
+	Schedules []*ScheduleConfig `yaml:"schedules" json:"schedules"`
+	// Windows during which firing notifications neither create nor reopen issues. Optional.
+	MaintenanceWindows []*MaintenanceWindowConfig `yaml:"maintenance_windows" json:"maintenance_windows"`
+
+	// Flapping issue settings. Optional (default: no flap detection).
+	FlapDetection *FlapDetectionConfig `yaml:"flap_detection" json:"flap_detection"`
+	// Priority escalation settings. Optional (default: no escalation).
+	Escalation *EscalationConfig `yaml:"escalation" json:"escalation"`
+	// Settings closing the issues of alert groups no longer notified. Optional (default: issues stay open until
+	// resolved).
+	StaleIssues *StaleIssuesConfig `yaml:"stale_issues" json:"stale_issues"`
+
+	// Custom fields kept up to date with the firing history of alert groups. Optional.
+	ManagedFields *ManagedFieldsConfig `yaml:"managed_fields" json:"managed_fields"`
+
+	// Flag to comment on the issue instead of failing the notification when a reopen or auto-resolve transition
+	// is not available from the issue's current state.
+	CommentOnTransitionFailure *bool `yaml:"comment_on_transition_failure" json:"comment_on_transition_failure"`
+
+	// Flag to fail rendering templates referencing missing map keys, e.g. misspelled labels, instead of rendering
+	// them empty. Optional (default: false).
+	TemplateStrict *bool `yaml:"template_strict" json:"template_strict"`
+	// IANA time zone of times formatted by the dateFormat template function, e.g. Europe/Berlin. Optional
+	// (default: UTC).
+	DefaultTimezone string `yaml:"default_timezone" json:"default_timezone"`
+
 	// Catches all undefined fields and must be empty after parsing.
 	XXX map[string]interface{} `yaml:",inline" json:"-"`
 }
 
 // UnmarshalYAML implements the yaml.Unmarshaler interface.
-func (rc *ReceiverConfig) UnmarshalYAML(unmarshal func(interface{}) error) error {
+func (rc *ReceiverConfig) UnmarshalYAML(node *yaml.Node) error {
+	if err := rc.extractSecretRefs(node); err != nil {
+		return err
+	}
 	type plain ReceiverConfig
-	if err := unmarshal((*plain)(rc)); err != nil {
+	if err := node.Decode((*plain)(rc)); err != nil {
 		return err
 	}
 	// Recursively convert any maps to map[string]interface{}, filtering out all non-string keys, so the json encoder
@@ -173,19 +1310,239 @@ func (rc *ReceiverConfig) UnmarshalYAML(unmarshal func(interface{}) error) error
 		return err
 	}
 	rc.Fields = fieldsWithStringKeys
+	for _, p := range rc.Presets {
+		if p.Fields, err = tcontainer.ConvertToMarshalMap(p.Fields, func(v string) string { return v }); err != nil {
+			return err
+		}
+	}
 	return checkOverflow(rc.XXX, "receiver")
 }
 
+// extractSecretRefs sets the references of secrets given as mappings instead of values, replacing them with empty
+// values in node.
+func (rc *ReceiverConfig) extractSecretRefs(node *yaml.Node) error {
+	if node.Kind != yaml.MappingNode {
+		return nil
+	}
+	content := node.Content
+	for i := 0; i+1 < len(content); i += 2 {
+		var ref **SecretRef
+		switch content[i].Value {
+		case "password":
+			ref = &rc.PasswordRef
+		case "personal_access_token":
+			ref = &rc.PersonalAccessTokenRef
+		case "api_token":
+			ref = &rc.APITokenRef
+		default:
+			continue
+		}
+		if content[i+1].Kind != yaml.MappingNode {
+			continue
+		}
+		*ref = &SecretRef{}
+		if err := content[i+1].Decode(*ref); err != nil {
+			return err
+		}
+		if err := checkSecretRef(*ref); err != nil {
+			return fmt.Errorf("bad %s reference: %s", content[i].Value, err)
+		}
+		content[i+1] = &yaml.Node{Kind: yaml.ScalarNode, Tag: "!!str"}
+	}
+	return nil
+}
+
+// WebhookSignatureConfig configures HMAC verification of incoming webhook payloads, as added by a signing proxy
+// placed in front of JIRAlert.
+type WebhookSignatureConfig struct {
+	// Shared secret used as the HMAC key.
+	Secret Secret `yaml:"secret" json:"secret"`
+	// Header carrying the hex encoded signature, optionally prefixed with "<algorithm>=".
+	Header string `yaml:"header" json:"header"`
+	// Hash algorithm, one of sha1, sha256 or sha512.
+	Algorithm string `yaml:"algorithm" json:"algorithm"`
+	// Optional header carrying the unix timestamp of the signature. When set, the signed payload is
+	// "<timestamp>.<body>" and requests older (or newer) than Tolerance are rejected as replays.
+	TimestampHeader string    `yaml:"timestamp_header" json:"timestamp_header"`
+	Tolerance       *Duration `yaml:"tolerance" json:"tolerance"`
+}
+
+// WebhookAuthConfig configures authentication of incoming webhook requests, matching the http_config of
+// Alertmanager webhook receivers. Only one of BasicAuth and BearerToken may be set.
+type WebhookAuthConfig struct {
+	BasicAuth   *BasicAuthConfig `yaml:"basic_auth" json:"basic_auth"`
+	BearerToken Secret           `yaml:"bearer_token" json:"bearer_token"`
+}
+
+// BasicAuthConfig holds HTTP basic authentication credentials.
+type BasicAuthConfig struct {
+	Username string `yaml:"username" json:"username"`
+	Password Secret `yaml:"password" json:"password"`
+}
+
+// TracingConfig configures exporting OpenTelemetry traces to an OTLP/HTTP collector.
+type TracingConfig struct {
+	// Endpoint is the host:port of the collector.
+	Endpoint string `yaml:"endpoint" json:"endpoint"`
+	// Insecure disables TLS.
+	Insecure bool `yaml:"insecure" json:"insecure"`
+	// SamplingFraction of webhook requests traced, between 0 and 1 (default). Requests carrying a sampled trace
+	// context are traced regardless.
+	SamplingFraction *float64          `yaml:"sampling_fraction" json:"sampling_fraction"`
+	Headers          map[string]Secret `yaml:"headers" json:"headers"`
+	Timeout          *Duration         `yaml:"timeout" json:"timeout"`
+}
+
+// ClusterConfig shards alert groups across JIRAlert replicas, so that each alert group is only handled by one of
+// them. Replicas forward notifications of alert groups they don't own to the owner.
+type ClusterConfig struct {
+	// Peers are the base URLs of all replicas, including this one, e.g. http://jiralert-0.jiralert:9097.
+	Peers []string `yaml:"peers" json:"peers"`
+	// Self is the URL of this replica in Peers, usually set through environment variables.
+	Self string `yaml:"self" json:"self"`
+	// ForwardTimeout of notifications forwarded to their owner. Optional (default: 30s).
+	ForwardTimeout *Duration `yaml:"forward_timeout" json:"forward_timeout"`
+}
+
+// TemplateHTTPConfig enables the httpGet and jsonLookup template functions, fetching data from the allowed hosts
+// only.
+type TemplateHTTPConfig struct {
+	// AllowedHosts are the host names, or host:port, templates may fetch from. A leading *. matches subdomains.
+	AllowedHosts []string `yaml:"allowed_hosts" json:"allowed_hosts"`
+	// Timeout of requests. Optional (default: 5s).
+	Timeout *Duration `yaml:"timeout" json:"timeout"`
+	// How long responses are reused for. Optional (default: 5m).
+	CacheTTL *Duration         `yaml:"cache_ttl" json:"cache_ttl"`
+	Headers  map[string]Secret `yaml:"headers" json:"headers"`
+}
+
+// MapConfig is a lookup table of the lookup template function, e.g. mapping the values of the team label to the IDs of
+// the options of a custom field, or regions to components.
+type MapConfig struct {
+	// Values by key.
+	Values map[string]string `yaml:"values" json:"values"`
+	// Value of the keys missing from values. Optional (default: empty).
+	Default string `yaml:"default" json:"default"`
+}
+
+// IngestConfig is an endpoint converting arbitrary JSON payloads, e.g. of CloudWatch alarms sent through SNS or of
+// custom scripts, into Alertmanager notifications.
+type IngestConfig struct {
+	// Name of the endpoint, served on /ingest/<name>.
+	Name string `yaml:"name" json:"name"`
+	// Go template rendering the Alertmanager webhook payload, as JSON, from the request body parsed as JSON.
+	Template string `yaml:"template" json:"template"`
+	// Receiver handling the notifications whose rendered payload has none. Optional.
+	Receiver string `yaml:"receiver" json:"receiver"`
+}
+
+// ingestNameRE matches the names of ingest endpoints, which are URL path segments.
+var ingestNameRE = regexp.MustCompile(`^[A-Za-z0-9_.-]+$`)
+
+// projectKeyRE matches Jira project keys, e.g. OPS or OPS2.
+var projectKeyRE = regexp.MustCompile(`^[A-Za-z][A-Za-z0-9_]*$`)
+
+// ValidProjectKey reports whether key has the format of Jira project keys.
+func ValidProjectKey(key string) bool {
+	return projectKeyRE.MatchString(key)
+}
+
 // Config is the top-level configuration for JIRAlert's config file.
 type Config struct {
 	Defaults  *ReceiverConfig   `yaml:"defaults,omitempty" json:"defaults,omitempty"`
 	Receivers []*ReceiverConfig `yaml:"receivers,omitempty" json:"receivers,omitempty"`
 	Template  string            `yaml:"template" json:"template"`
+	// Glob patterns of further template files, e.g. to split template libraries per team.
+	Templates []string `yaml:"templates,omitempty" json:"templates,omitempty"`
+
+	// Optional verification of signed webhook requests.
+	WebhookSignature *WebhookSignatureConfig `yaml:"webhook_signature,omitempty" json:"webhook_signature,omitempty"`
+	// Optional authentication of webhook requests.
+	WebhookAuth *WebhookAuthConfig `yaml:"webhook_auth,omitempty" json:"webhook_auth,omitempty"`
+	// Optional export of traces of webhook requests and Jira calls.
+	Tracing *TracingConfig `yaml:"tracing,omitempty" json:"tracing,omitempty"`
+	// Optional lookups of external data from templates.
+	TemplateHTTP *TemplateHTTPConfig `yaml:"template_http,omitempty" json:"template_http,omitempty"`
+	// Optional sharding of alert groups across replicas.
+	Cluster *ClusterConfig `yaml:"cluster,omitempty" json:"cluster,omitempty"`
+	// Optional endpoints converting arbitrary JSON payloads into notifications.
+	Ingest []*IngestConfig `yaml:"ingest,omitempty" json:"ingest,omitempty"`
+	// Optional lookup tables of the lookup template function, by name.
+	Maps map[string]*MapConfig `yaml:"maps,omitempty" json:"maps,omitempty"`
 
 	// Catches all undefined fields and must be empty after parsing.
 	XXX map[string]interface{} `yaml:",inline" json:"-"`
 }
 
+// authMethods returns the number of authentication methods configured in rc.
+func authMethods(rc *ReceiverConfig) int {
+	n := 0
+	for _, set := range []bool{
+		rc.Password != "" || rc.PasswordFile != "" || rc.PasswordRef != nil,
+		rc.APIToken != "" || rc.APITokenFile != "" || rc.APITokenRef != nil,
+		hasPAT(rc),
+		rc.OAuth != nil,
+		rc.Connect != nil,
+	} {
+		if set {
+			n++
+		}
+	}
+	return n
+}
+
+func hasBasicAuthSecret(rc *ReceiverConfig) bool {
+	return rc.Password != "" || rc.PasswordFile != "" || rc.PasswordRef != nil ||
+		rc.APIToken != "" || rc.APITokenFile != "" || rc.APITokenRef != nil
+}
+
+func hasPAT(rc *ReceiverConfig) bool {
+	return rc.PersonalAccessToken != "" || rc.PersonalAccessTokenFile != "" || rc.PersonalAccessTokenRef != nil
+}
+
+// checkSecretFiles returns an error if both a secret and the file holding it are set.
+func checkSecretFiles(rc *ReceiverConfig) error {
+	for _, f := range []struct {
+		name   string
+		secret bool
+		file   string
+	}{
+		{"password", rc.Password != "" || rc.PasswordRef != nil, rc.PasswordFile},
+		{"personal_access_token", rc.PersonalAccessToken != "" || rc.PersonalAccessTokenRef != nil, rc.PersonalAccessTokenFile},
+		{"api_token", rc.APIToken != "" || rc.APITokenRef != nil, rc.APITokenFile},
+	} {
+		if f.secret && f.file != "" {
+			return fmt.Errorf("%s and %s_file are mutually exclusive", f.name, f.name)
+		}
+	}
+	return nil
+}
+
+func checkOAuth(c *OAuthConfig) error {
+	if c == nil {
+		return nil
+	}
+	if c.ClientID == "" || c.ClientSecret == "" {
+		return fmt.Errorf("client_id and client_secret are required")
+	}
+	if c.TokenURL == "" {
+		c.TokenURL = DefaultOAuthTokenURL
+	}
+	if _, err := url.Parse(c.TokenURL); err != nil {
+		return fmt.Errorf("invalid token_url %q: %s", c.TokenURL, err)
+	}
+	return nil
+}
+
+// TemplateFiles returns the template file and the glob patterns of further template files.
+func (c *Config) TemplateFiles() []string {
+	var files []string
+	if c.Template != "" {
+		files = append(files, c.Template)
+	}
+	return append(files, c.Templates...)
+}
+
 func (c Config) String() string {
 	b, err := yaml.Marshal(c)
 	if err != nil {
@@ -210,13 +1567,69 @@ func (c *Config) UnmarshalYAML(unmarshal func(interface{}) error) error {
 	if (c.Defaults.User != "" || c.Defaults.Password != "") && c.Defaults.PersonalAccessToken != "" {
 		return fmt.Errorf("bad auth config in defaults section: user/password and PAT authentication are mutually exclusive")
 	}
+	if err := checkSecretFiles(c.Defaults); err != nil {
+		return fmt.Errorf("bad auth config in defaults section: %s", err)
+	}
+	if authMethods(c.Defaults) > 1 {
+		return fmt.Errorf("bad auth config in defaults section: only one of password, api_token, personal_access_token, oauth and connect may be set")
+	}
+	if err := checkOAuth(c.Defaults.OAuth); err != nil {
+		return fmt.Errorf("bad oauth config in defaults section: %s", err)
+	}
+	if c.Defaults.Connect != nil && (c.Defaults.Connect.Key == "" || c.Defaults.Connect.SharedSecret == "") {
+		return fmt.Errorf("bad connect config in defaults section: key and shared_secret are required")
+	}
+
+	if err := checkTLSConfig(c.Defaults.TLSConfig); err != nil {
+		return fmt.Errorf("bad tls_config in defaults section: %s", err)
+	}
 
 	if c.Defaults.AutoResolve != nil {
 		if c.Defaults.AutoResolve.State == "" {
 			return fmt.Errorf("bad config in defaults section: state cannot be empty")
 		}
 	}
+	if err := checkFlapDetection(c.Defaults.FlapDetection); err != nil {
+		return fmt.Errorf("bad flap_detection config in defaults section: %s", err)
+	}
+	if err := checkEscalation(c.Defaults.Escalation); err != nil {
+		return fmt.Errorf("bad escalation config in defaults section: %s", err)
+	}
+	if err := checkRetry(c.Defaults.Retry); err != nil {
+		return fmt.Errorf("bad retry config in defaults section: %s", err)
+	}
+	if err := checkAlertOrder(c.Defaults.AlertOrder); err != nil {
+		return fmt.Errorf("bad alert_order config in defaults section: %s", err)
+	}
+	if err := checkRunbookLink(c.Defaults.RunbookLink); err != nil {
+		return fmt.Errorf("bad runbook_link config in defaults section: %s", err)
+	}
+	if err := checkAlertmanagerLink(c.Defaults.AlertmanagerLink); err != nil {
+		return fmt.Errorf("bad alertmanager_link config in defaults section: %s", err)
+	}
+	if err := checkGraph(c.Defaults.Graph); err != nil {
+		return fmt.Errorf("bad graph config in defaults section: %s", err)
+	}
+	if err := checkWontFixSilence(c.Defaults.WontFixSilence); err != nil {
+		return fmt.Errorf("bad wont_fix_silence config in defaults section: %s", err)
+	}
+	if err := checkStaleIssues(c.Defaults.StaleIssues); err != nil {
+		return fmt.Errorf("bad stale_issues config in defaults section: %s", err)
+	}
+	if err := checkManagedFields(c.Defaults.ManagedFields); err != nil {
+		return fmt.Errorf("bad managed_fields config in defaults section: %s", err)
+	}
+	if err := checkPresets(c.Defaults.Presets); err != nil {
+		return fmt.Errorf("bad presets config in defaults section: %s", err)
+	}
+	if err := checkSchedules(c.Defaults.Schedules); err != nil {
+		return fmt.Errorf("bad schedules config in defaults section: %s", err)
+	}
+	if err := checkMaintenanceWindows(c.Defaults.MaintenanceWindows); err != nil {
+		return fmt.Errorf("bad maintenance_windows config in defaults section: %s", err)
+	}
 
+	rateLimits := map[string]*ReceiverConfig{}
 	for _, rc := range c.Receivers {
 		if rc.Name == "" {
 			return fmt.Errorf("missing name for receiver %+v", rc)
@@ -232,24 +1645,122 @@ func (c *Config) UnmarshalYAML(unmarshal func(interface{}) error) error {
 		if _, err := url.Parse(rc.APIURL); err != nil {
 			return fmt.Errorf("invalid api_url %q in receiver %q: %s", rc.APIURL, rc.Name, err)
 		}
+		if rc.AlertmanagerURL == "" {
+			rc.AlertmanagerURL = c.Defaults.AlertmanagerURL
+		}
+		if rc.AlertmanagerURL != "" {
+			if u, err := url.Parse(rc.AlertmanagerURL); err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+				return fmt.Errorf("invalid alertmanager_url %q in receiver %q: must be an http or https URL", rc.AlertmanagerURL, rc.Name)
+			}
+		}
+		if rc.PrometheusURL == "" {
+			rc.PrometheusURL = c.Defaults.PrometheusURL
+		}
+		if rc.PrometheusURL != "" {
+			if u, err := url.Parse(rc.PrometheusURL); err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+				return fmt.Errorf("invalid prometheus_url %q in receiver %q: must be an http or https URL", rc.PrometheusURL, rc.Name)
+			}
+		}
+
+		if rc.APIVersion == 0 {
+			rc.APIVersion = c.Defaults.APIVersion
+		}
+		switch rc.APIVersion {
+		case 0, APIVersion2, APIVersion3:
+		default:
+			return fmt.Errorf("unsupported api_version %d in receiver %q", rc.APIVersion, rc.Name)
+		}
+		if rc.TLSConfig == nil {
+			rc.TLSConfig = c.Defaults.TLSConfig
+		}
+		if err := checkTLSConfig(rc.TLSConfig); err != nil {
+			return fmt.Errorf("bad tls_config in receiver %q: %s", rc.Name, err)
+		}
+		if rc.Timeout == nil {
+			rc.Timeout = c.Defaults.Timeout
+		}
+		if rc.Timeout != nil && *rc.Timeout <= 0 {
+			return fmt.Errorf("bad config in receiver %q, 'timeout' must be positive", rc.Name)
+		}
+		if rc.IdleConnTimeout == nil {
+			rc.IdleConnTimeout = c.Defaults.IdleConnTimeout
+		}
+		if rc.MaxIdleConnsPerHost == nil {
+			rc.MaxIdleConnsPerHost = c.Defaults.MaxIdleConnsPerHost
+		}
+		if rc.MaxIdleConnsPerHost != nil && *rc.MaxIdleConnsPerHost < 0 {
+			return fmt.Errorf("bad config in receiver %q, 'max_idle_conns_per_host' cannot be negative", rc.Name)
+		}
+		if rc.RateLimit == nil {
+			rc.RateLimit = c.Defaults.RateLimit
+		}
+		if rl := rc.RateLimit; rl != nil {
+			if rl.RequestsPerSecond <= 0 {
+				return fmt.Errorf("bad rate_limit config in receiver %q: requests_per_second must be positive", rc.Name)
+			}
+			if rl.Burst < 0 {
+				return fmt.Errorf("bad rate_limit config in receiver %q: burst cannot be negative", rc.Name)
+			}
+		}
+		if err := checkRetry(rc.Retry); err != nil {
+			return fmt.Errorf("bad retry config in receiver %q: %s", rc.Name, err)
+		}
+		if rc.Retry == nil {
+			rc.Retry = c.Defaults.Retry
+		}
+		if err := checkAlertOrder(rc.AlertOrder); err != nil {
+			return fmt.Errorf("bad alert_order config in receiver %q: %s", rc.Name, err)
+		}
+		if rc.AlertOrder == nil {
+			rc.AlertOrder = c.Defaults.AlertOrder
+		}
+		// Receivers share the limiter of their Jira instance, so they have to agree on its settings.
+		if other, ok := rateLimits[strings.TrimSuffix(rc.APIURL, "/")]; ok && !reflect.DeepEqual(other.RateLimit, rc.RateLimit) {
+			return fmt.Errorf("bad rate_limit config in receiver %q: differs from receiver %q with the same api_url", rc.Name, other.Name)
+		}
+		rateLimits[strings.TrimSuffix(rc.APIURL, "/")] = rc
 
 		if (rc.User != "" || rc.Password != "") && rc.PersonalAccessToken != "" {
 			return fmt.Errorf("bad auth config in receiver %q: user/password and PAT authentication are mutually exclusive", rc.Name)
 		}
 
-		if (rc.User == "" || rc.Password == "") && rc.PersonalAccessToken == "" {
+		if err := checkSecretFiles(rc); err != nil {
+			return fmt.Errorf("bad auth config in receiver %q: %s", rc.Name, err)
+		}
+		if authMethods(rc) > 1 {
+			return fmt.Errorf("bad auth config in receiver %q: only one of password, api_token, personal_access_token, oauth and connect may be set", rc.Name)
+		}
+		if err := checkOAuth(rc.OAuth); err != nil {
+			return fmt.Errorf("bad oauth config in receiver %q: %s", rc.Name, err)
+		}
+		if rc.Connect != nil && (rc.Connect.Key == "" || rc.Connect.SharedSecret == "") {
+			return fmt.Errorf("bad connect config in receiver %q: key and shared_secret are required", rc.Name)
+		}
+
+		if (rc.User == "" || !hasBasicAuthSecret(rc)) && !hasPAT(rc) && rc.OAuth == nil && rc.Connect == nil {
 			if rc.User == "" && c.Defaults.User != "" {
 				rc.User = c.Defaults.User
 			}
 
-			if rc.Password == "" && c.Defaults.Password != "" {
+			if !hasBasicAuthSecret(rc) {
 				rc.Password = c.Defaults.Password
+				rc.PasswordFile = c.Defaults.PasswordFile
+				rc.PasswordRef = c.Defaults.PasswordRef
+				rc.APIToken = c.Defaults.APIToken
+				rc.APITokenFile = c.Defaults.APITokenFile
+				rc.APITokenRef = c.Defaults.APITokenRef
 			}
 
-			if rc.User != "" && rc.Password != "" {
+			if rc.User != "" && hasBasicAuthSecret(rc) {
 				// Nothing to do, we're ready to go with basic auth.
-			} else if c.Defaults.PersonalAccessToken != "" {
+			} else if hasPAT(c.Defaults) {
 				rc.PersonalAccessToken = c.Defaults.PersonalAccessToken
+				rc.PersonalAccessTokenFile = c.Defaults.PersonalAccessTokenFile
+				rc.PersonalAccessTokenRef = c.Defaults.PersonalAccessTokenRef
+			} else if c.Defaults.OAuth != nil {
+				rc.OAuth = c.Defaults.OAuth
+			} else if c.Defaults.Connect != nil {
+				rc.Connect = c.Defaults.Connect
 			} else {
 				return fmt.Errorf("missing authentication in receiver %q", rc.Name)
 			}
@@ -262,6 +1773,26 @@ func (c *Config) UnmarshalYAML(unmarshal func(interface{}) error) error {
 			}
 			rc.Project = c.Defaults.Project
 		}
+		if len(rc.AllowedProjects) == 0 {
+			rc.AllowedProjects = c.Defaults.AllowedProjects
+		}
+		for _, p := range rc.AllowedProjects {
+			if !ValidProjectKey(p) {
+				return fmt.Errorf("invalid project key %q in 'allowed_projects' of receiver %q", p, rc.Name)
+			}
+		}
+		if rc.FallbackProject == "" {
+			rc.FallbackProject = c.Defaults.FallbackProject
+		}
+		if rc.FallbackProject != "" && !ValidProjectKey(rc.FallbackProject) {
+			return fmt.Errorf("invalid fallback_project %q in receiver %q: must be a project key", rc.FallbackProject, rc.Name)
+		}
+		if rc.FallbackIssueType == "" {
+			rc.FallbackIssueType = c.Defaults.FallbackIssueType
+		}
+		if strings.Contains(rc.FallbackIssueType, "{{") {
+			return fmt.Errorf("invalid fallback_issue_type %q in receiver %q: cannot be a template", rc.FallbackIssueType, rc.Name)
+		}
 		if rc.IssueType == "" {
 			if c.Defaults.IssueType == "" {
 				return fmt.Errorf("missing issue_type in receiver %q", rc.Name)
@@ -294,9 +1825,87 @@ func (c *Config) UnmarshalYAML(unmarshal func(interface{}) error) error {
 		if rc.Description == "" && c.Defaults.Description != "" {
 			rc.Description = c.Defaults.Description
 		}
+		if rc.SummaryFiringPrefix == "" {
+			rc.SummaryFiringPrefix = c.Defaults.SummaryFiringPrefix
+		}
+		if rc.SummaryResolvedPrefix == "" {
+			rc.SummaryResolvedPrefix = c.Defaults.SummaryResolvedPrefix
+		}
+		if rc.Environment == "" && c.Defaults.Environment != "" {
+			rc.Environment = c.Defaults.Environment
+		}
+		if rc.Assignee == "" {
+			rc.Assignee = c.Defaults.Assignee
+		}
+		if rc.Reporter == "" {
+			rc.Reporter = c.Defaults.Reporter
+		}
+		if len(rc.Watchers) == 0 {
+			rc.Watchers = c.Defaults.Watchers
+		}
+		if rc.UpdateEnvironment == nil {
+			rc.UpdateEnvironment = c.Defaults.UpdateEnvironment
+		}
+		if rc.MinUpdateInterval == nil {
+			rc.MinUpdateInterval = c.Defaults.MinUpdateInterval
+		}
+		if rc.SearchCacheTTL == nil {
+			rc.SearchCacheTTL = c.Defaults.SearchCacheTTL
+		}
+		if rc.TransitionCacheTTL == nil {
+			rc.TransitionCacheTTL = c.Defaults.TransitionCacheTTL
+		}
+		if rc.BulkCreateWait == nil {
+			rc.BulkCreateWait = c.Defaults.BulkCreateWait
+		}
+		if rc.SetMissingPriority == nil {
+			rc.SetMissingPriority = c.Defaults.SetMissingPriority
+		}
+		if rc.FreezeInProgress == nil {
+			rc.FreezeInProgress = c.Defaults.FreezeInProgress
+		}
+		if rc.ManagedDescription == nil {
+			rc.ManagedDescription = c.Defaults.ManagedDescription
+		}
+		if rc.TruncatedAlertsComment == nil {
+			rc.TruncatedAlertsComment = c.Defaults.TruncatedAlertsComment
+		}
 		if rc.WontFixResolution == "" && c.Defaults.WontFixResolution != "" {
 			rc.WontFixResolution = c.Defaults.WontFixResolution
 		}
+		if err := checkRunbookLink(rc.RunbookLink); err != nil {
+			return fmt.Errorf("bad runbook_link config in receiver %q: %s", rc.Name, err)
+		}
+		if rc.RunbookLink == nil {
+			rc.RunbookLink = c.Defaults.RunbookLink
+		}
+		if err := checkAlertmanagerLink(rc.AlertmanagerLink); err != nil {
+			return fmt.Errorf("bad alertmanager_link config in receiver %q: %s", rc.Name, err)
+		}
+		if rc.AlertmanagerLink == nil {
+			rc.AlertmanagerLink = c.Defaults.AlertmanagerLink
+		}
+		if err := checkGraph(rc.Graph); err != nil {
+			return fmt.Errorf("bad graph config in receiver %q: %s", rc.Name, err)
+		}
+		if rc.Graph == nil {
+			rc.Graph = c.Defaults.Graph
+		}
+		if err := checkWontFixSilence(rc.WontFixSilence); err != nil {
+			return fmt.Errorf("bad wont_fix_silence config in receiver %q: %s", rc.Name, err)
+		}
+		if rc.WontFixSilence == nil {
+			rc.WontFixSilence = c.Defaults.WontFixSilence
+		}
+		if rc.WontFixSilence != nil && (rc.WontFixResolution == "" || rc.AlertmanagerURL == "") {
+			return fmt.Errorf("bad wont_fix_silence config in receiver %q: wont_fix_resolution and alertmanager_url are required", rc.Name)
+		}
+		if len(rc.IgnoreLabels) == 0 {
+			rc.IgnoreLabels = c.Defaults.IgnoreLabels
+		}
+		if len(rc.IgnoreStatuses) == 0 {
+			rc.IgnoreStatuses = c.Defaults.IgnoreStatuses
+		}
 		if rc.AutoResolve != nil {
 			if rc.AutoResolve.State == "" {
 				return fmt.Errorf("bad config in receiver %q, 'auto_resolve' was defined with empty 'state' field", rc.Name)
@@ -305,6 +1914,48 @@ func (c *Config) UnmarshalYAML(unmarshal func(interface{}) error) error {
 		if rc.AutoResolve == nil && c.Defaults.AutoResolve != nil {
 			rc.AutoResolve = c.Defaults.AutoResolve
 		}
+		if err := checkFlapDetection(rc.FlapDetection); err != nil {
+			return fmt.Errorf("bad flap_detection config in receiver %q: %s", rc.Name, err)
+		}
+		if rc.FlapDetection == nil {
+			rc.FlapDetection = c.Defaults.FlapDetection
+		}
+		if err := checkEscalation(rc.Escalation); err != nil {
+			return fmt.Errorf("bad escalation config in receiver %q: %s", rc.Name, err)
+		}
+		if rc.Escalation == nil {
+			rc.Escalation = c.Defaults.Escalation
+		}
+		if err := checkStaleIssues(rc.StaleIssues); err != nil {
+			return fmt.Errorf("bad stale_issues config in receiver %q: %s", rc.Name, err)
+		}
+		if rc.StaleIssues == nil {
+			rc.StaleIssues = c.Defaults.StaleIssues
+		}
+		if err := checkManagedFields(rc.ManagedFields); err != nil {
+			return fmt.Errorf("bad managed_fields config in receiver %q: %s", rc.Name, err)
+		}
+		if rc.ManagedFields == nil {
+			rc.ManagedFields = c.Defaults.ManagedFields
+		}
+		if err := checkPresets(rc.Presets); err != nil {
+			return fmt.Errorf("bad presets config in receiver %q: %s", rc.Name, err)
+		}
+		if rc.Presets == nil {
+			rc.Presets = c.Defaults.Presets
+		}
+		if err := checkSchedules(rc.Schedules); err != nil {
+			return fmt.Errorf("bad schedules config in receiver %q: %s", rc.Name, err)
+		}
+		if rc.Schedules == nil {
+			rc.Schedules = c.Defaults.Schedules
+		}
+		if err := checkMaintenanceWindows(rc.MaintenanceWindows); err != nil {
+			return fmt.Errorf("bad maintenance_windows config in receiver %q: %s", rc.Name, err)
+		}
+		if rc.MaintenanceWindows == nil {
+			rc.MaintenanceWindows = c.Defaults.MaintenanceWindows
+		}
 		if len(c.Defaults.Fields) > 0 {
 			for key, value := range c.Defaults.Fields {
 				if _, ok := rc.Fields[key]; !ok {
@@ -312,27 +1963,301 @@ func (c *Config) UnmarshalYAML(unmarshal func(interface{}) error) error {
 				}
 			}
 		}
+		for key, typ := range c.Defaults.FieldTypes {
+			if _, ok := rc.FieldTypes[key]; !ok {
+				if rc.FieldTypes == nil {
+					rc.FieldTypes = map[string]string{}
+				}
+				rc.FieldTypes[key] = typ
+			}
+		}
+		for key, typ := range rc.FieldTypes {
+			switch typ {
+			case FieldTypeString, FieldTypeNumber, FieldTypeJSON:
+			default:
+				return fmt.Errorf("bad config in receiver %q, unknown type %q of field %q in 'field_types'", rc.Name, typ, key)
+			}
+		}
+		if rc.ManagedFields != nil {
+			for _, id := range rc.ManagedFields.IDs() {
+				if _, ok := rc.Fields[id]; ok {
+					return fmt.Errorf("bad managed_fields config in receiver %q: field %q is also set in 'fields'", rc.Name, id)
+				}
+			}
+		}
 		if len(c.Defaults.StaticLabels) > 0 {
 			rc.StaticLabels = append(rc.StaticLabels, c.Defaults.StaticLabels...)
 		}
 		if len(c.Defaults.OtherProjects) > 0 {
 			rc.OtherProjects = append(rc.OtherProjects, c.Defaults.OtherProjects...)
 		}
+		for _, p := range rc.OtherProjects {
+			if p == "" {
+				return fmt.Errorf("bad config in receiver %q, empty project in 'other_projects'", rc.Name)
+			}
+			if p == AllProjects {
+				rc.OtherProjects = []string{AllProjects}
+				break
+			}
+		}
 		if rc.AddGroupLabels == nil {
 			rc.AddGroupLabels = c.Defaults.AddGroupLabels
 		}
 		if rc.UpdateInComment == nil {
 			rc.UpdateInComment = c.Defaults.UpdateInComment
 		}
+		if rc.Renderer == "" {
+			rc.Renderer = c.Defaults.Renderer
+		}
+		switch rc.Renderer {
+		case "", RendererWiki, RendererMarkdown, RendererPlain:
+		default:
+			return fmt.Errorf("bad config in receiver %q, unknown 'renderer' %q", rc.Name, rc.Renderer)
+		}
+		if rc.MaxCommentLength == nil {
+			rc.MaxCommentLength = c.Defaults.MaxCommentLength
+		}
+		if rc.MaxCommentLength != nil && *rc.MaxCommentLength < minCommentLength {
+			return fmt.Errorf("bad config in receiver %q, 'max_comment_length' must be at least %d", rc.Name, minCommentLength)
+		}
+		if rc.CommentOverflow == "" {
+			rc.CommentOverflow = c.Defaults.CommentOverflow
+		}
+		switch rc.CommentOverflow {
+		case "", CommentOverflowSplit, CommentOverflowTruncate:
+		default:
+			return fmt.Errorf("bad config in receiver %q, unknown 'comment_overflow' %q", rc.Name, rc.CommentOverflow)
+		}
+		if rc.ServiceDesk == nil {
+			rc.ServiceDesk = c.Defaults.ServiceDesk
+		}
+		if rc.ServiceDesk != nil && (rc.ServiceDesk.ServiceDeskID == "" || rc.ServiceDesk.RequestTypeID == "") {
+			return fmt.Errorf("bad service_desk config in receiver %q: service_desk_id and request_type_id are required", rc.Name)
+		}
+		if rc.Identity == nil {
+			rc.Identity = c.Defaults.Identity
+		}
+		if rc.Identity != nil {
+			for _, strategy := range []string{rc.Identity.Strategy, rc.Identity.MigrateFrom} {
+				switch strategy {
+				case "", IdentityHash, IdentityLabel:
+				case IdentityField:
+					if rc.Identity.Field == "" {
+						return fmt.Errorf("bad identity config in receiver %q: field strategy requires 'field'", rc.Name)
+					}
+				case "property":
+					return fmt.Errorf("bad identity config in receiver %q: issue properties are not supported, as JQL only searches those indexed by a Jira app", rc.Name)
+				default:
+					return fmt.Errorf("bad identity config in receiver %q: unknown strategy %q", rc.Name, strategy)
+				}
+			}
+		}
+		if rc.HashJiraLabel == nil {
+			rc.HashJiraLabel = c.Defaults.HashJiraLabel
+		}
+		if rc.UpdateSummary == nil {
+			rc.UpdateSummary = c.Defaults.UpdateSummary
+		}
+		if rc.UpdateDescription == nil {
+			rc.UpdateDescription = c.Defaults.UpdateDescription
+		}
+		if rc.ReopenTickets == nil {
+			rc.ReopenTickets = c.Defaults.ReopenTickets
+		}
+		if rc.MaxDescriptionLength == nil {
+			rc.MaxDescriptionLength = c.Defaults.MaxDescriptionLength
+		}
+		if rc.MaxDescriptionLength != nil && *rc.MaxDescriptionLength <= 0 {
+			return fmt.Errorf("bad config in receiver %q, 'max_description_length' must be positive", rc.Name)
+		}
+		if rc.MaxAlertsInDescription == nil {
+			rc.MaxAlertsInDescription = c.Defaults.MaxAlertsInDescription
+		}
+		if rc.MaxAlertsInDescription != nil && *rc.MaxAlertsInDescription <= 0 {
+			return fmt.Errorf("bad config in receiver %q, 'max_alerts_in_description' must be positive", rc.Name)
+		}
+		if rc.CommentOnTransitionFailure == nil {
+			rc.CommentOnTransitionFailure = c.Defaults.CommentOnTransitionFailure
+		}
+		if rc.TemplateStrict == nil {
+			rc.TemplateStrict = c.Defaults.TemplateStrict
+		}
+		if rc.DefaultTimezone == "" {
+			rc.DefaultTimezone = c.Defaults.DefaultTimezone
+		}
+		if rc.DefaultTimezone != "" {
+			if _, err := time.LoadLocation(rc.DefaultTimezone); err != nil {
+				return fmt.Errorf("bad config in receiver %q, unknown time zone %q in 'default_timezone'", rc.Name, rc.DefaultTimezone)
+			}
+		}
+		if len(rc.LabelInclude) == 0 {
+			rc.LabelInclude = c.Defaults.LabelInclude
+		}
+		if len(rc.LabelExclude) == 0 {
+			rc.LabelExclude = c.Defaults.LabelExclude
+		}
+		if rc.LabelFormat == "" {
+			rc.LabelFormat = c.Defaults.LabelFormat
+		}
+		if rc.SyncGroupLabels == nil {
+			rc.SyncGroupLabels = c.Defaults.SyncGroupLabels
+		}
+		if rc.StatusLabel == nil {
+			rc.StatusLabel = c.Defaults.StatusLabel
+		}
 	}
 
 	if len(c.Receivers) == 0 {
 		return fmt.Errorf("no receivers defined")
 	}
 
-	if c.Template == "" {
+	for _, rc := range c.Receivers {
+		if rc.FanOut == nil {
+			continue
+		}
+		if len(rc.FanOut.Receivers) == 0 {
+			return fmt.Errorf("bad fan_out config in receiver %q: receivers cannot be empty", rc.Name)
+		}
+		for _, name := range rc.FanOut.Receivers {
+			if name == rc.Name {
+				return fmt.Errorf("bad fan_out config in receiver %q: receiver cannot fan out to itself", rc.Name)
+			}
+			if c.ReceiverByName(name) == nil {
+				return fmt.Errorf("bad fan_out config in receiver %q: unknown receiver %q", rc.Name, name)
+			}
+		}
+	}
+
+	if c.Template == "" && len(c.Templates) == 0 {
 		return fmt.Errorf("missing template file")
 	}
+	for _, pattern := range c.Templates {
+		if _, err := filepath.Match(pattern, ""); err != nil {
+			return fmt.Errorf("bad templates config: invalid pattern %q", pattern)
+		}
+	}
+
+	if ws := c.WebhookSignature; ws != nil {
+		if ws.Secret == "" {
+			return fmt.Errorf("bad webhook_signature config: secret cannot be empty")
+		}
+		if ws.Header == "" {
+			ws.Header = DefaultWebhookSignatureHeader
+		}
+		switch ws.Algorithm {
+		case "":
+			ws.Algorithm = "sha256"
+		case "sha1", "sha256", "sha512":
+		default:
+			return fmt.Errorf("bad webhook_signature config: unsupported algorithm %q", ws.Algorithm)
+		}
+		if ws.Tolerance == nil {
+			tolerance := Duration(DefaultWebhookSignatureTolerance)
+			ws.Tolerance = &tolerance
+		}
+	}
+
+	if wa := c.WebhookAuth; wa != nil {
+		if (wa.BasicAuth == nil) == (wa.BearerToken == "") {
+			return fmt.Errorf("bad webhook_auth config: exactly one of basic_auth and bearer_token must be set")
+		}
+		if wa.BasicAuth != nil && (wa.BasicAuth.Username == "" || wa.BasicAuth.Password == "") {
+			return fmt.Errorf("bad webhook_auth config: basic_auth requires username and password")
+		}
+	}
+
+	if tc := c.Tracing; tc != nil {
+		if tc.Endpoint == "" {
+			return fmt.Errorf("bad tracing config: endpoint cannot be empty")
+		}
+		if strings.Contains(tc.Endpoint, "://") {
+			return fmt.Errorf("bad tracing config: endpoint must be host:port, without scheme")
+		}
+		if tc.SamplingFraction != nil && (*tc.SamplingFraction < 0 || *tc.SamplingFraction > 1) {
+			return fmt.Errorf("bad tracing config: sampling_fraction must be between 0 and 1")
+		}
+		if tc.Timeout != nil && *tc.Timeout <= 0 {
+			return fmt.Errorf("bad tracing config: timeout must be positive")
+		}
+	}
+
+	if cc := c.Cluster; cc != nil {
+		if len(cc.Peers) == 0 {
+			return fmt.Errorf("bad cluster config: peers cannot be empty")
+		}
+		seen := map[string]bool{}
+		for _, p := range cc.Peers {
+			u, err := url.Parse(p)
+			if err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+				return fmt.Errorf("bad cluster config: peer %q must be an http or https URL", p)
+			}
+			if seen[p] {
+				return fmt.Errorf("bad cluster config: duplicate peer %q", p)
+			}
+			seen[p] = true
+		}
+		if !seen[cc.Self] {
+			return fmt.Errorf("bad cluster config: self %q must be one of the peers", cc.Self)
+		}
+		if cc.ForwardTimeout == nil {
+			timeout := Duration(DefaultClusterForwardTimeout)
+			cc.ForwardTimeout = &timeout
+		}
+		if *cc.ForwardTimeout <= 0 {
+			return fmt.Errorf("bad cluster config: forward_timeout must be positive")
+		}
+	}
+
+	ingestNames := map[string]bool{}
+	for _, ic := range c.Ingest {
+		if !ingestNameRE.MatchString(ic.Name) {
+			return fmt.Errorf("bad ingest config: name %q must only contain letters, digits, '_', '.' and '-'", ic.Name)
+		}
+		if ingestNames[ic.Name] {
+			return fmt.Errorf("bad ingest config: duplicate endpoint %q", ic.Name)
+		}
+		ingestNames[ic.Name] = true
+		if ic.Template == "" {
+			return fmt.Errorf("bad ingest config of endpoint %q: template cannot be empty", ic.Name)
+		}
+		if ic.Receiver != "" && c.ReceiverByName(ic.Receiver) == nil {
+			return fmt.Errorf("bad ingest config of endpoint %q: unknown receiver %q", ic.Name, ic.Receiver)
+		}
+	}
+
+	if th := c.TemplateHTTP; th != nil {
+		if len(th.AllowedHosts) == 0 {
+			return fmt.Errorf("bad template_http config: allowed_hosts cannot be empty")
+		}
+		for _, h := range th.AllowedHosts {
+			if h == "" || strings.ContainsAny(h, "/") {
+				return fmt.Errorf("bad template_http config: allowed host %q must be a host name or host:port", h)
+			}
+		}
+		if th.Timeout == nil {
+			timeout := Duration(DefaultTemplateHTTPTimeout)
+			th.Timeout = &timeout
+		}
+		if *th.Timeout <= 0 {
+			return fmt.Errorf("bad template_http config: timeout must be positive")
+		}
+		if th.CacheTTL == nil {
+			ttl := Duration(DefaultTemplateHTTPCacheTTL)
+			th.CacheTTL = &ttl
+		}
+		if *th.CacheTTL < 0 {
+			return fmt.Errorf("bad template_http config: cache_ttl cannot be negative")
+		}
+	}
+
+	for name, m := range c.Maps {
+		if name == "" {
+			return fmt.Errorf("bad maps config: map name cannot be empty")
+		}
+		if m == nil || len(m.Values) == 0 {
+			return fmt.Errorf("bad maps config: map %q has no values", name)
+		}
+	}
 
 	return checkOverflow(c.XXX, "config")
 }
@@ -347,6 +2272,16 @@ func (c *Config) ReceiverByName(name string) *ReceiverConfig {
 	return nil
 }
 
+// IngestByName returns the ingest endpoint with the given name, or nil if none.
+func (c *Config) IngestByName(name string) *IngestConfig {
+	for _, ic := range c.Ingest {
+		if ic.Name == name {
+			return ic
+		}
+	}
+	return nil
+}
+
 func checkOverflow(m map[string]interface{}, ctx string) error {
 	if len(m) > 0 {
 		var keys []string
@@ -358,6 +2293,139 @@ func checkOverflow(m map[string]interface{}, ctx string) error {
 	return nil
 }
 
+// Regexp encapsulates a regexp.Regexp and makes it YAML marshalable. The expression is anchored at both ends.
+type Regexp struct {
+	*regexp.Regexp
+	original string
+}
+
+// NewRegexp creates a new anchored Regexp.
+func NewRegexp(s string) (Regexp, error) {
+	re, err := regexp.Compile("^(?:" + s + ")$")
+	return Regexp{Regexp: re, original: s}, err
+}
+
+// String returns the original (unanchored) expression.
+func (re Regexp) String() string {
+	return re.original
+}
+
+// MarshalYAML implements the yaml.Marshaler interface.
+func (re Regexp) MarshalYAML() (interface{}, error) {
+	if re.Regexp != nil {
+		return re.original, nil
+	}
+	return nil, nil
+}
+
+// MarshalJSON implements the json.Marshaler interface, returning the original expression as for YAML.
+func (re Regexp) MarshalJSON() ([]byte, error) {
+	if re.Regexp != nil {
+		return json.Marshal(re.original)
+	}
+	return []byte("null"), nil
+}
+
+// UnmarshalYAML implements the yaml.Unmarshaler interface.
+func (re *Regexp) UnmarshalYAML(unmarshal func(interface{}) error) error {
+	var s string
+	if err := unmarshal(&s); err != nil {
+		return err
+	}
+	r, err := NewRegexp(s)
+	if err != nil {
+		return err
+	}
+	*re = r
+	return nil
+}
+
+// Matcher matches the value of a label, like Alertmanager's matchers: name="value", name!="value", name=~"regex" or
+// name!~"regex". Values may be left unquoted, and regular expressions are anchored at both ends.
+type Matcher struct {
+	Name  string
+	Type  string
+	Value string
+	re    Regexp
+}
+
+// Matcher types.
+const (
+	MatchEqual     = "="
+	MatchNotEqual  = "!="
+	MatchRegexp    = "=~"
+	MatchNotRegexp = "!~"
+)
+
+var matcherRE = regexp.MustCompile(`^\s*([a-zA-Z_][a-zA-Z0-9_]*)\s*(=~|!~|!=|=)\s*(.*?)\s*$`)
+
+// ParseMatcher parses a matcher such as severity="critical".
+func ParseMatcher(s string) (Matcher, error) {
+	m := matcherRE.FindStringSubmatch(s)
+	if m == nil {
+		return Matcher{}, fmt.Errorf("bad matcher %q", s)
+	}
+	value := m[3]
+	if strings.HasPrefix(value, `"`) {
+		var err error
+		if value, err = strconv.Unquote(value); err != nil {
+			return Matcher{}, fmt.Errorf("bad value of matcher %q: %s", s, err)
+		}
+	}
+	matcher := Matcher{Name: m[1], Type: m[2], Value: value}
+	if matcher.Type == MatchRegexp || matcher.Type == MatchNotRegexp {
+		re, err := NewRegexp(value)
+		if err != nil {
+			return Matcher{}, fmt.Errorf("bad regexp of matcher %q: %s", s, err)
+		}
+		matcher.re = re
+	}
+	return matcher, nil
+}
+
+// Matches reports whether the value of the label matches. Missing labels have an empty value.
+func (m Matcher) Matches(value string) bool {
+	switch m.Type {
+	case MatchEqual:
+		return value == m.Value
+	case MatchNotEqual:
+		return value != m.Value
+	case MatchRegexp:
+		return m.re.MatchString(value)
+	case MatchNotRegexp:
+		return !m.re.MatchString(value)
+	}
+	return false
+}
+
+func (m Matcher) String() string {
+	return m.Name + m.Type + strconv.Quote(m.Value)
+}
+
+// MarshalYAML implements the yaml.Marshaler interface.
+func (m Matcher) MarshalYAML() (interface{}, error) {
+	return m.String(), nil
+}
+
+// MarshalJSON implements the json.Marshaler interface.
+func (m Matcher) MarshalJSON() ([]byte, error) {
+	return json.Marshal(m.String())
+}
+
+// UnmarshalYAML implements the yaml.Unmarshaler interface.
+func (m *Matcher) UnmarshalYAML(unmarshal func(interface{}) error) error {
+	var s string
+	if err := unmarshal(&s); err != nil {
+		return err
+	}
+	matcher, err := ParseMatcher(s)
+	if err != nil {
+		return err
+	}
+	*m = matcher
+	return nil
+}
+
 type Duration time.Duration
 
 var durationRE = regexp.MustCompile("^([0-9]+)(y|w|d|h|m|s|ms)$")
@@ -13,13 +13,18 @@
 package config
 
 import (
+	"encoding/json"
 	"os"
 	"path"
+	"path/filepath"
 	"reflect"
+	"strings"
 	"testing"
+	"time"
 
 	"github.com/go-kit/log"
 	"github.com/stretchr/testify/require"
+	"github.com/trivago/tgo/tcontainer"
 	yaml "gopkg.in/yaml.v3"
 )
 
@@ -96,6 +101,61 @@ func TestLoadFile(t *testing.T) {
 
 }
 
+// Checks that the files of a configuration directory are merged.
+func TestLoadDirectory(t *testing.T) {
+	dir := t.TempDir()
+	write := func(name, content string) {
+		require.NoError(t, os.WriteFile(path.Join(dir, name), []byte(content), os.ModePerm))
+	}
+	write("00-defaults.yml", `
+defaults:
+  api_url: https://jiralert.atlassian.net
+  user: jiralert
+  password: 'JIRAlert'
+  issue_type: Bug
+  summary: '{{ template "jira.summary" . }}'
+  reopen_state: "To Do"
+  reopen_duration: 0h
+template: jiralert.tmpl
+`)
+	write("team-a.yml", `
+receivers:
+  - name: 'jira-ab'
+    project: AB
+`)
+	write("team-x.yml", `
+receivers:
+  - name: 'jira-xy'
+    project: XY
+    issue_type: Task
+`)
+	// Ignored.
+	write("notes.txt", "receivers: []")
+
+	cfg, _, err := LoadFile(dir, log.NewNopLogger())
+	require.NoError(t, err)
+	require.Len(t, cfg.Receivers, 2)
+	require.Equal(t, "AB", cfg.ReceiverByName("jira-ab").Project)
+	require.Equal(t, "Bug", cfg.ReceiverByName("jira-ab").IssueType)
+	require.Equal(t, "Task", cfg.ReceiverByName("jira-xy").IssueType)
+	require.Equal(t, path.Join(dir, "jiralert.tmpl"), cfg.Template)
+
+	write("team-y.yml", `
+receivers:
+  - name: 'jira-xy'
+    project: YY
+`)
+	_, _, err = LoadFile(dir, log.NewNopLogger())
+	require.ErrorContains(t, err, `duplicate receiver "jira-xy"`)
+	require.NoError(t, os.Remove(path.Join(dir, "team-y.yml")))
+
+	write("team-z.yml", `
+template: other.tmpl
+`)
+	_, _, err = LoadFile(dir, log.NewNopLogger())
+	require.ErrorContains(t, err, `"template" is set in both`)
+}
+
 // Checks if the env var substitution is happening correctly in the loaded file
 func TestEnvSubstitution(t *testing.T) {
 
@@ -516,3 +576,1320 @@ func TestStaticLabelsConfigMerge(t *testing.T) {
 		require.ElementsMatch(t, receiver.StaticLabels, test.expectedElements, "Elements should match (failing index: %v)", i)
 	}
 }
+
+func TestLabelFilterConfig(t *testing.T) {
+	const conf = `
+defaults:
+  api_url: https://jiralert.atlassian.net
+  user: jiralert
+  password: 'JIRAlert'
+  issue_type: Bug
+  summary: summary
+  reopen_state: "To Do"
+  reopen_duration: 0h
+  label_exclude: ["instance"]
+receivers:
+  - name: 'jira-ab'
+    project: AB
+    label_include: ["alert.*", "team"]
+    label_format: '{{ .Name }}:{{ .Value }}'
+template: jiralert.tmpl
+`
+	cfg, err := Load(conf)
+	require.NoError(t, err)
+
+	receiver := cfg.Receivers[0]
+	require.Len(t, receiver.LabelInclude, 2)
+	require.True(t, receiver.LabelInclude[0].MatchString("alertname"))
+	require.False(t, receiver.LabelInclude[1].MatchString("teams"))
+	require.Len(t, receiver.LabelExclude, 1)
+	require.Equal(t, "instance", receiver.LabelExclude[0].String())
+	require.Equal(t, "{{ .Name }}:{{ .Value }}", receiver.LabelFormat)
+
+	// The original expressions are output in JSON too, as in YAML.
+	b, err := json.Marshal(receiver.LabelInclude)
+	require.NoError(t, err)
+	require.JSONEq(t, `["alert.*", "team"]`, string(b))
+	b, err = json.Marshal(struct{ Re Regexp }{})
+	require.NoError(t, err)
+	require.JSONEq(t, `{"Re": null}`, string(b))
+
+	_, err = Load(strings.Replace(conf, `"team"`, `"(team"`, 1))
+	require.Error(t, err)
+}
+
+func TestCloudAuthConfig(t *testing.T) {
+	const conf = `
+defaults:
+  api_url: https://jiralert.atlassian.net
+  user: jiralert@example.com
+  api_token: 'token'
+  issue_type: Bug
+  summary: summary
+  reopen_state: "To Do"
+  reopen_duration: 0h
+receivers:
+  - name: 'jira-ab'
+    project: AB
+  - name: 'jira-oauth'
+    project: AB
+    api_url: https://api.atlassian.com/ex/jira/11223344-a1b2-3b33-c444-def123456789
+    oauth:
+      client_id: id
+      client_secret: secret
+      scopes: ["read:jira-work", "write:jira-work"]
+      refresh_token: refresh
+template: jiralert.tmpl
+`
+	cfg, err := Load(conf)
+	require.NoError(t, err)
+
+	require.Equal(t, Secret("token"), cfg.Receivers[0].APIToken)
+	require.Nil(t, cfg.Receivers[0].OAuth)
+
+	oauth := cfg.Receivers[1].OAuth
+	require.NotNil(t, oauth)
+	require.Equal(t, DefaultOAuthTokenURL, oauth.TokenURL)
+	require.Empty(t, cfg.Receivers[1].APIToken)
+
+	_, err = Load(strings.Replace(conf, "api_token: 'token'", "api_token: 'token'\n  password: 'JIRAlert'", 1))
+	require.EqualError(t, err, "bad auth config in defaults section: only one of password, api_token, personal_access_token, oauth and connect may be set")
+
+	_, err = Load(strings.Replace(conf, "client_secret: secret", "", 1))
+	require.EqualError(t, err, `bad oauth config in receiver "jira-oauth": client_id and client_secret are required`)
+}
+
+func TestSecretFilesConfig(t *testing.T) {
+	const conf = `
+defaults:
+  api_url: https://jiralert.atlassian.net
+  user: jiralert
+  password_file: secrets/password
+  issue_type: Bug
+  summary: summary
+  reopen_state: "To Do"
+  reopen_duration: 0h
+receivers:
+  - name: 'jira-ab'
+    project: AB
+  - name: 'jira-pat'
+    project: AB
+    personal_access_token_file: /etc/jiralert/pat
+template: jiralert.tmpl
+`
+	dir := t.TempDir()
+	file := filepath.Join(dir, "config.yml")
+	require.NoError(t, os.WriteFile(file, []byte(conf), 0o600))
+
+	cfg, _, err := LoadFile(file, log.NewNopLogger())
+	require.NoError(t, err)
+	require.Equal(t, filepath.Join(dir, "secrets/password"), cfg.Receivers[0].PasswordFile)
+	require.Equal(t, "/etc/jiralert/pat", cfg.Receivers[1].PersonalAccessTokenFile)
+	require.Empty(t, cfg.Receivers[1].PasswordFile)
+
+	_, err = Load(strings.Replace(conf, "password_file: secrets/password", "password_file: secrets/password\n  password: JIRAlert", 1))
+	require.EqualError(t, err, "bad auth config in defaults section: password and password_file are mutually exclusive")
+}
+
+func TestSecretRefConfig(t *testing.T) {
+	const conf = `
+defaults:
+  api_url: https://jiralert.atlassian.net
+  user: jiralert
+  password:
+    provider: vault
+    path: secret/data/jiralert
+    key: password
+    refresh_interval: 10m
+  issue_type: Bug
+  summary: summary
+  reopen_state: "To Do"
+  reopen_duration: 0h
+receivers:
+  - name: 'jira-ab'
+    project: AB
+  - name: 'jira-pat'
+    project: AB
+    personal_access_token:
+      provider: env
+      path: JIRA_PAT
+template: jiralert.tmpl
+`
+	cfg, err := Load(conf)
+	require.NoError(t, err)
+	interval := Duration(10 * time.Minute)
+	require.Equal(t, &SecretRef{Provider: SecretProviderVault, Path: "secret/data/jiralert", Key: "password", RefreshInterval: &interval}, cfg.Receivers[0].PasswordRef)
+	require.Empty(t, cfg.Receivers[0].Password)
+	require.Equal(t, &SecretRef{Provider: SecretProviderEnv, Path: "JIRA_PAT"}, cfg.Receivers[1].PersonalAccessTokenRef)
+	require.Nil(t, cfg.Receivers[1].PasswordRef)
+
+	_, err = Load(strings.Replace(conf, "provider: env", "provider: keychain", 1))
+	require.EqualError(t, err, `bad personal_access_token reference: unknown provider "keychain"`)
+	_, err = Load(strings.Replace(conf, "    key: password\n", "", 1))
+	require.EqualError(t, err, "bad password reference: vault secrets require 'key'")
+}
+
+func TestTLSConfig(t *testing.T) {
+	const conf = `
+defaults:
+  api_url: https://jira.example.com
+  user: jiralert
+  password: JIRAlert
+  tls_config:
+    ca_file: certs/ca.crt
+  issue_type: Bug
+  summary: summary
+  reopen_state: "To Do"
+  reopen_duration: 0h
+receivers:
+  - name: 'jira-ab'
+    project: AB
+  - name: 'jira-mtls'
+    project: AB
+    tls_config:
+      cert_file: /etc/jiralert/client.crt
+      key_file: /etc/jiralert/client.key
+      min_version: TLS13
+template: jiralert.tmpl
+`
+	dir := t.TempDir()
+	file := filepath.Join(dir, "config.yml")
+	require.NoError(t, os.WriteFile(file, []byte(conf), 0o600))
+
+	cfg, _, err := LoadFile(file, log.NewNopLogger())
+	require.NoError(t, err)
+	require.Equal(t, &TLSConfig{CAFile: filepath.Join(dir, "certs/ca.crt")}, cfg.Receivers[0].TLSConfig)
+	require.Equal(t, &TLSConfig{CertFile: "/etc/jiralert/client.crt", KeyFile: "/etc/jiralert/client.key", MinVersion: "TLS13"}, cfg.Receivers[1].TLSConfig)
+
+	_, err = Load(strings.Replace(conf, "min_version: TLS13", "min_version: SSL3", 1))
+	require.EqualError(t, err, `bad tls_config in receiver "jira-mtls": unknown min_version "SSL3"`)
+	_, err = Load(strings.Replace(conf, "      key_file: /etc/jiralert/client.key\n", "", 1))
+	require.EqualError(t, err, `bad tls_config in receiver "jira-mtls": cert_file and key_file must be set together`)
+}
+
+func TestTemplatesConfig(t *testing.T) {
+	const conf = `
+defaults:
+  api_url: https://jiralert.atlassian.net
+  user: jiralert
+  password: JIRAlert
+  issue_type: Bug
+  summary: summary
+  reopen_state: "To Do"
+  reopen_duration: 0h
+receivers:
+  - name: 'jira-ab'
+    project: AB
+templates:
+  - templates/*.tmpl
+  - /etc/jiralert/common.tmpl
+`
+	dir := t.TempDir()
+	require.NoError(t, os.WriteFile(path.Join(dir, "config.yaml"), []byte(conf), os.ModePerm))
+	cfg, _, err := LoadFile(path.Join(dir, "config.yaml"), log.NewNopLogger())
+	require.NoError(t, err)
+	require.Equal(t, []string{path.Join(dir, "templates/*.tmpl"), "/etc/jiralert/common.tmpl"}, cfg.TemplateFiles())
+
+	cfg, err = Load(conf + "template: jiralert.tmpl\n")
+	require.NoError(t, err)
+	require.Equal(t, []string{"jiralert.tmpl", "templates/*.tmpl", "/etc/jiralert/common.tmpl"}, cfg.TemplateFiles())
+
+	_, err = Load(strings.Replace(conf, "templates/*.tmpl", "templates/[.tmpl", 1))
+	require.EqualError(t, err, `bad templates config: invalid pattern "templates/[.tmpl"`)
+}
+
+func TestFieldTypesConfig(t *testing.T) {
+	const conf = `
+defaults:
+  api_url: https://jiralert.atlassian.net
+  user: jiralert
+  password: JIRAlert
+  issue_type: Bug
+  summary: summary
+  reopen_state: "To Do"
+  reopen_duration: 0h
+  field_types:
+    customfield_10001: json
+    customfield_10002: number
+receivers:
+  - name: 'jira-ab'
+    project: AB
+    field_types:
+      customfield_10002: string
+template: jiralert.tmpl
+`
+	cfg, err := Load(conf)
+	require.NoError(t, err)
+	require.Equal(t, map[string]string{"customfield_10001": FieldTypeJSON, "customfield_10002": FieldTypeString}, cfg.Receivers[0].FieldTypes)
+
+	_, err = Load(strings.Replace(conf, "customfield_10002: string", "customfield_10002: list", 1))
+	require.EqualError(t, err, `bad config in receiver "jira-ab", unknown type "list" of field "customfield_10002" in 'field_types'`)
+}
+
+func TestDefaultTimezoneConfig(t *testing.T) {
+	const conf = `
+defaults:
+  api_url: https://jiralert.atlassian.net
+  user: jiralert
+  password: JIRAlert
+  issue_type: Bug
+  summary: summary
+  reopen_state: "To Do"
+  reopen_duration: 0h
+  default_timezone: Europe/Berlin
+receivers:
+  - name: 'jira-ab'
+    project: AB
+  - name: 'jira-xy'
+    project: XY
+    default_timezone: America/New_York
+template: jiralert.tmpl
+`
+	cfg, err := Load(conf)
+	require.NoError(t, err)
+	require.Equal(t, "Europe/Berlin", cfg.Receivers[0].DefaultTimezone)
+	require.Equal(t, "America/New_York", cfg.Receivers[1].DefaultTimezone)
+
+	_, err = Load(strings.Replace(conf, "America/New_York", "America/Atlantis", 1))
+	require.EqualError(t, err, `bad config in receiver "jira-xy", unknown time zone "America/Atlantis" in 'default_timezone'`)
+}
+
+func TestOtherProjectsConfig(t *testing.T) {
+	const conf = `
+defaults:
+  api_url: https://jiralert.atlassian.net
+  user: jiralert
+  password: JIRAlert
+  issue_type: Bug
+  summary: summary
+  reopen_state: "To Do"
+  reopen_duration: 0h
+  other_projects: [ARCHIVE]
+receivers:
+  - name: 'jira-ab'
+    project: AB
+    other_projects: [OPS]
+template: jiralert.tmpl
+`
+	cfg, err := Load(conf)
+	require.NoError(t, err)
+	require.Equal(t, []string{"OPS", "ARCHIVE"}, cfg.Receivers[0].OtherProjects)
+
+	_, err = Load(strings.Replace(conf, "[OPS]", "['']", 1))
+	require.EqualError(t, err, `bad config in receiver "jira-ab", empty project in 'other_projects'`)
+	// The wildcard includes all other projects.
+	cfg, err = Load(strings.Replace(conf, "[OPS]", "['*']", 1))
+	require.NoError(t, err)
+	require.Equal(t, []string{"*"}, cfg.Receivers[0].OtherProjects)
+}
+
+func TestFanOutConfig(t *testing.T) {
+	const conf = `
+defaults:
+  api_url: https://jiralert.atlassian.net
+  user: jiralert
+  password: JIRAlert
+  issue_type: Bug
+  summary: summary
+  reopen_state: "To Do"
+  reopen_duration: 0h
+receivers:
+  - name: 'jira-sre'
+    project: SRE
+    fan_out:
+      receivers: [jira-owner]
+      link_type: Relates
+  - name: 'jira-owner'
+    project: OWN
+template: jiralert.tmpl
+`
+	cfg, err := Load(conf)
+	require.NoError(t, err)
+	require.Equal(t, &FanOutConfig{Receivers: []string{"jira-owner"}, LinkType: "Relates"}, cfg.Receivers[0].FanOut)
+
+	_, err = Load(strings.Replace(conf, "[jira-owner]", "[jira-other]", 1))
+	require.EqualError(t, err, `bad fan_out config in receiver "jira-sre": unknown receiver "jira-other"`)
+	_, err = Load(strings.Replace(conf, "[jira-owner]", "[jira-sre]", 1))
+	require.EqualError(t, err, `bad fan_out config in receiver "jira-sre": receiver cannot fan out to itself`)
+	_, err = Load(strings.Replace(conf, "[jira-owner]", "[]", 1))
+	require.EqualError(t, err, `bad fan_out config in receiver "jira-sre": receivers cannot be empty`)
+}
+
+func TestFlapDetectionConfig(t *testing.T) {
+	const conf = `
+defaults:
+  api_url: https://jiralert.atlassian.net
+  user: jiralert
+  password: JIRAlert
+  issue_type: Bug
+  summary: summary
+  reopen_state: "To Do"
+  reopen_duration: 0h
+  flap_detection:
+    reopens: 3
+    window: 1d
+receivers:
+  - name: 'jira-sre'
+    project: SRE
+  - name: 'jira-owner'
+    project: OWN
+    flap_detection:
+      reopens: 5
+      window: 2h
+      label: flapping
+template: jiralert.tmpl
+`
+	cfg, err := Load(conf)
+	require.NoError(t, err)
+	require.Equal(t, &FlapDetectionConfig{Reopens: 3, Window: Duration(24 * time.Hour)}, cfg.Receivers[0].FlapDetection)
+	require.Equal(t, &FlapDetectionConfig{Reopens: 5, Window: Duration(2 * time.Hour), Label: "flapping"}, cfg.Receivers[1].FlapDetection)
+
+	_, err = Load(strings.Replace(conf, "reopens: 5", "reopens: 0", 1))
+	require.EqualError(t, err, `bad flap_detection config in receiver "jira-owner": reopens must be positive`)
+	_, err = Load(strings.Replace(conf, "window: 1d", "window: 0s", 1))
+	require.EqualError(t, err, `bad flap_detection config in defaults section: window must be positive`)
+	_, err = Load(strings.Replace(conf, "label: flapping", "label: flapping alert", 1))
+	require.EqualError(t, err, `bad flap_detection config in receiver "jira-owner": label "flapping alert" cannot contain whitespace`)
+}
+
+func TestAlertmanagerURLConfig(t *testing.T) {
+	const conf = `
+defaults:
+  api_url: https://jiralert.atlassian.net
+  user: jiralert
+  password: JIRAlert
+  issue_type: Bug
+  summary: summary
+  reopen_state: "To Do"
+  reopen_duration: 0h
+  alertmanager_url: http://alertmanager:9093
+receivers:
+  - name: 'jira-sre'
+    project: SRE
+  - name: 'jira-owner'
+    project: OWN
+    alertmanager_url: https://alertmanager.example.com
+template: jiralert.tmpl
+`
+	cfg, err := Load(conf)
+	require.NoError(t, err)
+	require.Equal(t, "http://alertmanager:9093", cfg.Receivers[0].AlertmanagerURL)
+	require.Equal(t, "https://alertmanager.example.com", cfg.Receivers[1].AlertmanagerURL)
+
+	_, err = Load(strings.Replace(conf, "https://alertmanager.example.com", "alertmanager:9093", 1))
+	require.EqualError(t, err, `invalid alertmanager_url "alertmanager:9093" in receiver "jira-owner": must be an http or https URL`)
+}
+
+func TestStaleIssuesConfig(t *testing.T) {
+	const conf = `
+defaults:
+  api_url: https://jiralert.atlassian.net
+  user: jiralert
+  password: JIRAlert
+  issue_type: Bug
+  summary: summary
+  reopen_state: "To Do"
+  reopen_duration: 0h
+  stale_issues:
+    after: 1d
+    state: Done
+receivers:
+  - name: 'jira-sre'
+    project: SRE
+template: jiralert.tmpl
+`
+	cfg, err := Load(conf)
+	require.NoError(t, err)
+	require.Equal(t, &StaleIssuesConfig{After: Duration(24 * time.Hour), State: "Done"}, cfg.Receivers[0].StaleIssues)
+
+	_, err = Load(strings.Replace(conf, "after: 1d", "after: 0s", 1))
+	require.EqualError(t, err, `bad stale_issues config in defaults section: after must be positive`)
+	_, err = Load(strings.Replace(conf, "state: Done", "state: ''", 1))
+	require.EqualError(t, err, `bad stale_issues config in defaults section: state cannot be empty`)
+}
+
+func TestEscalationConfig(t *testing.T) {
+	const conf = `
+defaults:
+  api_url: https://jiralert.atlassian.net
+  user: jiralert
+  password: JIRAlert
+  issue_type: Bug
+  summary: summary
+  reopen_state: "To Do"
+  reopen_duration: 0h
+  escalation:
+    priorities: [Low, Medium, High]
+    after: [4h, 1d]
+receivers:
+  - name: 'jira-sre'
+    project: SRE
+  - name: 'jira-owner'
+    project: OWN
+    escalation:
+      priorities: [Minor, Major]
+      flapping: true
+template: jiralert.tmpl
+`
+	cfg, err := Load(conf)
+	require.NoError(t, err)
+	require.Equal(t, &EscalationConfig{Priorities: []string{"Low", "Medium", "High"}, After: []Duration{Duration(4 * time.Hour), Duration(24 * time.Hour)}}, cfg.Receivers[0].Escalation)
+	require.Equal(t, &EscalationConfig{Priorities: []string{"Minor", "Major"}, Flapping: true}, cfg.Receivers[1].Escalation)
+
+	_, err = Load(strings.Replace(conf, "[Minor, Major]", "[Major]", 1))
+	require.EqualError(t, err, `bad escalation config in receiver "jira-owner": at least two priorities are required`)
+	_, err = Load(strings.Replace(conf, "[Low, Medium, High]", "[Low, High, High]", 1))
+	require.EqualError(t, err, `bad escalation config in defaults section: duplicate priority "High"`)
+	_, err = Load(strings.Replace(conf, "flapping: true", "flapping: false", 1))
+	require.EqualError(t, err, `bad escalation config in receiver "jira-owner": after or flapping is required`)
+	_, err = Load(strings.Replace(conf, "[4h, 1d]", "[4h, 0s]", 1))
+	require.EqualError(t, err, `bad escalation config in defaults section: after durations must be positive`)
+}
+
+func TestManagedFieldsConfig(t *testing.T) {
+	const conf = `
+defaults:
+  api_url: https://jiralert.atlassian.net
+  user: jiralert
+  password: JIRAlert
+  issue_type: Bug
+  summary: summary
+  reopen_state: "To Do"
+  reopen_duration: 0h
+receivers:
+  - name: 'jira-sre'
+    project: SRE
+    managed_fields:
+      fire_count: customfield_10010
+      last_seen: customfield_10012
+    fields:
+      customfield_10001: value
+template: jiralert.tmpl
+`
+	cfg, err := Load(conf)
+	require.NoError(t, err)
+	require.Equal(t, []string{"customfield_10010", "customfield_10012"}, cfg.Receivers[0].ManagedFields.IDs())
+
+	_, err = Load(strings.Replace(conf, "customfield_10012", "duedate", 1))
+	require.EqualError(t, err, `bad managed_fields config in receiver "jira-sre": "duedate" is not a custom field ID`)
+	_, err = Load(strings.Replace(conf, "customfield_10012", "customfield_10010", 1))
+	require.EqualError(t, err, `bad managed_fields config in receiver "jira-sre": field "customfield_10010" is used more than once`)
+	_, err = Load(strings.Replace(conf, "customfield_10001", "customfield_10012", 1))
+	require.EqualError(t, err, `bad managed_fields config in receiver "jira-sre": field "customfield_10012" is also set in 'fields'`)
+}
+
+func TestRateLimitConfig(t *testing.T) {
+	const conf = `
+defaults:
+  api_url: https://jiralert.atlassian.net
+  user: jiralert
+  password: JIRAlert
+  rate_limit:
+    requests_per_second: 5
+    burst: 10
+  issue_type: Bug
+  summary: summary
+  reopen_state: "To Do"
+  reopen_duration: 0h
+receivers:
+  - name: 'jira-ab'
+    project: AB
+  - name: 'jira-cd'
+    project: CD
+template: jiralert.tmpl
+`
+	cfg, err := Load(conf)
+	require.NoError(t, err)
+	require.Equal(t, &RateLimitConfig{RequestsPerSecond: 5, Burst: 10}, cfg.Receivers[1].RateLimit)
+
+	_, err = Load(strings.Replace(conf, "    project: CD", "    project: CD\n    rate_limit:\n      requests_per_second: 1", 1))
+	require.EqualError(t, err, `bad rate_limit config in receiver "jira-cd": differs from receiver "jira-ab" with the same api_url`)
+	_, err = Load(strings.Replace(conf, "requests_per_second: 5", "requests_per_second: 0", 1))
+	require.EqualError(t, err, `bad rate_limit config in receiver "jira-ab": requests_per_second must be positive`)
+}
+
+func TestRetryConfig(t *testing.T) {
+	const conf = `
+defaults:
+  api_url: https://jiralert.atlassian.net
+  user: jiralert
+  password: JIRAlert
+  issue_type: Bug
+  summary: summary
+  reopen_state: "To Do"
+  reopen_duration: 0h
+  retry:
+    network_errors: false
+receivers:
+  - name: 'jira-ab'
+    project: AB
+  - name: 'jira-cd'
+    project: CD
+    retry:
+      status_codes: [429, 503]
+template: jiralert.tmpl
+`
+	cfg, err := Load(conf)
+	require.NoError(t, err)
+	disabled, enabled := false, true
+	require.Equal(t, &RetryConfig{StatusCodes: DefaultRetryStatusCodes, NetworkErrors: &disabled}, cfg.Receivers[0].Retry)
+	require.Equal(t, &RetryConfig{StatusCodes: []int{429, 503}, NetworkErrors: &enabled}, cfg.Receivers[1].Retry)
+
+	_, err = Load(strings.Replace(conf, "[429, 503]", "[429, 403]", 1))
+	require.EqualError(t, err, `bad retry config in receiver "jira-cd": status code 403 cannot be retried, as Jira rejects the same request again`)
+	_, err = Load(strings.Replace(conf, "[429, 503]", "[302]", 1))
+	require.EqualError(t, err, `bad retry config in receiver "jira-cd": status code 302 is not an error status code`)
+}
+
+func TestMaxAlertsInDescription(t *testing.T) {
+	const conf = `
+defaults:
+  api_url: https://jiralert.atlassian.net
+  user: jiralert
+  password: JIRAlert
+  issue_type: Bug
+  summary: summary
+  reopen_state: "To Do"
+  reopen_duration: 0h
+  max_alerts_in_description: 100
+receivers:
+  - name: 'jira-ab'
+    project: AB
+  - name: 'jira-cd'
+    project: CD
+    max_alerts_in_description: 10
+template: jiralert.tmpl
+`
+	cfg, err := Load(conf)
+	require.NoError(t, err)
+	require.Equal(t, 100, *cfg.Receivers[0].MaxAlertsInDescription)
+	require.Equal(t, 10, *cfg.Receivers[1].MaxAlertsInDescription)
+
+	_, err = Load(strings.Replace(conf, "max_alerts_in_description: 10\n", "max_alerts_in_description: 0\n", 1))
+	require.EqualError(t, err, `bad config in receiver "jira-cd", 'max_alerts_in_description' must be positive`)
+}
+
+func TestAlertOrderConfig(t *testing.T) {
+	const conf = `
+defaults:
+  api_url: https://jiralert.atlassian.net
+  user: jiralert
+  password: JIRAlert
+  issue_type: Bug
+  summary: summary
+  reopen_state: "To Do"
+  reopen_duration: 0h
+  alert_order:
+    limit: 50
+receivers:
+  - name: 'jira-ab'
+    project: AB
+  - name: 'jira-cd'
+    project: CD
+    alert_order:
+      sort_by: [-starts_at]
+      severity_label: priority
+template: jiralert.tmpl
+`
+	cfg, err := Load(conf)
+	require.NoError(t, err)
+	require.Equal(t, &AlertOrderConfig{
+		SortBy:        []string{AlertOrderSeverity, AlertOrderStartsAt, AlertOrderLabels},
+		SeverityLabel: "severity",
+		Severities:    DefaultSeverities,
+		Limit:         50,
+	}, cfg.Receivers[0].AlertOrder)
+	require.Equal(t, &AlertOrderConfig{
+		SortBy:        []string{"-starts_at"},
+		SeverityLabel: "priority",
+		Severities:    DefaultSeverities,
+	}, cfg.Receivers[1].AlertOrder)
+
+	_, err = Load(strings.Replace(conf, "[-starts_at]", "[endsAt]", 1))
+	require.EqualError(t, err, `bad alert_order config in receiver "jira-cd": unknown sort key "endsAt", must be one of starts_at, severity or labels, optionally prefixed with -`)
+	_, err = Load(strings.Replace(conf, "limit: 50", "limit: -1", 1))
+	require.EqualError(t, err, `bad alert_order config in defaults section: limit must not be negative`)
+}
+
+func TestWebhookAuthConfig(t *testing.T) {
+	const conf = `
+defaults:
+  api_url: https://jiralert.atlassian.net
+  user: jiralert
+  password: JIRAlert
+  issue_type: Bug
+  summary: summary
+  reopen_state: "To Do"
+  reopen_duration: 0h
+receivers:
+  - name: 'jira-ab'
+    project: AB
+template: jiralert.tmpl
+webhook_auth:
+  basic_auth:
+    username: alertmanager
+    password: s3cr3t
+`
+	cfg, err := Load(conf)
+	require.NoError(t, err)
+	require.Equal(t, &WebhookAuthConfig{BasicAuth: &BasicAuthConfig{Username: "alertmanager", Password: "s3cr3t"}}, cfg.WebhookAuth)
+	require.NotContains(t, cfg.String(), "s3cr3t")
+
+	_, err = Load(conf + "  bearer_token: t0k3n\n")
+	require.EqualError(t, err, "bad webhook_auth config: exactly one of basic_auth and bearer_token must be set")
+	_, err = Load(strings.Replace(conf, "    password: s3cr3t\n", "", 1))
+	require.EqualError(t, err, "bad webhook_auth config: basic_auth requires username and password")
+}
+
+func TestTracingConfig(t *testing.T) {
+	const conf = `
+defaults:
+  api_url: https://jiralert.atlassian.net
+  user: jiralert
+  password: JIRAlert
+  issue_type: Bug
+  summary: summary
+  reopen_state: "To Do"
+  reopen_duration: 0h
+receivers:
+  - name: 'jira-ab'
+    project: AB
+template: jiralert.tmpl
+tracing:
+  endpoint: otel-collector:4318
+  sampling_fraction: 0.5
+  headers:
+    Authorization: Bearer t0k3n
+`
+	cfg, err := Load(conf)
+	require.NoError(t, err)
+	fraction := 0.5
+	require.Equal(t, &TracingConfig{
+		Endpoint:         "otel-collector:4318",
+		SamplingFraction: &fraction,
+		Headers:          map[string]Secret{"Authorization": "Bearer t0k3n"},
+	}, cfg.Tracing)
+	require.NotContains(t, cfg.String(), "t0k3n")
+
+	_, err = Load(strings.Replace(conf, "otel-collector:4318", "http://otel-collector:4318", 1))
+	require.EqualError(t, err, "bad tracing config: endpoint must be host:port, without scheme")
+	_, err = Load(strings.Replace(conf, "0.5", "2", 1))
+	require.EqualError(t, err, "bad tracing config: sampling_fraction must be between 0 and 1")
+}
+
+func TestTemplateHTTPConfig(t *testing.T) {
+	const conf = `
+defaults:
+  api_url: https://jiralert.atlassian.net
+  user: jiralert
+  password: JIRAlert
+  issue_type: Bug
+  summary: summary
+  reopen_state: "To Do"
+  reopen_duration: 0h
+receivers:
+  - name: 'jira-ab'
+    project: AB
+template: jiralert.tmpl
+template_http:
+  allowed_hosts: ['cmdb.example.com', '*.ownership.example.com']
+  headers:
+    Authorization: Bearer t0k3n
+`
+	cfg, err := Load(conf)
+	require.NoError(t, err)
+	timeout, ttl := Duration(DefaultTemplateHTTPTimeout), Duration(DefaultTemplateHTTPCacheTTL)
+	require.Equal(t, &TemplateHTTPConfig{
+		AllowedHosts: []string{"cmdb.example.com", "*.ownership.example.com"},
+		Timeout:      &timeout,
+		CacheTTL:     &ttl,
+		Headers:      map[string]Secret{"Authorization": "Bearer t0k3n"},
+	}, cfg.TemplateHTTP)
+	require.NotContains(t, cfg.String(), "t0k3n")
+
+	_, err = Load(strings.Replace(conf, "'cmdb.example.com'", "'https://cmdb.example.com/'", 1))
+	require.EqualError(t, err, `bad template_http config: allowed host "https://cmdb.example.com/" must be a host name or host:port`)
+	_, err = Load(strings.Replace(conf, "  allowed_hosts: ['cmdb.example.com', '*.ownership.example.com']\n", "", 1))
+	require.EqualError(t, err, "bad template_http config: allowed_hosts cannot be empty")
+	_, err = Load(strings.Replace(conf, "template_http:\n", "template_http:\n  timeout: 0s\n", 1))
+	require.EqualError(t, err, "bad template_http config: timeout must be positive")
+}
+
+func TestClusterConfig(t *testing.T) {
+	const conf = `
+defaults:
+  api_url: https://jiralert.atlassian.net
+  user: jiralert
+  password: JIRAlert
+  issue_type: Bug
+  summary: summary
+  reopen_state: "To Do"
+  reopen_duration: 0h
+receivers:
+  - name: 'jira-ab'
+    project: AB
+template: jiralert.tmpl
+cluster:
+  peers: ['http://jiralert-0.jiralert:9097', 'http://jiralert-1.jiralert:9097']
+  self: 'http://jiralert-1.jiralert:9097'
+`
+	cfg, err := Load(conf)
+	require.NoError(t, err)
+	timeout := Duration(DefaultClusterForwardTimeout)
+	require.Equal(t, &ClusterConfig{
+		Peers:          []string{"http://jiralert-0.jiralert:9097", "http://jiralert-1.jiralert:9097"},
+		Self:           "http://jiralert-1.jiralert:9097",
+		ForwardTimeout: &timeout,
+	}, cfg.Cluster)
+
+	_, err = Load(strings.Replace(conf, "self: 'http://jiralert-1.jiralert:9097'", "self: 'http://jiralert-2.jiralert:9097'", 1))
+	require.EqualError(t, err, `bad cluster config: self "http://jiralert-2.jiralert:9097" must be one of the peers`)
+	_, err = Load(strings.Replace(conf, "'http://jiralert-0.jiralert:9097'", "'jiralert-0.jiralert:9097'", 1))
+	require.EqualError(t, err, `bad cluster config: peer "jiralert-0.jiralert:9097" must be an http or https URL`)
+	_, err = Load(strings.Replace(conf, "'http://jiralert-0.jiralert:9097'", "'http://jiralert-1.jiralert:9097'", 1))
+	require.EqualError(t, err, `bad cluster config: duplicate peer "http://jiralert-1.jiralert:9097"`)
+}
+
+func TestSecretRedaction(t *testing.T) {
+	cfg := &ReceiverConfig{Name: "jira", Password: "JIRAlert", PasswordFile: "/etc/password"}
+
+	b, err := json.Marshal(cfg)
+	require.NoError(t, err)
+	require.NotContains(t, string(b), "JIRAlert")
+	require.Contains(t, string(b), "/etc/password")
+
+	y, err := yaml.Marshal(cfg)
+	require.NoError(t, err)
+	require.NotContains(t, string(y), "JIRAlert")
+}
+
+func TestIngestConfig(t *testing.T) {
+	const conf = `
+defaults:
+  api_url: https://jiralert.atlassian.net
+  user: jiralert
+  password: JIRAlert
+  issue_type: Bug
+  summary: summary
+  reopen_state: "To Do"
+  reopen_duration: 0h
+receivers:
+  - name: 'jira-sre'
+    project: SRE
+ingest:
+  - name: cloudwatch
+    template: '{{ template "ingest.cloudwatch" . }}'
+    receiver: jira-sre
+  - name: scripts
+    template: '{{ template "ingest.scripts" . }}'
+template: jiralert.tmpl
+`
+	cfg, err := Load(conf)
+	require.NoError(t, err)
+	require.Equal(t, "jira-sre", cfg.IngestByName("cloudwatch").Receiver)
+	require.Nil(t, cfg.IngestByName("sns"))
+
+	_, err = Load(strings.Replace(conf, "receiver: jira-sre", "receiver: jira-ops", 1))
+	require.EqualError(t, err, `bad ingest config of endpoint "cloudwatch": unknown receiver "jira-ops"`)
+	_, err = Load(strings.Replace(conf, "name: scripts", "name: cloudwatch", 1))
+	require.EqualError(t, err, `bad ingest config: duplicate endpoint "cloudwatch"`)
+	_, err = Load(strings.Replace(conf, "name: scripts", "name: a/b", 1))
+	require.EqualError(t, err, `bad ingest config: name "a/b" must only contain letters, digits, '_', '.' and '-'`)
+	_, err = Load(strings.Replace(conf, `template: '{{ template "ingest.scripts" . }}'`, "", 1))
+	require.EqualError(t, err, `bad ingest config of endpoint "scripts": template cannot be empty`)
+}
+
+func TestPresetsConfig(t *testing.T) {
+	const conf = `
+defaults:
+  api_url: https://jiralert.atlassian.net
+  user: jiralert
+  password: JIRAlert
+  issue_type: Task
+  summary: summary
+  reopen_state: "To Do"
+  reopen_duration: 0h
+receivers:
+  - name: 'jira-sre'
+    project: SRE
+    presets:
+      - matchers: ['severity="critical"', team=~db|storage]
+        issue_type: Bug
+        priority: Critical
+        fields:
+          customfield_10001:
+            value: red
+template: jiralert.tmpl
+`
+	cfg, err := Load(conf)
+	require.NoError(t, err)
+	p := cfg.Receivers[0].Presets[0]
+	require.Equal(t, `severity="critical"`, p.Matchers[0].String())
+	require.Equal(t, `team=~"db|storage"`, p.Matchers[1].String())
+	require.Equal(t, tcontainer.MarshalMap{"value": "red"}, p.Fields["customfield_10001"])
+	require.True(t, p.Matches(map[string]string{"severity": "critical", "team": "db"}))
+	require.False(t, p.Matches(map[string]string{"severity": "critical", "team": "dba"}))
+	require.False(t, p.Matches(map[string]string{"team": "db"}))
+
+	for _, tc := range []struct {
+		matcher string
+		value   string
+		matches bool
+	}{
+		{matcher: `severity!=critical`, value: "", matches: true},
+		{matcher: `severity!~"crit.*"`, value: "critical", matches: false},
+		{matcher: ` severity = "a b" `, value: "a b", matches: true},
+	} {
+		m, err := ParseMatcher(tc.matcher)
+		require.NoError(t, err)
+		require.Equal(t, tc.matches, m.Matches(tc.value), tc.matcher)
+	}
+
+	_, err = Load(strings.Replace(conf, `team=~db|storage`, `team=~(`, 1))
+	require.Error(t, err)
+	_, err = Load(strings.Replace(conf, `team=~db|storage`, `"team db"`, 1))
+	require.Error(t, err)
+	_, err = Load(strings.Replace(conf, `['severity="critical"', team=~db|storage]`, `[]`, 1))
+	require.EqualError(t, err, `bad presets config in receiver "jira-sre": preset 0 has no matchers`)
+}
+
+func TestSchedulesConfig(t *testing.T) {
+	const conf = `
+defaults:
+  api_url: https://jiralert.atlassian.net
+  user: jiralert
+  password: JIRAlert
+  issue_type: Bug
+  summary: summary
+  reopen_state: "To Do"
+  reopen_duration: 0h
+receivers:
+  - name: 'jira-sre'
+    project: SRE
+    schedules:
+      - name: out-of-hours
+        time_zone: Europe/Berlin
+        windows:
+          - weekdays: [saturday, sunday]
+          - weekdays: ['monday:friday']
+            times:
+              - {start_time: "00:00", end_time: "08:00"}
+              - {start_time: "18:00", end_time: "24:00"}
+        project: NOC
+template: jiralert.tmpl
+`
+	cfg, err := Load(conf)
+	require.NoError(t, err)
+	s := cfg.Receivers[0].Schedules[0]
+	berlin, err := time.LoadLocation("Europe/Berlin")
+	require.NoError(t, err)
+	for _, tc := range []struct {
+		time   time.Time
+		active bool
+	}{
+		// Wednesday.
+		{time: time.Date(2023, 3, 1, 7, 59, 0, 0, berlin), active: true},
+		{time: time.Date(2023, 3, 1, 8, 0, 0, 0, berlin), active: false},
+		{time: time.Date(2023, 3, 1, 17, 59, 0, 0, berlin), active: false},
+		{time: time.Date(2023, 3, 1, 23, 59, 0, 0, berlin), active: true},
+		// 17:30 in Berlin.
+		{time: time.Date(2023, 3, 1, 16, 30, 0, 0, time.UTC), active: false},
+		// 18:30 in Berlin.
+		{time: time.Date(2023, 3, 1, 17, 30, 0, 0, time.UTC), active: true},
+		// Saturday noon.
+		{time: time.Date(2023, 3, 4, 12, 0, 0, 0, berlin), active: true},
+	} {
+		require.Equal(t, tc.active, s.Active(tc.time), tc.time.String())
+	}
+
+	for _, tc := range []struct {
+		old, new, err string
+	}{
+		{old: "time_zone: Europe/Berlin", new: "time_zone: Mars/Olympus", err: `unknown time zone "Mars/Olympus" of schedule "out-of-hours"`},
+		{old: "'monday:friday'", new: "'monday:fri'", err: `bad weekday "monday:fri", expected a day of the week or a range like monday:friday`},
+		{old: `end_time: "08:00"`, new: `end_time: "8"`, err: `bad time of day "8", expected HH:MM`},
+		{old: `end_time: "08:00"`, new: `end_time: "00:00"`, err: `start_time 00:00 must be before end_time 00:00`},
+		{old: "project: NOC", new: "", err: `bad schedules config in receiver "jira-sre": schedule "out-of-hours" overrides neither project nor priority`},
+	} {
+		_, err = Load(strings.Replace(conf, tc.old, tc.new, 1))
+		require.Error(t, err)
+		require.Contains(t, err.Error(), tc.err)
+	}
+}
+
+func TestMaintenanceWindowsConfig(t *testing.T) {
+	const conf = `
+defaults:
+  api_url: https://jiralert.atlassian.net
+  user: jiralert
+  password: JIRAlert
+  issue_type: Bug
+  summary: summary
+  reopen_state: "To Do"
+  reopen_duration: 0h
+  maintenance_windows:
+    - name: upgrade-eu-1
+      matchers: ['cluster="eu-1"']
+      start: 2023-03-01T22:00:00Z
+      end: 2023-03-02T02:00:00Z
+    - name: sunday-night
+      time_zone: Europe/Berlin
+      windows:
+        - weekdays: [sunday]
+          times:
+            - {start_time: "01:00", end_time: "03:00"}
+receivers:
+  - name: 'jira-sre'
+    project: SRE
+template: jiralert.tmpl
+`
+	cfg, err := Load(conf)
+	require.NoError(t, err)
+	windows := cfg.Receivers[0].MaintenanceWindows
+	require.Len(t, windows, 2)
+	berlin, err := time.LoadLocation("Europe/Berlin")
+	require.NoError(t, err)
+	for _, tc := range []struct {
+		window int
+		labels map[string]string
+		time   time.Time
+		active bool
+	}{
+		{window: 0, labels: map[string]string{"cluster": "eu-1"}, time: time.Date(2023, 3, 1, 21, 59, 0, 0, time.UTC), active: false},
+		{window: 0, labels: map[string]string{"cluster": "eu-1"}, time: time.Date(2023, 3, 1, 22, 0, 0, 0, time.UTC), active: true},
+		{window: 0, labels: map[string]string{"cluster": "eu-2"}, time: time.Date(2023, 3, 1, 22, 0, 0, 0, time.UTC), active: false},
+		{window: 0, labels: map[string]string{"cluster": "eu-1"}, time: time.Date(2023, 3, 2, 2, 0, 0, 0, time.UTC), active: false},
+		// Sunday.
+		{window: 1, time: time.Date(2023, 3, 5, 2, 0, 0, 0, berlin), active: true},
+		{window: 1, time: time.Date(2023, 3, 5, 3, 0, 0, 0, berlin), active: false},
+		{window: 1, time: time.Date(2023, 3, 6, 2, 0, 0, 0, berlin), active: false},
+	} {
+		require.Equal(t, tc.active, windows[tc.window].Active(tc.labels, tc.time), "%s %s %v", windows[tc.window].Name, tc.time, tc.labels)
+	}
+
+	for _, tc := range []struct {
+		old, new, err string
+	}{
+		{old: "time_zone: Europe/Berlin", new: "time_zone: Mars/Olympus", err: `unknown time zone "Mars/Olympus" of maintenance window "sunday-night"`},
+		{old: "end: 2023-03-02T02:00:00Z", new: "end: 2023-03-01T22:00:00Z", err: `bad maintenance_windows config in defaults section: start of maintenance window "upgrade-eu-1" must be before its end`},
+		{old: "name: sunday-night", new: "name: upgrade-eu-1", err: `duplicate maintenance window "upgrade-eu-1"`},
+		{old: "      windows:\n        - weekdays: [sunday]\n          times:\n            - {start_time: \"01:00\", end_time: \"03:00\"}\n", new: "", err: `maintenance window "sunday-night" needs a start, an end or windows`},
+	} {
+		_, err = Load(strings.Replace(conf, tc.old, tc.new, 1))
+		require.Error(t, err)
+		require.Contains(t, err.Error(), tc.err)
+	}
+}
+
+func TestWontFixSilenceConfig(t *testing.T) {
+	const conf = `
+defaults:
+  api_url: https://jiralert.atlassian.net
+  user: jiralert
+  password: JIRAlert
+  issue_type: Bug
+  summary: summary
+  reopen_state: "To Do"
+  reopen_duration: 0h
+  wont_fix_resolution: "Won't Fix"
+  alertmanager_url: http://alertmanager:9093
+  wont_fix_silence:
+    duration: 7d
+receivers:
+  - name: 'jira-sre'
+    project: SRE
+  - name: 'jira-owner'
+    project: OWN
+    wont_fix_silence:
+      duration: 1d
+      created_by: owner
+template: jiralert.tmpl
+`
+	cfg, err := Load(conf)
+	require.NoError(t, err)
+	require.Equal(t, &WontFixSilenceConfig{Duration: Duration(7 * 24 * time.Hour), CreatedBy: "jiralert"}, cfg.Receivers[0].WontFixSilence)
+	require.Equal(t, &WontFixSilenceConfig{Duration: Duration(24 * time.Hour), CreatedBy: "owner"}, cfg.Receivers[1].WontFixSilence)
+
+	_, err = Load(strings.Replace(conf, "duration: 1d", "duration: 0s", 1))
+	require.EqualError(t, err, `bad wont_fix_silence config in receiver "jira-owner": duration must be positive`)
+	_, err = Load(strings.Replace(conf, "  alertmanager_url: http://alertmanager:9093\n", "", 1))
+	require.EqualError(t, err, `bad wont_fix_silence config in receiver "jira-sre": wont_fix_resolution and alertmanager_url are required`)
+}
+
+func TestPrometheusURLConfig(t *testing.T) {
+	const conf = `
+defaults:
+  api_url: https://jiralert.atlassian.net
+  user: jiralert
+  password: JIRAlert
+  issue_type: Bug
+  summary: summary
+  reopen_state: "To Do"
+  reopen_duration: 0h
+  prometheus_url: http://prometheus:9090
+receivers:
+  - name: 'jira-sre'
+    project: SRE
+  - name: 'jira-owner'
+    project: OWN
+    prometheus_url: https://prometheus.example.com
+template: jiralert.tmpl
+`
+	cfg, err := Load(conf)
+	require.NoError(t, err)
+	require.Equal(t, "http://prometheus:9090", cfg.Receivers[0].PrometheusURL)
+	require.Equal(t, "https://prometheus.example.com", cfg.Receivers[1].PrometheusURL)
+
+	_, err = Load(strings.Replace(conf, "https://prometheus.example.com", "prometheus:9090", 1))
+	require.EqualError(t, err, `invalid prometheus_url "prometheus:9090" in receiver "jira-owner": must be an http or https URL`)
+}
+
+func TestGraphConfig(t *testing.T) {
+	const conf = `
+defaults:
+  api_url: https://jiralert.atlassian.net
+  user: jiralert
+  password: JIRAlert
+  issue_type: Bug
+  summary: summary
+  reopen_state: "To Do"
+  reopen_duration: 0h
+  graph:
+    url: 'https://grafana.example.com/render/d-solo/abc/alerts?panelId=2'
+    headers:
+      Authorization: 'Bearer t0k3n'
+receivers:
+  - name: 'jira-sre'
+    project: SRE
+  - name: 'jira-owner'
+    project: OWN
+    graph:
+      url: 'https://grafana.example.com/render/d-solo/def/owner?panelId=4'
+      before: 30m
+      width: 1000
+template: jiralert.tmpl
+`
+	cfg, err := Load(conf)
+	require.NoError(t, err)
+	hour, halfHour, timeout := Duration(time.Hour), Duration(30*time.Minute), Duration(30*time.Second)
+	require.Equal(t, &GraphConfig{
+		URL:      "https://grafana.example.com/render/d-solo/abc/alerts?panelId=2",
+		Before:   &hour,
+		Width:    800,
+		Height:   400,
+		Filename: "graph.png",
+		Timeout:  &timeout,
+		Headers:  map[string]Secret{"Authorization": "Bearer t0k3n"},
+	}, cfg.Receivers[0].Graph)
+	require.Equal(t, &GraphConfig{
+		URL:      "https://grafana.example.com/render/d-solo/def/owner?panelId=4",
+		Before:   &halfHour,
+		Width:    1000,
+		Height:   400,
+		Filename: "graph.png",
+		Timeout:  &timeout,
+	}, cfg.Receivers[1].Graph)
+
+	_, err = Load(strings.Replace(conf, "width: 1000", "width: -1", 1))
+	require.EqualError(t, err, `bad graph config in receiver "jira-owner": width and height cannot be negative`)
+}
+
+func TestRunbookLinkConfig(t *testing.T) {
+	const conf = `
+defaults:
+  api_url: https://jiralert.atlassian.net
+  user: jiralert
+  password: JIRAlert
+  issue_type: Bug
+  summary: summary
+  reopen_state: "To Do"
+  reopen_duration: 0h
+  runbook_link: {}
+receivers:
+  - name: 'jira-sre'
+    project: SRE
+  - name: 'jira-owner'
+    project: OWN
+    runbook_link:
+      annotation: playbook
+      icon_url: 'https://example.com/playbook.png'
+template: jiralert.tmpl
+`
+	cfg, err := Load(conf)
+	require.NoError(t, err)
+	require.Equal(t, &RunbookLinkConfig{Annotation: "runbook_url", Title: "Runbook"}, cfg.Receivers[0].RunbookLink)
+	require.Equal(t, &RunbookLinkConfig{
+		Annotation: "playbook",
+		Title:      "Runbook",
+		IconURL:    "https://example.com/playbook.png",
+	}, cfg.Receivers[1].RunbookLink)
+
+	_, err = Load(strings.Replace(conf, "https://example.com/playbook.png", "example.com/playbook.png", 1))
+	require.EqualError(t, err, `bad runbook_link config in receiver "jira-owner": icon_url "example.com/playbook.png" must be an http or https URL`)
+}
+
+func TestAlertmanagerLinkConfig(t *testing.T) {
+	const conf = `
+defaults:
+  api_url: https://jiralert.atlassian.net
+  user: jiralert
+  password: JIRAlert
+  issue_type: Bug
+  summary: summary
+  reopen_state: "To Do"
+  reopen_duration: 0h
+  alertmanager_link: {}
+receivers:
+  - name: 'jira-sre'
+    project: SRE
+  - name: 'jira-owner'
+    project: OWN
+    alertmanager_link:
+      url: '{{ silenceURL "https://alertmanager.example.com" .GroupLabels }}'
+      title: 'Silence'
+template: jiralert.tmpl
+`
+	cfg, err := Load(conf)
+	require.NoError(t, err)
+	require.Equal(t, &AlertmanagerLinkConfig{
+		URL:   "{{ alertsURL .ExternalURL .GroupLabels }}",
+		Title: "Alertmanager",
+	}, cfg.Receivers[0].AlertmanagerLink)
+	require.Equal(t, &AlertmanagerLinkConfig{
+		URL:   `{{ silenceURL "https://alertmanager.example.com" .GroupLabels }}`,
+		Title: "Silence",
+	}, cfg.Receivers[1].AlertmanagerLink)
+
+	_, err = Load(strings.Replace(conf, "title: 'Silence'", "icon_url: 'icon.png'", 1))
+	require.EqualError(t, err, `bad alertmanager_link config in receiver "jira-owner": icon_url "icon.png" must be an http or https URL`)
+}
+
+func TestMapsConfig(t *testing.T) {
+	const conf = `
+defaults:
+  api_url: https://jiralert.atlassian.net
+  user: jiralert
+  password: JIRAlert
+  issue_type: Bug
+  summary: summary
+  reopen_state: "To Do"
+  reopen_duration: 0h
+receivers:
+  - name: 'jira-ab'
+    project: AB
+    fields:
+      customfield_10001: { id: '{{ lookup "teams" .CommonLabels.team }}' }
+template: jiralert.tmpl
+maps:
+  teams:
+    values:
+      sre: '10001'
+      db: '10002'
+    default: '10000'
+`
+	cfg, err := Load(conf)
+	require.NoError(t, err)
+	require.Equal(t, map[string]*MapConfig{
+		"teams": {Values: map[string]string{"sre": "10001", "db": "10002"}, Default: "10000"},
+	}, cfg.Maps)
+
+	_, err = Load(strings.Replace(conf, "    values:\n      sre: '10001'\n      db: '10002'\n", "", 1))
+	require.EqualError(t, err, `bad maps config: map "teams" has no values`)
+}
+
+func TestUsersConfig(t *testing.T) {
+	const conf = `
+defaults:
+  api_url: https://jiralert.atlassian.net
+  user: jiralert
+  password: JIRAlert
+  issue_type: Bug
+  summary: summary
+  reopen_state: "To Do"
+  reopen_duration: 0h
+  assignee: '{{ .CommonLabels.owner }}@example.com'
+  watchers: ['oncall@example.com']
+receivers:
+  - name: 'jira-sre'
+    project: SRE
+  - name: 'jira-owner'
+    project: OWN
+    reporter: jiralert@example.com
+    watchers: ['{{ .CommonLabels.team }}-lead@example.com']
+template: jiralert.tmpl
+`
+	cfg, err := Load(conf)
+	require.NoError(t, err)
+	require.Equal(t, "{{ .CommonLabels.owner }}@example.com", cfg.Receivers[0].Assignee)
+	require.Equal(t, "", cfg.Receivers[0].Reporter)
+	require.Equal(t, []string{"oncall@example.com"}, cfg.Receivers[0].Watchers)
+	require.Equal(t, "{{ .CommonLabels.owner }}@example.com", cfg.Receivers[1].Assignee)
+	require.Equal(t, "jiralert@example.com", cfg.Receivers[1].Reporter)
+	require.Equal(t, []string{"{{ .CommonLabels.team }}-lead@example.com"}, cfg.Receivers[1].Watchers)
+}
+
+func TestFallbackProjectConfig(t *testing.T) {
+	const conf = `
+defaults:
+  api_url: https://jiralert.atlassian.net
+  user: jiralert
+  password: JIRAlert
+  issue_type: Bug
+  summary: summary
+  reopen_state: "To Do"
+  reopen_duration: 0h
+  fallback_project: OPS
+receivers:
+  - name: 'jira-teams'
+    project: '{{ .CommonLabels.team | toUpper }}'
+    allowed_projects: [DB, WEB]
+template: jiralert.tmpl
+`
+	cfg, err := Load(conf)
+	require.NoError(t, err)
+	require.Equal(t, []string{"DB", "WEB"}, cfg.Receivers[0].AllowedProjects)
+	require.Equal(t, "OPS", cfg.Receivers[0].FallbackProject)
+	require.Equal(t, "", cfg.Receivers[0].FallbackIssueType)
+
+	cfg, err = Load(strings.Replace(conf, "fallback_project: OPS", "fallback_project: OPS\n  fallback_issue_type: Task", 1))
+	require.NoError(t, err)
+	require.Equal(t, "Task", cfg.Receivers[0].FallbackIssueType)
+	_, err = Load(strings.Replace(conf, "fallback_project: OPS", "fallback_issue_type: '{{ .CommonLabels.type }}'", 1))
+	require.EqualError(t, err, `invalid fallback_issue_type "{{ .CommonLabels.type }}" in receiver "jira-teams": cannot be a template`)
+
+	_, err = Load(strings.Replace(conf, "fallback_project: OPS", "fallback_project: '{{ .CommonLabels.team }}'", 1))
+	require.EqualError(t, err, `invalid fallback_project "{{ .CommonLabels.team }}" in receiver "jira-teams": must be a project key`)
+	_, err = Load(strings.Replace(conf, "[DB, WEB]", "[DB, WEB-2]", 1))
+	require.EqualError(t, err, `invalid project key "WEB-2" in 'allowed_projects' of receiver "jira-teams"`)
+}
+
+func TestIdentityConfig(t *testing.T) {
+	const conf = `
+defaults:
+  api_url: https://jiralert.atlassian.net
+  user: jiralert
+  password: JIRAlert
+  issue_type: Bug
+  summary: summary
+  reopen_state: "To Do"
+  reopen_duration: 0h
+receivers:
+  - name: 'jira-sre'
+    project: SRE
+    identity:
+      strategy: field
+      field: customfield_10100
+      migrate_from: hash
+template: jiralert.tmpl
+`
+	cfg, err := Load(conf)
+	require.NoError(t, err)
+	require.Equal(t, &IdentityConfig{Strategy: IdentityField, Field: "customfield_10100", MigrateFrom: IdentityHash}, cfg.Receivers[0].Identity)
+
+	_, err = Load(strings.Replace(conf, "      field: customfield_10100\n", "", 1))
+	require.EqualError(t, err, `bad identity config in receiver "jira-sre": field strategy requires 'field'`)
+	_, err = Load(strings.Replace(conf, "migrate_from: hash", "migrate_from: property", 1))
+	require.EqualError(t, err, `bad identity config in receiver "jira-sre": issue properties are not supported, as JQL only searches those indexed by a Jira app`)
+	_, err = Load(strings.Replace(conf, "migrate_from: hash", "migrate_from: labels", 1))
+	require.EqualError(t, err, `bad identity config in receiver "jira-sre": unknown strategy "labels"`)
+}
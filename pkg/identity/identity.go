@@ -0,0 +1,249 @@
+// Copyright 2017 The Prometheus Authors
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package identity computes how an alert group is identified on the Jira issues managed for it.
+package identity
+
+import (
+	"crypto/sha512"
+	"encoding/hex"
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+
+	"github.com/pkg/errors"
+	"github.com/prometheus-community/jiralert/pkg/alertmanager"
+	"github.com/prometheus-community/jiralert/pkg/config"
+)
+
+// Strategy identifies the Jira issues of an alert group.
+type Strategy interface {
+	// Query returns a JQL condition matching the issues identified as the given group.
+	Query(groupLabels alertmanager.KV) string
+	// Labels returns the labels identifying a new issue as the given group.
+	Labels(groupLabels alertmanager.KV) []string
+	// Fields returns the fields identifying a new issue as the given group.
+	Fields(groupLabels alertmanager.KV) map[string]interface{}
+}
+
+// New creates the Strategy described by the given configuration. If c is nil, the hash or legacy label strategy is
+// used, depending on hashJiraLabel.
+func New(c *config.IdentityConfig, hashJiraLabel bool) (Strategy, error) {
+	if c == nil {
+		if hashJiraLabel {
+			return HashLabel{}, nil
+		}
+		return LegacyLabel{}, nil
+	}
+
+	primary, err := newStrategy(c.Strategy, c.Field, hashJiraLabel)
+	if err != nil {
+		return nil, err
+	}
+	if c.MigrateFrom == "" {
+		return primary, nil
+	}
+	secondary, err := newStrategy(c.MigrateFrom, c.Field, hashJiraLabel)
+	if err != nil {
+		return nil, err
+	}
+	return Migration{Primary: primary, Secondary: secondary, DualWrite: c.DualWrite != nil && *c.DualWrite}, nil
+}
+
+func newStrategy(name string, field string, hashJiraLabel bool) (Strategy, error) {
+	switch name {
+	case "":
+		if hashJiraLabel {
+			return HashLabel{}, nil
+		}
+		return LegacyLabel{}, nil
+	case config.IdentityHash:
+		return HashLabel{}, nil
+	case config.IdentityLabel:
+		return LegacyLabel{}, nil
+	case config.IdentityField:
+		if field == "" {
+			return nil, errors.New("identity field strategy requires a field")
+		}
+		return Field{Field: field}, nil
+	}
+	return nil, errors.Errorf("unknown identity strategy %q", name)
+}
+
+// HashLabel identifies issues by a JIRALERT{sha512(groupLabels)} label. Hashing ensures that Jira validation still
+// accepts the label even if the combined length of all group label pairs would be longer than 255 characters.
+type HashLabel struct{}
+
+// Query implements Strategy.
+func (HashLabel) Query(groupLabels alertmanager.KV) string {
+	return HashLabel{}.hashQuery(Hash(groupLabels))
+}
+
+func (HashLabel) hashQuery(hash string) string {
+	return fmt.Sprintf("labels=%q", hash)
+}
+
+// Labels implements Strategy.
+func (HashLabel) Labels(groupLabels alertmanager.KV) []string {
+	return []string{Hash(groupLabels)}
+}
+
+// Fields implements Strategy.
+func (HashLabel) Fields(alertmanager.KV) map[string]interface{} { return nil }
+
+// LegacyLabel identifies issues by a label in the form of an ALERT Prometheus metric name, with all spaces removed.
+type LegacyLabel struct{}
+
+// Query implements Strategy.
+func (LegacyLabel) Query(groupLabels alertmanager.KV) string {
+	return fmt.Sprintf("labels=%q", Legacy(groupLabels))
+}
+
+// Labels implements Strategy.
+func (LegacyLabel) Labels(groupLabels alertmanager.KV) []string {
+	return []string{Legacy(groupLabels)}
+}
+
+// Fields implements Strategy.
+func (LegacyLabel) Fields(alertmanager.KV) map[string]interface{} { return nil }
+
+// Field identifies issues by the group hash stored in a (text) custom field, leaving issue labels untouched.
+type Field struct {
+	// Field is the custom field key, e.g. customfield_10100.
+	Field string
+}
+
+// Query implements Strategy.
+func (f Field) Query(groupLabels alertmanager.KV) string {
+	return f.hashQuery(Hash(groupLabels))
+}
+
+func (f Field) hashQuery(hash string) string {
+	name := f.Field
+	if id := strings.TrimPrefix(f.Field, "customfield_"); id != f.Field {
+		name = "cf[" + id + "]"
+	}
+	// Text fields only support the contains operator; quoting turns it into a phrase match.
+	return fmt.Sprintf(`%s ~ "\"%s\""`, name, hash)
+}
+
+// Labels implements Strategy.
+func (Field) Labels(alertmanager.KV) []string { return nil }
+
+// Fields implements Strategy.
+func (f Field) Fields(groupLabels alertmanager.KV) map[string]interface{} {
+	return map[string]interface{}{f.Field: Hash(groupLabels)}
+}
+
+// Migration searches issues identified by either of two strategies, so existing issues keep being found while
+// moving from one scheme to another. New issues are identified by the primary strategy, and also by the secondary
+// one if DualWrite is set.
+type Migration struct {
+	Primary, Secondary Strategy
+	DualWrite          bool
+}
+
+// Query implements Strategy.
+func (m Migration) Query(groupLabels alertmanager.KV) string {
+	return fmt.Sprintf("(%s or %s)", m.Primary.Query(groupLabels), m.Secondary.Query(groupLabels))
+}
+
+// Labels implements Strategy.
+func (m Migration) Labels(groupLabels alertmanager.KV) []string {
+	labels := m.Primary.Labels(groupLabels)
+	if m.DualWrite {
+		labels = append(labels, m.Secondary.Labels(groupLabels)...)
+	}
+	return labels
+}
+
+// Fields implements Strategy.
+func (m Migration) Fields(groupLabels alertmanager.KV) map[string]interface{} {
+	fields := map[string]interface{}{}
+	if m.DualWrite {
+		for k, v := range m.Secondary.Fields(groupLabels) {
+			fields[k] = v
+		}
+	}
+	for k, v := range m.Primary.Fields(groupLabels) {
+		fields[k] = v
+	}
+	return fields
+}
+
+// hashRE matches the hex encoded sha512 of a JIRALERT{...} hash.
+var hashRE = regexp.MustCompile(`^[0-9a-f]{128}$`)
+
+// HashQuery returns a JQL condition matching the issues identified by the given group hash, as returned by Hash,
+// either in full or only the hex encoded part. It fails for strategies which do not identify issues by hash.
+func HashQuery(s Strategy, hash string) (string, error) {
+	hex := strings.TrimSuffix(strings.TrimPrefix(hash, "JIRALERT{"), "}")
+	if !hashRE.MatchString(hex) {
+		return "", errors.Errorf("invalid group hash %q", hash)
+	}
+	hash = "JIRALERT{" + hex + "}"
+
+	switch s := s.(type) {
+	case HashLabel:
+		return s.hashQuery(hash), nil
+	case Field:
+		return s.hashQuery(hash), nil
+	case Migration:
+		primary, perr := HashQuery(s.Primary, hash)
+		secondary, serr := HashQuery(s.Secondary, hash)
+		switch {
+		case perr != nil && serr != nil:
+			return "", perr
+		case perr != nil:
+			return secondary, nil
+		case serr != nil:
+			return primary, nil
+		}
+		return fmt.Sprintf("(%s or %s)", primary, secondary), nil
+	}
+	return "", errors.New("issues are not identified by group hash")
+}
+
+// Hash returns the group labels as a JIRALERT{sha512(groupLabels)} string.
+func Hash(groupLabels alertmanager.KV) string {
+	hash := sha512.New()
+	var kv []byte
+	for _, p := range groupLabels.SortedPairs() {
+		kv = append(kv[:0], p.Name...)
+		kv = append(kv, '=')
+		kv = strconv.AppendQuote(kv, p.Value)
+		kv = append(kv, ',')
+		_, _ = hash.Write(kv) // hash.Write can never return an error
+	}
+
+	var sum [sha512.Size]byte
+	res := make([]byte, len("JIRALERT{")+hex.EncodedLen(sha512.Size)+len("}"))
+	copy(res, "JIRALERT{")
+	hex.Encode(res[len("JIRALERT{"):], hash.Sum(sum[:0]))
+	res[len(res)-1] = '}'
+	return string(res)
+}
+
+// Legacy returns the group labels as an ALERT{...} string, with all spaces removed.
+func Legacy(groupLabels alertmanager.KV) string {
+	buf := []byte("ALERT{")
+	for _, p := range groupLabels.SortedPairs() {
+		buf = append(buf, p.Name...)
+		buf = append(buf, '=')
+		buf = strconv.AppendQuote(buf, p.Value)
+		buf = append(buf, ',')
+	}
+	buf = append(buf[:len(buf)-1], '}')
+	return strings.ReplaceAll(string(buf), " ", "")
+}
@@ -0,0 +1,124 @@
+// Copyright 2017 The Prometheus Authors
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+package identity
+
+import (
+	"testing"
+
+	"github.com/prometheus-community/jiralert/pkg/alertmanager"
+	"github.com/prometheus-community/jiralert/pkg/config"
+	"github.com/stretchr/testify/require"
+)
+
+const testHash = `JIRALERT{9897cb21a3d1ba47d2aab501ce9bc60b74bf65e26658f8e34a7fc81705e6b6eadfe6ad8edfe7c68142b3fe10f2c89127bd85e5f3687fe6b9ff1eff4b3f71dd49}`
+
+func TestToGroupTicketLabel(t *testing.T) {
+	require.Equal(t, testHash, Hash(alertmanager.KV{"a": "B", "C": "d"}))
+	require.Equal(t, `ALERT{C="d",a="B"}`, Legacy(alertmanager.KV{"a": "B", "C": "d"}))
+}
+
+func TestNew(t *testing.T) {
+	groupLabels := alertmanager.KV{"a": "B", "C": "d"}
+	dualWrite := true
+
+	for _, tcase := range []struct {
+		name           string
+		conf           *config.IdentityConfig
+		hashJiraLabel  bool
+		expectedQuery  string
+		expectedLabels []string
+		expectedFields map[string]interface{}
+	}{
+		{
+			name:           "default hash",
+			hashJiraLabel:  true,
+			expectedQuery:  `labels="` + testHash + `"`,
+			expectedLabels: []string{testHash},
+		},
+		{
+			name:           "default legacy",
+			expectedQuery:  `labels="ALERT{C=\"d\",a=\"B\"}"`,
+			expectedLabels: []string{`ALERT{C="d",a="B"}`},
+		},
+		{
+			name:           "field",
+			conf:           &config.IdentityConfig{Strategy: config.IdentityField, Field: "customfield_10100"},
+			expectedQuery:  `cf[10100] ~ "\"` + testHash + `\""`,
+			expectedFields: map[string]interface{}{"customfield_10100": testHash},
+		},
+		{
+			name:           "migration, dual read",
+			conf:           &config.IdentityConfig{Strategy: config.IdentityHash, MigrateFrom: config.IdentityLabel},
+			expectedQuery:  `(labels="` + testHash + `" or labels="ALERT{C=\"d\",a=\"B\"}")`,
+			expectedLabels: []string{testHash},
+			expectedFields: map[string]interface{}{},
+		},
+		{
+			name:           "migration, dual write",
+			conf:           &config.IdentityConfig{Strategy: config.IdentityField, Field: "customfield_10100", MigrateFrom: config.IdentityHash, DualWrite: &dualWrite},
+			expectedQuery:  `(cf[10100] ~ "\"` + testHash + `\"" or labels="` + testHash + `")`,
+			expectedLabels: []string{testHash},
+			expectedFields: map[string]interface{}{"customfield_10100": testHash},
+		},
+	} {
+		t.Run(tcase.name, func(t *testing.T) {
+			s, err := New(tcase.conf, tcase.hashJiraLabel)
+			require.NoError(t, err)
+			require.Equal(t, tcase.expectedQuery, s.Query(groupLabels))
+			require.Equal(t, tcase.expectedLabels, s.Labels(groupLabels))
+			require.Equal(t, tcase.expectedFields, s.Fields(groupLabels))
+		})
+	}
+
+	_, err := New(&config.IdentityConfig{Strategy: "property"}, true)
+	require.Error(t, err)
+}
+
+func TestHashQuery(t *testing.T) {
+	hex := testHash[len("JIRALERT{") : len(testHash)-1]
+
+	q, err := HashQuery(HashLabel{}, testHash)
+	require.NoError(t, err)
+	require.Equal(t, `labels="`+testHash+`"`, q)
+
+	q, err = HashQuery(Field{Field: "customfield_10100"}, hex)
+	require.NoError(t, err)
+	require.Equal(t, `cf[10100] ~ "\"`+testHash+`\""`, q)
+
+	// Migrations from the legacy label can only be searched by the hash of the primary strategy.
+	q, err = HashQuery(Migration{Primary: HashLabel{}, Secondary: LegacyLabel{}}, testHash)
+	require.NoError(t, err)
+	require.Equal(t, `labels="`+testHash+`"`, q)
+
+	_, err = HashQuery(LegacyLabel{}, testHash)
+	require.Error(t, err)
+	_, err = HashQuery(HashLabel{}, `JIRALERT{x" or project = "ABC}`)
+	require.Error(t, err)
+}
+
+func BenchmarkHash(b *testing.B) {
+	groupLabels := alertmanager.KV{"alertname": "HighLatency", "cluster": "eu-west-1", "namespace": "api", "severity": "critical"}
+
+	b.Run("hash", func(b *testing.B) {
+		b.ReportAllocs()
+		for i := 0; i < b.N; i++ {
+			_ = Hash(groupLabels)
+		}
+	})
+	b.Run("legacy", func(b *testing.B) {
+		b.ReportAllocs()
+		for i := 0; i < b.N; i++ {
+			_ = Legacy(groupLabels)
+		}
+	})
+}
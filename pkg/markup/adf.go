@@ -0,0 +1,454 @@
+// Copyright 2017 The Prometheus Authors
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package markup converts issue text between the markup dialects understood by Jira.
+package markup
+
+import (
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+// Node is a node of an Atlassian Document Format (ADF) document, as used by the Jira Cloud REST API v3.
+type Node struct {
+	Type    string                 `json:"type"`
+	Version int                    `json:"version,omitempty"`
+	Attrs   map[string]interface{} `json:"attrs,omitempty"`
+	Content []*Node                `json:"content,omitempty"`
+	Text    string                 `json:"text,omitempty"`
+	Marks   []Mark                 `json:"marks,omitempty"`
+}
+
+// Mark is an inline formatting applied to an ADF text node.
+type Mark struct {
+	Type  string                 `json:"type"`
+	Attrs map[string]interface{} `json:"attrs,omitempty"`
+}
+
+var (
+	headingRe = regexp.MustCompile(`^h([1-6])\.\s+(.*)$`)
+	listRe    = regexp.MustCompile(`^([*#]+|-)\s+(.*)$`)
+	codeRe    = regexp.MustCompile(`^\{(code|noformat)(?::([^}]*))?\}\s*$`)
+)
+
+// WikiToADF converts Jira wiki markup to an ADF document. Headings, lists, tables, quotes, rules, code blocks,
+// links and the common text effects are converted; anything else is kept as text.
+func WikiToADF(s string) *Node {
+	doc := &Node{Type: "doc", Version: 1}
+	lines := strings.Split(strings.ReplaceAll(s, "\r\n", "\n"), "\n")
+
+	var para []string
+	flush := func() {
+		if len(para) > 0 {
+			doc.Content = append(doc.Content, paragraph(para))
+			para = nil
+		}
+	}
+	for i := 0; i < len(lines); i++ {
+		line := lines[i]
+		if m := codeRe.FindStringSubmatch(line); m != nil {
+			var code []string
+			j := i + 1
+			for ; j < len(lines) && strings.TrimSpace(lines[j]) != "{"+m[1]+"}"; j++ {
+				code = append(code, lines[j])
+			}
+			if j < len(lines) {
+				flush()
+				block := &Node{Type: "codeBlock"}
+				if m[2] != "" {
+					block.Attrs = map[string]interface{}{"language": m[2]}
+				}
+				if len(code) > 0 {
+					block.Content = []*Node{{Type: "text", Text: strings.Join(code, "\n")}}
+				}
+				doc.Content = append(doc.Content, block)
+				i = j
+				continue
+			}
+		}
+
+		switch {
+		case strings.TrimSpace(line) == "":
+			flush()
+		case line == "----":
+			flush()
+			doc.Content = append(doc.Content, &Node{Type: "rule"})
+		case headingRe.MatchString(line):
+			flush()
+			m := headingRe.FindStringSubmatch(line)
+			level, _ := strconv.Atoi(m[1])
+			doc.Content = append(doc.Content, &Node{
+				Type:    "heading",
+				Attrs:   map[string]interface{}{"level": level},
+				Content: inline(m[2], nil),
+			})
+		case strings.HasPrefix(line, "bq. "):
+			flush()
+			doc.Content = append(doc.Content, &Node{Type: "blockquote", Content: []*Node{paragraph([]string{line[4:]})}})
+		case listRe.MatchString(line):
+			flush()
+			var items []listLine
+			for ; i < len(lines) && listRe.MatchString(lines[i]) && lines[i] != "----"; i++ {
+				m := listRe.FindStringSubmatch(lines[i])
+				items = append(items, listLine{markers: m[1], text: m[2]})
+			}
+			i--
+			for j := 0; j < len(items); {
+				var list *Node
+				list, j = buildList(items, j, 1)
+				doc.Content = append(doc.Content, list)
+			}
+		case strings.HasPrefix(line, "|"):
+			flush()
+			table := &Node{Type: "table"}
+			for ; i < len(lines) && strings.HasPrefix(lines[i], "|"); i++ {
+				table.Content = append(table.Content, tableRow(lines[i]))
+			}
+			i--
+			doc.Content = append(doc.Content, table)
+		default:
+			para = append(para, line)
+		}
+	}
+	flush()
+	return doc
+}
+
+type listLine struct {
+	markers string
+	text    string
+}
+
+// buildList builds the list starting at items[i] with the given nesting depth, returning the index of the first
+// item not part of it.
+func buildList(items []listLine, i, depth int) (*Node, int) {
+	list := &Node{Type: "bulletList"}
+	if items[i].markers[len(items[i].markers)-1] == '#' {
+		list.Type = "orderedList"
+	}
+	for i < len(items) && len(items[i].markers) >= depth {
+		if len(items[i].markers) == depth {
+			list.Content = append(list.Content, &Node{Type: "listItem", Content: []*Node{paragraph([]string{items[i].text})}})
+			i++
+			continue
+		}
+		if len(list.Content) == 0 {
+			list.Content = append(list.Content, &Node{Type: "listItem", Content: []*Node{{Type: "paragraph"}}})
+		}
+		var sub *Node
+		sub, i = buildList(items, i, depth+1)
+		last := list.Content[len(list.Content)-1]
+		last.Content = append(last.Content, sub)
+	}
+	return list, i
+}
+
+func tableRow(line string) *Node {
+	cellType, sep := "tableCell", "|"
+	if strings.HasPrefix(line, "||") {
+		cellType, sep = "tableHeader", "||"
+	}
+	line = strings.TrimSuffix(strings.TrimPrefix(strings.TrimSpace(line), sep), sep)
+
+	row := &Node{Type: "tableRow"}
+	for _, cell := range strings.Split(line, sep) {
+		row.Content = append(row.Content, &Node{Type: cellType, Content: []*Node{paragraph([]string{strings.TrimSpace(cell)})}})
+	}
+	return row
+}
+
+func paragraph(lines []string) *Node {
+	p := &Node{Type: "paragraph"}
+	for i, line := range lines {
+		if i > 0 {
+			p.Content = append(p.Content, &Node{Type: "hardBreak"})
+		}
+		p.Content = append(p.Content, inline(line, nil)...)
+	}
+	return p
+}
+
+// textEffects maps wiki text effect delimiters to ADF marks.
+var textEffects = map[byte]string{
+	'*': "strong",
+	'_': "em",
+	'-': "strike",
+	'+': "underline",
+}
+
+// inline converts a line of wiki markup to ADF text nodes, each carrying the given marks plus its own.
+func inline(s string, marks []Mark) []*Node {
+	var (
+		nodes []*Node
+		buf   strings.Builder
+	)
+	emit := func(n ...*Node) {
+		if buf.Len() > 0 {
+			nodes = append(nodes, &Node{Type: "text", Text: buf.String(), Marks: marks})
+			buf.Reset()
+		}
+		nodes = append(nodes, n...)
+	}
+
+	for i := 0; i < len(s); i++ {
+		c := s[i]
+		switch {
+		case c == '\\' && i+1 < len(s) && strings.IndexByte(wikiSpecial, s[i+1]) >= 0:
+			i++
+			buf.WriteByte(s[i])
+			continue
+		case strings.HasPrefix(s[i:], "{{"):
+			if end := strings.Index(s[i+2:], "}}"); end > 0 {
+				emit(&Node{Type: "text", Text: s[i+2 : i+2+end], Marks: withMark(marks, Mark{Type: "code"})})
+				i += end + 3
+				continue
+			}
+		case c == '[':
+			if end := strings.IndexByte(s[i:], ']'); end > 0 {
+				text, href := s[i+1:i+end], s[i+1:i+end]
+				if sep := strings.LastIndexByte(text, '|'); sep >= 0 {
+					text, href = text[:sep], text[sep+1:]
+				}
+				if isURL(href) {
+					if text == "" {
+						text = href
+					}
+					emit(inline(text, withMark(marks, linkMark(href)))...)
+					i += end
+					continue
+				}
+			}
+		case isURL(s[i:]) && (i == 0 || !isWordChar(s[i-1])):
+			end := strings.IndexAny(s[i:], " \t")
+			if end < 0 {
+				end = len(s) - i
+			}
+			url := s[i : i+end]
+			emit(&Node{Type: "text", Text: url, Marks: withMark(marks, linkMark(url))})
+			i += end - 1
+			continue
+		}
+
+		if mark, ok := textEffects[c]; ok && (i == 0 || !isWordChar(s[i-1])) && i+1 < len(s) && s[i+1] != ' ' && s[i+1] != c {
+			if end := closingEffect(s, i); end > 0 {
+				emit(inline(s[i+1:end], withMark(marks, Mark{Type: mark}))...)
+				i = end
+				continue
+			}
+		}
+		buf.WriteByte(c)
+	}
+	emit()
+	return nodes
+}
+
+// closingEffect returns the index of the delimiter closing the text effect opened at s[i], or -1.
+func closingEffect(s string, i int) int {
+	c := s[i]
+	for j := i + 2; j < len(s); j++ {
+		if s[j] == c && s[j-1] != ' ' && (j+1 == len(s) || !isWordChar(s[j+1])) {
+			return j
+		}
+	}
+	return -1
+}
+
+func withMark(marks []Mark, m Mark) []Mark {
+	return append(append([]Mark{}, marks...), m)
+}
+
+func linkMark(href string) Mark {
+	return Mark{Type: "link", Attrs: map[string]interface{}{"href": href}}
+}
+
+func isURL(s string) bool {
+	return strings.HasPrefix(s, "http://") || strings.HasPrefix(s, "https://") || strings.HasPrefix(s, "mailto:")
+}
+
+func isWordChar(c byte) bool {
+	return c >= 'a' && c <= 'z' || c >= 'A' && c <= 'Z' || c >= '0' && c <= '9'
+}
+
+// ADFToWiki converts an ADF document to Jira wiki markup. Nodes without a wiki equivalent are reduced to their
+// text.
+func ADFToWiki(n *Node) string {
+	if n == nil {
+		return ""
+	}
+	return strings.Join(blocks(n.Content, ""), "\n\n")
+}
+
+// NormalizeWiki returns s in the form Jira returns it after a round trip through ADF, so that rendered templates
+// can be compared with the fields of existing issues.
+func NormalizeWiki(s string) string {
+	return ADFToWiki(WikiToADF(s))
+}
+
+func blocks(nodes []*Node, listPrefix string) []string {
+	var out []string
+	for _, n := range nodes {
+		switch n.Type {
+		case "paragraph":
+			out = append(out, inlineWiki(n.Content))
+		case "heading":
+			level := 1
+			if l, ok := n.Attrs["level"].(float64); ok {
+				level = int(l)
+			} else if l, ok := n.Attrs["level"].(int); ok {
+				level = l
+			}
+			out = append(out, "h"+strconv.Itoa(level)+". "+inlineWiki(n.Content))
+		case "bulletList", "orderedList":
+			out = append(out, strings.Join(listWiki(n, listPrefix), "\n"))
+		case "codeBlock":
+			open := "{code}"
+			if lang, ok := n.Attrs["language"].(string); ok && lang != "" {
+				open = "{code:" + lang + "}"
+			}
+			out = append(out, open+"\n"+plainText(n.Content)+"\n{code}")
+		case "rule":
+			out = append(out, "----")
+		case "blockquote":
+			for _, b := range blocks(n.Content, listPrefix) {
+				out = append(out, "bq. "+b)
+			}
+		case "table":
+			var rows []string
+			for _, row := range n.Content {
+				var line strings.Builder
+				for _, cell := range row.Content {
+					sep := "|"
+					if cell.Type == "tableHeader" {
+						sep = "||"
+					}
+					line.WriteString(sep + strings.Join(blocks(cell.Content, ""), " "))
+					if cell == row.Content[len(row.Content)-1] {
+						line.WriteString(sep)
+					}
+				}
+				rows = append(rows, line.String())
+			}
+			out = append(out, strings.Join(rows, "\n"))
+		case "text", "hardBreak", "mention", "emoji", "inlineCard", "date", "status":
+			out = append(out, inlineWiki([]*Node{n}))
+		default:
+			out = append(out, blocks(n.Content, listPrefix)...)
+		}
+	}
+	return out
+}
+
+func listWiki(list *Node, prefix string) []string {
+	marker := "*"
+	if list.Type == "orderedList" {
+		marker = "#"
+	}
+	prefix += marker
+
+	var lines []string
+	for _, item := range list.Content {
+		var text []string
+		for _, c := range item.Content {
+			if c.Type == "bulletList" || c.Type == "orderedList" {
+				if len(text) > 0 {
+					lines = append(lines, prefix+" "+strings.Join(text, " "))
+					text = nil
+				}
+				lines = append(lines, listWiki(c, prefix)...)
+				continue
+			}
+			text = append(text, blocks([]*Node{c}, prefix)...)
+		}
+		if len(text) > 0 {
+			lines = append(lines, prefix+" "+strings.Join(text, " "))
+		}
+	}
+	return lines
+}
+
+func inlineWiki(nodes []*Node) string {
+	var b strings.Builder
+	for _, n := range nodes {
+		switch n.Type {
+		case "text":
+			b.WriteString(markText(n.Text, n.Marks))
+		case "hardBreak":
+			b.WriteString("\n")
+		case "mention":
+			b.WriteString(attr(n, "text"))
+		case "emoji":
+			b.WriteString(attr(n, "shortName"))
+		case "inlineCard":
+			b.WriteString(attr(n, "url"))
+		case "status":
+			b.WriteString(attr(n, "text"))
+		default:
+			b.WriteString(inlineWiki(n.Content))
+		}
+	}
+	return b.String()
+}
+
+func markText(text string, marks []Mark) string {
+	var link string
+	if !hasMark(marks, "code") && !(hasMark(marks, "link") && isURL(text)) {
+		text = EscapeWiki(text)
+	}
+	for i := len(marks) - 1; i >= 0; i-- {
+		switch marks[i].Type {
+		case "code":
+			text = "{{" + text + "}}"
+		case "strong":
+			text = "*" + text + "*"
+		case "em":
+			text = "_" + text + "_"
+		case "strike":
+			text = "-" + text + "-"
+		case "underline":
+			text = "+" + text + "+"
+		case "link":
+			link, _ = marks[i].Attrs["href"].(string)
+		}
+	}
+	if link == "" {
+		return text
+	}
+	if text == link {
+		return link
+	}
+	return "[" + text + "|" + link + "]"
+}
+
+func hasMark(marks []Mark, typ string) bool {
+	for _, m := range marks {
+		if m.Type == typ {
+			return true
+		}
+	}
+	return false
+}
+
+func plainText(nodes []*Node) string {
+	var b strings.Builder
+	for _, n := range nodes {
+		b.WriteString(n.Text)
+		b.WriteString(plainText(n.Content))
+	}
+	return b.String()
+}
+
+func attr(n *Node, key string) string {
+	s, _ := n.Attrs[key].(string)
+	return s
+}
@@ -0,0 +1,67 @@
+// Copyright 2017 The Prometheus Authors
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+package markup
+
+import (
+	"encoding/json"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestWikiToADF(t *testing.T) {
+	for _, tcase := range []struct {
+		name     string
+		wiki     string
+		expected string
+	}{
+		{
+			name:     "text effects",
+			wiki:     "*bold* _em_ {{code}} job_name=a-b",
+			expected: `{"type":"doc","version":1,"content":[{"type":"paragraph","content":[{"type":"text","text":"bold","marks":[{"type":"strong"}]},{"type":"text","text":" "},{"type":"text","text":"em","marks":[{"type":"em"}]},{"type":"text","text":" "},{"type":"text","text":"code","marks":[{"type":"code"}]},{"type":"text","text":" job_name=a-b"}]}]}`,
+		},
+		{
+			name:     "links",
+			wiki:     "[graph|http://prom/graph] http://am/",
+			expected: `{"type":"doc","version":1,"content":[{"type":"paragraph","content":[{"type":"text","text":"graph","marks":[{"type":"link","attrs":{"href":"http://prom/graph"}}]},{"type":"text","text":" "},{"type":"text","text":"http://am/","marks":[{"type":"link","attrs":{"href":"http://am/"}}]}]}]}`,
+		},
+		{
+			name:     "heading and nested list",
+			wiki:     "h2. Alerts\n* a\n** b\n* c",
+			expected: `{"type":"doc","version":1,"content":[{"type":"heading","attrs":{"level":2},"content":[{"type":"text","text":"Alerts"}]},{"type":"bulletList","content":[{"type":"listItem","content":[{"type":"paragraph","content":[{"type":"text","text":"a"}]},{"type":"bulletList","content":[{"type":"listItem","content":[{"type":"paragraph","content":[{"type":"text","text":"b"}]}]}]}]},{"type":"listItem","content":[{"type":"paragraph","content":[{"type":"text","text":"c"}]}]}]}]}`,
+		},
+		{
+			name:     "code block",
+			wiki:     "{code:yaml}\na: *b*\n{code}",
+			expected: `{"type":"doc","version":1,"content":[{"type":"codeBlock","attrs":{"language":"yaml"},"content":[{"type":"text","text":"a: *b*"}]}]}`,
+		},
+	} {
+		t.Run(tcase.name, func(t *testing.T) {
+			b, err := json.Marshal(WikiToADF(tcase.wiki))
+			require.NoError(t, err)
+			require.JSONEq(t, tcase.expected, string(b))
+		})
+	}
+}
+
+func TestNormalizeWiki(t *testing.T) {
+	for _, wiki := range []string{
+		"Labels:\n - alertname = Down\n - job_name = node\n\nAnnotations:\n - summary = *down*\n\nSource: http://prom/graph?g0.expr=up\n",
+		"h1. Title\n# one\n## one.one\n# two\n----\n||a||b||\n|1|2|\nbq. quoted",
+		"[link text|https://grafana/d/abc] +under+ -strike- {{up == 0}}",
+	} {
+		normalized := NormalizeWiki(wiki)
+		require.Equal(t, normalized, NormalizeWiki(normalized), "normalization of %q is not stable", wiki)
+	}
+	require.Equal(t, "h1. Title\n\n* a\n** b", NormalizeWiki("h1. Title\n- a\n** b\n"))
+}
@@ -0,0 +1,191 @@
+// Copyright 2017 The Prometheus Authors
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package markup
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+)
+
+var (
+	mdFenceRe     = regexp.MustCompile("^\\s*(```|~~~)\\s*([\\w+-]*)\\s*$")
+	mdHeadingRe   = regexp.MustCompile(`^(#{1,6})\s+(.*?)\s*#*\s*$`)
+	mdListRe      = regexp.MustCompile(`^(\s*)([-*+]|\d+[.)])\s+(.*)$`)
+	mdQuoteRe     = regexp.MustCompile(`^\s*>\s?(.*)$`)
+	mdRuleRe      = regexp.MustCompile(`^\s*([-*_])(\s*([-*_])){2,}\s*$`)
+	mdTableSepRe  = regexp.MustCompile(`^\s*\|?\s*:?-+:?\s*(\|\s*:?-+:?\s*)*\|?\s*$`)
+	mdCodeSpanRe  = regexp.MustCompile("`([^`]+)`")
+	mdImageRe     = regexp.MustCompile(`!\[([^\]]*)\]\(([^)\s]+)\)`)
+	mdLinkRe      = regexp.MustCompile(`\[([^\]]+)\]\(([^)\s]+)\)`)
+	mdAutolinkRe  = regexp.MustCompile(`<((?:https?|mailto):[^>\s]+)>`)
+	mdBareURLRe   = regexp.MustCompile(`(?:https?|mailto):[^\s]+`)
+	mdStrongRe    = regexp.MustCompile(`\*\*([^\s*](?:.*?[^\s*])?)\*\*|__([^\s_](?:.*?[^\s_])?)__`)
+	mdEmStarRe    = regexp.MustCompile(`(^|[^\w*])\*([^\s*](?:[^*]*?[^\s*])?)\*`)
+	mdStrikeRe    = regexp.MustCompile(`~~([^~]+)~~`)
+	placeholderRe = regexp.MustCompile("\x00(\\d+)\x00")
+)
+
+// MarkdownToWiki converts Markdown, as used for chat notifications, to Jira wiki markup. Headings, lists, quotes,
+// rules, fenced code blocks, tables, links, images and emphasis are converted; other text is escaped so that it is
+// not interpreted as wiki markup.
+func MarkdownToWiki(s string) string {
+	lines := strings.Split(strings.ReplaceAll(s, "\r\n", "\n"), "\n")
+	out := make([]string, 0, len(lines))
+
+	var indents []int
+	for i := 0; i < len(lines); i++ {
+		line := lines[i]
+		if m := mdFenceRe.FindStringSubmatch(line); m != nil {
+			j := i + 1
+			for ; j < len(lines) && strings.TrimSpace(lines[j]) != m[1]; j++ {
+			}
+			open := "{code}"
+			if m[2] != "" {
+				open = "{code:" + m[2] + "}"
+			}
+			out = append(out, open)
+			out = append(out, lines[i+1:min(j, len(lines))]...)
+			out = append(out, "{code}")
+			i = j
+			continue
+		}
+
+		if m := mdListRe.FindStringSubmatch(line); m != nil && !mdRuleRe.MatchString(line) {
+			// The nesting level is given by the number of distinct indentations of the enclosing items.
+			indent := len(strings.ReplaceAll(m[1], "\t", "    "))
+			for len(indents) > 0 && indents[len(indents)-1] > indent {
+				indents = indents[:len(indents)-1]
+			}
+			if len(indents) == 0 || indents[len(indents)-1] < indent {
+				indents = append(indents, indent)
+			}
+			marker := "*"
+			if m[2][0] >= '0' && m[2][0] <= '9' {
+				marker = "#"
+			}
+			out = append(out, strings.Repeat(marker, len(indents))+" "+mdInline(m[3]))
+			continue
+		}
+		indents = nil
+
+		switch {
+		case mdRuleRe.MatchString(line):
+			out = append(out, "----")
+		case mdHeadingRe.MatchString(line):
+			m := mdHeadingRe.FindStringSubmatch(line)
+			out = append(out, fmt.Sprintf("h%d. %s", len(m[1]), mdInline(m[2])))
+		case mdQuoteRe.MatchString(line):
+			out = append(out, "bq. "+mdInline(mdQuoteRe.FindStringSubmatch(line)[1]))
+		case strings.HasPrefix(strings.TrimSpace(line), "|") && i+1 < len(lines) && mdTableSepRe.MatchString(lines[i+1]):
+			out = append(out, mdTableRow(line, "||"))
+			for i += 2; i < len(lines) && strings.HasPrefix(strings.TrimSpace(lines[i]), "|"); i++ {
+				out = append(out, mdTableRow(lines[i], "|"))
+			}
+			i--
+		default:
+			out = append(out, mdInline(line))
+		}
+	}
+	return strings.Join(out, "\n")
+}
+
+func mdTableRow(line, sep string) string {
+	line = strings.TrimSpace(line)
+	line = strings.TrimSuffix(strings.TrimPrefix(line, "|"), "|")
+	cells := strings.Split(line, "|")
+	for i, c := range cells {
+		cells[i] = mdInline(strings.TrimSpace(c))
+	}
+	return sep + strings.Join(cells, sep) + sep
+}
+
+// mdInline converts the inline Markdown syntax of a line. Code spans, links and URLs are converted first and
+// protected from further processing by placeholders.
+func mdInline(s string) string {
+	var protected []string
+	protect := func(wiki string) string {
+		protected = append(protected, wiki)
+		return fmt.Sprintf("\x00%d\x00", len(protected)-1)
+	}
+
+	s = mdCodeSpanRe.ReplaceAllStringFunc(s, func(m string) string {
+		return protect("{{" + mdCodeSpanRe.FindStringSubmatch(m)[1] + "}}")
+	})
+	s = mdImageRe.ReplaceAllStringFunc(s, func(m string) string {
+		return protect("!" + mdImageRe.FindStringSubmatch(m)[2] + "!")
+	})
+	s = mdLinkRe.ReplaceAllStringFunc(s, func(m string) string {
+		sm := mdLinkRe.FindStringSubmatch(m)
+		return protect("[" + EscapeWiki(sm[1]) + "|" + sm[2] + "]")
+	})
+	s = mdAutolinkRe.ReplaceAllStringFunc(s, func(m string) string {
+		return protect(mdAutolinkRe.FindStringSubmatch(m)[1])
+	})
+	s = mdBareURLRe.ReplaceAllStringFunc(s, protect)
+
+	// Emphasis delimiters are replaced by placeholders too, so the remaining text can be escaped.
+	s = mdStrongRe.ReplaceAllString(s, "\x01$1$2\x01")
+	s = mdEmStarRe.ReplaceAllString(s, "$1\x02$2\x02")
+	s = mdStrikeRe.ReplaceAllString(s, "\x03$1\x03")
+	s = escapeInline(s)
+	s = strings.NewReplacer("\x01", "*", "\x02", "_", "\x03", "-").Replace(s)
+
+	return placeholderRe.ReplaceAllStringFunc(s, func(m string) string {
+		var i int
+		fmt.Sscanf(placeholderRe.FindStringSubmatch(m)[1], "%d", &i)
+		return protected[i]
+	})
+}
+
+// escapeInline escapes the characters of s which would start wiki markup, except _ which Markdown and wiki
+// markup use alike for emphasis.
+func escapeInline(s string) string {
+	var b strings.Builder
+	for i := 0; i < len(s); i++ {
+		switch s[i] {
+		case '{', '}', '[', ']', '|', '*', '+', '\\':
+			b.WriteByte('\\')
+		case '-':
+			// A lone dash is harmless; only escape dashes which could open or close a strikethrough.
+			if (i == 0 || !isWordChar(s[i-1])) != (i+1 == len(s) || !isWordChar(s[i+1])) {
+				b.WriteByte('\\')
+			}
+		}
+		b.WriteByte(s[i])
+	}
+	return b.String()
+}
+
+// EscapeWiki escapes s so that Jira displays it verbatim instead of interpreting it as wiki markup.
+func EscapeWiki(s string) string {
+	var b strings.Builder
+	for i := 0; i < len(s); i++ {
+		if strings.IndexByte(wikiSpecial, s[i]) >= 0 {
+			b.WriteByte('\\')
+		}
+		b.WriteByte(s[i])
+	}
+	return b.String()
+}
+
+// wikiSpecial are the characters which may start wiki markup.
+const wikiSpecial = `*_-+{}[]|!#^~\`
+
+func min(a, b int) int {
+	if a < b {
+		return a
+	}
+	return b
+}
@@ -0,0 +1,67 @@
+// Copyright 2017 The Prometheus Authors
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+package markup
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestMarkdownToWiki(t *testing.T) {
+	for _, tcase := range []struct {
+		name     string
+		markdown string
+		expected string
+	}{
+		{
+			name:     "emphasis and code",
+			markdown: "**Down** since *5m*, ~~flapping~~, query `up{job=\"node\"} == 0`",
+			expected: "*Down* since _5m_, -flapping-, query {{up{job=\"node\"} == 0}}",
+		},
+		{
+			name:     "links",
+			markdown: "[Runbook](https://runbooks/node-down) and <https://grafana/d/abc> ![graph](https://prom/graph.png)",
+			expected: "[Runbook|https://runbooks/node-down] and https://grafana/d/abc !https://prom/graph.png!",
+		},
+		{
+			name:     "escaped text",
+			markdown: "labels {job=\"node\"} [a] 1 + 1 - x -y job_name",
+			expected: "labels \\{job=\"node\"\\} \\[a\\] 1 \\+ 1 - x \\-y job_name",
+		},
+		{
+			name:     "headings, lists and quotes",
+			markdown: "## Alerts\n- a\n  - b\n    1. c\n- d\n> note\n---",
+			expected: "h2. Alerts\n* a\n** b\n### c\n* d\nbq. note\n----",
+		},
+		{
+			name:     "fenced code",
+			markdown: "```yaml\na: **b**\n```\ndone",
+			expected: "{code:yaml}\na: **b**\n{code}\ndone",
+		},
+		{
+			name:     "table",
+			markdown: "| name | value |\n|------|------:|\n| job | node |",
+			expected: "||name||value||\n|job|node|",
+		},
+	} {
+		t.Run(tcase.name, func(t *testing.T) {
+			require.Equal(t, tcase.expected, MarkdownToWiki(tcase.markdown))
+		})
+	}
+}
+
+func TestEscapeWiki(t *testing.T) {
+	require.Equal(t, `\*not bold\* \{code\} a\_b`, EscapeWiki("*not bold* {code} a_b"))
+	require.Equal(t, `\*not bold\* \{code\} a\_b`, NormalizeWiki(EscapeWiki("*not bold* {code} a_b")))
+}
@@ -0,0 +1,115 @@
+// Copyright 2017 The Prometheus Authors
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package notify
+
+import (
+	"sort"
+	"strings"
+
+	"github.com/prometheus-community/jiralert/pkg/alertmanager"
+	"github.com/prometheus-community/jiralert/pkg/config"
+)
+
+// orderAlerts returns the notification with its alerts sorted by the alert_order of the receiver, if any. The order
+// doesn't change how notifications are handled, only the order in which templates list the alerts.
+func (r *Receiver) orderAlerts(data *alertmanager.Data) *alertmanager.Data {
+	if r.conf.AlertOrder == nil || len(data.Alerts) < 2 {
+		return data
+	}
+	d := *data
+	d.Alerts = sortAlerts(data.Alerts, r.conf.AlertOrder)
+	return &d
+}
+
+// templateData returns the notification passed to templates, with at most the alert_order limit of alerts. The
+// alerts left out are added to TruncatedAlerts, so that templates may show how many alerts they leave out. The
+// notification itself keeps all alerts, so that e.g. a limit leaving out all firing alerts doesn't resolve the issue.
+func (r *Receiver) templateData(data *alertmanager.Data) *alertmanager.Data {
+	c := r.conf.AlertOrder
+	if c == nil || c.Limit == 0 || len(data.Alerts) <= c.Limit {
+		return data
+	}
+	d := *data
+	d.Alerts = data.Alerts[:c.Limit:c.Limit]
+	d.TruncatedAlerts += uint64(len(data.Alerts) - c.Limit)
+	return &d
+}
+
+// sortAlerts returns a copy of the alerts sorted by the sort keys of c, keeping the order of alerts they don't tell
+// apart.
+func sortAlerts(alerts alertmanager.Alerts, c *config.AlertOrderConfig) alertmanager.Alerts {
+	// The keys of each alert are computed once rather than on each comparison.
+	severities := make([]int, len(alerts))
+	labels := make([]string, len(alerts))
+	ranks := make(map[string]int, len(c.Severities))
+	for i := len(c.Severities) - 1; i >= 0; i-- {
+		ranks[c.Severities[i]] = i
+	}
+	for i := range alerts {
+		rank, ok := ranks[alerts[i].Labels[c.SeverityLabel]]
+		if !ok {
+			rank = len(c.Severities)
+		}
+		severities[i] = rank
+		labels[i] = labelsKey(alerts[i].Labels)
+	}
+
+	idx := make([]int, len(alerts))
+	for i := range idx {
+		idx[i] = i
+	}
+	sort.SliceStable(idx, func(i, j int) bool {
+		a, b := idx[i], idx[j]
+		for _, key := range c.SortBy {
+			var cmp int
+			switch strings.TrimPrefix(key, "-") {
+			case config.AlertOrderStartsAt:
+				if alerts[a].StartsAt.Before(alerts[b].StartsAt) {
+					cmp = -1
+				} else if alerts[a].StartsAt.After(alerts[b].StartsAt) {
+					cmp = 1
+				}
+			case config.AlertOrderSeverity:
+				cmp = severities[a] - severities[b]
+			case config.AlertOrderLabels:
+				cmp = strings.Compare(labels[a], labels[b])
+			}
+			if strings.HasPrefix(key, "-") {
+				cmp = -cmp
+			}
+			if cmp != 0 {
+				return cmp < 0
+			}
+		}
+		return false
+	})
+
+	res := make(alertmanager.Alerts, len(alerts))
+	for i, k := range idx {
+		res[i] = alerts[k]
+	}
+	return res
+}
+
+// labelsKey returns the label pairs as a string which sorts label sets pair by pair.
+func labelsKey(kv alertmanager.KV) string {
+	var b strings.Builder
+	for _, p := range kv.SortedPairs() {
+		b.WriteString(p.Name)
+		b.WriteByte(0)
+		b.WriteString(p.Value)
+		b.WriteByte(0)
+	}
+	return b.String()
+}
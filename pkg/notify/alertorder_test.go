@@ -0,0 +1,89 @@
+// Copyright 2017 The Prometheus Authors
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+package notify
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/go-kit/log"
+	"github.com/prometheus-community/jiralert/pkg/alertmanager"
+	"github.com/prometheus-community/jiralert/pkg/config"
+	"github.com/prometheus-community/jiralert/pkg/template"
+	"github.com/stretchr/testify/require"
+)
+
+func TestSortAlerts(t *testing.T) {
+	t0 := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+	alerts := alertmanager.Alerts{
+		{Labels: alertmanager.KV{"instance": "a", "severity": "warning"}, StartsAt: t0},
+		{Labels: alertmanager.KV{"instance": "b", "severity": "page"}, StartsAt: t0},
+		{Labels: alertmanager.KV{"instance": "c", "severity": "critical"}, StartsAt: t0.Add(time.Minute)},
+		{Labels: alertmanager.KV{"instance": "d", "severity": "critical"}, StartsAt: t0},
+		{Labels: alertmanager.KV{"instance": "e", "severity": "warning"}, StartsAt: t0},
+	}
+	instances := func(alerts alertmanager.Alerts) []string {
+		var res []string
+		for _, a := range alerts {
+			res = append(res, a.Labels["instance"])
+		}
+		return res
+	}
+
+	c := &config.AlertOrderConfig{
+		SortBy:        []string{config.AlertOrderSeverity, config.AlertOrderStartsAt, config.AlertOrderLabels},
+		SeverityLabel: "severity",
+		Severities:    config.DefaultSeverities,
+	}
+	// Unknown severities come last.
+	require.Equal(t, []string{"d", "c", "a", "e", "b"}, instances(sortAlerts(alerts, c)))
+
+	c.SortBy = []string{"-" + config.AlertOrderStartsAt, "-" + config.AlertOrderLabels}
+	require.Equal(t, []string{"c", "e", "d", "b", "a"}, instances(sortAlerts(alerts, c)))
+
+	// Alerts the keys don't tell apart keep their order.
+	c.SortBy = []string{config.AlertOrderStartsAt}
+	require.Equal(t, []string{"a", "b", "d", "e", "c"}, instances(sortAlerts(alerts, c)))
+	// The alerts of the notification are left as is.
+	require.Equal(t, []string{"a", "b", "c", "d", "e"}, instances(alerts))
+}
+
+func TestNotifyAlertOrder(t *testing.T) {
+	fakeJira := newTestFakeJira()
+	conf := testReceiverConfig1()
+	conf.AlertOrder = &config.AlertOrderConfig{
+		SortBy:        []string{config.AlertOrderSeverity},
+		SeverityLabel: "severity",
+		Severities:    config.DefaultSeverities,
+		Limit:         1,
+	}
+	conf.Summary = `{{ range .Alerts }}{{ .Labels.instance }}{{ end }} and {{ .TruncatedAlerts }} more`
+	conf.Description = `{{ range .Alerts.Firing }}{{ .Labels.instance }}{{ end }}`
+	data := &alertmanager.Data{
+		Status: alertmanager.AlertFiring,
+		Alerts: alertmanager.Alerts{
+			{Status: alertmanager.AlertFiring, Labels: alertmanager.KV{"instance": "a", "severity": "warning"}},
+			{Status: alertmanager.AlertResolved, Labels: alertmanager.KV{"instance": "b", "severity": "critical"}},
+		},
+		GroupLabels: alertmanager.KV{"a": "b"},
+	}
+
+	_, err := NewReceiver(log.NewNopLogger(), conf, template.SimpleTemplate(), fakeJira).Notify(context.Background(), data, Options{MaxDescriptionLength: 32768})
+	require.NoError(t, err)
+	// Templates only see the first alert, but the alert group is still firing.
+	issue := fakeJira.issuesByKey["1"]
+	require.Equal(t, "b and 1 more", issue.Fields.Summary)
+	require.Equal(t, "", issue.Fields.Description)
+	require.Equal(t, "a", data.Alerts[0].Labels["instance"])
+}
@@ -0,0 +1,170 @@
+// Copyright 2017 The Prometheus Authors
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package notify
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	"github.com/andygrunwald/go-jira"
+	"github.com/go-kit/log"
+	"github.com/go-kit/log/level"
+	"github.com/pkg/errors"
+	"github.com/prometheus-community/jiralert/pkg/clientset"
+	"github.com/prometheus-community/jiralert/pkg/config"
+)
+
+// bulkRequest is an issue waiting to be created in a bulk create request.
+type bulkRequest struct {
+	issue *jira.Issue
+	done  chan bulkResult
+}
+
+type bulkResult struct {
+	issue *jira.Issue
+	retry bool
+	err   error
+}
+
+// bulkBatch gathers the issues of one receiver until they are sent.
+type bulkBatch struct {
+	client   jiraIssueService
+	retry    *config.RetryConfig
+	logger   log.Logger
+	requests []*bulkRequest
+	timer    *time.Timer
+	once     sync.Once
+}
+
+// BulkCreator gathers the issues created by receivers with a bulk_create_wait into bulk create requests, by receiver.
+type BulkCreator struct {
+	mtx     sync.Mutex
+	batches map[string]*bulkBatch
+}
+
+// NewBulkCreator creates a BulkCreator without pending issues.
+func NewBulkCreator() *BulkCreator {
+	return &BulkCreator{batches: map[string]*bulkBatch{}}
+}
+
+// Create adds the issue to the pending batch of the receiver, starting a batch sent after wait if there is none, and
+// returns the created issue once the batch is sent. Full batches are sent right away. The first issue of a batch
+// sends it through the given client, classifying errors with the given retry settings, for all the issues of the
+// batch.
+//
+// Issues are created even if ctx is done before their batch is sent, as the other issues of the batch are.
+func (b *BulkCreator) Create(ctx context.Context, receiver string, client jiraIssueService, retry *config.RetryConfig, issue *jira.Issue, wait time.Duration, logger log.Logger) (*jira.Issue, bool, error) {
+	req := &bulkRequest{issue: issue, done: make(chan bulkResult, 1)}
+
+	b.mtx.Lock()
+	batch, ok := b.batches[receiver]
+	if !ok {
+		batch = &bulkBatch{client: client, retry: retry, logger: logger}
+		b.batches[receiver] = batch
+		batch.timer = time.AfterFunc(wait, func() { b.send(receiver, batch) })
+	}
+	batch.requests = append(batch.requests, req)
+	full := len(batch.requests) >= clientset.MaxBulkCreate
+	if full {
+		delete(b.batches, receiver)
+	}
+	b.mtx.Unlock()
+	if full {
+		batch.timer.Stop()
+		go b.send(receiver, batch)
+	}
+
+	select {
+	case res := <-req.done:
+		return res.issue, res.retry, res.err
+	case <-ctx.Done():
+		// Alertmanager retries webhook requests timing out on its side, so should we.
+		return nil, true, errors.Wrap(ctx.Err(), "wait for bulk create")
+	}
+}
+
+// send sends the batch, once, after taking it out of the pending batches.
+func (b *BulkCreator) send(receiver string, batch *bulkBatch) {
+	b.mtx.Lock()
+	if b.batches[receiver] == batch {
+		delete(b.batches, receiver)
+	}
+	b.mtx.Unlock()
+	batch.once.Do(batch.send)
+}
+
+// send creates the issues of the batch, using the bulk create API unless there is only one, and passes the results
+// to the waiting receivers. The requests are not tied to the context of any of them.
+func (batch *bulkBatch) send() {
+	ctx := context.Background()
+	if len(batch.requests) == 1 {
+		created, resp, err := batch.client.CreateWithContext(ctx, batch.requests[0].issue)
+		res := bulkResult{issue: created}
+		if err != nil {
+			res = bulkResult{}
+			res.retry, res.err = handleCreateErrResponse(resp, err, batch.retry, batch.logger)
+		}
+		batch.requests[0].done <- res
+		return
+	}
+
+	issues := make([]*jira.Issue, 0, len(batch.requests))
+	for _, req := range batch.requests {
+		issues = append(issues, req.issue)
+	}
+	level.Debug(batch.logger).Log("msg", "bulk create", "issues", len(issues))
+	created, errs, resp, err := batch.client.BulkCreateWithContext(ctx, issues)
+	if err != nil {
+		// The response body can only be read once, so the error is the same for all issues.
+		retry, err := handleJiraErrResponse("Issue.BulkCreate", resp, err, batch.retry, batch.logger)
+		for _, req := range batch.requests {
+			req.done <- bulkResult{retry: retry, err: err}
+		}
+		return
+	}
+	for i, req := range batch.requests {
+		if errs[i] == nil {
+			req.done <- bulkResult{issue: created[i]}
+			continue
+		}
+		var bulkErr *clientset.BulkCreateError
+		retry := errors.As(errs[i], &bulkErr) && retriesStatus(bulkErr.Status, batch.retry)
+		err := errors.Wrap(errs[i], "JIRA bulk create failed")
+		if bulkErr != nil {
+			err = &JiraError{
+				API:        "Issue.BulkCreate",
+				StatusCode: bulkErr.Status,
+				Messages:   bulkErr.ElementErrors.ErrorMessages,
+				Fields:     bulkErr.ElementErrors.Errors,
+				msg:        err.Error(),
+			}
+		}
+		if rejectsIssueType(err) {
+			err = invalidIssueTypeError{err}
+		}
+		req.done <- bulkResult{retry: retry, err: err}
+	}
+}
+
+// WithBulkCreator makes receivers with a bulk_create_wait create issues through c.
+func (r *Receiver) WithBulkCreator(c *BulkCreator) *Receiver {
+	r.bulk = c
+	return r
+}
+
+// bulkCreates reports whether the receiver gathers the issues it creates into bulk create requests.
+func (r *Receiver) bulkCreates() bool {
+	return r.bulk != nil && !r.dryRun && r.conf.BulkCreateWait != nil && *r.conf.BulkCreateWait > 0
+}
@@ -0,0 +1,116 @@
+// Copyright 2017 The Prometheus Authors
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+package notify
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/andygrunwald/go-jira"
+	"github.com/go-kit/log"
+	"github.com/prometheus-community/jiralert/pkg/clientset"
+	"github.com/stretchr/testify/require"
+)
+
+// bulkFakeJira records bulk and single creates, failing to create issues with the summary "bad".
+type bulkFakeJira struct {
+	jiraIssueService
+
+	mtx     sync.Mutex
+	bulks   []int
+	creates int
+}
+
+func (f *bulkFakeJira) CreateWithContext(_ context.Context, issue *jira.Issue) (*jira.Issue, *jira.Response, error) {
+	f.mtx.Lock()
+	defer f.mtx.Unlock()
+	f.creates++
+	return &jira.Issue{Key: "ABC-" + issue.Fields.Summary}, nil, nil
+}
+
+func (f *bulkFakeJira) BulkCreateWithContext(_ context.Context, issues []*jira.Issue) ([]*jira.Issue, []error, *jira.Response, error) {
+	f.mtx.Lock()
+	defer f.mtx.Unlock()
+	f.bulks = append(f.bulks, len(issues))
+	created := make([]*jira.Issue, len(issues))
+	errs := make([]error, len(issues))
+	for i, issue := range issues {
+		if issue.Fields.Summary == "bad" {
+			errs[i] = &clientset.BulkCreateError{Status: 400, ElementErrors: jira.Error{Errors: map[string]string{"summary": "invalid"}}, FailedElementNumber: i}
+			continue
+		}
+		created[i] = &jira.Issue{Key: "ABC-" + issue.Fields.Summary}
+	}
+	return created, errs, nil, nil
+}
+
+func TestBulkCreator(t *testing.T) {
+	b := NewBulkCreator()
+	client := &bulkFakeJira{}
+	create := func(summary string, wait time.Duration) (*jira.Issue, bool, error) {
+		issue := &jira.Issue{Fields: &jira.IssueFields{Summary: summary}}
+		return b.Create(context.Background(), "r", client, nil, issue, wait, log.NewNopLogger())
+	}
+
+	// Issues created within the wait are sent in one request.
+	var wg sync.WaitGroup
+	results := map[string]error{}
+	var mtx sync.Mutex
+	for _, summary := range []string{"1", "2", "bad"} {
+		wg.Add(1)
+		go func(summary string) {
+			defer wg.Done()
+			issue, retry, err := create(summary, 50*time.Millisecond)
+			require.False(t, retry)
+			if err == nil {
+				require.Equal(t, "ABC-"+summary, issue.Key)
+			}
+			mtx.Lock()
+			results[summary] = err
+			mtx.Unlock()
+		}(summary)
+	}
+	wg.Wait()
+	require.Equal(t, []int{3}, client.bulks)
+	require.NoError(t, results["1"])
+	require.NoError(t, results["2"])
+	require.EqualError(t, results["bad"], "JIRA bulk create failed: status 400: summary: invalid")
+
+	// A single issue is created on its own.
+	issue, _, err := create("3", time.Millisecond)
+	require.NoError(t, err)
+	require.Equal(t, "ABC-3", issue.Key)
+	require.Equal(t, 1, client.creates)
+
+	// Full batches are sent without waiting.
+	for i := 0; i < clientset.MaxBulkCreate; i++ {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			_, _, err := create(fmt.Sprint(i), time.Hour)
+			require.NoError(t, err)
+		}(i)
+	}
+	wg.Wait()
+	require.Equal(t, []int{3, clientset.MaxBulkCreate}, client.bulks)
+
+	// Receivers waiting for a batch give up once their context is done.
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+	defer cancel()
+	_, retry, err := b.Create(ctx, "r", client, nil, &jira.Issue{Fields: &jira.IssueFields{Summary: "4"}}, time.Hour, log.NewNopLogger())
+	require.Error(t, err)
+	require.True(t, retry)
+}
@@ -0,0 +1,81 @@
+// Copyright 2017 The Prometheus Authors
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package notify
+
+import (
+	"fmt"
+	"strings"
+	"unicode/utf8"
+
+	"github.com/prometheus-community/jiralert/pkg/config"
+)
+
+// defaultMaxCommentLength is Jira's default limit on the length of a comment.
+const defaultMaxCommentLength = 32767
+
+// partHeaderLength is the space reserved for the "(part i/n)" header of split comments.
+const partHeaderLength = len("(part 9999/9999)\n")
+
+// commentBodies returns the comments to add for content, split or truncated according to the receiver settings.
+func commentBodies(content string, conf *config.ReceiverConfig) []string {
+	limit := defaultMaxCommentLength
+	if conf.MaxCommentLength != nil {
+		limit = *conf.MaxCommentLength
+	}
+	if len(content) <= limit {
+		return []string{content}
+	}
+	if conf.CommentOverflow == config.CommentOverflowTruncate {
+		return []string{truncateComment(content, limit)}
+	}
+	return splitComment(content, limit)
+}
+
+// splitComment splits content into numbered parts no longer than limit, breaking at line ends where possible.
+func splitComment(content string, limit int) []string {
+	var chunks []string
+	for len(content) > 0 {
+		chunk := cutAt(content, limit-partHeaderLength)
+		if len(chunk) < len(content) {
+			if i := strings.LastIndexByte(chunk, '\n'); i > 0 {
+				chunk = chunk[:i+1]
+			}
+		}
+		chunks = append(chunks, chunk)
+		content = content[len(chunk):]
+	}
+
+	parts := make([]string, 0, len(chunks))
+	for i, chunk := range chunks {
+		parts = append(parts, fmt.Sprintf("(part %d/%d)\n%s", i+1, len(chunks), chunk))
+	}
+	return parts
+}
+
+// truncateComment cuts content to fit limit, ending it with a line telling how much was left out.
+func truncateComment(content string, limit int) string {
+	note := fmt.Sprintf("\n... truncated, %d characters in total.", len(content))
+	return cutAt(content, limit-len(note)) + note
+}
+
+// cutAt returns the longest prefix of s not longer than n bytes which doesn't end in the middle of a rune.
+func cutAt(s string, n int) string {
+	if len(s) <= n {
+		return s
+	}
+	for n > 0 && !utf8.RuneStart(s[n]) {
+		n--
+	}
+	return s[:n]
+}
@@ -0,0 +1,69 @@
+// Copyright 2017 The Prometheus Authors
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+package notify
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/prometheus-community/jiralert/pkg/config"
+	"github.com/stretchr/testify/require"
+)
+
+func TestCommentBodies(t *testing.T) {
+	limit := 100
+	line := strings.Repeat("x", 39) + "\n"
+	long := strings.Repeat(line, 5)
+
+	for _, tcase := range []struct {
+		name     string
+		content  string
+		overflow string
+		expected []string
+	}{
+		{
+			name:     "short comment",
+			content:  line,
+			expected: []string{line},
+		},
+		{
+			name:    "split at line ends",
+			content: long,
+			expected: []string{
+				"(part 1/3)\n" + line + line,
+				"(part 2/3)\n" + line + line,
+				"(part 3/3)\n" + line,
+			},
+		},
+		{
+			name:     "truncate",
+			content:  long,
+			overflow: config.CommentOverflowTruncate,
+			expected: []string{long[:60] + "\n... truncated, 200 characters in total."},
+		},
+	} {
+		t.Run(tcase.name, func(t *testing.T) {
+			conf := &config.ReceiverConfig{MaxCommentLength: &limit, CommentOverflow: tcase.overflow}
+			bodies := commentBodies(tcase.content, conf)
+			require.Equal(t, tcase.expected, bodies)
+			for _, b := range bodies {
+				require.LessOrEqual(t, len(b), limit)
+			}
+		})
+	}
+}
+
+func TestCutAt(t *testing.T) {
+	require.Equal(t, "ab", cutAt("abü", 3))
+	require.Equal(t, "abü", cutAt("abü", 4))
+}
@@ -0,0 +1,61 @@
+// Copyright 2017 The Prometheus Authors
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package notify
+
+import (
+	"github.com/prometheus-community/jiralert/pkg/alertmanager"
+	"github.com/prometheus-community/jiralert/pkg/config"
+)
+
+// renderDescription renders the description of the issue in wiki markup, truncated to the max_description_length,
+// returning whether it was truncated. Rendering stops once the output is known to be truncated, and only passes the
+// first max_alerts_in_description alerts to the template, so that huge alert groups don't build huge descriptions
+// only to truncate them.
+func (r *Receiver) renderDescription(data *alertmanager.Data, opts Options) (string, bool, error) {
+	limit := opts.MaxDescriptionLength
+	if r.conf.Renderer == config.RendererMarkdown {
+		// Markdown may shrink when converted to wiki markup, e.g. **bold** to *bold*.
+		limit *= 2
+	}
+	desc, truncated, err := r.tmpl.ExecuteLimit(r.conf.Description, r.descriptionData(r.templateData(data)), limit)
+	if err != nil {
+		return "", false, err
+	}
+	desc = toWiki(desc, r.conf.Renderer)
+	if len(desc) > opts.MaxDescriptionLength {
+		desc = cutAt(desc, opts.MaxDescriptionLength)
+		truncated = true
+	}
+	return desc, truncated, nil
+}
+
+// descriptionData returns the notification with at most max_alerts_in_description alerts, firing alerts first. The
+// alerts left out are added to TruncatedAlerts, so that templates may show how many alerts the description leaves out.
+func (r *Receiver) descriptionData(data *alertmanager.Data) *alertmanager.Data {
+	if r.conf.MaxAlertsInDescription == nil || len(data.Alerts) <= *r.conf.MaxAlertsInDescription {
+		return data
+	}
+	limit := *r.conf.MaxAlertsInDescription
+	d := *data
+	d.Alerts = make(alertmanager.Alerts, 0, limit)
+	for _, firing := range []bool{true, false} {
+		for i := 0; i < len(data.Alerts) && len(d.Alerts) < limit; i++ {
+			if (data.Alerts[i].Status == alertmanager.AlertFiring) == firing {
+				d.Alerts = append(d.Alerts, data.Alerts[i])
+			}
+		}
+	}
+	d.TruncatedAlerts += uint64(len(data.Alerts) - limit)
+	return &d
+}
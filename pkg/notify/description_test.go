@@ -0,0 +1,92 @@
+// Copyright 2017 The Prometheus Authors
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+package notify
+
+import (
+	"context"
+	"strings"
+	"testing"
+
+	"github.com/go-kit/log"
+	"github.com/prometheus-community/jiralert/pkg/alertmanager"
+	"github.com/prometheus-community/jiralert/pkg/config"
+	"github.com/prometheus-community/jiralert/pkg/template"
+	"github.com/stretchr/testify/require"
+)
+
+func TestRenderDescription(t *testing.T) {
+	conf := testReceiverConfig1()
+	conf.Description = `{{ range .Alerts }}{{ .Labels.instance }} {{ .Status }}
+{{ end }}{{ with .TruncatedAlerts }}and {{ . }} more{{ end }}`
+	limit := 2
+	conf.MaxAlertsInDescription = &limit
+	receiver := NewReceiver(log.NewNopLogger(), conf, template.SimpleTemplate(), nil)
+	data := &alertmanager.Data{
+		Status: alertmanager.AlertFiring,
+		Alerts: alertmanager.Alerts{
+			{Status: alertmanager.AlertResolved, Labels: alertmanager.KV{"instance": "a"}},
+			{Status: alertmanager.AlertFiring, Labels: alertmanager.KV{"instance": "b"}},
+			{Status: alertmanager.AlertResolved, Labels: alertmanager.KV{"instance": "c"}},
+		},
+		TruncatedAlerts: 1,
+	}
+
+	// Firing alerts are kept first, and the others are counted along with the ones Alertmanager left out.
+	desc, truncated, err := receiver.renderDescription(data, Options{MaxDescriptionLength: 100})
+	require.NoError(t, err)
+	require.False(t, truncated)
+	require.Equal(t, "b firing\na resolved\nand 2 more", desc)
+	require.Len(t, data.Alerts, 3)
+
+	desc, truncated, err = receiver.renderDescription(data, Options{MaxDescriptionLength: 5})
+	require.NoError(t, err)
+	require.True(t, truncated)
+	require.Equal(t, "b fir", desc)
+
+	// Markdown is truncated after converting it to wiki markup.
+	conf.Renderer = config.RendererMarkdown
+	conf.Description = "**" + strings.Repeat("a", 10) + "**"
+	receiver = NewReceiver(log.NewNopLogger(), conf, template.SimpleTemplate(), nil)
+	desc, truncated, err = receiver.renderDescription(data, Options{MaxDescriptionLength: 12})
+	require.NoError(t, err)
+	require.False(t, truncated)
+	require.Equal(t, "*"+strings.Repeat("a", 10)+"*", desc)
+
+	// Descriptions are cut on rune boundaries.
+	conf.Description = strings.Repeat("é", 10)
+	receiver = NewReceiver(log.NewNopLogger(), conf, template.SimpleTemplate(), nil)
+	desc, truncated, err = receiver.renderDescription(data, Options{MaxDescriptionLength: 5})
+	require.NoError(t, err)
+	require.True(t, truncated)
+	require.Equal(t, "éé", desc)
+}
+
+func TestNotifyTruncatedDescription(t *testing.T) {
+	fakeJira := newTestFakeJira()
+	conf := testReceiverConfig1()
+	conf.Description = `{{ range .Alerts }}{{ .Annotations.description }}{{ end }}`
+	data := &alertmanager.Data{
+		Status:      alertmanager.AlertFiring,
+		GroupLabels: alertmanager.KV{"a": "b"},
+	}
+	for i := 0; i < 1000; i++ {
+		data.Alerts = append(data.Alerts, alertmanager.Alert{
+			Status:      alertmanager.AlertFiring,
+			Annotations: alertmanager.KV{"description": strings.Repeat("x", 100)},
+		})
+	}
+
+	_, err := NewReceiver(log.NewNopLogger(), conf, template.SimpleTemplate(), fakeJira).Notify(context.Background(), data, Options{MaxDescriptionLength: 250})
+	require.NoError(t, err)
+	require.Equal(t, strings.Repeat("x", 250), fakeJira.issuesByKey["1"].Fields.Description)
+}
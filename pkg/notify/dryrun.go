@@ -0,0 +1,304 @@
+// Copyright 2017 The Prometheus Authors
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package notify
+
+import (
+	"context"
+	"encoding/json"
+	"io"
+	"net/http"
+	"reflect"
+	"sort"
+	"time"
+
+	"github.com/andygrunwald/go-jira"
+	"github.com/pkg/errors"
+	"github.com/prometheus-community/jiralert/pkg/alertmanager"
+	"github.com/prometheus-community/jiralert/pkg/config"
+	"github.com/prometheus-community/jiralert/pkg/identity"
+	"github.com/prometheus-community/jiralert/pkg/markup"
+)
+
+// Operation is a Jira write skipped in dry-run mode.
+type Operation struct {
+	API      string      `json:"api"`
+	IssueKey string      `json:"issue_key,omitempty"`
+	Payload  interface{} `json:"payload,omitempty"`
+}
+
+// FieldDiff is the difference between the value of a field on a live issue and the value JIRAlert would write.
+type FieldDiff struct {
+	Field   string      `json:"field"`
+	Current interface{} `json:"current"`
+	Desired interface{} `json:"desired"`
+}
+
+// DryRunResult describes what a notification would have done to Jira.
+type DryRunResult struct {
+	// IssueKey is the key of the existing issue matched by the alert group, if any.
+	IssueKey   string      `json:"issue_key,omitempty"`
+	Operations []Operation `json:"operations"`
+	// Diff compares the existing issue with the issue JIRAlert would create for the alert group.
+	Diff []FieldDiff `json:"diff,omitempty"`
+	// Schedule is the name of the schedule of the receiver active at the time of the notification, if any.
+	Schedule string `json:"schedule,omitempty"`
+	// UpdatesThrottled is set if the issue changed less than min_update_interval ago, so that its summary,
+	// description, environment and comments would not be updated.
+	UpdatesThrottled bool `json:"updates_throttled,omitempty"`
+	// ResolveAt is when the issue would be resolved, should the alert group stay resolved for the auto_resolve delay.
+	ResolveAt *time.Time `json:"resolve_at,omitempty"`
+	// ResolveCanceled is set if the alert group fires again while the resolution of its issue is pending.
+	ResolveCanceled bool `json:"resolve_canceled,omitempty"`
+}
+
+// dryRunClient passes reads to the wrapped jiraIssueService and records writes instead of executing them.
+type dryRunClient struct {
+	jiraIssueService
+
+	extraFields []string
+	found       *jira.Issue
+	ops         []Operation
+}
+
+func (c *dryRunClient) SearchWithContext(ctx context.Context, jql string, options *jira.SearchOptions) ([]jira.Issue, *jira.Response, error) {
+	opts := *options
+	opts.Fields = append(append([]string{}, options.Fields...), c.extraFields...)
+	issues, resp, err := c.jiraIssueService.SearchWithContext(ctx, jql, &opts)
+	if err == nil && len(issues) > 0 {
+		c.found = &issues[0]
+	}
+	return issues, resp, err
+}
+
+func (c *dryRunClient) CreateWithContext(ctx context.Context, issue *jira.Issue) (*jira.Issue, *jira.Response, error) {
+	c.ops = append(c.ops, Operation{API: "Issue.Create", Payload: issue})
+	return issue, nil, nil
+}
+
+func (c *dryRunClient) BulkCreateWithContext(ctx context.Context, issues []*jira.Issue) ([]*jira.Issue, []error, *jira.Response, error) {
+	for _, issue := range issues {
+		c.ops = append(c.ops, Operation{API: "Issue.Create", Payload: issue})
+	}
+	return issues, make([]error, len(issues)), nil, nil
+}
+
+func (c *dryRunClient) UpdateWithOptionsWithContext(ctx context.Context, issue *jira.Issue, _ *jira.UpdateQueryOptions) (*jira.Issue, *jira.Response, error) {
+	c.ops = append(c.ops, Operation{API: "Issue.UpdateWithOptions", IssueKey: issue.Key, Payload: issue.Fields})
+	return issue, nil, nil
+}
+
+func (c *dryRunClient) UpdateIssueWithContext(ctx context.Context, jiraID string, data map[string]interface{}) (*jira.Response, error) {
+	c.ops = append(c.ops, Operation{API: "Issue.UpdateIssue", IssueKey: jiraID, Payload: data})
+	return nil, nil
+}
+
+func (c *dryRunClient) AddCommentWithContext(ctx context.Context, issueID string, comment *jira.Comment) (*jira.Comment, *jira.Response, error) {
+	c.ops = append(c.ops, Operation{API: "Issue.AddComment", IssueKey: issueID, Payload: comment})
+	return comment, nil, nil
+}
+
+func (c *dryRunClient) CreateRequestWithContext(ctx context.Context, request *jira.Request) (*jira.Request, *jira.Response, error) {
+	c.ops = append(c.ops, Operation{API: "Request.Create", Payload: request})
+	return request, nil, nil
+}
+
+func (c *dryRunClient) DoTransitionWithContext(ctx context.Context, ticketID, transitionID string) (*jira.Response, error) {
+	c.ops = append(c.ops, Operation{API: "Issue.DoTransition", IssueKey: ticketID, Payload: map[string]string{"transition": transitionID}})
+	return nil, nil
+}
+
+func (c *dryRunClient) AddLinkWithContext(ctx context.Context, issueLink *jira.IssueLink) (*jira.Response, error) {
+	c.ops = append(c.ops, Operation{API: "Issue.AddLink", IssueKey: issueLink.OutwardIssue.Key, Payload: issueLink})
+	return nil, nil
+}
+
+func (c *dryRunClient) AddWorklogRecordWithContext(ctx context.Context, issueID string, record *jira.WorklogRecord, options ...func(*http.Request) error) (*jira.WorklogRecord, *jira.Response, error) {
+	c.ops = append(c.ops, Operation{API: "Issue.AddWorklogRecord", IssueKey: issueID, Payload: record})
+	return record, nil, nil
+}
+
+func (c *dryRunClient) AddRemoteLinkWithContext(ctx context.Context, issueID string, remotelink *jira.RemoteLink) (*jira.RemoteLink, *jira.Response, error) {
+	c.ops = append(c.ops, Operation{API: "Issue.AddRemoteLink", IssueKey: issueID, Payload: remotelink})
+	return remotelink, nil, nil
+}
+
+func (c *dryRunClient) AddWatcherWithContext(ctx context.Context, issueID string, userName string) (*jira.Response, error) {
+	c.ops = append(c.ops, Operation{API: "Issue.AddWatcher", IssueKey: issueID, Payload: userName})
+	return nil, nil
+}
+
+func (c *dryRunClient) PostAttachmentWithContext(ctx context.Context, issueID string, r io.Reader, attachmentName string) (*[]jira.Attachment, *jira.Response, error) {
+	c.ops = append(c.ops, Operation{API: "Issue.PostAttachment", IssueKey: issueID, Payload: map[string]string{"filename": attachmentName}})
+	return &[]jira.Attachment{{Filename: attachmentName}}, nil, nil
+}
+
+// DryRun runs the notification without writing anything to Jira, returning the writes it would have done. If an
+// existing issue matches the alert group, it is compared field by field with the issue JIRAlert would create.
+func (r *Receiver) DryRun(ctx context.Context, data *alertmanager.Data, opts Options) (*DryRunResult, bool, error) {
+	client := &dryRunClient{jiraIssueService: r.client, extraFields: []string{"priority", "issuetype", "labels"}}
+	for key := range r.configured(data).conf.Fields {
+		client.extraFields = append(client.extraFields, key)
+	}
+	dr := *r
+	dr.client = client
+	dr.dryRun = true
+
+	res := &DryRunResult{}
+	if s := activeSchedule(r.conf.Schedules, r.timeNow()); s != nil {
+		res.Schedule = s.Name
+	}
+	dr.planned = res
+	retry, err := dr.Notify(ctx, data, opts)
+	res.Operations = client.ops
+	if err != nil {
+		return res, retry, err
+	}
+	if client.found == nil {
+		return res, false, nil
+	}
+	res.IssueKey = client.found.Key
+
+	desired, err := dr.configured(data).renderDesired(data, opts.Merge(r.conf))
+	if err != nil {
+		return res, false, err
+	}
+	res.Diff = diffIssues(client.found, desired)
+	return res, false, nil
+}
+
+// RenderResult is the issue JIRAlert would create for an alert group, as rendered from the receiver's templates.
+type RenderResult struct {
+	Receiver    string                 `json:"receiver"`
+	Project     string                 `json:"project"`
+	IssueType   string                 `json:"issue_type"`
+	Summary     string                 `json:"summary"`
+	Description string                 `json:"description"`
+	Environment string                 `json:"environment,omitempty"`
+	Priority    string                 `json:"priority,omitempty"`
+	Components  []string               `json:"components,omitempty"`
+	Labels      []string               `json:"labels"`
+	Fields      map[string]interface{} `json:"fields,omitempty"`
+	Schedule    string                 `json:"schedule,omitempty"`
+}
+
+// Render renders the issue the receiver would create for the given alert group, without calling Jira.
+func (r *Receiver) Render(data *alertmanager.Data, opts Options) (*RenderResult, error) {
+	dr := *r.configured(data)
+	dr.dryRun = true
+	issue, err := dr.renderDesired(data, opts.Merge(r.conf))
+	if err != nil {
+		return nil, err
+	}
+	res := &RenderResult{
+		Receiver:    r.conf.Name,
+		Project:     issue.Fields.Project.Key,
+		IssueType:   issue.Fields.Type.Name,
+		Summary:     issue.Fields.Summary,
+		Description: issue.Fields.Description,
+		Environment: issue.Fields.Environment,
+		Labels:      issue.Fields.Labels,
+		Fields:      issue.Fields.Unknowns,
+	}
+	if issue.Fields.Priority != nil {
+		res.Priority = issue.Fields.Priority.Name
+	}
+	if s := activeSchedule(r.conf.Schedules, r.timeNow()); s != nil {
+		res.Schedule = s.Name
+	}
+	for _, c := range issue.Fields.Components {
+		res.Components = append(res.Components, c.Name)
+	}
+	return res, nil
+}
+
+func (r *Receiver) renderDesired(data *alertmanager.Data, opts Options) (*jira.Issue, error) {
+	data = r.orderAlerts(data)
+	project, _, err := r.renderProject(data)
+	if err != nil {
+		return nil, err
+	}
+	summary, err := r.renderSummary(data)
+	if err != nil {
+		return nil, err
+	}
+	description, _, err := r.renderDescription(data, opts)
+	if err != nil {
+		return nil, errors.Wrap(err, "render issue description")
+	}
+	environment, err := r.tmpl.Execute(r.conf.Environment, r.templateData(data))
+	if err != nil {
+		return nil, errors.Wrap(err, "render issue environment")
+	}
+	if r.conf.APIVersion == config.APIVersion3 {
+		description = markup.NormalizeWiki(description)
+		environment = markup.NormalizeWiki(environment)
+	}
+	strategy, err := identity.New(r.conf.Identity, opts.HashJiraLabel)
+	if err != nil {
+		return nil, err
+	}
+	return r.newIssue(data, project, summary, description, environment, strategy)
+}
+
+// diffIssues compares the fields JIRAlert manages on the current issue with the desired ones.
+func diffIssues(current, desired *jira.Issue) []FieldDiff {
+	var diffs []FieldDiff
+	add := func(field string, c, d interface{}) {
+		if !reflect.DeepEqual(c, d) {
+			diffs = append(diffs, FieldDiff{Field: field, Current: c, Desired: d})
+		}
+	}
+
+	add("summary", current.Fields.Summary, desired.Fields.Summary)
+	add("description", current.Fields.Description, desired.Fields.Description)
+	add("environment", current.Fields.Environment, desired.Fields.Environment)
+
+	var currentPrio, desiredPrio string
+	if current.Fields.Priority != nil {
+		currentPrio = current.Fields.Priority.Name
+	}
+	if desired.Fields.Priority != nil {
+		desiredPrio = desired.Fields.Priority.Name
+	}
+	add("priority", currentPrio, desiredPrio)
+
+	currentLabels := append([]string{}, current.Fields.Labels...)
+	desiredLabels := append([]string{}, desired.Fields.Labels...)
+	sort.Strings(currentLabels)
+	sort.Strings(desiredLabels)
+	add("labels", currentLabels, desiredLabels)
+
+	keys := make([]string, 0, len(desired.Fields.Unknowns))
+	for key := range desired.Fields.Unknowns {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	for _, key := range keys {
+		add(key, normalizeJSON(current.Fields.Unknowns[key]), normalizeJSON(desired.Fields.Unknowns[key]))
+	}
+	return diffs
+}
+
+// normalizeJSON round-trips v through JSON, so values decoded from Jira compare equal to rendered ones.
+func normalizeJSON(v interface{}) interface{} {
+	b, err := json.Marshal(v)
+	if err != nil {
+		return v
+	}
+	var n interface{}
+	if err := json.Unmarshal(b, &n); err != nil {
+		return v
+	}
+	return n
+}
@@ -0,0 +1,132 @@
+// Copyright 2017 The Prometheus Authors
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package notify
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/andygrunwald/go-jira"
+	"github.com/go-kit/log/level"
+	"github.com/pkg/errors"
+	"github.com/prometheus-community/jiralert/pkg/alertmanager"
+	"github.com/prometheus-community/jiralert/pkg/config"
+)
+
+// firingFor returns how long the alert group has been firing at now, since the start of its earliest firing alert.
+func firingFor(alerts alertmanager.Alerts, now time.Time) time.Duration {
+	var start time.Time
+	for _, a := range alerts.Firing() {
+		if !a.StartsAt.IsZero() && (start.IsZero() || a.StartsAt.Before(start)) {
+			start = a.StartsAt
+		}
+	}
+	if start.IsZero() {
+		return 0
+	}
+	return now.Sub(start)
+}
+
+// priorityIndex returns the index of the priority within the escalation priorities, or -1.
+func priorityIndex(esc *config.EscalationConfig, priority string) int {
+	for i, p := range esc.Priorities {
+		if p == priority {
+			return i
+		}
+	}
+	return -1
+}
+
+func issuePriority(issue *jira.Issue) string {
+	if issue.Fields.Priority == nil {
+		return ""
+	}
+	return issue.Fields.Priority.Name
+}
+
+// escalate raises the priority of the open issue of a firing alert group one level above the priority of the
+// receiver for each escalation after duration the alert group has been firing for. Priorities are never lowered,
+// e.g. if people raised them further, and priorities other than the escalation priorities are left alone.
+func (r *Receiver) escalate(ctx context.Context, issue *jira.Issue, data *alertmanager.Data) (bool, error) {
+	esc := r.conf.Escalation
+	firing := firingFor(data.Alerts, r.timeNow())
+	steps := 0
+	for _, d := range esc.After {
+		if firing >= time.Duration(d) {
+			steps++
+		}
+	}
+	if steps == 0 || r.conf.Priority == "" {
+		return false, nil
+	}
+
+	base, err := r.execute(r.conf.Priority, data)
+	if err != nil {
+		return false, errors.Wrap(err, "render issue priority")
+	}
+	baseIndex := priorityIndex(esc, base)
+	if baseIndex < 0 {
+		level.Warn(r.logger).Log("msg", "priority is not one of the escalation priorities, not escalating", "key", issue.Key, "priority", base)
+		return false, nil
+	}
+	target := baseIndex + steps
+	if target >= len(esc.Priorities) {
+		target = len(esc.Priorities) - 1
+	}
+	current := issuePriority(issue)
+	currentIndex := priorityIndex(esc, current)
+	if (current != "" && currentIndex < 0) || currentIndex >= target {
+		return false, nil
+	}
+
+	priority := esc.Priorities[target]
+	level.Info(r.logger).Log("msg", "escalating issue", "key", issue.Key, "firing_for", firing, "from", current, "to", priority)
+	if retry, err := r.updatePriority(ctx, issue.Key, priority); err != nil {
+		return retry, err
+	}
+	if !r.dryRun {
+		issuesEscalated.WithLabelValues(r.conf.Name).Inc()
+	}
+
+	comment := fmt.Sprintf("Alert firing for %s: raising priority to %s.", firing.Round(time.Minute), priority)
+	if esc.Comment != "" {
+		if comment, err = r.execute(esc.Comment, data); err != nil {
+			return false, errors.Wrap(err, "render escalation comment")
+		}
+	}
+	if comment == "" {
+		return false, nil
+	}
+	return r.addComment(ctx, issue.Key, comment)
+}
+
+// escalateFlapping raises the priority of a flapping issue one level, if escalation is enabled for flapping issues.
+func (r *Receiver) escalateFlapping(ctx context.Context, issue *jira.Issue) error {
+	esc := r.conf.Escalation
+	if esc == nil || !esc.Flapping {
+		return nil
+	}
+	current := priorityIndex(esc, issuePriority(issue))
+	if current < 0 || current == len(esc.Priorities)-1 {
+		return nil
+	}
+	priority := esc.Priorities[current+1]
+	level.Info(r.logger).Log("msg", "escalating flapping issue", "key", issue.Key, "from", issuePriority(issue), "to", priority)
+	if _, err := r.updatePriority(ctx, issue.Key, priority); err != nil {
+		return err
+	}
+	issuesEscalated.WithLabelValues(r.conf.Name).Inc()
+	return nil
+}
@@ -0,0 +1,102 @@
+// Copyright 2017 The Prometheus Authors
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+package notify
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/andygrunwald/go-jira"
+	"github.com/go-kit/log"
+	"github.com/prometheus-community/jiralert/pkg/alertmanager"
+	"github.com/prometheus-community/jiralert/pkg/config"
+	"github.com/prometheus-community/jiralert/pkg/template"
+	"github.com/stretchr/testify/require"
+)
+
+func TestNotifyEscalation(t *testing.T) {
+	fakeJira := newTestFakeJira()
+	conf := testReceiverConfig1()
+	conf.Priority = "Medium"
+	conf.Escalation = &config.EscalationConfig{
+		Priorities: []string{"Low", "Medium", "High", "Highest"},
+		After:      []config.Duration{config.Duration(time.Hour), config.Duration(4 * time.Hour)},
+	}
+	opts := Options{MaxDescriptionLength: 32768}
+	start := time.Date(2023, 5, 1, 10, 0, 0, 0, time.UTC)
+	notifyAt := func(d time.Duration, status string) *jira.Issue {
+		data := &alertmanager.Data{
+			Status:      status,
+			Alerts:      alertmanager.Alerts{{Status: status, StartsAt: start}},
+			GroupLabels: alertmanager.KV{"a": "b"},
+		}
+		r := NewReceiver(log.NewNopLogger(), conf, template.SimpleTemplate(), fakeJira)
+		r.timeNow = func() time.Time { return start.Add(d) }
+		_, err := r.Notify(context.Background(), data, opts)
+		require.NoError(t, err)
+		return fakeJira.issuesByKey["1"]
+	}
+
+	issue := notifyAt(10*time.Minute, alertmanager.AlertFiring)
+	require.Equal(t, "Medium", issue.Fields.Priority.Name)
+	require.Nil(t, issue.Fields.Comments)
+
+	issue = notifyAt(2*time.Hour, alertmanager.AlertFiring)
+	require.Equal(t, "High", issue.Fields.Priority.Name)
+	require.Len(t, issue.Fields.Comments.Comments, 1)
+	require.Equal(t, "Alert firing for 2h0m0s: raising priority to High.", issue.Fields.Comments.Comments[0].Body)
+
+	// Each level is escalated once.
+	issue = notifyAt(3*time.Hour, alertmanager.AlertFiring)
+	require.Equal(t, "High", issue.Fields.Priority.Name)
+	require.Len(t, issue.Fields.Comments.Comments, 1)
+
+	issue = notifyAt(5*time.Hour, alertmanager.AlertFiring)
+	require.Equal(t, "Highest", issue.Fields.Priority.Name)
+	require.Len(t, issue.Fields.Comments.Comments, 2)
+
+	// Priorities are never lowered, nor changed when set to others than the escalation priorities.
+	issue.Fields.Priority = &jira.Priority{Name: "Blocker"}
+	issue = notifyAt(6*time.Hour, alertmanager.AlertFiring)
+	require.Equal(t, "Blocker", issue.Fields.Priority.Name)
+	require.Len(t, issue.Fields.Comments.Comments, 2)
+}
+
+func TestNotifyEscalationFlapping(t *testing.T) {
+	fakeJira := newTestFakeJira()
+	fakeJira.transitionsByID["5"] = jira.Transition{ID: "5", Name: "reopened"}
+	conf := testReceiverConfig1()
+	conf.Priority = "Low"
+	conf.FlapDetection = &config.FlapDetectionConfig{Reopens: 1, Window: config.Duration(time.Hour)}
+	conf.Escalation = &config.EscalationConfig{Priorities: []string{"Low", "Medium", "High"}, Flapping: true}
+	opts := Options{MaxDescriptionLength: 32768, ReopenTickets: true}
+	flaps := NewFlapTracker()
+	notify := func() *jira.Issue {
+		data := &alertmanager.Data{
+			Status:      alertmanager.AlertFiring,
+			Alerts:      alertmanager.Alerts{{Status: alertmanager.AlertFiring}},
+			GroupLabels: alertmanager.KV{"a": "b"},
+		}
+		_, err := NewReceiver(log.NewNopLogger(), conf, template.SimpleTemplate(), fakeJira).WithFlapTracker(flaps).Notify(context.Background(), data, opts)
+		require.NoError(t, err)
+		return fakeJira.issuesByKey["1"]
+	}
+
+	require.Equal(t, "Low", notify().Fields.Priority.Name)
+	fakeJira.issuesByKey["1"].Fields.Status.StatusCategory.Key = "done"
+	require.Equal(t, "Low", notify().Fields.Priority.Name)
+	// The second reopen within the hour marks the issue as flapping.
+	fakeJira.issuesByKey["1"].Fields.Status.StatusCategory.Key = "done"
+	require.Equal(t, "Medium", notify().Fields.Priority.Name)
+}
@@ -0,0 +1,84 @@
+// Copyright 2017 The Prometheus Authors
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package notify
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"github.com/andygrunwald/go-jira"
+	"github.com/go-kit/log/level"
+	"github.com/pkg/errors"
+	"github.com/prometheus-community/jiralert/pkg/alertmanager"
+)
+
+// FanOut notifies the receiver and then each of the targets, the receivers listed in its fan_out configuration, of
+// the alert group. All of them are notified even if some fail, and their outcomes are returned in the same order.
+//
+// The returned error lists the failed receivers. Retrying is safe as the receivers which succeeded find their issues
+// again, so retry is true if any failed receiver may succeed on retry.
+//
+// If a fan_out link_type is configured, newly created issues are linked with the receiver's issue, if both are in
+// the same Jira instance.
+func (r *Receiver) FanOut(ctx context.Context, targets []*Receiver, data *alertmanager.Data, opts Options) ([]Notification, bool, error) {
+	results := make([]Notification, 0, 1+len(targets))
+	var (
+		retry  bool
+		failed []string
+	)
+	for _, rcv := range append([]*Receiver{r}, targets...) {
+		n, rcvRetry, err := rcv.notifyRecorded(ctx, data, opts)
+		results = append(results, n)
+		if err != nil {
+			level.Error(rcv.logger).Log("msg", "fan-out notification failed", "receiver", rcv.conf.Name, "err", err)
+			failed = append(failed, fmt.Sprintf("receiver %s: %v", rcv.conf.Name, err))
+			retry = retry || rcvRetry
+		}
+	}
+
+	if r.conf.FanOut != nil && r.conf.FanOut.LinkType != "" {
+		for i, t := range targets {
+			r.linkIssues(ctx, results[0], t, results[i+1])
+		}
+	}
+
+	if len(failed) > 0 {
+		return results, retry, errors.Errorf("%d of %d receivers failed: %s", len(failed), len(results), strings.Join(failed, "; "))
+	}
+	return results, false, nil
+}
+
+// linkIssues links the issue of the target with the receiver's issue, if either was just created.
+func (r *Receiver) linkIssues(ctx context.Context, n Notification, target *Receiver, tn Notification) {
+	if n.IssueKey == "" || tn.IssueKey == "" || (n.Action != ActionCreated && tn.Action != ActionCreated) {
+		return
+	}
+	if strings.TrimSuffix(target.conf.APIURL, "/") != strings.TrimSuffix(r.conf.APIURL, "/") {
+		level.Debug(r.logger).Log("msg", "not linking issues in different Jira instances", "key", n.IssueKey, "linked_key", tn.IssueKey, "linked_receiver", target.conf.Name)
+		return
+	}
+	link := &jira.IssueLink{
+		Type:         jira.IssueLinkType{Name: r.conf.FanOut.LinkType},
+		OutwardIssue: &jira.Issue{Key: n.IssueKey},
+		InwardIssue:  &jira.Issue{Key: tn.IssueKey},
+	}
+	if resp, err := r.client.AddLinkWithContext(ctx, link); err != nil {
+		// Both issues exist, so failing the notification would not link them on retry either.
+		_, err = handleJiraErrResponse("Issue.AddLink", resp, err, r.conf.Retry, r.logger)
+		level.Warn(r.logger).Log("msg", "failed to link fan-out issues", "key", n.IssueKey, "linked_key", tn.IssueKey, "err", err)
+		return
+	}
+	level.Info(r.logger).Log("msg", "linked fan-out issues", "key", n.IssueKey, "linked_key", tn.IssueKey, "type", r.conf.FanOut.LinkType)
+}
@@ -0,0 +1,91 @@
+// Copyright 2017 The Prometheus Authors
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+package notify
+
+import (
+	"context"
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/andygrunwald/go-jira"
+	"github.com/go-kit/log"
+	"github.com/pkg/errors"
+	"github.com/prometheus-community/jiralert/pkg/alertmanager"
+	"github.com/prometheus-community/jiralert/pkg/config"
+	"github.com/prometheus-community/jiralert/pkg/template"
+	"github.com/stretchr/testify/require"
+)
+
+// unavailableJira fails all searches as if Jira was unavailable.
+type unavailableJira struct {
+	*fakeJira
+}
+
+func (f *unavailableJira) SearchWithContext(context.Context, string, *jira.SearchOptions) ([]jira.Issue, *jira.Response, error) {
+	return nil, fakeResponse(http.StatusServiceUnavailable), errors.New("unavailable")
+}
+
+func TestFanOut(t *testing.T) {
+	fakeJira := newTestFakeJira()
+	conf := testReceiverConfig1()
+	conf.Name = "sre"
+	conf.FanOut = &config.FanOutConfig{Receivers: []string{"owner"}, LinkType: "Relates"}
+	targetConf := testReceiverConfig1()
+	targetConf.Name = "owner"
+	targetConf.Project = "def"
+	data := &alertmanager.Data{
+		Status:      alertmanager.AlertFiring,
+		Alerts:      alertmanager.Alerts{{Status: alertmanager.AlertFiring}},
+		GroupLabels: alertmanager.KV{"a": "b"},
+	}
+	opts := Options{MaxDescriptionLength: 32768}
+	fanOut := func(targetClient jiraIssueService) ([]Notification, bool, error) {
+		r := NewReceiver(log.NewNopLogger(), conf, template.SimpleTemplate(), fakeJira)
+		target := NewReceiver(log.NewNopLogger(), targetConf, template.SimpleTemplate(), targetClient)
+		return r.FanOut(context.Background(), []*Receiver{target}, data, opts)
+	}
+
+	res, _, err := fanOut(fakeJira)
+	require.NoError(t, err)
+	require.Len(t, res, 2)
+	require.Equal(t, Notification{Receiver: "sre", Action: ActionCreated, IssueKey: "1", IssueURL: "/browse/1", Status: alertmanager.AlertFiring, Alerts: 1}, withoutTime(res[0]))
+	require.Equal(t, Notification{Receiver: "owner", Action: ActionCreated, IssueKey: "2", IssueURL: "/browse/2", Status: alertmanager.AlertFiring, Alerts: 1}, withoutTime(res[1]))
+	require.Equal(t, "abc", fakeJira.issuesByKey["1"].Fields.Project.Key)
+	require.Equal(t, "def", fakeJira.issuesByKey["2"].Fields.Project.Key)
+	require.Equal(t, []jira.IssueLink{{
+		Type:         jira.IssueLinkType{Name: "Relates"},
+		OutwardIssue: &jira.Issue{Key: "1"},
+		InwardIssue:  &jira.Issue{Key: "2"},
+	}}, fakeJira.links)
+
+	// Existing issues are not linked again.
+	_, _, err = fanOut(fakeJira)
+	require.NoError(t, err)
+	require.Len(t, fakeJira.issuesByKey, 2)
+	require.Len(t, fakeJira.links, 1)
+
+	// Failures of some receivers are reported after notifying all of them.
+	res, retry, err := fanOut(&unavailableJira{fakeJira: fakeJira})
+	require.Error(t, err)
+	require.Contains(t, err.Error(), "1 of 2 receivers failed: receiver owner:")
+	require.True(t, retry)
+	require.Equal(t, ActionMatched, res[0].Action)
+	require.Equal(t, ActionFailed, res[1].Action)
+	require.Len(t, fakeJira.links, 1)
+}
+
+func withoutTime(n Notification) Notification {
+	n.Time = time.Time{}
+	return n
+}
@@ -0,0 +1,139 @@
+// Copyright 2017 The Prometheus Authors
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package notify
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/andygrunwald/go-jira"
+	"github.com/go-kit/log/level"
+	"github.com/pkg/errors"
+	"github.com/prometheus-community/jiralert/pkg/alertmanager"
+)
+
+type reopenEntry struct {
+	times  []time.Time
+	window time.Duration
+}
+
+// FlapTracker remembers in memory when issues were recently reopened by receivers with flap_detection.
+type FlapTracker struct {
+	mtx     sync.Mutex
+	reopens map[updateKey]*reopenEntry
+}
+
+// NewFlapTracker creates an empty FlapTracker.
+func NewFlapTracker() *FlapTracker {
+	return &FlapTracker{reopens: map[updateKey]*reopenEntry{}}
+}
+
+// Record records that the issue was reopened at the given time, and returns how many times it was reopened within
+// the window until then. Issues not reopened within their window are forgotten.
+func (t *FlapTracker) Record(receiver, issue string, now time.Time, window time.Duration) int {
+	t.mtx.Lock()
+	defer t.mtx.Unlock()
+	for k, e := range t.reopens {
+		if !now.Before(e.times[len(e.times)-1].Add(e.window)) {
+			delete(t.reopens, k)
+		}
+	}
+
+	k := updateKey{receiver: receiver, issue: issue}
+	e, ok := t.reopens[k]
+	if !ok {
+		e = &reopenEntry{}
+		t.reopens[k] = e
+	}
+	e.window = window
+	times := e.times[:0]
+	for _, ts := range e.times {
+		if now.Before(ts.Add(window)) {
+			times = append(times, ts)
+		}
+	}
+	e.times = append(times, now)
+	return len(e.times)
+}
+
+// WithFlapTracker makes receivers with flap_detection count the reopens of issues in t.
+func (r *Receiver) WithFlapTracker(t *FlapTracker) *Receiver {
+	r.flaps = t
+	return r
+}
+
+// checkFlapping records that the issue was just reopened and, once it was reopened more than flap_detection reopens
+// times within its window, comments on it and raises its priority or labels it as configured. This happens once,
+// when the issue starts flapping.
+func (r *Receiver) checkFlapping(ctx context.Context, issue *jira.Issue, data *alertmanager.Data) {
+	fd := r.conf.FlapDetection
+	if r.flaps == nil || r.dryRun || fd == nil {
+		return
+	}
+	reopens := r.flaps.Record(r.conf.Name, issue.Key, r.timeNow(), time.Duration(fd.Window))
+	if reopens != fd.Reopens+1 {
+		return
+	}
+
+	level.Info(r.logger).Log("msg", "issue is flapping", "key", issue.Key, "reopens", reopens, "window", fd.Window)
+	flappingIssues.WithLabelValues(r.conf.Name).Inc()
+	// The issue is reopened already, so failing the notification would not mark it on retry either.
+	if err := r.markFlapping(ctx, issue, data, reopens); err != nil {
+		level.Warn(r.logger).Log("msg", "failed to mark flapping issue", "key", issue.Key, "err", err)
+	}
+}
+
+// markFlapping comments on the flapping issue, and raises or escalates its priority and labels it if configured.
+func (r *Receiver) markFlapping(ctx context.Context, issue *jira.Issue, data *alertmanager.Data, reopens int) error {
+	fd := r.conf.FlapDetection
+	comment := fmt.Sprintf("Flapping alert: this issue was reopened %d times within %s.", reopens, fd.Window)
+	if fd.Comment != "" {
+		var err error
+		if comment, err = r.execute(fd.Comment, data); err != nil {
+			return errors.Wrap(err, "render flap_detection comment")
+		}
+	}
+	if comment != "" {
+		if _, err := r.addComment(ctx, issue.Key, comment); err != nil {
+			return err
+		}
+	}
+
+	if fd.Priority != "" {
+		priority, err := r.execute(fd.Priority, data)
+		if err != nil {
+			return errors.Wrap(err, "render flap_detection priority")
+		}
+		if priority != "" {
+			if _, err := r.updatePriority(ctx, issue.Key, priority); err != nil {
+				return err
+			}
+		}
+	} else if err := r.escalateFlapping(ctx, issue); err != nil {
+		return err
+	}
+
+	if fd.Label != "" {
+		resp, err := r.client.UpdateIssueWithContext(ctx, issue.Key, map[string]interface{}{
+			"update": map[string]interface{}{"labels": []map[string]string{{"add": fd.Label}}},
+		})
+		if err != nil {
+			_, err = handleJiraErrResponse("Issue.UpdateIssue", resp, err, r.conf.Retry, r.logger)
+			return err
+		}
+	}
+	return nil
+}
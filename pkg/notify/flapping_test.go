@@ -0,0 +1,86 @@
+// Copyright 2017 The Prometheus Authors
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+package notify
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/andygrunwald/go-jira"
+	"github.com/go-kit/log"
+	"github.com/prometheus-community/jiralert/pkg/alertmanager"
+	"github.com/prometheus-community/jiralert/pkg/config"
+	"github.com/prometheus-community/jiralert/pkg/template"
+	"github.com/stretchr/testify/require"
+)
+
+func TestFlapTracker(t *testing.T) {
+	ft := NewFlapTracker()
+	now := time.Now()
+	require.Equal(t, 1, ft.Record("r", "ABC-1", now, time.Hour))
+	require.Equal(t, 2, ft.Record("r", "ABC-1", now.Add(30*time.Minute), time.Hour))
+	require.Equal(t, 1, ft.Record("r", "ABC-2", now.Add(30*time.Minute), time.Hour))
+	// Reopens older than the window are not counted.
+	require.Equal(t, 2, ft.Record("r", "ABC-1", now.Add(70*time.Minute), time.Hour))
+	require.Equal(t, 1, ft.Record("r", "ABC-1", now.Add(3*time.Hour), time.Hour))
+}
+
+func TestNotifyFlapDetection(t *testing.T) {
+	fakeJira := newTestFakeJira()
+	fakeJira.transitionsByID["5"] = jira.Transition{ID: "5", Name: "reopened"}
+	flaps := NewFlapTracker()
+	conf := testReceiverConfig1()
+	conf.FlapDetection = &config.FlapDetectionConfig{Reopens: 2, Window: config.Duration(time.Hour), Priority: "High", Label: "flapping"}
+	opts := Options{MaxDescriptionLength: 32768, ReopenTickets: true}
+	start := time.Now()
+	// reopenAt resolves the issue and reopens it at the given time.
+	reopenAt := func(d time.Duration) *jira.Issue {
+		fakeJira.issuesByKey["1"].Fields.Status.StatusCategory.Key = "done"
+		data := &alertmanager.Data{
+			Status:      alertmanager.AlertFiring,
+			Alerts:      alertmanager.Alerts{{Status: alertmanager.AlertFiring}},
+			GroupLabels: alertmanager.KV{"a": "b"},
+		}
+		r := NewReceiver(log.NewNopLogger(), conf, template.SimpleTemplate(), fakeJira).WithFlapTracker(flaps)
+		r.timeNow = func() time.Time { return start.Add(d) }
+		_, err := r.Notify(context.Background(), data, opts)
+		require.NoError(t, err)
+		issue := fakeJira.issuesByKey["1"]
+		require.Equal(t, "reopened", issue.Fields.Status.StatusCategory.Key)
+		return issue
+	}
+	_, err := NewReceiver(log.NewNopLogger(), conf, template.SimpleTemplate(), fakeJira).Notify(context.Background(), &alertmanager.Data{
+		Status:      alertmanager.AlertFiring,
+		Alerts:      alertmanager.Alerts{{Status: alertmanager.AlertFiring}},
+		GroupLabels: alertmanager.KV{"a": "b"},
+	}, opts)
+	require.NoError(t, err)
+	fakeJira.issuesByKey["1"].Fields.Comments = &jira.Comments{}
+
+	reopenAt(0)
+	issue := reopenAt(10 * time.Minute)
+	require.Empty(t, issue.Fields.Comments.Comments)
+	require.Nil(t, issue.Fields.Priority)
+
+	// The third reopen within the hour marks the issue as flapping.
+	issue = reopenAt(20 * time.Minute)
+	require.Len(t, issue.Fields.Comments.Comments, 1)
+	require.Equal(t, "Flapping alert: this issue was reopened 3 times within 1h.", issue.Fields.Comments.Comments[0].Body)
+	require.Equal(t, "High", issue.Fields.Priority.Name)
+	require.Contains(t, issue.Fields.Labels, "flapping")
+
+	// Only once.
+	issue = reopenAt(30 * time.Minute)
+	require.Len(t, issue.Fields.Comments.Comments, 1)
+}
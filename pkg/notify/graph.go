@@ -0,0 +1,118 @@
+// Copyright 2017 The Prometheus Authors
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package notify
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/andygrunwald/go-jira"
+	"github.com/go-kit/log/level"
+	"github.com/pkg/errors"
+	"github.com/prometheus-community/jiralert/pkg/alertmanager"
+	"github.com/prometheus-community/jiralert/pkg/config"
+)
+
+// maxGraphSize limits the size of graph images, in bytes.
+const maxGraphSize = 10 << 20
+
+// attachGraph attaches to the created issue the image of a graph of the alert group, if configured. Failures are
+// logged rather than failing the notification, as the issue itself was created.
+func (r *Receiver) attachGraph(ctx context.Context, issue *jira.Issue, data *alertmanager.Data) {
+	g := r.conf.Graph
+	if g == nil || r.dryRun {
+		return
+	}
+	rawURL, err := r.execute(g.URL, data)
+	if err != nil {
+		level.Warn(r.logger).Log("msg", "failed to render graph URL", "key", issue.Key, "err", err)
+		return
+	}
+	u, err := r.graphURL(rawURL, data)
+	if err != nil {
+		level.Warn(r.logger).Log("msg", "invalid graph URL", "key", issue.Key, "url", rawURL, "err", err)
+		return
+	}
+	image, err := fetchGraph(ctx, u, g)
+	if err != nil {
+		level.Warn(r.logger).Log("msg", "failed to render graph", "key", issue.Key, "url", u, "err", err)
+		return
+	}
+	if _, resp, err := r.client.PostAttachmentWithContext(ctx, issue.Key, bytes.NewReader(image), g.Filename); err != nil {
+		_, err = handleJiraErrResponse("Issue.PostAttachment", resp, err, r.conf.Retry, r.logger)
+		level.Warn(r.logger).Log("msg", "failed to attach graph", "key", issue.Key, "err", err)
+		return
+	}
+	level.Debug(r.logger).Log("msg", "attached graph", "key", issue.Key, "filename", g.Filename, "size", len(image))
+}
+
+// graphURL sets the query parameters of Grafana's render API on rawURL: the time range from the before duration
+// prior to the first firing alert until now, in milliseconds, and the size of the image.
+func (r *Receiver) graphURL(rawURL string, data *alertmanager.Data) (string, error) {
+	u, err := url.Parse(rawURL)
+	if err != nil {
+		return "", err
+	}
+	if u.Scheme != "http" && u.Scheme != "https" {
+		return "", errors.New("must be an http or https URL")
+	}
+	to := r.timeNow()
+	from := to
+	for _, a := range data.Alerts.Firing() {
+		if !a.StartsAt.IsZero() && a.StartsAt.Before(from) {
+			from = a.StartsAt
+		}
+	}
+	from = from.Add(-time.Duration(*r.conf.Graph.Before))
+
+	q := u.Query()
+	q.Set("from", strconv.FormatInt(from.UnixMilli(), 10))
+	q.Set("to", strconv.FormatInt(to.UnixMilli(), 10))
+	q.Set("width", strconv.Itoa(r.conf.Graph.Width))
+	q.Set("height", strconv.Itoa(r.conf.Graph.Height))
+	u.RawQuery = q.Encode()
+	return u.String(), nil
+}
+
+// fetchGraph returns the image at rawURL.
+func fetchGraph(ctx context.Context, rawURL string, g *config.GraphConfig) ([]byte, error) {
+	ctx, cancel := context.WithTimeout(ctx, time.Duration(*g.Timeout))
+	defer cancel()
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, rawURL, nil)
+	if err != nil {
+		return nil, err
+	}
+	for k, v := range g.Headers {
+		req.Header.Set(k, string(v))
+	}
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("unexpected status %s", resp.Status)
+	}
+	if ct := resp.Header.Get("Content-Type"); !strings.HasPrefix(ct, "image/") {
+		return nil, fmt.Errorf("unexpected content type %q, not an image", ct)
+	}
+	return io.ReadAll(io.LimitReader(resp.Body, maxGraphSize))
+}
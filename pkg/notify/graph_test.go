@@ -0,0 +1,93 @@
+// Copyright 2017 The Prometheus Authors
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+package notify
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/andygrunwald/go-jira"
+	"github.com/go-kit/log"
+	"github.com/prometheus-community/jiralert/pkg/alertmanager"
+	"github.com/prometheus-community/jiralert/pkg/config"
+	"github.com/prometheus-community/jiralert/pkg/template"
+	"github.com/stretchr/testify/require"
+)
+
+func TestNotifyAttachGraph(t *testing.T) {
+	var query map[string][]string
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		if req.Header.Get("Authorization") != "Bearer t0k3n" {
+			w.WriteHeader(http.StatusUnauthorized)
+			return
+		}
+		query = req.URL.Query()
+		w.Header().Set("Content-Type", "image/png")
+		_, _ = w.Write([]byte("png"))
+	}))
+	defer srv.Close()
+
+	fakeJira := newTestFakeJira()
+	conf := testReceiverConfig1()
+	before, timeout := config.Duration(time.Hour), config.Duration(time.Second)
+	conf.Graph = &config.GraphConfig{
+		URL:      srv.URL + "/render/d-solo/abc/alerts?panelId=2&var-alert={{ .GroupLabels.a }}",
+		Before:   &before,
+		Width:    800,
+		Height:   400,
+		Filename: "graph.png",
+		Timeout:  &timeout,
+		Headers:  map[string]config.Secret{"Authorization": "Bearer t0k3n"},
+	}
+	now := time.Date(2023, 5, 1, 10, 0, 0, 0, time.UTC)
+	data := &alertmanager.Data{
+		Status: alertmanager.AlertFiring,
+		Alerts: alertmanager.Alerts{
+			{Status: alertmanager.AlertFiring, StartsAt: now.Add(-10 * time.Minute)},
+			{Status: alertmanager.AlertFiring, StartsAt: now.Add(-30 * time.Minute)},
+		},
+		GroupLabels: alertmanager.KV{"a": "b"},
+	}
+	notify := func() {
+		r := NewReceiver(log.NewNopLogger(), conf, template.SimpleTemplate(), fakeJira)
+		r.timeNow = func() time.Time { return now }
+		_, err := r.Notify(context.Background(), data, Options{MaxDescriptionLength: 32768})
+		require.NoError(t, err)
+	}
+
+	notify()
+	require.Equal(t, []fakeAttachment{{issueKey: "1", filename: "graph.png", content: []byte("png")}}, fakeJira.attachments)
+	require.Equal(t, map[string][]string{
+		"panelId":   {"2"},
+		"var-alert": {"b"},
+		"from":      {"1682929800000"},
+		"to":        {"1682935200000"},
+		"width":     {"800"},
+		"height":    {"400"},
+	}, query)
+
+	// Graphs are only attached to created issues.
+	notify()
+	require.Len(t, fakeJira.attachments, 1)
+
+	// Failing to render the graph does not fail the notification.
+	conf.Graph.Headers = nil
+	fakeJira.issuesByKey["1"].Fields.Status.StatusCategory.Key = "done"
+	fakeJira.issuesByKey["1"].Fields.Resolutiondate = jira.Time(now.Add(-48 * time.Hour))
+	notify()
+	require.Len(t, fakeJira.issuesByKey, 2)
+	require.Len(t, fakeJira.attachments, 1)
+}
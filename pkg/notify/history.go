@@ -0,0 +1,164 @@
+// Copyright 2017 The Prometheus Authors
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package notify
+
+import (
+	"context"
+	"fmt"
+	"math"
+	"strconv"
+	"time"
+
+	"github.com/andygrunwald/go-jira"
+	"github.com/go-kit/log/level"
+	"github.com/prometheus-community/jiralert/pkg/alertmanager"
+)
+
+// jiraTimeLayout is the format of date time field values accepted and returned by Jira.
+const jiraTimeLayout = "2006-01-02T15:04:05.000-0700"
+
+// alertHistory is the firing history of an alert group, as kept in the managed_fields of its issue.
+type alertHistory struct {
+	fireCount      int
+	firstSeen      time.Time
+	lastSeen       time.Time
+	firingDuration time.Duration
+}
+
+// record returns the history updated with the alerts of a notification received at the given time.
+//
+// The fields of the issue are the only state, so alerts are told apart from the ones already recorded by their
+// timestamps: an alert which started after the group was last seen firing is counted as fired, and a resolved alert
+// which ended after it adds its firing time to the duration. Timestamps are compared with the millisecond precision
+// of Jira.
+func (h alertHistory) record(alerts alertmanager.Alerts, now time.Time) alertHistory {
+	now = now.Truncate(time.Millisecond)
+	last := h.lastSeen
+	for _, a := range alerts {
+		startsAt := a.StartsAt.Truncate(time.Millisecond)
+		if startsAt.IsZero() {
+			startsAt = now
+		}
+		if h.firstSeen.IsZero() || startsAt.Before(h.firstSeen) {
+			h.firstSeen = startsAt
+		}
+
+		if a.Status == alertmanager.AlertResolved {
+			endsAt := a.EndsAt.Truncate(time.Millisecond)
+			if endsAt.IsZero() {
+				endsAt = now
+			}
+			if !endsAt.After(last) {
+				// Recorded already.
+				continue
+			}
+			if startsAt.After(last) {
+				h.fireCount++
+			}
+			if endsAt.After(startsAt) {
+				h.firingDuration += endsAt.Sub(startsAt)
+			}
+			if endsAt.After(h.lastSeen) {
+				h.lastSeen = endsAt
+			}
+			continue
+		}
+
+		if startsAt.After(last) {
+			h.fireCount++
+		}
+		if now.After(h.lastSeen) {
+			h.lastSeen = now
+		}
+	}
+	return h
+}
+
+// historyFields returns the values of the managed fields holding the history.
+func (r *Receiver) historyFields(h alertHistory) map[string]interface{} {
+	mf := r.conf.ManagedFields
+	fields := map[string]interface{}{}
+	if mf.FireCount != "" {
+		fields[mf.FireCount] = h.fireCount
+	}
+	if mf.FirstSeen != "" && !h.firstSeen.IsZero() {
+		fields[mf.FirstSeen] = h.firstSeen.Format(jiraTimeLayout)
+	}
+	if mf.LastSeen != "" && !h.lastSeen.IsZero() {
+		fields[mf.LastSeen] = h.lastSeen.Format(jiraTimeLayout)
+	}
+	if mf.FiringDuration != "" {
+		fields[mf.FiringDuration] = math.Round(h.firingDuration.Seconds())
+	}
+	return fields
+}
+
+// readHistory returns the history kept in the managed fields of the issue. Missing or unparsable values are read as
+// empty, e.g. for issues created before the fields were configured.
+func (r *Receiver) readHistory(issue *jira.Issue) alertHistory {
+	mf := r.conf.ManagedFields
+	unknowns := issue.Fields.Unknowns
+	return alertHistory{
+		fireCount:      int(numberValue(unknowns[mf.FireCount])),
+		firstSeen:      timeValue(unknowns[mf.FirstSeen]),
+		lastSeen:       timeValue(unknowns[mf.LastSeen]),
+		firingDuration: time.Duration(numberValue(unknowns[mf.FiringDuration]) * float64(time.Second)),
+	}
+}
+
+func numberValue(v interface{}) float64 {
+	switch v := v.(type) {
+	case float64:
+		return v
+	case int:
+		return float64(v)
+	case string:
+		f, _ := strconv.ParseFloat(v, 64)
+		return f
+	}
+	return 0
+}
+
+func timeValue(v interface{}) time.Time {
+	s, ok := v.(string)
+	if !ok {
+		return time.Time{}
+	}
+	for _, layout := range []string{jiraTimeLayout, time.RFC3339} {
+		if t, err := time.Parse(layout, s); err == nil {
+			return t
+		}
+	}
+	return time.Time{}
+}
+
+// updateManagedFields records the alerts of the notification in the managed fields of the issue, if configured.
+func (r *Receiver) updateManagedFields(ctx context.Context, issue *jira.Issue, data *alertmanager.Data) (bool, error) {
+	if r.conf.ManagedFields == nil {
+		return false, nil
+	}
+	old := r.readHistory(issue)
+	h := old.record(data.Alerts, r.timeNow())
+	if h == old {
+		return false, nil
+	}
+
+	fields := r.historyFields(h)
+	level.Debug(r.logger).Log("msg", "updating managed fields", "key", issue.Key, "fields", fmt.Sprintf("%v", fields))
+	resp, err := r.client.UpdateIssueWithContext(ctx, issue.Key, map[string]interface{}{"fields": fields})
+	if err != nil {
+		return handleJiraErrResponse("Issue.UpdateIssue", resp, err, r.conf.Retry, r.logger)
+	}
+	return false, nil
+}
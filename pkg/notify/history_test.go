@@ -0,0 +1,82 @@
+// Copyright 2017 The Prometheus Authors
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+package notify
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/go-kit/log"
+	"github.com/prometheus-community/jiralert/pkg/alertmanager"
+	"github.com/prometheus-community/jiralert/pkg/config"
+	"github.com/prometheus-community/jiralert/pkg/template"
+	"github.com/stretchr/testify/require"
+)
+
+func TestNotifyManagedFields(t *testing.T) {
+	fakeJira := newTestFakeJira()
+	conf := testReceiverConfig1()
+	conf.ManagedFields = &config.ManagedFieldsConfig{
+		FireCount:      "customfield_1",
+		FirstSeen:      "customfield_2",
+		LastSeen:       "customfield_3",
+		FiringDuration: "customfield_4",
+	}
+	opts := Options{MaxDescriptionLength: 32768}
+	start := time.Date(2023, 5, 1, 10, 0, 0, 0, time.UTC)
+	notifyAt := func(d time.Duration, alerts ...alertmanager.Alert) {
+		data := &alertmanager.Data{Status: alertmanager.AlertResolved, Alerts: alerts, GroupLabels: alertmanager.KV{"a": "b"}}
+		if len(data.Alerts.Firing()) > 0 {
+			data.Status = alertmanager.AlertFiring
+		}
+		r := NewReceiver(log.NewNopLogger(), conf, template.SimpleTemplate(), fakeJira)
+		r.timeNow = func() time.Time { return start.Add(d) }
+		_, err := r.Notify(context.Background(), data, opts)
+		require.NoError(t, err)
+	}
+	firing := func(startsAt time.Duration) alertmanager.Alert {
+		return alertmanager.Alert{Status: alertmanager.AlertFiring, StartsAt: start.Add(startsAt)}
+	}
+	resolved := func(startsAt, endsAt time.Duration) alertmanager.Alert {
+		return alertmanager.Alert{Status: alertmanager.AlertResolved, StartsAt: start.Add(startsAt), EndsAt: start.Add(endsAt)}
+	}
+	requireFields := func(count int, firstSeen, lastSeen time.Duration, duration float64) {
+		fields := fakeJira.issuesByKey["1"].Fields.Unknowns
+		require.EqualValues(t, count, fields["customfield_1"])
+		require.Equal(t, start.Add(firstSeen).Format(jiraTimeLayout), fields["customfield_2"])
+		require.Equal(t, start.Add(lastSeen).Format(jiraTimeLayout), fields["customfield_3"])
+		require.EqualValues(t, duration, fields["customfield_4"])
+	}
+
+	notifyAt(time.Minute, firing(0))
+	requireFields(1, 0, time.Minute, 0)
+
+	// Repeated notifications only move the last seen time.
+	notifyAt(5*time.Minute, firing(0))
+	requireFields(1, 0, 5*time.Minute, 0)
+
+	// Another alert of the group fires.
+	notifyAt(10*time.Minute, firing(0), firing(8*time.Minute))
+	requireFields(2, 0, 10*time.Minute, 0)
+
+	// Resolved alerts add their firing time, once.
+	notifyAt(15*time.Minute, firing(0), resolved(8*time.Minute, 12*time.Minute))
+	requireFields(2, 0, 15*time.Minute, 240)
+	notifyAt(20*time.Minute, resolved(0, 18*time.Minute), resolved(8*time.Minute, 12*time.Minute))
+	requireFields(2, 0, 18*time.Minute, 240+1080)
+
+	// An alert firing and resolving between notifications is counted too.
+	notifyAt(40*time.Minute, resolved(30*time.Minute, 35*time.Minute))
+	requireFields(3, 0, 35*time.Minute, 240+1080+300)
+}
@@ -0,0 +1,191 @@
+// Copyright 2017 The Prometheus Authors
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package notify
+
+import (
+	"context"
+	"io"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/andygrunwald/go-jira"
+	"github.com/prometheus-community/jiralert/pkg/tracing"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/trace"
+)
+
+// instrumentedClient records a span, the duration and the error category, if failed, of each call to the wrapped
+// jiraIssueService.
+type instrumentedClient struct {
+	receiver string
+	next     jiraIssueService
+}
+
+// start starts the span of a call to the given API, returning the function ending it, which records the Jira response
+// status and err.
+func (c *instrumentedClient) start(ctx context.Context, api string, attrs ...attribute.KeyValue) (context.Context, func(*jira.Response, error)) {
+	ctx, span := tracing.Tracer().Start(ctx, "jira "+api, trace.WithSpanKind(trace.SpanKindClient), trace.WithAttributes(attrs...))
+	start := time.Now()
+	return ctx, func(resp *jira.Response, err error) {
+		code := ""
+		if resp != nil {
+			code = strconv.Itoa(resp.StatusCode)
+			span.SetAttributes(attribute.Int("http.status_code", resp.StatusCode))
+		}
+		jiraRequestDuration.WithLabelValues(c.receiver, api, code).Observe(time.Since(start).Seconds())
+		if err != nil {
+			category, _ := classifyJiraError(resp, err, nil)
+			jiraAPIErrors.WithLabelValues(c.receiver, api, category).Inc()
+			span.SetAttributes(attribute.String("jira.error_category", category))
+			span.RecordError(err)
+			span.SetStatus(codes.Error, err.Error())
+		}
+		span.End()
+	}
+}
+
+func (c *instrumentedClient) SearchWithContext(ctx context.Context, jql string, options *jira.SearchOptions) ([]jira.Issue, *jira.Response, error) {
+	ctx, end := c.start(ctx, "Issue.Search", attribute.String("jira.jql", jql))
+	issues, resp, err := c.next.SearchWithContext(ctx, jql, options)
+	trace.SpanFromContext(ctx).SetAttributes(attribute.Int("jira.issues", len(issues)))
+	end(resp, err)
+	return issues, resp, err
+}
+
+func (c *instrumentedClient) GetWithContext(ctx context.Context, issueID string, options *jira.GetQueryOptions) (*jira.Issue, *jira.Response, error) {
+	ctx, end := c.start(ctx, "Issue.Get", attribute.String("jira.issue", issueID))
+	issue, resp, err := c.next.GetWithContext(ctx, issueID, options)
+	end(resp, err)
+	return issue, resp, err
+}
+
+func (c *instrumentedClient) GetTransitionsWithContext(ctx context.Context, id string) ([]jira.Transition, *jira.Response, error) {
+	ctx, end := c.start(ctx, "Issue.GetTransitions", attribute.String("jira.issue", id))
+	transitions, resp, err := c.next.GetTransitionsWithContext(ctx, id)
+	end(resp, err)
+	return transitions, resp, err
+}
+
+func (c *instrumentedClient) GetCreateMetaWithContext(ctx context.Context, projectkeys string) (*jira.CreateMetaInfo, *jira.Response, error) {
+	ctx, end := c.start(ctx, "Issue.GetCreateMeta", attribute.String("jira.project", projectkeys))
+	meta, resp, err := c.next.GetCreateMetaWithContext(ctx, projectkeys)
+	end(resp, err)
+	return meta, resp, err
+}
+
+func (c *instrumentedClient) FindUsersWithContext(ctx context.Context, query string) ([]jira.User, *jira.Response, error) {
+	ctx, end := c.start(ctx, "User.Find")
+	users, resp, err := c.next.FindUsersWithContext(ctx, query)
+	end(resp, err)
+	return users, resp, err
+}
+
+func (c *instrumentedClient) CreateWithContext(ctx context.Context, issue *jira.Issue) (*jira.Issue, *jira.Response, error) {
+	ctx, end := c.start(ctx, "Issue.Create")
+	created, resp, err := c.next.CreateWithContext(ctx, issue)
+	if created != nil {
+		trace.SpanFromContext(ctx).SetAttributes(attribute.String("jira.issue", created.Key))
+	}
+	end(resp, err)
+	return created, resp, err
+}
+
+func (c *instrumentedClient) BulkCreateWithContext(ctx context.Context, issues []*jira.Issue) ([]*jira.Issue, []error, *jira.Response, error) {
+	ctx, end := c.start(ctx, "Issue.BulkCreate", attribute.Int("jira.issues", len(issues)))
+	created, errs, resp, err := c.next.BulkCreateWithContext(ctx, issues)
+	end(resp, err)
+	return created, errs, resp, err
+}
+
+func (c *instrumentedClient) UpdateWithOptionsWithContext(ctx context.Context, issue *jira.Issue, opts *jira.UpdateQueryOptions) (*jira.Issue, *jira.Response, error) {
+	ctx, end := c.start(ctx, "Issue.UpdateWithOptions", attribute.String("jira.issue", issue.Key))
+	updated, resp, err := c.next.UpdateWithOptionsWithContext(ctx, issue, opts)
+	end(resp, err)
+	return updated, resp, err
+}
+
+func (c *instrumentedClient) UpdateIssueWithContext(ctx context.Context, jiraID string, data map[string]interface{}) (*jira.Response, error) {
+	ctx, end := c.start(ctx, "Issue.UpdateIssue", attribute.String("jira.issue", jiraID))
+	resp, err := c.next.UpdateIssueWithContext(ctx, jiraID, data)
+	end(resp, err)
+	return resp, err
+}
+
+func (c *instrumentedClient) AddCommentWithContext(ctx context.Context, issueID string, comment *jira.Comment) (*jira.Comment, *jira.Response, error) {
+	ctx, end := c.start(ctx, "Issue.AddComment", attribute.String("jira.issue", issueID))
+	added, resp, err := c.next.AddCommentWithContext(ctx, issueID, comment)
+	end(resp, err)
+	return added, resp, err
+}
+
+func (c *instrumentedClient) DoTransitionWithContext(ctx context.Context, ticketID, transitionID string) (*jira.Response, error) {
+	ctx, end := c.start(ctx, "Issue.DoTransition", attribute.String("jira.issue", ticketID), attribute.String("jira.transition", transitionID))
+	resp, err := c.next.DoTransitionWithContext(ctx, ticketID, transitionID)
+	end(resp, err)
+	return resp, err
+}
+
+func (c *instrumentedClient) AddLinkWithContext(ctx context.Context, issueLink *jira.IssueLink) (*jira.Response, error) {
+	ctx, end := c.start(ctx, "Issue.AddLink", attribute.String("jira.issue", issueLink.OutwardIssue.Key), attribute.String("jira.linked_issue", issueLink.InwardIssue.Key))
+	resp, err := c.next.AddLinkWithContext(ctx, issueLink)
+	end(resp, err)
+	return resp, err
+}
+
+func (c *instrumentedClient) AddWorklogRecordWithContext(ctx context.Context, issueID string, record *jira.WorklogRecord, options ...func(*http.Request) error) (*jira.WorklogRecord, *jira.Response, error) {
+	ctx, end := c.start(ctx, "Issue.AddWorklogRecord", attribute.String("jira.issue", issueID))
+	added, resp, err := c.next.AddWorklogRecordWithContext(ctx, issueID, record, options...)
+	end(resp, err)
+	return added, resp, err
+}
+
+func (c *instrumentedClient) GetRemoteLinksWithContext(ctx context.Context, id string) (*[]jira.RemoteLink, *jira.Response, error) {
+	ctx, end := c.start(ctx, "Issue.GetRemoteLinks", attribute.String("jira.issue", id))
+	links, resp, err := c.next.GetRemoteLinksWithContext(ctx, id)
+	end(resp, err)
+	return links, resp, err
+}
+
+func (c *instrumentedClient) AddRemoteLinkWithContext(ctx context.Context, issueID string, remotelink *jira.RemoteLink) (*jira.RemoteLink, *jira.Response, error) {
+	ctx, end := c.start(ctx, "Issue.AddRemoteLink", attribute.String("jira.issue", issueID))
+	added, resp, err := c.next.AddRemoteLinkWithContext(ctx, issueID, remotelink)
+	end(resp, err)
+	return added, resp, err
+}
+
+func (c *instrumentedClient) PostAttachmentWithContext(ctx context.Context, issueID string, r io.Reader, attachmentName string) (*[]jira.Attachment, *jira.Response, error) {
+	ctx, end := c.start(ctx, "Issue.PostAttachment", attribute.String("jira.issue", issueID))
+	attachments, resp, err := c.next.PostAttachmentWithContext(ctx, issueID, r, attachmentName)
+	end(resp, err)
+	return attachments, resp, err
+}
+
+func (c *instrumentedClient) AddWatcherWithContext(ctx context.Context, issueID string, userName string) (*jira.Response, error) {
+	ctx, end := c.start(ctx, "Issue.AddWatcher", attribute.String("jira.issue", issueID))
+	resp, err := c.next.AddWatcherWithContext(ctx, issueID, userName)
+	end(resp, err)
+	return resp, err
+}
+
+func (c *instrumentedClient) CreateRequestWithContext(ctx context.Context, request *jira.Request) (*jira.Request, *jira.Response, error) {
+	ctx, end := c.start(ctx, "Request.Create", attribute.String("jira.service_desk", request.ServiceDeskID))
+	created, resp, err := c.next.CreateRequestWithContext(ctx, request)
+	if created != nil {
+		trace.SpanFromContext(ctx).SetAttributes(attribute.String("jira.issue", created.IssueKey))
+	}
+	end(resp, err)
+	return created, resp, err
+}
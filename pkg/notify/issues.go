@@ -0,0 +1,245 @@
+// Copyright 2017 The Prometheus Authors
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package notify
+
+import (
+	"context"
+	"io"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/andygrunwald/go-jira"
+	"github.com/prometheus-community/jiralert/pkg/alertmanager"
+	"github.com/prometheus-community/jiralert/pkg/identity"
+	"github.com/prometheus/client_golang/prometheus"
+	dto "github.com/prometheus/client_model/go"
+	"github.com/prometheus/common/expfmt"
+	"github.com/prometheus/common/model"
+	"google.golang.org/protobuf/proto"
+)
+
+// Actions taken on managed issues.
+const (
+	ActionCreated  = "created"
+	ActionMatched  = "matched"
+	ActionReopened = "reopened"
+	ActionResolved = "resolved"
+)
+
+// ManagedIssue is a Jira issue a notification was handled with.
+type ManagedIssue struct {
+	Time        time.Time       `json:"time"`
+	Receiver    string          `json:"receiver"`
+	Key         string          `json:"key"`
+	Project     string          `json:"project"`
+	URL         string          `json:"url"`
+	GroupLabels alertmanager.KV `json:"group_labels"`
+	Action      string          `json:"action"`
+}
+
+// IssueLog keeps the most recently managed issues in memory, the latest notification for each issue only.
+type IssueLog struct {
+	mtx    sync.Mutex
+	size   int
+	issues []ManagedIssue
+}
+
+// NewIssueLog creates an IssueLog keeping up to size issues.
+func NewIssueLog(size int) *IssueLog {
+	return &IssueLog{size: size}
+}
+
+// Record adds the issue, replacing earlier entries for the same issue and dropping the oldest issue if full.
+func (l *IssueLog) Record(issue ManagedIssue) {
+	l.mtx.Lock()
+	defer l.mtx.Unlock()
+	for i, other := range l.issues {
+		if other.Receiver == issue.Receiver && other.Key == issue.Key {
+			l.issues = append(l.issues[:i], l.issues[i+1:]...)
+			break
+		}
+	}
+	l.issues = append(l.issues, issue)
+	if len(l.issues) > l.size {
+		l.issues = l.issues[len(l.issues)-l.size:]
+	}
+}
+
+// List returns the issues managed by the given receiver, or all receivers if empty, most recent first.
+func (l *IssueLog) List(receiver string) []ManagedIssue {
+	l.mtx.Lock()
+	defer l.mtx.Unlock()
+	issues := []ManagedIssue{}
+	for i := len(l.issues) - 1; i >= 0; i-- {
+		if receiver == "" || l.issues[i].Receiver == receiver {
+			issues = append(issues, l.issues[i])
+		}
+	}
+	return issues
+}
+
+var issueInfoDesc = prometheus.NewDesc(
+	"jiralert_issue_info",
+	"A metric with a constant '1' value labeled by the receiver, key and project of the issues recently handled by notifications.",
+	[]string{"receiver", "issue_key", "project"}, nil,
+)
+
+// Describe implements prometheus.Collector.
+func (l *IssueLog) Describe(ch chan<- *prometheus.Desc) {
+	ch <- issueInfoDesc
+}
+
+// Collect implements prometheus.Collector, exposing jiralert_issue_info for each issue in the log.
+func (l *IssueLog) Collect(ch chan<- prometheus.Metric) {
+	for _, issue := range l.List("") {
+		ch <- prometheus.MustNewConstMetric(issueInfoDesc, prometheus.GaugeValue, 1, issue.Receiver, issue.Key, issue.Project)
+	}
+}
+
+// WriteMapping writes the issues in the log in the Prometheus text exposition format, as jiralert_issue_mapping
+// series labeled by the receiver, key and project of each issue along with the group labels of its alert group, so
+// that recording rules can join alerts with their issues. Group labels named like one of the former are left out.
+func (l *IssueLog) WriteMapping(w io.Writer) error {
+	mf := &dto.MetricFamily{
+		Name: proto.String("jiralert_issue_mapping"),
+		Help: proto.String("A metric with a constant '1' value labeled by the receiver, key and project of the issues recently handled by notifications and the group labels of their alert groups."),
+		Type: dto.MetricType_GAUGE.Enum(),
+	}
+	for _, issue := range l.List("") {
+		labels := []*dto.LabelPair{
+			{Name: proto.String("receiver"), Value: proto.String(issue.Receiver)},
+			{Name: proto.String("issue_key"), Value: proto.String(issue.Key)},
+			{Name: proto.String("project"), Value: proto.String(issue.Project)},
+		}
+		for _, p := range issue.GroupLabels.SortedPairs() {
+			switch p.Name {
+			case "receiver", "issue_key", "project":
+				continue
+			}
+			if !model.LabelName(p.Name).IsValid() {
+				continue
+			}
+			labels = append(labels, &dto.LabelPair{Name: proto.String(p.Name), Value: proto.String(p.Value)})
+		}
+		mf.Metric = append(mf.Metric, &dto.Metric{Label: labels, Gauge: &dto.Gauge{Value: proto.Float64(1)}})
+	}
+	_, err := expfmt.MetricFamilyToText(w, mf)
+	return err
+}
+
+// WithIssueLog makes the receiver record the issues it manages in l.
+func (r *Receiver) WithIssueLog(l *IssueLog) *Receiver {
+	r.issues = l
+	return r
+}
+
+// recordIssue records the action taken on the issue for the given alert group, and counts it.
+func (r *Receiver) recordIssue(issue *jira.Issue, groupLabels alertmanager.KV, action string) {
+	if r.handled != nil {
+		r.handled.Action, r.handled.IssueKey, r.handled.IssueURL = action, issue.Key, r.browseURL(issue.Key)
+	}
+	if r.dryRun {
+		return
+	}
+	switch action {
+	case ActionCreated:
+		issuesCreated.WithLabelValues(r.conf.Name).Inc()
+	case ActionReopened:
+		issuesReopened.WithLabelValues(r.conf.Name).Inc()
+	case ActionResolved:
+		issuesResolved.WithLabelValues(r.conf.Name).Inc()
+	}
+	if r.issues == nil {
+		return
+	}
+	r.issues.Record(ManagedIssue{
+		Time:        r.timeNow(),
+		Receiver:    r.conf.Name,
+		Key:         issue.Key,
+		Project:     issueProject(issue),
+		URL:         r.browseURL(issue.Key),
+		GroupLabels: groupLabels,
+		Action:      action,
+	})
+}
+
+// issueProject returns the key of the issue's project, from its fields if known or else from its key.
+func issueProject(issue *jira.Issue) string {
+	if issue.Fields != nil && issue.Fields.Project.Key != "" {
+		return issue.Fields.Project.Key
+	}
+	project, _, _ := strings.Cut(issue.Key, "-")
+	return project
+}
+
+func (r *Receiver) browseURL(key string) string {
+	return strings.TrimSuffix(r.conf.APIURL, "/") + "/browse/" + key
+}
+
+// LookupResult is the Jira issue a notification for an alert group would be handled with.
+type LookupResult struct {
+	Receiver string `json:"receiver"`
+	Query    string `json:"query"`
+	// Issue is nil if no issue matches, or the matching issue was resolved too long ago to be reopened.
+	Issue *IssueSummary `json:"issue"`
+}
+
+// IssueSummary describes a Jira issue.
+type IssueSummary struct {
+	Key        string `json:"key"`
+	URL        string `json:"url"`
+	Summary    string `json:"summary"`
+	Status     string `json:"status,omitempty"`
+	Resolution string `json:"resolution,omitempty"`
+}
+
+// Lookup searches the issue a notification for the alert group identified by groupLabels, or by hash if not empty,
+// would update, using the same search as Notify.
+func (r *Receiver) Lookup(ctx context.Context, groupLabels alertmanager.KV, hash string, opts Options) (*LookupResult, bool, error) {
+	opts = opts.Merge(r.conf)
+
+	data := &alertmanager.Data{Receiver: r.conf.Name, GroupLabels: groupLabels, CommonLabels: groupLabels}
+	project, _, err := r.renderProject(data)
+	if err != nil {
+		return nil, false, err
+	}
+
+	strategy, err := identity.New(r.conf.Identity, opts.HashJiraLabel)
+	if err != nil {
+		return nil, false, err
+	}
+	res := &LookupResult{Receiver: r.conf.Name, Query: strategy.Query(groupLabels)}
+	if hash != "" {
+		if res.Query, err = identity.HashQuery(strategy, hash); err != nil {
+			return nil, false, err
+		}
+	}
+
+	issue, retry, err := r.findIssueToReuse(ctx, project, res.Query)
+	if err != nil {
+		return nil, retry, err
+	}
+	if issue == nil {
+		return res, false, nil
+	}
+	res.Issue = &IssueSummary{Key: issue.Key, URL: r.browseURL(issue.Key), Summary: issue.Fields.Summary}
+	if issue.Fields.Status != nil {
+		res.Issue.Status = issue.Fields.Status.Name
+	}
+	if issue.Fields.Resolution != nil {
+		res.Issue.Resolution = issue.Fields.Resolution.Name
+	}
+	return res, false, nil
+}
@@ -0,0 +1,107 @@
+// Copyright 2017 The Prometheus Authors
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+package notify
+
+import (
+	"bytes"
+	"context"
+	"strings"
+	"testing"
+
+	"github.com/go-kit/log"
+	"github.com/prometheus-community/jiralert/pkg/alertmanager"
+	"github.com/prometheus-community/jiralert/pkg/identity"
+	"github.com/prometheus-community/jiralert/pkg/template"
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	"github.com/stretchr/testify/require"
+)
+
+func TestIssueLog(t *testing.T) {
+	l := NewIssueLog(2)
+	l.Record(ManagedIssue{Receiver: "a", Key: "1", Action: ActionCreated})
+	l.Record(ManagedIssue{Receiver: "b", Key: "1", Action: ActionCreated})
+	l.Record(ManagedIssue{Receiver: "a", Key: "1", Action: ActionMatched})
+	require.Equal(t, []ManagedIssue{
+		{Receiver: "a", Key: "1", Action: ActionMatched},
+		{Receiver: "b", Key: "1", Action: ActionCreated},
+	}, l.List(""))
+
+	l.Record(ManagedIssue{Receiver: "a", Key: "2", Action: ActionCreated})
+	require.Equal(t, []ManagedIssue{
+		{Receiver: "a", Key: "2", Action: ActionCreated},
+		{Receiver: "a", Key: "1", Action: ActionMatched},
+	}, l.List("a"))
+	require.Empty(t, l.List("c"))
+}
+
+func TestIssueLogMetrics(t *testing.T) {
+	l := NewIssueLog(10)
+	l.Record(ManagedIssue{Receiver: "a", Key: "ABC-1", Project: "ABC", GroupLabels: alertmanager.KV{"alertname": "Down", "project": "x"}})
+	l.Record(ManagedIssue{Receiver: "b", Key: "DEF-2", Project: "DEF", GroupLabels: alertmanager.KV{"job": "api"}})
+
+	require.NoError(t, testutil.CollectAndCompare(l, strings.NewReader(`
+# HELP jiralert_issue_info A metric with a constant '1' value labeled by the receiver, key and project of the issues recently handled by notifications.
+# TYPE jiralert_issue_info gauge
+jiralert_issue_info{issue_key="ABC-1",project="ABC",receiver="a"} 1
+jiralert_issue_info{issue_key="DEF-2",project="DEF",receiver="b"} 1
+`)))
+
+	var buf bytes.Buffer
+	require.NoError(t, l.WriteMapping(&buf))
+	require.Equal(t, `# HELP jiralert_issue_mapping A metric with a constant '1' value labeled by the receiver, key and project of the issues recently handled by notifications and the group labels of their alert groups.
+# TYPE jiralert_issue_mapping gauge
+jiralert_issue_mapping{receiver="b",issue_key="DEF-2",project="DEF",job="api"} 1
+jiralert_issue_mapping{receiver="a",issue_key="ABC-1",project="ABC",alertname="Down"} 1
+`, buf.String())
+}
+
+func TestLookup(t *testing.T) {
+	conf := testReceiverConfig1()
+	conf.Name = "jira"
+	conf.APIURL = "https://jira.example.com/"
+	groupLabels := alertmanager.KV{"a": "b"}
+	opts := Options{HashJiraLabel: true, MaxDescriptionLength: 32768}
+
+	issues := NewIssueLog(10)
+	receiver := NewReceiver(log.NewNopLogger(), conf, template.SimpleTemplate(), newTestFakeJira()).WithIssueLog(issues)
+
+	res, _, err := receiver.Lookup(context.Background(), groupLabels, "", opts)
+	require.NoError(t, err)
+	require.Nil(t, res.Issue)
+
+	_, err = receiver.Notify(context.Background(), &alertmanager.Data{
+		Alerts:      alertmanager.Alerts{{Status: alertmanager.AlertFiring}},
+		Status:      alertmanager.AlertFiring,
+		GroupLabels: groupLabels,
+	}, opts)
+	require.NoError(t, err)
+
+	managed := issues.List("")
+	require.Len(t, managed, 1)
+	require.Equal(t, "1", managed[0].Key)
+	require.Equal(t, "https://jira.example.com/browse/1", managed[0].URL)
+	require.Equal(t, ActionCreated, managed[0].Action)
+
+	res, _, err = receiver.Lookup(context.Background(), groupLabels, "", opts)
+	require.NoError(t, err)
+	require.Equal(t, &IssueSummary{Key: "1", URL: "https://jira.example.com/browse/1", Summary: "[FIRING:1] b "}, res.Issue)
+
+	res, _, err = receiver.Lookup(context.Background(), nil, identity.Hash(groupLabels), opts)
+	require.NoError(t, err)
+	require.NotNil(t, res.Issue)
+	require.Equal(t, "1", res.Issue.Key)
+
+	// The legacy label is not a hash.
+	_, _, err = receiver.Lookup(context.Background(), nil, identity.Hash(groupLabels), Options{MaxDescriptionLength: 32768})
+	require.Error(t, err)
+}
@@ -0,0 +1,87 @@
+// Copyright 2017 The Prometheus Authors
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package notify
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"strings"
+
+	"github.com/andygrunwald/go-jira"
+	"github.com/go-kit/log"
+	"github.com/go-kit/log/level"
+	"github.com/pkg/errors"
+	"github.com/prometheus-community/jiralert/pkg/config"
+)
+
+// issueTypeFallbackComment annotates the issues created with the fallback_issue_type.
+const issueTypeFallbackComment = "Created as %s, as the issue type %q rendered for the alert group was rejected by JIRA."
+
+// invalidIssueTypeError is the error of Jira rejecting the issue type of an issue to create.
+type invalidIssueTypeError struct {
+	error
+}
+
+func (e invalidIssueTypeError) Unwrap() error {
+	return e.error
+}
+
+// handleCreateErrResponse is handleJiraErrResponse for issue creations, telling apart the errors of Jira rejecting
+// the issue type.
+func handleCreateErrResponse(resp *jira.Response, err error, conf *config.RetryConfig, logger log.Logger) (bool, error) {
+	retry, err := handleJiraErrResponse("Issue.Create", resp, err, conf, logger)
+	if rejectsIssueType(err) {
+		err = invalidIssueTypeError{err}
+	}
+	return retry, err
+}
+
+// rejectsIssueType reports whether err is Jira rejecting the issue type of the request, e.g.
+// {"errors":{"issuetype":"valid issue type is required"}}.
+func rejectsIssueType(err error) bool {
+	var jerr *JiraError
+	return errors.As(err, &jerr) && jerr.StatusCode == http.StatusBadRequest && jerr.Fields["issuetype"] != ""
+}
+
+// createWithFallbackType creates the issue, with the fallback_issue_type if its issue type rendered empty or Jira
+// rejects it, e.g. as a templated issue type rendered a type the project does not have. Issues created with the
+// fallback type are commented, so that people notice.
+func (r *Receiver) createWithFallbackType(ctx context.Context, issue *jira.Issue) (bool, error) {
+	fallback := r.conf.FallbackIssueType
+	rendered := issue.Fields.Type.Name
+	if fallback == "" || rendered == fallback {
+		return r.create(ctx, issue)
+	}
+	if strings.TrimSpace(rendered) != "" {
+		retry, err := r.create(ctx, issue)
+		var typeErr invalidIssueTypeError
+		if err == nil || !errors.As(err, &typeErr) {
+			return retry, err
+		}
+		level.Warn(r.logger).Log("msg", "issue type rejected, creating issue with fallback issue type", "issue_type", rendered, "fallback_issue_type", fallback, "err", err)
+	}
+
+	issue.Fields.Type = jira.IssueType{Name: fallback}
+	if retry, err := r.create(ctx, issue); err != nil {
+		return retry, err
+	}
+	if !r.dryRun {
+		issueTypeFallbacks.WithLabelValues(r.conf.Name).Inc()
+	}
+	if _, err := r.addComment(ctx, issue.Key, fmt.Sprintf(issueTypeFallbackComment, fallback, rendered)); err != nil {
+		level.Warn(r.logger).Log("msg", "failed to comment on fallback issue type", "key", issue.Key, "err", err)
+	}
+	return false, nil
+}
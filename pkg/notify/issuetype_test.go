@@ -0,0 +1,62 @@
+// Copyright 2017 The Prometheus Authors
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+package notify
+
+import (
+	"context"
+	"fmt"
+	"testing"
+
+	"github.com/go-kit/log"
+	"github.com/prometheus-community/jiralert/pkg/alertmanager"
+	"github.com/prometheus-community/jiralert/pkg/template"
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	"github.com/stretchr/testify/require"
+)
+
+func TestNotifyFallbackIssueType(t *testing.T) {
+	fakeJira := newTestFakeJira()
+	fakeJira.issueTypes = map[string]bool{"Bug": true, "Task": true}
+	conf := testReceiverConfig1()
+	conf.Name = "fallback-type"
+	conf.IssueType = "{{ .CommonLabels.type }}"
+	notify := func(group, issueType string) error {
+		r := NewReceiver(log.NewNopLogger(), conf, template.SimpleTemplate(), fakeJira)
+		_, err := r.Notify(context.Background(), &alertmanager.Data{
+			Status:       alertmanager.AlertFiring,
+			Alerts:       alertmanager.Alerts{{Status: alertmanager.AlertFiring}},
+			GroupLabels:  alertmanager.KV{"a": group},
+			CommonLabels: alertmanager.KV{"type": issueType},
+		}, Options{MaxDescriptionLength: 32768})
+		return err
+	}
+
+	// Without fallback issue type, the notification fails.
+	require.ErrorContains(t, notify("a", "Incident"), "valid issue type is required")
+	require.Empty(t, fakeJira.issuesByKey)
+
+	conf.FallbackIssueType = "Task"
+	require.NoError(t, notify("b", "Bug"))
+	require.Equal(t, "Bug", fakeJira.issuesByKey["1"].Fields.Type.Name)
+	require.Nil(t, fakeJira.issuesByKey["1"].Fields.Comments)
+
+	require.NoError(t, notify("c", "Incident"))
+	issue := fakeJira.issuesByKey["2"]
+	require.Equal(t, "Task", issue.Fields.Type.Name)
+	require.Equal(t, fmt.Sprintf(issueTypeFallbackComment, "Task", "Incident"), issue.Fields.Comments.Comments[0].Body)
+
+	// Empty issue types are not sent to Jira.
+	require.NoError(t, notify("d", ""))
+	require.Equal(t, "Task", fakeJira.issuesByKey["3"].Fields.Type.Name)
+	require.Equal(t, 2.0, testutil.ToFloat64(issueTypeFallbacks.WithLabelValues("fallback-type")))
+}
@@ -0,0 +1,52 @@
+// Copyright 2017 The Prometheus Authors
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package notify
+
+import (
+	"encoding/json"
+
+	"github.com/andygrunwald/go-jira"
+	"github.com/pkg/errors"
+)
+
+// JiraError is returned for Jira requests Jira responded to with an error status.
+type JiraError struct {
+	// API is the Jira API of the request, e.g. Issue.Create.
+	API        string
+	StatusCode int
+	// Messages are the errorMessages of the response.
+	Messages []string
+	// Fields are the errors of the response by field, e.g. the custom fields Jira rejected.
+	Fields map[string]string
+
+	msg string
+}
+
+func (e *JiraError) Error() string {
+	return e.msg
+}
+
+// parseJiraError returns the error of the Jira error response, e.g. {"errorMessages":[],"errors":{"customfield_10010":
+// "Field 'customfield_10010' cannot be set."}}, from err if go-jira parsed it already, or from body.
+func parseJiraError(err error, body []byte) jira.Error {
+	var jerr *jira.Error
+	if errors.As(err, &jerr) && (len(jerr.ErrorMessages) > 0 || len(jerr.Errors) > 0) {
+		return *jerr
+	}
+	var parsed jira.Error
+	if json.Unmarshal(body, &parsed) != nil {
+		return jira.Error{}
+	}
+	return parsed
+}
@@ -0,0 +1,66 @@
+// Copyright 2017 The Prometheus Authors
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+package notify
+
+import (
+	"context"
+	"io"
+	"net/http"
+	"strings"
+	"testing"
+
+	"github.com/andygrunwald/go-jira"
+	"github.com/go-kit/log"
+	"github.com/pkg/errors"
+	"github.com/prometheus-community/jiralert/pkg/alertmanager"
+	"github.com/prometheus-community/jiralert/pkg/template"
+	"github.com/stretchr/testify/require"
+)
+
+func TestHandleJiraErrResponseFields(t *testing.T) {
+	resp := fakeResponse(http.StatusBadRequest)
+	resp.Body = io.NopCloser(strings.NewReader(`{"errorMessages":[],"errors":{"customfield_10010":"Option id 'null' is not valid","components":"Component name 'db' is not valid"}}`))
+	_, err := handleJiraErrResponse("Issue.Create", resp, errors.New("request failed"), nil, log.NewNopLogger())
+
+	var jerr *JiraError
+	require.True(t, errors.As(errors.Wrap(err, "create issue"), &jerr))
+	require.Equal(t, "Issue.Create", jerr.API)
+	require.Equal(t, http.StatusBadRequest, jerr.StatusCode)
+	require.Equal(t, map[string]string{
+		"customfield_10010": "Option id 'null' is not valid",
+		"components":        "Component name 'db' is not valid",
+	}, jerr.Fields)
+
+	// go-jira consumes the body of some APIs, parsing the error itself.
+	resp = fakeResponse(http.StatusBadRequest)
+	_, err = handleJiraErrResponse("Issue.Create", resp, &jira.Error{Errors: map[string]string{"priority": "Priority name 'P0' is not valid"}}, nil, log.NewNopLogger())
+	require.True(t, errors.As(err, &jerr))
+	require.Equal(t, map[string]string{"priority": "Priority name 'P0' is not valid"}, jerr.Fields)
+}
+
+func TestNotifyJiraErrorFields(t *testing.T) {
+	fakeJira := newTestFakeJira()
+	fakeJira.issueTypes = map[string]bool{"Task": true}
+	receiver := NewReceiver(log.NewNopLogger(), testReceiverConfig1(), template.SimpleTemplate(), fakeJira)
+	data := &alertmanager.Data{
+		Alerts:      alertmanager.Alerts{{Status: alertmanager.AlertFiring}},
+		Status:      alertmanager.AlertFiring,
+		GroupLabels: alertmanager.KV{"a": "b"},
+	}
+
+	retry, err := receiver.Notify(context.Background(), data, Options{HashJiraLabel: true})
+	require.False(t, retry)
+	var jerr *JiraError
+	require.True(t, errors.As(err, &jerr))
+	require.Equal(t, map[string]string{"issuetype": "valid issue type is required"}, jerr.Fields)
+}
@@ -0,0 +1,137 @@
+// Copyright 2017 The Prometheus Authors
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package notify
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/andygrunwald/go-jira"
+	"github.com/go-kit/log/level"
+	"github.com/prometheus-community/jiralert/pkg/store"
+)
+
+// GroupLocker serializes the notifications for the same alert group, e.g. sent by both Alertmanagers of an HA
+// pair, so that they don't both create an issue.
+type GroupLocker struct {
+	mtx   sync.Mutex
+	locks map[string]*groupLock
+}
+
+type groupLock struct {
+	// held is closed when the lock is released.
+	held chan struct{}
+}
+
+// NewGroupLocker creates a GroupLocker.
+func NewGroupLocker() *GroupLocker {
+	return &GroupLocker{locks: map[string]*groupLock{}}
+}
+
+// Lock waits until no other notification holds the lock of key, or ctx is done. The returned function releases
+// the lock.
+func (l *GroupLocker) Lock(ctx context.Context, key string) (func(), error) {
+	for {
+		l.mtx.Lock()
+		lock, ok := l.locks[key]
+		if !ok {
+			lock = &groupLock{held: make(chan struct{})}
+			l.locks[key] = lock
+			l.mtx.Unlock()
+			return func() { l.unlock(key, lock) }, nil
+		}
+		l.mtx.Unlock()
+
+		select {
+		case <-lock.held:
+		case <-ctx.Done():
+			return nil, ctx.Err()
+		}
+	}
+}
+
+func (l *GroupLocker) unlock(key string, lock *groupLock) {
+	l.mtx.Lock()
+	defer l.mtx.Unlock()
+	delete(l.locks, key)
+	close(lock.held)
+}
+
+// WithGroupLocker makes the receiver handle one notification per alert group at a time, as locked in l.
+func (r *Receiver) WithGroupLocker(l *GroupLocker) *Receiver {
+	r.locks = l
+	return r
+}
+
+// sharedLockTTL is how long alert groups stay locked in a shared store if the replica handling the notification dies.
+const sharedLockTTL = 5 * time.Minute
+
+// lockGroup locks the alert group identified by groupQuery in the project, if the receiver has a GroupLocker, and
+// across replicas if its store is shared. Failing to lock the alert group in the store, other than due to ctx, is
+// logged and the notification is handled anyway, so that alerts keep being notified while the store is down.
+func (r *Receiver) lockGroup(ctx context.Context, project, groupQuery string) (func(), error) {
+	group := r.groupKey(project, groupQuery)
+	unlock := func() {}
+	if r.locks != nil {
+		var err error
+		if unlock, err = r.locks.Lock(ctx, group); err != nil {
+			return nil, err
+		}
+	}
+
+	locker, ok := r.store.(store.Locker)
+	if !ok || !r.storeEnabled() {
+		return unlock, nil
+	}
+	unlockShared, err := locker.Lock(ctx, group, sharedLockTTL)
+	if err != nil {
+		if ctx.Err() != nil {
+			unlock()
+			return nil, err
+		}
+		level.Warn(r.logger).Log("msg", "failed to lock alert group in store, handling the notification anyway", "query", groupQuery, "err", err)
+		return unlock, nil
+	}
+	return func() {
+		unlockShared()
+		unlock()
+	}, nil
+}
+
+// groupKey identifies the alert group matching groupQuery in the project, as handled by the receiver.
+func (r *Receiver) groupKey(project, groupQuery string) string {
+	return fmt.Sprintf("%s/%s/%s", r.conf.Name, project, groupQuery)
+}
+
+// checkDuplicates searches for other unresolved issues of the alert group after creating issue, which another
+// JIRAlert instance may have created concurrently. Duplicates are logged and counted, but left alone for people to
+// close, as it is unknown which of the issues others already work on.
+func (r *Receiver) checkDuplicates(ctx context.Context, project, groupQuery string, issue *jira.Issue) {
+	query := fmt.Sprintf("project in('%s') and %s order by resolutiondate desc", project, groupQuery)
+	issues, resp, err := r.client.SearchWithContext(ctx, query, &jira.SearchOptions{Fields: []string{"status"}, MaxResults: 10})
+	if err != nil {
+		_, err = handleJiraErrResponse("Issue.Search", resp, err, r.conf.Retry, r.logger)
+		level.Warn(r.logger).Log("msg", "failed to check for duplicate issues", "key", issue.Key, "err", err)
+		return
+	}
+	for _, other := range issues {
+		if other.Key == issue.Key || other.Fields == nil || other.Fields.Status == nil || other.Fields.Status.StatusCategory.Key == "done" {
+			continue
+		}
+		level.Warn(r.logger).Log("msg", "created issue duplicates another unresolved issue of the alert group", "key", issue.Key, "duplicate_of", other.Key, "query", groupQuery)
+		duplicateIssues.WithLabelValues(r.conf.Name).Inc()
+	}
+}
@@ -0,0 +1,114 @@
+// Copyright 2017 The Prometheus Authors
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+package notify
+
+import (
+	"context"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/andygrunwald/go-jira"
+	"github.com/go-kit/log"
+	"github.com/prometheus-community/jiralert/pkg/alertmanager"
+	"github.com/prometheus-community/jiralert/pkg/template"
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	"github.com/stretchr/testify/require"
+)
+
+func TestGroupLocker(t *testing.T) {
+	l := NewGroupLocker()
+	unlock, err := l.Lock(context.Background(), "a")
+	require.NoError(t, err)
+
+	// Other keys are not blocked.
+	unlockB, err := l.Lock(context.Background(), "b")
+	require.NoError(t, err)
+	unlockB()
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+	defer cancel()
+	_, err = l.Lock(ctx, "a")
+	require.ErrorIs(t, err, context.DeadlineExceeded)
+
+	locked := make(chan struct{})
+	go func() {
+		unlock, err := l.Lock(context.Background(), "a")
+		require.NoError(t, err)
+		unlock()
+		close(locked)
+	}()
+	unlock()
+	<-locked
+	require.Empty(t, l.locks)
+}
+
+func TestNotifyConcurrent(t *testing.T) {
+	fakeJira := newTestFakeJira()
+	locks := NewGroupLocker()
+	data := &alertmanager.Data{
+		Alerts:      alertmanager.Alerts{{Status: alertmanager.AlertFiring}},
+		Status:      alertmanager.AlertFiring,
+		GroupLabels: alertmanager.KV{"a": "b"},
+	}
+
+	// The fake Jira is not safe for concurrent use, so this also fails with -race if notifications are not
+	// serialized.
+	var wg sync.WaitGroup
+	for i := 0; i < 10; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			receiver := NewReceiver(log.NewNopLogger(), testReceiverConfig1(), template.SimpleTemplate(), fakeJira).WithGroupLocker(locks)
+			_, err := receiver.Notify(context.Background(), data, Options{MaxDescriptionLength: 32768})
+			require.NoError(t, err)
+		}()
+	}
+	wg.Wait()
+	require.Len(t, fakeJira.issuesByKey, 1)
+}
+
+// staleSearchJira misses the issues of the first searches, as if another instance created them concurrently.
+type staleSearchJira struct {
+	*fakeJira
+	stale int
+}
+
+func (f *staleSearchJira) SearchWithContext(ctx context.Context, jql string, options *jira.SearchOptions) ([]jira.Issue, *jira.Response, error) {
+	if f.stale > 0 {
+		f.stale--
+		return nil, nil, nil
+	}
+	return f.fakeJira.SearchWithContext(ctx, jql, options)
+}
+
+func TestNotifyDuplicates(t *testing.T) {
+	fakeJira := newTestFakeJira()
+	conf := testReceiverConfig1()
+	conf.Name = "duplicates"
+	data := &alertmanager.Data{
+		Alerts:      alertmanager.Alerts{{Status: alertmanager.AlertFiring}},
+		Status:      alertmanager.AlertFiring,
+		GroupLabels: alertmanager.KV{"a": "b"},
+	}
+	opts := Options{MaxDescriptionLength: 32768}
+
+	_, err := NewReceiver(log.NewNopLogger(), conf, template.SimpleTemplate(), fakeJira).Notify(context.Background(), data, opts)
+	require.NoError(t, err)
+	require.Equal(t, 0.0, testutil.ToFloat64(duplicateIssues.WithLabelValues("duplicates")))
+
+	_, err = NewReceiver(log.NewNopLogger(), conf, template.SimpleTemplate(), &staleSearchJira{fakeJira: fakeJira, stale: 1}).Notify(context.Background(), data, opts)
+	require.NoError(t, err)
+	require.Len(t, fakeJira.issuesByKey, 2)
+	require.Equal(t, 1.0, testutil.ToFloat64(duplicateIssues.WithLabelValues("duplicates")))
+}
@@ -0,0 +1,45 @@
+// Copyright 2017 The Prometheus Authors
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package notify
+
+import (
+	"github.com/andygrunwald/go-jira"
+	"github.com/prometheus-community/jiralert/pkg/alertmanager"
+	"github.com/prometheus-community/jiralert/pkg/config"
+)
+
+// maintenanceWindow returns the first maintenance window of the receiver active for the notification, if any.
+func (r *Receiver) maintenanceWindow(data *alertmanager.Data) *config.MaintenanceWindowConfig {
+	now := r.timeNow()
+	for _, mw := range r.conf.MaintenanceWindows {
+		if mw.Active(data.CommonLabels, now) {
+			return mw
+		}
+	}
+	return nil
+}
+
+// suppress records that the maintenance window kept the notification from creating an issue, or from reopening the
+// given one, and counts it.
+func (r *Receiver) suppress(issue *jira.Issue, mw *config.MaintenanceWindowConfig) {
+	if r.handled != nil {
+		r.handled.Action = ActionSuppressed
+		if issue != nil {
+			r.handled.IssueKey, r.handled.IssueURL = issue.Key, r.browseURL(issue.Key)
+		}
+	}
+	if !r.dryRun {
+		suppressedNotifications.WithLabelValues(r.conf.Name, mw.Name).Inc()
+	}
+}
@@ -0,0 +1,69 @@
+// Copyright 2017 The Prometheus Authors
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+package notify
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/go-kit/log"
+	"github.com/prometheus-community/jiralert/pkg/alertmanager"
+	"github.com/prometheus-community/jiralert/pkg/config"
+	"github.com/prometheus-community/jiralert/pkg/template"
+	"github.com/stretchr/testify/require"
+	yaml "gopkg.in/yaml.v3"
+)
+
+func TestNotifyMaintenanceWindows(t *testing.T) {
+	fakeJira := newTestFakeJira()
+	conf := testReceiverConfig1()
+	conf.AutoResolve = &config.AutoResolve{State: "Done"}
+	require.NoError(t, yaml.Unmarshal([]byte(`
+- name: upgrade
+  matchers: ['cluster="eu-1"']
+  start: 2023-03-01T22:00:00Z
+  end: 2023-03-02T02:00:00Z
+`), &conf.MaintenanceWindows))
+	opts := Options{MaxDescriptionLength: 32768, ReopenTickets: true}
+	before := time.Date(2023, 3, 1, 21, 0, 0, 0, time.UTC)
+	during := time.Date(2023, 3, 1, 23, 0, 0, 0, time.UTC)
+	notify := func(at time.Time, status, group, cluster string) Notification {
+		data := &alertmanager.Data{
+			Status:       status,
+			Alerts:       alertmanager.Alerts{{Status: status}},
+			GroupLabels:  alertmanager.KV{"a": group},
+			CommonLabels: alertmanager.KV{"a": group, "cluster": cluster},
+		}
+		r := NewReceiver(log.NewNopLogger(), conf, template.SimpleTemplate(), fakeJira).At(at)
+		n, _, err := r.notifyRecorded(context.Background(), data, opts)
+		require.NoError(t, err)
+		return n
+	}
+
+	require.Equal(t, ActionCreated, notify(before, alertmanager.AlertFiring, "b", "eu-1").Action)
+
+	// No issues are created during the window, unless the labels don't match.
+	require.Equal(t, ActionSuppressed, notify(during, alertmanager.AlertFiring, "c", "eu-1").Action)
+	require.Len(t, fakeJira.issuesByKey, 1)
+	require.Equal(t, ActionCreated, notify(during, alertmanager.AlertFiring, "c", "us-1").Action)
+	require.Len(t, fakeJira.issuesByKey, 2)
+
+	// Resolved notifications are handled, but issues are not reopened.
+	require.Equal(t, ActionResolved, notify(during, alertmanager.AlertResolved, "b", "eu-1").Action)
+	fakeJira.issuesByKey["1"].Fields.Status.StatusCategory.Key = "done"
+	n := notify(during, alertmanager.AlertFiring, "b", "eu-1")
+	require.Equal(t, ActionSuppressed, n.Action)
+	require.Equal(t, "1", n.IssueKey)
+	require.Equal(t, "done", fakeJira.issuesByKey["1"].Fields.Status.StatusCategory.Key)
+}
@@ -0,0 +1,76 @@
+// Copyright 2017 The Prometheus Authors
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package notify
+
+import "strings"
+
+// Markers delimiting the section of descriptions managed by JIRAlert, with managed_description.
+const (
+	managedStart = "{jiralert:start}"
+	managedEnd   = "{jiralert:end}"
+)
+
+// wrapManaged returns the rendered description wrapped in the managed section markers.
+func wrapManaged(description string) string {
+	return managedStart + "\n" + description + "\n" + managedEnd
+}
+
+// managedBounds returns the start of the managed section of description, including its markers, and its end.
+func managedBounds(description string) (int, int, bool) {
+	start := strings.Index(description, managedStart)
+	if start < 0 {
+		return 0, 0, false
+	}
+	end := strings.Index(description[start+len(managedStart):], managedEnd)
+	if end < 0 {
+		return 0, 0, false
+	}
+	return start, start + len(managedStart) + end + len(managedEnd), true
+}
+
+// managedSection returns the content between the managed section markers of description, if it has them.
+func managedSection(description string) (string, bool) {
+	start, end, ok := managedBounds(description)
+	if !ok {
+		return "", false
+	}
+	return description[start+len(managedStart) : end-len(managedEnd)], true
+}
+
+// describes returns true if the existing description of an issue shows the rendered description, in its managed
+// section if the receiver has managed_description enabled.
+func (r *Receiver) describes(existing, rendered string) bool {
+	if !isEnabled(r.conf.ManagedDescription) {
+		return existing == rendered
+	}
+	section, ok := managedSection(existing)
+	return ok && strings.TrimSpace(section) == strings.TrimSpace(rendered)
+}
+
+// updatedDescription returns the description to update the issue with, and false if it is up to date or must not be
+// updated. With managed_description, only the managed section is replaced, keeping what people wrote around it;
+// issues without the markers, e.g. created before enabling it or whose markers people removed, are left alone.
+func (r *Receiver) updatedDescription(existing, rendered string) (string, bool) {
+	if !isEnabled(r.conf.ManagedDescription) {
+		return rendered, existing != rendered
+	}
+	if r.describes(existing, rendered) {
+		return "", false
+	}
+	start, end, ok := managedBounds(existing)
+	if !ok {
+		return "", false
+	}
+	return existing[:start] + wrapManaged(rendered) + existing[end:], true
+}
@@ -0,0 +1,70 @@
+// Copyright 2017 The Prometheus Authors
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+package notify
+
+import (
+	"context"
+	"testing"
+
+	"github.com/go-kit/log"
+	"github.com/prometheus-community/jiralert/pkg/alertmanager"
+	"github.com/prometheus-community/jiralert/pkg/template"
+	"github.com/stretchr/testify/require"
+)
+
+func TestManagedSection(t *testing.T) {
+	for _, tcase := range []struct {
+		description string
+		section     string
+		ok          bool
+	}{
+		{description: "{jiralert:start}\nalerts\n{jiralert:end}", section: "\nalerts\n", ok: true},
+		{description: "notes\n{jiralert:start}alerts{jiralert:end}\nmore notes", section: "alerts", ok: true},
+		{description: "{jiralert:end}{jiralert:start}alerts", ok: false},
+		{description: "alerts", ok: false},
+	} {
+		section, ok := managedSection(tcase.description)
+		require.Equal(t, tcase.ok, ok, tcase.description)
+		require.Equal(t, tcase.section, section, tcase.description)
+	}
+}
+
+func TestNotifyManagedDescription(t *testing.T) {
+	fakeJira := newTestFakeJira()
+	conf := testReceiverConfig1()
+	conf.Description = `{{ .Alerts.Firing | len }} firing`
+	managed := true
+	conf.ManagedDescription = &managed
+	opts := Options{MaxDescriptionLength: 32768, UpdateDescription: true}
+	notify := func(alerts int) {
+		data := &alertmanager.Data{Status: alertmanager.AlertFiring, GroupLabels: alertmanager.KV{"a": "b"}}
+		for i := 0; i < alerts; i++ {
+			data.Alerts = append(data.Alerts, alertmanager.Alert{Status: alertmanager.AlertFiring})
+		}
+		_, err := NewReceiver(log.NewNopLogger(), conf, template.SimpleTemplate(), fakeJira).Notify(context.Background(), data, opts)
+		require.NoError(t, err)
+	}
+
+	notify(1)
+	require.Equal(t, "{jiralert:start}\n1 firing\n{jiralert:end}", fakeJira.issuesByKey["1"].Fields.Description)
+
+	// Only the managed section is updated, keeping the notes around it.
+	fakeJira.issuesByKey["1"].Fields.Description = "Looking into it.\n{jiralert:start}\n1 firing\n{jiralert:end}\nSee runbook."
+	notify(2)
+	require.Equal(t, "Looking into it.\n{jiralert:start}\n2 firing\n{jiralert:end}\nSee runbook.", fakeJira.issuesByKey["1"].Fields.Description)
+
+	// Descriptions without the markers are left alone.
+	fakeJira.issuesByKey["1"].Fields.Description = "Rewritten by hand."
+	notify(3)
+	require.Equal(t, "Rewritten by hand.", fakeJira.issuesByKey["1"].Fields.Description)
+}
@@ -0,0 +1,178 @@
+// Copyright 2017 The Prometheus Authors
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package notify
+
+import "github.com/prometheus/client_golang/prometheus"
+
+var (
+	issuesCreated = prometheus.NewCounterVec(
+		prometheus.CounterOpts{
+			Name: "jiralert_issues_created_total",
+			Help: "Jira issues created, by receiver.",
+		},
+		[]string{"receiver"},
+	)
+	issuesReopened = prometheus.NewCounterVec(
+		prometheus.CounterOpts{
+			Name: "jiralert_issues_reopened_total",
+			Help: "Jira issues reopened, by receiver.",
+		},
+		[]string{"receiver"},
+	)
+	issuesResolved = prometheus.NewCounterVec(
+		prometheus.CounterOpts{
+			Name: "jiralert_issues_resolved_total",
+			Help: "Jira issues resolved automatically, by receiver.",
+		},
+		[]string{"receiver"},
+	)
+	commentsAdded = prometheus.NewCounterVec(
+		prometheus.CounterOpts{
+			Name: "jiralert_comments_added_total",
+			Help: "Comments added to Jira issues, by receiver.",
+		},
+		[]string{"receiver"},
+	)
+	templateErrors = prometheus.NewCounterVec(
+		prometheus.CounterOpts{
+			Name: "jiralert_template_errors_total",
+			Help: "Errors rendering templates, by receiver.",
+		},
+		[]string{"receiver"},
+	)
+	truncations = prometheus.NewCounterVec(
+		prometheus.CounterOpts{
+			Name: "jiralert_truncations_total",
+			Help: "Descriptions and comments truncated to fit Jira's limits, by receiver and field.",
+		},
+		[]string{"receiver", "field"},
+	)
+	updatesSkipped = prometheus.NewCounterVec(
+		prometheus.CounterOpts{
+			Name: "jiralert_issue_updates_skipped_total",
+			Help: "Notifications not updating an existing issue as it changed less than min_update_interval ago, by receiver.",
+		},
+		[]string{"receiver"},
+	)
+	duplicateIssues = prometheus.NewCounterVec(
+		prometheus.CounterOpts{
+			Name: "jiralert_duplicate_issues_total",
+			Help: "Unresolved issues of the same alert group found right after creating an issue, e.g. created concurrently by another instance, by receiver.",
+		},
+		[]string{"receiver"},
+	)
+	searchCacheHits = prometheus.NewCounterVec(
+		prometheus.CounterOpts{
+			Name: "jiralert_search_cache_hits_total",
+			Help: "Notifications reusing the issue, or absence of one, recently found for the alert group instead of searching Jira, by receiver.",
+		},
+		[]string{"receiver"},
+	)
+	storeHits = prometheus.NewCounterVec(
+		prometheus.CounterOpts{
+			Name: "jiralert_store_hits_total",
+			Help: "Notifications fetching the issue recorded for the alert group in the store instead of searching Jira, by receiver.",
+		},
+		[]string{"receiver"},
+	)
+	resolvesCanceled = prometheus.NewCounterVec(
+		prometheus.CounterOpts{
+			Name: "jiralert_resolves_canceled_total",
+			Help: "Delayed resolutions of issues canceled as their alert group fired again within the auto_resolve delay, by receiver.",
+		},
+		[]string{"receiver"},
+	)
+	flappingIssues = prometheus.NewCounterVec(
+		prometheus.CounterOpts{
+			Name: "jiralert_flapping_issues_total",
+			Help: "Issues reopened more than flap_detection reopens times within its window, by receiver.",
+		},
+		[]string{"receiver"},
+	)
+	issuesEscalated = prometheus.NewCounterVec(
+		prometheus.CounterOpts{
+			Name: "jiralert_issues_escalated_total",
+			Help: "Issues whose priority was raised by escalation, by receiver.",
+		},
+		[]string{"receiver"},
+	)
+	staleIssuesClosed = prometheus.NewCounterVec(
+		prometheus.CounterOpts{
+			Name: "jiralert_stale_issues_closed_total",
+			Help: "Open issues closed as their alert group was not notified for the stale_issues after duration, by receiver.",
+		},
+		[]string{"receiver"},
+	)
+	reconciledNotifications = prometheus.NewCounterVec(
+		prometheus.CounterOpts{
+			Name: "jiralert_reconciled_notifications_total",
+			Help: "Alert groups notified by reconciling issues with the alert groups firing in Alertmanager, by receiver and status.",
+		},
+		[]string{"receiver", "status"},
+	)
+	silencesCreated = prometheus.NewCounterVec(
+		prometheus.CounterOpts{
+			Name: "jiralert_silences_created_total",
+			Help: "Silences created in Alertmanager for the alert groups of issues resolved as won't fix, by receiver.",
+		},
+		[]string{"receiver"},
+	)
+	truncatedAlerts = prometheus.NewCounterVec(
+		prometheus.CounterOpts{
+			Name: "jiralert_truncated_alerts_total",
+			Help: "Alerts left out of notifications by Alertmanager, as alert groups exceeded the max_alerts of the webhook configuration, by receiver.",
+		},
+		[]string{"receiver"},
+	)
+	suppressedNotifications = prometheus.NewCounterVec(
+		prometheus.CounterOpts{
+			Name: "jiralert_notifications_suppressed_total",
+			Help: "Firing notifications which did not create or reopen an issue due to a maintenance window, by receiver and window.",
+		},
+		[]string{"receiver", "window"},
+	)
+	projectFallbacks = prometheus.NewCounterVec(
+		prometheus.CounterOpts{
+			Name: "jiralert_project_fallbacks_total",
+			Help: "Notifications handled in the fallback_project, as their rendered project was not a valid project key or not allowed, by receiver.",
+		},
+		[]string{"receiver"},
+	)
+	issueTypeFallbacks = prometheus.NewCounterVec(
+		prometheus.CounterOpts{
+			Name: "jiralert_issue_type_fallbacks_total",
+			Help: "Issues created with the fallback_issue_type, as Jira rejected their rendered issue type, by receiver.",
+		},
+		[]string{"receiver"},
+	)
+	jiraRequestDuration = prometheus.NewHistogramVec(
+		prometheus.HistogramOpts{
+			Name:    "jiralert_jira_api_request_duration_seconds",
+			Help:    "Duration of Jira API requests, by receiver, API and HTTP status code (empty if no response was received).",
+			Buckets: prometheus.DefBuckets,
+		},
+		[]string{"receiver", "api", "code"},
+	)
+	jiraAPIErrors = prometheus.NewCounterVec(
+		prometheus.CounterOpts{
+			Name: "jiralert_jira_api_errors_total",
+			Help: "Failed Jira API requests, by receiver, API and error category: timeout, network, rate_limited, auth, client, server or other.",
+		},
+		[]string{"receiver", "api", "category"},
+	)
+)
+
+func init() {
+	prometheus.MustRegister(issuesCreated, issuesReopened, issuesResolved, commentsAdded, templateErrors, truncations, updatesSkipped, duplicateIssues, searchCacheHits, storeHits, resolvesCanceled, flappingIssues, issuesEscalated, staleIssuesClosed, reconciledNotifications, silencesCreated, truncatedAlerts, suppressedNotifications, projectFallbacks, issueTypeFallbacks, jiraRequestDuration, jiraAPIErrors)
+}
@@ -0,0 +1,55 @@
+// Copyright 2017 The Prometheus Authors
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+package notify
+
+import (
+	"context"
+	"testing"
+
+	"github.com/go-kit/log"
+	"github.com/prometheus-community/jiralert/pkg/alertmanager"
+	"github.com/prometheus-community/jiralert/pkg/template"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	dto "github.com/prometheus/client_model/go"
+	"github.com/stretchr/testify/require"
+)
+
+func TestMetrics(t *testing.T) {
+	conf := testReceiverConfig2()
+	conf.Name = "metrics"
+	receiver := NewReceiver(log.NewNopLogger(), conf, template.SimpleTemplate(), newTestFakeJira())
+	data := &alertmanager.Data{
+		Alerts:      alertmanager.Alerts{{Status: alertmanager.AlertFiring}},
+		Status:      alertmanager.AlertFiring,
+		GroupLabels: alertmanager.KV{"a": "b"},
+	}
+	opts := Options{HashJiraLabel: true, MaxDescriptionLength: 32768}
+
+	// Dry runs don't count.
+	_, _, err := receiver.DryRun(context.Background(), data, opts)
+	require.NoError(t, err)
+	require.Equal(t, 0.0, testutil.ToFloat64(issuesCreated.WithLabelValues("metrics")))
+
+	_, err = receiver.Notify(context.Background(), data, opts)
+	require.NoError(t, err)
+	require.Equal(t, 1.0, testutil.ToFloat64(issuesCreated.WithLabelValues("metrics")))
+	m := &dto.Metric{}
+	require.NoError(t, jiraRequestDuration.WithLabelValues("metrics", "Issue.Create", "").(prometheus.Metric).Write(m))
+	require.Equal(t, uint64(1), m.GetHistogram().GetSampleCount())
+
+	conf.Summary = "{{ .Missing.Field }}"
+	_, err = receiver.Notify(context.Background(), data, opts)
+	require.Error(t, err)
+	require.Equal(t, 1.0, testutil.ToFloat64(templateErrors.WithLabelValues("metrics")))
+}
@@ -0,0 +1,88 @@
+// Copyright 2017 The Prometheus Authors
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package notify
+
+import (
+	"sync"
+	"time"
+)
+
+// Outcomes of notifications which did not act on an issue.
+const (
+	// ActionNone means no issue matched a notification without firing alerts, so there was nothing to do.
+	ActionNone = "none"
+	// ActionFailed means the notification failed, see the error.
+	ActionFailed = "failed"
+	// ActionSuppressed means a maintenance window kept the notification from creating or reopening an issue.
+	ActionSuppressed = "suppressed"
+)
+
+// Notification is the outcome of a processed webhook notification.
+type Notification struct {
+	Time     time.Time `json:"time"`
+	Receiver string    `json:"receiver"`
+	GroupKey string    `json:"group_key"`
+	Status   string    `json:"status"`
+	Alerts   int       `json:"alerts"`
+	// Action is the action taken on the issue, or ActionNone, ActionFailed or ActionSuppressed.
+	Action   string `json:"action"`
+	IssueKey string `json:"issue_key,omitempty"`
+	IssueURL string `json:"issue_url,omitempty"`
+	Error    string `json:"error,omitempty"`
+}
+
+// NotificationLog keeps the most recent notifications in a ring buffer.
+type NotificationLog struct {
+	mtx           sync.Mutex
+	notifications []Notification
+	// next is the index the next notification is written at, once the buffer is full.
+	next int
+}
+
+// NewNotificationLog creates a NotificationLog keeping up to size notifications.
+func NewNotificationLog(size int) *NotificationLog {
+	return &NotificationLog{notifications: make([]Notification, 0, size)}
+}
+
+// Record adds the notification, overwriting the oldest one if full.
+func (l *NotificationLog) Record(n Notification) {
+	l.mtx.Lock()
+	defer l.mtx.Unlock()
+	if len(l.notifications) < cap(l.notifications) {
+		l.notifications = append(l.notifications, n)
+		return
+	}
+	if len(l.notifications) == 0 {
+		return
+	}
+	l.notifications[l.next] = n
+	l.next = (l.next + 1) % len(l.notifications)
+}
+
+// List returns the notifications, most recent first.
+func (l *NotificationLog) List() []Notification {
+	l.mtx.Lock()
+	defer l.mtx.Unlock()
+	res := make([]Notification, 0, len(l.notifications))
+	for i := len(l.notifications) - 1; i >= 0; i-- {
+		res = append(res, l.notifications[(l.next+i)%len(l.notifications)])
+	}
+	return res
+}
+
+// WithNotificationLog makes the receiver record the outcome of its notifications in l.
+func (r *Receiver) WithNotificationLog(l *NotificationLog) *Receiver {
+	r.notifications = l
+	return r
+}
@@ -0,0 +1,120 @@
+// Copyright 2017 The Prometheus Authors
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+package notify
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/go-kit/log"
+	"github.com/prometheus-community/jiralert/pkg/alertmanager"
+	"github.com/prometheus-community/jiralert/pkg/template"
+	"github.com/stretchr/testify/require"
+)
+
+func TestNotificationLog(t *testing.T) {
+	l := NewNotificationLog(3)
+	require.Empty(t, l.List())
+
+	for _, key := range []string{"a", "b"} {
+		l.Record(Notification{GroupKey: key})
+	}
+	require.Equal(t, []Notification{{GroupKey: "b"}, {GroupKey: "a"}}, l.List())
+
+	for _, key := range []string{"c", "d", "e"} {
+		l.Record(Notification{GroupKey: key})
+	}
+	require.Equal(t, []Notification{{GroupKey: "e"}, {GroupKey: "d"}, {GroupKey: "c"}}, l.List())
+
+	// Logs of size 0 keep nothing.
+	l = NewNotificationLog(0)
+	l.Record(Notification{GroupKey: "a"})
+	require.Empty(t, l.List())
+}
+
+func TestNotifyRecordsNotification(t *testing.T) {
+	conf := testReceiverConfig1()
+	conf.Name = "jira"
+	conf.APIURL = "https://jira.example.com"
+	now := time.Unix(1000, 0)
+	opts := Options{HashJiraLabel: true, MaxDescriptionLength: 32768}
+
+	notifications := NewNotificationLog(10)
+	receiver := NewReceiver(log.NewNopLogger(), conf, template.SimpleTemplate(), newTestFakeJira()).WithNotificationLog(notifications)
+	receiver.timeNow = func() time.Time { return now }
+
+	resolved := &alertmanager.Data{
+		GroupKey:    "{}:{a=\"b\"}",
+		Alerts:      alertmanager.Alerts{{Status: alertmanager.AlertResolved}},
+		Status:      alertmanager.AlertResolved,
+		GroupLabels: alertmanager.KV{"a": "b"},
+	}
+	_, err := receiver.Notify(context.Background(), resolved, opts)
+	require.NoError(t, err)
+
+	firing := &alertmanager.Data{
+		GroupKey:    "{}:{a=\"b\"}",
+		Alerts:      alertmanager.Alerts{{Status: alertmanager.AlertFiring}},
+		Status:      alertmanager.AlertFiring,
+		GroupLabels: alertmanager.KV{"a": "b"},
+	}
+	_, err = receiver.Notify(context.Background(), firing, opts)
+	require.NoError(t, err)
+
+	// Dry runs are not recorded.
+	_, _, err = receiver.DryRun(context.Background(), firing, opts)
+	require.NoError(t, err)
+
+	conf.Summary = "{{ .Missing.Field }}"
+	_, err = receiver.Notify(context.Background(), firing, opts)
+	require.Error(t, err)
+
+	n := notifications.List()
+	require.Len(t, n, 3)
+	require.Equal(t, ActionFailed, n[0].Action)
+	require.Contains(t, n[0].Error, "generate summary from template")
+	require.Equal(t, Notification{
+		Time:     now,
+		Receiver: "jira",
+		GroupKey: "{}:{a=\"b\"}",
+		Status:   alertmanager.AlertFiring,
+		Alerts:   1,
+		Action:   ActionCreated,
+		IssueKey: "1",
+		IssueURL: "https://jira.example.com/browse/1",
+	}, n[1])
+	require.Equal(t, ActionNone, n[2].Action)
+	require.Empty(t, n[2].IssueKey)
+}
+
+func TestNotifyOutcome(t *testing.T) {
+	receiver := NewReceiver(log.NewNopLogger(), testReceiverConfig1(), template.SimpleTemplate(), newTestFakeJira())
+	opts := Options{HashJiraLabel: true, MaxDescriptionLength: 32768}
+	firing := &alertmanager.Data{
+		Alerts:      alertmanager.Alerts{{Status: alertmanager.AlertFiring}},
+		Status:      alertmanager.AlertFiring,
+		GroupLabels: alertmanager.KV{"a": "b"},
+	}
+
+	n, retry, err := receiver.NotifyOutcome(context.Background(), firing, opts)
+	require.NoError(t, err)
+	require.False(t, retry)
+	require.Equal(t, ActionCreated, n.Action)
+	require.Equal(t, "1", n.IssueKey)
+
+	n, _, err = receiver.NotifyOutcome(context.Background(), firing, opts)
+	require.NoError(t, err)
+	require.Equal(t, ActionMatched, n.Action)
+	require.Equal(t, "1", n.IssueKey)
+}
@@ -14,11 +14,14 @@
 package notify
 
 import (
-	"bytes"
-	"crypto/sha512"
+	"context"
+	"encoding/json"
 	"fmt"
 	"io"
+	"math"
+	"net/http"
 	"reflect"
+	"strconv"
 	"strings"
 	"time"
 
@@ -28,6 +31,9 @@ import (
 	"github.com/pkg/errors"
 	"github.com/prometheus-community/jiralert/pkg/alertmanager"
 	"github.com/prometheus-community/jiralert/pkg/config"
+	"github.com/prometheus-community/jiralert/pkg/identity"
+	"github.com/prometheus-community/jiralert/pkg/markup"
+	"github.com/prometheus-community/jiralert/pkg/store"
 	"github.com/prometheus-community/jiralert/pkg/template"
 	"github.com/trivago/tgo/tcontainer"
 )
@@ -35,13 +41,26 @@ import (
 // TODO(bwplotka): Consider renaming this package to ticketer.
 
 type jiraIssueService interface {
-	Search(jql string, options *jira.SearchOptions) ([]jira.Issue, *jira.Response, error)
-	GetTransitions(id string) ([]jira.Transition, *jira.Response, error)
-
-	Create(issue *jira.Issue) (*jira.Issue, *jira.Response, error)
-	UpdateWithOptions(issue *jira.Issue, opts *jira.UpdateQueryOptions) (*jira.Issue, *jira.Response, error)
-	AddComment(issueID string, comment *jira.Comment) (*jira.Comment, *jira.Response, error)
-	DoTransition(ticketID, transitionID string) (*jira.Response, error)
+	SearchWithContext(ctx context.Context, jql string, options *jira.SearchOptions) ([]jira.Issue, *jira.Response, error)
+	GetWithContext(ctx context.Context, issueID string, options *jira.GetQueryOptions) (*jira.Issue, *jira.Response, error)
+	GetTransitionsWithContext(ctx context.Context, id string) ([]jira.Transition, *jira.Response, error)
+	GetCreateMetaWithContext(ctx context.Context, projectkeys string) (*jira.CreateMetaInfo, *jira.Response, error)
+	FindUsersWithContext(ctx context.Context, query string) ([]jira.User, *jira.Response, error)
+
+	CreateWithContext(ctx context.Context, issue *jira.Issue) (*jira.Issue, *jira.Response, error)
+	BulkCreateWithContext(ctx context.Context, issues []*jira.Issue) ([]*jira.Issue, []error, *jira.Response, error)
+	UpdateWithOptionsWithContext(ctx context.Context, issue *jira.Issue, opts *jira.UpdateQueryOptions) (*jira.Issue, *jira.Response, error)
+	UpdateIssueWithContext(ctx context.Context, jiraID string, data map[string]interface{}) (*jira.Response, error)
+	AddCommentWithContext(ctx context.Context, issueID string, comment *jira.Comment) (*jira.Comment, *jira.Response, error)
+	DoTransitionWithContext(ctx context.Context, ticketID, transitionID string) (*jira.Response, error)
+	AddLinkWithContext(ctx context.Context, issueLink *jira.IssueLink) (*jira.Response, error)
+	AddWorklogRecordWithContext(ctx context.Context, issueID string, record *jira.WorklogRecord, options ...func(*http.Request) error) (*jira.WorklogRecord, *jira.Response, error)
+	GetRemoteLinksWithContext(ctx context.Context, id string) (*[]jira.RemoteLink, *jira.Response, error)
+	AddRemoteLinkWithContext(ctx context.Context, issueID string, remotelink *jira.RemoteLink) (*jira.RemoteLink, *jira.Response, error)
+	PostAttachmentWithContext(ctx context.Context, issueID string, r io.Reader, attachmentName string) (*[]jira.Attachment, *jira.Response, error)
+	AddWatcherWithContext(ctx context.Context, issueID string, userName string) (*jira.Response, error)
+
+	CreateRequestWithContext(ctx context.Context, request *jira.Request) (*jira.Request, *jira.Response, error)
 }
 
 // Receiver wraps a specific Alertmanager receiver with its configuration and templates, creating/updating/reopening Jira issues based on Alertmanager notifications.
@@ -51,153 +70,427 @@ type Receiver struct {
 	// TODO(bwplotka): Consider splitting receiver config with ticket service details.
 	conf *config.ReceiverConfig
 	tmpl *template.Template
+	// issues records the managed issues, if set.
+	issues *IssueLog
+	// notifications records the outcome of notifications, if set.
+	notifications *NotificationLog
+	// updates throttles updates of issues, if set.
+	updates *UpdateTracker
+	// locks serializes notifications of the same alert group, if set.
+	locks *GroupLocker
+	// searches caches the issues found for alert groups, if set.
+	searches *SearchCache
+	// transitions caches the workflow transitions available from issue statuses, if set.
+	transitions *TransitionCache
+	// users caches the Jira users found for email addresses and usernames, if set.
+	users *UserCache
+	// resolves delays the resolution of issues, if set.
+	resolves *ResolveScheduler
+	// flaps counts the reopens of issues, if set.
+	flaps *FlapTracker
+	// bulk gathers created issues into bulk create requests, if set.
+	bulk *BulkCreator
+	// stale tracks when issues were last notified firing, if set.
+	stale *StaleTracker
+	// store records the issues of alert groups, looked up before searching, if set.
+	store store.Store
+	// resolveNow is set when handling a notification again after the auto_resolve delay.
+	resolveNow bool
+	// handled is the outcome of the notification being handled, completed by recordIssue.
+	handled *Notification
+	// planned is the result of the dry run being handled, completed with what it would do besides writing to Jira.
+	planned *DryRunResult
+	// dryRun is set for dry runs and renders, which neither record issues nor update metrics.
+	dryRun bool
 
 	timeNow func() time.Time
 }
 
 // NewReceiver creates a Receiver using the provided configuration, template and jiraIssueService.
 func NewReceiver(logger log.Logger, c *config.ReceiverConfig, t *template.Template, client jiraIssueService) *Receiver {
+	if client != nil {
+		client = &instrumentedClient{receiver: c.Name, next: client}
+	}
+	if isEnabled(c.TemplateStrict) {
+		t = t.Strict()
+	}
+	if c.DefaultTimezone != "" {
+		// The time zone is validated when loading the configuration.
+		if loc, err := time.LoadLocation(c.DefaultTimezone); err == nil {
+			t = t.In(loc)
+		}
+	}
+	if c.PrometheusURL != "" {
+		t = t.WithPrometheus(c.PrometheusURL)
+	}
 	return &Receiver{logger: logger, conf: c, tmpl: t, client: client, timeNow: time.Now}
 }
 
 // Notify manages JIRA issues based on alertmanager webhook notify message.
-func (r *Receiver) Notify(data *alertmanager.Data, hashJiraLabel bool, updateSummary bool, updateDescription bool, reopenTickets bool, maxDescriptionLength int) (bool, error) {
-	project, err := r.tmpl.Execute(r.conf.Project, data)
+func (r *Receiver) Notify(ctx context.Context, data *alertmanager.Data, opts Options) (bool, error) {
+	_, retry, err := r.notifyRecorded(ctx, data, opts)
+	return retry, err
+}
+
+// NotifyOutcome is Notify, also returning the outcome of the notification: the action taken and the issue it was
+// taken on, if any.
+func (r *Receiver) NotifyOutcome(ctx context.Context, data *alertmanager.Data, opts Options) (Notification, bool, error) {
+	return r.notifyRecorded(ctx, data, opts)
+}
+
+// notifyRecorded handles the notification, returning and recording its outcome.
+func (r *Receiver) notifyRecorded(ctx context.Context, data *alertmanager.Data, opts Options) (Notification, bool, error) {
+	n := &Notification{
+		Time:     r.timeNow(),
+		Receiver: r.conf.Name,
+		GroupKey: data.GroupKey,
+		Status:   data.Status,
+		Alerts:   len(data.Alerts),
+		Action:   ActionNone,
+	}
+	r.handled = n
+	retry, err := r.configured(data).notify(ctx, data, opts)
+	r.handled = nil
 	if err != nil {
-		return false, errors.Wrap(err, "generate project from template")
+		n.Action, n.Error = ActionFailed, err.Error()
+	}
+	if r.notifications != nil && !r.dryRun {
+		r.notifications.Record(*n)
 	}
+	return *n, retry, err
+}
 
-	issueGroupLabel := toGroupTicketLabel(data.GroupLabels, hashJiraLabel)
+// configured returns the receiver with the overrides of its presets and schedules applying to the notification.
+func (r *Receiver) configured(data *alertmanager.Data) *Receiver {
+	return r.withPreset(data).withSchedule()
+}
 
-	issue, retry, err := r.findIssueToReuse(project, issueGroupLabel)
+func (r *Receiver) notify(ctx context.Context, data *alertmanager.Data, opts Options) (bool, error) {
+	opts = opts.Merge(r.conf)
+	data = r.orderAlerts(data)
+
+	project, fallback, err := r.renderProject(data)
+	if err != nil {
+		return false, err
+	}
+	if fallback && !r.dryRun {
+		projectFallbacks.WithLabelValues(r.conf.Name).Inc()
+	}
+
+	strategy, err := identity.New(r.conf.Identity, opts.HashJiraLabel)
+	if err != nil {
+		return false, err
+	}
+	groupQuery := strategy.Query(data.GroupLabels)
+
+	unlock, err := r.lockGroup(ctx, project, groupQuery)
+	if err != nil {
+		return errors.Is(err, context.DeadlineExceeded), errors.Wrap(err, "wait for concurrent notification of the alert group")
+	}
+	defer unlock()
+	defer r.trackStale(project, groupQuery, data)
+	defer r.recordGroup(ctx, project, groupQuery, data)
+
+	if data.Alerts.HasFiring() {
+		r.cancelResolve(ctx, project, groupQuery)
+	}
+	r.countTruncatedAlerts(data)
+
+	issue, retry, err := r.findIssueToReuse(ctx, project, groupQuery)
 	if err != nil {
 		return retry, err
 	}
+	if issue != nil && r.ignored(issue) {
+		level.Info(r.logger).Log("msg", "issue is marked to be left alone, not touching it", "key", issue.Key, "query", groupQuery)
+		r.recordIssue(issue, data.GroupLabels, ActionMatched)
+		return false, nil
+	}
 
 	// We want up to date title no matter what.
 	// This allows reflecting current group state if desired by user e.g {{ len $.Alerts.Firing() }}
-	issueSummary, err := r.tmpl.Execute(r.conf.Summary, data)
+	issueSummary, err := r.renderSummary(data)
 	if err != nil {
-		return false, errors.Wrap(err, "generate summary from template")
+		return false, err
 	}
 
-	issueDesc, err := r.tmpl.Execute(r.conf.Description, data)
+	issueDesc, truncated, err := r.renderDescription(data, opts)
 	if err != nil {
+		r.countTemplateError()
 		return false, errors.Wrap(err, "render issue description")
 	}
 
-	if len(issueDesc) > maxDescriptionLength {
-		level.Warn(r.logger).Log("msg", "truncating description", "original", len(issueDesc), "limit", maxDescriptionLength)
-		issueDesc = issueDesc[:maxDescriptionLength]
+	issueEnv, err := r.execute(r.conf.Environment, data)
+	if err != nil {
+		return false, errors.Wrap(err, "render issue environment")
+	}
+
+	if truncated {
+		level.Warn(r.logger).Log("msg", "truncated description", "limit", opts.MaxDescriptionLength)
+		r.countTruncation("description")
+	}
+
+	if r.conf.APIVersion == config.APIVersion3 {
+		// Compare with existing issues in the form Jira returns after converting to and from ADF.
+		issueDesc = markup.NormalizeWiki(issueDesc)
+		issueEnv = markup.NormalizeWiki(issueEnv)
 	}
 
 	if issue != nil {
+		throttled := r.updateThrottled(issue.Key)
+		frozen := r.inProgressFrozen(issue)
+		updated := false
+		summary := issue.Fields.Summary
 
 		// Update summary if needed.
-		if updateSummary {
-			if issue.Fields.Summary != issueSummary {
+		if opts.UpdateSummary && !throttled && !frozen {
+			if summary != issueSummary {
 				level.Debug(r.logger).Log("updateSummaryDisabled executing")
-				retry, err := r.updateSummary(issue.Key, issueSummary)
+				retry, err := r.updateSummary(ctx, issue.Key, issueSummary)
 				if err != nil {
 					return retry, err
 				}
+				summary = issueSummary
+				updated = true
 			}
 		}
 
-		if r.conf.UpdateInComment != nil && *r.conf.UpdateInComment {
+		if r.conf.UpdateInComment != nil && *r.conf.UpdateInComment && !throttled {
+			comments := commentBodies(issueDesc, r.conf)
+			if len(comments) == 1 && comments[0] != issueDesc {
+				r.countTruncation("comment")
+			}
 			numComments := 0
 			if issue.Fields.Comments != nil {
 				numComments = len(issue.Fields.Comments.Comments)
 			}
-			if numComments > 0 && issue.Fields.Comments.Comments[(numComments-1)].Body == issueDesc {
+			if numComments > 0 && issue.Fields.Comments.Comments[(numComments-1)].Body == comments[len(comments)-1] {
 				// if the new comment is identical to the most recent comment,
 				// this is probably due to the prometheus repeat_interval and should not be added.
 				level.Debug(r.logger).Log("msg", "not adding new comment identical to last", "key", issue.Key)
-			} else if numComments == 0 && issue.Fields.Description == issueDesc {
+			} else if numComments == 0 && r.describes(issue.Fields.Description, issueDesc) {
 				// if the first comment is identical to the description,
 				// this is probably due to the prometheus repeat_interval and should not be added.
 				level.Debug(r.logger).Log("msg", "not adding comment identical to description", "key", issue.Key)
 			} else {
-				retry, err := r.addComment(issue.Key, issueDesc)
+				if len(comments) > 1 {
+					level.Debug(r.logger).Log("msg", "splitting long comment", "key", issue.Key, "length", len(issueDesc), "parts", len(comments))
+				}
+				for _, comment := range comments {
+					retry, err := r.addComment(ctx, issue.Key, comment)
+					if err != nil {
+						return retry, err
+					}
+				}
+				updated = true
+			}
+		}
+
+		// update description if enabled. This has to be done after comment adding logic which needs to handle redundant commentary vs description case.
+		if opts.UpdateDescription && !throttled && !frozen {
+			if description, ok := r.updatedDescription(issue.Fields.Description, issueDesc); ok {
+				retry, err := r.updateDescription(ctx, issue.Key, description)
 				if err != nil {
 					return retry, err
 				}
+				updated = true
 			}
 		}
 
-		// update description if enabled. This has to be done after comment adding logic which needs to handle redundant commentary vs description case.
-		if updateDescription {
-			if issue.Fields.Description != issueDesc {
-				retry, err := r.updateDescription(issue.Key, issueDesc)
+		if isEnabled(r.conf.UpdateEnvironment) && !throttled && !frozen && issue.Fields.Environment != issueEnv {
+			retry, err := r.updateEnvironment(ctx, issue.Key, issueEnv)
+			if err != nil {
+				return retry, err
+			}
+			updated = true
+		}
+
+		if updated {
+			r.recordUpdate(issue.Key)
+		}
+
+		if isEnabled(r.conf.SetMissingPriority) && issue.Fields.Priority == nil && r.conf.Priority != "" {
+			issuePrio, err := r.execute(r.conf.Priority, data)
+			if err != nil {
+				return false, errors.Wrap(err, "render issue priority")
+			}
+			if issuePrio != "" {
+				retry, err := r.updatePriority(ctx, issue.Key, issuePrio)
 				if err != nil {
 					return retry, err
 				}
 			}
 		}
 
-		if len(data.Alerts.Firing()) == 0 {
+		if r.conf.Escalation != nil && data.Alerts.HasFiring() && issue.Fields.Status.StatusCategory.Key != "done" {
+			retry, err := r.escalate(ctx, issue, data)
+			if err != nil {
+				return retry, err
+			}
+		}
+
+		if m, ok := strategy.(identity.Migration); ok {
+			retry, err := r.backfillIdentity(ctx, issue, data.GroupLabels, m)
+			if err != nil {
+				return retry, err
+			}
+		}
+
+		if isEnabled(r.conf.AddGroupLabels) && isEnabled(r.conf.SyncGroupLabels) {
+			retry, err := r.syncGroupLabels(ctx, issue, data.GroupLabels, strategy)
+			if err != nil {
+				return retry, err
+			}
+		}
+
+		if isEnabled(r.conf.StatusLabel) {
+			retry, err := r.syncStatusLabel(ctx, issue, data)
+			if err != nil {
+				return retry, err
+			}
+		}
+
+		if retry, err := r.updateManagedFields(ctx, issue, data); err != nil {
+			return retry, err
+		}
+		r.warnTruncated(ctx, issue, data)
+		r.syncRemoteLinks(ctx, issue, data, false)
+
+		if !data.Alerts.HasFiring() {
+			if delay := r.resolveDelay(); delay > 0 {
+				level.Debug(r.logger).Log("msg", "no firing alert; resolving issue unless it fires again", "key", issue.Key, "query", groupQuery, "delay", delay)
+				r.scheduleResolve(ctx, data, opts, project, groupQuery, delay)
+				r.recordIssue(issue, data.GroupLabels, ActionMatched)
+				return false, nil
+			}
 			if r.conf.AutoResolve != nil {
-				level.Debug(r.logger).Log("msg", "no firing alert; resolving issue", "key", issue.Key, "label", issueGroupLabel)
-				retry, err := r.resolveIssue(issue.Key)
+				level.Debug(r.logger).Log("msg", "no firing alert; resolving issue", "key", issue.Key, "query", groupQuery)
+				if retry, err := r.syncSummaryPrefix(ctx, issue.Key, summary, issueSummary); err != nil {
+					return retry, err
+				}
+				retry, err := r.resolveIssue(ctx, issue)
 				if err != nil {
 					return retry, err
 				}
+				r.logWork(ctx, issue, data.Alerts)
+				r.invalidateSearch(project, groupQuery)
+				r.recordIssue(issue, data.GroupLabels, ActionResolved)
 				return false, nil
 			}
 
-			level.Debug(r.logger).Log("msg", "no firing alert; summary checked, nothing else to do.", "key", issue.Key, "label", issueGroupLabel)
+			level.Debug(r.logger).Log("msg", "no firing alert; summary checked, nothing else to do.", "key", issue.Key, "query", groupQuery)
+			r.recordIssue(issue, data.GroupLabels, ActionMatched)
 			return false, nil
 		}
 
 		// The set of JIRA status categories is fixed, this is a safe check to make.
 		if issue.Fields.Status.StatusCategory.Key != "done" {
-			level.Debug(r.logger).Log("msg", "issue is unresolved, all is done", "key", issue.Key, "label", issueGroupLabel)
+			level.Debug(r.logger).Log("msg", "issue is unresolved, all is done", "key", issue.Key, "query", groupQuery)
+			r.recordIssue(issue, data.GroupLabels, ActionMatched)
 			return false, nil
 		}
 
-		if reopenTickets {
+		if opts.ReopenTickets {
 			if r.conf.WontFixResolution != "" && issue.Fields.Resolution != nil &&
 				issue.Fields.Resolution.Name == r.conf.WontFixResolution {
-				level.Info(r.logger).Log("msg", "issue was resolved as won't fix, not reopening", "key", issue.Key, "label", issueGroupLabel, "resolution", issue.Fields.Resolution.Name)
+				level.Info(r.logger).Log("msg", "issue was resolved as won't fix, not reopening", "key", issue.Key, "query", groupQuery, "resolution", issue.Fields.Resolution.Name)
+				r.silenceWontFix(ctx, issue, data)
+				r.recordIssue(issue, data.GroupLabels, ActionMatched)
+				return false, nil
+			}
+
+			if mw := r.maintenanceWindow(data); mw != nil {
+				level.Info(r.logger).Log("msg", "issue was recently resolved, not reopening it during maintenance window", "key", issue.Key, "query", groupQuery, "window", mw.Name)
+				r.suppress(issue, mw)
 				return false, nil
 			}
 
-			level.Info(r.logger).Log("msg", "issue was recently resolved, reopening", "key", issue.Key, "label", issueGroupLabel)
-			return r.reopen(issue.Key)
+			level.Info(r.logger).Log("msg", "issue was recently resolved, reopening", "key", issue.Key, "query", groupQuery)
+			if retry, err := r.syncSummaryPrefix(ctx, issue.Key, summary, issueSummary); err != nil {
+				return retry, err
+			}
+			retry, err := r.reopen(ctx, issue)
+			if err != nil {
+				return retry, err
+			}
+			r.checkFlapping(ctx, issue, data)
+			r.invalidateSearch(project, groupQuery)
+			r.recordIssue(issue, data.GroupLabels, ActionReopened)
+			return false, nil
 		}
 
 		level.Debug(r.logger).Log("Did not update anything")
+		r.recordIssue(issue, data.GroupLabels, ActionMatched)
 		return false, nil
 	}
 
-	if len(data.Alerts.Firing()) == 0 {
-		level.Debug(r.logger).Log("msg", "no firing alert; nothing to do.", "label", issueGroupLabel)
+	if !data.Alerts.HasFiring() {
+		level.Debug(r.logger).Log("msg", "no firing alert; nothing to do.", "query", groupQuery)
 		return false, nil
 	}
 
-	level.Info(r.logger).Log("msg", "no recent matching issue found, creating new issue", "label", issueGroupLabel)
+	if mw := r.maintenanceWindow(data); mw != nil {
+		level.Info(r.logger).Log("msg", "no recent matching issue found, not creating one during maintenance window", "query", groupQuery, "window", mw.Name)
+		r.suppress(nil, mw)
+		return false, nil
+	}
+
+	level.Info(r.logger).Log("msg", "no recent matching issue found, creating new issue", "query", groupQuery)
 
-	issueType, err := r.tmpl.Execute(r.conf.IssueType, data)
+	issue, err = r.newIssue(data, project, issueSummary, issueDesc, issueEnv, strategy)
 	if err != nil {
-		return false, errors.Wrap(err, "render issue type")
+		return false, err
+	}
+	r.setUsers(ctx, issue, data)
+	if r.conf.ServiceDesk != nil {
+		retry, err = r.createRequest(ctx, issue, data)
+	} else {
+		retry, err = r.createWithFallbackType(ctx, issue)
+	}
+	if err != nil {
+		return retry, err
+	}
+	if !r.dryRun {
+		r.checkDuplicates(ctx, project, groupQuery, issue)
+	}
+	r.warnTruncated(ctx, issue, data)
+	r.attachGraph(ctx, issue, data)
+	r.syncRemoteLinks(ctx, issue, data, true)
+	r.addWatchers(ctx, issue, data)
+	r.cacheSearch(project, groupQuery, issue.Key)
+	r.recordUpdate(issue.Key)
+	r.recordIssue(issue, data.GroupLabels, ActionCreated)
+	return false, nil
+}
+
+// newIssue renders the issue to create for the given alert group.
+func (r *Receiver) newIssue(data *alertmanager.Data, project, summary, description, environment string, strategy identity.Strategy) (*jira.Issue, error) {
+	issueType, err := r.execute(r.conf.IssueType, data)
+	if err != nil {
+		return nil, errors.Wrap(err, "render issue type")
 	}
 
-	staticLabels := r.conf.StaticLabels
+	// Copy static labels, so appending doesn't modify the configuration.
+	labels := append([]string{}, r.conf.StaticLabels...)
 
-	issue = &jira.Issue{
+	if isEnabled(r.conf.ManagedDescription) {
+		description = wrapManaged(description)
+	}
+
+	issue := &jira.Issue{
 		Fields: &jira.IssueFields{
 			Project:     jira.Project{Key: project},
 			Type:        jira.IssueType{Name: issueType},
-			Description: issueDesc,
-			Summary:     issueSummary,
-			Labels:      append(staticLabels, issueGroupLabel),
+			Description: description,
+			Summary:     summary,
+			Environment: environment,
+			Labels:      append(labels, strategy.Labels(data.GroupLabels)...),
 			Unknowns:    tcontainer.NewMarshalMap(),
 		},
 	}
 	if r.conf.Priority != "" {
-		issuePrio, err := r.tmpl.Execute(r.conf.Priority, data)
+		issuePrio, err := r.execute(r.conf.Priority, data)
 		if err != nil {
-			return false, errors.Wrap(err, "render issue priority")
+			return nil, errors.Wrap(err, "render issue priority")
 		}
 
 		issue.Fields.Priority = &jira.Priority{Name: issuePrio}
@@ -206,9 +499,9 @@ func (r *Receiver) Notify(data *alertmanager.Data, hashJiraLabel bool, updateSum
 	if len(r.conf.Components) > 0 {
 		issue.Fields.Components = make([]*jira.Component, 0, len(r.conf.Components))
 		for _, component := range r.conf.Components {
-			issueComp, err := r.tmpl.Execute(component, data)
+			issueComp, err := r.execute(component, data)
 			if err != nil {
-				return false, errors.Wrap(err, "render issue component")
+				return nil, errors.Wrap(err, "render issue component")
 			}
 
 			issue.Fields.Components = append(issue.Fields.Components, &jira.Component{Name: issueComp})
@@ -216,19 +509,85 @@ func (r *Receiver) Notify(data *alertmanager.Data, hashJiraLabel bool, updateSum
 	}
 
 	if r.conf.AddGroupLabels != nil && *r.conf.AddGroupLabels {
-		for k, v := range data.GroupLabels {
-			issue.Fields.Labels = append(issue.Fields.Labels, fmt.Sprintf("%s=%.200q", k, v))
+		groupLabels, err := r.groupLabelsToJiraLabels(data.GroupLabels)
+		if err != nil {
+			return nil, err
 		}
+		issue.Fields.Labels = append(issue.Fields.Labels, groupLabels...)
+	}
+	if isEnabled(r.conf.StatusLabel) {
+		issue.Fields.Labels = append(issue.Fields.Labels, statusLabel(data))
 	}
 
 	for key, value := range r.conf.Fields {
-		issue.Fields.Unknowns[key], err = deepCopyWithTemplate(value, r.tmpl, data)
+		rendered, err := deepCopyWithTemplate(value, r.tmpl, r.templateData(data))
 		if err != nil {
-			return false, err
+			r.countTemplateError()
+			return nil, err
+		}
+		issue.Fields.Unknowns[key], err = convertField(rendered, r.conf.FieldTypes[key])
+		if err != nil {
+			return nil, errors.Wrapf(err, "convert field %s", key)
+		}
+	}
+	for key, value := range strategy.Fields(data.GroupLabels) {
+		issue.Fields.Unknowns[key] = value
+	}
+	if r.conf.ManagedFields != nil {
+		for key, value := range r.historyFields(alertHistory{}.record(data.Alerts, r.timeNow())) {
+			issue.Fields.Unknowns[key] = value
 		}
 	}
 
-	return r.create(issue)
+	return issue, nil
+}
+
+// execute renders the template text, counting errors. Notifications are limited to the alerts passed to templates.
+func (r *Receiver) execute(text string, data interface{}) (string, error) {
+	if d, ok := data.(*alertmanager.Data); ok {
+		data = r.templateData(d)
+	}
+	s, err := r.tmpl.Execute(text, data)
+	if err != nil {
+		r.countTemplateError()
+	}
+	return s, err
+}
+
+func (r *Receiver) countTemplateError() {
+	if !r.dryRun {
+		templateErrors.WithLabelValues(r.conf.Name).Inc()
+	}
+}
+
+func (r *Receiver) countTruncation(field string) {
+	if !r.dryRun {
+		truncations.WithLabelValues(r.conf.Name, field).Inc()
+	}
+}
+
+// convertField converts the rendered value of a field to the given type, see config.ReceiverConfig.FieldTypes. Only
+// values rendered from string templates are converted.
+func convertField(value interface{}, typ string) (interface{}, error) {
+	s, ok := value.(string)
+	if !ok {
+		return value, nil
+	}
+	switch typ {
+	case config.FieldTypeNumber:
+		f, err := strconv.ParseFloat(strings.TrimSpace(s), 64)
+		if err != nil {
+			return nil, errors.Errorf("%q is not a number", s)
+		}
+		return f, nil
+	case config.FieldTypeJSON:
+		var v interface{}
+		if err := json.Unmarshal([]byte(s), &v); err != nil {
+			return nil, errors.Wrapf(err, "parse %q as JSON", s)
+		}
+		return v, nil
+	}
+	return s, nil
 }
 
 // deepCopyWithTemplate returns a deep copy of a map/slice/array/string/int/bool or combination thereof, executing the
@@ -282,48 +641,182 @@ func deepCopyWithTemplate(value interface{}, tmpl *template.Template, data inter
 	}
 }
 
-// toGroupTicketLabel returns the group labels as a single string.
-// This is used to reference each ticket groups.
-// (old) default behavior: String is the form of an ALERT Prometheus metric name, with all spaces removed.
-// new opt-in behavior: String is the form of JIRALERT{sha512hash(groupLabels)}
-// hashing ensures that JIRA validation still accepts the output even
-// if the combined length of all groupLabel key-value pairs would be
-// longer than 255 chars
-func toGroupTicketLabel(groupLabels alertmanager.KV, hashJiraLabel bool) string {
-	// new opt in behavior
-	if hashJiraLabel {
-		hash := sha512.New()
-		for _, p := range groupLabels.SortedPairs() {
-			kvString := fmt.Sprintf("%s=%q,", p.Name, p.Value)
-			_, _ = hash.Write([]byte(kvString)) // hash.Write can never return an error
+// groupLabelsToJiraLabels renders the group labels selected by the receiver's include/exclude lists as Jira labels.
+func (r *Receiver) groupLabelsToJiraLabels(groupLabels alertmanager.KV) ([]string, error) {
+	var labels []string
+	for _, p := range groupLabels.SortedPairs() {
+		if !matchesAny(r.conf.LabelInclude, p.Name, true) || matchesAny(r.conf.LabelExclude, p.Name, false) {
+			continue
+		}
+
+		label, err := r.renderGroupLabel(p)
+		if err != nil {
+			return nil, err
+		}
+		if label != "" {
+			labels = append(labels, label)
 		}
-		return fmt.Sprintf("JIRALERT{%x}", hash.Sum(nil))
 	}
+	return labels, nil
+}
 
-	// old default behavior
-	buf := bytes.NewBufferString("ALERT{")
-	for _, p := range groupLabels.SortedPairs() {
-		buf.WriteString(p.Name)
-		buf.WriteString(fmt.Sprintf("=%q,", p.Value))
+func (r *Receiver) renderGroupLabel(p alertmanager.Pair) (string, error) {
+	if r.conf.LabelFormat == "" {
+		return fmt.Sprintf("%s=%.200q", p.Name, p.Value), nil
+	}
+	label, err := r.execute(r.conf.LabelFormat, p)
+	if err != nil {
+		return "", errors.Wrap(err, "render group label")
+	}
+	// Jira labels cannot contain spaces.
+	return strings.Replace(label, " ", "_", -1), nil
+}
+
+// syncGroupLabels adds missing group labels to an existing issue and removes the ones rendered for group label
+// values that no longer apply. Labels not looking like group labels (e.g. added by humans) are left untouched, and
+// so are the identity, static and status labels.
+func (r *Receiver) syncGroupLabels(ctx context.Context, issue *jira.Issue, groupLabels alertmanager.KV, strategy identity.Strategy) (bool, error) {
+	desired, err := r.groupLabelsToJiraLabels(groupLabels)
+	if err != nil {
+		return false, err
+	}
+
+	current := make(map[string]struct{}, len(issue.Fields.Labels))
+	for _, l := range issue.Fields.Labels {
+		current[l] = struct{}{}
+	}
+	wanted := make(map[string]struct{}, len(desired))
+	var ops []map[string]string
+	for _, l := range desired {
+		wanted[l] = struct{}{}
+		if _, ok := current[l]; !ok {
+			ops = append(ops, map[string]string{"add": l})
+		}
+	}
+
+	// Never remove labels jiralert relies on, even if they happen to look like group labels.
+	protected := map[string]struct{}{statusLabelFiring: {}, statusLabelResolved: {}}
+	identityLabels := strategy.Labels(groupLabels)
+	if m, ok := strategy.(identity.Migration); ok {
+		// Issues found through the secondary strategy are still identified by its labels.
+		identityLabels = append(identityLabels, m.Secondary.Labels(groupLabels)...)
 	}
-	buf.Truncate(buf.Len() - 1)
-	buf.WriteString("}")
-	return strings.Replace(buf.String(), " ", "", -1)
+	for _, l := range append(identityLabels, r.conf.StaticLabels...) {
+		protected[l] = struct{}{}
+	}
+
+	// Render each group label name with a placeholder value, to recognize labels previously rendered for it.
+	const placeholder = "__jiralert_value__"
+	removed := map[string]struct{}{}
+	for _, name := range groupLabels.Names() {
+		rendered, err := r.renderGroupLabel(alertmanager.Pair{Name: name, Value: placeholder})
+		if err != nil {
+			return false, err
+		}
+		parts := strings.SplitN(rendered, placeholder, 2)
+		if len(parts) != 2 {
+			continue
+		}
+		if parts[0] == "" && parts[1] == "" {
+			// Without any literal text around the value, every label would look like a group label.
+			level.Warn(r.logger).Log("msg", "label format renders the bare value, not removing stale group labels", "label", name)
+			continue
+		}
+		for _, l := range issue.Fields.Labels {
+			if _, ok := wanted[l]; ok {
+				continue
+			}
+			if _, ok := protected[l]; ok {
+				continue
+			}
+			if _, ok := removed[l]; ok {
+				continue
+			}
+			if strings.HasPrefix(l, parts[0]) && strings.HasSuffix(l, parts[1]) && len(l) >= len(parts[0])+len(parts[1]) {
+				removed[l] = struct{}{}
+				ops = append(ops, map[string]string{"remove": l})
+			}
+		}
+	}
+
+	if len(ops) == 0 {
+		return false, nil
+	}
+
+	level.Debug(r.logger).Log("msg", "updating issue group labels", "key", issue.Key, "ops", fmt.Sprintf("%v", ops))
+	resp, err := r.client.UpdateIssueWithContext(ctx, issue.Key, map[string]interface{}{
+		"update": map[string]interface{}{"labels": ops},
+	})
+	if err != nil {
+		return handleJiraErrResponse("Issue.UpdateIssue", resp, err, r.conf.Retry, r.logger)
+	}
+	return false, nil
 }
 
-func (r *Receiver) search(projects []string, issueLabel string) (*jira.Issue, bool, error) {
+// backfillIdentity adds the labels and fields of the primary strategy of the migration to an issue missing them,
+// e.g. found by the secondary strategy, so that it keeps being found once the migration is over.
+func (r *Receiver) backfillIdentity(ctx context.Context, issue *jira.Issue, groupLabels alertmanager.KV, m identity.Migration) (bool, error) {
+	current := make(map[string]struct{}, len(issue.Fields.Labels))
+	for _, l := range issue.Fields.Labels {
+		current[l] = struct{}{}
+	}
+	var ops []map[string]string
+	for _, l := range m.Primary.Labels(groupLabels) {
+		if _, ok := current[l]; !ok {
+			ops = append(ops, map[string]string{"add": l})
+		}
+	}
+	if len(ops) > 0 {
+		level.Info(r.logger).Log("msg", "adding identity labels to issue", "key", issue.Key, "ops", fmt.Sprintf("%v", ops))
+		resp, err := r.client.UpdateIssueWithContext(ctx, issue.Key, map[string]interface{}{
+			"update": map[string]interface{}{"labels": ops},
+		})
+		if err != nil {
+			return handleJiraErrResponse("Issue.UpdateIssue", resp, err, r.conf.Retry, r.logger)
+		}
+	}
+
+	fields := map[string]interface{}{}
+	for key, value := range m.Primary.Fields(groupLabels) {
+		if issue.Fields.Unknowns[key] != value {
+			fields[key] = value
+		}
+	}
+	if len(fields) > 0 {
+		level.Info(r.logger).Log("msg", "adding identity fields to issue", "key", issue.Key, "fields", fmt.Sprintf("%v", fields))
+		resp, err := r.client.UpdateIssueWithContext(ctx, issue.Key, map[string]interface{}{"fields": fields})
+		if err != nil {
+			return handleJiraErrResponse("Issue.UpdateIssue", resp, err, r.conf.Retry, r.logger)
+		}
+	}
+	return false, nil
+}
+
+// matchesAny returns true if s matches any of the given expressions, or ifEmpty if there are none.
+func matchesAny(res []config.Regexp, s string, ifEmpty bool) bool {
+	if len(res) == 0 {
+		return ifEmpty
+	}
+	for _, re := range res {
+		if re.MatchString(s) {
+			return true
+		}
+	}
+	return false
+}
+
+func (r *Receiver) search(ctx context.Context, projects []string, groupQuery string) (*jira.Issue, bool, error) {
 	// Search multiple projects in case issue was moved and further alert firings are desired in existing JIRA.
-	projectList := "'" + strings.Join(projects, "', '") + "'"
-	query := fmt.Sprintf("project in(%s) and labels=%q order by resolutiondate desc", projectList, issueLabel)
+	query := fmt.Sprintf("%s order by resolutiondate desc", projectsQuery(projects, groupQuery))
 	options := &jira.SearchOptions{
-		Fields:     []string{"summary", "status", "resolution", "resolutiondate", "description", "comment"},
+		Fields:     r.searchFields(),
 		MaxResults: 2,
 	}
 
 	level.Debug(r.logger).Log("msg", "search", "query", query, "options", fmt.Sprintf("%+v", options))
-	issues, resp, err := r.client.Search(query, options)
+	issues, resp, err := r.client.SearchWithContext(ctx, query, options)
 	if err != nil {
-		retry, err := handleJiraErrResponse("Issue.Search", resp, err, r.logger)
+		retry, err := handleJiraErrResponse("Issue.Search", resp, err, r.conf.Retry, r.logger)
 		return nil, retry, err
 	}
 
@@ -341,7 +834,64 @@ func (r *Receiver) search(projects []string, issueLabel string) (*jira.Issue, bo
 	return &issue, false, nil
 }
 
-func (r *Receiver) findIssueToReuse(project string, issueGroupLabel string) (*jira.Issue, bool, error) {
+// inProgressFrozen returns true if freeze_in_progress is enabled and someone started working on the issue, whose
+// summary, description and environment must then not be overwritten.
+func (r *Receiver) inProgressFrozen(issue *jira.Issue) bool {
+	if !isEnabled(r.conf.FreezeInProgress) || issue.Fields.Status == nil || issue.Fields.Status.StatusCategory.Key != jira.StatusCategoryInProgress {
+		return false
+	}
+	level.Debug(r.logger).Log("msg", "issue is in progress, not updating its summary, description and environment", "key", issue.Key, "status", issue.Fields.Status.Name)
+	return true
+}
+
+// ignored returns true if the issue has one of the ignore_labels or ignore_statuses.
+func (r *Receiver) ignored(issue *jira.Issue) bool {
+	for _, l := range issue.Fields.Labels {
+		for _, ignore := range r.conf.IgnoreLabels {
+			if l == ignore {
+				return true
+			}
+		}
+	}
+	if issue.Fields.Status != nil {
+		for _, ignore := range r.conf.IgnoreStatuses {
+			if issue.Fields.Status.Name == ignore {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// searchFields returns the fields of existing issues needed to update them.
+func (r *Receiver) searchFields() []string {
+	fields := []string{"summary", "status", "resolution", "resolutiondate", "description", "comment", "labels", "environment", "project", "issuetype"}
+	if isEnabled(r.conf.SetMissingPriority) || r.conf.Escalation != nil {
+		fields = append(fields, "priority")
+	}
+	if r.conf.ManagedFields != nil {
+		fields = append(fields, r.conf.ManagedFields.IDs()...)
+	}
+	if r.conf.Identity != nil && r.conf.Identity.Field != "" {
+		// Compared when backfilling the identity of issues while migrating.
+		fields = append(fields, r.conf.Identity.Field)
+	}
+	return fields
+}
+
+// projectsQuery restricts groupQuery to the given projects, unless they include AllProjects.
+func projectsQuery(projects []string, groupQuery string) string {
+	for _, p := range projects {
+		if p == config.AllProjects {
+			return groupQuery
+		}
+	}
+	return fmt.Sprintf("project in('%s') and %s", strings.Join(projects, "', '"), groupQuery)
+}
+
+// findIssueToReuse returns the issue of the alert group to update, if any. Issues moved to one of the other_projects
+// keep being updated there, under their new key: updates and transitions never move them back.
+func (r *Receiver) findIssueToReuse(ctx context.Context, project string, groupQuery string) (*jira.Issue, bool, error) {
 	projectsToSearch := []string{project}
 	// In case issue was moved to a different project, include the other configured projects in search (if any).
 	for _, other := range r.conf.OtherProjects {
@@ -350,7 +900,7 @@ func (r *Receiver) findIssueToReuse(project string, issueGroupLabel string) (*ji
 		}
 	}
 
-	issue, retry, err := r.search(projectsToSearch, issueGroupLabel)
+	issue, retry, err := r.storedSearch(ctx, project, projectsToSearch, groupQuery)
 	if err != nil {
 		return nil, retry, err
 	}
@@ -361,7 +911,7 @@ func (r *Receiver) findIssueToReuse(project string, issueGroupLabel string) (*ji
 
 	resolutionTime := time.Time(issue.Fields.Resolutiondate)
 	if resolutionTime != (time.Time{}) && resolutionTime.Add(time.Duration(*r.conf.ReopenDuration)).Before(r.timeNow()) && *r.conf.ReopenDuration != 0 {
-		level.Debug(r.logger).Log("msg", "existing resolved issue is too old to reopen, skipping", "key", issue.Key, "label", issueGroupLabel, "resolution_time", resolutionTime.Format(time.RFC3339), "reopen_duration", *r.conf.ReopenDuration)
+		level.Debug(r.logger).Log("msg", "existing resolved issue is too old to reopen, skipping", "key", issue.Key, "query", groupQuery, "resolution_time", resolutionTime.Format(time.RFC3339), "reopen_duration", *r.conf.ReopenDuration)
 		return nil, false, nil
 	}
 
@@ -369,7 +919,7 @@ func (r *Receiver) findIssueToReuse(project string, issueGroupLabel string) (*ji
 	return issue, false, nil
 }
 
-func (r *Receiver) updateSummary(issueKey string, summary string) (bool, error) {
+func (r *Receiver) updateSummary(ctx context.Context, issueKey string, summary string) (bool, error) {
 	level.Debug(r.logger).Log("msg", "updating issue with new summary", "key", issueKey, "summary", summary)
 
 	issueUpdate := &jira.Issue{
@@ -378,15 +928,15 @@ func (r *Receiver) updateSummary(issueKey string, summary string) (bool, error)
 			Summary: summary,
 		},
 	}
-	issue, resp, err := r.client.UpdateWithOptions(issueUpdate, nil)
+	issue, resp, err := r.client.UpdateWithOptionsWithContext(ctx, issueUpdate, nil)
 	if err != nil {
-		return handleJiraErrResponse("Issue.UpdateWithOptions", resp, err, r.logger)
+		return handleJiraErrResponse("Issue.UpdateWithOptions", resp, err, r.conf.Retry, r.logger)
 	}
 	level.Debug(r.logger).Log("msg", "issue summary updated", "key", issue.Key, "id", issue.ID)
 	return false, nil
 }
 
-func (r *Receiver) updateDescription(issueKey string, description string) (bool, error) {
+func (r *Receiver) updateDescription(ctx context.Context, issueKey string, description string) (bool, error) {
 	level.Debug(r.logger).Log("msg", "updating issue with new description", "key", issueKey, "description", description)
 
 	issueUpdate := &jira.Issue{
@@ -395,84 +945,259 @@ func (r *Receiver) updateDescription(issueKey string, description string) (bool,
 			Description: description,
 		},
 	}
-	issue, resp, err := r.client.UpdateWithOptions(issueUpdate, nil)
+	issue, resp, err := r.client.UpdateWithOptionsWithContext(ctx, issueUpdate, nil)
 	if err != nil {
-		return handleJiraErrResponse("Issue.UpdateWithOptions", resp, err, r.logger)
+		return handleJiraErrResponse("Issue.UpdateWithOptions", resp, err, r.conf.Retry, r.logger)
 	}
 	level.Debug(r.logger).Log("msg", "issue summary updated", "key", issue.Key, "id", issue.ID)
 	return false, nil
 }
 
-func (r *Receiver) addComment(issueKey string, content string) (bool, error) {
+func (r *Receiver) updateEnvironment(ctx context.Context, issueKey string, environment string) (bool, error) {
+	level.Debug(r.logger).Log("msg", "updating issue with new environment", "key", issueKey, "environment", environment)
+
+	issueUpdate := &jira.Issue{
+		Key: issueKey,
+		Fields: &jira.IssueFields{
+			Environment: environment,
+		},
+	}
+	issue, resp, err := r.client.UpdateWithOptionsWithContext(ctx, issueUpdate, nil)
+	if err != nil {
+		return handleJiraErrResponse("Issue.UpdateWithOptions", resp, err, r.conf.Retry, r.logger)
+	}
+	level.Debug(r.logger).Log("msg", "issue environment updated", "key", issue.Key, "id", issue.ID)
+	return false, nil
+}
+
+func (r *Receiver) updatePriority(ctx context.Context, issueKey string, priority string) (bool, error) {
+	level.Debug(r.logger).Log("msg", "setting priority of issue", "key", issueKey, "priority", priority)
+
+	issueUpdate := &jira.Issue{
+		Key: issueKey,
+		Fields: &jira.IssueFields{
+			Priority: &jira.Priority{Name: priority},
+		},
+	}
+	issue, resp, err := r.client.UpdateWithOptionsWithContext(ctx, issueUpdate, nil)
+	if err != nil {
+		return handleJiraErrResponse("Issue.UpdateWithOptions", resp, err, r.conf.Retry, r.logger)
+	}
+	level.Debug(r.logger).Log("msg", "issue priority updated", "key", issue.Key, "id", issue.ID)
+	return false, nil
+}
+
+func (r *Receiver) addComment(ctx context.Context, issueKey string, content string) (bool, error) {
 	level.Debug(r.logger).Log("msg", "adding comment to existing issue", "key", issueKey, "content", content)
 
 	commentDetails := &jira.Comment{
 		Body: content,
 	}
 
-	comment, resp, err := r.client.AddComment(issueKey, commentDetails)
+	comment, resp, err := r.client.AddCommentWithContext(ctx, issueKey, commentDetails)
 	if err != nil {
-		return handleJiraErrResponse("Issue.AddComment", resp, err, r.logger)
+		return handleJiraErrResponse("Issue.AddComment", resp, err, r.conf.Retry, r.logger)
 	}
 	level.Debug(r.logger).Log("msg", "added comment to issue", "key", issueKey, "id", comment.ID)
+	if !r.dryRun {
+		commentsAdded.WithLabelValues(r.conf.Name).Inc()
+	}
 	return false, nil
 }
 
-func (r *Receiver) reopen(issueKey string) (bool, error) {
-	return r.doTransition(issueKey, r.conf.ReopenState)
+func (r *Receiver) reopen(ctx context.Context, issue *jira.Issue) (bool, error) {
+	return r.doTransition(ctx, issue, r.conf.ReopenState, "Alert re-fired but automatic reopen failed")
 }
 
-func (r *Receiver) create(issue *jira.Issue) (bool, error) {
+func (r *Receiver) create(ctx context.Context, issue *jira.Issue) (bool, error) {
 	level.Debug(r.logger).Log("msg", "create", "issue", fmt.Sprintf("%+v", *issue.Fields))
-	newIssue, resp, err := r.client.Create(issue)
-	if err != nil {
-		return handleJiraErrResponse("Issue.Create", resp, err, r.logger)
+	if r.bulkCreates() {
+		newIssue, retry, err := r.bulk.Create(ctx, r.conf.Name, r.client, r.conf.Retry, issue, time.Duration(*r.conf.BulkCreateWait), r.logger)
+		if err != nil {
+			return retry, err
+		}
+		*issue = *newIssue
+	} else {
+		newIssue, resp, err := r.client.CreateWithContext(ctx, issue)
+		if err != nil {
+			return handleCreateErrResponse(resp, err, r.conf.Retry, r.logger)
+		}
+		*issue = *newIssue
 	}
-	*issue = *newIssue
 
 	level.Info(r.logger).Log("msg", "issue created", "key", issue.Key, "id", issue.ID)
 	return false, nil
 }
 
-func handleJiraErrResponse(api string, resp *jira.Response, err error, logger log.Logger) (bool, error) {
+// createRequest creates the given issue as a Jira Service Management customer request. Labels and fields which
+// cannot be set through the request are set on the resulting issue afterwards, so that it can be found again.
+func (r *Receiver) createRequest(ctx context.Context, issue *jira.Issue, data *alertmanager.Data) (bool, error) {
+	values := map[string]string{
+		"summary":     issue.Fields.Summary,
+		"description": issue.Fields.Description,
+	}
+	for id, tmpl := range r.conf.ServiceDesk.RequestFieldValues {
+		value, err := r.execute(tmpl, data)
+		if err != nil {
+			return false, errors.Wrapf(err, "render request field %s", id)
+		}
+		values[id] = value
+	}
+
+	request := &jira.Request{
+		ServiceDeskID: r.conf.ServiceDesk.ServiceDeskID,
+		TypeID:        r.conf.ServiceDesk.RequestTypeID,
+	}
+	for id, value := range values {
+		request.FieldValues = append(request.FieldValues, jira.RequestFieldValue{FieldID: id, Value: value})
+	}
+
+	level.Debug(r.logger).Log("msg", "create request", "service_desk", request.ServiceDeskID, "request_type", request.TypeID)
+	newRequest, resp, err := r.client.CreateRequestWithContext(ctx, request)
+	if err != nil {
+		return handleJiraErrResponse("Request.Create", resp, err, r.conf.Retry, r.logger)
+	}
+	level.Info(r.logger).Log("msg", "request created", "key", newRequest.IssueKey, "id", newRequest.IssueID)
+
+	issueUpdate := &jira.Issue{
+		Key: newRequest.IssueKey,
+		Fields: &jira.IssueFields{
+			Labels:   issue.Fields.Labels,
+			Unknowns: issue.Fields.Unknowns,
+		},
+	}
+	if _, resp, err := r.client.UpdateWithOptionsWithContext(ctx, issueUpdate, nil); err != nil {
+		return handleJiraErrResponse("Issue.UpdateWithOptions", resp, err, r.conf.Retry, r.logger)
+	}
+	issue.Key, issue.ID = newRequest.IssueKey, newRequest.IssueID
+	return false, nil
+}
+
+// toWiki converts a rendered template written in the given markup dialect to Jira wiki markup.
+func toWiki(s, renderer string) string {
+	switch renderer {
+	case config.RendererMarkdown:
+		return markup.MarkdownToWiki(s)
+	case config.RendererPlain:
+		return markup.EscapeWiki(s)
+	}
+	return s
+}
+
+// handleJiraErrResponse returns the error of a failed Jira request, and whether to retry the notification according
+// to the retry settings conf.
+func handleJiraErrResponse(api string, resp *jira.Response, err error, conf *config.RetryConfig, logger log.Logger) (bool, error) {
+	category, retry := classifyJiraError(resp, err, conf)
 	if resp == nil || resp.Request == nil {
-		level.Debug(logger).Log("msg", "handleJiraErrResponse", "api", api, "err", err)
+		level.Debug(logger).Log("msg", "handleJiraErrResponse", "api", api, "category", category, "retry", retry, "err", err)
 	} else {
-		level.Debug(logger).Log("msg", "handleJiraErrResponse", "api", api, "err", err, "url", resp.Request.URL)
+		level.Debug(logger).Log("msg", "handleJiraErrResponse", "api", api, "category", category, "retry", retry, "err", err, "url", resp.Request.URL)
 	}
 
 	if resp != nil && resp.StatusCode/100 != 2 {
-		retry := resp.StatusCode == 500 || resp.StatusCode == 503 || resp.StatusCode == 429
 		// Sometimes go-jira consumes the body (e.g. in `Search`) and includes it in the error message;
 		// sometimes (e.g. in `Create`) it doesn't. Include both the error and the body, just in case.
 		body, _ := io.ReadAll(resp.Body)
-		return retry, errors.Errorf("JIRA request %s returned status %s, error %q, body %q", resp.Request.URL, resp.Status, err, body)
+		parsed := parseJiraError(err, body)
+		msg := fmt.Sprintf("JIRA request %s returned status %s", resp.Request.URL, resp.Status)
+		if len(parsed.ErrorMessages) > 0 {
+			msg += ": " + strings.Join(parsed.ErrorMessages, "; ")
+		}
+		return retry, &JiraError{
+			API:        api,
+			StatusCode: resp.StatusCode,
+			Messages:   parsed.ErrorMessages,
+			Fields:     parsed.Errors,
+			msg:        fmt.Sprintf("%s, error %q, body %q", msg, err, body),
+		}
 	}
-	return false, errors.Wrapf(err, "JIRA request %s failed", api)
+	return retry, errors.Wrapf(err, "JIRA request %s failed", api)
+}
+
+func (r *Receiver) resolveIssue(ctx context.Context, issue *jira.Issue) (bool, error) {
+	return r.doTransition(ctx, issue, r.conf.AutoResolve.State, "Alert resolved but automatic resolve failed")
 }
 
-func (r *Receiver) resolveIssue(issueKey string) (bool, error) {
-	return r.doTransition(issueKey, r.conf.AutoResolve.State)
+// logWork logs how long the alert group fired, from the start of its first alert to the end of its last one, as work
+// on the resolved issue, if the auto_resolve worklog is enabled.
+func (r *Receiver) logWork(ctx context.Context, issue *jira.Issue, alerts alertmanager.Alerts) {
+	if !r.conf.AutoResolve.Worklog {
+		return
+	}
+	var start, end time.Time
+	for _, a := range alerts {
+		if !a.StartsAt.IsZero() && (start.IsZero() || a.StartsAt.Before(start)) {
+			start = a.StartsAt
+		}
+		if a.EndsAt.After(end) {
+			end = a.EndsAt
+		}
+	}
+	if start.IsZero() || !end.After(start) {
+		level.Debug(r.logger).Log("msg", "alert start or end time missing, not logging work", "key", issue.Key)
+		return
+	}
+
+	fired := end.Sub(start).Round(time.Second)
+	started := jira.Time(start)
+	record := &jira.WorklogRecord{
+		Comment: fmt.Sprintf("Alert fired for %s.", fired),
+		Started: &started,
+		// Jira rejects work logged for less than a minute.
+		TimeSpentSeconds: int(math.Max(fired.Seconds(), 60)),
+	}
+	if _, resp, err := r.client.AddWorklogRecordWithContext(ctx, issue.Key, record); err != nil {
+		// The issue is resolved already, so failing the notification would not log the work on retry either.
+		_, err = handleJiraErrResponse("Issue.AddWorklogRecord", resp, err, r.conf.Retry, r.logger)
+		level.Warn(r.logger).Log("msg", "failed to log alert duration as work", "key", issue.Key, "err", err)
+		return
+	}
+	level.Debug(r.logger).Log("msg", "logged alert duration as work", "key", issue.Key, "duration", fired)
 }
 
-func (r *Receiver) doTransition(issueKey string, transitionState string) (bool, error) {
-	transitions, resp, err := r.client.GetTransitions(issueKey)
+// doTransition transitions the issue into the given state. If no such transition is possible from the issue's
+// current state and comment_on_transition_failure is enabled, a comment starting with failureMsg is added instead.
+func (r *Receiver) doTransition(ctx context.Context, issue *jira.Issue, transitionState string, failureMsg string) (bool, error) {
+	issueKey := issue.Key
+	transitions, cached, retry, err := r.getTransitions(ctx, issue)
 	if err != nil {
-		return handleJiraErrResponse("Issue.GetTransitions", resp, err, r.logger)
+		return retry, err
 	}
 
 	for _, t := range transitions {
 		if t.Name == transitionState {
 			level.Debug(r.logger).Log("msg", fmt.Sprintf("transition %s", transitionState), "key", issueKey, "transitionID", t.ID)
-			resp, err = r.client.DoTransition(issueKey, t.ID)
+			resp, err := r.client.DoTransitionWithContext(ctx, issueKey, t.ID)
 			if err != nil {
-				return handleJiraErrResponse("Issue.DoTransition", resp, err, r.logger)
+				if cached && resp != nil && resp.StatusCode == http.StatusBadRequest {
+					// Jira rejects transitions which are not available (anymore) from the issue's status.
+					r.invalidateTransitions(issue)
+					return r.doTransition(ctx, issue, transitionState, failureMsg)
+				}
+				return handleJiraErrResponse("Issue.DoTransition", resp, err, r.conf.Retry, r.logger)
 			}
 
 			level.Debug(r.logger).Log("msg", transitionState, "key", issueKey)
 			return false, nil
 		}
 	}
-	return false, errors.Errorf("JIRA state %q does not exist or no transition possible for %s", transitionState, issueKey)
+	if cached {
+		r.invalidateTransitions(issue)
+		return r.doTransition(ctx, issue, transitionState, failureMsg)
+	}
 
+	if r.conf.CommentOnTransitionFailure != nil && *r.conf.CommentOnTransitionFailure {
+		currentState := "<unknown>"
+		if issue.Fields.Status != nil && issue.Fields.Status.Name != "" {
+			currentState = issue.Fields.Status.Name
+		}
+		msg := fmt.Sprintf("%s: state %q has no transition to %q.", failureMsg, currentState, transitionState)
+		if c := issue.Fields.Comments; c != nil && len(c.Comments) > 0 && c.Comments[len(c.Comments)-1].Body == msg {
+			level.Debug(r.logger).Log("msg", "no transition possible, already commented", "key", issueKey, "state", currentState, "target", transitionState)
+			return false, nil
+		}
+		level.Warn(r.logger).Log("msg", "no transition possible, commenting instead", "key", issueKey, "state", currentState, "target", transitionState)
+		return r.addComment(ctx, issueKey, msg)
+	}
+	return false, errors.Errorf("JIRA state %q does not exist or no transition possible for %s", transitionState, issueKey)
 }
@@ -13,9 +13,14 @@
 package notify
 
 import (
+	"context"
 	"fmt"
+	"io"
+	"net/http"
+	"net/url"
 	"os"
 	"sort"
+	"strings"
 	"testing"
 	"time"
 
@@ -27,21 +32,41 @@ import (
 	"github.com/pkg/errors"
 	"github.com/prometheus-community/jiralert/pkg/alertmanager"
 	"github.com/prometheus-community/jiralert/pkg/config"
+	"github.com/prometheus-community/jiralert/pkg/identity"
 	"github.com/prometheus-community/jiralert/pkg/template"
 	"github.com/stretchr/testify/require"
 )
 
-func TestToGroupTicketLabel(t *testing.T) {
-	require.Equal(t, `JIRALERT{9897cb21a3d1ba47d2aab501ce9bc60b74bf65e26658f8e34a7fc81705e6b6eadfe6ad8edfe7c68142b3fe10f2c89127bd85e5f3687fe6b9ff1eff4b3f71dd49}`, toGroupTicketLabel(alertmanager.KV{"a": "B", "C": "d"}, true))
-	require.Equal(t, `ALERT{C="d",a="B"}`, toGroupTicketLabel(alertmanager.KV{"a": "B", "C": "d"}, false))
-}
-
 type fakeJira struct {
 	// Key = ID for simplification.
 	issuesByKey map[string]*jira.Issue
 	keysByQuery map[string][]string
 
 	transitionsByID map[string]jira.Transition
+	createMeta      jira.CreateMetaInfo
+	// searches counts the calls of SearchWithContext.
+	searches int
+	// transitionGets counts the calls of GetTransitionsWithContext.
+	transitionGets int
+	links          []jira.IssueLink
+	worklogs       []*jira.WorklogRecord
+	attachments    []fakeAttachment
+	// remoteLinks are the remote links of issues, by key.
+	remoteLinks map[string][]jira.RemoteLink
+	// remoteLinkGets and remoteLinkAdds count the calls of GetRemoteLinksWithContext and AddRemoteLinkWithContext.
+	remoteLinkGets, remoteLinkAdds int
+	// users are the users found by FindUsersWithContext, by query, and userSearches counts its calls.
+	users        map[string][]jira.User
+	userSearches int
+	// watchers are the watchers added to issues, by key.
+	watchers map[string][]string
+	// issueTypes are the issue types issues can be created with, if set.
+	issueTypes map[string]bool
+}
+
+type fakeAttachment struct {
+	issueKey, filename string
+	content            []byte
 }
 
 func newTestFakeJira() *fakeJira {
@@ -52,32 +77,14 @@ func newTestFakeJira() *fakeJira {
 	}
 }
 
-func (f *fakeJira) Search(jql string, options *jira.SearchOptions) ([]jira.Issue, *jira.Response, error) {
+func (f *fakeJira) SearchWithContext(ctx context.Context, jql string, options *jira.SearchOptions) ([]jira.Issue, *jira.Response, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, nil, err
+	}
+	f.searches++
 	var issues []jira.Issue
 	for _, key := range f.keysByQuery[jql] {
-		issue := jira.Issue{Key: key, Fields: &jira.IssueFields{}}
-		for _, field := range options.Fields {
-			switch field {
-			case "summary":
-				issue.Fields.Summary = f.issuesByKey[key].Fields.Summary
-			case "description":
-				issue.Fields.Description = f.issuesByKey[key].Fields.Description
-			case "resolution":
-				if f.issuesByKey[key].Fields.Resolution == nil {
-					continue
-				}
-				issue.Fields.Resolution = &jira.Resolution{
-					Name: f.issuesByKey[key].Fields.Resolution.Name,
-				}
-			case "resolutiondate":
-				issue.Fields.Resolutiondate = f.issuesByKey[key].Fields.Resolutiondate
-			case "status":
-				issue.Fields.Status = &jira.Status{
-					StatusCategory: f.issuesByKey[key].Fields.Status.StatusCategory,
-				}
-			}
-		}
-		issues = append(issues, issue)
+		issues = append(issues, f.issueWithFields(key, options.Fields))
 	}
 
 	// We assume query 'order by resolutiondate desc' so let's sort by resolution date if any.
@@ -91,7 +98,63 @@ func (f *fakeJira) Search(jql string, options *jira.SearchOptions) ([]jira.Issue
 	return issues, nil, nil
 }
 
-func (f *fakeJira) GetTransitions(_ string) ([]jira.Transition, *jira.Response, error) {
+// issueWithFields returns a copy of the issue with only the given fields set.
+func (f *fakeJira) issueWithFields(key string, fields []string) jira.Issue {
+	issue := jira.Issue{Key: key, Fields: &jira.IssueFields{}}
+	for _, field := range fields {
+		switch field {
+		case "summary":
+			issue.Fields.Summary = f.issuesByKey[key].Fields.Summary
+		case "description":
+			issue.Fields.Description = f.issuesByKey[key].Fields.Description
+		case "resolution":
+			if f.issuesByKey[key].Fields.Resolution == nil {
+				continue
+			}
+			issue.Fields.Resolution = &jira.Resolution{
+				Name: f.issuesByKey[key].Fields.Resolution.Name,
+			}
+		case "resolutiondate":
+			issue.Fields.Resolutiondate = f.issuesByKey[key].Fields.Resolutiondate
+		case "status":
+			issue.Fields.Status = &jira.Status{
+				Name:           f.issuesByKey[key].Fields.Status.Name,
+				StatusCategory: f.issuesByKey[key].Fields.Status.StatusCategory,
+			}
+		case "comment":
+			issue.Fields.Comments = f.issuesByKey[key].Fields.Comments
+		case "labels":
+			issue.Fields.Labels = f.issuesByKey[key].Fields.Labels
+		case "environment":
+			issue.Fields.Environment = f.issuesByKey[key].Fields.Environment
+		case "priority":
+			issue.Fields.Priority = f.issuesByKey[key].Fields.Priority
+		case "project":
+			issue.Fields.Project = jira.Project{Key: f.issuesByKey[key].Fields.Project.Key}
+		case "issuetype":
+			issue.Fields.Type = jira.IssueType{Name: f.issuesByKey[key].Fields.Type.Name}
+		default:
+			if v, ok := f.issuesByKey[key].Fields.Unknowns[field]; ok {
+				if issue.Fields.Unknowns == nil {
+					issue.Fields.Unknowns = tcontainer.NewMarshalMap()
+				}
+				issue.Fields.Unknowns[field] = v
+			}
+		}
+	}
+	return issue
+}
+
+func (f *fakeJira) GetWithContext(_ context.Context, issueID string, options *jira.GetQueryOptions) (*jira.Issue, *jira.Response, error) {
+	if _, ok := f.issuesByKey[issueID]; !ok {
+		return nil, fakeResponse(http.StatusNotFound), errors.Errorf("no such issue %s", issueID)
+	}
+	issue := f.issueWithFields(issueID, strings.Split(options.Fields, ","))
+	return &issue, nil, nil
+}
+
+func (f *fakeJira) GetTransitionsWithContext(_ context.Context, _ string) ([]jira.Transition, *jira.Response, error) {
+	f.transitionGets++
 	var trs []jira.Transition
 	for _, tr := range f.transitionsByID {
 		trs = append(trs, tr)
@@ -99,7 +162,16 @@ func (f *fakeJira) GetTransitions(_ string) ([]jira.Transition, *jira.Response,
 	return trs, nil, nil
 }
 
-func (f *fakeJira) Create(issue *jira.Issue) (*jira.Issue, *jira.Response, error) {
+func (f *fakeJira) GetCreateMetaWithContext(_ context.Context, _ string) (*jira.CreateMetaInfo, *jira.Response, error) {
+	return &f.createMeta, nil, nil
+}
+
+func (f *fakeJira) CreateWithContext(_ context.Context, issue *jira.Issue) (*jira.Issue, *jira.Response, error) {
+	if f.issueTypes != nil && !f.issueTypes[issue.Fields.Type.Name] {
+		resp := fakeResponse(http.StatusBadRequest)
+		resp.Body = io.NopCloser(strings.NewReader(`{"errorMessages":[],"errors":{"issuetype":"valid issue type is required"}}`))
+		return nil, resp, errors.New("request failed")
+	}
 	issue.Key = fmt.Sprintf("%d", len(f.issuesByKey)+1)
 	issue.ID = issue.Key
 	issue.Fields.Status = &jira.Status{
@@ -118,7 +190,42 @@ func (f *fakeJira) Create(issue *jira.Issue) (*jira.Issue, *jira.Response, error
 	return issue, nil, nil
 }
 
-func (f *fakeJira) UpdateWithOptions(old *jira.Issue, _ *jira.UpdateQueryOptions) (*jira.Issue, *jira.Response, error) {
+func (f *fakeJira) BulkCreateWithContext(ctx context.Context, issues []*jira.Issue) ([]*jira.Issue, []error, *jira.Response, error) {
+	created := make([]*jira.Issue, 0, len(issues))
+	for _, issue := range issues {
+		issue, _, _ := f.CreateWithContext(ctx, issue)
+		created = append(created, issue)
+	}
+	return created, make([]error, len(issues)), nil, nil
+}
+
+// Service desk ID = project key for simplification.
+func (f *fakeJira) CreateRequestWithContext(_ context.Context, request *jira.Request) (*jira.Request, *jira.Response, error) {
+	issue := &jira.Issue{
+		Key: fmt.Sprintf("%d", len(f.issuesByKey)+1),
+		Fields: &jira.IssueFields{
+			Project: jira.Project{Key: request.ServiceDeskID},
+			Status: &jira.Status{
+				StatusCategory: jira.StatusCategory{Key: "NotDone"},
+			},
+		},
+	}
+	issue.ID = issue.Key
+	for _, v := range request.FieldValues {
+		switch v.FieldID {
+		case "summary":
+			issue.Fields.Summary = v.Value
+		case "description":
+			issue.Fields.Description = v.Value
+		}
+	}
+	f.issuesByKey[issue.Key] = issue
+
+	request.IssueKey, request.IssueID = issue.Key, issue.ID
+	return request, nil, nil
+}
+
+func (f *fakeJira) UpdateWithOptionsWithContext(_ context.Context, old *jira.Issue, _ *jira.UpdateQueryOptions) (*jira.Issue, *jira.Response, error) {
 	issue, ok := f.issuesByKey[old.Key]
 	if !ok {
 		return nil, nil, errors.Errorf("no such issue %s", old.Key)
@@ -132,17 +239,74 @@ func (f *fakeJira) UpdateWithOptions(old *jira.Issue, _ *jira.UpdateQueryOptions
 		issue.Fields.Description = old.Fields.Description
 	}
 
+	if old.Fields.Environment != "" {
+		issue.Fields.Environment = old.Fields.Environment
+	}
+
+	if old.Fields.Priority != nil {
+		issue.Fields.Priority = old.Fields.Priority
+	}
+
+	if len(old.Fields.Labels) > 0 {
+		issue.Fields.Labels = old.Fields.Labels
+		// Assuming single label.
+		query := fmt.Sprintf(
+			"project in('%s') and labels=%q order by resolutiondate desc",
+			issue.Fields.Project.Key,
+			issue.Fields.Labels[0],
+		)
+		f.keysByQuery[query] = append(f.keysByQuery[query], issue.Key)
+	}
+
+	if old.Fields.Unknowns != nil {
+		issue.Fields.Unknowns = old.Fields.Unknowns
+	}
+
 	f.issuesByKey[issue.Key] = issue
 	return issue, nil, nil
 }
 
-func (f *fakeJira) AddComment(issueID string, comment *jira.Comment) (*jira.Comment, *jira.Response, error) {
+func (f *fakeJira) UpdateIssueWithContext(_ context.Context, jiraID string, data map[string]interface{}) (*jira.Response, error) {
+	issue, ok := f.issuesByKey[jiraID]
+	if !ok {
+		return nil, errors.Errorf("no such issue %s", jiraID)
+	}
+
+	// Only setting custom fields and label add/remove operations are supported.
+	if fields, ok := data["fields"].(map[string]interface{}); ok {
+		for k, v := range fields {
+			issue.Fields.Unknowns[k] = v
+		}
+		return nil, nil
+	}
+	ops := data["update"].(map[string]interface{})["labels"].([]map[string]string)
+	for _, op := range ops {
+		if l, ok := op["add"]; ok {
+			issue.Fields.Labels = append(issue.Fields.Labels, l)
+		}
+		if l, ok := op["remove"]; ok {
+			var labels []string
+			for _, existing := range issue.Fields.Labels {
+				if existing != l {
+					labels = append(labels, existing)
+				}
+			}
+			issue.Fields.Labels = labels
+		}
+	}
+	return nil, nil
+}
+
+func (f *fakeJira) AddCommentWithContext(_ context.Context, issueID string, comment *jira.Comment) (*jira.Comment, *jira.Response, error) {
+	if f.issuesByKey[issueID].Fields.Comments == nil {
+		f.issuesByKey[issueID].Fields.Comments = &jira.Comments{}
+	}
 	f.issuesByKey[issueID].Fields.Comments.Comments = append(f.issuesByKey[issueID].Fields.Comments.Comments, comment)
 
 	return comment, nil, nil
 }
 
-func (f *fakeJira) DoTransition(ticketID, transitionID string) (*jira.Response, error) {
+func (f *fakeJira) DoTransitionWithContext(_ context.Context, ticketID, transitionID string) (*jira.Response, error) {
 	issue, ok := f.issuesByKey[ticketID]
 	if !ok {
 		return nil, errors.Errorf("no such issue %s", ticketID)
@@ -150,7 +314,7 @@ func (f *fakeJira) DoTransition(ticketID, transitionID string) (*jira.Response,
 
 	tr, ok := f.transitionsByID[transitionID]
 	if !ok {
-		return nil, errors.Errorf("no such transition %s", tr.ID)
+		return fakeResponse(http.StatusBadRequest), errors.Errorf("no such transition %s", transitionID)
 	}
 
 	issue.Fields.Status.StatusCategory.Key = tr.Name
@@ -160,6 +324,70 @@ func (f *fakeJira) DoTransition(ticketID, transitionID string) (*jira.Response,
 	return nil, nil
 }
 
+func (f *fakeJira) AddLinkWithContext(_ context.Context, issueLink *jira.IssueLink) (*jira.Response, error) {
+	f.links = append(f.links, *issueLink)
+	return nil, nil
+}
+
+func (f *fakeJira) AddWorklogRecordWithContext(_ context.Context, issueID string, record *jira.WorklogRecord, _ ...func(*http.Request) error) (*jira.WorklogRecord, *jira.Response, error) {
+	f.worklogs = append(f.worklogs, record)
+	return record, nil, nil
+}
+
+func (f *fakeJira) FindUsersWithContext(_ context.Context, query string) ([]jira.User, *jira.Response, error) {
+	f.userSearches++
+	return f.users[query], nil, nil
+}
+
+func (f *fakeJira) AddWatcherWithContext(_ context.Context, issueID string, userName string) (*jira.Response, error) {
+	if f.watchers == nil {
+		f.watchers = map[string][]string{}
+	}
+	f.watchers[issueID] = append(f.watchers[issueID], userName)
+	return nil, nil
+}
+
+func (f *fakeJira) GetRemoteLinksWithContext(_ context.Context, id string) (*[]jira.RemoteLink, *jira.Response, error) {
+	f.remoteLinkGets++
+	links := append([]jira.RemoteLink{}, f.remoteLinks[id]...)
+	return &links, nil, nil
+}
+
+// AddRemoteLinkWithContext updates the remote link of the same global ID, if any, like Jira.
+func (f *fakeJira) AddRemoteLinkWithContext(_ context.Context, issueID string, remotelink *jira.RemoteLink) (*jira.RemoteLink, *jira.Response, error) {
+	if f.remoteLinks == nil {
+		f.remoteLinks = map[string][]jira.RemoteLink{}
+	}
+	f.remoteLinkAdds++
+	for i, l := range f.remoteLinks[issueID] {
+		if remotelink.GlobalID != "" && l.GlobalID == remotelink.GlobalID {
+			f.remoteLinks[issueID][i] = *remotelink
+			return remotelink, nil, nil
+		}
+	}
+	f.remoteLinks[issueID] = append(f.remoteLinks[issueID], *remotelink)
+	return remotelink, nil, nil
+}
+
+func (f *fakeJira) PostAttachmentWithContext(_ context.Context, issueID string, r io.Reader, attachmentName string) (*[]jira.Attachment, *jira.Response, error) {
+	content, err := io.ReadAll(r)
+	if err != nil {
+		return nil, nil, err
+	}
+	f.attachments = append(f.attachments, fakeAttachment{issueKey: issueID, filename: attachmentName, content: content})
+	return &[]jira.Attachment{{Filename: attachmentName, Size: len(content)}}, nil, nil
+}
+
+// fakeResponse returns an error response with the given status code.
+func fakeResponse(code int) *jira.Response {
+	return &jira.Response{Response: &http.Response{
+		StatusCode: code,
+		Status:     http.StatusText(code),
+		Body:       io.NopCloser(strings.NewReader("")),
+		Request:    &http.Request{URL: &url.URL{}},
+	}}
+}
+
 func testReceiverConfig1() *config.ReceiverConfig {
 	reopen := config.Duration(1 * time.Hour)
 	return &config.ReceiverConfig{
@@ -222,6 +450,102 @@ func testReceiverConfigWithStaticLabels() *config.ReceiverConfig {
 	}
 }
 
+func testReceiverConfigWithGroupLabels() *config.ReceiverConfig {
+	reopen := config.Duration(1 * time.Hour)
+	addGroupLabels := true
+	include, _ := config.NewRegexp("a|c|team")
+	exclude, _ := config.NewRegexp("c")
+	return &config.ReceiverConfig{
+		Project:           "abc",
+		Summary:           `[{{ .Status | toUpper }}{{ if eq .Status "firing" }}:{{ .Alerts.Firing | len }}{{ end }}] {{ .GroupLabels.SortedPairs.Values | join " " }} {{ if gt (len .CommonLabels) (len .GroupLabels) }}({{ with .CommonLabels.Remove .GroupLabels.Names }}{{ .Values | join " " }}{{ end }}){{ end }}`,
+		ReopenDuration:    &reopen,
+		ReopenState:       "reopened",
+		WontFixResolution: "won't-fix",
+		AddGroupLabels:    &addGroupLabels,
+		LabelInclude:      []config.Regexp{include},
+		LabelExclude:      []config.Regexp{exclude},
+		LabelFormat:       `{{ .Name }}:{{ .Value }}`,
+	}
+}
+
+func testReceiverConfigCommentOnTransitionFailure() *config.ReceiverConfig {
+	reopen := config.Duration(1 * time.Hour)
+	commentOnTransitionFailure := true
+	return &config.ReceiverConfig{
+		Project:                    "abc",
+		Summary:                    `[{{ .Status | toUpper }}{{ if eq .Status "firing" }}:{{ .Alerts.Firing | len }}{{ end }}] {{ .GroupLabels.SortedPairs.Values | join " " }} {{ if gt (len .CommonLabels) (len .GroupLabels) }}({{ with .CommonLabels.Remove .GroupLabels.Names }}{{ .Values | join " " }}{{ end }}){{ end }}`,
+		ReopenDuration:             &reopen,
+		ReopenState:                "reopened",
+		WontFixResolution:          "won't-fix",
+		CommentOnTransitionFailure: &commentOnTransitionFailure,
+	}
+}
+
+func testReceiverConfigSyncGroupLabels() *config.ReceiverConfig {
+	reopen := config.Duration(1 * time.Hour)
+	enabled := true
+	return &config.ReceiverConfig{
+		Project:           "abc",
+		Summary:           `[{{ .Status | toUpper }}{{ if eq .Status "firing" }}:{{ .Alerts.Firing | len }}{{ end }}] {{ .GroupLabels.SortedPairs.Values | join " " }} {{ if gt (len .CommonLabels) (len .GroupLabels) }}({{ with .CommonLabels.Remove .GroupLabels.Names }}{{ .Values | join " " }}{{ end }}){{ end }}`,
+		ReopenDuration:    &reopen,
+		ReopenState:       "reopened",
+		WontFixResolution: "won't-fix",
+		AddGroupLabels:    &enabled,
+		SyncGroupLabels:   &enabled,
+	}
+}
+
+func testReceiverConfigSyncValueOnlyGroupLabels() *config.ReceiverConfig {
+	c := testReceiverConfigSyncGroupLabels()
+	c.LabelFormat = "{{ .Value }}"
+	c.StaticLabels = []string{"alerting"}
+	return c
+}
+
+func testReceiverConfigWithEnvironment() *config.ReceiverConfig {
+	reopen := config.Duration(1 * time.Hour)
+	updateEnvironment := true
+	return &config.ReceiverConfig{
+		Project:           "abc",
+		Summary:           `[{{ .Status | toUpper }}{{ if eq .Status "firing" }}:{{ .Alerts.Firing | len }}{{ end }}] {{ .GroupLabels.SortedPairs.Values | join " " }} {{ if gt (len .CommonLabels) (len .GroupLabels) }}({{ with .CommonLabels.Remove .GroupLabels.Names }}{{ .Values | join " " }}{{ end }}){{ end }}`,
+		ReopenDuration:    &reopen,
+		ReopenState:       "reopened",
+		WontFixResolution: "won't-fix",
+		Environment:       `{{ .CommonLabels.cluster }}/{{ .CommonLabels.namespace }}`,
+		UpdateEnvironment: &updateEnvironment,
+	}
+}
+
+func testReceiverConfigWithServiceDesk() *config.ReceiverConfig {
+	reopen := config.Duration(1 * time.Hour)
+	return &config.ReceiverConfig{
+		Project:           "abc",
+		Summary:           `[{{ .Status | toUpper }}{{ if eq .Status "firing" }}:{{ .Alerts.Firing | len }}{{ end }}] {{ .GroupLabels.SortedPairs.Values | join " " }} {{ if gt (len .CommonLabels) (len .GroupLabels) }}({{ with .CommonLabels.Remove .GroupLabels.Names }}{{ .Values | join " " }}{{ end }}){{ end }}`,
+		Description:       `{{ .CommonLabels.cluster }} is on fire`,
+		ReopenDuration:    &reopen,
+		ReopenState:       "reopened",
+		WontFixResolution: "won't-fix",
+		ServiceDesk: &config.ServiceDeskConfig{
+			ServiceDeskID:      "abc",
+			RequestTypeID:      "10",
+			RequestFieldValues: map[string]string{"customfield_10001": `{{ .CommonLabels.cluster }}`},
+		},
+	}
+}
+
+func testReceiverConfigWithMarkdown() *config.ReceiverConfig {
+	reopen := config.Duration(1 * time.Hour)
+	return &config.ReceiverConfig{
+		Project:           "abc",
+		Summary:           `[{{ .Status | toUpper }}{{ if eq .Status "firing" }}:{{ .Alerts.Firing | len }}{{ end }}] {{ .GroupLabels.SortedPairs.Values | join " " }} {{ if gt (len .CommonLabels) (len .GroupLabels) }}({{ with .CommonLabels.Remove .GroupLabels.Names }}{{ .Values | join " " }}{{ end }}){{ end }}`,
+		Description:       "**{{ .CommonLabels.cluster }}** is down, see [runbook](https://runbooks/{{ .CommonLabels.cluster }})",
+		Renderer:          config.RendererMarkdown,
+		ReopenDuration:    &reopen,
+		ReopenState:       "reopened",
+		WontFixResolution: "won't-fix",
+	}
+}
+
 func TestNotify_JIRAInteraction(t *testing.T) {
 	testNowTime := time.Now()
 
@@ -266,7 +590,7 @@ func TestNotify_JIRAInteraction(t *testing.T) {
 			inputConfig: testReceiverConfig1(),
 			initJira: func(t *testing.T) *fakeJira {
 				f := newTestFakeJira()
-				_, _, err := f.Create(&jira.Issue{
+				_, _, err := f.CreateWithContext(context.Background(), &jira.Issue{
 					ID:  "1",
 					Key: "1",
 					Fields: &jira.IssueFields{
@@ -308,7 +632,7 @@ func TestNotify_JIRAInteraction(t *testing.T) {
 			inputConfig: testReceiverConfig2(),
 			initJira: func(t *testing.T) *fakeJira {
 				f := newTestFakeJira()
-				_, _, err := f.Create(&jira.Issue{
+				_, _, err := f.CreateWithContext(context.Background(), &jira.Issue{
 					ID:  "1",
 					Key: "1",
 					Fields: &jira.IssueFields{
@@ -352,7 +676,7 @@ func TestNotify_JIRAInteraction(t *testing.T) {
 			inputConfig: testReceiverConfig1(),
 			initJira: func(t *testing.T) *fakeJira {
 				f := newTestFakeJira()
-				_, _, err := f.Create(&jira.Issue{
+				_, _, err := f.CreateWithContext(context.Background(), &jira.Issue{
 					ID:  "1",
 					Key: "1",
 					Fields: &jira.IssueFields{
@@ -402,12 +726,64 @@ func TestNotify_JIRAInteraction(t *testing.T) {
 				},
 			},
 		},
+		{
+			name:        "closed ticket, no reopen transition, comment instead",
+			inputConfig: testReceiverConfigCommentOnTransitionFailure(),
+			initJira: func(t *testing.T) *fakeJira {
+				f := newTestFakeJira()
+				_, _, err := f.CreateWithContext(context.Background(), &jira.Issue{
+					ID:  "1",
+					Key: "1",
+					Fields: &jira.IssueFields{
+						Project:  jira.Project{Key: testReceiverConfig1().Project},
+						Labels:   []string{"JIRALERT{819ba5ecba4ea5946a8d17d285cb23f3bb6862e08bb602ab08fd231cd8e1a83a1d095b0208a661787e9035f0541817634df5a994d1b5d4200d6c68a7663c97f5}"},
+						Unknowns: tcontainer.MarshalMap{},
+						Summary:  "[FIRING:1] b d ",
+						Comments: &jira.Comments{Comments: []*jira.Comment{}},
+					},
+				})
+				// Close it, without a transition to the reopen state.
+				f.issuesByKey["1"].Fields.Status.Name = "Archived"
+				f.issuesByKey["1"].Fields.Status.StatusCategory.Key = "done"
+				f.issuesByKey["1"].Fields.Resolutiondate = jira.Time(testNowTime.Add(-30 * time.Minute))
+
+				require.NoError(t, err)
+				return f
+			},
+			inputAlert: &alertmanager.Data{
+				Alerts: alertmanager.Alerts{
+					{Status: alertmanager.AlertFiring},
+				},
+				Status:      alertmanager.AlertFiring,
+				GroupLabels: alertmanager.KV{"a": "b", "c": "d"},
+			},
+			expectedJiraIssues: map[string]*jira.Issue{
+				"1": {
+					ID:  "1",
+					Key: "1",
+					Fields: &jira.IssueFields{
+						Project: jira.Project{Key: testReceiverConfig1().Project},
+						Labels:  []string{"JIRALERT{819ba5ecba4ea5946a8d17d285cb23f3bb6862e08bb602ab08fd231cd8e1a83a1d095b0208a661787e9035f0541817634df5a994d1b5d4200d6c68a7663c97f5}"},
+						Status: &jira.Status{
+							Name:           "Archived",
+							StatusCategory: jira.StatusCategory{Key: "done"},
+						},
+						Unknowns: tcontainer.MarshalMap{},
+						Summary:  "[FIRING:1] b d ",
+						Comments: &jira.Comments{Comments: []*jira.Comment{
+							{Body: `Alert re-fired but automatic reopen failed: state "Archived" has no transition to "reopened".`},
+						}},
+						Resolutiondate: jira.Time(testNowTime.Add(-30 * time.Minute)),
+					},
+				},
+			},
+		},
 		{
 			name:        "closed won't fix ticket, update summary",
 			inputConfig: testReceiverConfig1(),
 			initJira: func(t *testing.T) *fakeJira {
 				f := newTestFakeJira()
-				_, _, err := f.Create(&jira.Issue{
+				_, _, err := f.CreateWithContext(context.Background(), &jira.Issue{
 					ID:  "1",
 					Key: "1",
 					Fields: &jira.IssueFields{
@@ -462,7 +838,7 @@ func TestNotify_JIRAInteraction(t *testing.T) {
 			inputConfig: testReceiverConfig1(),
 			initJira: func(t *testing.T) *fakeJira {
 				f := newTestFakeJira()
-				_, _, err := f.Create(&jira.Issue{
+				_, _, err := f.CreateWithContext(context.Background(), &jira.Issue{
 					ID:  "1",
 					Key: "1",
 					Fields: &jira.IssueFields{
@@ -539,7 +915,7 @@ func TestNotify_JIRAInteraction(t *testing.T) {
 			},
 			initJira: func(t *testing.T) *fakeJira {
 				f := newTestFakeJira()
-				_, _, err := f.Create(&jira.Issue{
+				_, _, err := f.CreateWithContext(context.Background(), &jira.Issue{
 					ID:  "1",
 					Key: "1",
 					Fields: &jira.IssueFields{
@@ -599,12 +975,224 @@ func TestNotify_JIRAInteraction(t *testing.T) {
 				},
 			},
 		},
+		{
+			name:        "empty jira, new alert group with filtered and formatted group labels",
+			inputConfig: testReceiverConfigWithGroupLabels(),
+			initJira:    func(t *testing.T) *fakeJira { return newTestFakeJira() },
+			inputAlert: &alertmanager.Data{
+				Alerts: alertmanager.Alerts{
+					{Status: alertmanager.AlertFiring},
+				},
+				Status:      alertmanager.AlertFiring,
+				GroupLabels: alertmanager.KV{"a": "b", "c": "d", "team": "sre ops"},
+			},
+			expectedJiraIssues: map[string]*jira.Issue{
+				"1": {
+					ID:  "1",
+					Key: "1",
+					Fields: &jira.IssueFields{
+						Project: jira.Project{Key: testReceiverConfig1().Project},
+						Labels:  []string{"JIRALERT{a2dd2593dcb4f2b5868a64cf177ca319384b3c8854ffff721a756efc9095935ea3002bfb12efc5d81a25bd1487d5d4e690c4631144725adacdf18695d0016e59}", "a:b", "team:sre_ops"},
+						Status: &jira.Status{
+							StatusCategory: jira.StatusCategory{Key: "NotDone"},
+						},
+						Unknowns: tcontainer.MarshalMap{},
+						Summary:  "[FIRING:1] b d sre ops ",
+					},
+				},
+			},
+		},
+		{
+			name:        "opened ticket, sync group labels",
+			inputConfig: testReceiverConfigSyncGroupLabels(),
+			initJira: func(t *testing.T) *fakeJira {
+				f := newTestFakeJira()
+				_, _, err := f.CreateWithContext(context.Background(), &jira.Issue{
+					ID:  "1",
+					Key: "1",
+					Fields: &jira.IssueFields{
+						Project:  jira.Project{Key: testReceiverConfig1().Project},
+						Labels:   []string{"JIRALERT{819ba5ecba4ea5946a8d17d285cb23f3bb6862e08bb602ab08fd231cd8e1a83a1d095b0208a661787e9035f0541817634df5a994d1b5d4200d6c68a7663c97f5}", `a="b"`, `c="x"`, "manual"},
+						Unknowns: tcontainer.MarshalMap{},
+						Summary:  "[FIRING:1] b d ",
+					},
+				})
+				require.NoError(t, err)
+				return f
+			},
+			inputAlert: &alertmanager.Data{
+				Alerts: alertmanager.Alerts{
+					{Status: alertmanager.AlertFiring},
+				},
+				Status:      alertmanager.AlertFiring,
+				GroupLabels: alertmanager.KV{"a": "b", "c": "d"},
+			},
+			expectedJiraIssues: map[string]*jira.Issue{
+				"1": {
+					ID:  "1",
+					Key: "1",
+					Fields: &jira.IssueFields{
+						Project: jira.Project{Key: testReceiverConfig1().Project},
+						Labels:  []string{"JIRALERT{819ba5ecba4ea5946a8d17d285cb23f3bb6862e08bb602ab08fd231cd8e1a83a1d095b0208a661787e9035f0541817634df5a994d1b5d4200d6c68a7663c97f5}", `a="b"`, "manual", `c="d"`},
+						Status: &jira.Status{
+							StatusCategory: jira.StatusCategory{Key: "NotDone"},
+						},
+						Unknowns: tcontainer.MarshalMap{},
+						Summary:  "[FIRING:1] b d ",
+					},
+				},
+			},
+		},
+		{
+			name:        "opened ticket, sync value-only group labels",
+			inputConfig: testReceiverConfigSyncValueOnlyGroupLabels(),
+			initJira: func(t *testing.T) *fakeJira {
+				f := newTestFakeJira()
+				_, _, err := f.CreateWithContext(context.Background(), &jira.Issue{
+					ID:  "1",
+					Key: "1",
+					Fields: &jira.IssueFields{
+						Project:  jira.Project{Key: testReceiverConfig1().Project},
+						Labels:   []string{"JIRALERT{819ba5ecba4ea5946a8d17d285cb23f3bb6862e08bb602ab08fd231cd8e1a83a1d095b0208a661787e9035f0541817634df5a994d1b5d4200d6c68a7663c97f5}", "alerting", "b", "x", "manual"},
+						Unknowns: tcontainer.MarshalMap{},
+						Summary:  "[FIRING:1] b d ",
+					},
+				})
+				require.NoError(t, err)
+				return f
+			},
+			inputAlert: &alertmanager.Data{
+				Alerts: alertmanager.Alerts{
+					{Status: alertmanager.AlertFiring},
+				},
+				Status:      alertmanager.AlertFiring,
+				GroupLabels: alertmanager.KV{"a": "b", "c": "d"},
+			},
+			expectedJiraIssues: map[string]*jira.Issue{
+				"1": {
+					ID:  "1",
+					Key: "1",
+					Fields: &jira.IssueFields{
+						Project: jira.Project{Key: testReceiverConfig1().Project},
+						// Stale values cannot be told apart from other labels, so only missing ones are added.
+						Labels: []string{"JIRALERT{819ba5ecba4ea5946a8d17d285cb23f3bb6862e08bb602ab08fd231cd8e1a83a1d095b0208a661787e9035f0541817634df5a994d1b5d4200d6c68a7663c97f5}", "alerting", "b", "x", "manual", "d"},
+						Status: &jira.Status{
+							StatusCategory: jira.StatusCategory{Key: "NotDone"},
+						},
+						Unknowns: tcontainer.MarshalMap{},
+						Summary:  "[FIRING:1] b d ",
+					},
+				},
+			},
+		},
+		{
+			name:        "empty jira, new alert group, service desk request",
+			inputConfig: testReceiverConfigWithServiceDesk(),
+			initJira:    func(t *testing.T) *fakeJira { return newTestFakeJira() },
+			inputAlert: &alertmanager.Data{
+				Alerts: alertmanager.Alerts{
+					{Status: alertmanager.AlertFiring},
+				},
+				Status:       alertmanager.AlertFiring,
+				GroupLabels:  alertmanager.KV{"a": "b", "c": "d"},
+				CommonLabels: alertmanager.KV{"a": "b", "c": "d", "cluster": "eu1"},
+			},
+			expectedJiraIssues: map[string]*jira.Issue{
+				"1": {
+					ID:  "1",
+					Key: "1",
+					Fields: &jira.IssueFields{
+						Project: jira.Project{Key: testReceiverConfigWithServiceDesk().Project},
+						Labels:  []string{"JIRALERT{819ba5ecba4ea5946a8d17d285cb23f3bb6862e08bb602ab08fd231cd8e1a83a1d095b0208a661787e9035f0541817634df5a994d1b5d4200d6c68a7663c97f5}"},
+						Status: &jira.Status{
+							StatusCategory: jira.StatusCategory{Key: "NotDone"},
+						},
+						Unknowns:    tcontainer.MarshalMap{},
+						Summary:     "[FIRING:1] b d (eu1)",
+						Description: "eu1 is on fire",
+					},
+				},
+			},
+		},
+		{
+			name:        "empty jira, new alert group, markdown description",
+			inputConfig: testReceiverConfigWithMarkdown(),
+			initJira:    func(t *testing.T) *fakeJira { return newTestFakeJira() },
+			inputAlert: &alertmanager.Data{
+				Alerts: alertmanager.Alerts{
+					{Status: alertmanager.AlertFiring},
+				},
+				Status:       alertmanager.AlertFiring,
+				GroupLabels:  alertmanager.KV{"a": "b", "c": "d"},
+				CommonLabels: alertmanager.KV{"a": "b", "c": "d", "cluster": "eu1"},
+			},
+			expectedJiraIssues: map[string]*jira.Issue{
+				"1": {
+					ID:  "1",
+					Key: "1",
+					Fields: &jira.IssueFields{
+						Project: jira.Project{Key: testReceiverConfigWithMarkdown().Project},
+						Labels:  []string{"JIRALERT{819ba5ecba4ea5946a8d17d285cb23f3bb6862e08bb602ab08fd231cd8e1a83a1d095b0208a661787e9035f0541817634df5a994d1b5d4200d6c68a7663c97f5}"},
+						Status: &jira.Status{
+							StatusCategory: jira.StatusCategory{Key: "NotDone"},
+						},
+						Unknowns:    tcontainer.MarshalMap{},
+						Summary:     "[FIRING:1] b d (eu1)",
+						Description: "*eu1* is down, see [runbook|https://runbooks/eu1]",
+					},
+				},
+			},
+		},
+		{
+			name:        "opened ticket, update environment",
+			inputConfig: testReceiverConfigWithEnvironment(),
+			initJira: func(t *testing.T) *fakeJira {
+				f := newTestFakeJira()
+				_, _, err := f.CreateWithContext(context.Background(), &jira.Issue{
+					ID:  "1",
+					Key: "1",
+					Fields: &jira.IssueFields{
+						Project:     jira.Project{Key: testReceiverConfig1().Project},
+						Labels:      []string{"JIRALERT{819ba5ecba4ea5946a8d17d285cb23f3bb6862e08bb602ab08fd231cd8e1a83a1d095b0208a661787e9035f0541817634df5a994d1b5d4200d6c68a7663c97f5}"},
+						Unknowns:    tcontainer.MarshalMap{},
+						Summary:     "[FIRING:1] b d (eu1 monitoring)",
+						Environment: "eu1/default",
+					},
+				})
+				require.NoError(t, err)
+				return f
+			},
+			inputAlert: &alertmanager.Data{
+				Alerts: alertmanager.Alerts{
+					{Status: alertmanager.AlertFiring},
+				},
+				Status:       alertmanager.AlertFiring,
+				GroupLabels:  alertmanager.KV{"a": "b", "c": "d"},
+				CommonLabels: alertmanager.KV{"a": "b", "c": "d", "cluster": "eu1", "namespace": "monitoring"},
+			},
+			expectedJiraIssues: map[string]*jira.Issue{
+				"1": {
+					ID:  "1",
+					Key: "1",
+					Fields: &jira.IssueFields{
+						Project: jira.Project{Key: testReceiverConfig1().Project},
+						Labels:  []string{"JIRALERT{819ba5ecba4ea5946a8d17d285cb23f3bb6862e08bb602ab08fd231cd8e1a83a1d095b0208a661787e9035f0541817634df5a994d1b5d4200d6c68a7663c97f5}"},
+						Status: &jira.Status{
+							StatusCategory: jira.StatusCategory{Key: "NotDone"},
+						},
+						Unknowns:    tcontainer.MarshalMap{},
+						Summary:     "[FIRING:1] b d (eu1 monitoring)",
+						Environment: "eu1/monitoring",
+					},
+				},
+			},
+		},
 		{
 			name:        "existing ticket, new instance firing, add comment",
 			inputConfig: testReceiverConfigAddComments(),
 			initJira: func(t *testing.T) *fakeJira {
 				f := newTestFakeJira()
-				_, _, err := f.Create(&jira.Issue{
+				_, _, err := f.CreateWithContext(context.Background(), &jira.Issue{
 					ID:  "1",
 					Key: "1",
 					Fields: &jira.IssueFields{
@@ -648,7 +1236,7 @@ func TestNotify_JIRAInteraction(t *testing.T) {
 			inputConfig: testReceiverConfigAddComments(),
 			initJira: func(t *testing.T) *fakeJira {
 				f := newTestFakeJira()
-				_, _, err := f.Create(&jira.Issue{
+				_, _, err := f.CreateWithContext(context.Background(), &jira.Issue{
 					ID:  "1",
 					Key: "1",
 					Fields: &jira.IssueFields{
@@ -701,7 +1289,13 @@ func TestNotify_JIRAInteraction(t *testing.T) {
 				return testNowTime
 			}
 
-			_, err := receiver.Notify(tcase.inputAlert, true, true, true, true, 32768)
+			_, err := receiver.Notify(context.Background(), tcase.inputAlert, Options{
+				HashJiraLabel:        true,
+				UpdateSummary:        true,
+				UpdateDescription:    true,
+				ReopenTickets:        true,
+				MaxDescriptionLength: 32768,
+			})
 			require.NoError(t, err)
 			require.Equal(t, tcase.expectedJiraIssues, fakeJira.issuesByKey)
 		}); !ok {
@@ -709,3 +1303,683 @@ func TestNotify_JIRAInteraction(t *testing.T) {
 		}
 	}
 }
+
+func TestNotifyCanceled(t *testing.T) {
+	receiver := NewReceiver(log.NewNopLogger(), testReceiverConfig1(), template.SimpleTemplate(), newTestFakeJira())
+	data := &alertmanager.Data{
+		Alerts:      alertmanager.Alerts{{Status: alertmanager.AlertFiring}},
+		Status:      alertmanager.AlertFiring,
+		GroupLabels: alertmanager.KV{"a": "b"},
+	}
+
+	// Notifications running past their deadline are retried.
+	ctx, cancel := context.WithTimeout(context.Background(), 0)
+	defer cancel()
+	retry, err := receiver.Notify(ctx, data, Options{MaxDescriptionLength: 32768})
+	require.Error(t, err)
+	require.True(t, retry)
+
+	ctx, cancel = context.WithCancel(context.Background())
+	cancel()
+	retry, err = receiver.Notify(ctx, data, Options{MaxDescriptionLength: 32768})
+	require.ErrorIs(t, err, context.Canceled)
+	require.False(t, retry)
+}
+
+func TestNotifySetMissingPriority(t *testing.T) {
+	fakeJira := newTestFakeJira()
+	data := &alertmanager.Data{
+		Alerts:       alertmanager.Alerts{{Status: alertmanager.AlertFiring}},
+		Status:       alertmanager.AlertFiring,
+		GroupLabels:  alertmanager.KV{"a": "b"},
+		CommonLabels: alertmanager.KV{"severity": "critical"},
+	}
+	opts := Options{MaxDescriptionLength: 32768}
+
+	// Create an issue without priority.
+	conf := testReceiverConfig1()
+	_, err := NewReceiver(log.NewNopLogger(), conf, template.SimpleTemplate(), fakeJira).Notify(context.Background(), data, opts)
+	require.NoError(t, err)
+	require.Len(t, fakeJira.issuesByKey, 1)
+	require.Nil(t, fakeJira.issuesByKey["1"].Fields.Priority)
+
+	// Existing issues are left alone by default.
+	conf.Priority = `{{ if eq .CommonLabels.severity "critical" }}High{{ end }}`
+	_, err = NewReceiver(log.NewNopLogger(), conf, template.SimpleTemplate(), fakeJira).Notify(context.Background(), data, opts)
+	require.NoError(t, err)
+	require.Nil(t, fakeJira.issuesByKey["1"].Fields.Priority)
+
+	// Empty priorities are not set.
+	enabled := true
+	conf.SetMissingPriority = &enabled
+	data.CommonLabels["severity"] = "warning"
+	_, err = NewReceiver(log.NewNopLogger(), conf, template.SimpleTemplate(), fakeJira).Notify(context.Background(), data, opts)
+	require.NoError(t, err)
+	require.Nil(t, fakeJira.issuesByKey["1"].Fields.Priority)
+
+	data.CommonLabels["severity"] = "critical"
+	_, err = NewReceiver(log.NewNopLogger(), conf, template.SimpleTemplate(), fakeJira).Notify(context.Background(), data, opts)
+	require.NoError(t, err)
+	require.Len(t, fakeJira.issuesByKey, 1)
+	require.Equal(t, &jira.Priority{Name: "High"}, fakeJira.issuesByKey["1"].Fields.Priority)
+
+	// Priorities set in Jira are kept.
+	fakeJira.issuesByKey["1"].Fields.Priority = &jira.Priority{Name: "Low"}
+	_, err = NewReceiver(log.NewNopLogger(), conf, template.SimpleTemplate(), fakeJira).Notify(context.Background(), data, opts)
+	require.NoError(t, err)
+	require.Equal(t, &jira.Priority{Name: "Low"}, fakeJira.issuesByKey["1"].Fields.Priority)
+}
+
+func TestNotifyPresets(t *testing.T) {
+	fakeJira := newTestFakeJira()
+	matcher, err := config.ParseMatcher(`severity="critical"`)
+	require.NoError(t, err)
+	conf := testReceiverConfig1()
+	conf.IssueType = "Task"
+	conf.StaticLabels = []string{"jiralert"}
+	conf.Fields = map[string]interface{}{"customfield_1": "a", "customfield_2": "b"}
+	conf.Presets = []*config.PresetConfig{{
+		Matchers:     []config.Matcher{matcher},
+		IssueType:    "Bug",
+		Priority:     "High",
+		StaticLabels: []string{"paging"},
+		Fields:       map[string]interface{}{"customfield_2": "{{ .CommonLabels.severity }}"},
+	}}
+	opts := Options{MaxDescriptionLength: 32768}
+
+	for _, severity := range []string{"warning", "critical"} {
+		data := &alertmanager.Data{
+			Alerts:       alertmanager.Alerts{{Status: alertmanager.AlertFiring}},
+			Status:       alertmanager.AlertFiring,
+			GroupLabels:  alertmanager.KV{"a": severity},
+			CommonLabels: alertmanager.KV{"a": severity, "severity": severity},
+		}
+		_, err := NewReceiver(log.NewNopLogger(), conf, template.SimpleTemplate(), fakeJira).Notify(context.Background(), data, opts)
+		require.NoError(t, err)
+	}
+	require.Len(t, fakeJira.issuesByKey, 2)
+
+	warning := fakeJira.issuesByKey["1"].Fields
+	require.Equal(t, "Task", warning.Type.Name)
+	require.Nil(t, warning.Priority)
+	require.Equal(t, "jiralert", warning.Labels[0])
+	require.Equal(t, "b", warning.Unknowns["customfield_2"])
+
+	critical := fakeJira.issuesByKey["2"].Fields
+	require.Equal(t, "Bug", critical.Type.Name)
+	require.Equal(t, &jira.Priority{Name: "High"}, critical.Priority)
+	require.Equal(t, "paging", critical.Labels[0])
+	require.Equal(t, "a", critical.Unknowns["customfield_1"])
+	require.Equal(t, "critical", critical.Unknowns["customfield_2"])
+	// The configuration of the receiver is left alone.
+	require.Equal(t, "b", conf.Fields["customfield_2"])
+}
+
+func TestNotifyOtherProjects(t *testing.T) {
+	for _, tc := range []struct {
+		otherProjects []string
+		query         string
+	}{
+		{otherProjects: []string{"OTHER"}, query: "project in('abc', 'OTHER') and "},
+		{otherProjects: []string{config.AllProjects}, query: ""},
+	} {
+		t.Run(strings.Join(tc.otherProjects, ","), func(t *testing.T) {
+			fakeJira := newTestFakeJira()
+			conf := testReceiverConfig1()
+			conf.OtherProjects = tc.otherProjects
+			data := &alertmanager.Data{
+				Status:      alertmanager.AlertFiring,
+				Alerts:      alertmanager.Alerts{{Status: alertmanager.AlertFiring}},
+				GroupLabels: alertmanager.KV{"a": "b"},
+			}
+			opts := Options{MaxDescriptionLength: 32768, UpdateSummary: true}
+			_, err := NewReceiver(log.NewNopLogger(), conf, template.SimpleTemplate(), fakeJira).Notify(context.Background(), data, opts)
+			require.NoError(t, err)
+
+			// Move the issue to the other project, under a new key.
+			issue := fakeJira.issuesByKey["1"]
+			issue.Key, issue.ID, issue.Fields.Project.Key = "OTHER-1", "OTHER-1", "OTHER"
+			fakeJira.issuesByKey = map[string]*jira.Issue{"OTHER-1": issue}
+			moved := map[string][]string{}
+			for query := range fakeJira.keysByQuery {
+				moved[strings.Replace(query, "project in('abc') and ", tc.query, 1)] = []string{"OTHER-1"}
+			}
+			fakeJira.keysByQuery = moved
+
+			data.Alerts = append(data.Alerts, alertmanager.Alert{Status: alertmanager.AlertFiring})
+			_, err = NewReceiver(log.NewNopLogger(), conf, template.SimpleTemplate(), fakeJira).Notify(context.Background(), data, opts)
+			require.NoError(t, err)
+			require.Len(t, fakeJira.issuesByKey, 1)
+			require.Equal(t, "[FIRING:2] b ", fakeJira.issuesByKey["OTHER-1"].Fields.Summary)
+		})
+	}
+}
+
+func TestNotifyIgnored(t *testing.T) {
+	for name, mark := range map[string]func(*jira.Issue){
+		"label":  func(issue *jira.Issue) { issue.Fields.Labels = append(issue.Fields.Labels, "jiralert-ignore") },
+		"status": func(issue *jira.Issue) { issue.Fields.Status.Name = "On Hold" },
+	} {
+		t.Run(name, func(t *testing.T) {
+			fakeJira := newTestFakeJira()
+			conf := testReceiverConfig1()
+			conf.IgnoreLabels = []string{"jiralert-ignore"}
+			conf.IgnoreStatuses = []string{"On Hold"}
+			conf.AutoResolve = &config.AutoResolve{State: "Done"}
+			opts := Options{MaxDescriptionLength: 32768, UpdateSummary: true}
+			notify := func(status string, alerts int) {
+				data := &alertmanager.Data{Status: status, GroupLabels: alertmanager.KV{"a": "b"}}
+				for i := 0; i < alerts; i++ {
+					data.Alerts = append(data.Alerts, alertmanager.Alert{Status: status})
+				}
+				_, err := NewReceiver(log.NewNopLogger(), conf, template.SimpleTemplate(), fakeJira).Notify(context.Background(), data, opts)
+				require.NoError(t, err)
+			}
+
+			notify(alertmanager.AlertFiring, 1)
+			mark(fakeJira.issuesByKey["1"])
+
+			notify(alertmanager.AlertFiring, 2)
+			require.Equal(t, "[FIRING:1] b ", fakeJira.issuesByKey["1"].Fields.Summary)
+			notify(alertmanager.AlertResolved, 1)
+			require.Equal(t, "NotDone", fakeJira.issuesByKey["1"].Fields.Status.StatusCategory.Key)
+			require.Len(t, fakeJira.issuesByKey, 1)
+		})
+	}
+}
+
+func TestNotifyFreezeInProgress(t *testing.T) {
+	fakeJira := newTestFakeJira()
+	conf := testReceiverConfig1()
+	freeze := true
+	conf.FreezeInProgress = &freeze
+	conf.AutoResolve = &config.AutoResolve{State: "Done"}
+	opts := Options{MaxDescriptionLength: 32768, UpdateSummary: true}
+	notify := func(status string, alerts int) {
+		data := &alertmanager.Data{Status: status, GroupLabels: alertmanager.KV{"a": "b"}}
+		for i := 0; i < alerts; i++ {
+			data.Alerts = append(data.Alerts, alertmanager.Alert{Status: status})
+		}
+		_, err := NewReceiver(log.NewNopLogger(), conf, template.SimpleTemplate(), fakeJira).Notify(context.Background(), data, opts)
+		require.NoError(t, err)
+	}
+
+	notify(alertmanager.AlertFiring, 1)
+	notify(alertmanager.AlertFiring, 2)
+	require.Equal(t, "[FIRING:2] b ", fakeJira.issuesByKey["1"].Fields.Summary)
+
+	fakeJira.issuesByKey["1"].Fields.Status.StatusCategory.Key = jira.StatusCategoryInProgress
+	notify(alertmanager.AlertFiring, 3)
+	require.Equal(t, "[FIRING:2] b ", fakeJira.issuesByKey["1"].Fields.Summary)
+
+	// Issues in progress are still resolved.
+	notify(alertmanager.AlertResolved, 1)
+	require.Equal(t, "Done", fakeJira.issuesByKey["1"].Fields.Status.StatusCategory.Key)
+}
+
+func TestNotifySummaryPrefix(t *testing.T) {
+	fakeJira := newTestFakeJira()
+	fakeJira.transitionsByID["5"] = jira.Transition{ID: "5", Name: "reopened"}
+	conf := testReceiverConfig1()
+	conf.Summary = "{{ .GroupLabels.a }}"
+	conf.SummaryFiringPrefix = "[FIRING:{{ len .Alerts.Firing }}] "
+	conf.SummaryResolvedPrefix = "[RESOLVED] "
+	conf.AutoResolve = &config.AutoResolve{State: "Done"}
+	// The prefix still follows reopens and resolves without summary updates.
+	opts := Options{MaxDescriptionLength: 32768, ReopenTickets: true}
+	notify := func(status string, alerts int) {
+		data := &alertmanager.Data{Status: status, GroupLabels: alertmanager.KV{"a": "b"}}
+		for i := 0; i < alerts; i++ {
+			data.Alerts = append(data.Alerts, alertmanager.Alert{Status: status})
+		}
+		_, err := NewReceiver(log.NewNopLogger(), conf, template.SimpleTemplate(), fakeJira).Notify(context.Background(), data, opts)
+		require.NoError(t, err)
+	}
+
+	notify(alertmanager.AlertFiring, 1)
+	require.Equal(t, "[FIRING:1] b", fakeJira.issuesByKey["1"].Fields.Summary)
+	notify(alertmanager.AlertFiring, 2)
+	require.Equal(t, "[FIRING:1] b", fakeJira.issuesByKey["1"].Fields.Summary)
+
+	notify(alertmanager.AlertResolved, 2)
+	require.Equal(t, "[RESOLVED] b", fakeJira.issuesByKey["1"].Fields.Summary)
+
+	fakeJira.issuesByKey["1"].Fields.Status.StatusCategory.Key = "done"
+	notify(alertmanager.AlertFiring, 3)
+	require.Equal(t, "[FIRING:3] b", fakeJira.issuesByKey["1"].Fields.Summary)
+	require.Len(t, fakeJira.issuesByKey, 1)
+
+	// With summary updates, the prefix follows the alert group on every notification.
+	opts.UpdateSummary = true
+	notify(alertmanager.AlertFiring, 4)
+	require.Equal(t, "[FIRING:4] b", fakeJira.issuesByKey["1"].Fields.Summary)
+}
+
+func TestNotifyStatusLabel(t *testing.T) {
+	fakeJira := newTestFakeJira()
+	conf := testReceiverConfig1()
+	enabled := true
+	conf.StatusLabel = &enabled
+	opts := Options{MaxDescriptionLength: 32768}
+	notify := func(status string) []string {
+		data := &alertmanager.Data{
+			Status:      status,
+			Alerts:      alertmanager.Alerts{{Status: status}},
+			GroupLabels: alertmanager.KV{"a": "b"},
+		}
+		_, err := NewReceiver(log.NewNopLogger(), conf, template.SimpleTemplate(), fakeJira).Notify(context.Background(), data, opts)
+		require.NoError(t, err)
+		require.Len(t, fakeJira.issuesByKey, 1)
+		return fakeJira.issuesByKey["1"].Fields.Labels
+	}
+
+	require.Contains(t, notify(alertmanager.AlertFiring), "jiralert:firing")
+	labels := notify(alertmanager.AlertResolved)
+	require.Contains(t, labels, "jiralert:resolved")
+	require.NotContains(t, labels, "jiralert:firing")
+	labels = notify(alertmanager.AlertFiring)
+	require.Contains(t, labels, "jiralert:firing")
+	require.NotContains(t, labels, "jiralert:resolved")
+	require.Equal(t, labels, notify(alertmanager.AlertFiring))
+}
+
+func TestNotifyAutoResolveWorklog(t *testing.T) {
+	fakeJira := newTestFakeJira()
+	conf := testReceiverConfig1()
+	conf.AutoResolve = &config.AutoResolve{State: "Done", Worklog: true}
+	opts := Options{MaxDescriptionLength: 32768}
+	start := time.Date(2023, 5, 1, 10, 0, 0, 0, time.UTC)
+	notify := func(alerts ...alertmanager.Alert) {
+		data := &alertmanager.Data{Status: alerts[0].Status, Alerts: alerts, GroupLabels: alertmanager.KV{"a": "b"}}
+		_, err := NewReceiver(log.NewNopLogger(), conf, template.SimpleTemplate(), fakeJira).Notify(context.Background(), data, opts)
+		require.NoError(t, err)
+	}
+
+	notify(alertmanager.Alert{Status: alertmanager.AlertFiring, StartsAt: start})
+	notify(
+		alertmanager.Alert{Status: alertmanager.AlertResolved, StartsAt: start, EndsAt: start.Add(20 * time.Minute)},
+		alertmanager.Alert{Status: alertmanager.AlertResolved, StartsAt: start.Add(5 * time.Minute), EndsAt: start.Add(90 * time.Minute)},
+	)
+	require.Equal(t, "Done", fakeJira.issuesByKey["1"].Fields.Status.StatusCategory.Key)
+	require.Len(t, fakeJira.worklogs, 1)
+	require.Equal(t, 5400, fakeJira.worklogs[0].TimeSpentSeconds)
+	require.Equal(t, start, time.Time(*fakeJira.worklogs[0].Started))
+	require.Equal(t, "Alert fired for 1h30m0s.", fakeJira.worklogs[0].Comment)
+}
+
+func TestNotifyTruncatedAlerts(t *testing.T) {
+	fakeJira := newTestFakeJira()
+	conf := testReceiverConfig1()
+	enabled := true
+	conf.TruncatedAlertsComment = &enabled
+	conf.Summary = "{{ .TruncatedAlerts }} more alerts"
+	opts := Options{MaxDescriptionLength: 32768}
+	data := &alertmanager.Data{
+		Status:          alertmanager.AlertFiring,
+		Alerts:          alertmanager.Alerts{{Status: alertmanager.AlertFiring}},
+		GroupLabels:     alertmanager.KV{"a": "b"},
+		TruncatedAlerts: 3,
+	}
+
+	for i := 0; i < 2; i++ {
+		_, err := NewReceiver(log.NewNopLogger(), conf, template.SimpleTemplate(), fakeJira).Notify(context.Background(), data, opts)
+		require.NoError(t, err)
+	}
+	issue := fakeJira.issuesByKey["1"]
+	require.Equal(t, "3 more alerts", issue.Fields.Summary)
+	// Not repeated on repeated notifications.
+	require.Len(t, issue.Fields.Comments.Comments, 1)
+	require.Equal(t, "Alertmanager left 3 alerts of this alert group out of the notification, as it exceeded max_alerts. This issue may not list all of the alerts.", issue.Fields.Comments.Comments[0].Body)
+}
+
+func TestNotifyMinUpdateInterval(t *testing.T) {
+	fakeJira := newTestFakeJira()
+	updates := NewUpdateTracker()
+	conf := testReceiverConfig1()
+	interval := config.Duration(time.Hour)
+	conf.MinUpdateInterval = &interval
+	opts := Options{MaxDescriptionLength: 32768, UpdateSummary: true}
+	start := time.Now()
+	notifyAt := func(d time.Duration, alerts int) {
+		data := &alertmanager.Data{Status: alertmanager.AlertFiring, GroupLabels: alertmanager.KV{"a": "b"}}
+		for i := 0; i < alerts; i++ {
+			data.Alerts = append(data.Alerts, alertmanager.Alert{Status: alertmanager.AlertFiring})
+		}
+		r := NewReceiver(log.NewNopLogger(), conf, template.SimpleTemplate(), fakeJira).WithUpdateTracker(updates)
+		r.timeNow = func() time.Time { return start.Add(d) }
+		_, err := r.Notify(context.Background(), data, opts)
+		require.NoError(t, err)
+	}
+
+	notifyAt(0, 1)
+	require.Len(t, fakeJira.issuesByKey, 1)
+	require.Equal(t, "[FIRING:1] b ", fakeJira.issuesByKey["1"].Fields.Summary)
+
+	// Updates are skipped within the interval after creation.
+	notifyAt(30*time.Minute, 2)
+	require.Equal(t, "[FIRING:1] b ", fakeJira.issuesByKey["1"].Fields.Summary)
+
+	notifyAt(61*time.Minute, 2)
+	require.Equal(t, "[FIRING:2] b ", fakeJira.issuesByKey["1"].Fields.Summary)
+
+	// And within the interval after an update.
+	notifyAt(90*time.Minute, 3)
+	require.Equal(t, "[FIRING:2] b ", fakeJira.issuesByKey["1"].Fields.Summary)
+	require.Len(t, fakeJira.issuesByKey, 1)
+}
+
+func TestDryRunMinUpdateInterval(t *testing.T) {
+	fakeJira := newTestFakeJira()
+	updates := NewUpdateTracker()
+	conf := testReceiverConfig1()
+	interval := config.Duration(time.Hour)
+	conf.MinUpdateInterval = &interval
+	opts := Options{MaxDescriptionLength: 32768, UpdateSummary: true}
+	start := time.Now()
+	receiverAt := func(d time.Duration) *Receiver {
+		r := NewReceiver(log.NewNopLogger(), conf, template.SimpleTemplate(), fakeJira).WithUpdateTracker(updates)
+		r.timeNow = func() time.Time { return start.Add(d) }
+		return r
+	}
+	data := &alertmanager.Data{
+		Status:      alertmanager.AlertFiring,
+		Alerts:      alertmanager.Alerts{{Status: alertmanager.AlertFiring}, {Status: alertmanager.AlertFiring}},
+		GroupLabels: alertmanager.KV{"a": "b"},
+	}
+
+	_, err := receiverAt(0).Notify(context.Background(), &alertmanager.Data{
+		Status:      alertmanager.AlertFiring,
+		Alerts:      alertmanager.Alerts{{Status: alertmanager.AlertFiring}},
+		GroupLabels: alertmanager.KV{"a": "b"},
+	}, opts)
+	require.NoError(t, err)
+
+	// Dry runs within the interval report the throttled updates.
+	res, _, err := receiverAt(30*time.Minute).DryRun(context.Background(), data, opts)
+	require.NoError(t, err)
+	require.True(t, res.UpdatesThrottled)
+	require.Empty(t, res.Operations)
+
+	res, _, err = receiverAt(61*time.Minute).DryRun(context.Background(), data, opts)
+	require.NoError(t, err)
+	require.False(t, res.UpdatesThrottled)
+	require.Len(t, res.Operations, 1)
+	require.Equal(t, "Issue.UpdateWithOptions", res.Operations[0].API)
+
+	// Dry runs do not record updates.
+	_, err = receiverAt(61*time.Minute).Notify(context.Background(), data, opts)
+	require.NoError(t, err)
+	require.Equal(t, "[FIRING:2] b ", fakeJira.issuesByKey["1"].Fields.Summary)
+}
+
+func TestDryRun(t *testing.T) {
+	fakeJira := newTestFakeJira()
+	_, _, err := fakeJira.CreateWithContext(context.Background(), &jira.Issue{
+		ID:  "1",
+		Key: "1",
+		Fields: &jira.IssueFields{
+			Project:     jira.Project{Key: testReceiverConfig2().Project},
+			Labels:      []string{"JIRALERT{819ba5ecba4ea5946a8d17d285cb23f3bb6862e08bb602ab08fd231cd8e1a83a1d095b0208a661787e9035f0541817634df5a994d1b5d4200d6c68a7663c97f5}"},
+			Unknowns:    tcontainer.MarshalMap{},
+			Summary:     "[FIRING:2] b d ",
+			Description: "2",
+		},
+	})
+	require.NoError(t, err)
+
+	receiver := NewReceiver(log.NewNopLogger(), testReceiverConfig2(), template.SimpleTemplate(), fakeJira)
+	res, _, err := receiver.DryRun(context.Background(), &alertmanager.Data{
+		Alerts: alertmanager.Alerts{
+			{Status: alertmanager.AlertFiring},
+		},
+		Status:      alertmanager.AlertFiring,
+		GroupLabels: alertmanager.KV{"a": "b", "c": "d"},
+	}, Options{HashJiraLabel: true, UpdateSummary: true, UpdateDescription: true, MaxDescriptionLength: 32768})
+	require.NoError(t, err)
+
+	// Nothing was written.
+	require.Equal(t, "[FIRING:2] b d ", fakeJira.issuesByKey["1"].Fields.Summary)
+	require.Equal(t, "2", fakeJira.issuesByKey["1"].Fields.Description)
+
+	require.Equal(t, "1", res.IssueKey)
+	require.Len(t, res.Operations, 2)
+	require.Equal(t, []FieldDiff{
+		{Field: "summary", Current: "[FIRING:2] b d ", Desired: "[FIRING:1] b d "},
+		{Field: "description", Current: "2", Desired: "1"},
+	}, res.Diff)
+}
+
+func TestRenderFieldTypes(t *testing.T) {
+	conf := testReceiverConfig2()
+	conf.Fields = map[string]interface{}{
+		"customfield_10001": `[{{ range $i, $a := .Alerts }}{{ if $i }},{{ end }}{"value": {{ $a.Labels.team | toJson }}}{{ end }}]`,
+		"customfield_10002": "{{ len .Alerts }}",
+		"customfield_10003": "{{ len .Alerts }}",
+	}
+	conf.FieldTypes = map[string]string{"customfield_10001": config.FieldTypeJSON, "customfield_10002": config.FieldTypeNumber}
+
+	receiver := NewReceiver(log.NewNopLogger(), conf, template.SimpleTemplate(), nil)
+	data := &alertmanager.Data{
+		Alerts: alertmanager.Alerts{
+			{Status: alertmanager.AlertFiring, Labels: alertmanager.KV{"team": "sre"}},
+			{Status: alertmanager.AlertFiring, Labels: alertmanager.KV{"team": "db"}},
+		},
+		Status:      alertmanager.AlertFiring,
+		GroupLabels: alertmanager.KV{"a": "b"},
+	}
+	opts := Options{MaxDescriptionLength: 32768}
+	res, err := receiver.Render(data, opts)
+	require.NoError(t, err)
+	require.Equal(t, map[string]interface{}{
+		"customfield_10001": []interface{}{map[string]interface{}{"value": "sre"}, map[string]interface{}{"value": "db"}},
+		"customfield_10002": 2.0,
+		"customfield_10003": "2",
+	}, res.Fields)
+
+	conf.Fields["customfield_10001"] = "[{{ len .Alerts }}"
+	_, err = receiver.Render(data, opts)
+	require.ErrorContains(t, err, "convert field customfield_10001")
+}
+
+func TestRenderStrict(t *testing.T) {
+	conf := testReceiverConfig2()
+	conf.Priority = "{{ .CommonLabels.sevrity }}"
+	data := &alertmanager.Data{
+		Alerts:       alertmanager.Alerts{{Status: alertmanager.AlertFiring}},
+		Status:       alertmanager.AlertFiring,
+		GroupLabels:  alertmanager.KV{"a": "b"},
+		CommonLabels: alertmanager.KV{"severity": "critical"},
+	}
+	opts := Options{MaxDescriptionLength: 32768}
+
+	res, err := NewReceiver(log.NewNopLogger(), conf, template.SimpleTemplate(), nil).Render(data, opts)
+	require.NoError(t, err)
+	require.Equal(t, "", res.Priority)
+
+	strict := true
+	conf.TemplateStrict = &strict
+	_, err = NewReceiver(log.NewNopLogger(), conf, template.SimpleTemplate(), nil).Render(data, opts)
+	require.ErrorContains(t, err, `map has no entry for key "sevrity"`)
+}
+
+func TestRender(t *testing.T) {
+	conf := testReceiverConfig2()
+	conf.Priority = "{{ .CommonLabels.severity }}"
+	conf.Components = []string{"{{ .CommonLabels.team }}"}
+	conf.Fields = map[string]interface{}{"customfield_10001": "{{ .GroupLabels.a }}"}
+
+	// Rendering does not need a Jira client.
+	receiver := NewReceiver(log.NewNopLogger(), conf, template.SimpleTemplate(), nil)
+	res, err := receiver.Render(&alertmanager.Data{
+		Alerts: alertmanager.Alerts{
+			{Status: alertmanager.AlertFiring},
+		},
+		Status:       alertmanager.AlertFiring,
+		GroupLabels:  alertmanager.KV{"a": "b", "c": "d"},
+		CommonLabels: alertmanager.KV{"severity": "critical", "team": "sre"},
+	}, Options{HashJiraLabel: true, MaxDescriptionLength: 32768})
+	require.NoError(t, err)
+	require.Equal(t, &RenderResult{
+		Receiver:    conf.Name,
+		Project:     conf.Project,
+		IssueType:   conf.IssueType,
+		Summary:     "[FIRING:1] b d ",
+		Description: "1",
+		Priority:    "critical",
+		Components:  []string{"sre"},
+		Labels:      []string{"JIRALERT{819ba5ecba4ea5946a8d17d285cb23f3bb6862e08bb602ab08fd231cd8e1a83a1d095b0208a661787e9035f0541817634df5a994d1b5d4200d6c68a7663c97f5}"},
+		Fields:      tcontainer.MarshalMap{"customfield_10001": "b"},
+	}, res)
+}
+
+// BenchmarkRender measures rendering all templates of a notification, with the templates parsed on the first
+// iteration only.
+func BenchmarkRender(b *testing.B) {
+	conf := testReceiverConfig2()
+	conf.Priority = "{{ .CommonLabels.severity }}"
+	conf.Components = []string{"{{ .CommonLabels.team }}"}
+	conf.Fields = map[string]interface{}{"customfield_10001": "{{ .GroupLabels.a }}"}
+	receiver := NewReceiver(log.NewNopLogger(), conf, template.SimpleTemplate(), nil)
+	data := &alertmanager.Data{
+		Alerts:       alertmanager.Alerts{{Status: alertmanager.AlertFiring}},
+		Status:       alertmanager.AlertFiring,
+		GroupLabels:  alertmanager.KV{"a": "b", "c": "d"},
+		CommonLabels: alertmanager.KV{"severity": "critical", "team": "sre"},
+	}
+	opts := Options{HashJiraLabel: true, MaxDescriptionLength: 32768}
+
+	b.ReportAllocs()
+	for i := 0; i < b.N; i++ {
+		if _, err := receiver.Render(data, opts); err != nil {
+			b.Fatal(err)
+		}
+	}
+}
+
+// largeAlertGroup returns a notification of n firing alerts with long annotations, as sent for an outage.
+func largeAlertGroup(n int) *alertmanager.Data {
+	data := &alertmanager.Data{
+		Receiver:     "test",
+		Status:       alertmanager.AlertFiring,
+		GroupLabels:  alertmanager.KV{"alertname": "HighLatency", "cluster": "eu-west-1"},
+		CommonLabels: alertmanager.KV{"alertname": "HighLatency", "cluster": "eu-west-1", "severity": "critical"},
+	}
+	for i := 0; i < n; i++ {
+		data.Alerts = append(data.Alerts, alertmanager.Alert{
+			Status: alertmanager.AlertFiring,
+			Labels: alertmanager.KV{
+				"alertname": "HighLatency",
+				"cluster":   "eu-west-1",
+				"severity":  "critical",
+				"instance":  fmt.Sprintf("10.0.%d.%d:9090", i/256, i%256),
+				"job":       "api",
+			},
+			Annotations: alertmanager.KV{
+				"summary":     fmt.Sprintf("99th percentile latency of instance %d is above 500ms", i),
+				"description": strings.Repeat("The API server is responding slowly, check the dashboards and the logs of its dependencies. ", 10),
+			},
+			StartsAt:     time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC),
+			GeneratorURL: "http://prometheus.example.com/graph?g0.expr=histogram_quantile",
+		})
+	}
+	return data
+}
+
+// BenchmarkNotify measures handling notifications of large alert groups, creating an issue or updating the issue
+// of the group.
+func BenchmarkNotify(b *testing.B) {
+	conf := testReceiverConfig2()
+	conf.Priority = "{{ .CommonLabels.severity }}"
+	conf.Description = `{{ range .Alerts.Firing }}{{ .Annotations.summary }}
+{{ .Annotations.description }}
+Labels:
+{{ range .Labels.SortedPairs }} - {{ .Name }} = {{ .Value }}
+{{ end }}Source: {{ .GeneratorURL }}
+{{ end }}`
+	opts := Options{HashJiraLabel: true, MaxDescriptionLength: 32768}
+
+	for _, alerts := range []int{10, 100, 500} {
+		data := largeAlertGroup(alerts)
+		tmpl := template.SimpleTemplate()
+
+		b.Run(fmt.Sprintf("create/alerts=%d", alerts), func(b *testing.B) {
+			b.ReportAllocs()
+			for i := 0; i < b.N; i++ {
+				receiver := NewReceiver(log.NewNopLogger(), conf, tmpl, newTestFakeJira())
+				if _, err := receiver.Notify(context.Background(), data, opts); err != nil {
+					b.Fatal(err)
+				}
+			}
+		})
+		b.Run(fmt.Sprintf("update/alerts=%d", alerts), func(b *testing.B) {
+			receiver := NewReceiver(log.NewNopLogger(), conf, tmpl, newTestFakeJira())
+			if _, err := receiver.Notify(context.Background(), data, opts); err != nil {
+				b.Fatal(err)
+			}
+			b.ReportAllocs()
+			b.ResetTimer()
+			for i := 0; i < b.N; i++ {
+				if _, err := receiver.Notify(context.Background(), data, opts); err != nil {
+					b.Fatal(err)
+				}
+			}
+		})
+	}
+}
+
+func TestNotifyIdentityMigrationBackfill(t *testing.T) {
+	groupLabels := alertmanager.KV{"a": "b"}
+	data := &alertmanager.Data{
+		Status:      alertmanager.AlertFiring,
+		Alerts:      alertmanager.Alerts{{Status: alertmanager.AlertFiring}},
+		GroupLabels: groupLabels,
+	}
+	opts := Options{MaxDescriptionLength: 32768, HashJiraLabel: true}
+
+	for _, tcase := range []struct {
+		name           string
+		identity       *config.IdentityConfig
+		expectedLabels []string
+		expectedField  interface{}
+	}{
+		{
+			name:           "hash from label",
+			identity:       &config.IdentityConfig{Strategy: config.IdentityHash, MigrateFrom: config.IdentityLabel},
+			expectedLabels: []string{identity.Legacy(groupLabels), identity.Hash(groupLabels)},
+		},
+		{
+			name:           "field from label",
+			identity:       &config.IdentityConfig{Strategy: config.IdentityField, Field: "customfield_10100", MigrateFrom: config.IdentityLabel},
+			expectedLabels: []string{identity.Legacy(groupLabels)},
+			expectedField:  identity.Hash(groupLabels),
+		},
+	} {
+		t.Run(tcase.name, func(t *testing.T) {
+			fakeJira := newTestFakeJira()
+			conf := testReceiverConfig1()
+			conf.Identity = tcase.identity
+
+			// An issue created before the migration, only identified by the secondary strategy.
+			_, _, err := fakeJira.CreateWithContext(context.Background(), &jira.Issue{
+				Fields: &jira.IssueFields{
+					Project:  jira.Project{Key: conf.Project},
+					Labels:   []string{identity.Legacy(groupLabels)},
+					Unknowns: tcontainer.MarshalMap{},
+					Summary:  "[FIRING:1] b ",
+				},
+			})
+			require.NoError(t, err)
+			strategy, err := identity.New(conf.Identity, true)
+			require.NoError(t, err)
+			query := fmt.Sprintf("%s order by resolutiondate desc", projectsQuery([]string{conf.Project}, strategy.Query(groupLabels)))
+			fakeJira.keysByQuery[query] = []string{"1"}
+
+			_, err = NewReceiver(log.NewNopLogger(), conf, template.SimpleTemplate(), fakeJira).Notify(context.Background(), data, opts)
+			require.NoError(t, err)
+			require.Len(t, fakeJira.issuesByKey, 1)
+			require.Equal(t, tcase.expectedLabels, fakeJira.issuesByKey["1"].Fields.Labels)
+			require.Equal(t, tcase.expectedField, fakeJira.issuesByKey["1"].Fields.Unknowns["customfield_10100"])
+
+		})
+	}
+}
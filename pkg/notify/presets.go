@@ -0,0 +1,61 @@
+// Copyright 2017 The Prometheus Authors
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package notify
+
+import (
+	"strings"
+
+	"github.com/go-kit/log/level"
+	"github.com/prometheus-community/jiralert/pkg/alertmanager"
+)
+
+// withPreset returns the receiver with the issue settings of the first of its presets matching the common labels of
+// the notification, or the receiver itself if none matches.
+func (r *Receiver) withPreset(data *alertmanager.Data) *Receiver {
+	for i, p := range r.conf.Presets {
+		if !p.Matches(data.CommonLabels) {
+			continue
+		}
+		conf := *r.conf
+		conf.Presets = nil
+		if p.IssueType != "" {
+			conf.IssueType = p.IssueType
+		}
+		if p.Priority != "" {
+			conf.Priority = p.Priority
+		}
+		if p.StaticLabels != nil {
+			conf.StaticLabels = p.StaticLabels
+		}
+		if len(p.Fields) > 0 {
+			conf.Fields = make(map[string]interface{}, len(r.conf.Fields)+len(p.Fields))
+			for key, value := range r.conf.Fields {
+				conf.Fields[key] = value
+			}
+			for key, value := range p.Fields {
+				conf.Fields[key] = value
+			}
+		}
+
+		matchers := make([]string, 0, len(p.Matchers))
+		for _, m := range p.Matchers {
+			matchers = append(matchers, m.String())
+		}
+		level.Debug(r.logger).Log("msg", "applying preset", "preset", i, "matchers", strings.Join(matchers, ","))
+		pr := *r
+		pr.conf = &conf
+		return &pr
+	}
+	return r
+}
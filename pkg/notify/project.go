@@ -0,0 +1,60 @@
+// Copyright 2017 The Prometheus Authors
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package notify
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/go-kit/log/level"
+	"github.com/pkg/errors"
+	"github.com/prometheus-community/jiralert/pkg/alertmanager"
+	"github.com/prometheus-community/jiralert/pkg/config"
+)
+
+// renderProject renders the project of the alert group. Projects which are not valid project keys, e.g. as they
+// rendered empty due to a missing label, or which are not allowed are replaced with the fallback_project, if any,
+// rather than failing with an obscure Jira error. The returned flag reports whether the fallback was used.
+func (r *Receiver) renderProject(data *alertmanager.Data) (string, bool, error) {
+	project, err := r.execute(r.conf.Project, data)
+	if err != nil {
+		return "", false, errors.Wrap(err, "generate project from template")
+	}
+	project = strings.TrimSpace(project)
+
+	var reason string
+	switch {
+	case !config.ValidProjectKey(project):
+		reason = "not a valid project key"
+	case len(r.conf.AllowedProjects) > 0 && !projectAllowed(r.conf.AllowedProjects, project):
+		reason = "not one of the allowed projects"
+	default:
+		return project, false, nil
+	}
+	if r.conf.FallbackProject == "" {
+		return "", false, fmt.Errorf("rendered project %q is %s", project, reason)
+	}
+	level.Warn(r.logger).Log("msg", "using fallback project", "project", project, "reason", reason, "fallback_project", r.conf.FallbackProject)
+	return r.conf.FallbackProject, true, nil
+}
+
+// projectAllowed reports whether project is one of the allowed keys. Jira project keys are case-insensitive.
+func projectAllowed(allowed []string, project string) bool {
+	for _, p := range allowed {
+		if strings.EqualFold(p, project) {
+			return true
+		}
+	}
+	return false
+}
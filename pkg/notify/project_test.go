@@ -0,0 +1,56 @@
+// Copyright 2017 The Prometheus Authors
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+package notify
+
+import (
+	"context"
+	"testing"
+
+	"github.com/go-kit/log"
+	"github.com/prometheus-community/jiralert/pkg/alertmanager"
+	"github.com/prometheus-community/jiralert/pkg/template"
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	"github.com/stretchr/testify/require"
+)
+
+func TestNotifyFallbackProject(t *testing.T) {
+	fakeJira := newTestFakeJira()
+	conf := testReceiverConfig1()
+	conf.Name = "fallback"
+	conf.Project = "{{ .CommonLabels.project }}"
+	conf.AllowedProjects = []string{"OPS", "DB"}
+	notify := func(project string) error {
+		r := NewReceiver(log.NewNopLogger(), conf, template.SimpleTemplate(), fakeJira)
+		_, err := r.Notify(context.Background(), &alertmanager.Data{
+			Status:       alertmanager.AlertFiring,
+			Alerts:       alertmanager.Alerts{{Status: alertmanager.AlertFiring}},
+			GroupLabels:  alertmanager.KV{"project": project},
+			CommonLabels: alertmanager.KV{"project": project},
+		}, Options{MaxDescriptionLength: 32768})
+		return err
+	}
+
+	require.NoError(t, notify("db"))
+	require.Equal(t, "db", fakeJira.issuesByKey["1"].Fields.Project.Key)
+
+	// Without fallback project, invalid projects fail before reaching Jira.
+	require.EqualError(t, notify(""), `rendered project "" is not a valid project key`)
+	require.EqualError(t, notify("WEB"), `rendered project "WEB" is not one of the allowed projects`)
+	require.Len(t, fakeJira.issuesByKey, 1)
+
+	conf.FallbackProject = "OPS"
+	require.NoError(t, notify("web-team"))
+	require.Equal(t, "OPS", fakeJira.issuesByKey["2"].Fields.Project.Key)
+	require.NoError(t, notify("WEB"))
+	require.Equal(t, 2.0, testutil.ToFloat64(projectFallbacks.WithLabelValues("fallback")))
+}
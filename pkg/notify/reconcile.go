@@ -0,0 +1,84 @@
+// Copyright 2017 The Prometheus Authors
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package notify
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/go-kit/log/level"
+	"github.com/pkg/errors"
+	"github.com/prometheus-community/jiralert/pkg/alertmanager"
+)
+
+// Reconcile notifies the receiver of the alert groups firing in Alertmanager as of fetched, creating or reopening
+// their missing issues, and notifies the alert groups it last notified firing before then, but which no longer fire,
+// as resolved. This makes issues eventually consistent with Alertmanager even if webhook requests were lost. It
+// returns how many alert groups were notified.
+func (r *Receiver) Reconcile(ctx context.Context, firing []*alertmanager.Data, fetched time.Time, opts Options) (int, error) {
+	var (
+		notified int
+		errs     []string
+		active   = map[string]struct{}{}
+	)
+	for _, data := range firing {
+		active[groupID(data.GroupLabels)] = struct{}{}
+		if _, err := r.Notify(ctx, data, opts); err != nil {
+			errs = append(errs, fmt.Sprintf("%s: %s", groupID(data.GroupLabels), err))
+			continue
+		}
+		reconciledNotifications.WithLabelValues(r.conf.Name, alertmanager.AlertFiring).Inc()
+		notified++
+	}
+
+	if r.stale != nil {
+		for _, issue := range r.stale.Firing(r.conf.Name) {
+			// Alert groups notified after fetching the firing ones may be missing from them.
+			if _, ok := active[groupID(issue.GroupLabels)]; ok || !issue.Seen.Before(fetched) {
+				continue
+			}
+			level.Info(r.logger).Log("msg", "alert group no longer firing in Alertmanager, resolving it", "key", issue.Key, "query", issue.GroupQuery)
+			data := &alertmanager.Data{
+				Version:      alertmanager.WebhookVersion4,
+				Receiver:     r.conf.Name,
+				Status:       alertmanager.AlertResolved,
+				GroupLabels:  issue.GroupLabels,
+				CommonLabels: issue.GroupLabels,
+			}
+			if _, err := r.Notify(ctx, data, opts); err != nil {
+				errs = append(errs, fmt.Sprintf("%s: %s", groupID(issue.GroupLabels), err))
+				continue
+			}
+			reconciledNotifications.WithLabelValues(r.conf.Name, alertmanager.AlertResolved).Inc()
+			notified++
+		}
+	}
+
+	if len(errs) > 0 {
+		return notified, errors.Errorf("failed to reconcile alert groups: %s", strings.Join(errs, "; "))
+	}
+	return notified, nil
+}
+
+// groupID identifies the alert group with the given group labels.
+func groupID(groupLabels alertmanager.KV) string {
+	pairs := groupLabels.SortedPairs()
+	parts := make([]string, 0, len(pairs))
+	for _, p := range pairs {
+		parts = append(parts, fmt.Sprintf("%s=%q", p.Name, p.Value))
+	}
+	return "{" + strings.Join(parts, ",") + "}"
+}
@@ -0,0 +1,72 @@
+// Copyright 2017 The Prometheus Authors
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+package notify
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/go-kit/log"
+	"github.com/prometheus-community/jiralert/pkg/alertmanager"
+	"github.com/prometheus-community/jiralert/pkg/config"
+	"github.com/prometheus-community/jiralert/pkg/template"
+	"github.com/stretchr/testify/require"
+)
+
+func TestReconcile(t *testing.T) {
+	fakeJira := newTestFakeJira()
+	stale := NewStaleTracker()
+	conf := testReceiverConfig1()
+	conf.AlertmanagerURL = "http://alertmanager:9093"
+	conf.AutoResolve = &config.AutoResolve{State: "Done"}
+	opts := Options{MaxDescriptionLength: 32768}
+	start := time.Date(2023, 5, 1, 10, 0, 0, 0, time.UTC)
+	receiverAt := func(d time.Duration) *Receiver {
+		r := NewReceiver(log.NewNopLogger(), conf, template.SimpleTemplate(), fakeJira).WithStaleTracker(stale)
+		r.timeNow = func() time.Time { return start.Add(d) }
+		return r
+	}
+	firing := func(group string) *alertmanager.Data {
+		return &alertmanager.Data{
+			Status:      alertmanager.AlertFiring,
+			Alerts:      alertmanager.Alerts{{Status: alertmanager.AlertFiring}},
+			GroupLabels: alertmanager.KV{"a": group},
+		}
+	}
+
+	_, err := receiverAt(0).Notify(context.Background(), firing("b"), opts)
+	require.NoError(t, err)
+	_, err = receiverAt(0).Notify(context.Background(), firing("c"), opts)
+	require.NoError(t, err)
+	// Notified after fetching the firing alert groups.
+	_, err = receiverAt(2*time.Minute).Notify(context.Background(), firing("e"), opts)
+	require.NoError(t, err)
+	require.Len(t, fakeJira.issuesByKey, 3)
+
+	// The resolved notification of b and the firing notification of d were lost.
+	notified, err := receiverAt(2*time.Minute).Reconcile(context.Background(), []*alertmanager.Data{firing("c"), firing("d")}, start.Add(time.Minute), opts)
+	require.NoError(t, err)
+	require.Equal(t, 3, notified)
+	require.Len(t, fakeJira.issuesByKey, 4)
+	require.Equal(t, "Done", fakeJira.issuesByKey["1"].Fields.Status.StatusCategory.Key)
+	for _, key := range []string{"2", "3", "4"} {
+		require.NotEqual(t, "Done", fakeJira.issuesByKey[key].Fields.Status.StatusCategory.Key, key)
+	}
+
+	// Resolved alert groups are not resolved again.
+	notified, err = receiverAt(10*time.Minute).Reconcile(context.Background(), []*alertmanager.Data{firing("c"), firing("d"), firing("e")}, start.Add(9*time.Minute), opts)
+	require.NoError(t, err)
+	require.Equal(t, 3, notified)
+	require.Len(t, fakeJira.issuesByKey, 4)
+}
@@ -0,0 +1,135 @@
+// Copyright 2017 The Prometheus Authors
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package notify
+
+import (
+	"context"
+	"net/url"
+
+	"github.com/andygrunwald/go-jira"
+	"github.com/go-kit/log/level"
+	"github.com/prometheus-community/jiralert/pkg/alertmanager"
+)
+
+// Global IDs of the remote links, which Jira uses to update a link rather than adding another one when e.g. the
+// runbook changes.
+const (
+	runbookLinkID      = "jiralert:runbook"
+	alertmanagerLinkID = "jiralert:alertmanager"
+)
+
+// remoteLinks returns the remote links the issue of the alert group should have.
+func (r *Receiver) remoteLinks(data *alertmanager.Data) []*jira.RemoteLink {
+	var links []*jira.RemoteLink
+	if l := r.runbookLink(data); l != nil {
+		links = append(links, l)
+	}
+	if l := r.alertmanagerLink(data); l != nil {
+		links = append(links, l)
+	}
+	return links
+}
+
+// runbookLink returns the remote link to the runbook of the alert group, if configured and annotated: the common
+// runbook annotation of the alerts, or else the one of the first alert with the annotation.
+func (r *Receiver) runbookLink(data *alertmanager.Data) *jira.RemoteLink {
+	c := r.conf.RunbookLink
+	if c == nil {
+		return nil
+	}
+	u := data.CommonAnnotations[c.Annotation]
+	for _, a := range data.Alerts {
+		if u != "" {
+			break
+		}
+		u = a.Annotations[c.Annotation]
+	}
+	if u == "" {
+		return nil
+	}
+	title, err := r.execute(c.Title, data)
+	if err != nil {
+		level.Warn(r.logger).Log("msg", "failed to render runbook link title", "err", err)
+		return nil
+	}
+	return newRemoteLink(runbookLinkID, "runbook", u, title, c.IconURL)
+}
+
+// alertmanagerLink returns the remote link to the alerts of the group in the Alertmanager UI, if configured. Alert
+// groups without an absolute URL, e.g. notified by an Alertmanager without external URL, get no link.
+func (r *Receiver) alertmanagerLink(data *alertmanager.Data) *jira.RemoteLink {
+	c := r.conf.AlertmanagerLink
+	if c == nil {
+		return nil
+	}
+	rawURL, err := r.execute(c.URL, data)
+	if err != nil {
+		level.Warn(r.logger).Log("msg", "failed to render Alertmanager link URL", "err", err)
+		return nil
+	}
+	if u, err := url.Parse(rawURL); err != nil || !u.IsAbs() {
+		level.Debug(r.logger).Log("msg", "not linking to Alertmanager without an absolute URL", "url", rawURL)
+		return nil
+	}
+	title, err := r.execute(c.Title, data)
+	if err != nil {
+		level.Warn(r.logger).Log("msg", "failed to render Alertmanager link title", "err", err)
+		return nil
+	}
+	return newRemoteLink(alertmanagerLinkID, "alerts", rawURL, title, c.IconURL)
+}
+
+func newRemoteLink(globalID, relationship, u, title, iconURL string) *jira.RemoteLink {
+	obj := &jira.RemoteLinkObject{URL: u, Title: title}
+	if iconURL != "" {
+		obj.Icon = &jira.RemoteLinkIcon{Url16x16: iconURL, Title: title}
+	}
+	return &jira.RemoteLink{GlobalID: globalID, Relationship: relationship, Object: obj}
+}
+
+// syncRemoteLinks adds the remote links of the alert group to the issue, and updates the links which changed, e.g. as
+// the runbook moved. The links of created issues are not fetched, as there are none. Failures are logged rather than
+// failing the notification, as the issue itself is up to date.
+func (r *Receiver) syncRemoteLinks(ctx context.Context, issue *jira.Issue, data *alertmanager.Data, created bool) {
+	desired := r.remoteLinks(data)
+	if len(desired) == 0 {
+		return
+	}
+	current := map[string]jira.RemoteLink{}
+	if !created {
+		links, resp, err := r.client.GetRemoteLinksWithContext(ctx, issue.Key)
+		if err != nil {
+			_, err = handleJiraErrResponse("Issue.GetRemoteLinks", resp, err, r.conf.Retry, r.logger)
+			level.Warn(r.logger).Log("msg", "failed to get remote links", "key", issue.Key, "err", err)
+			return
+		}
+		for _, l := range *links {
+			if l.GlobalID != "" {
+				current[l.GlobalID] = l
+			}
+		}
+	}
+	for _, l := range desired {
+		if c, ok := current[l.GlobalID]; ok && c.Object != nil && c.Object.URL == l.Object.URL && c.Object.Title == l.Object.Title {
+			continue
+		}
+		// Jira updates the link of the same global ID, if any.
+		if _, resp, err := r.client.AddRemoteLinkWithContext(ctx, issue.Key, l); err != nil {
+			_, err = handleJiraErrResponse("Issue.AddRemoteLink", resp, err, r.conf.Retry, r.logger)
+			level.Warn(r.logger).Log("msg", "failed to add remote link", "key", issue.Key, "url", l.Object.URL, "err", err)
+			continue
+		}
+		level.Debug(r.logger).Log("msg", "added remote link", "key", issue.Key, "url", l.Object.URL)
+	}
+}
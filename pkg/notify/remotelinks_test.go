@@ -0,0 +1,118 @@
+// Copyright 2017 The Prometheus Authors
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+package notify
+
+import (
+	"context"
+	"testing"
+
+	"github.com/andygrunwald/go-jira"
+	"github.com/go-kit/log"
+	"github.com/prometheus-community/jiralert/pkg/alertmanager"
+	"github.com/prometheus-community/jiralert/pkg/config"
+	"github.com/prometheus-community/jiralert/pkg/template"
+	"github.com/stretchr/testify/require"
+)
+
+func TestNotifyRunbookLink(t *testing.T) {
+	fakeJira := newTestFakeJira()
+	conf := testReceiverConfig1()
+	conf.RunbookLink = &config.RunbookLinkConfig{
+		Annotation: "runbook_url",
+		Title:      "Runbook of {{ .GroupLabels.a }}",
+		IconURL:    "https://example.com/runbook.png",
+	}
+	data := &alertmanager.Data{
+		Status: alertmanager.AlertFiring,
+		Alerts: alertmanager.Alerts{
+			{Status: alertmanager.AlertFiring},
+			{Status: alertmanager.AlertFiring, Annotations: alertmanager.KV{"runbook_url": "https://runbooks.example.com/a"}},
+		},
+		GroupLabels: alertmanager.KV{"a": "b"},
+	}
+	notify := func() {
+		r := NewReceiver(log.NewNopLogger(), conf, template.SimpleTemplate(), fakeJira)
+		_, err := r.Notify(context.Background(), data, Options{MaxDescriptionLength: 32768})
+		require.NoError(t, err)
+	}
+
+	notify()
+	require.Equal(t, map[string][]jira.RemoteLink{"1": {{
+		GlobalID:     runbookLinkID,
+		Relationship: "runbook",
+		Object: &jira.RemoteLinkObject{
+			URL:   "https://runbooks.example.com/a",
+			Title: "Runbook of b",
+			Icon:  &jira.RemoteLinkIcon{Url16x16: "https://example.com/runbook.png", Title: "Runbook of b"},
+		},
+	}}}, fakeJira.remoteLinks)
+	// The links of created issues are not fetched.
+	require.Equal(t, 0, fakeJira.remoteLinkGets)
+	require.Equal(t, 1, fakeJira.remoteLinkAdds)
+
+	// Unchanged links are not updated.
+	notify()
+	require.Equal(t, 1, fakeJira.remoteLinkGets)
+	require.Equal(t, 1, fakeJira.remoteLinkAdds)
+
+	// The common annotation takes precedence, and updates the link.
+	data.CommonAnnotations = alertmanager.KV{"runbook_url": "https://runbooks.example.com/b"}
+	notify()
+	require.Equal(t, 2, fakeJira.remoteLinkAdds)
+	require.Len(t, fakeJira.remoteLinks["1"], 1)
+	require.Equal(t, "https://runbooks.example.com/b", fakeJira.remoteLinks["1"][0].Object.URL)
+
+	// Alert groups without runbook get no link.
+	data.CommonAnnotations = nil
+	data.Alerts[1].Annotations = nil
+	notify()
+	require.Equal(t, 2, fakeJira.remoteLinkGets)
+	require.Equal(t, 2, fakeJira.remoteLinkAdds)
+}
+
+func TestNotifyAlertmanagerLink(t *testing.T) {
+	fakeJira := newTestFakeJira()
+	conf := testReceiverConfig1()
+	conf.AlertmanagerLink = &config.AlertmanagerLinkConfig{
+		URL:   "{{ alertsURL .ExternalURL .GroupLabels }}",
+		Title: "Alertmanager",
+	}
+	conf.RunbookLink = &config.RunbookLinkConfig{Annotation: "runbook_url", Title: "Runbook"}
+	data := &alertmanager.Data{
+		Status:            alertmanager.AlertFiring,
+		Alerts:            alertmanager.Alerts{{Status: alertmanager.AlertFiring}},
+		GroupLabels:       alertmanager.KV{"alertname": "HighLatency", "service": "api"},
+		CommonAnnotations: alertmanager.KV{"runbook_url": "https://runbooks.example.com/a"},
+	}
+	notify := func() {
+		r := NewReceiver(log.NewNopLogger(), conf, template.SimpleTemplate(), fakeJira)
+		_, err := r.Notify(context.Background(), data, Options{MaxDescriptionLength: 32768})
+		require.NoError(t, err)
+	}
+
+	// Alert groups notified without external URL are not linked to Alertmanager.
+	notify()
+	require.Len(t, fakeJira.remoteLinks["1"], 1)
+	require.Equal(t, runbookLinkID, fakeJira.remoteLinks["1"][0].GlobalID)
+
+	data.ExternalURL = "http://alertmanager:9093"
+	notify()
+	require.Equal(t, []jira.RemoteLink{
+		{GlobalID: runbookLinkID, Relationship: "runbook", Object: &jira.RemoteLinkObject{URL: "https://runbooks.example.com/a", Title: "Runbook"}},
+		{GlobalID: alertmanagerLinkID, Relationship: "alerts", Object: &jira.RemoteLinkObject{
+			URL:   "http://alertmanager:9093/#/alerts?filter=%7Balertname%3D%22HighLatency%22%2Cservice%3D%22api%22%7D",
+			Title: "Alertmanager",
+		}},
+	}, fakeJira.remoteLinks["1"])
+	require.Equal(t, 2, fakeJira.remoteLinkAdds)
+}
@@ -0,0 +1,241 @@
+// Copyright 2017 The Prometheus Authors
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package notify
+
+import (
+	"context"
+	"encoding/json"
+	"sync"
+	"time"
+
+	"github.com/go-kit/log/level"
+	"github.com/pkg/errors"
+	"github.com/prometheus-community/jiralert/pkg/alertmanager"
+	"github.com/prometheus-community/jiralert/pkg/store"
+)
+
+// ResolveScheduler delays the resolution of issues by receivers with an auto_resolve delay, until their alert groups
+// stayed resolved for it. Receivers with a store also record pending resolutions there, so that they are scheduled
+// again after a restart; they are lost on restart otherwise, leaving the issues unresolved until Alertmanager
+// notifies their alert groups again.
+type ResolveScheduler struct {
+	timeout time.Duration
+
+	mtx     sync.Mutex
+	pending map[string]*time.Timer
+	stopped bool
+	running sync.WaitGroup
+}
+
+// NewResolveScheduler creates a ResolveScheduler without pending resolutions. Like notifications, resolutions are
+// canceled after timeout, unless it is zero.
+func NewResolveScheduler(timeout time.Duration) *ResolveScheduler {
+	return &ResolveScheduler{timeout: timeout, pending: map[string]*time.Timer{}}
+}
+
+// Schedule calls resolve after delay, unless the resolution of key is canceled or scheduled again before, or the
+// scheduler is stopped.
+func (s *ResolveScheduler) Schedule(key string, delay time.Duration, resolve func(context.Context)) {
+	s.mtx.Lock()
+	defer s.mtx.Unlock()
+	if t, ok := s.pending[key]; ok {
+		t.Stop()
+		delete(s.pending, key)
+	}
+	if s.stopped {
+		return
+	}
+	var t *time.Timer
+	t = time.AfterFunc(delay, func() {
+		s.mtx.Lock()
+		if s.stopped || s.pending[key] != t {
+			// Canceled, scheduled again or stopped while firing.
+			s.mtx.Unlock()
+			return
+		}
+		delete(s.pending, key)
+		s.running.Add(1)
+		s.mtx.Unlock()
+		defer s.running.Done()
+
+		ctx := context.Background()
+		if s.timeout > 0 {
+			var cancel context.CancelFunc
+			ctx, cancel = context.WithTimeout(ctx, s.timeout)
+			defer cancel()
+		}
+		resolve(ctx)
+	})
+	s.pending[key] = t
+}
+
+// Cancel cancels the pending resolution of key, returning false if there is none.
+func (s *ResolveScheduler) Cancel(key string) bool {
+	s.mtx.Lock()
+	defer s.mtx.Unlock()
+	t, ok := s.pending[key]
+	if !ok {
+		return false
+	}
+	t.Stop()
+	delete(s.pending, key)
+	return true
+}
+
+// Pending returns true if the resolution of key is pending.
+func (s *ResolveScheduler) Pending(key string) bool {
+	s.mtx.Lock()
+	defer s.mtx.Unlock()
+	_, ok := s.pending[key]
+	return ok
+}
+
+// Stop stops the pending resolutions, leaving those recorded in a store to be scheduled again on startup, and waits
+// for the running ones to finish until ctx is done. Resolutions scheduled afterwards are only recorded.
+func (s *ResolveScheduler) Stop(ctx context.Context) error {
+	s.mtx.Lock()
+	s.stopped = true
+	for key, t := range s.pending {
+		t.Stop()
+		delete(s.pending, key)
+	}
+	s.mtx.Unlock()
+
+	done := make(chan struct{})
+	go func() {
+		s.running.Wait()
+		close(done)
+	}()
+	select {
+	case <-done:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+// WithResolveScheduler makes receivers with an auto_resolve delay schedule resolutions in s.
+func (r *Receiver) WithResolveScheduler(s *ResolveScheduler) *Receiver {
+	r.resolves = s
+	return r
+}
+
+// resolveDelay returns how long the alert group must stay resolved before its issue is resolved, or zero to resolve
+// it right away.
+func (r *Receiver) resolveDelay() time.Duration {
+	if r.resolves == nil || r.resolveNow || r.conf.AutoResolve == nil {
+		return 0
+	}
+	return time.Duration(r.conf.AutoResolve.Delay)
+}
+
+// scheduleResolve handles the notification again once the alert group stayed resolved for the auto_resolve delay,
+// resolving its issue then. The pending resolution is recorded in the store, if any. Dry runs only report when the
+// issue would be resolved.
+func (r *Receiver) scheduleResolve(ctx context.Context, data *alertmanager.Data, opts Options, project, groupQuery string, delay time.Duration) {
+	group := r.groupKey(project, groupQuery)
+	at := r.timeNow().Add(delay)
+	if r.dryRun {
+		if r.planned != nil {
+			r.planned.ResolveAt = &at
+		}
+		return
+	}
+	if r.storeEnabled() {
+		body, err := json.Marshal(data)
+		if err == nil {
+			err = r.store.SetResolve(ctx, group, store.PendingResolve{Receiver: r.conf.Name, At: at, Data: body})
+		}
+		if err != nil {
+			level.Warn(r.logger).Log("msg", "failed to record pending resolution in store", "query", groupQuery, "err", err)
+		}
+	}
+	r.resolveAt(group, data, opts, at)
+}
+
+// RestoreResolve schedules again the pending resolution of the issue of the alert group recorded in the store, e.g.
+// before a restart. Resolutions past due are handled right away.
+func (r *Receiver) RestoreResolve(group string, res store.PendingResolve, opts Options) error {
+	if r.resolves == nil {
+		return errors.New("no resolve scheduler")
+	}
+	var data alertmanager.Data
+	if err := json.Unmarshal(res.Data, &data); err != nil {
+		return errors.Wrap(err, "decode resolved notification")
+	}
+	r.resolveAt(group, &data, opts, res.At)
+	return nil
+}
+
+// resolveAt schedules the resolution of the issue of the alert group at the given time. With a store, the issue is
+// only resolved if the resolution is still pending there, as another replica may have canceled or scheduled it again.
+// Failed resolutions are kept in the store, to be retried on startup.
+func (r *Receiver) resolveAt(group string, data *alertmanager.Data, opts Options, at time.Time) {
+	rr := *r
+	rr.resolveNow = true
+	r.resolves.Schedule(group, at.Sub(r.timeNow()), func(ctx context.Context) {
+		if rr.storeEnabled() {
+			res, ok, err := rr.store.GetResolve(ctx, group)
+			if err != nil {
+				level.Warn(rr.logger).Log("msg", "failed to read pending resolution from store, resolving anyway", "group", group, "err", err)
+			} else if !ok || !res.At.Equal(at) {
+				return
+			}
+		}
+		if _, _, err := rr.notifyRecorded(ctx, data, opts); err != nil {
+			level.Error(rr.logger).Log("msg", "delayed resolution failed", "group", group, "err", err)
+			return
+		}
+		if rr.storeEnabled() {
+			if err := rr.store.DeleteResolve(ctx, group); err != nil {
+				level.Warn(rr.logger).Log("msg", "failed to delete pending resolution from store", "group", group, "err", err)
+			}
+		}
+	})
+}
+
+// cancelResolve cancels the pending resolution of the alert group, as it fires again. With a store, this also cancels
+// resolutions scheduled before a restart or by other replicas. Dry runs only report whether a resolution is pending.
+func (r *Receiver) cancelResolve(ctx context.Context, project, groupQuery string) {
+	if r.resolves == nil {
+		return
+	}
+	group := r.groupKey(project, groupQuery)
+	if r.dryRun {
+		pending := r.resolves.Pending(group)
+		if !pending && r.storeEnabled() && r.conf.AutoResolve != nil {
+			_, ok, err := r.store.GetResolve(ctx, group)
+			pending = err == nil && ok
+		}
+		if r.planned != nil {
+			r.planned.ResolveCanceled = pending
+		}
+		return
+	}
+	canceled := r.resolves.Cancel(group)
+	if r.storeEnabled() && r.conf.AutoResolve != nil {
+		_, ok, err := r.store.GetResolve(ctx, group)
+		if err == nil && ok {
+			err = r.store.DeleteResolve(ctx, group)
+			canceled = true
+		}
+		if err != nil {
+			level.Warn(r.logger).Log("msg", "failed to cancel pending resolution in store", "query", groupQuery, "err", err)
+		}
+	}
+	if canceled {
+		level.Info(r.logger).Log("msg", "alert group fired again within the auto_resolve delay, not resolving its issue", "query", groupQuery)
+		resolvesCanceled.WithLabelValues(r.conf.Name).Inc()
+	}
+}
@@ -0,0 +1,218 @@
+// Copyright 2017 The Prometheus Authors
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+package notify
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/go-kit/log"
+	"github.com/prometheus-community/jiralert/pkg/alertmanager"
+	"github.com/prometheus-community/jiralert/pkg/config"
+	"github.com/prometheus-community/jiralert/pkg/identity"
+	"github.com/prometheus-community/jiralert/pkg/store"
+	"github.com/prometheus-community/jiralert/pkg/template"
+	"github.com/stretchr/testify/require"
+)
+
+func TestResolveScheduler(t *testing.T) {
+	s := NewResolveScheduler(time.Minute)
+	resolved := make(chan string, 2)
+
+	s.Schedule("a", time.Hour, func(context.Context) { resolved <- "a" })
+	require.True(t, s.Pending("a"))
+	require.True(t, s.Cancel("a"))
+	require.False(t, s.Pending("a"))
+	require.False(t, s.Cancel("a"))
+
+	// Scheduling again replaces the pending resolution.
+	s.Schedule("b", time.Hour, func(context.Context) { resolved <- "b1" })
+	s.Schedule("b", time.Millisecond, func(ctx context.Context) {
+		// Resolutions have the deadline of notifications.
+		_, ok := ctx.Deadline()
+		require.True(t, ok)
+		resolved <- "b2"
+	})
+	require.Equal(t, "b2", <-resolved)
+	require.False(t, s.Pending("b"))
+	require.Empty(t, resolved)
+
+	// Stopping waits for running resolutions, and stops pending ones.
+	running, release := make(chan struct{}), make(chan struct{})
+	s.Schedule("c", time.Millisecond, func(context.Context) {
+		close(running)
+		<-release
+	})
+	s.Schedule("d", time.Hour, func(context.Context) { resolved <- "d" })
+	<-running
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+	defer cancel()
+	require.ErrorIs(t, s.Stop(ctx), context.DeadlineExceeded)
+	close(release)
+	require.NoError(t, s.Stop(context.Background()))
+	require.False(t, s.Pending("d"))
+	s.Schedule("e", 0, func(context.Context) { resolved <- "e" })
+	require.False(t, s.Pending("e"))
+	require.Empty(t, resolved)
+}
+
+func TestNotifyResolveDelay(t *testing.T) {
+	fakeJira := newTestFakeJira()
+	resolves := NewResolveScheduler(0)
+	notifications := NewNotificationLog(10)
+	conf := testReceiverConfig1()
+	conf.AutoResolve = &config.AutoResolve{State: "Done", Delay: config.Duration(time.Hour)}
+	opts := Options{MaxDescriptionLength: 32768}
+	notify := func(status string) {
+		data := &alertmanager.Data{
+			Status:      status,
+			Alerts:      alertmanager.Alerts{{Status: status}},
+			GroupLabels: alertmanager.KV{"a": "b"},
+		}
+		r := NewReceiver(log.NewNopLogger(), conf, template.SimpleTemplate(), fakeJira).WithResolveScheduler(resolves).WithNotificationLog(notifications)
+		_, err := r.Notify(context.Background(), data, opts)
+		require.NoError(t, err)
+	}
+	strategy, err := identity.New(conf.Identity, false)
+	require.NoError(t, err)
+	key := NewReceiver(log.NewNopLogger(), conf, template.SimpleTemplate(), nil).groupKey("abc", strategy.Query(alertmanager.KV{"a": "b"}))
+
+	notify(alertmanager.AlertFiring)
+	notify(alertmanager.AlertResolved)
+	require.True(t, resolves.Pending(key))
+	require.Equal(t, "NotDone", fakeJira.issuesByKey["1"].Fields.Status.StatusCategory.Key)
+
+	// Firing again within the delay cancels the resolution.
+	notify(alertmanager.AlertFiring)
+	require.False(t, resolves.Pending(key))
+
+	// Staying resolved for the delay resolves the issue.
+	conf.AutoResolve.Delay = config.Duration(time.Millisecond)
+	notify(alertmanager.AlertResolved)
+	require.Eventually(t, func() bool {
+		n := notifications.List()
+		return len(n) == 5 && n[0].Action == ActionResolved
+	}, time.Second, time.Millisecond)
+	require.Equal(t, "Done", fakeJira.issuesByKey["1"].Fields.Status.StatusCategory.Key)
+}
+
+func TestDryRunResolveDelay(t *testing.T) {
+	fakeJira := newTestFakeJira()
+	resolves := NewResolveScheduler(0)
+	defer func() { require.NoError(t, resolves.Stop(context.Background())) }()
+	conf := testReceiverConfig1()
+	conf.AutoResolve = &config.AutoResolve{State: "Done", Delay: config.Duration(time.Hour)}
+	opts := Options{MaxDescriptionLength: 32768}
+	now := time.Now()
+	receiver := func() *Receiver {
+		r := NewReceiver(log.NewNopLogger(), conf, template.SimpleTemplate(), fakeJira).WithResolveScheduler(resolves)
+		r.timeNow = func() time.Time { return now }
+		return r
+	}
+	data := func(status string) *alertmanager.Data {
+		return &alertmanager.Data{
+			Status:      status,
+			Alerts:      alertmanager.Alerts{{Status: status}},
+			GroupLabels: alertmanager.KV{"a": "b"},
+		}
+	}
+	strategy, err := identity.New(conf.Identity, false)
+	require.NoError(t, err)
+	key := receiver().groupKey("abc", strategy.Query(alertmanager.KV{"a": "b"}))
+
+	_, err = receiver().Notify(context.Background(), data(alertmanager.AlertFiring), opts)
+	require.NoError(t, err)
+
+	// Dry runs report when the issue would be resolved, without scheduling it.
+	res, _, err := receiver().DryRun(context.Background(), data(alertmanager.AlertResolved), opts)
+	require.NoError(t, err)
+	require.NotNil(t, res.ResolveAt)
+	require.Equal(t, now.Add(time.Hour), *res.ResolveAt)
+	require.Empty(t, res.Operations)
+	require.False(t, resolves.Pending(key))
+
+	// Dry runs report the resolutions firing again would cancel, without canceling them.
+	_, err = receiver().Notify(context.Background(), data(alertmanager.AlertResolved), opts)
+	require.NoError(t, err)
+	require.True(t, resolves.Pending(key))
+	res, _, err = receiver().DryRun(context.Background(), data(alertmanager.AlertFiring), opts)
+	require.NoError(t, err)
+	require.True(t, res.ResolveCanceled)
+	require.Nil(t, res.ResolveAt)
+	require.True(t, resolves.Pending(key))
+	require.Equal(t, "NotDone", fakeJira.issuesByKey["1"].Fields.Status.StatusCategory.Key)
+}
+
+func TestNotifyResolveDelayStore(t *testing.T) {
+	fakeJira := newTestFakeJira()
+	s := store.NewMemory(0)
+	notifications := NewNotificationLog(10)
+	conf := testReceiverConfig1()
+	conf.AutoResolve = &config.AutoResolve{State: "Done", Delay: config.Duration(time.Hour)}
+	opts := Options{MaxDescriptionLength: 32768}
+	newReceiver := func(resolves *ResolveScheduler) *Receiver {
+		return NewReceiver(log.NewNopLogger(), conf, template.SimpleTemplate(), fakeJira).WithResolveScheduler(resolves).WithNotificationLog(notifications).WithStore(s)
+	}
+	notify := func(resolves *ResolveScheduler, status string) {
+		data := &alertmanager.Data{
+			Status:      status,
+			Alerts:      alertmanager.Alerts{{Status: status}},
+			GroupLabels: alertmanager.KV{"a": "b"},
+		}
+		_, err := newReceiver(resolves).Notify(context.Background(), data, opts)
+		require.NoError(t, err)
+	}
+	strategy, err := identity.New(conf.Identity, false)
+	require.NoError(t, err)
+	key := newReceiver(nil).groupKey("abc", strategy.Query(alertmanager.KV{"a": "b"}))
+	ctx := context.Background()
+
+	resolves := NewResolveScheduler(0)
+	notify(resolves, alertmanager.AlertFiring)
+	notify(resolves, alertmanager.AlertResolved)
+	res, ok, err := s.GetResolve(ctx, key)
+	require.NoError(t, err)
+	require.True(t, ok)
+	require.Equal(t, conf.Name, res.Receiver)
+
+	// Resolutions pending before a restart are canceled by the alert group firing again.
+	require.NoError(t, resolves.Stop(ctx))
+	resolves = NewResolveScheduler(0)
+	require.NoError(t, newReceiver(resolves).RestoreResolve(key, res, opts))
+	require.True(t, resolves.Pending(key))
+	resolves.Cancel(key)
+	notify(resolves, alertmanager.AlertFiring)
+	_, ok, err = s.GetResolve(ctx, key)
+	require.NoError(t, err)
+	require.False(t, ok)
+
+	// Resolutions canceled in the store, e.g. by another replica, don't resolve the issue.
+	notify(resolves, alertmanager.AlertResolved)
+	res, _, err = s.GetResolve(ctx, key)
+	require.NoError(t, err)
+	require.NoError(t, s.DeleteResolve(ctx, key))
+	res.At = time.Now()
+	require.NoError(t, newReceiver(resolves).RestoreResolve(key, res, opts))
+	require.Eventually(t, func() bool { return !resolves.Pending(key) }, time.Second, time.Millisecond)
+	require.Equal(t, "NotDone", fakeJira.issuesByKey["1"].Fields.Status.StatusCategory.Key)
+
+	// Resolutions past due are handled right away once restored.
+	require.NoError(t, s.SetResolve(ctx, key, res))
+	require.NoError(t, newReceiver(resolves).RestoreResolve(key, res, opts))
+	require.Eventually(t, func() bool {
+		_, ok, err := s.GetResolve(ctx, key)
+		return err == nil && !ok
+	}, time.Second, time.Millisecond)
+	require.Equal(t, "Done", fakeJira.issuesByKey["1"].Fields.Status.StatusCategory.Key)
+}
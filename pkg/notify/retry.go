@@ -0,0 +1,83 @@
+// Copyright 2017 The Prometheus Authors
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package notify
+
+import (
+	"context"
+	"net"
+
+	"github.com/andygrunwald/go-jira"
+	"github.com/pkg/errors"
+	"github.com/prometheus-community/jiralert/pkg/config"
+)
+
+// Categories of the errors of Jira requests, for the jiralert_jira_api_errors_total metric.
+const (
+	errorCategoryTimeout     = "timeout"
+	errorCategoryNetwork     = "network"
+	errorCategoryRateLimited = "rate_limited"
+	errorCategoryAuth        = "auth"
+	errorCategoryClient      = "client"
+	errorCategoryServer      = "server"
+	errorCategoryOther       = "other"
+)
+
+// classifyJiraError returns the category of the error of a Jira request, and whether the request is retried with the
+// given retry settings, or the default ones if nil.
+func classifyJiraError(resp *jira.Response, err error, conf *config.RetryConfig) (string, bool) {
+	if resp != nil && resp.StatusCode/100 != 2 {
+		retry := retriesStatus(resp.StatusCode, conf)
+		switch {
+		case resp.StatusCode == 429:
+			return errorCategoryRateLimited, retry
+		case resp.StatusCode == 401 || resp.StatusCode == 403:
+			return errorCategoryAuth, retry
+		case resp.StatusCode/100 == 4:
+			return errorCategoryClient, retry
+		case resp.StatusCode/100 == 5:
+			return errorCategoryServer, retry
+		}
+		return errorCategoryOther, retry
+	}
+
+	networkErrors := conf == nil || isEnabled(conf.NetworkErrors)
+	// Alertmanager retries webhook requests timing out on its side, so should we.
+	if errors.Is(err, context.DeadlineExceeded) {
+		return errorCategoryTimeout, true
+	}
+	var netErr net.Error
+	if errors.As(err, &netErr) && netErr.Timeout() {
+		return errorCategoryTimeout, networkErrors
+	}
+	var opErr *net.OpError
+	if errors.As(err, &opErr) {
+		return errorCategoryNetwork, networkErrors
+	}
+	return errorCategoryOther, false
+}
+
+// retriesStatus reports whether Jira responses with the given status code are retried with the given retry settings,
+// or the default ones if nil.
+func retriesStatus(code int, conf *config.RetryConfig) bool {
+	statusCodes := config.DefaultRetryStatusCodes
+	if conf != nil {
+		statusCodes = conf.StatusCodes
+	}
+	for _, c := range statusCodes {
+		if c == code {
+			return true
+		}
+	}
+	return false
+}
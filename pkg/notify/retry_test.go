@@ -0,0 +1,91 @@
+// Copyright 2017 The Prometheus Authors
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+package notify
+
+import (
+	"context"
+	"io"
+	"net"
+	"net/http"
+	"net/url"
+	"strings"
+	"testing"
+
+	"github.com/andygrunwald/go-jira"
+	"github.com/go-kit/log"
+	"github.com/pkg/errors"
+	"github.com/prometheus-community/jiralert/pkg/config"
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	"github.com/stretchr/testify/require"
+)
+
+func TestClassifyJiraError(t *testing.T) {
+	noNetworkErrors := false
+	refused := errors.Wrap(&url.Error{Op: "Get", URL: "https://jira.example.com", Err: &net.OpError{Op: "dial", Net: "tcp", Err: errors.New("connection refused")}}, "No response returned")
+	timeout := errors.Wrap(&url.Error{Op: "Get", URL: "https://jira.example.com", Err: &net.DNSError{Err: "i/o timeout", IsTimeout: true}}, "No response returned")
+
+	for _, tcase := range []struct {
+		name     string
+		resp     *jira.Response
+		err      error
+		conf     *config.RetryConfig
+		category string
+		retry    bool
+	}{
+		{name: "rate limited", resp: fakeResponse(http.StatusTooManyRequests), category: errorCategoryRateLimited, retry: true},
+		{name: "bad gateway", resp: fakeResponse(http.StatusBadGateway), category: errorCategoryServer, retry: true},
+		{name: "gateway timeout", resp: fakeResponse(http.StatusGatewayTimeout), category: errorCategoryServer, retry: true},
+		{name: "not implemented", resp: fakeResponse(http.StatusNotImplemented), category: errorCategoryServer},
+		{name: "bad request", resp: fakeResponse(http.StatusBadRequest), category: errorCategoryClient},
+		{name: "forbidden", resp: fakeResponse(http.StatusForbidden), category: errorCategoryAuth},
+		{name: "configured status codes", resp: fakeResponse(http.StatusBadGateway), conf: &config.RetryConfig{StatusCodes: []int{http.StatusServiceUnavailable}}, category: errorCategoryServer},
+		{name: "deadline exceeded", err: errors.Wrap(context.DeadlineExceeded, "search"), category: errorCategoryTimeout, retry: true},
+		{name: "network timeout", err: timeout, category: errorCategoryTimeout, retry: true},
+		{name: "connection refused", err: refused, category: errorCategoryNetwork, retry: true},
+		{name: "network errors not retried", err: refused, conf: &config.RetryConfig{NetworkErrors: &noNetworkErrors}, category: errorCategoryNetwork},
+		{name: "canceled", err: context.Canceled, category: errorCategoryOther},
+		{name: "invalid response", resp: fakeResponse(http.StatusOK), err: errors.New("invalid character"), category: errorCategoryOther},
+	} {
+		t.Run(tcase.name, func(t *testing.T) {
+			category, retry := classifyJiraError(tcase.resp, tcase.err, tcase.conf)
+			require.Equal(t, tcase.category, category)
+			require.Equal(t, tcase.retry, retry)
+		})
+	}
+}
+
+func TestHandleJiraErrResponseErrorMessages(t *testing.T) {
+	resp := fakeResponse(http.StatusNotFound)
+	resp.Body = io.NopCloser(strings.NewReader(`{"errorMessages":["Issue does not exist or you do not have permission to see it."],"errors":{}}`))
+	retry, err := handleJiraErrResponse("Issue.Get", resp, errors.New("request failed"), nil, log.NewNopLogger())
+	require.False(t, retry)
+	require.Contains(t, err.Error(), "returned status Not Found: Issue does not exist or you do not have permission to see it., error")
+
+	// go-jira parses the body of some APIs itself.
+	resp = fakeResponse(http.StatusBadRequest)
+	jerr := &jira.Error{ErrorMessages: []string{"The value 'XYZ' does not exist for the field 'project'."}}
+	_, err = handleJiraErrResponse("Issue.Search", resp, jerr, nil, log.NewNopLogger())
+	require.Contains(t, err.Error(), "returned status Bad Request: The value 'XYZ' does not exist for the field 'project'., error")
+}
+
+func TestJiraAPIErrorsMetric(t *testing.T) {
+	fakeJira := newTestFakeJira()
+	fakeJira.issueTypes = map[string]bool{"Task": true}
+	client := &instrumentedClient{receiver: "jira-api-errors", next: fakeJira}
+
+	_, resp, err := client.CreateWithContext(context.Background(), &jira.Issue{Fields: &jira.IssueFields{Type: jira.IssueType{Name: "Bug"}}})
+	require.Error(t, err)
+	retry, _ := handleJiraErrResponse("Issue.Create", resp, err, nil, log.NewNopLogger())
+	require.False(t, retry)
+	require.Equal(t, 1.0, testutil.ToFloat64(jiraAPIErrors.WithLabelValues("jira-api-errors", "Issue.Create", errorCategoryClient)))
+}
@@ -0,0 +1,78 @@
+// Copyright 2017 The Prometheus Authors
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package notify
+
+import (
+	"time"
+
+	"github.com/go-kit/log/level"
+	"github.com/prometheus-community/jiralert/pkg/config"
+)
+
+// activeSchedule returns the first of the schedules active at the given time, or nil if none is.
+func activeSchedule(schedules []*config.ScheduleConfig, now time.Time) *config.ScheduleConfig {
+	for _, s := range schedules {
+		if s.Active(now) {
+			return s
+		}
+	}
+	return nil
+}
+
+// withSchedule returns the receiver with the project and priority of the first of its schedules active now, if any.
+// Alert groups keep their issue when a schedule starts or ends, as the issues of receivers with schedules are also
+// searched in the projects of the receiver and of all its schedules.
+func (r *Receiver) withSchedule() *Receiver {
+	if len(r.conf.Schedules) == 0 {
+		return r
+	}
+	conf := *r.conf
+	conf.Schedules = nil
+
+	conf.OtherProjects = append([]string{}, r.conf.OtherProjects...)
+	seen := map[string]struct{}{}
+	for _, p := range conf.OtherProjects {
+		seen[p] = struct{}{}
+	}
+	projects := []string{r.conf.Project}
+	for _, s := range r.conf.Schedules {
+		projects = append(projects, s.Project)
+	}
+	for _, p := range projects {
+		if _, ok := seen[p]; ok || p == "" || isTemplated(p) {
+			continue
+		}
+		seen[p] = struct{}{}
+		conf.OtherProjects = append(conf.OtherProjects, p)
+	}
+
+	if s := activeSchedule(r.conf.Schedules, r.timeNow()); s != nil {
+		level.Debug(r.logger).Log("msg", "applying schedule", "schedule", s.Name)
+		if s.Project != "" {
+			conf.Project = s.Project
+		}
+		if s.Priority != "" {
+			conf.Priority = s.Priority
+		}
+	}
+	sr := *r
+	sr.conf = &conf
+	return &sr
+}
+
+// At makes the receiver handle notifications as if at the given time, e.g. to preview schedules in dry runs.
+func (r *Receiver) At(t time.Time) *Receiver {
+	r.timeNow = func() time.Time { return t }
+	return r
+}
@@ -0,0 +1,74 @@
+// Copyright 2017 The Prometheus Authors
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+package notify
+
+import (
+	"testing"
+	"time"
+
+	"github.com/go-kit/log"
+	"github.com/prometheus-community/jiralert/pkg/alertmanager"
+	"github.com/prometheus-community/jiralert/pkg/config"
+	"github.com/prometheus-community/jiralert/pkg/template"
+	"github.com/stretchr/testify/require"
+	yaml "gopkg.in/yaml.v3"
+)
+
+func TestSchedules(t *testing.T) {
+	var schedules []*config.ScheduleConfig
+	require.NoError(t, yaml.Unmarshal([]byte(`
+- name: weekend
+  windows: [{weekdays: [saturday, sunday]}]
+  priority: Low
+- name: nights
+  windows: [{times: [{start_time: "20:00", end_time: "24:00"}, {start_time: "00:00", end_time: "06:00"}]}]
+  project: NOC
+  priority: High
+`), &schedules))
+
+	// Monday.
+	require.Nil(t, activeSchedule(schedules, time.Date(2023, 3, 6, 12, 0, 0, 0, time.UTC)))
+	require.Equal(t, "nights", activeSchedule(schedules, time.Date(2023, 3, 6, 22, 0, 0, 0, time.UTC)).Name)
+	// The first active schedule wins on Saturday nights.
+	require.Equal(t, "weekend", activeSchedule(schedules, time.Date(2023, 3, 4, 22, 0, 0, 0, time.UTC)).Name)
+
+	conf := testReceiverConfig1()
+	conf.Priority = "Medium"
+	conf.Schedules = schedules
+	data := &alertmanager.Data{
+		Status:      alertmanager.AlertFiring,
+		Alerts:      alertmanager.Alerts{{Status: alertmanager.AlertFiring}},
+		GroupLabels: alertmanager.KV{"a": "b"},
+	}
+	for _, tc := range []struct {
+		at       time.Time
+		schedule string
+		project  string
+		priority string
+	}{
+		{at: time.Date(2023, 3, 6, 12, 0, 0, 0, time.UTC), project: "abc", priority: "Medium"},
+		{at: time.Date(2023, 3, 6, 22, 0, 0, 0, time.UTC), schedule: "nights", project: "NOC", priority: "High"},
+		{at: time.Date(2023, 3, 5, 12, 0, 0, 0, time.UTC), schedule: "weekend", project: "abc", priority: "Low"},
+	} {
+		res, err := NewReceiver(log.NewNopLogger(), conf, template.SimpleTemplate(), nil).At(tc.at).Render(data, Options{MaxDescriptionLength: 32768})
+		require.NoError(t, err)
+		require.Equal(t, tc.schedule, res.Schedule)
+		require.Equal(t, tc.project, res.Project)
+		require.Equal(t, tc.priority, res.Priority)
+	}
+
+	// Issues are searched in the projects of all schedules, whichever is active.
+	r := NewReceiver(log.NewNopLogger(), conf, template.SimpleTemplate(), nil).At(time.Date(2023, 3, 6, 12, 0, 0, 0, time.UTC)).withSchedule()
+	require.Equal(t, []string{"abc", "NOC"}, r.conf.OtherProjects)
+	require.Empty(t, conf.OtherProjects)
+}
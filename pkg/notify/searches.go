@@ -0,0 +1,144 @@
+// Copyright 2017 The Prometheus Authors
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package notify
+
+import (
+	"context"
+	"net/http"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/andygrunwald/go-jira"
+	"github.com/go-kit/log/level"
+)
+
+type searchEntry struct {
+	// issue is the key of the issue found, empty if there was none.
+	issue   string
+	expires time.Time
+}
+
+// SearchCache remembers in memory which issue, if any, the searches of receivers with a search_cache_ttl recently
+// found for alert groups, so that repeated notifications fetch the issue by key, or skip Jira if there was none,
+// instead of searching again.
+type SearchCache struct {
+	mtx     sync.Mutex
+	entries map[string]searchEntry
+}
+
+// NewSearchCache creates an empty SearchCache.
+func NewSearchCache() *SearchCache {
+	return &SearchCache{entries: map[string]searchEntry{}}
+}
+
+// Get returns the key of the issue found for the alert group, empty if none was, and whether the result is still
+// cached at the given time.
+func (c *SearchCache) Get(group string, now time.Time) (string, bool) {
+	c.mtx.Lock()
+	defer c.mtx.Unlock()
+	e, ok := c.entries[group]
+	if !ok || !now.Before(e.expires) {
+		return "", false
+	}
+	return e.issue, true
+}
+
+// Set caches the issue found for the alert group, empty if none was, until the given time, and forgets expired
+// results.
+func (c *SearchCache) Set(group, issue string, now, expires time.Time) {
+	c.mtx.Lock()
+	defer c.mtx.Unlock()
+	for k, e := range c.entries {
+		if !now.Before(e.expires) {
+			delete(c.entries, k)
+		}
+	}
+	c.entries[group] = searchEntry{issue: issue, expires: expires}
+}
+
+// Invalidate forgets the result cached for the alert group.
+func (c *SearchCache) Invalidate(group string) {
+	c.mtx.Lock()
+	defer c.mtx.Unlock()
+	delete(c.entries, group)
+}
+
+// WithSearchCache makes receivers with a search_cache_ttl reuse the results of recent searches cached in c.
+func (r *Receiver) WithSearchCache(c *SearchCache) *Receiver {
+	r.searches = c
+	return r
+}
+
+// searchCacheEnabled returns true if search results are cached. Dry runs always search, to report the live issue.
+func (r *Receiver) searchCacheEnabled() bool {
+	return r.searches != nil && !r.dryRun && r.conf.SearchCacheTTL != nil && *r.conf.SearchCacheTTL > 0
+}
+
+// cachedSearch returns the issue recently found for the alert group, fetched again by key to get its current
+// state, or searches the projects if there is no cached result.
+func (r *Receiver) cachedSearch(ctx context.Context, project string, projects []string, groupQuery string) (*jira.Issue, bool, error) {
+	if !r.searchCacheEnabled() {
+		return r.search(ctx, projects, groupQuery)
+	}
+
+	group := r.groupKey(project, groupQuery)
+	if key, ok := r.searches.Get(group, r.timeNow()); ok {
+		if key == "" {
+			level.Debug(r.logger).Log("msg", "no issue found recently, skipping search", "query", groupQuery)
+			searchCacheHits.WithLabelValues(r.conf.Name).Inc()
+			return nil, false, nil
+		}
+		issue, resp, err := r.client.GetWithContext(ctx, key, &jira.GetQueryOptions{Fields: strings.Join(r.searchFields(), ",")})
+		if err == nil {
+			level.Debug(r.logger).Log("msg", "found recently, skipping search", "key", issue.Key, "query", groupQuery)
+			searchCacheHits.WithLabelValues(r.conf.Name).Inc()
+			return issue, false, nil
+		}
+		if resp == nil || resp.StatusCode != http.StatusNotFound {
+			retry, err := handleJiraErrResponse("Issue.Get", resp, err, r.conf.Retry, r.logger)
+			return nil, retry, err
+		}
+		level.Debug(r.logger).Log("msg", "recently found issue no longer exists, searching", "key", key, "query", groupQuery)
+		r.searches.Invalidate(group)
+	}
+
+	issue, retry, err := r.search(ctx, projects, groupQuery)
+	if err != nil {
+		return nil, retry, err
+	}
+	key := ""
+	if issue != nil {
+		key = issue.Key
+	}
+	r.cacheSearch(project, groupQuery, key)
+	return issue, false, nil
+}
+
+// cacheSearch records that issueKey, or no issue if empty, is the issue of the alert group.
+func (r *Receiver) cacheSearch(project, groupQuery, issueKey string) {
+	if !r.searchCacheEnabled() {
+		return
+	}
+	now := r.timeNow()
+	r.searches.Set(r.groupKey(project, groupQuery), issueKey, now, now.Add(time.Duration(*r.conf.SearchCacheTTL)))
+}
+
+// invalidateSearch makes the next notification of the alert group search again.
+func (r *Receiver) invalidateSearch(project, groupQuery string) {
+	if !r.searchCacheEnabled() {
+		return
+	}
+	r.searches.Invalidate(r.groupKey(project, groupQuery))
+}
@@ -0,0 +1,101 @@
+// Copyright 2017 The Prometheus Authors
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+package notify
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/andygrunwald/go-jira"
+	"github.com/go-kit/log"
+	"github.com/prometheus-community/jiralert/pkg/alertmanager"
+	"github.com/prometheus-community/jiralert/pkg/config"
+	"github.com/prometheus-community/jiralert/pkg/template"
+	"github.com/stretchr/testify/require"
+)
+
+func TestSearchCache(t *testing.T) {
+	c := NewSearchCache()
+	now := time.Now()
+	_, ok := c.Get("a", now)
+	require.False(t, ok)
+
+	c.Set("a", "ABC-1", now, now.Add(time.Minute))
+	c.Set("b", "", now, now.Add(time.Minute))
+	key, ok := c.Get("a", now)
+	require.True(t, ok)
+	require.Equal(t, "ABC-1", key)
+	key, ok = c.Get("b", now)
+	require.True(t, ok)
+	require.Equal(t, "", key)
+
+	_, ok = c.Get("a", now.Add(time.Minute))
+	require.False(t, ok)
+
+	c.Invalidate("b")
+	_, ok = c.Get("b", now)
+	require.False(t, ok)
+}
+
+func TestNotifySearchCache(t *testing.T) {
+	fakeJira := newTestFakeJira()
+	searches := NewSearchCache()
+	conf := testReceiverConfig1()
+	ttl := config.Duration(5 * time.Minute)
+	conf.SearchCacheTTL = &ttl
+	conf.AutoResolve = &config.AutoResolve{State: "Done"}
+	opts := Options{MaxDescriptionLength: 32768, UpdateSummary: true}
+	start := time.Now()
+	notifyAt := func(d time.Duration, labels alertmanager.KV, alerts int) {
+		data := &alertmanager.Data{Status: alertmanager.AlertFiring, GroupLabels: labels}
+		for i := 0; i < alerts; i++ {
+			data.Alerts = append(data.Alerts, alertmanager.Alert{Status: alertmanager.AlertFiring})
+		}
+		if alerts == 0 {
+			data.Status = alertmanager.AlertResolved
+			data.Alerts = alertmanager.Alerts{{Status: alertmanager.AlertResolved}}
+		}
+		r := NewReceiver(log.NewNopLogger(), conf, template.SimpleTemplate(), fakeJira).WithSearchCache(searches)
+		r.timeNow = func() time.Time { return start.Add(d) }
+		_, err := r.Notify(context.Background(), data, opts)
+		require.NoError(t, err)
+	}
+	ab := alertmanager.KV{"a": "b"}
+
+	notifyAt(0, ab, 1)
+	require.Len(t, fakeJira.issuesByKey, 1)
+	// The search for an existing issue, and the check for duplicates.
+	require.Equal(t, 2, fakeJira.searches)
+
+	// The created issue is fetched by key and updated without searching.
+	notifyAt(time.Minute, ab, 2)
+	require.Equal(t, 2, fakeJira.searches)
+	require.Equal(t, "[FIRING:2] b ", fakeJira.issuesByKey["1"].Fields.Summary)
+
+	// Resolving the issue makes the next notification search again.
+	notifyAt(2*time.Minute, ab, 0)
+	require.Equal(t, "Done", fakeJira.issuesByKey["1"].Fields.Status.StatusCategory.Key)
+	notifyAt(3*time.Minute, ab, 1)
+	require.Equal(t, 3, fakeJira.searches)
+
+	// A deleted issue is searched again, and its absence cached until the TTL expires.
+	fakeJira.issuesByKey = map[string]*jira.Issue{}
+	fakeJira.keysByQuery = map[string][]string{}
+	notifyAt(4*time.Minute, ab, 0)
+	require.Equal(t, 4, fakeJira.searches)
+	notifyAt(8*time.Minute, ab, 0)
+	require.Equal(t, 4, fakeJira.searches)
+	notifyAt(9*time.Minute, ab, 0)
+	require.Equal(t, 5, fakeJira.searches)
+}
@@ -0,0 +1,224 @@
+// Copyright 2017 The Prometheus Authors
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package notify
+
+import (
+	"github.com/prometheus-community/jiralert/pkg/alertmanager"
+	"github.com/prometheus-community/jiralert/pkg/config"
+)
+
+// Options are the global notification settings, as given on the command line. Receivers may override each of them.
+type Options struct {
+	HashJiraLabel        bool `json:"hash_jira_label"`
+	UpdateSummary        bool `json:"update_summary"`
+	UpdateDescription    bool `json:"update_description"`
+	ReopenTickets        bool `json:"reopen_tickets"`
+	MaxDescriptionLength int  `json:"max_description_length"`
+}
+
+// Merge returns a copy of o with the overrides defined in the receiver configuration applied.
+func (o Options) Merge(c *config.ReceiverConfig) Options {
+	if c.HashJiraLabel != nil {
+		o.HashJiraLabel = *c.HashJiraLabel
+	}
+	if c.UpdateSummary != nil {
+		o.UpdateSummary = *c.UpdateSummary
+	}
+	if c.UpdateDescription != nil {
+		o.UpdateDescription = *c.UpdateDescription
+	}
+	if c.ReopenTickets != nil {
+		o.ReopenTickets = *c.ReopenTickets
+	}
+	if c.MaxDescriptionLength != nil {
+		o.MaxDescriptionLength = *c.MaxDescriptionLength
+	}
+	return o
+}
+
+// EffectiveSettings are the behavioral settings a receiver applies when handling notifications, after merging
+// command line flags, configuration defaults and receiver overrides.
+type EffectiveSettings struct {
+	Receiver string `json:"receiver"`
+	Options
+
+	Project      string   `json:"project"`
+	IssueType    string   `json:"issue_type"`
+	Priority     string   `json:"priority,omitempty"`
+	StaticLabels []string `json:"static_labels,omitempty"`
+	// Schedule is the name of the schedule of the receiver active at the time the settings apply to, if any.
+	Schedule string `json:"schedule,omitempty"`
+
+	Renderer                   string   `json:"renderer"`
+	UpdateInComment            bool     `json:"update_in_comment"`
+	MaxCommentLength           int      `json:"max_comment_length"`
+	CommentOverflow            string   `json:"comment_overflow"`
+	AddGroupLabels             bool     `json:"add_group_labels"`
+	SyncGroupLabels            bool     `json:"sync_group_labels"`
+	StatusLabel                bool     `json:"status_label"`
+	SetMissingPriority         bool     `json:"set_missing_priority"`
+	FreezeInProgress           bool     `json:"freeze_in_progress"`
+	ManagedDescription         bool     `json:"managed_description"`
+	TruncatedAlertsComment     bool     `json:"truncated_alerts_comment"`
+	MaxAlertsInDescription     int      `json:"max_alerts_in_description,omitempty"`
+	AlertOrderSortBy           []string `json:"alert_order_sort_by,omitempty"`
+	AlertOrderLimit            int      `json:"alert_order_limit,omitempty"`
+	CommentOnTransitionFailure bool     `json:"comment_on_transition_failure"`
+	TemplateStrict             bool     `json:"template_strict"`
+	DefaultTimezone            string   `json:"default_timezone"`
+	ReopenState                string   `json:"reopen_state"`
+	ReopenDuration             string   `json:"reopen_duration"`
+	MinUpdateInterval          string   `json:"min_update_interval"`
+	SearchCacheTTL             string   `json:"search_cache_ttl"`
+	TransitionCacheTTL         string   `json:"transition_cache_ttl"`
+	BulkCreateWait             string   `json:"bulk_create_wait"`
+	WontFixResolution          string   `json:"wont_fix_resolution,omitempty"`
+	WontFixSilence             string   `json:"wont_fix_silence,omitempty"`
+	IgnoreLabels               []string `json:"ignore_labels,omitempty"`
+	IgnoreStatuses             []string `json:"ignore_statuses,omitempty"`
+	AutoResolveState           string   `json:"auto_resolve_state,omitempty"`
+	AutoResolveDelay           string   `json:"auto_resolve_delay,omitempty"`
+	AutoResolveWorklog         bool     `json:"auto_resolve_worklog,omitempty"`
+	FlapReopens                int      `json:"flap_reopens,omitempty"`
+	FlapWindow                 string   `json:"flap_window,omitempty"`
+	EscalationPriorities       []string `json:"escalation_priorities,omitempty"`
+	EscalationAfter            []string `json:"escalation_after,omitempty"`
+	StaleIssuesAfter           string   `json:"stale_issues_after,omitempty"`
+	StaleIssuesState           string   `json:"stale_issues_state,omitempty"`
+	AlertmanagerURL            string   `json:"alertmanager_url,omitempty"`
+	PrometheusURL              string   `json:"prometheus_url,omitempty"`
+	GraphURL                   string   `json:"graph_url,omitempty"`
+	RunbookLinkAnnotation      string   `json:"runbook_link_annotation,omitempty"`
+	AlertmanagerLinkURL        string   `json:"alertmanager_link_url,omitempty"`
+	ManagedFields              []string `json:"managed_fields,omitempty"`
+}
+
+// NewEffectiveSettings computes the effective settings of the given receiver.
+func NewEffectiveSettings(c *config.ReceiverConfig, opts Options) EffectiveSettings {
+	s := EffectiveSettings{
+		Receiver:                   c.Name,
+		Options:                    opts.Merge(c),
+		Project:                    c.Project,
+		IssueType:                  c.IssueType,
+		Priority:                   c.Priority,
+		StaticLabels:               c.StaticLabels,
+		Renderer:                   config.RendererWiki,
+		UpdateInComment:            isEnabled(c.UpdateInComment),
+		MaxCommentLength:           defaultMaxCommentLength,
+		CommentOverflow:            config.CommentOverflowSplit,
+		AddGroupLabels:             isEnabled(c.AddGroupLabels),
+		SyncGroupLabels:            isEnabled(c.SyncGroupLabels),
+		StatusLabel:                isEnabled(c.StatusLabel),
+		SetMissingPriority:         isEnabled(c.SetMissingPriority),
+		FreezeInProgress:           isEnabled(c.FreezeInProgress),
+		ManagedDescription:         isEnabled(c.ManagedDescription),
+		TruncatedAlertsComment:     isEnabled(c.TruncatedAlertsComment),
+		CommentOnTransitionFailure: isEnabled(c.CommentOnTransitionFailure),
+		TemplateStrict:             isEnabled(c.TemplateStrict),
+		DefaultTimezone:            "UTC",
+		MinUpdateInterval:          "0s",
+		SearchCacheTTL:             "0s",
+		TransitionCacheTTL:         "0s",
+		BulkCreateWait:             "0s",
+		ReopenState:                c.ReopenState,
+		WontFixResolution:          c.WontFixResolution,
+		IgnoreLabels:               c.IgnoreLabels,
+		IgnoreStatuses:             c.IgnoreStatuses,
+		AlertmanagerURL:            c.AlertmanagerURL,
+		PrometheusURL:              c.PrometheusURL,
+	}
+	if c.Renderer != "" {
+		s.Renderer = c.Renderer
+	}
+	if c.DefaultTimezone != "" {
+		s.DefaultTimezone = c.DefaultTimezone
+	}
+	if c.MaxCommentLength != nil {
+		s.MaxCommentLength = *c.MaxCommentLength
+	}
+	if c.MaxAlertsInDescription != nil {
+		s.MaxAlertsInDescription = *c.MaxAlertsInDescription
+	}
+	if c.CommentOverflow != "" {
+		s.CommentOverflow = c.CommentOverflow
+	}
+	if c.ReopenDuration != nil {
+		s.ReopenDuration = c.ReopenDuration.String()
+	}
+	if c.MinUpdateInterval != nil {
+		s.MinUpdateInterval = c.MinUpdateInterval.String()
+	}
+	if c.SearchCacheTTL != nil {
+		s.SearchCacheTTL = c.SearchCacheTTL.String()
+	}
+	if c.TransitionCacheTTL != nil {
+		s.TransitionCacheTTL = c.TransitionCacheTTL.String()
+	}
+	if c.BulkCreateWait != nil {
+		s.BulkCreateWait = c.BulkCreateWait.String()
+	}
+	if c.AutoResolve != nil {
+		s.AutoResolveState = c.AutoResolve.State
+		s.AutoResolveDelay = c.AutoResolve.Delay.String()
+		s.AutoResolveWorklog = c.AutoResolve.Worklog
+	}
+	if c.AlertOrder != nil {
+		s.AlertOrderSortBy = c.AlertOrder.SortBy
+		s.AlertOrderLimit = c.AlertOrder.Limit
+	}
+	if c.FlapDetection != nil {
+		s.FlapReopens = c.FlapDetection.Reopens
+		s.FlapWindow = c.FlapDetection.Window.String()
+	}
+	if c.Escalation != nil {
+		s.EscalationPriorities = c.Escalation.Priorities
+		for _, d := range c.Escalation.After {
+			s.EscalationAfter = append(s.EscalationAfter, d.String())
+		}
+	}
+	if c.Graph != nil {
+		s.GraphURL = c.Graph.URL
+	}
+	if c.RunbookLink != nil {
+		s.RunbookLinkAnnotation = c.RunbookLink.Annotation
+	}
+	if c.AlertmanagerLink != nil {
+		s.AlertmanagerLinkURL = c.AlertmanagerLink.URL
+	}
+	if c.WontFixSilence != nil {
+		s.WontFixSilence = c.WontFixSilence.Duration.String()
+	}
+	if c.StaleIssues != nil {
+		s.StaleIssuesAfter = c.StaleIssues.After.String()
+		s.StaleIssuesState = c.StaleIssues.State
+	}
+	if c.ManagedFields != nil {
+		s.ManagedFields = c.ManagedFields.IDs()
+	}
+	return s
+}
+
+// EffectiveSettings returns the settings the receiver applies to notifications with the given common labels, at the
+// time set with At if any, after applying the presets and schedules matching them like notifications do.
+func (r *Receiver) EffectiveSettings(commonLabels alertmanager.KV, opts Options) EffectiveSettings {
+	s := NewEffectiveSettings(r.configured(&alertmanager.Data{CommonLabels: commonLabels}).conf, opts)
+	if sc := activeSchedule(r.conf.Schedules, r.timeNow()); sc != nil {
+		s.Schedule = sc.Name
+	}
+	return s
+}
+
+func isEnabled(b *bool) bool {
+	return b != nil && *b
+}
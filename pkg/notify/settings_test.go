@@ -0,0 +1,188 @@
+// Copyright 2017 The Prometheus Authors
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+package notify
+
+import (
+	"testing"
+	"time"
+
+	"github.com/go-kit/log"
+	"github.com/prometheus-community/jiralert/pkg/alertmanager"
+	"github.com/prometheus-community/jiralert/pkg/config"
+	"github.com/prometheus-community/jiralert/pkg/template"
+	"github.com/stretchr/testify/require"
+	yaml "gopkg.in/yaml.v3"
+)
+
+// testFlags are the options given on the command line.
+var testFlags = Options{HashJiraLabel: true, UpdateSummary: true, UpdateDescription: true, ReopenTickets: false, MaxDescriptionLength: 32768}
+
+func TestOptionsMerge(t *testing.T) {
+	enabled, disabled, length := true, false, 1000
+	for _, tc := range []struct {
+		name     string
+		conf     *config.ReceiverConfig
+		expected Options
+	}{
+		{
+			name:     "flags",
+			conf:     &config.ReceiverConfig{},
+			expected: testFlags,
+		},
+		{
+			name: "receiver overrides",
+			conf: &config.ReceiverConfig{
+				HashJiraLabel:        &disabled,
+				UpdateSummary:        &disabled,
+				UpdateDescription:    &disabled,
+				ReopenTickets:        &enabled,
+				MaxDescriptionLength: &length,
+			},
+			expected: Options{HashJiraLabel: false, UpdateSummary: false, UpdateDescription: false, ReopenTickets: true, MaxDescriptionLength: 1000},
+		},
+		{
+			name:     "partial override",
+			conf:     &config.ReceiverConfig{ReopenTickets: &enabled},
+			expected: Options{HashJiraLabel: true, UpdateSummary: true, UpdateDescription: true, ReopenTickets: true, MaxDescriptionLength: 32768},
+		},
+		{
+			// Overriding with the value of the flag is not a change.
+			name:     "override with flag value",
+			conf:     &config.ReceiverConfig{UpdateSummary: &enabled},
+			expected: testFlags,
+		},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			require.Equal(t, tc.expected, testFlags.Merge(tc.conf))
+		})
+	}
+}
+
+func TestNewEffectiveSettings(t *testing.T) {
+	// The defaults section applies to receivers not overriding it, and both take precedence over the flags.
+	cfg, err := config.Load(`
+defaults:
+  api_url: https://jiralert.atlassian.net
+  user: jiralert
+  password: JIRAlert
+  issue_type: Bug
+  summary: summary
+  reopen_state: "To Do"
+  reopen_duration: 0h
+  update_summary: false
+  min_update_interval: 10m
+receivers:
+  - name: 'jira-defaults'
+    project: SRE
+  - name: 'jira-overrides'
+    project: SRE
+    update_summary: true
+    reopen_tickets: true
+    max_description_length: 1000
+    min_update_interval: 1h
+    renderer: markdown
+    auto_resolve:
+      state: Done
+      delay: 5m
+template: jiralert.tmpl
+`)
+	require.NoError(t, err)
+
+	for _, tc := range []struct {
+		receiver string
+		check    func(t *testing.T, s EffectiveSettings)
+	}{
+		{
+			receiver: "jira-defaults",
+			check: func(t *testing.T, s EffectiveSettings) {
+				require.Equal(t, Options{HashJiraLabel: true, UpdateSummary: false, UpdateDescription: true, ReopenTickets: false, MaxDescriptionLength: 32768}, s.Options)
+				require.Equal(t, "SRE", s.Project)
+				require.Equal(t, "Bug", s.IssueType)
+				require.Equal(t, config.RendererWiki, s.Renderer)
+				require.Equal(t, "10m", s.MinUpdateInterval)
+				require.Equal(t, "0s", s.SearchCacheTTL)
+				require.Equal(t, "UTC", s.DefaultTimezone)
+				require.Equal(t, defaultMaxCommentLength, s.MaxCommentLength)
+				require.Empty(t, s.AutoResolveState)
+			},
+		},
+		{
+			receiver: "jira-overrides",
+			check: func(t *testing.T, s EffectiveSettings) {
+				require.Equal(t, Options{HashJiraLabel: true, UpdateSummary: true, UpdateDescription: true, ReopenTickets: true, MaxDescriptionLength: 1000}, s.Options)
+				require.Equal(t, "markdown", s.Renderer)
+				require.Equal(t, "1h", s.MinUpdateInterval)
+				require.Equal(t, "Done", s.AutoResolveState)
+				require.Equal(t, "5m", s.AutoResolveDelay)
+			},
+		},
+	} {
+		t.Run(tc.receiver, func(t *testing.T) {
+			s := NewEffectiveSettings(cfg.ReceiverByName(tc.receiver), testFlags)
+			require.Equal(t, tc.receiver, s.Receiver)
+			tc.check(t, s)
+		})
+	}
+}
+
+func TestReceiverEffectiveSettings(t *testing.T) {
+	conf := testReceiverConfig1()
+	conf.IssueType = "Task"
+	conf.Priority = "Medium"
+	require.NoError(t, yaml.Unmarshal([]byte(`
+- matchers: ['severity="critical"']
+  issue_type: Bug
+  static_labels: [critical]
+`), &conf.Presets))
+	require.NoError(t, yaml.Unmarshal([]byte(`
+- name: nights
+  windows: [{times: [{start_time: "20:00", end_time: "24:00"}]}]
+  project: NOC
+  priority: High
+`), &conf.Schedules))
+
+	day, night := time.Date(2023, 3, 6, 12, 0, 0, 0, time.UTC), time.Date(2023, 3, 6, 22, 0, 0, 0, time.UTC)
+	for _, tc := range []struct {
+		name         string
+		commonLabels alertmanager.KV
+		at           time.Time
+		expected     EffectiveSettings
+	}{
+		{
+			name:     "receiver",
+			at:       day,
+			expected: EffectiveSettings{Project: "abc", IssueType: "Task", Priority: "Medium"},
+		},
+		{
+			name:         "preset",
+			commonLabels: alertmanager.KV{"severity": "critical"},
+			at:           day,
+			expected:     EffectiveSettings{Project: "abc", IssueType: "Bug", Priority: "Medium", StaticLabels: []string{"critical"}},
+		},
+		{
+			name:         "preset and schedule",
+			commonLabels: alertmanager.KV{"severity": "critical"},
+			at:           night,
+			expected:     EffectiveSettings{Project: "NOC", IssueType: "Bug", Priority: "High", StaticLabels: []string{"critical"}, Schedule: "nights"},
+		},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			s := NewReceiver(log.NewNopLogger(), conf, template.SimpleTemplate(), nil).At(tc.at).EffectiveSettings(tc.commonLabels, testFlags)
+			require.Equal(t, tc.expected.Project, s.Project)
+			require.Equal(t, tc.expected.IssueType, s.IssueType)
+			require.Equal(t, tc.expected.Priority, s.Priority)
+			require.Equal(t, tc.expected.StaticLabels, s.StaticLabels)
+			require.Equal(t, tc.expected.Schedule, s.Schedule)
+		})
+	}
+}
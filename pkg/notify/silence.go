@@ -0,0 +1,58 @@
+// Copyright 2017 The Prometheus Authors
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package notify
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/andygrunwald/go-jira"
+	"github.com/go-kit/log/level"
+	"github.com/prometheus-community/jiralert/pkg/alertmanager"
+)
+
+// silenceClient creates silences in Alertmanager.
+var silenceClient = &http.Client{Timeout: 10 * time.Second}
+
+// silenceWontFix silences the alert group of the issue resolved as won't fix in Alertmanager, if configured, so that
+// it stops being notified, and comments the link to the silence on the issue. Failures are logged, as the issue is
+// left alone either way.
+func (r *Receiver) silenceWontFix(ctx context.Context, issue *jira.Issue, data *alertmanager.Data) {
+	c := r.conf.WontFixSilence
+	if c == nil || r.conf.AlertmanagerURL == "" || r.dryRun || len(data.GroupLabels) == 0 {
+		return
+	}
+	now := r.timeNow()
+	id, err := alertmanager.CreateSilence(ctx, silenceClient, r.conf.AlertmanagerURL, alertmanager.Silence{
+		Labels:    data.GroupLabels,
+		StartsAt:  now,
+		EndsAt:    now.Add(time.Duration(c.Duration)),
+		CreatedBy: c.CreatedBy,
+		Comment:   fmt.Sprintf("Issue %s was resolved as %s.", issue.Key, r.conf.WontFixResolution),
+	})
+	if err != nil {
+		level.Error(r.logger).Log("msg", "failed to silence alert group of issue resolved as won't fix", "key", issue.Key, "url", r.conf.AlertmanagerURL, "err", err)
+		return
+	}
+	level.Info(r.logger).Log("msg", "silenced alert group of issue resolved as won't fix", "key", issue.Key, "silence", id, "duration", c.Duration)
+	silencesCreated.WithLabelValues(r.conf.Name).Inc()
+
+	comment := fmt.Sprintf("Silenced the alerts in Alertmanager until %s: %s/#/silences/%s", now.Add(time.Duration(c.Duration)).UTC().Format(time.RFC3339), strings.TrimSuffix(r.conf.AlertmanagerURL, "/"), id)
+	if _, err := r.addComment(ctx, issue.Key, comment); err != nil {
+		level.Warn(r.logger).Log("msg", "failed to comment silence on issue", "key", issue.Key, "err", err)
+	}
+}
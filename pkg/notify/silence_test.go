@@ -0,0 +1,82 @@
+// Copyright 2017 The Prometheus Authors
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+package notify
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/andygrunwald/go-jira"
+	"github.com/go-kit/log"
+	"github.com/prometheus-community/jiralert/pkg/alertmanager"
+	"github.com/prometheus-community/jiralert/pkg/config"
+	"github.com/prometheus-community/jiralert/pkg/template"
+	"github.com/stretchr/testify/require"
+)
+
+func TestNotifyWontFixSilence(t *testing.T) {
+	var silences []map[string]interface{}
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		var s map[string]interface{}
+		require.NoError(t, json.NewDecoder(req.Body).Decode(&s))
+		silences = append(silences, s)
+		_, _ = w.Write([]byte(`{"silenceID": "abc"}`))
+	}))
+	defer srv.Close()
+
+	fakeJira := newTestFakeJira()
+	conf := testReceiverConfig1()
+	conf.AlertmanagerURL = srv.URL
+	conf.WontFixSilence = &config.WontFixSilenceConfig{Duration: config.Duration(24 * time.Hour), CreatedBy: "jiralert"}
+	opts := Options{MaxDescriptionLength: 32768, ReopenTickets: true}
+	now := time.Date(2023, 5, 1, 10, 0, 0, 0, time.UTC)
+	data := &alertmanager.Data{
+		Status:      alertmanager.AlertFiring,
+		Alerts:      alertmanager.Alerts{{Status: alertmanager.AlertFiring}},
+		GroupLabels: alertmanager.KV{"a": "b"},
+	}
+	notify := func() {
+		r := NewReceiver(log.NewNopLogger(), conf, template.SimpleTemplate(), fakeJira)
+		r.timeNow = func() time.Time { return now }
+		_, err := r.Notify(context.Background(), data, opts)
+		require.NoError(t, err)
+	}
+
+	notify()
+	require.Empty(t, silences)
+
+	issue := fakeJira.issuesByKey["1"]
+	issue.Fields.Status.StatusCategory.Key = "done"
+	issue.Fields.Resolution = &jira.Resolution{Name: conf.WontFixResolution}
+	issue.Fields.Resolutiondate = jira.Time(now.Add(-time.Minute))
+	notify()
+	require.Equal(t, "done", issue.Fields.Status.StatusCategory.Key)
+	require.Len(t, silences, 1)
+	require.Equal(t, []interface{}{map[string]interface{}{"name": "a", "value": "b", "isRegex": false, "isEqual": true}}, silences[0]["matchers"])
+	require.Equal(t, "2023-05-02T10:00:00Z", silences[0]["endsAt"])
+	require.Equal(t, "jiralert", silences[0]["createdBy"])
+	require.Equal(t, "Issue 1 was resolved as won't-fix.", silences[0]["comment"])
+	require.Len(t, issue.Fields.Comments.Comments, 1)
+	require.Equal(t, "Silenced the alerts in Alertmanager until 2023-05-02T10:00:00Z: "+srv.URL+"/#/silences/abc", issue.Fields.Comments.Comments[0].Body)
+
+	// Dry runs don't silence alert groups.
+	r := NewReceiver(log.NewNopLogger(), conf, template.SimpleTemplate(), fakeJira)
+	r.timeNow = func() time.Time { return now }
+	_, _, err := r.DryRun(context.Background(), data, opts)
+	require.NoError(t, err)
+	require.Len(t, silences, 1)
+}
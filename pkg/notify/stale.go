@@ -0,0 +1,196 @@
+// Copyright 2017 The Prometheus Authors
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package notify
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/andygrunwald/go-jira"
+	"github.com/go-kit/log/level"
+	"github.com/pkg/errors"
+	"github.com/prometheus-community/jiralert/pkg/alertmanager"
+)
+
+// StaleIssue is an open issue of an alert group, as last notified firing.
+type StaleIssue struct {
+	Receiver    string
+	Key         string
+	Project     string
+	GroupQuery  string
+	GroupLabels alertmanager.KV
+	Seen        time.Time
+}
+
+// StaleTracker remembers in memory when the issues of receivers with stale_issues or an alertmanager_url were last
+// notified firing, until their alert groups are notified resolved. Issues are forgotten on restart, and then left open
+// until notified again.
+type StaleTracker struct {
+	mtx    sync.Mutex
+	issues map[string]StaleIssue
+}
+
+// NewStaleTracker creates an empty StaleTracker.
+func NewStaleTracker() *StaleTracker {
+	return &StaleTracker{issues: map[string]StaleIssue{}}
+}
+
+// Seen records that the issue of the alert group identified by group was notified firing.
+func (t *StaleTracker) Seen(group string, issue StaleIssue) {
+	t.mtx.Lock()
+	defer t.mtx.Unlock()
+	t.issues[group] = issue
+}
+
+// Forget forgets the issue of the alert group, unless it was notified firing again after seen. Zero seen forgets it
+// regardless.
+func (t *StaleTracker) Forget(group string, seen time.Time) {
+	t.mtx.Lock()
+	defer t.mtx.Unlock()
+	if issue, ok := t.issues[group]; ok && (seen.IsZero() || !issue.Seen.After(seen)) {
+		delete(t.issues, group)
+	}
+}
+
+// Stale returns the issues of the receiver last notified firing at least after ago, by alert group.
+func (t *StaleTracker) Stale(receiver string, now time.Time, after time.Duration) map[string]StaleIssue {
+	t.mtx.Lock()
+	defer t.mtx.Unlock()
+	stale := map[string]StaleIssue{}
+	for group, issue := range t.issues {
+		if issue.Receiver == receiver && !now.Before(issue.Seen.Add(after)) {
+			stale[group] = issue
+		}
+	}
+	return stale
+}
+
+// Firing returns the issues of the receiver whose alert groups were last notified firing, by alert group.
+func (t *StaleTracker) Firing(receiver string) map[string]StaleIssue {
+	t.mtx.Lock()
+	defer t.mtx.Unlock()
+	firing := map[string]StaleIssue{}
+	for group, issue := range t.issues {
+		if issue.Receiver == receiver {
+			firing[group] = issue
+		}
+	}
+	return firing
+}
+
+// stillStale returns true if the issue of the alert group was not notified firing since seen.
+func (t *StaleTracker) stillStale(group string, seen time.Time) bool {
+	t.mtx.Lock()
+	defer t.mtx.Unlock()
+	issue, ok := t.issues[group]
+	return ok && !issue.Seen.After(seen)
+}
+
+// WithStaleTracker makes receivers with stale_issues or an alertmanager_url track in t when their issues were last
+// notified firing.
+func (r *Receiver) WithStaleTracker(t *StaleTracker) *Receiver {
+	r.stale = t
+	return r
+}
+
+// trackStale records the issue of a firing alert group as notified, or forgets the issue of a resolved one.
+func (r *Receiver) trackStale(project, groupQuery string, data *alertmanager.Data) {
+	if r.stale == nil || (r.conf.StaleIssues == nil && r.conf.AlertmanagerURL == "") || r.dryRun || r.handled == nil {
+		return
+	}
+	group := r.groupKey(project, groupQuery)
+	if !data.Alerts.HasFiring() {
+		r.stale.Forget(group, time.Time{})
+		return
+	}
+	if r.handled.IssueKey == "" {
+		return
+	}
+	r.stale.Seen(group, StaleIssue{
+		Receiver:    r.conf.Name,
+		Key:         r.handled.IssueKey,
+		Project:     project,
+		GroupQuery:  groupQuery,
+		GroupLabels: data.GroupLabels,
+		Seen:        r.timeNow(),
+	})
+}
+
+// CloseStale transitions the open issues of alert groups not notified firing for the stale_issues after duration
+// into its state, with a comment, returning how many were closed. Issues failing to be closed are tried again on the
+// next call.
+func (r *Receiver) CloseStale(ctx context.Context) (int, error) {
+	sc := r.conf.StaleIssues
+	if r.stale == nil || sc == nil {
+		return 0, nil
+	}
+	closed := 0
+	var errs []string
+	for group, issue := range r.stale.Stale(r.conf.Name, r.timeNow(), time.Duration(sc.After)) {
+		ok, err := r.closeStale(ctx, group, issue)
+		if err != nil {
+			errs = append(errs, fmt.Sprintf("%s: %s", issue.Key, err))
+			continue
+		}
+		if ok {
+			closed++
+		}
+	}
+	if len(errs) > 0 {
+		return closed, errors.Errorf("failed to close stale issues: %s", strings.Join(errs, "; "))
+	}
+	return closed, nil
+}
+
+func (r *Receiver) closeStale(ctx context.Context, group string, stale StaleIssue) (bool, error) {
+	unlock, err := r.lockGroup(ctx, stale.Project, stale.GroupQuery)
+	if err != nil {
+		return false, err
+	}
+	defer unlock()
+	// The alert group may have been notified while waiting for the lock.
+	if !r.stale.stillStale(group, stale.Seen) {
+		return false, nil
+	}
+
+	issue, resp, err := r.client.GetWithContext(ctx, stale.Key, &jira.GetQueryOptions{Fields: strings.Join(r.searchFields(), ",")})
+	if err != nil {
+		_, err = handleJiraErrResponse("Issue.Get", resp, err, r.conf.Retry, r.logger)
+		return false, err
+	}
+	if issue.Fields.Status == nil || issue.Fields.Status.StatusCategory.Key == "done" || r.ignored(issue) {
+		r.stale.Forget(group, stale.Seen)
+		return false, nil
+	}
+
+	since := r.timeNow().Sub(stale.Seen).Round(time.Minute)
+	level.Info(r.logger).Log("msg", "alert group not notified firing for too long, closing stale issue", "key", issue.Key, "query", stale.GroupQuery, "since", since)
+	comment := r.conf.StaleIssues.Comment
+	if comment == "" {
+		comment = fmt.Sprintf("Alert group not notified for %s, closing the issue as stale. The resolved notification may have been lost.", since)
+	}
+	if _, err := r.addComment(ctx, issue.Key, comment); err != nil {
+		return false, err
+	}
+	if _, err := r.doTransition(ctx, issue, r.conf.StaleIssues.State, "Alert group went stale but closing the issue failed"); err != nil {
+		return false, err
+	}
+	r.stale.Forget(group, stale.Seen)
+	r.invalidateSearch(stale.Project, stale.GroupQuery)
+	staleIssuesClosed.WithLabelValues(r.conf.Name).Inc()
+	return true, nil
+}
@@ -0,0 +1,97 @@
+// Copyright 2017 The Prometheus Authors
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+package notify
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/go-kit/log"
+	"github.com/prometheus-community/jiralert/pkg/alertmanager"
+	"github.com/prometheus-community/jiralert/pkg/config"
+	"github.com/prometheus-community/jiralert/pkg/template"
+	"github.com/stretchr/testify/require"
+)
+
+func TestStaleTracker(t *testing.T) {
+	tr := NewStaleTracker()
+	now := time.Now()
+	tr.Seen("a", StaleIssue{Receiver: "r1", Key: "1", Seen: now})
+	tr.Seen("b", StaleIssue{Receiver: "r1", Key: "2", Seen: now.Add(time.Hour)})
+	tr.Seen("c", StaleIssue{Receiver: "r2", Key: "3", Seen: now})
+
+	require.Empty(t, tr.Stale("r1", now.Add(time.Hour-time.Second), time.Hour))
+	require.Equal(t, map[string]StaleIssue{"a": {Receiver: "r1", Key: "1", Seen: now}}, tr.Stale("r1", now.Add(time.Hour), time.Hour))
+
+	// Issues notified again are not forgotten.
+	tr.Seen("a", StaleIssue{Receiver: "r1", Key: "1", Seen: now.Add(time.Minute)})
+	tr.Forget("a", now)
+	require.Len(t, tr.Stale("r1", now.Add(2*time.Hour), time.Hour), 2)
+	tr.Forget("a", time.Time{})
+	require.Len(t, tr.Stale("r1", now.Add(2*time.Hour), time.Hour), 1)
+}
+
+func TestNotifyCloseStale(t *testing.T) {
+	fakeJira := newTestFakeJira()
+	stale := NewStaleTracker()
+	conf := testReceiverConfig1()
+	conf.StaleIssues = &config.StaleIssuesConfig{After: config.Duration(6 * time.Hour), State: "Done"}
+	opts := Options{MaxDescriptionLength: 32768}
+	start := time.Date(2023, 5, 1, 10, 0, 0, 0, time.UTC)
+	receiverAt := func(d time.Duration) *Receiver {
+		r := NewReceiver(log.NewNopLogger(), conf, template.SimpleTemplate(), fakeJira).WithStaleTracker(stale)
+		r.timeNow = func() time.Time { return start.Add(d) }
+		return r
+	}
+	notifyAt := func(d time.Duration, status string, group string) {
+		data := &alertmanager.Data{
+			Status:      status,
+			Alerts:      alertmanager.Alerts{{Status: status}},
+			GroupLabels: alertmanager.KV{"a": group},
+		}
+		_, err := receiverAt(d).Notify(context.Background(), data, opts)
+		require.NoError(t, err)
+	}
+
+	notifyAt(0, alertmanager.AlertFiring, "b")
+	notifyAt(0, alertmanager.AlertFiring, "c")
+	notifyAt(0, alertmanager.AlertFiring, "d")
+	// Alert groups notified resolved, or firing again, are not stale.
+	notifyAt(time.Hour, alertmanager.AlertResolved, "c")
+	notifyAt(4*time.Hour, alertmanager.AlertFiring, "d")
+
+	closed, err := receiverAt(5 * time.Hour).CloseStale(context.Background())
+	require.NoError(t, err)
+	require.Equal(t, 0, closed)
+
+	closed, err = receiverAt(7 * time.Hour).CloseStale(context.Background())
+	require.NoError(t, err)
+	require.Equal(t, 1, closed)
+	issue := fakeJira.issuesByKey["1"]
+	require.Equal(t, "Done", issue.Fields.Status.StatusCategory.Key)
+	require.Len(t, issue.Fields.Comments.Comments, 1)
+	require.Equal(t, "Alert group not notified for 7h0m0s, closing the issue as stale. The resolved notification may have been lost.", issue.Fields.Comments.Comments[0].Body)
+	require.NotEqual(t, "Done", fakeJira.issuesByKey["2"].Fields.Status.StatusCategory.Key)
+	require.NotEqual(t, "Done", fakeJira.issuesByKey["3"].Fields.Status.StatusCategory.Key)
+
+	// Closed issues are forgotten.
+	closed, err = receiverAt(8 * time.Hour).CloseStale(context.Background())
+	require.NoError(t, err)
+	require.Equal(t, 0, closed)
+
+	closed, err = receiverAt(11 * time.Hour).CloseStale(context.Background())
+	require.NoError(t, err)
+	require.Equal(t, 1, closed)
+	require.Equal(t, "Done", fakeJira.issuesByKey["3"].Fields.Status.StatusCategory.Key)
+}
@@ -0,0 +1,68 @@
+// Copyright 2017 The Prometheus Authors
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package notify
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/andygrunwald/go-jira"
+	"github.com/go-kit/log/level"
+	"github.com/prometheus-community/jiralert/pkg/alertmanager"
+)
+
+// Labels of issues matching the state of their alert group, with status_label.
+const (
+	statusLabelFiring   = "jiralert:firing"
+	statusLabelResolved = "jiralert:resolved"
+)
+
+// statusLabel returns the status label matching the state of the alert group.
+func statusLabel(data *alertmanager.Data) string {
+	if data.Alerts.HasFiring() {
+		return statusLabelFiring
+	}
+	return statusLabelResolved
+}
+
+// syncStatusLabel adds the status label matching the state of the alert group to the issue, if missing, and removes
+// the other one.
+func (r *Receiver) syncStatusLabel(ctx context.Context, issue *jira.Issue, data *alertmanager.Data) (bool, error) {
+	desired := statusLabel(data)
+	found := false
+	var ops []map[string]string
+	for _, l := range issue.Fields.Labels {
+		switch {
+		case l == desired:
+			found = true
+		case l == statusLabelFiring || l == statusLabelResolved:
+			ops = append(ops, map[string]string{"remove": l})
+		}
+	}
+	if !found {
+		ops = append(ops, map[string]string{"add": desired})
+	}
+	if len(ops) == 0 {
+		return false, nil
+	}
+
+	level.Debug(r.logger).Log("msg", "updating issue status label", "key", issue.Key, "ops", fmt.Sprintf("%v", ops))
+	resp, err := r.client.UpdateIssueWithContext(ctx, issue.Key, map[string]interface{}{
+		"update": map[string]interface{}{"labels": ops},
+	})
+	if err != nil {
+		return handleJiraErrResponse("Issue.UpdateIssue", resp, err, r.conf.Retry, r.logger)
+	}
+	return false, nil
+}
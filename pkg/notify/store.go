@@ -0,0 +1,102 @@
+// Copyright 2017 The Prometheus Authors
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package notify
+
+import (
+	"context"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/andygrunwald/go-jira"
+	"github.com/go-kit/log/level"
+	"github.com/prometheus-community/jiralert/pkg/alertmanager"
+	"github.com/prometheus-community/jiralert/pkg/store"
+)
+
+// WithStore makes the receiver look up the issues of alert groups in s before searching Jira, and record there the
+// issues notifications were handled with.
+func (r *Receiver) WithStore(s store.Store) *Receiver {
+	r.store = s
+	return r
+}
+
+// storeEnabled returns true if issues are looked up in and recorded into the store. Dry runs always search, to
+// report the live issue.
+func (r *Receiver) storeEnabled() bool {
+	return r.store != nil && !r.dryRun
+}
+
+// storedSearch returns the issue recorded for the alert group in the store, fetched by key to get its current state,
+// or searches for it if there is none. Failing to read the store falls back to searching.
+func (r *Receiver) storedSearch(ctx context.Context, project string, projects []string, groupQuery string) (*jira.Issue, bool, error) {
+	if !r.storeEnabled() {
+		return r.cachedSearch(ctx, project, projects, groupQuery)
+	}
+
+	group := r.groupKey(project, groupQuery)
+	rec, ok, err := r.store.Get(ctx, group)
+	if err != nil {
+		level.Warn(r.logger).Log("msg", "failed to look up issue in store, searching", "query", groupQuery, "err", err)
+	} else if ok && rec.IssueKey != "" {
+		issue, resp, err := r.client.GetWithContext(ctx, rec.IssueKey, &jira.GetQueryOptions{Fields: strings.Join(r.searchFields(), ",")})
+		if err == nil {
+			level.Debug(r.logger).Log("msg", "found in store, skipping search", "key", issue.Key, "query", groupQuery)
+			storeHits.WithLabelValues(r.conf.Name).Inc()
+			return issue, false, nil
+		}
+		if resp == nil || resp.StatusCode != http.StatusNotFound {
+			retry, err := handleJiraErrResponse("Issue.Get", resp, err, r.conf.Retry, r.logger)
+			return nil, retry, err
+		}
+		level.Debug(r.logger).Log("msg", "issue in store no longer exists, searching", "key", rec.IssueKey, "query", groupQuery)
+		if err := r.store.Delete(ctx, group); err != nil {
+			level.Warn(r.logger).Log("msg", "failed to delete record from store", "key", rec.IssueKey, "query", groupQuery, "err", err)
+		}
+	}
+	return r.cachedSearch(ctx, project, projects, groupQuery)
+}
+
+// recordGroup records in the store the issue the notification of the alert group was handled with, if any, along
+// with when the alert group was notified, fired and resolved.
+func (r *Receiver) recordGroup(ctx context.Context, project, groupQuery string, data *alertmanager.Data) {
+	if !r.storeEnabled() || r.handled == nil || r.handled.IssueKey == "" {
+		return
+	}
+	group := r.groupKey(project, groupQuery)
+	prev, ok, err := r.store.Get(ctx, group)
+	if err != nil {
+		level.Warn(r.logger).Log("msg", "failed to read record from store", "key", r.handled.IssueKey, "query", groupQuery, "err", err)
+		return
+	}
+
+	now := r.timeNow()
+	rec := store.Record{IssueKey: r.handled.IssueKey, LastUpdate: now}
+	if ok && prev.IssueKey == rec.IssueKey {
+		rec.FirstFiring, rec.LastFiring, rec.ResolvedAt = prev.FirstFiring, prev.LastFiring, prev.ResolvedAt
+	}
+	if data.Alerts.HasFiring() {
+		if rec.FirstFiring.IsZero() {
+			rec.FirstFiring = now
+		}
+		rec.LastFiring = now
+		rec.ResolvedAt = time.Time{}
+	} else if rec.ResolvedAt.IsZero() {
+		// Repeated resolved notifications don't extend the retention of the record.
+		rec.ResolvedAt = now
+	}
+	if err := r.store.Set(ctx, group, rec); err != nil {
+		level.Warn(r.logger).Log("msg", "failed to record issue in store", "key", rec.IssueKey, "query", groupQuery, "err", err)
+	}
+}
@@ -0,0 +1,118 @@
+// Copyright 2017 The Prometheus Authors
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+package notify
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/go-kit/log"
+	"github.com/prometheus-community/jiralert/pkg/alertmanager"
+	"github.com/prometheus-community/jiralert/pkg/identity"
+	"github.com/prometheus-community/jiralert/pkg/store"
+	"github.com/prometheus-community/jiralert/pkg/template"
+	"github.com/stretchr/testify/require"
+)
+
+func TestNotifyStore(t *testing.T) {
+	fakeJira := newTestFakeJira()
+	s := store.NewMemory(0)
+	conf := testReceiverConfig1()
+	opts := Options{MaxDescriptionLength: 32768, UpdateSummary: true}
+	start := time.Date(2023, 5, 1, 10, 0, 0, 0, time.UTC)
+	var group string
+	notifyAt := func(d time.Duration, alerts int) {
+		data := &alertmanager.Data{Status: alertmanager.AlertFiring, GroupLabels: alertmanager.KV{"a": "b"}}
+		for i := 0; i < alerts; i++ {
+			data.Alerts = append(data.Alerts, alertmanager.Alert{Status: alertmanager.AlertFiring})
+		}
+		if alerts == 0 {
+			data.Status = alertmanager.AlertResolved
+			data.Alerts = alertmanager.Alerts{{Status: alertmanager.AlertResolved}}
+		}
+		r := NewReceiver(log.NewNopLogger(), conf, template.SimpleTemplate(), fakeJira).WithStore(s)
+		r.timeNow = func() time.Time { return start.Add(d) }
+		_, err := r.Notify(context.Background(), data, opts)
+		require.NoError(t, err)
+		strategy, err := identity.New(conf.Identity, false)
+		require.NoError(t, err)
+		group = r.groupKey(conf.Project, strategy.Query(data.GroupLabels))
+	}
+	record := func() store.Record {
+		rec, ok, err := s.Get(context.Background(), group)
+		require.NoError(t, err)
+		require.True(t, ok)
+		return rec
+	}
+
+	notifyAt(0, 1)
+	require.Len(t, fakeJira.issuesByKey, 1)
+	searches := fakeJira.searches
+	require.Equal(t, store.Record{IssueKey: "1", LastUpdate: start, FirstFiring: start, LastFiring: start}, record())
+
+	// The recorded issue is fetched by key and updated without searching, even once resolved.
+	notifyAt(time.Minute, 2)
+	require.Equal(t, "[FIRING:2] b ", fakeJira.issuesByKey["1"].Fields.Summary)
+	notifyAt(2*time.Minute, 0)
+	require.Equal(t, searches, fakeJira.searches)
+	require.Equal(t, store.Record{IssueKey: "1", LastUpdate: start.Add(2 * time.Minute), FirstFiring: start, LastFiring: start.Add(time.Minute), ResolvedAt: start.Add(2 * time.Minute)}, record())
+	// Repeated resolved notifications keep when the alert group was resolved, from which the record expires.
+	notifyAt(150*time.Second, 0)
+	require.Equal(t, start.Add(2*time.Minute), record().ResolvedAt)
+
+	// Deleted issues are searched for again, and replaced by a new issue, which the fake creates under the same key.
+	delete(fakeJira.issuesByKey, "1")
+	fakeJira.keysByQuery = map[string][]string{}
+	notifyAt(3*time.Minute, 1)
+	require.Greater(t, fakeJira.searches, searches)
+	require.Equal(t, store.Record{IssueKey: "1", LastUpdate: start.Add(3 * time.Minute), FirstFiring: start.Add(3 * time.Minute), LastFiring: start.Add(3 * time.Minute)}, record())
+}
+
+// lockingStore records the alert groups locked in it.
+type lockingStore struct {
+	*store.Memory
+	locked, unlocked []string
+	err              error
+}
+
+func (s *lockingStore) Lock(_ context.Context, group string, _ time.Duration) (func(), error) {
+	if s.err != nil {
+		return nil, s.err
+	}
+	s.locked = append(s.locked, group)
+	return func() { s.unlocked = append(s.unlocked, group) }, nil
+}
+
+func TestNotifyStoreLock(t *testing.T) {
+	fakeJira := newTestFakeJira()
+	s := &lockingStore{Memory: store.NewMemory(0)}
+	conf := testReceiverConfig1()
+	data := &alertmanager.Data{
+		Status:      alertmanager.AlertFiring,
+		Alerts:      alertmanager.Alerts{{Status: alertmanager.AlertFiring}},
+		GroupLabels: alertmanager.KV{"a": "b"},
+	}
+	r := NewReceiver(log.NewNopLogger(), conf, template.SimpleTemplate(), fakeJira).WithGroupLocker(NewGroupLocker()).WithStore(s)
+	_, err := r.Notify(context.Background(), data, Options{MaxDescriptionLength: 32768})
+	require.NoError(t, err)
+	require.Len(t, s.locked, 1)
+	require.Equal(t, s.locked, s.unlocked)
+
+	// Notifications are handled even if the store fails to lock.
+	s.err = errors.New("connection refused")
+	_, err = r.Notify(context.Background(), data, Options{MaxDescriptionLength: 32768})
+	require.NoError(t, err)
+	require.Len(t, s.locked, 1)
+}
@@ -0,0 +1,55 @@
+// Copyright 2017 The Prometheus Authors
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package notify
+
+import (
+	"context"
+
+	"github.com/go-kit/log/level"
+	"github.com/pkg/errors"
+	"github.com/prometheus-community/jiralert/pkg/alertmanager"
+)
+
+// renderSummary renders the summary of the issue, prefixed with the summary_firing_prefix or summary_resolved_prefix
+// matching the state of the alert group.
+func (r *Receiver) renderSummary(data *alertmanager.Data) (string, error) {
+	summary, err := r.execute(r.conf.Summary, data)
+	if err != nil {
+		return "", errors.Wrap(err, "generate summary from template")
+	}
+	prefix := r.conf.SummaryFiringPrefix
+	if !data.Alerts.HasFiring() {
+		prefix = r.conf.SummaryResolvedPrefix
+	}
+	if prefix == "" {
+		return summary, nil
+	}
+	if prefix, err = r.execute(prefix, data); err != nil {
+		return "", errors.Wrap(err, "render summary prefix")
+	}
+	return prefix + summary, nil
+}
+
+// syncSummaryPrefix updates the current summary of an issue about to be reopened or resolved if summary prefixes are
+// configured, even if summary updates are disabled, throttled or frozen, so that the prefix matches the new status.
+func (r *Receiver) syncSummaryPrefix(ctx context.Context, issueKey, current, summary string) (bool, error) {
+	if r.conf.SummaryFiringPrefix == "" && r.conf.SummaryResolvedPrefix == "" {
+		return false, nil
+	}
+	if current == summary {
+		return false, nil
+	}
+	level.Debug(r.logger).Log("msg", "updating summary prefix to the state of the alert group", "key", issueKey)
+	return r.updateSummary(ctx, issueKey, summary)
+}
@@ -0,0 +1,120 @@
+// Copyright 2017 The Prometheus Authors
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package notify
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/andygrunwald/go-jira"
+	"github.com/go-kit/log/level"
+)
+
+type transitionEntry struct {
+	transitions []jira.Transition
+	expires     time.Time
+}
+
+// TransitionCache remembers in memory the workflow transitions available from the statuses of issues, by project
+// and issue type, for receivers with a transition_cache_ttl.
+type TransitionCache struct {
+	mtx     sync.Mutex
+	entries map[string]transitionEntry
+}
+
+// NewTransitionCache creates an empty TransitionCache.
+func NewTransitionCache() *TransitionCache {
+	return &TransitionCache{entries: map[string]transitionEntry{}}
+}
+
+// Get returns the transitions cached for key, if they have not expired at the given time.
+func (c *TransitionCache) Get(key string, now time.Time) ([]jira.Transition, bool) {
+	c.mtx.Lock()
+	defer c.mtx.Unlock()
+	e, ok := c.entries[key]
+	if !ok || !now.Before(e.expires) {
+		return nil, false
+	}
+	return e.transitions, true
+}
+
+// Set caches the transitions of key until the given time, and forgets expired transitions.
+func (c *TransitionCache) Set(key string, transitions []jira.Transition, now, expires time.Time) {
+	c.mtx.Lock()
+	defer c.mtx.Unlock()
+	for k, e := range c.entries {
+		if !now.Before(e.expires) {
+			delete(c.entries, k)
+		}
+	}
+	c.entries[key] = transitionEntry{transitions: transitions, expires: expires}
+}
+
+// Invalidate forgets the transitions cached for key.
+func (c *TransitionCache) Invalidate(key string) {
+	c.mtx.Lock()
+	defer c.mtx.Unlock()
+	delete(c.entries, key)
+}
+
+// WithTransitionCache makes receivers with a transition_cache_ttl reuse the transitions cached in c.
+func (r *Receiver) WithTransitionCache(c *TransitionCache) *Receiver {
+	r.transitions = c
+	return r
+}
+
+// transitionKey returns the key of the transitions available from the issue's status, or an empty string if
+// they cannot be cached as the issue's project, type or status is unknown.
+func (r *Receiver) transitionKey(issue *jira.Issue) string {
+	if r.transitions == nil || r.conf.TransitionCacheTTL == nil || *r.conf.TransitionCacheTTL <= 0 {
+		return ""
+	}
+	f := issue.Fields
+	if f == nil || f.Project.Key == "" || f.Type.Name == "" || f.Status == nil || f.Status.Name == "" {
+		return ""
+	}
+	return fmt.Sprintf("%s/%s/%s/%s", r.conf.Name, f.Project.Key, f.Type.Name, f.Status.Name)
+}
+
+// getTransitions returns the transitions available for the issue, and whether they were cached.
+func (r *Receiver) getTransitions(ctx context.Context, issue *jira.Issue) ([]jira.Transition, bool, bool, error) {
+	key := r.transitionKey(issue)
+	if key != "" {
+		if transitions, ok := r.transitions.Get(key, r.timeNow()); ok {
+			level.Debug(r.logger).Log("msg", "using cached transitions", "key", issue.Key, "status", issue.Fields.Status.Name)
+			return transitions, true, false, nil
+		}
+	}
+
+	transitions, resp, err := r.client.GetTransitionsWithContext(ctx, issue.Key)
+	if err != nil {
+		retry, err := handleJiraErrResponse("Issue.GetTransitions", resp, err, r.conf.Retry, r.logger)
+		return nil, false, retry, err
+	}
+	if key != "" {
+		now := r.timeNow()
+		r.transitions.Set(key, transitions, now, now.Add(time.Duration(*r.conf.TransitionCacheTTL)))
+	}
+	return transitions, false, false, nil
+}
+
+// invalidateTransitions forgets the cached transitions of the issue's status, e.g. after the workflow changed.
+func (r *Receiver) invalidateTransitions(issue *jira.Issue) {
+	if key := r.transitionKey(issue); key != "" {
+		level.Debug(r.logger).Log("msg", "cached transitions are outdated, fetching them again", "key", issue.Key, "status", issue.Fields.Status.Name)
+		r.transitions.Invalidate(key)
+	}
+}
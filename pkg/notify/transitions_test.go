@@ -0,0 +1,74 @@
+// Copyright 2017 The Prometheus Authors
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+package notify
+
+import (
+	"context"
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/andygrunwald/go-jira"
+	"github.com/go-kit/log"
+	"github.com/prometheus-community/jiralert/pkg/alertmanager"
+	"github.com/prometheus-community/jiralert/pkg/config"
+	"github.com/prometheus-community/jiralert/pkg/template"
+	"github.com/stretchr/testify/require"
+)
+
+func TestNotifyTransitionCache(t *testing.T) {
+	fakeJira := newTestFakeJira()
+	transitions := NewTransitionCache()
+	conf := testReceiverConfig1()
+	conf.IssueType = "Bug"
+	ttl := config.Duration(time.Hour)
+	conf.TransitionCacheTTL = &ttl
+	conf.AutoResolve = &config.AutoResolve{State: "Done"}
+	opts := Options{MaxDescriptionLength: 32768}
+	notify := func(value string, status string) {
+		data := &alertmanager.Data{
+			Status:      status,
+			Alerts:      alertmanager.Alerts{{Status: status}},
+			GroupLabels: alertmanager.KV{"a": value},
+		}
+		r := NewReceiver(log.NewNopLogger(), conf, template.SimpleTemplate(), fakeJira).WithTransitionCache(transitions)
+		_, err := r.Notify(context.Background(), data, opts)
+		require.NoError(t, err)
+	}
+	// resolve creates an issue in status Open and resolves it.
+	resolve := func(value string) *jira.Issue {
+		notify(value, alertmanager.AlertFiring)
+		issue := fakeJira.issuesByKey[fmt.Sprint(len(fakeJira.issuesByKey))]
+		issue.Fields.Status.Name = "Open"
+		notify(value, alertmanager.AlertResolved)
+		return issue
+	}
+
+	require.Equal(t, "Done", resolve("b").Fields.Status.StatusCategory.Key)
+	require.Equal(t, 1, fakeJira.transitionGets)
+
+	// Issues of the same project, type and status reuse the transitions.
+	require.Equal(t, "Done", resolve("c").Fields.Status.StatusCategory.Key)
+	require.Equal(t, 1, fakeJira.transitionGets)
+
+	// Transitions rejected by Jira are fetched again.
+	fakeJira.transitionsByID = map[string]jira.Transition{"5678": {ID: "5678", Name: "Done"}}
+	require.Equal(t, "Done", resolve("d").Fields.Status.StatusCategory.Key)
+	require.Equal(t, 2, fakeJira.transitionGets)
+
+	// So are transitions lacking the target state.
+	fakeJira.transitionsByID = map[string]jira.Transition{"9": {ID: "9", Name: "Closed"}}
+	conf.AutoResolve.State = "Closed"
+	require.Equal(t, "Closed", resolve("e").Fields.Status.StatusCategory.Key)
+	require.Equal(t, 3, fakeJira.transitionGets)
+}
@@ -0,0 +1,52 @@
+// Copyright 2017 The Prometheus Authors
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package notify
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/andygrunwald/go-jira"
+	"github.com/go-kit/log/level"
+	"github.com/prometheus-community/jiralert/pkg/alertmanager"
+)
+
+// truncatedAlertsComment is the comment added to issues by receivers with truncated_alerts_comment when Alertmanager
+// left alerts out of the notification.
+const truncatedAlertsComment = "Alertmanager left %d alerts of this alert group out of the notification, as it exceeded max_alerts. This issue may not list all of the alerts."
+
+// countTruncatedAlerts counts the alerts Alertmanager left out of the notification, once rather than again on its
+// delayed resolution.
+func (r *Receiver) countTruncatedAlerts(data *alertmanager.Data) {
+	if data.TruncatedAlerts > 0 && !r.dryRun && !r.resolveNow {
+		truncatedAlerts.WithLabelValues(r.conf.Name).Add(float64(data.TruncatedAlerts))
+	}
+}
+
+// warnTruncated comments on the issue if Alertmanager left alerts out of the notification, unless the last comment
+// already says so, e.g. on repeated notifications. Failures are logged rather than failing the notification, as the
+// issue itself was handled.
+func (r *Receiver) warnTruncated(ctx context.Context, issue *jira.Issue, data *alertmanager.Data) {
+	if data.TruncatedAlerts == 0 || !isEnabled(r.conf.TruncatedAlertsComment) {
+		return
+	}
+	comment := fmt.Sprintf(truncatedAlertsComment, data.TruncatedAlerts)
+	if c := issue.Fields.Comments; c != nil && len(c.Comments) > 0 && c.Comments[len(c.Comments)-1].Body == comment {
+		level.Debug(r.logger).Log("msg", "not repeating truncated alerts comment", "key", issue.Key)
+		return
+	}
+	if _, err := r.addComment(ctx, issue.Key, comment); err != nil {
+		level.Warn(r.logger).Log("msg", "failed to comment on truncated alerts", "key", issue.Key, "err", err)
+	}
+}
@@ -0,0 +1,92 @@
+// Copyright 2017 The Prometheus Authors
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package notify
+
+import (
+	"sync"
+	"time"
+
+	"github.com/go-kit/log/level"
+)
+
+type updateKey struct {
+	receiver, issue string
+}
+
+// UpdateTracker remembers in memory until when the issues recently created or updated by receivers with a
+// min_update_interval must not be updated again.
+type UpdateTracker struct {
+	mtx   sync.Mutex
+	until map[updateKey]time.Time
+}
+
+// NewUpdateTracker creates an empty UpdateTracker.
+func NewUpdateTracker() *UpdateTracker {
+	return &UpdateTracker{until: map[updateKey]time.Time{}}
+}
+
+// Throttled returns true if the issue must not be updated at the given time.
+func (t *UpdateTracker) Throttled(receiver, issue string, now time.Time) bool {
+	t.mtx.Lock()
+	defer t.mtx.Unlock()
+	until, ok := t.until[updateKey{receiver: receiver, issue: issue}]
+	return ok && now.Before(until)
+}
+
+// Record blocks updates of the issue until the given time, and forgets issues which may be updated again.
+func (t *UpdateTracker) Record(receiver, issue string, now, until time.Time) {
+	t.mtx.Lock()
+	defer t.mtx.Unlock()
+	for k, u := range t.until {
+		if !now.Before(u) {
+			delete(t.until, k)
+		}
+	}
+	t.until[updateKey{receiver: receiver, issue: issue}] = until
+}
+
+// WithUpdateTracker makes the receiver skip updates of issues changed less than min_update_interval ago, as
+// recorded in t.
+func (r *Receiver) WithUpdateTracker(t *UpdateTracker) *Receiver {
+	r.updates = t
+	return r
+}
+
+// updateThrottled returns true if the summary, description, environment and comments of the issue must not be
+// updated yet.
+func (r *Receiver) updateThrottled(issueKey string) bool {
+	if r.updates == nil || r.conf.MinUpdateInterval == nil || *r.conf.MinUpdateInterval <= 0 {
+		return false
+	}
+	if !r.updates.Throttled(r.conf.Name, issueKey, r.timeNow()) {
+		return false
+	}
+	level.Debug(r.logger).Log("msg", "issue changed less than min_update_interval ago, skipping updates", "key", issueKey)
+	if r.planned != nil {
+		r.planned.UpdatesThrottled = true
+	}
+	if !r.dryRun {
+		updatesSkipped.WithLabelValues(r.conf.Name).Inc()
+	}
+	return true
+}
+
+// recordUpdate records that the issue was created or updated now.
+func (r *Receiver) recordUpdate(issueKey string) {
+	if r.updates == nil || r.dryRun || r.conf.MinUpdateInterval == nil || *r.conf.MinUpdateInterval <= 0 {
+		return
+	}
+	now := r.timeNow()
+	r.updates.Record(r.conf.Name, issueKey, now, now.Add(time.Duration(*r.conf.MinUpdateInterval)))
+}
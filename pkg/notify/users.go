@@ -0,0 +1,164 @@
+// Copyright 2017 The Prometheus Authors
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package notify
+
+import (
+	"context"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/andygrunwald/go-jira"
+	"github.com/go-kit/log/level"
+	"github.com/prometheus-community/jiralert/pkg/alertmanager"
+)
+
+// userCacheTTL is how long the users found for email addresses and usernames, or their absence, are remembered.
+const userCacheTTL = time.Hour
+
+type userEntry struct {
+	user    *jira.User
+	expires time.Time
+}
+
+// UserCache remembers in memory the Jira users found for the email addresses and usernames of assignees, reporters
+// and watchers, by Jira instance, so that each is only searched once an hour.
+type UserCache struct {
+	mtx     sync.Mutex
+	entries map[string]userEntry
+}
+
+// NewUserCache creates an empty UserCache.
+func NewUserCache() *UserCache {
+	return &UserCache{entries: map[string]userEntry{}}
+}
+
+// Get returns the user cached for key, nil if the user was not found, if the entry has not expired at the given time.
+func (c *UserCache) Get(key string, now time.Time) (*jira.User, bool) {
+	c.mtx.Lock()
+	defer c.mtx.Unlock()
+	e, ok := c.entries[key]
+	if !ok || !now.Before(e.expires) {
+		return nil, false
+	}
+	return e.user, true
+}
+
+// Set caches the user of key until the given time, and forgets expired users.
+func (c *UserCache) Set(key string, user *jira.User, now, expires time.Time) {
+	c.mtx.Lock()
+	defer c.mtx.Unlock()
+	for k, e := range c.entries {
+		if !now.Before(e.expires) {
+			delete(c.entries, k)
+		}
+	}
+	c.entries[key] = userEntry{user: user, expires: expires}
+}
+
+// WithUserCache makes receivers reuse the users cached in c.
+func (r *Receiver) WithUserCache(c *UserCache) *Receiver {
+	r.users = c
+	return r
+}
+
+// findUser returns the user of the given email address or username, or nil if there is none: the user whose email
+// address, username or account ID matches exactly, or else the only user found.
+func (r *Receiver) findUser(ctx context.Context, query string) (*jira.User, error) {
+	key := r.conf.APIURL + "|" + query
+	if r.users != nil {
+		if user, ok := r.users.Get(key, r.timeNow()); ok {
+			return user, nil
+		}
+	}
+
+	users, resp, err := r.client.FindUsersWithContext(ctx, query)
+	if err != nil {
+		_, err = handleJiraErrResponse("User.Find", resp, err, r.conf.Retry, r.logger)
+		return nil, err
+	}
+	var user *jira.User
+	for i, u := range users {
+		if strings.EqualFold(u.EmailAddress, query) || u.Name == query || u.AccountID == query {
+			user = &users[i]
+			break
+		}
+	}
+	if user == nil && len(users) == 1 {
+		user = &users[0]
+	}
+	if r.users != nil {
+		now := r.timeNow()
+		r.users.Set(key, user, now, now.Add(userCacheTTL))
+	}
+	return user, nil
+}
+
+// resolveUser renders the template of a user setting, and returns the reference to the user in Jira requests: the
+// account ID on Jira Cloud, the username on Jira Server and Data Center. Users which cannot be rendered or found are
+// logged and nil.
+func (r *Receiver) resolveUser(ctx context.Context, setting, text string, data *alertmanager.Data) *jira.User {
+	query, err := r.execute(text, data)
+	if err != nil {
+		level.Warn(r.logger).Log("msg", "failed to render user", "setting", setting, "err", err)
+		return nil
+	}
+	query = strings.TrimSpace(query)
+	if query == "" {
+		return nil
+	}
+	user, err := r.findUser(ctx, query)
+	if err != nil {
+		level.Warn(r.logger).Log("msg", "failed to search user", "setting", setting, "user", query, "err", err)
+		return nil
+	}
+	if user == nil {
+		level.Warn(r.logger).Log("msg", "user not found, or ambiguous", "setting", setting, "user", query)
+		return nil
+	}
+	if user.AccountID != "" {
+		return &jira.User{AccountID: user.AccountID}
+	}
+	return &jira.User{Name: user.Name}
+}
+
+// setUsers sets the assignee and reporter of the issue to create, if configured and found. Issues are created
+// regardless, e.g. unassigned.
+func (r *Receiver) setUsers(ctx context.Context, issue *jira.Issue, data *alertmanager.Data) {
+	if r.conf.Assignee != "" {
+		issue.Fields.Assignee = r.resolveUser(ctx, "assignee", r.conf.Assignee, data)
+	}
+	if r.conf.Reporter != "" {
+		issue.Fields.Reporter = r.resolveUser(ctx, "reporter", r.conf.Reporter, data)
+	}
+}
+
+// addWatchers adds the configured watchers found to the created issue. Failures are logged rather than failing the
+// notification, as the issue itself was created.
+func (r *Receiver) addWatchers(ctx context.Context, issue *jira.Issue, data *alertmanager.Data) {
+	for _, text := range r.conf.Watchers {
+		user := r.resolveUser(ctx, "watchers", text, data)
+		if user == nil {
+			continue
+		}
+		id := user.AccountID
+		if id == "" {
+			id = user.Name
+		}
+		if resp, err := r.client.AddWatcherWithContext(ctx, issue.Key, id); err != nil {
+			_, err = handleJiraErrResponse("Issue.AddWatcher", resp, err, r.conf.Retry, r.logger)
+			level.Warn(r.logger).Log("msg", "failed to add watcher", "key", issue.Key, "watcher", id, "err", err)
+		}
+	}
+}
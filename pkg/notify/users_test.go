@@ -0,0 +1,73 @@
+// Copyright 2017 The Prometheus Authors
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+package notify
+
+import (
+	"context"
+	"testing"
+
+	"github.com/andygrunwald/go-jira"
+	"github.com/go-kit/log"
+	"github.com/prometheus-community/jiralert/pkg/alertmanager"
+	"github.com/prometheus-community/jiralert/pkg/template"
+	"github.com/stretchr/testify/require"
+)
+
+func TestNotifyUsers(t *testing.T) {
+	fakeJira := newTestFakeJira()
+	fakeJira.users = map[string][]jira.User{
+		// Jira Cloud.
+		"jane@example.com": {
+			{AccountID: "5b10ac8d82e05b22cc7d4ef5", EmailAddress: "jane@example.com"},
+			{AccountID: "5b10ac8d82e05b22cc7d4ef6", EmailAddress: "jane@example.com.au"},
+		},
+		// Jira Server.
+		"jdoe": {{Name: "jdoe", EmailAddress: "john@example.com"}},
+		// Ambiguous.
+		"ops": {{AccountID: "1", DisplayName: "Ops 1"}, {AccountID: "2", DisplayName: "Ops 2"}},
+	}
+	conf := testReceiverConfig1()
+	conf.Assignee = "{{ .CommonLabels.owner }}@example.com"
+	conf.Reporter = "unknown"
+	conf.Watchers = []string{"jdoe", "ops", "{{ .CommonLabels.missing }}"}
+	users := NewUserCache()
+	notify := func(data *alertmanager.Data) {
+		r := NewReceiver(log.NewNopLogger(), conf, template.SimpleTemplate(), fakeJira).WithUserCache(users)
+		_, err := r.Notify(context.Background(), data, Options{MaxDescriptionLength: 32768})
+		require.NoError(t, err)
+	}
+
+	notify(&alertmanager.Data{
+		Status:       alertmanager.AlertFiring,
+		Alerts:       alertmanager.Alerts{{Status: alertmanager.AlertFiring}},
+		GroupLabels:  alertmanager.KV{"a": "b"},
+		CommonLabels: alertmanager.KV{"owner": "jane"},
+	})
+	issue := fakeJira.issuesByKey["1"]
+	require.Equal(t, &jira.User{AccountID: "5b10ac8d82e05b22cc7d4ef5"}, issue.Fields.Assignee)
+	// Users which are not found, or ambiguous, are left out.
+	require.Nil(t, issue.Fields.Reporter)
+	require.Equal(t, map[string][]string{"1": {"jdoe"}}, fakeJira.watchers)
+	require.Equal(t, 4, fakeJira.userSearches)
+
+	// Users are searched once, whether found or not.
+	notify(&alertmanager.Data{
+		Status:       alertmanager.AlertFiring,
+		Alerts:       alertmanager.Alerts{{Status: alertmanager.AlertFiring}},
+		GroupLabels:  alertmanager.KV{"a": "c"},
+		CommonLabels: alertmanager.KV{"owner": "jane"},
+	})
+	require.Equal(t, &jira.User{AccountID: "5b10ac8d82e05b22cc7d4ef5"}, fakeJira.issuesByKey["2"].Fields.Assignee)
+	require.Equal(t, []string{"jdoe"}, fakeJira.watchers["2"])
+	require.Equal(t, 4, fakeJira.userSearches)
+}
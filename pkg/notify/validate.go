@@ -0,0 +1,295 @@
+// Copyright 2017 The Prometheus Authors
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package notify
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/andygrunwald/go-jira"
+	"github.com/go-kit/log/level"
+	"github.com/prometheus-community/jiralert/pkg/config"
+)
+
+// ValidationResult lists the problems found validating a receiver's configuration against Jira.
+type ValidationResult struct {
+	Receiver string   `json:"receiver"`
+	Problems []string `json:"problems"`
+	// Error is set if Jira could not be queried.
+	Error string `json:"error,omitempty"`
+	// Schema lists the fields of the receiver's issue type, if its fields were found invalid.
+	Schema []FieldSchema `json:"schema,omitempty"`
+}
+
+// FieldSchema describes a field of an issue type, as returned by the create metadata API.
+type FieldSchema struct {
+	Key      string `json:"key"`
+	Name     string `json:"name"`
+	Type     string `json:"type"`
+	Required bool   `json:"required"`
+}
+
+// OK returns true if the configuration is valid.
+func (v *ValidationResult) OK() bool {
+	return v.Error == "" && len(v.Problems) == 0
+}
+
+// Validate verifies that the receiver's project and issue type exist, that its priority and components can be set
+// on issues of this type, and that the reopen and auto-resolve transitions are possible. Settings using templates
+// are only known once alerts arrive and are skipped.
+//
+// Transitions depend on the current state of an issue, so they are checked on the most recently resolved and
+// unresolved issues of the project, if any.
+func (r *Receiver) Validate(ctx context.Context) *ValidationResult {
+	res := &ValidationResult{Receiver: r.conf.Name, Problems: []string{}}
+	problem := func(format string, args ...interface{}) {
+		res.Problems = append(res.Problems, fmt.Sprintf(format, args...))
+	}
+
+	project := r.conf.Project
+	if isTemplated(project) {
+		level.Debug(r.logger).Log("msg", "project is templated, skipping validation", "receiver", r.conf.Name)
+		return res
+	}
+
+	meta, resp, err := r.client.GetCreateMetaWithContext(ctx, project)
+	if err != nil {
+		_, err = handleJiraErrResponse("Issue.GetCreateMeta", resp, err, r.conf.Retry, r.logger)
+		res.Error = err.Error()
+		return res
+	}
+	metaProject := meta.GetProjectWithKey(project)
+	if metaProject == nil {
+		problem("project %q does not exist or the user lacks permission to create issues in it", project)
+		return res
+	}
+
+	if !isTemplated(r.conf.IssueType) {
+		issueType := metaProject.GetIssueTypeWithName(r.conf.IssueType)
+		if issueType == nil {
+			problem("issue type %q does not exist in project %q, valid types: %s", r.conf.IssueType, project, strings.Join(issueTypeNames(metaProject), ", "))
+		} else {
+			if r.conf.Priority != "" && !isTemplated(r.conf.Priority) {
+				checkAllowedValue(issueType, "priority", r.conf.Priority, problem)
+			}
+			for _, component := range r.conf.Components {
+				if !isTemplated(component) {
+					checkAllowedValue(issueType, "components", component, problem)
+				}
+			}
+		}
+	}
+	if fallback := r.conf.FallbackIssueType; fallback != "" && metaProject.GetIssueTypeWithName(fallback) == nil {
+		problem("fallback_issue_type %q does not exist in project %q, valid types: %s", fallback, project, strings.Join(issueTypeNames(metaProject), ", "))
+	}
+
+	if r.conf.ReopenState != "" {
+		if err := r.checkTransition(ctx, project, "statusCategory = Done", r.conf.ReopenState, "reopen_state", problem); err != nil {
+			res.Error = err.Error()
+			return res
+		}
+	}
+	if r.conf.AutoResolve != nil {
+		if err := r.checkTransition(ctx, project, "statusCategory != Done", r.conf.AutoResolve.State, "auto_resolve state", problem); err != nil {
+			res.Error = err.Error()
+			return res
+		}
+	}
+	return res
+}
+
+// ValidateFields verifies that every field the receiver sets, the configured fields as well as the identity and managed
+// fields, can be set on issues of the receiver's issue type, and that the fields Jira requires are set. If not, the
+// result includes the schema of the issue type's fields. Templated projects and issue types are skipped.
+func (r *Receiver) ValidateFields(ctx context.Context) *ValidationResult {
+	res := &ValidationResult{Receiver: r.conf.Name, Problems: []string{}}
+	project, issueTypeName := r.conf.Project, r.conf.IssueType
+	if isTemplated(project) || isTemplated(issueTypeName) {
+		level.Debug(r.logger).Log("msg", "project or issue type is templated, skipping field validation", "receiver", r.conf.Name)
+		return res
+	}
+
+	meta, resp, err := r.client.GetCreateMetaWithContext(ctx, project)
+	if err != nil {
+		_, err = handleJiraErrResponse("Issue.GetCreateMeta", resp, err, r.conf.Retry, r.logger)
+		res.Error = err.Error()
+		return res
+	}
+	metaProject := meta.GetProjectWithKey(project)
+	if metaProject == nil {
+		res.Problems = append(res.Problems, fmt.Sprintf("project %q does not exist or the user lacks permission to create issues in it", project))
+		return res
+	}
+	issueType := metaProject.GetIssueTypeWithName(issueTypeName)
+	if issueType == nil {
+		res.Problems = append(res.Problems, fmt.Sprintf("issue type %q does not exist in project %q, valid types: %s", issueTypeName, project, strings.Join(issueTypeNames(metaProject), ", ")))
+		return res
+	}
+
+	set := r.setFields()
+	for _, key := range sortedKeys(set) {
+		if _, ok := issueType.Fields[key]; !ok {
+			res.Problems = append(res.Problems, fmt.Sprintf("field %s cannot be set on issues of type %q in project %q", key, issueTypeName, project))
+		}
+	}
+	schema := fieldSchema(issueType)
+	for _, f := range schema {
+		if _, ok := set[f.Key]; !ok && f.Required && !hasDefaultValue(issueType, f.Key) {
+			res.Problems = append(res.Problems, fmt.Sprintf("required field %s (%s) of issues of type %q in project %q is not set", f.Key, f.Name, issueTypeName, project))
+		}
+	}
+	if len(res.Problems) > 0 {
+		res.Schema = schema
+	}
+	return res
+}
+
+// setFields returns the keys of the fields set on the issues the receiver creates.
+func (r *Receiver) setFields() map[string]struct{} {
+	set := map[string]struct{}{}
+	for _, key := range []string{"project", "issuetype", "summary", "description", "labels"} {
+		set[key] = struct{}{}
+	}
+	if r.conf.Priority != "" {
+		set["priority"] = struct{}{}
+	}
+	if len(r.conf.Components) > 0 {
+		set["components"] = struct{}{}
+	}
+	if r.conf.Assignee != "" {
+		set["assignee"] = struct{}{}
+	}
+	if r.conf.Reporter != "" {
+		set["reporter"] = struct{}{}
+	}
+	for key := range r.conf.Fields {
+		set[key] = struct{}{}
+	}
+	if id := r.conf.Identity; id != nil && (id.Strategy == config.IdentityField || id.MigrateFrom == config.IdentityField) {
+		set[id.Field] = struct{}{}
+	}
+	if r.conf.ManagedFields != nil {
+		for _, key := range r.conf.ManagedFields.IDs() {
+			set[key] = struct{}{}
+		}
+	}
+	return set
+}
+
+// fieldSchema returns the fields of the issue type, sorted by key.
+func fieldSchema(issueType *jira.MetaIssueType) []FieldSchema {
+	schema := make([]FieldSchema, 0, len(issueType.Fields))
+	for _, key := range sortedKeys(issueType.Fields) {
+		f, _ := issueType.Fields[key].(map[string]interface{})
+		s := FieldSchema{Key: key}
+		s.Name, _ = f["name"].(string)
+		s.Required, _ = f["required"].(bool)
+		if t, ok := f["schema"].(map[string]interface{}); ok {
+			s.Type, _ = t["type"].(string)
+			if items, ok := t["items"].(string); ok {
+				s.Type += "<" + items + ">"
+			}
+		}
+		schema = append(schema, s)
+	}
+	return schema
+}
+
+// hasDefaultValue reports whether Jira sets the field of the issue type when not set, e.g. the reporter.
+func hasDefaultValue(issueType *jira.MetaIssueType, key string) bool {
+	f, _ := issueType.Fields[key].(map[string]interface{})
+	ok, _ := f["hasDefaultValue"].(bool)
+	return ok
+}
+
+func sortedKeys[V any](m map[string]V) []string {
+	keys := make([]string, 0, len(m))
+	for k := range m {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	return keys
+}
+
+// checkTransition verifies that the most recently updated issue of the project matching cond can be transitioned
+// to state.
+func (r *Receiver) checkTransition(ctx context.Context, project, cond, state, setting string, problem func(string, ...interface{})) error {
+	query := fmt.Sprintf("project = '%s' and %s order by updated desc", project, cond)
+	issues, resp, err := r.client.SearchWithContext(ctx, query, &jira.SearchOptions{Fields: []string{"status"}, MaxResults: 1})
+	if err != nil {
+		_, err = handleJiraErrResponse("Issue.Search", resp, err, r.conf.Retry, r.logger)
+		return err
+	}
+	if len(issues) == 0 {
+		level.Debug(r.logger).Log("msg", "no issue to check transitions on", "receiver", r.conf.Name, "query", query)
+		return nil
+	}
+
+	transitions, resp, err := r.client.GetTransitionsWithContext(ctx, issues[0].Key)
+	if err != nil {
+		_, err = handleJiraErrResponse("Issue.GetTransitions", resp, err, r.conf.Retry, r.logger)
+		return err
+	}
+	names := make([]string, 0, len(transitions))
+	for _, t := range transitions {
+		if t.Name == state {
+			return nil
+		}
+		names = append(names, fmt.Sprintf("%q", t.Name))
+	}
+	status := "<unknown>"
+	if issues[0].Fields != nil && issues[0].Fields.Status != nil {
+		status = issues[0].Fields.Status.Name
+	}
+	problem("%s %q is not a transition of %s (status %q), available transitions: %s", setting, state, issues[0].Key, status, strings.Join(names, ", "))
+	return nil
+}
+
+// checkAllowedValue verifies that value is one of the names allowed for the given field of the issue type.
+func checkAllowedValue(issueType *jira.MetaIssueType, field, value string, problem func(string, ...interface{})) {
+	f, ok := issueType.Fields[field].(map[string]interface{})
+	if !ok {
+		problem("field %s cannot be set on issues of type %q", field, issueType.Name)
+		return
+	}
+	allowed, ok := f["allowedValues"].([]interface{})
+	if !ok {
+		return
+	}
+	names := make([]string, 0, len(allowed))
+	for _, a := range allowed {
+		if v, ok := a.(map[string]interface{}); ok {
+			name, _ := v["name"].(string)
+			if name == value {
+				return
+			}
+			names = append(names, fmt.Sprintf("%q", name))
+		}
+	}
+	problem("%s %q is not valid for issues of type %q, valid values: %s", field, value, issueType.Name, strings.Join(names, ", "))
+}
+
+func issueTypeNames(p *jira.MetaProject) []string {
+	names := make([]string, 0, len(p.IssueTypes))
+	for _, t := range p.IssueTypes {
+		names = append(names, fmt.Sprintf("%q", t.Name))
+	}
+	return names
+}
+
+func isTemplated(s string) bool {
+	return strings.Contains(s, "{{")
+}
@@ -0,0 +1,135 @@
+// Copyright 2017 The Prometheus Authors
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+package notify
+
+import (
+	"context"
+	"testing"
+
+	"github.com/andygrunwald/go-jira"
+	"github.com/go-kit/log"
+	"github.com/prometheus-community/jiralert/pkg/config"
+	"github.com/prometheus-community/jiralert/pkg/template"
+	"github.com/stretchr/testify/require"
+	"github.com/trivago/tgo/tcontainer"
+)
+
+func TestValidate(t *testing.T) {
+	fakeJira := newTestFakeJira()
+	fakeJira.createMeta = jira.CreateMetaInfo{Projects: []*jira.MetaProject{{
+		Key: "abc",
+		IssueTypes: []*jira.MetaIssueType{{
+			Name: "Bug",
+			Fields: tcontainer.MarshalMap{
+				"priority":   map[string]interface{}{"allowedValues": []interface{}{map[string]interface{}{"name": "High"}}},
+				"components": map[string]interface{}{"allowedValues": []interface{}{map[string]interface{}{"name": "API"}}},
+			},
+		}},
+	}}}
+	fakeJira.issuesByKey["1"] = &jira.Issue{Key: "1", Fields: &jira.IssueFields{Status: &jira.Status{Name: "Done"}}}
+	fakeJira.keysByQuery["project = 'abc' and statusCategory = Done order by updated desc"] = []string{"1"}
+
+	validate := func(conf *config.ReceiverConfig) *ValidationResult {
+		conf.Name = "jira"
+		return NewReceiver(log.NewNopLogger(), conf, template.SimpleTemplate(), fakeJira).Validate(context.Background())
+	}
+
+	conf := testReceiverConfig1()
+	conf.IssueType = "Bug"
+	conf.Priority = "High"
+	conf.Components = []string{"API", "{{ .CommonLabels.component }}"}
+	conf.ReopenState = "Done"
+	res := validate(conf)
+	require.True(t, res.OK(), "%v", res.Problems)
+
+	conf.Priority = "Highest"
+	conf.Components = []string{"UI"}
+	conf.ReopenState = "reopened"
+	res = validate(conf)
+	require.Equal(t, []string{
+		`priority "Highest" is not valid for issues of type "Bug", valid values: "High"`,
+		`components "UI" is not valid for issues of type "Bug", valid values: "API"`,
+		`reopen_state "reopened" is not a transition of 1 (status "Done"), available transitions: "Done"`,
+	}, res.Problems)
+
+	conf.IssueType = "Task"
+	res = validate(conf)
+	require.Equal(t, `issue type "Task" does not exist in project "abc", valid types: "Bug"`, res.Problems[0])
+
+	conf.Project = "xyz"
+	res = validate(conf)
+	require.Equal(t, []string{`project "xyz" does not exist or the user lacks permission to create issues in it`}, res.Problems)
+
+	// Templated projects are only known once alerts arrive.
+	conf.Project = "{{ .CommonLabels.project }}"
+	require.True(t, validate(conf).OK())
+}
+
+func TestValidateFields(t *testing.T) {
+	field := func(name, typ string, required bool) map[string]interface{} {
+		return map[string]interface{}{"name": name, "required": required, "schema": map[string]interface{}{"type": typ}}
+	}
+	fakeJira := newTestFakeJira()
+	fakeJira.createMeta = jira.CreateMetaInfo{Projects: []*jira.MetaProject{{
+		Key: "abc",
+		IssueTypes: []*jira.MetaIssueType{{
+			Name: "Bug",
+			Fields: tcontainer.MarshalMap{
+				"project":           field("Project", "project", true),
+				"issuetype":         field("Issue Type", "issuetype", true),
+				"summary":           field("Summary", "string", true),
+				"description":       field("Description", "string", false),
+				"labels":            map[string]interface{}{"name": "Labels", "schema": map[string]interface{}{"type": "array", "items": "string"}},
+				"reporter":          map[string]interface{}{"name": "Reporter", "required": true, "hasDefaultValue": true, "schema": map[string]interface{}{"type": "user"}},
+				"customfield_10001": field("Team", "option", true),
+			},
+		}},
+	}}}
+
+	validate := func(conf *config.ReceiverConfig) *ValidationResult {
+		conf.Name = "jira"
+		return NewReceiver(log.NewNopLogger(), conf, template.SimpleTemplate(), fakeJira).ValidateFields(context.Background())
+	}
+
+	conf := testReceiverConfig1()
+	conf.IssueType = "Bug"
+	conf.Fields = map[string]interface{}{"customfield_10001": map[string]interface{}{"value": "SRE"}}
+	res := validate(conf)
+	require.True(t, res.OK(), "%v", res.Problems)
+	require.Empty(t, res.Schema)
+
+	conf.Fields = map[string]interface{}{"customfield_12345": "{{ .CommonLabels.team }}"}
+	conf.ManagedFields = &config.ManagedFieldsConfig{FireCount: "customfield_10010"}
+	res = validate(conf)
+	require.Equal(t, []string{
+		`field customfield_10010 cannot be set on issues of type "Bug" in project "abc"`,
+		`field customfield_12345 cannot be set on issues of type "Bug" in project "abc"`,
+		`required field customfield_10001 (Team) of issues of type "Bug" in project "abc" is not set`,
+	}, res.Problems)
+	require.Equal(t, []FieldSchema{
+		{Key: "customfield_10001", Name: "Team", Type: "option", Required: true},
+		{Key: "description", Name: "Description", Type: "string"},
+		{Key: "issuetype", Name: "Issue Type", Type: "issuetype", Required: true},
+		{Key: "labels", Name: "Labels", Type: "array<string>"},
+		{Key: "project", Name: "Project", Type: "project", Required: true},
+		{Key: "reporter", Name: "Reporter", Type: "user", Required: true},
+		{Key: "summary", Name: "Summary", Type: "string", Required: true},
+	}, res.Schema)
+
+	conf.IssueType = "Task"
+	require.Equal(t, []string{`issue type "Task" does not exist in project "abc", valid types: "Bug"`}, validate(conf).Problems)
+
+	// Templated issue types are only known once alerts arrive.
+	conf.IssueType = "{{ .CommonLabels.type }}"
+	require.True(t, validate(conf).OK())
+}
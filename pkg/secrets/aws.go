@@ -0,0 +1,137 @@
+// Copyright 2017 The Prometheus Authors
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package secrets
+
+import (
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	v4 "github.com/aws/aws-sdk-go-v2/aws/signer/v4"
+	awsconfig "github.com/aws/aws-sdk-go-v2/config"
+	"github.com/pkg/errors"
+)
+
+// AWSProvider reads secrets from AWS Secrets Manager. Secrets are addressed by name or ARN; the region of ARNs
+// takes precedence over Region.
+type AWSProvider struct {
+	// Credentials sign the requests, e.g. those of the default credential chain of the AWS SDK.
+	Credentials aws.CredentialsProvider
+	Region      string
+	// Endpoint overrides the regional Secrets Manager endpoint, e.g. for VPC endpoints.
+	Endpoint string
+	Client   *http.Client
+
+	// err is why the AWS configuration could not be loaded, returned by Fetch.
+	err     error
+	timeNow func() time.Time
+}
+
+// NewAWSProviderFromEnv returns an AWS provider using the default credential chain and region of the AWS SDK: the
+// standard AWS_* environment variables, the shared configuration and credentials files, web identity tokens, and
+// container and EC2 instance roles.
+func NewAWSProviderFromEnv(client *http.Client) *AWSProvider {
+	p := &AWSProvider{
+		Endpoint: os.Getenv("AWS_ENDPOINT_URL_SECRETS_MANAGER"),
+		Client:   client,
+		timeNow:  time.Now,
+	}
+	// Credentials are only retrieved when signing requests.
+	cfg, err := awsconfig.LoadDefaultConfig(context.Background())
+	if err != nil {
+		p.err = errors.Wrap(err, "load AWS configuration")
+		return p
+	}
+	p.Credentials, p.Region = cfg.Credentials, cfg.Region
+	return p
+}
+
+func (p *AWSProvider) Fetch(id string) (string, time.Duration, error) {
+	if p.err != nil {
+		return "", 0, p.err
+	}
+	if p.Credentials == nil {
+		return "", 0, errors.New("no AWS credentials configured")
+	}
+	region := p.Region
+	// arn:aws:secretsmanager:<region>:<account>:secret:<name>
+	if parts := strings.Split(id, ":"); len(parts) > 3 && parts[0] == "arn" {
+		region = parts[3]
+	}
+	if region == "" {
+		return "", 0, errors.New("AWS region unknown, set AWS_REGION")
+	}
+	endpoint := p.Endpoint
+	if endpoint == "" {
+		endpoint = fmt.Sprintf("https://secretsmanager.%s.amazonaws.com", region)
+	}
+
+	body, err := json.Marshal(map[string]string{"SecretId": id})
+	if err != nil {
+		return "", 0, err
+	}
+	ctx := context.Background()
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, strings.TrimSuffix(endpoint, "/")+"/", bytes.NewReader(body))
+	if err != nil {
+		return "", 0, err
+	}
+	req.Header.Set("Content-Type", "application/x-amz-json-1.1")
+	req.Header.Set("X-Amz-Target", "secretsmanager.GetSecretValue")
+
+	creds, err := p.Credentials.Retrieve(ctx)
+	if err != nil {
+		return "", 0, errors.Wrap(err, "retrieve AWS credentials")
+	}
+	now := time.Now
+	if p.timeNow != nil {
+		now = p.timeNow
+	}
+	payloadHash := sha256.Sum256(body)
+	if err := v4.NewSigner().SignHTTP(ctx, creds, req, hex.EncodeToString(payloadHash[:]), "secretsmanager", region, now()); err != nil {
+		return "", 0, errors.Wrap(err, "sign AWS request")
+	}
+
+	resp, err := p.Client.Do(req)
+	if err != nil {
+		return "", 0, errors.Wrap(err, "request AWS secret")
+	}
+	defer resp.Body.Close()
+	respBody, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return "", 0, errors.Wrap(err, "read AWS response")
+	}
+	if resp.StatusCode != http.StatusOK {
+		return "", 0, errors.Errorf("request AWS secret: status %d: %s", resp.StatusCode, respBody)
+	}
+
+	var secret struct {
+		SecretString *string `json:"SecretString"`
+	}
+	if err := json.Unmarshal(respBody, &secret); err != nil {
+		return "", 0, errors.Wrap(err, "decode AWS response")
+	}
+	if secret.SecretString == nil {
+		return "", 0, errors.New("binary AWS secrets are not supported")
+	}
+	return *secret.SecretString, 0, nil
+}
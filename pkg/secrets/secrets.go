@@ -0,0 +1,186 @@
+// Copyright 2017 The Prometheus Authors
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package secrets resolves credentials referenced from the configuration and held by external secret providers.
+package secrets
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"os"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/pkg/errors"
+	"github.com/prometheus-community/jiralert/pkg/config"
+	"golang.org/x/sync/singleflight"
+)
+
+// defaultRefreshInterval is how long resolved secrets are cached unless configured otherwise.
+const defaultRefreshInterval = 5 * time.Minute
+
+// Provider fetches secrets from a secret store.
+type Provider interface {
+	// Fetch returns the secret at path, along with how long it may be cached (zero if unknown).
+	Fetch(path string) (string, time.Duration, error)
+}
+
+// Resolver resolves secret references, caching resolved values.
+type Resolver struct {
+	providers map[string]Provider
+
+	mtx   sync.Mutex
+	cache map[config.SecretRef]*entry
+	// fetches deduplicates concurrent fetches of a secret, e.g. by all receivers once its cached value expired.
+	fetches singleflight.Group
+
+	timeNow func() time.Time
+}
+
+type entry struct {
+	value   string
+	expires time.Time
+}
+
+// NewResolver returns a resolver using the given providers, keyed by name.
+func NewResolver(providers map[string]Provider) *Resolver {
+	return &Resolver{
+		providers: providers,
+		cache:     map[config.SecretRef]*entry{},
+		timeNow:   time.Now,
+	}
+}
+
+var (
+	defaultResolverOnce sync.Once
+	defaultResolver     *Resolver
+)
+
+// DefaultResolver returns the resolver shared by all receivers, using the env, file, vault and aws providers
+// configured from the environment.
+func DefaultResolver() *Resolver {
+	defaultResolverOnce.Do(func() {
+		client := &http.Client{Timeout: time.Minute}
+		defaultResolver = NewResolver(map[string]Provider{
+			config.SecretProviderEnv:   envProvider{},
+			config.SecretProviderFile:  fileProvider{},
+			config.SecretProviderVault: &VaultProvider{Addr: os.Getenv("VAULT_ADDR"), Token: os.Getenv("VAULT_TOKEN"), Client: client},
+			config.SecretProviderAWS:   NewAWSProviderFromEnv(client),
+		})
+	})
+	return defaultResolver
+}
+
+// Resolve returns the value of the referenced secret. Cached values are renewed once their refresh interval has
+// passed; if renewal fails, the previous value is used until the provider recovers.
+func (r *Resolver) Resolve(ref config.SecretRef) (string, error) {
+	// Pointers differ between otherwise identical references, so key the cache by interval value.
+	var interval time.Duration
+	if ref.RefreshInterval != nil {
+		interval = time.Duration(*ref.RefreshInterval)
+	}
+	key := ref
+	key.RefreshInterval = nil
+
+	r.mtx.Lock()
+	cached, ok := r.cache[key]
+	r.mtx.Unlock()
+	if ok && r.timeNow().Before(cached.expires) {
+		return cached.value, nil
+	}
+
+	// Providers are not called with the lock held, so that a slow provider doesn't hold up cached secrets, and
+	// concurrent fetches of the same secret share a single call.
+	res, err, _ := r.fetches.Do(strings.Join([]string{key.Provider, key.Path, key.Key}, "\x00"), func() (interface{}, error) {
+		value, ttl, err := r.fetch(ref)
+		return fetched{value: value, ttl: ttl}, err
+	})
+	if err != nil {
+		if ok {
+			return cached.value, nil
+		}
+		return "", errors.Wrapf(err, "resolve %s secret %q", ref.Provider, ref.Path)
+	}
+	f := res.(fetched)
+	if interval == 0 {
+		interval = f.ttl
+	}
+	if interval <= 0 {
+		interval = defaultRefreshInterval
+	}
+	r.mtx.Lock()
+	defer r.mtx.Unlock()
+	r.cache[key] = &entry{value: f.value, expires: r.timeNow().Add(interval)}
+	return f.value, nil
+}
+
+// fetched is the outcome of a fetch shared by concurrent Resolve calls.
+type fetched struct {
+	value string
+	ttl   time.Duration
+}
+
+// Func returns a function resolving ref on each call, as used by the authenticating transports.
+func (r *Resolver) Func(ref config.SecretRef) func() (string, error) {
+	return func() (string, error) { return r.Resolve(ref) }
+}
+
+func (r *Resolver) fetch(ref config.SecretRef) (string, time.Duration, error) {
+	p, ok := r.providers[ref.Provider]
+	if !ok {
+		return "", 0, errors.Errorf("unknown provider %q", ref.Provider)
+	}
+	value, ttl, err := p.Fetch(ref.Path)
+	if err != nil {
+		return "", 0, err
+	}
+	if ref.Key == "" {
+		return strings.TrimSpace(value), ttl, nil
+	}
+	var fields map[string]interface{}
+	if err := json.Unmarshal([]byte(value), &fields); err != nil {
+		return "", 0, errors.Wrap(err, "decode secret as JSON object")
+	}
+	v, ok := fields[ref.Key]
+	if !ok {
+		return "", 0, errors.Errorf("secret has no key %q", ref.Key)
+	}
+	if s, ok := v.(string); ok {
+		return s, ttl, nil
+	}
+	return fmt.Sprint(v), ttl, nil
+}
+
+// envProvider reads secrets from environment variables.
+type envProvider struct{}
+
+func (envProvider) Fetch(name string) (string, time.Duration, error) {
+	v, ok := os.LookupEnv(name)
+	if !ok {
+		return "", 0, errors.Errorf("environment variable %s not set", name)
+	}
+	return v, 0, nil
+}
+
+// fileProvider reads secrets from files.
+type fileProvider struct{}
+
+func (fileProvider) Fetch(path string) (string, time.Duration, error) {
+	b, err := os.ReadFile(path)
+	if err != nil {
+		return "", 0, err
+	}
+	return string(b), 0, nil
+}
@@ -0,0 +1,277 @@
+// Copyright 2017 The Prometheus Authors
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+package secrets
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"path/filepath"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/aws/aws-sdk-go-v2/credentials"
+	"github.com/pkg/errors"
+	"github.com/prometheus-community/jiralert/pkg/config"
+	"github.com/stretchr/testify/require"
+)
+
+type fakeProvider struct {
+	value string
+	ttl   time.Duration
+	err   error
+	calls int
+}
+
+func (p *fakeProvider) Fetch(string) (string, time.Duration, error) {
+	p.calls++
+	return p.value, p.ttl, p.err
+}
+
+func TestResolver(t *testing.T) {
+	p := &fakeProvider{value: "s3cr3t\n"}
+	r := NewResolver(map[string]Provider{"fake": p})
+	now := time.Unix(0, 0)
+	r.timeNow = func() time.Time { return now }
+
+	interval := config.Duration(time.Minute)
+	ref := config.SecretRef{Provider: "fake", Path: "jira", RefreshInterval: &interval}
+	v, err := r.Resolve(ref)
+	require.NoError(t, err)
+	require.Equal(t, "s3cr3t", v)
+
+	// Cached until the refresh interval passes, also for copies of the reference.
+	p.value = "rotated"
+	interval2 := interval
+	ref2 := ref
+	ref2.RefreshInterval = &interval2
+	v, err = r.Resolve(ref2)
+	require.NoError(t, err)
+	require.Equal(t, "s3cr3t", v)
+	require.Equal(t, 1, p.calls)
+
+	now = now.Add(2 * time.Minute)
+	v, err = r.Resolve(ref)
+	require.NoError(t, err)
+	require.Equal(t, "rotated", v)
+
+	// Failing renewals keep the previous value.
+	now = now.Add(2 * time.Minute)
+	p.err = errors.New("unavailable")
+	v, err = r.Resolve(ref)
+	require.NoError(t, err)
+	require.Equal(t, "rotated", v)
+
+	_, err = r.Resolve(config.SecretRef{Provider: "fake", Path: "other"})
+	require.Error(t, err)
+	_, err = r.Resolve(config.SecretRef{Provider: "unknown", Path: "jira"})
+	require.Error(t, err)
+}
+
+type blockingProvider struct {
+	fetching, release chan struct{}
+}
+
+func (p *blockingProvider) Fetch(string) (string, time.Duration, error) {
+	close(p.fetching)
+	<-p.release
+	return "slow", 0, nil
+}
+
+func TestResolverSlowProvider(t *testing.T) {
+	slow := &blockingProvider{fetching: make(chan struct{}), release: make(chan struct{})}
+	r := NewResolver(map[string]Provider{"fake": &fakeProvider{value: "fast"}, "slow": slow})
+	_, err := r.Resolve(config.SecretRef{Provider: "fake", Path: "jira"})
+	require.NoError(t, err)
+
+	done := make(chan string)
+	go func() {
+		v, _ := r.Resolve(config.SecretRef{Provider: "slow", Path: "jira"})
+		done <- v
+	}()
+	<-slow.fetching
+	// Cached secrets are resolved while another secret is fetched.
+	v, err := r.Resolve(config.SecretRef{Provider: "fake", Path: "jira"})
+	require.NoError(t, err)
+	require.Equal(t, "fast", v)
+	close(slow.release)
+	require.Equal(t, "slow", <-done)
+}
+
+type countingProvider struct {
+	release chan struct{}
+	calls   int32
+}
+
+func (p *countingProvider) Fetch(string) (string, time.Duration, error) {
+	atomic.AddInt32(&p.calls, 1)
+	<-p.release
+	return "rotated", 0, nil
+}
+
+func TestResolverConcurrentFetches(t *testing.T) {
+	p := &countingProvider{release: make(chan struct{})}
+	r := NewResolver(map[string]Provider{"fake": p})
+	ref := config.SecretRef{Provider: "fake", Path: "jira"}
+	// All receivers resolve the secret once its cached value expired.
+	r.cache[ref] = &entry{value: "s3cr3t", expires: time.Unix(0, 0)}
+
+	const n = 10
+	var checked, resolved sync.WaitGroup
+	checked.Add(n)
+	r.timeNow = func() time.Time {
+		checked.Done()
+		return time.Unix(1, 0)
+	}
+	values := make(chan string, n)
+	for i := 0; i < n; i++ {
+		resolved.Add(1)
+		go func() {
+			defer resolved.Done()
+			v, err := r.Resolve(ref)
+			require.NoError(t, err)
+			values <- v
+		}()
+	}
+	checked.Wait()
+	r.timeNow = func() time.Time { return time.Unix(1, 0) }
+	// Let all calls join the fetch of the first one.
+	time.Sleep(50 * time.Millisecond)
+	close(p.release)
+	resolved.Wait()
+	close(values)
+
+	for v := range values {
+		require.Equal(t, "rotated", v)
+	}
+	require.Equal(t, int32(1), atomic.LoadInt32(&p.calls))
+}
+
+func TestResolverKey(t *testing.T) {
+	r := NewResolver(map[string]Provider{"fake": &fakeProvider{value: `{"user": "jiralert", "password": "s3cr3t", "pin": 1234}`}})
+
+	v, err := r.Resolve(config.SecretRef{Provider: "fake", Path: "jira", Key: "password"})
+	require.NoError(t, err)
+	require.Equal(t, "s3cr3t", v)
+
+	v, err = r.Resolve(config.SecretRef{Provider: "fake", Path: "jira", Key: "pin"})
+	require.NoError(t, err)
+	require.Equal(t, "1234", v)
+
+	_, err = r.Resolve(config.SecretRef{Provider: "fake", Path: "jira", Key: "token"})
+	require.Error(t, err)
+}
+
+func TestEnvProvider(t *testing.T) {
+	t.Setenv("JIRALERT_TEST_PASSWORD", "s3cr3t")
+	r := NewResolver(map[string]Provider{config.SecretProviderEnv: envProvider{}})
+
+	v, err := r.Resolve(config.SecretRef{Provider: config.SecretProviderEnv, Path: "JIRALERT_TEST_PASSWORD"})
+	require.NoError(t, err)
+	require.Equal(t, "s3cr3t", v)
+
+	_, err = r.Resolve(config.SecretRef{Provider: config.SecretProviderEnv, Path: "JIRALERT_TEST_UNSET"})
+	require.Error(t, err)
+}
+
+func TestVaultProvider(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		if req.Header.Get("X-Vault-Token") != "root" {
+			w.WriteHeader(http.StatusForbidden)
+			return
+		}
+		switch req.URL.Path {
+		case "/v1/secret/data/jiralert":
+			_, _ = w.Write([]byte(`{"lease_duration": 0, "data": {"data": {"password": "kv2"}, "metadata": {"version": 3}}}`))
+		case "/v1/kv/jiralert":
+			_, _ = w.Write([]byte(`{"lease_duration": 600, "data": {"password": "kv1"}}`))
+		default:
+			w.WriteHeader(http.StatusNotFound)
+		}
+	}))
+	defer srv.Close()
+
+	p := &VaultProvider{Addr: srv.URL, Token: "root", Client: srv.Client()}
+	v, ttl, err := p.Fetch("secret/data/jiralert")
+	require.NoError(t, err)
+	require.JSONEq(t, `{"password": "kv2"}`, v)
+	require.Equal(t, time.Duration(0), ttl)
+
+	v, ttl, err = p.Fetch("kv/jiralert")
+	require.NoError(t, err)
+	require.JSONEq(t, `{"password": "kv1"}`, v)
+	require.Equal(t, 10*time.Minute, ttl)
+
+	_, _, err = p.Fetch("secret/data/missing")
+	require.Error(t, err)
+}
+
+func newTestAWSServer(t *testing.T) *httptest.Server {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		auth := req.Header.Get("Authorization")
+		if !strings.HasPrefix(auth, "AWS4-HMAC-SHA256 Credential=AKID/20221021/eu-west-1/secretsmanager/aws4_request, ") ||
+			req.Header.Get("X-Amz-Target") != "secretsmanager.GetSecretValue" ||
+			req.Header.Get("X-Amz-Security-Token") != "session" {
+			w.WriteHeader(http.StatusForbidden)
+			return
+		}
+		var in struct{ SecretId string }
+		if err := json.NewDecoder(req.Body).Decode(&in); err != nil {
+			w.WriteHeader(http.StatusBadRequest)
+			return
+		}
+		_ = json.NewEncoder(w).Encode(map[string]string{"SecretString": "value of " + in.SecretId})
+	}))
+	t.Cleanup(srv.Close)
+	return srv
+}
+
+func TestAWSProvider(t *testing.T) {
+	srv := newTestAWSServer(t)
+	p := &AWSProvider{
+		Credentials: credentials.NewStaticCredentialsProvider("AKID", "secret", "session"),
+		Region:      "us-east-1",
+		Endpoint:    srv.URL,
+		Client:      srv.Client(),
+		timeNow:     func() time.Time { return time.Date(2022, 10, 21, 0, 0, 0, 0, time.UTC) },
+	}
+	v, _, err := p.Fetch("arn:aws:secretsmanager:eu-west-1:123456789012:secret:jiralert")
+	require.NoError(t, err)
+	require.Equal(t, "value of arn:aws:secretsmanager:eu-west-1:123456789012:secret:jiralert", v)
+
+	p.Credentials = nil
+	_, _, err = p.Fetch("jiralert")
+	require.EqualError(t, err, "no AWS credentials configured")
+}
+
+func TestNewAWSProviderFromEnv(t *testing.T) {
+	srv := newTestAWSServer(t)
+	// Keep the shared configuration of the host out of the credential chain.
+	dir := t.TempDir()
+	t.Setenv("AWS_CONFIG_FILE", filepath.Join(dir, "config"))
+	t.Setenv("AWS_SHARED_CREDENTIALS_FILE", filepath.Join(dir, "credentials"))
+	t.Setenv("AWS_ACCESS_KEY_ID", "AKID")
+	t.Setenv("AWS_SECRET_ACCESS_KEY", "secret")
+	t.Setenv("AWS_SESSION_TOKEN", "session")
+	t.Setenv("AWS_REGION", "eu-west-1")
+	t.Setenv("AWS_ENDPOINT_URL_SECRETS_MANAGER", srv.URL)
+
+	p := NewAWSProviderFromEnv(srv.Client())
+	p.timeNow = func() time.Time { return time.Date(2022, 10, 21, 0, 0, 0, 0, time.UTC) }
+	v, _, err := p.Fetch("jiralert")
+	require.NoError(t, err)
+	require.Equal(t, "value of jiralert", v)
+}
@@ -0,0 +1,73 @@
+// Copyright 2017 The Prometheus Authors
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package secrets
+
+import (
+	"encoding/json"
+	"io"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/pkg/errors"
+)
+
+// VaultProvider reads secrets from HashiCorp Vault, returning their data as a JSON object. Both KV version 1 and
+// version 2 (whose paths contain "data/") secrets engines are supported.
+type VaultProvider struct {
+	Addr   string
+	Token  string
+	Client *http.Client
+}
+
+func (p *VaultProvider) Fetch(path string) (string, time.Duration, error) {
+	if p.Addr == "" {
+		return "", 0, errors.New("VAULT_ADDR not set")
+	}
+	req, err := http.NewRequest(http.MethodGet, strings.TrimSuffix(p.Addr, "/")+"/v1/"+strings.TrimPrefix(path, "/"), nil)
+	if err != nil {
+		return "", 0, err
+	}
+	req.Header.Set("X-Vault-Token", p.Token)
+
+	resp, err := p.Client.Do(req)
+	if err != nil {
+		return "", 0, errors.Wrap(err, "request Vault secret")
+	}
+	defer resp.Body.Close()
+	body, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return "", 0, errors.Wrap(err, "read Vault response")
+	}
+	if resp.StatusCode != http.StatusOK {
+		return "", 0, errors.Errorf("request Vault secret: status %d: %s", resp.StatusCode, body)
+	}
+
+	var secret struct {
+		LeaseDuration int64                      `json:"lease_duration"`
+		Data          map[string]json.RawMessage `json:"data"`
+	}
+	if err := json.Unmarshal(body, &secret); err != nil {
+		return "", 0, errors.Wrap(err, "decode Vault response")
+	}
+	data, err := json.Marshal(secret.Data)
+	if err != nil {
+		return "", 0, err
+	}
+	// KV version 2 nests the secret data along with its metadata.
+	if nested, ok := secret.Data["data"]; ok && len(secret.Data["metadata"]) > 0 {
+		data = nested
+	}
+	return string(data), time.Duration(secret.LeaseDuration) * time.Second, nil
+}
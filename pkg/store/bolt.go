@@ -0,0 +1,194 @@
+// Copyright 2017 The Prometheus Authors
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package store
+
+import (
+	"context"
+	"encoding/json"
+	"time"
+
+	"github.com/pkg/errors"
+	bolt "go.etcd.io/bbolt"
+)
+
+var (
+	// boltBucket holds the records, by group key.
+	boltBucket = []byte("groups")
+	// boltResolvesBucket holds the pending resolutions, by group key.
+	boltResolvesBucket = []byte("resolves")
+)
+
+// Bolt is a Store keeping records in a BoltDB file, which only one process can open at a time.
+type Bolt struct {
+	db        *bolt.DB
+	retention time.Duration
+
+	timeNow func() time.Time
+}
+
+// OpenBolt opens the BoltDB file at path, creating it if needed. Records are kept for retention once their alert
+// group was resolved.
+func OpenBolt(path string, retention time.Duration) (*Bolt, error) {
+	if path == "" {
+		return nil, errors.New("path of the bolt store is required")
+	}
+	// Fail rather than wait forever if another process holds the file.
+	db, err := bolt.Open(path, 0o600, &bolt.Options{Timeout: 10 * time.Second})
+	if err != nil {
+		return nil, errors.Wrapf(err, "open bolt store %s", path)
+	}
+	if err := db.Update(func(tx *bolt.Tx) error {
+		for _, name := range [][]byte{boltBucket, boltResolvesBucket} {
+			if _, err := tx.CreateBucketIfNotExists(name); err != nil {
+				return err
+			}
+		}
+		return nil
+	}); err != nil {
+		db.Close()
+		return nil, errors.Wrapf(err, "create buckets in bolt store %s", path)
+	}
+	return &Bolt{db: db, retention: retention, timeNow: time.Now}, nil
+}
+
+// Get implements Store.
+func (b *Bolt) Get(_ context.Context, group string) (Record, bool, error) {
+	var (
+		rec Record
+		ok  bool
+	)
+	err := b.db.View(func(tx *bolt.Tx) error {
+		v := tx.Bucket(boltBucket).Get([]byte(group))
+		if v == nil {
+			return nil
+		}
+		ok = true
+		return json.Unmarshal(v, &rec)
+	})
+	if err != nil {
+		return Record{}, false, errors.Wrapf(err, "get record of %s", group)
+	}
+	if !ok || rec.expired(b.timeNow(), b.retention) {
+		return Record{}, false, nil
+	}
+	return rec, true, nil
+}
+
+// Set implements Store.
+func (b *Bolt) Set(_ context.Context, group string, rec Record) error {
+	v, err := json.Marshal(rec)
+	if err != nil {
+		return err
+	}
+	return errors.Wrapf(b.db.Update(func(tx *bolt.Tx) error {
+		return tx.Bucket(boltBucket).Put([]byte(group), v)
+	}), "set record of %s", group)
+}
+
+// Delete implements Store.
+func (b *Bolt) Delete(_ context.Context, group string) error {
+	return errors.Wrapf(b.db.Update(func(tx *bolt.Tx) error {
+		return tx.Bucket(boltBucket).Delete([]byte(group))
+	}), "delete record of %s", group)
+}
+
+// Prune implements Pruner.
+func (b *Bolt) Prune(_ context.Context) (int, error) {
+	now, pruned := b.timeNow(), 0
+	err := b.db.Update(func(tx *bolt.Tx) error {
+		var expired [][]byte
+		bucket := tx.Bucket(boltBucket)
+		if err := bucket.ForEach(func(k, v []byte) error {
+			var rec Record
+			// Undecodable records are left for Get to report.
+			if json.Unmarshal(v, &rec) == nil && rec.expired(now, b.retention) {
+				expired = append(expired, k)
+			}
+			return nil
+		}); err != nil {
+			return err
+		}
+		// Keys are only valid during the transaction, and mustn't be deleted while iterating.
+		for _, k := range expired {
+			if err := bucket.Delete(k); err != nil {
+				return err
+			}
+		}
+		pruned = len(expired)
+		return nil
+	})
+	return pruned, errors.Wrap(err, "prune records")
+}
+
+// GetResolve implements Store.
+func (b *Bolt) GetResolve(_ context.Context, group string) (PendingResolve, bool, error) {
+	var (
+		res PendingResolve
+		ok  bool
+	)
+	err := b.db.View(func(tx *bolt.Tx) error {
+		v := tx.Bucket(boltResolvesBucket).Get([]byte(group))
+		if v == nil {
+			return nil
+		}
+		ok = true
+		return json.Unmarshal(v, &res)
+	})
+	if err != nil {
+		return PendingResolve{}, false, errors.Wrapf(err, "get pending resolution of %s", group)
+	}
+	return res, ok, nil
+}
+
+// SetResolve implements Store.
+func (b *Bolt) SetResolve(_ context.Context, group string, res PendingResolve) error {
+	v, err := json.Marshal(res)
+	if err != nil {
+		return err
+	}
+	return errors.Wrapf(b.db.Update(func(tx *bolt.Tx) error {
+		return tx.Bucket(boltResolvesBucket).Put([]byte(group), v)
+	}), "set pending resolution of %s", group)
+}
+
+// DeleteResolve implements Store.
+func (b *Bolt) DeleteResolve(_ context.Context, group string) error {
+	return errors.Wrapf(b.db.Update(func(tx *bolt.Tx) error {
+		return tx.Bucket(boltResolvesBucket).Delete([]byte(group))
+	}), "delete pending resolution of %s", group)
+}
+
+// Resolves implements Store.
+func (b *Bolt) Resolves(_ context.Context) (map[string]PendingResolve, error) {
+	resolves := map[string]PendingResolve{}
+	err := b.db.View(func(tx *bolt.Tx) error {
+		return tx.Bucket(boltResolvesBucket).ForEach(func(k, v []byte) error {
+			var res PendingResolve
+			if err := json.Unmarshal(v, &res); err != nil {
+				return errors.Wrapf(err, "decode pending resolution of %s", k)
+			}
+			resolves[string(k)] = res
+			return nil
+		})
+	})
+	if err != nil {
+		return nil, errors.Wrap(err, "list pending resolutions")
+	}
+	return resolves, nil
+}
+
+// Close implements Store.
+func (b *Bolt) Close() error {
+	return b.db.Close()
+}
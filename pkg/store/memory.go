@@ -0,0 +1,117 @@
+// Copyright 2017 The Prometheus Authors
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package store
+
+import (
+	"context"
+	"sync"
+	"time"
+)
+
+// Memory is a Store keeping records in memory.
+type Memory struct {
+	retention time.Duration
+
+	mtx      sync.Mutex
+	records  map[string]Record
+	resolves map[string]PendingResolve
+
+	timeNow func() time.Time
+}
+
+// NewMemory creates an empty Memory store, keeping records for retention once their alert group was resolved.
+func NewMemory(retention time.Duration) *Memory {
+	return &Memory{retention: retention, records: map[string]Record{}, resolves: map[string]PendingResolve{}, timeNow: time.Now}
+}
+
+// Get implements Store.
+func (m *Memory) Get(_ context.Context, group string) (Record, bool, error) {
+	m.mtx.Lock()
+	defer m.mtx.Unlock()
+	rec, ok := m.records[group]
+	if !ok || rec.expired(m.timeNow(), m.retention) {
+		return Record{}, false, nil
+	}
+	return rec, true, nil
+}
+
+// Set implements Store.
+func (m *Memory) Set(_ context.Context, group string, rec Record) error {
+	m.mtx.Lock()
+	defer m.mtx.Unlock()
+	m.records[group] = rec
+	return nil
+}
+
+// Delete implements Store.
+func (m *Memory) Delete(_ context.Context, group string) error {
+	m.mtx.Lock()
+	defer m.mtx.Unlock()
+	delete(m.records, group)
+	return nil
+}
+
+// Prune implements Pruner.
+func (m *Memory) Prune(_ context.Context) (int, error) {
+	m.mtx.Lock()
+	defer m.mtx.Unlock()
+	now, pruned := m.timeNow(), 0
+	for group, rec := range m.records {
+		if rec.expired(now, m.retention) {
+			delete(m.records, group)
+			pruned++
+		}
+	}
+	return pruned, nil
+}
+
+// GetResolve implements Store.
+func (m *Memory) GetResolve(_ context.Context, group string) (PendingResolve, bool, error) {
+	m.mtx.Lock()
+	defer m.mtx.Unlock()
+	res, ok := m.resolves[group]
+	return res, ok, nil
+}
+
+// SetResolve implements Store.
+func (m *Memory) SetResolve(_ context.Context, group string, res PendingResolve) error {
+	m.mtx.Lock()
+	defer m.mtx.Unlock()
+	m.resolves[group] = res
+	return nil
+}
+
+// DeleteResolve implements Store.
+func (m *Memory) DeleteResolve(_ context.Context, group string) error {
+	m.mtx.Lock()
+	defer m.mtx.Unlock()
+	delete(m.resolves, group)
+	return nil
+}
+
+// Resolves implements Store.
+func (m *Memory) Resolves(_ context.Context) (map[string]PendingResolve, error) {
+	m.mtx.Lock()
+	defer m.mtx.Unlock()
+	res := make(map[string]PendingResolve, len(m.resolves))
+	for group, r := range m.resolves {
+		res[group] = r
+	}
+	return res, nil
+}
+
+// Close implements Store.
+func (m *Memory) Close() error {
+	return nil
+}
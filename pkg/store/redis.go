@@ -0,0 +1,195 @@
+// Copyright 2017 The Prometheus Authors
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package store
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"encoding/json"
+	"time"
+
+	"github.com/pkg/errors"
+	"github.com/redis/go-redis/v9"
+)
+
+// redisLockPoll is how often a locked alert group is checked again.
+const redisLockPoll = 100 * time.Millisecond
+
+// redisUnlock deletes a lock only if still held with the given token, as it may have expired and been taken by
+// another replica meanwhile.
+var redisUnlock = redis.NewScript(`
+if redis.call("get", KEYS[1]) == ARGV[1] then
+	return redis.call("del", KEYS[1])
+end
+return 0
+`)
+
+// Redis is a Store keeping records in Redis, so that replicas share them, and locking alert groups across replicas.
+type Redis struct {
+	client    *redis.Client
+	prefix    string
+	retention time.Duration
+}
+
+// OpenRedis connects to the Redis server at the given URL, prefixing keys with prefix. Records expire in Redis
+// after retention once their alert group was resolved.
+func OpenRedis(url, prefix string, retention time.Duration) (*Redis, error) {
+	opts, err := redis.ParseURL(url)
+	if err != nil {
+		return nil, errors.Wrapf(err, "parse Redis URL")
+	}
+	client := redis.NewClient(opts)
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+	if err := client.Ping(ctx).Err(); err != nil {
+		client.Close()
+		return nil, errors.Wrapf(err, "connect to Redis at %s", opts.Addr)
+	}
+	return &Redis{client: client, prefix: prefix, retention: retention}, nil
+}
+
+func (r *Redis) recordKey(group string) string {
+	return r.prefix + "group:" + group
+}
+
+func (r *Redis) lockKey(group string) string {
+	return r.prefix + "lock:" + group
+}
+
+// resolvesKey is the hash of the pending resolutions, by group key, which are listed on startup.
+func (r *Redis) resolvesKey() string {
+	return r.prefix + "resolves"
+}
+
+// Get implements Store.
+func (r *Redis) Get(ctx context.Context, group string) (Record, bool, error) {
+	v, err := r.client.Get(ctx, r.recordKey(group)).Bytes()
+	if err == redis.Nil {
+		return Record{}, false, nil
+	}
+	if err != nil {
+		return Record{}, false, errors.Wrapf(err, "get record of %s", group)
+	}
+	var rec Record
+	if err := json.Unmarshal(v, &rec); err != nil {
+		return Record{}, false, errors.Wrapf(err, "decode record of %s", group)
+	}
+	return rec, true, nil
+}
+
+// Set implements Store.
+func (r *Redis) Set(ctx context.Context, group string, rec Record) error {
+	v, err := json.Marshal(rec)
+	if err != nil {
+		return err
+	}
+	// Records of firing alert groups don't expire, even if they did before.
+	var ttl time.Duration
+	if expires := rec.expires(r.retention); !expires.IsZero() {
+		if ttl = time.Until(expires); ttl <= 0 {
+			return r.Delete(ctx, group)
+		}
+	}
+	return errors.Wrapf(r.client.Set(ctx, r.recordKey(group), v, ttl).Err(), "set record of %s", group)
+}
+
+// Delete implements Store.
+func (r *Redis) Delete(ctx context.Context, group string) error {
+	return errors.Wrapf(r.client.Del(ctx, r.recordKey(group)).Err(), "delete record of %s", group)
+}
+
+// GetResolve implements Store.
+func (r *Redis) GetResolve(ctx context.Context, group string) (PendingResolve, bool, error) {
+	v, err := r.client.HGet(ctx, r.resolvesKey(), group).Bytes()
+	if err == redis.Nil {
+		return PendingResolve{}, false, nil
+	}
+	if err != nil {
+		return PendingResolve{}, false, errors.Wrapf(err, "get pending resolution of %s", group)
+	}
+	var res PendingResolve
+	if err := json.Unmarshal(v, &res); err != nil {
+		return PendingResolve{}, false, errors.Wrapf(err, "decode pending resolution of %s", group)
+	}
+	return res, true, nil
+}
+
+// SetResolve implements Store.
+func (r *Redis) SetResolve(ctx context.Context, group string, res PendingResolve) error {
+	v, err := json.Marshal(res)
+	if err != nil {
+		return err
+	}
+	return errors.Wrapf(r.client.HSet(ctx, r.resolvesKey(), group, v).Err(), "set pending resolution of %s", group)
+}
+
+// DeleteResolve implements Store.
+func (r *Redis) DeleteResolve(ctx context.Context, group string) error {
+	return errors.Wrapf(r.client.HDel(ctx, r.resolvesKey(), group).Err(), "delete pending resolution of %s", group)
+}
+
+// Resolves implements Store.
+func (r *Redis) Resolves(ctx context.Context) (map[string]PendingResolve, error) {
+	values, err := r.client.HGetAll(ctx, r.resolvesKey()).Result()
+	if err != nil {
+		return nil, errors.Wrap(err, "list pending resolutions")
+	}
+	resolves := make(map[string]PendingResolve, len(values))
+	for group, v := range values {
+		var res PendingResolve
+		if err := json.Unmarshal([]byte(v), &res); err != nil {
+			return nil, errors.Wrapf(err, "decode pending resolution of %s", group)
+		}
+		resolves[group] = res
+	}
+	return resolves, nil
+}
+
+// Lock implements Locker.
+func (r *Redis) Lock(ctx context.Context, group string, ttl time.Duration) (func(), error) {
+	b := make([]byte, 16)
+	if _, err := rand.Read(b); err != nil {
+		return nil, err
+	}
+	token, key := hex.EncodeToString(b), r.lockKey(group)
+	for {
+		ok, err := r.client.SetNX(ctx, key, token, ttl).Result()
+		if err != nil {
+			if ctx.Err() != nil {
+				return nil, ctx.Err()
+			}
+			return nil, errors.Wrapf(err, "lock %s", group)
+		}
+		if ok {
+			break
+		}
+		select {
+		case <-ctx.Done():
+			return nil, ctx.Err()
+		case <-time.After(redisLockPoll):
+		}
+	}
+	return func() {
+		// Unlock even if the notification was canceled meanwhile.
+		ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+		defer cancel()
+		_ = redisUnlock.Run(ctx, r.client, []string{key}, token).Err()
+	}, nil
+}
+
+// Close implements Store.
+func (r *Redis) Close() error {
+	return r.client.Close()
+}
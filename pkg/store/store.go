@@ -0,0 +1,135 @@
+// Copyright 2017 The Prometheus Authors
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package store records which issue JIRAlert manages for each alert group, along with when the alert group was last
+// notified and fired, so that notifications fetch the issue by key instead of searching Jira, and the history
+// survives restarts with a persistent backend.
+package store
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"time"
+)
+
+// Backends of the store.
+const (
+	// BackendMemory keeps records in memory, until JIRAlert restarts.
+	BackendMemory = "memory"
+	// BackendBolt keeps records in a BoltDB file.
+	BackendBolt = "bolt"
+	// BackendRedis keeps records in Redis, shared by replicas.
+	BackendRedis = "redis"
+)
+
+// Record is what is known about the issue of an alert group.
+type Record struct {
+	// IssueKey is the key of the issue managing the alert group.
+	IssueKey string `json:"issue_key"`
+	// LastUpdate is when a notification of the alert group was last handled.
+	LastUpdate time.Time `json:"last_update"`
+	// FirstFiring is when the alert group was first notified firing, since the issue was created.
+	FirstFiring time.Time `json:"first_firing,omitempty"`
+	// LastFiring is when the alert group was last notified firing.
+	LastFiring time.Time `json:"last_firing,omitempty"`
+	// ResolvedAt is when the alert group was notified resolved, or zero while it fires. The record is forgotten once
+	// the retention of the store passed since.
+	ResolvedAt time.Time `json:"resolved_at,omitempty"`
+}
+
+// expires returns when the record is forgotten, or zero if it is kept.
+func (r Record) expires(retention time.Duration) time.Time {
+	if retention <= 0 || r.ResolvedAt.IsZero() {
+		return time.Time{}
+	}
+	return r.ResolvedAt.Add(retention)
+}
+
+// expired returns true if the record is forgotten at now.
+func (r Record) expired(now time.Time, retention time.Duration) bool {
+	expires := r.expires(retention)
+	return !expires.IsZero() && !now.Before(expires)
+}
+
+// PendingResolve is the resolution of the issue of an alert group, delayed by the auto_resolve delay of its receiver.
+type PendingResolve struct {
+	// Receiver is the name of the receiver, prefixed with "<tenant>/" for the receivers of tenants.
+	Receiver string `json:"receiver"`
+	// At is when the issue is resolved, unless the alert group fires again before.
+	At time.Time `json:"at"`
+	// Data is the resolved notification of the alert group, in the webhook format.
+	Data json.RawMessage `json:"data"`
+}
+
+// Store records the issues of alert groups, identified by group keys, and the pending resolutions of their issues.
+// Implementations are safe for concurrent use.
+type Store interface {
+	// Get returns the record of the alert group, and whether there is one.
+	Get(ctx context.Context, group string) (Record, bool, error)
+	// Set records the issue of the alert group, replacing any previous record.
+	Set(ctx context.Context, group string, rec Record) error
+	// Delete forgets the alert group.
+	Delete(ctx context.Context, group string) error
+	// GetResolve returns the pending resolution of the issue of the alert group, and whether there is one.
+	GetResolve(ctx context.Context, group string) (PendingResolve, bool, error)
+	// SetResolve records the pending resolution of the issue of the alert group, replacing any previous one.
+	SetResolve(ctx context.Context, group string, res PendingResolve) error
+	// DeleteResolve forgets the pending resolution of the issue of the alert group.
+	DeleteResolve(ctx context.Context, group string) error
+	// Resolves returns all pending resolutions, by alert group.
+	Resolves(ctx context.Context) (map[string]PendingResolve, error)
+	// Close releases the resources of the store.
+	Close() error
+}
+
+// Pruner is implemented by stores which don't expire records by themselves, to delete the expired records
+// periodically. Expired records are not returned in the meantime.
+type Pruner interface {
+	// Prune deletes the expired records, returning how many.
+	Prune(ctx context.Context) (int, error)
+}
+
+// Locker is implemented by stores shared by replicas, to handle one notification of an alert group at a time across
+// them.
+type Locker interface {
+	// Lock locks the alert group until the returned function is called, or until ttl elapses in case the replica
+	// holding the lock dies. It waits for the lock until ctx is done.
+	Lock(ctx context.Context, group string, ttl time.Duration) (func(), error)
+}
+
+// Options configure the backends of the store.
+type Options struct {
+	// Path is the file of the bolt backend.
+	Path string
+	// RedisURL is the URL of the server of the redis backend, e.g. redis://:password@localhost:6379/0.
+	RedisURL string
+	// KeyPrefix is prepended to the keys of the redis backend, e.g. to share a Redis server.
+	KeyPrefix string
+	// Retention is how long records are kept once their alert group was notified resolved. Zero keeps them forever.
+	Retention time.Duration
+}
+
+// Open opens the store of the given backend.
+func Open(backend string, opts Options) (Store, error) {
+	switch backend {
+	case BackendMemory:
+		return NewMemory(opts.Retention), nil
+	case BackendBolt:
+		return OpenBolt(opts.Path, opts.Retention)
+	case BackendRedis:
+		return OpenRedis(opts.RedisURL, opts.KeyPrefix, opts.Retention)
+	default:
+		return nil, fmt.Errorf("unknown store backend %q, must be %s, %s or %s", backend, BackendMemory, BackendBolt, BackendRedis)
+	}
+}
@@ -0,0 +1,198 @@
+// Copyright 2017 The Prometheus Authors
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+package store
+
+import (
+	"context"
+	"encoding/json"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/alicebob/miniredis/v2"
+	"github.com/stretchr/testify/require"
+)
+
+func testStore(t *testing.T, s Store) {
+	ctx := context.Background()
+	_, ok, err := s.Get(ctx, "a")
+	require.NoError(t, err)
+	require.False(t, ok)
+
+	now := time.Date(2023, 5, 1, 10, 0, 0, 0, time.UTC)
+	rec := Record{IssueKey: "ABC-1", LastUpdate: now, FirstFiring: now.Add(-time.Hour), LastFiring: now}
+	require.NoError(t, s.Set(ctx, "a", rec))
+	got, ok, err := s.Get(ctx, "a")
+	require.NoError(t, err)
+	require.True(t, ok)
+	require.Equal(t, rec, got)
+
+	require.NoError(t, s.Delete(ctx, "a"))
+	_, ok, err = s.Get(ctx, "a")
+	require.NoError(t, err)
+	require.False(t, ok)
+	require.NoError(t, s.Delete(ctx, "a"))
+
+	resolves, err := s.Resolves(ctx)
+	require.NoError(t, err)
+	require.Empty(t, resolves)
+	res := PendingResolve{Receiver: "jira", At: now.Add(time.Hour), Data: json.RawMessage(`{"status":"resolved"}`)}
+	require.NoError(t, s.SetResolve(ctx, "a", res))
+	gotRes, ok, err := s.GetResolve(ctx, "a")
+	require.NoError(t, err)
+	require.True(t, ok)
+	require.Equal(t, res, gotRes)
+	resolves, err = s.Resolves(ctx)
+	require.NoError(t, err)
+	require.Equal(t, map[string]PendingResolve{"a": res}, resolves)
+
+	// Pending resolutions are kept apart from the records.
+	_, ok, err = s.Get(ctx, "a")
+	require.NoError(t, err)
+	require.False(t, ok)
+	require.NoError(t, s.DeleteResolve(ctx, "a"))
+	_, ok, err = s.GetResolve(ctx, "a")
+	require.NoError(t, err)
+	require.False(t, ok)
+	require.NoError(t, s.DeleteResolve(ctx, "a"))
+}
+
+// testRetention checks that the records of resolved alert groups expire after an hour of retention, advancing the time
+// of the store with wait.
+func testRetention(t *testing.T, s Store, wait func(time.Duration)) {
+	ctx := context.Background()
+	now := time.Now()
+	require.NoError(t, s.Set(ctx, "firing", Record{IssueKey: "ABC-1", LastFiring: now}))
+	require.NoError(t, s.Set(ctx, "resolved", Record{IssueKey: "ABC-2", ResolvedAt: now}))
+	require.NoError(t, s.Set(ctx, "refiring", Record{IssueKey: "ABC-3", ResolvedAt: now}))
+	require.NoError(t, s.Set(ctx, "refiring", Record{IssueKey: "ABC-3", LastFiring: now}))
+
+	wait(59 * time.Minute)
+	_, ok, err := s.Get(ctx, "resolved")
+	require.NoError(t, err)
+	require.True(t, ok)
+
+	wait(2 * time.Minute)
+	if p, ok := s.(Pruner); ok {
+		pruned, err := p.Prune(ctx)
+		require.NoError(t, err)
+		require.Equal(t, 1, pruned)
+	}
+	for group, kept := range map[string]bool{"firing": true, "resolved": false, "refiring": true} {
+		_, ok, err := s.Get(ctx, group)
+		require.NoError(t, err)
+		require.Equal(t, kept, ok, group)
+	}
+}
+
+func TestMemory(t *testing.T) {
+	testStore(t, NewMemory(0))
+}
+
+func TestBolt(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "jiralert.db")
+	s, err := OpenBolt(path, 0)
+	require.NoError(t, err)
+	testStore(t, s)
+
+	// Records survive reopening the file.
+	require.NoError(t, s.Set(context.Background(), "b", Record{IssueKey: "ABC-2"}))
+	require.NoError(t, s.Close())
+	s, err = OpenBolt(path, 0)
+	require.NoError(t, err)
+	defer s.Close()
+	rec, ok, err := s.Get(context.Background(), "b")
+	require.NoError(t, err)
+	require.True(t, ok)
+	require.Equal(t, "ABC-2", rec.IssueKey)
+}
+
+func TestMemoryRetention(t *testing.T) {
+	s := NewMemory(time.Hour)
+	now := time.Now()
+	s.timeNow = func() time.Time { return now }
+	testRetention(t, s, func(d time.Duration) { now = now.Add(d) })
+}
+
+func TestBoltRetention(t *testing.T) {
+	s, err := OpenBolt(filepath.Join(t.TempDir(), "jiralert.db"), time.Hour)
+	require.NoError(t, err)
+	defer s.Close()
+	now := time.Now()
+	s.timeNow = func() time.Time { return now }
+	testRetention(t, s, func(d time.Duration) { now = now.Add(d) })
+}
+
+func TestOpen(t *testing.T) {
+	s, err := Open(BackendMemory, Options{})
+	require.NoError(t, err)
+	require.IsType(t, &Memory{}, s)
+
+	_, err = Open(BackendBolt, Options{})
+	require.Error(t, err)
+	_, err = Open(BackendRedis, Options{RedisURL: "localhost:6379"})
+	require.Error(t, err)
+	_, err = Open("etcd", Options{})
+	require.EqualError(t, err, `unknown store backend "etcd", must be memory, bolt or redis`)
+}
+
+func TestRedis(t *testing.T) {
+	srv := miniredis.RunT(t)
+	s, err := OpenRedis("redis://"+srv.Addr(), "jiralert:", 0)
+	require.NoError(t, err)
+	defer s.Close()
+	testStore(t, s)
+
+	require.NoError(t, s.Set(context.Background(), "b", Record{IssueKey: "ABC-2"}))
+	require.True(t, srv.Exists("jiralert:group:b"))
+}
+
+func TestRedisRetention(t *testing.T) {
+	srv := miniredis.RunT(t)
+	s, err := OpenRedis("redis://"+srv.Addr(), "", time.Hour)
+	require.NoError(t, err)
+	defer s.Close()
+	testRetention(t, s, srv.FastForward)
+}
+
+func TestRedisLock(t *testing.T) {
+	srv := miniredis.RunT(t)
+	s, err := OpenRedis("redis://"+srv.Addr(), "", 0)
+	require.NoError(t, err)
+	defer s.Close()
+
+	unlock, err := s.Lock(context.Background(), "a", time.Minute)
+	require.NoError(t, err)
+	// Other alert groups are not locked.
+	unlockB, err := s.Lock(context.Background(), "b", time.Minute)
+	require.NoError(t, err)
+	unlockB()
+
+	ctx, cancel := context.WithTimeout(context.Background(), 300*time.Millisecond)
+	defer cancel()
+	_, err = s.Lock(ctx, "a", time.Minute)
+	require.ErrorIs(t, err, context.DeadlineExceeded)
+
+	unlock()
+	unlock, err = s.Lock(context.Background(), "a", time.Minute)
+	require.NoError(t, err)
+
+	// Expired locks are taken over, and the previous holder does not release the new one.
+	srv.FastForward(time.Minute)
+	unlockNext, err := s.Lock(context.Background(), "a", time.Minute)
+	require.NoError(t, err)
+	unlock()
+	require.True(t, srv.Exists("lock:a"))
+	unlockNext()
+	require.False(t, srv.Exists("lock:a"))
+}
@@ -0,0 +1,467 @@
+// Copyright 2017 The Prometheus Authors
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package template
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"math"
+	"net/url"
+	"reflect"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/prometheus-community/jiralert/pkg/markup"
+)
+
+// The functions below implement a subset of Sprig (https://masterminds.github.io/sprig/). Like Sprig's, they take
+// the subject last for pipelining and return zero values rather than failing on unexpected input.
+
+// defaultValue returns given, or d if given is missing or empty.
+func defaultValue(d interface{}, given ...interface{}) interface{} {
+	if len(given) == 0 || isEmpty(given[0]) {
+		return d
+	}
+	return given[0]
+}
+
+func isEmpty(v interface{}) bool {
+	if v == nil {
+		return true
+	}
+	rv := reflect.ValueOf(v)
+	switch rv.Kind() {
+	case reflect.Array, reflect.Map, reflect.Slice, reflect.String:
+		return rv.Len() == 0
+	case reflect.Ptr, reflect.Interface:
+		return rv.IsNil()
+	case reflect.Struct:
+		if t, ok := v.(time.Time); ok {
+			return t.IsZero()
+		}
+		return false
+	default:
+		return rv.IsZero()
+	}
+}
+
+// trunc truncates s to n characters, or to its last -n characters if n is negative.
+func trunc(n int, s string) string {
+	r := []rune(s)
+	switch {
+	case n >= 0 && len(r) > n:
+		return string(r[:n])
+	case n < 0 && len(r) > -n:
+		return string(r[len(r)+n:])
+	}
+	return s
+}
+
+func toInt64(v interface{}) int64 {
+	switch v := v.(type) {
+	case string:
+		if i, err := strconv.ParseInt(v, 10, 64); err == nil {
+			return i
+		}
+		f, _ := strconv.ParseFloat(v, 64)
+		return int64(f)
+	case bool:
+		if v {
+			return 1
+		}
+		return 0
+	}
+	rv := reflect.ValueOf(v)
+	switch rv.Kind() {
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		return rv.Int()
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		return int64(rv.Uint())
+	case reflect.Float32, reflect.Float64:
+		return int64(rv.Float())
+	}
+	return 0
+}
+
+func toFloat64(v interface{}) float64 {
+	switch v := v.(type) {
+	case string:
+		f, _ := strconv.ParseFloat(v, 64)
+		return f
+	case bool:
+		if v {
+			return 1
+		}
+		return 0
+	}
+	rv := reflect.ValueOf(v)
+	switch rv.Kind() {
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		return float64(rv.Int())
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		return float64(rv.Uint())
+	case reflect.Float32, reflect.Float64:
+		return rv.Float()
+	}
+	return 0
+}
+
+func add(values ...interface{}) int64 {
+	var sum int64
+	for _, v := range values {
+		sum += toInt64(v)
+	}
+	return sum
+}
+
+// date formats t, a time.Time or a Unix timestamp in seconds, with the given Go time layout.
+func date(layout string, t interface{}) string {
+	tt, ok := toTime(t)
+	if !ok {
+		return ""
+	}
+	return tt.Format(layout)
+}
+
+// toTime converts a time.Time, *time.Time or Unix timestamp in seconds to a time.Time. It returns false for nil
+// pointers.
+func toTime(t interface{}) (time.Time, bool) {
+	switch t := t.(type) {
+	case time.Time:
+		return t, true
+	case *time.Time:
+		if t == nil {
+			return time.Time{}, false
+		}
+		return *t, true
+	}
+	return time.Unix(toInt64(t), 0), true
+}
+
+// namedLayouts are the layouts dateFormat accepts by name, besides Go time layouts.
+var namedLayouts = map[string]string{
+	"RFC3339":  time.RFC3339,
+	"RFC1123":  time.RFC1123,
+	"RFC822":   time.RFC822,
+	"Kitchen":  time.Kitchen,
+	"DateTime": "2006-01-02 15:04:05",
+	"DateOnly": "2006-01-02",
+	"TimeOnly": "15:04:05",
+}
+
+// dateFormatIn returns a function formatting times like date, but converted to loc and with the layout optionally
+// given by name, e.g. RFC3339.
+func dateFormatIn(loc *time.Location) func(string, interface{}) string {
+	return func(layout string, t interface{}) string {
+		tt, ok := toTime(t)
+		if !ok {
+			return ""
+		}
+		if l, ok := namedLayouts[layout]; ok {
+			layout = l
+		}
+		return tt.In(loc).Format(layout)
+	}
+}
+
+// tz converts t, a time.Time or a Unix timestamp in seconds, to the IANA time zone name, e.g. Europe/Berlin.
+func tz(name string, t interface{}) (time.Time, error) {
+	loc, err := time.LoadLocation(name)
+	if err != nil {
+		return time.Time{}, err
+	}
+	tt, ok := toTime(t)
+	if !ok {
+		return time.Time{}, nil
+	}
+	return tt.In(loc), nil
+}
+
+// humanizeDuration formats a time.Duration, or a number of seconds, like Prometheus' function of the same name,
+// e.g. 1d 2h 3m 4s.
+func humanizeDuration(v interface{}) string {
+	var seconds float64
+	if d, ok := v.(time.Duration); ok {
+		seconds = d.Seconds()
+	} else {
+		seconds = toFloat64(v)
+	}
+	if math.IsNaN(seconds) || math.IsInf(seconds, 0) {
+		return fmt.Sprintf("%.4g", seconds)
+	}
+	if math.Abs(seconds) >= 1 {
+		sign := ""
+		if seconds < 0 {
+			sign = "-"
+			seconds = -seconds
+		}
+		s := int64(seconds)
+		days, hours, minutes := s/86400, s/3600%24, s/60%60
+		secs := s % 60
+		switch {
+		case days != 0:
+			return fmt.Sprintf("%s%dd %dh %dm %ds", sign, days, hours, minutes, secs)
+		case hours != 0:
+			return fmt.Sprintf("%s%dh %dm %ds", sign, hours, minutes, secs)
+		case minutes != 0:
+			return fmt.Sprintf("%s%dm %ds", sign, minutes, secs)
+		}
+		// Show fractions of seconds below one minute.
+		return fmt.Sprintf("%s%.4gs", sign, seconds)
+	}
+	for _, unit := range []struct {
+		name  string
+		scale float64
+	}{{"ms", 1e3}, {"us", 1e6}} {
+		if seconds*unit.scale >= 1 || seconds*unit.scale <= -1 {
+			return fmt.Sprintf("%.4g%s", seconds*unit.scale, unit.name)
+		}
+	}
+	if seconds == 0 {
+		return "0s"
+	}
+	return fmt.Sprintf("%.4gns", seconds*1e9)
+}
+
+func toJSON(v interface{}) string {
+	b, err := json.Marshal(v)
+	if err != nil {
+		return ""
+	}
+	return string(b)
+}
+
+// fromJSON decodes s, returning nil if it is not valid JSON.
+func fromJSON(s string) interface{} {
+	var v interface{}
+	if err := json.Unmarshal([]byte(s), &v); err != nil {
+		return nil
+	}
+	return v
+}
+
+// mustFromJSON decodes s, failing the template if it is not valid JSON.
+func mustFromJSON(s string) (interface{}, error) {
+	var v interface{}
+	if err := json.Unmarshal([]byte(s), &v); err != nil {
+		return nil, err
+	}
+	return v, nil
+}
+
+func b64enc(s string) string {
+	return base64.StdEncoding.EncodeToString([]byte(s))
+}
+
+func b64dec(s string) string {
+	b, err := base64.StdEncoding.DecodeString(s)
+	if err != nil {
+		return ""
+	}
+	return string(b)
+}
+
+// The functions below build Jira wiki markup, escaping the given values so that characters such as {, [, | or *
+// in label values don't corrupt it.
+
+// code wraps the last argument in a {code} block, with the language given as first argument if two are passed.
+func code(args ...string) (string, error) {
+	switch len(args) {
+	case 1:
+		return "{code}\n" + breakTag(args[0], "{code}") + "\n{code}", nil
+	case 2:
+		return "{code:" + args[0] + "}\n" + breakTag(args[1], "{code}") + "\n{code}", nil
+	}
+	return "", fmt.Errorf("code takes the text and an optional language, got %d arguments", len(args))
+}
+
+func noformat(s string) string {
+	return "{noformat}\n" + breakTag(s, "{noformat}") + "\n{noformat}"
+}
+
+// breakTag inserts a zero width space into occurrences of the closing tag in s, which can't be escaped in blocks
+// displayed verbatim.
+func breakTag(s, tag string) string {
+	return strings.ReplaceAll(s, tag, tag[:1]+"\u200b"+tag[1:])
+}
+
+// tableCell escapes v for use as a table cell, which must neither be empty nor span lines.
+func tableCell(v interface{}) string {
+	s := strings.TrimSpace(fmt.Sprint(v))
+	if s == "" {
+		return " "
+	}
+	lines := strings.Split(s, "\n")
+	for i, l := range lines {
+		lines[i] = markup.EscapeWiki(strings.TrimSpace(l))
+	}
+	return strings.Join(lines, " \\\\ ")
+}
+
+func tableRow(cells ...interface{}) string {
+	return tableLine("|", cells)
+}
+
+func tableHeader(cells ...interface{}) string {
+	return tableLine("||", cells)
+}
+
+func tableLine(sep string, cells []interface{}) string {
+	var b strings.Builder
+	for _, c := range cells {
+		b.WriteString(sep)
+		b.WriteString(tableCell(c))
+	}
+	b.WriteString(sep)
+	return b.String()
+}
+
+// kvTable renders a map with string keys, e.g. the labels or annotations of alerts, as a table sorted by key with
+// the given column headers.
+func kvTable(keyHeader, valueHeader string, m interface{}) (string, error) {
+	rv := reflect.ValueOf(m)
+	if rv.Kind() != reflect.Map || rv.Type().Key().Kind() != reflect.String {
+		return "", fmt.Errorf("kvTable expects a map with string keys, got %T", m)
+	}
+	keys := make([]string, 0, rv.Len())
+	for _, k := range rv.MapKeys() {
+		keys = append(keys, k.String())
+	}
+	sort.Strings(keys)
+
+	lines := []string{tableHeader(keyHeader, valueHeader)}
+	for _, k := range keys {
+		lines = append(lines, tableRow(k, rv.MapIndex(reflect.ValueOf(k).Convert(rv.Type().Key())).Interface()))
+	}
+	return strings.Join(lines, "\n"), nil
+}
+
+// The functions below build links to Alertmanager, Prometheus and Grafana, and to runbooks, from the contents of
+// notifications. Like the functions above, they take the URL to operate on last.
+
+// silenceURL returns the page of the Alertmanager UI creating a silence for the given labels, e.g. the group
+// labels of a notification.
+func silenceURL(externalURL string, labels interface{}) (string, error) {
+	filter, err := labelMatchers("silenceURL", labels)
+	if err != nil {
+		return "", err
+	}
+	return strings.TrimSuffix(externalURL, "/") + "/#/silences/new?filter=" + url.QueryEscape(filter), nil
+}
+
+// alertsURL returns the page of the Alertmanager UI listing the alerts with the given labels.
+func alertsURL(externalURL string, labels interface{}) (string, error) {
+	filter, err := labelMatchers("alertsURL", labels)
+	if err != nil {
+		return "", err
+	}
+	return strings.TrimSuffix(externalURL, "/") + "/#/alerts?filter=" + url.QueryEscape(filter), nil
+}
+
+// labelMatchers formats a map with string keys as equality matchers sorted by name, e.g. {alertname="Test"}.
+func labelMatchers(fn string, m interface{}) (string, error) {
+	rv := reflect.ValueOf(m)
+	if rv.Kind() != reflect.Map || rv.Type().Key().Kind() != reflect.String {
+		return "", fmt.Errorf("%s expects a map with string keys, got %T", fn, m)
+	}
+	keys := make([]string, 0, rv.Len())
+	for _, k := range rv.MapKeys() {
+		keys = append(keys, k.String())
+	}
+	sort.Strings(keys)
+
+	matchers := make([]string, 0, len(keys))
+	for _, k := range keys {
+		v := rv.MapIndex(reflect.ValueOf(k).Convert(rv.Type().Key())).Interface()
+		matchers = append(matchers, fmt.Sprintf("%s=%q", k, fmt.Sprint(v)))
+	}
+	return "{" + strings.Join(matchers, ",") + "}", nil
+}
+
+// generatorQuery returns the PromQL expression of a Prometheus generator URL, or an empty string if there is none.
+func generatorQuery(generatorURL string) string {
+	u, err := url.Parse(generatorURL)
+	if err != nil {
+		return ""
+	}
+	return u.Query().Get("g0.expr")
+}
+
+// rebaseURL replaces the scheme and host of rawURL with those of base, and prefixes its path with the path of base,
+// e.g. to link to Prometheus through its public URL rather than the internal one of generator URLs.
+func rebaseURL(base, rawURL string) (string, error) {
+	b, err := url.Parse(base)
+	if err != nil {
+		return "", err
+	}
+	u, err := url.Parse(rawURL)
+	if err != nil {
+		return "", err
+	}
+	u.Scheme, u.Host, u.User = b.Scheme, b.Host, b.User
+	u.Path, u.RawPath = strings.TrimSuffix(b.Path, "/")+u.Path, ""
+	return u.String(), nil
+}
+
+// withQuery sets the query parameter key of rawURL to value, e.g. the time range of a generator URL.
+func withQuery(key, value, rawURL string) (string, error) {
+	u, err := url.Parse(rawURL)
+	if err != nil {
+		return "", err
+	}
+	q := u.Query()
+	q.Set(key, value)
+	u.RawQuery = q.Encode()
+	return u.String(), nil
+}
+
+// urlJoin appends the elements, escaped as path segments, to base, e.g. the alert name to the URL of a runbook
+// repository.
+func urlJoin(base string, elems ...string) string {
+	escaped := make([]string, 0, len(elems)+1)
+	escaped = append(escaped, strings.TrimSuffix(base, "/"))
+	for _, e := range elems {
+		escaped = append(escaped, url.PathEscape(e))
+	}
+	return strings.Join(escaped, "/")
+}
+
+type grafanaQuery struct {
+	RefID string `json:"refId"`
+	Expr  string `json:"expr"`
+}
+
+type grafanaExploreState struct {
+	Datasource string         `json:"datasource"`
+	Queries    []grafanaQuery `json:"queries"`
+	Range      struct {
+		From string `json:"from"`
+		To   string `json:"to"`
+	} `json:"range"`
+}
+
+// grafanaExploreURL returns the Grafana Explore page running query over the last hour against the data source with
+// the given UID or name.
+func grafanaExploreURL(grafanaURL, datasource, query string) (string, error) {
+	state := grafanaExploreState{Datasource: datasource, Queries: []grafanaQuery{{RefID: "A", Expr: query}}}
+	state.Range.From, state.Range.To = "now-1h", "now"
+	b, err := json.Marshal(state)
+	if err != nil {
+		return "", err
+	}
+	return strings.TrimSuffix(grafanaURL, "/") + "/explore?left=" + url.QueryEscape(string(b)), nil
+}
@@ -0,0 +1,212 @@
+// Copyright 2017 The Prometheus Authors
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package template
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"sync"
+	"text/template"
+	"time"
+
+	"github.com/go-kit/log"
+	"github.com/go-kit/log/level"
+	"github.com/pkg/errors"
+	"github.com/prometheus-community/jiralert/pkg/config"
+)
+
+const (
+	// maxLookupResponseSize limits the responses of template HTTP lookups, in bytes.
+	maxLookupResponseSize = 1 << 20
+	// maxLookupCacheEntries limits the number of cached responses, as URLs are usually built from label values.
+	maxLookupCacheEntries = 1000
+)
+
+type lookupEntry struct {
+	body    []byte
+	expires time.Time
+}
+
+// httpLookup fetches data from allow-listed hosts for templates, caching responses.
+type httpLookup struct {
+	conf   *config.TemplateHTTPConfig
+	client *http.Client
+	logger log.Logger
+
+	mtx   sync.Mutex
+	cache map[string]lookupEntry
+
+	timeNow func() time.Time
+}
+
+func newHTTPLookup(c *config.TemplateHTTPConfig, logger log.Logger) *httpLookup {
+	l := &httpLookup{
+		conf:    c,
+		logger:  logger,
+		cache:   map[string]lookupEntry{},
+		timeNow: time.Now,
+	}
+	l.client = &http.Client{Timeout: time.Duration(*c.Timeout), CheckRedirect: l.checkRedirect}
+	return l
+}
+
+// checkRedirect only follows redirects to allowed hosts, which also keeps the configured headers from being sent
+// anywhere else.
+func (l *httpLookup) checkRedirect(req *http.Request, via []*http.Request) error {
+	if len(via) >= 10 {
+		return errors.New("stopped after 10 redirects")
+	}
+	if req.URL.Scheme != "http" && req.URL.Scheme != "https" {
+		return errors.Errorf("redirect to %q must use http or https", req.URL)
+	}
+	if !l.allowed(req.URL) {
+		return errors.Errorf("host %q of redirect is not in template_http allowed_hosts", req.URL.Host)
+	}
+	return nil
+}
+
+// funcs returns the httpGet and jsonLookup template functions. If l is nil, they fail as lookups are disabled.
+func (l *httpLookup) funcs() template.FuncMap {
+	if l == nil {
+		disabled := func(string, ...string) (interface{}, error) {
+			return nil, errors.New("HTTP lookups are disabled, see the template_http configuration")
+		}
+		return template.FuncMap{"httpGet": disabled, "jsonLookup": disabled}
+	}
+	return template.FuncMap{"httpGet": l.httpGet, "jsonLookup": l.jsonLookup}
+}
+
+// httpGet returns the body of the response to a GET request of rawURL. Failed requests are logged and return an
+// empty string, so that issues are still created when the looked up service is unavailable.
+func (l *httpLookup) httpGet(rawURL string) (string, error) {
+	body, err := l.get(rawURL)
+	if err != nil {
+		return "", err
+	}
+	return string(body), nil
+}
+
+// jsonLookup returns the JSON response to a GET request of rawURL, or the value at the given dot-separated path of
+// object keys and array indexes in it, e.g. "owner.team". Failed requests and missing values return an empty string.
+func (l *httpLookup) jsonLookup(rawURL string, path ...string) (interface{}, error) {
+	body, err := l.get(rawURL)
+	if err != nil || len(body) == 0 {
+		return "", err
+	}
+	var v interface{}
+	if err := json.Unmarshal(body, &v); err != nil {
+		level.Warn(l.logger).Log("msg", "template lookup returned invalid JSON", "url", rawURL, "err", err)
+		return "", nil
+	}
+	if len(path) > 0 && path[0] != "" {
+		for _, key := range strings.Split(path[0], ".") {
+			switch node := v.(type) {
+			case map[string]interface{}:
+				v = node[key]
+			case []interface{}:
+				i, err := strconv.Atoi(key)
+				if err != nil || i < 0 || i >= len(node) {
+					return "", nil
+				}
+				v = node[i]
+			default:
+				return "", nil
+			}
+		}
+	}
+	if v == nil {
+		return "", nil
+	}
+	return v, nil
+}
+
+// get returns the cached or fetched body of rawURL. Only disallowed URLs fail the template; failed requests are
+// logged and return an empty body, which is not cached.
+func (l *httpLookup) get(rawURL string) ([]byte, error) {
+	u, err := url.Parse(rawURL)
+	if err != nil {
+		return nil, errors.Wrapf(err, "parse lookup URL %q", rawURL)
+	}
+	if u.Scheme != "http" && u.Scheme != "https" {
+		return nil, errors.Errorf("lookup URL %q must use http or https", rawURL)
+	}
+	if !l.allowed(u) {
+		return nil, errors.Errorf("host %q of lookup URL is not in template_http allowed_hosts", u.Host)
+	}
+
+	now := l.timeNow()
+	l.mtx.Lock()
+	e, ok := l.cache[rawURL]
+	l.mtx.Unlock()
+	if ok && now.Before(e.expires) {
+		return e.body, nil
+	}
+
+	body, err := l.fetch(rawURL)
+	if err != nil {
+		level.Warn(l.logger).Log("msg", "template lookup failed", "url", rawURL, "err", err)
+		return nil, nil
+	}
+
+	l.mtx.Lock()
+	defer l.mtx.Unlock()
+	if len(l.cache) >= maxLookupCacheEntries {
+		for k, e := range l.cache {
+			if !now.Before(e.expires) {
+				delete(l.cache, k)
+			}
+		}
+	}
+	if len(l.cache) < maxLookupCacheEntries {
+		l.cache[rawURL] = lookupEntry{body: body, expires: now.Add(time.Duration(*l.conf.CacheTTL))}
+	}
+	return body, nil
+}
+
+func (l *httpLookup) fetch(rawURL string) ([]byte, error) {
+	req, err := http.NewRequest(http.MethodGet, rawURL, nil)
+	if err != nil {
+		return nil, err
+	}
+	for k, v := range l.conf.Headers {
+		req.Header.Set(k, string(v))
+	}
+	resp, err := l.client.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode/100 != 2 {
+		return nil, fmt.Errorf("unexpected status %s", resp.Status)
+	}
+	return io.ReadAll(io.LimitReader(resp.Body, maxLookupResponseSize))
+}
+
+func (l *httpLookup) allowed(u *url.URL) bool {
+	host := u.Hostname()
+	for _, a := range l.conf.AllowedHosts {
+		switch {
+		case a == u.Host || a == host:
+			return true
+		case strings.HasPrefix(a, "*.") && strings.HasSuffix(host, a[1:]):
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,151 @@
+// Copyright 2017 The Prometheus Authors
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+package template
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"testing"
+	"time"
+
+	"github.com/go-kit/log"
+	"github.com/prometheus-community/jiralert/pkg/config"
+
+	"github.com/stretchr/testify/require"
+)
+
+func newTestLookupTemplate(t *testing.T, handler http.HandlerFunc) (*Template, *httpLookup, string) {
+	srv := httptest.NewServer(handler)
+	t.Cleanup(srv.Close)
+	u, err := url.Parse(srv.URL)
+	require.NoError(t, err)
+
+	timeout, ttl := config.Duration(time.Second), config.Duration(time.Minute)
+	l := newHTTPLookup(&config.TemplateHTTPConfig{
+		AllowedHosts: []string{u.Host},
+		Timeout:      &timeout,
+		CacheTTL:     &ttl,
+		Headers:      map[string]config.Secret{"Authorization": "Bearer t0k3n"},
+	}, log.NewNopLogger())
+	tmpl := SimpleTemplate()
+	tmpl.tmpl.Funcs(l.funcs())
+	return tmpl, l, srv.URL
+}
+
+func TestHTTPLookup(t *testing.T) {
+	requests := 0
+	tmpl, l, base := newTestLookupTemplate(t, func(w http.ResponseWriter, r *http.Request) {
+		requests++
+		if r.Header.Get("Authorization") != "Bearer t0k3n" {
+			w.WriteHeader(http.StatusUnauthorized)
+			return
+		}
+		switch r.URL.Path {
+		case "/owner/api":
+			w.Write([]byte(`{"owner": {"team": "infra", "oncall": ["alice", "bob"]}}`))
+		case "/text":
+			w.Write([]byte("plain"))
+		default:
+			w.WriteHeader(http.StatusInternalServerError)
+		}
+	})
+
+	for _, tc := range []struct {
+		text, out string
+	}{
+		{text: `{{ httpGet "` + base + `/text" }}`, out: "plain"},
+		{text: `{{ jsonLookup "` + base + `/owner/api" "owner.team" }}`, out: "infra"},
+		{text: `{{ jsonLookup "` + base + `/owner/api" "owner.oncall.1" }}`, out: "bob"},
+		{text: `{{ jsonLookup "` + base + `/owner/api" "owner.missing" | default "unknown" }}`, out: "unknown"},
+		{text: `{{ (jsonLookup "` + base + `/owner/api").owner.team }}`, out: "infra"},
+		{text: `{{ jsonLookup "` + base + `/fail" "owner.team" | default "unknown" }}`, out: "unknown"},
+	} {
+		t.Run(tc.text, func(t *testing.T) {
+			out, err := tmpl.Execute(tc.text, nil)
+			require.NoError(t, err)
+			require.Equal(t, tc.out, out)
+		})
+	}
+	// Successful responses are cached, failed ones are retried.
+	require.Equal(t, 3, requests)
+	_, err := tmpl.Execute(`{{ httpGet "`+base+`/fail" }}`, nil)
+	require.NoError(t, err)
+	require.Equal(t, 4, requests)
+
+	// Cached responses expire.
+	l.timeNow = func() time.Time { return time.Now().Add(2 * time.Minute) }
+	_, err = tmpl.Execute(`{{ httpGet "`+base+`/text" }}`, nil)
+	require.NoError(t, err)
+	require.Equal(t, 5, requests)
+
+	_, err = tmpl.Execute(`{{ httpGet "http://cmdb.example.com/" }}`, nil)
+	require.ErrorContains(t, err, `host "cmdb.example.com" of lookup URL is not in template_http allowed_hosts`)
+	_, err = tmpl.Execute(`{{ httpGet "file:///etc/passwd" }}`, nil)
+	require.ErrorContains(t, err, `lookup URL "file:///etc/passwd" must use http or https`)
+}
+
+func TestHTTPLookupRedirect(t *testing.T) {
+	var leaked []string
+	disallowed := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		leaked = append(leaked, r.Header.Get("Authorization"))
+		w.Write([]byte("leaked"))
+	}))
+	defer disallowed.Close()
+
+	tmpl, _, base := newTestLookupTemplate(t, func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/away":
+			http.Redirect(w, r, disallowed.URL+"/", http.StatusFound)
+		case "/here":
+			http.Redirect(w, r, "/text", http.StatusFound)
+		case "/text":
+			w.Write([]byte("plain"))
+		}
+	})
+
+	out, err := tmpl.Execute(`{{ httpGet "`+base+`/here" }}`, nil)
+	require.NoError(t, err)
+	require.Equal(t, "plain", out)
+
+	// Redirects to other hosts fail like other requests, without sending the configured headers there.
+	out, err = tmpl.Execute(`{{ httpGet "`+base+`/away" }}`, nil)
+	require.NoError(t, err)
+	require.Equal(t, "", out)
+	require.Empty(t, leaked)
+}
+
+func TestHTTPLookupAllowed(t *testing.T) {
+	l := &httpLookup{conf: &config.TemplateHTTPConfig{AllowedHosts: []string{"cmdb.example.com", "owners.example.com:8443", "*.example.org"}}}
+	for _, tc := range []struct {
+		url     string
+		allowed bool
+	}{
+		{url: "https://cmdb.example.com/hosts/a", allowed: true},
+		{url: "https://cmdb.example.com:8080/hosts/a", allowed: true},
+		{url: "https://owners.example.com:8443/", allowed: true},
+		{url: "https://owners.example.com/", allowed: false},
+		{url: "https://team.example.org/", allowed: true},
+		{url: "https://example.org/", allowed: false},
+		{url: "https://cmdb.example.com.evil.com/", allowed: false},
+	} {
+		u, err := url.Parse(tc.url)
+		require.NoError(t, err)
+		require.Equal(t, tc.allowed, l.allowed(u), tc.url)
+	}
+}
+
+func TestHTTPLookupDisabled(t *testing.T) {
+	_, err := SimpleTemplate().Execute(`{{ httpGet "https://cmdb.example.com/" }}`, nil)
+	require.ErrorContains(t, err, "HTTP lookups are disabled")
+}
@@ -0,0 +1,41 @@
+// Copyright 2017 The Prometheus Authors
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package template
+
+import (
+	"fmt"
+	"text/template"
+
+	"github.com/prometheus-community/jiralert/pkg/config"
+)
+
+// valueMaps are the lookup tables of the maps configuration, by name.
+type valueMaps map[string]*config.MapConfig
+
+func (m valueMaps) funcs() template.FuncMap {
+	return template.FuncMap{"lookup": m.lookup}
+}
+
+// lookup returns the value of key in the named map, or the default value of the map if key is missing, e.g.
+// {{ lookup "teams" .CommonLabels.team }}.
+func (m valueMaps) lookup(name, key string) (string, error) {
+	c, ok := m[name]
+	if !ok {
+		return "", fmt.Errorf("unknown map %q, see the maps configuration", name)
+	}
+	if v, ok := c.Values[key]; ok {
+		return v, nil
+	}
+	return c.Default, nil
+}
@@ -0,0 +1,53 @@
+// Copyright 2017 The Prometheus Authors
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+package template
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/go-kit/log"
+	"github.com/prometheus-community/jiralert/pkg/config"
+	"github.com/stretchr/testify/require"
+)
+
+func TestLookup(t *testing.T) {
+	dir := t.TempDir()
+	path := filepath.Join(dir, "jiralert.tmpl")
+	require.NoError(t, os.WriteFile(path, []byte(`{{ define "team.option" }}{{ lookup "teams" .CommonLabels.team }}{{ end }}`), 0o644))
+	tmpl, err := LoadTemplates([]string{path}, nil, map[string]*config.MapConfig{
+		"teams":   {Values: map[string]string{"sre": "10001", "db": "10002"}, Default: "10000"},
+		"regions": {Values: map[string]string{"eu-west-1": "Europe"}},
+	}, log.NewNopLogger())
+	require.NoError(t, err)
+
+	for _, tc := range []struct {
+		text string
+		out  string
+	}{
+		{text: `{{ template "team.option" . }}`, out: "10002"},
+		{text: `{{ lookup "teams" "unknown" }}`, out: "10000"},
+		{text: `{{ lookup "regions" .CommonLabels.region }}`, out: "Europe"},
+		{text: `{{ lookup "regions" "us-east-1" }}`, out: ""},
+	} {
+		out, err := tmpl.Execute(tc.text, map[string]interface{}{"CommonLabels": map[string]string{"team": "db", "region": "eu-west-1"}})
+		require.NoError(t, err, tc.text)
+		require.Equal(t, tc.out, out, tc.text)
+	}
+
+	_, err = tmpl.Execute(`{{ lookup "owners" "db" }}`, nil)
+	require.ErrorContains(t, err, `unknown map "owners", see the maps configuration`)
+	_, err = SimpleTemplate().Execute(`{{ lookup "teams" "db" }}`, nil)
+	require.ErrorContains(t, err, `unknown map "teams"`)
+}
@@ -0,0 +1,226 @@
+// Copyright 2017 The Prometheus Authors
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package template
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"text/template"
+	"time"
+
+	"github.com/go-kit/log"
+	"github.com/go-kit/log/level"
+	"github.com/pkg/errors"
+)
+
+const (
+	// maxQueryResponseSize limits the responses of Prometheus queries, in bytes.
+	maxQueryResponseSize = 4 << 20
+	// maxRangePoints limits the points per series of range queries, like Prometheus does.
+	maxRangePoints = 11000
+)
+
+// queryClient sends the Prometheus queries of templates.
+var queryClient = &http.Client{Timeout: 10 * time.Second}
+
+// Sample is a value of an instant query.
+type Sample struct {
+	Labels    map[string]string
+	Value     float64
+	Timestamp time.Time
+}
+
+// Point is a value of a range query.
+type Point struct {
+	Value     float64
+	Timestamp time.Time
+}
+
+// Series is the values of one series of a range query.
+type Series struct {
+	Labels map[string]string
+	Points []Point
+}
+
+// prometheusQuerier runs the PromQL queries of templates against the Prometheus server at url.
+type prometheusQuerier struct {
+	url    string
+	logger log.Logger
+
+	timeNow func() time.Time
+}
+
+// funcs returns the query and queryRange template functions. If q is nil, they fail as no Prometheus is configured.
+func (q *prometheusQuerier) funcs() template.FuncMap {
+	if q == nil {
+		return template.FuncMap{
+			"query": func(string) ([]Sample, error) {
+				return nil, errors.New("Prometheus queries are disabled, see prometheus_url")
+			},
+			"queryRange": func(string, string, string) ([]Series, error) {
+				return nil, errors.New("Prometheus queries are disabled, see prometheus_url")
+			},
+		}
+	}
+	return template.FuncMap{"query": q.query, "queryRange": q.queryRange}
+}
+
+// query returns the result of the instant query expr as of now, as samples: one per series of a vector, or a single
+// one without labels for a scalar. Failed queries are logged and return no samples, so that issues are still
+// created when Prometheus is unavailable.
+func (q *prometheusQuerier) query(expr string) ([]Sample, error) {
+	res, err := q.get("/api/v1/query", url.Values{"query": {expr}, "time": {formatTime(q.timeNow())}})
+	if err != nil {
+		level.Warn(q.logger).Log("msg", "template Prometheus query failed", "query", expr, "err", err)
+		return nil, nil
+	}
+	var samples []Sample
+	switch res.ResultType {
+	case "vector":
+		var vector []struct {
+			Metric map[string]string `json:"metric"`
+			Value  apiValue          `json:"value"`
+		}
+		if err := json.Unmarshal(res.Result, &vector); err != nil {
+			level.Warn(q.logger).Log("msg", "template Prometheus query returned an invalid vector", "query", expr, "err", err)
+			return nil, nil
+		}
+		for _, s := range vector {
+			samples = append(samples, Sample{Labels: s.Metric, Value: s.Value.value, Timestamp: s.Value.timestamp})
+		}
+	case "scalar":
+		var v apiValue
+		if err := json.Unmarshal(res.Result, &v); err != nil {
+			level.Warn(q.logger).Log("msg", "template Prometheus query returned an invalid scalar", "query", expr, "err", err)
+			return nil, nil
+		}
+		samples = append(samples, Sample{Labels: map[string]string{}, Value: v.value, Timestamp: v.timestamp})
+	default:
+		level.Warn(q.logger).Log("msg", "template Prometheus query returned an unsupported result", "query", expr, "type", res.ResultType)
+	}
+	return samples, nil
+}
+
+// queryRange returns the series of the range query expr over the given duration until now, with values every step,
+// e.g. queryRange "rate(errors_total[5m])" "1h" "1m". Failed queries are logged and return no series.
+func (q *prometheusQuerier) queryRange(expr, duration, step string) ([]Series, error) {
+	d, err := time.ParseDuration(duration)
+	if err != nil || d <= 0 {
+		return nil, errors.Errorf("invalid duration %q of range query", duration)
+	}
+	s, err := time.ParseDuration(step)
+	if err != nil || s <= 0 {
+		return nil, errors.Errorf("invalid step %q of range query", step)
+	}
+	if d/s > maxRangePoints {
+		return nil, errors.Errorf("range query of %s every %s exceeds %d points", duration, step, maxRangePoints)
+	}
+
+	end := q.timeNow()
+	res, err := q.get("/api/v1/query_range", url.Values{
+		"query": {expr},
+		"start": {formatTime(end.Add(-d))},
+		"end":   {formatTime(end)},
+		"step":  {strconv.FormatFloat(s.Seconds(), 'f', -1, 64)},
+	})
+	if err != nil {
+		level.Warn(q.logger).Log("msg", "template Prometheus range query failed", "query", expr, "err", err)
+		return nil, nil
+	}
+	var matrix []struct {
+		Metric map[string]string `json:"metric"`
+		Values []apiValue        `json:"values"`
+	}
+	if res.ResultType != "matrix" || json.Unmarshal(res.Result, &matrix) != nil {
+		level.Warn(q.logger).Log("msg", "template Prometheus range query returned an invalid matrix", "query", expr, "type", res.ResultType)
+		return nil, nil
+	}
+	series := make([]Series, 0, len(matrix))
+	for _, m := range matrix {
+		points := make([]Point, 0, len(m.Values))
+		for _, v := range m.Values {
+			points = append(points, Point{Value: v.value, Timestamp: v.timestamp})
+		}
+		series = append(series, Series{Labels: m.Metric, Points: points})
+	}
+	return series, nil
+}
+
+type apiResult struct {
+	ResultType string          `json:"resultType"`
+	Result     json.RawMessage `json:"result"`
+}
+
+// get sends the query to the API endpoint of Prometheus, returning its result.
+func (q *prometheusQuerier) get(path string, params url.Values) (*apiResult, error) {
+	req, err := http.NewRequest(http.MethodGet, strings.TrimSuffix(q.url, "/")+path+"?"+params.Encode(), nil)
+	if err != nil {
+		return nil, err
+	}
+	resp, err := queryClient.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+	var body struct {
+		Status string    `json:"status"`
+		Data   apiResult `json:"data"`
+		Error  string    `json:"error"`
+	}
+	if err := json.NewDecoder(io.LimitReader(resp.Body, maxQueryResponseSize)).Decode(&body); err != nil {
+		return nil, fmt.Errorf("unexpected status %s: %s", resp.Status, err)
+	}
+	if body.Status != "success" {
+		return nil, fmt.Errorf("unexpected status %s: %s", resp.Status, body.Error)
+	}
+	return &body.Data, nil
+}
+
+// apiValue is a [<unix time>, "<value>"] pair of the Prometheus API.
+type apiValue struct {
+	timestamp time.Time
+	value     float64
+}
+
+func (v *apiValue) UnmarshalJSON(b []byte) error {
+	var pair [2]interface{}
+	if err := json.Unmarshal(b, &pair); err != nil {
+		return err
+	}
+	ts, ok := pair[0].(float64)
+	if !ok {
+		return errors.Errorf("invalid timestamp %v", pair[0])
+	}
+	s, ok := pair[1].(string)
+	if !ok {
+		return errors.Errorf("invalid value %v", pair[1])
+	}
+	value, err := strconv.ParseFloat(s, 64)
+	if err != nil {
+		return err
+	}
+	v.timestamp = time.UnixMilli(int64(ts * 1000)).UTC()
+	v.value = value
+	return nil
+}
+
+// formatTime formats t as a Unix timestamp, as accepted by the Prometheus API.
+func formatTime(t time.Time) string {
+	return strconv.FormatFloat(float64(t.UnixMilli())/1000, 'f', -1, 64)
+}
@@ -0,0 +1,77 @@
+// Copyright 2017 The Prometheus Authors
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+package template
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestPrometheusQuery(t *testing.T) {
+	now := time.Date(2023, 5, 1, 10, 0, 0, 0, time.UTC)
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		q := r.URL.Query()
+		switch {
+		case r.URL.Path == "/api/v1/query" && q.Get("query") == "up":
+			require.Equal(t, "1682935200", q.Get("time"))
+			w.Write([]byte(`{"status": "success", "data": {"resultType": "vector", "result": [
+  {"metric": {"instance": "a"}, "value": [1682935200, "1"]},
+  {"metric": {"instance": "b"}, "value": [1682935200, "0.5"]}
+]}}`))
+		case r.URL.Path == "/api/v1/query" && q.Get("query") == "scalar(1)":
+			w.Write([]byte(`{"status": "success", "data": {"resultType": "scalar", "result": [1682935200, "1"]}}`))
+		case r.URL.Path == "/api/v1/query_range":
+			require.Equal(t, "1682931600", q.Get("start"))
+			require.Equal(t, "1682935200", q.Get("end"))
+			require.Equal(t, "1800", q.Get("step"))
+			w.Write([]byte(`{"status": "success", "data": {"resultType": "matrix", "result": [
+  {"metric": {"instance": "a"}, "values": [[1682931600, "1"], [1682933400, "2"], [1682935200, "3"]]}
+]}}`))
+		default:
+			w.WriteHeader(http.StatusBadRequest)
+			w.Write([]byte(`{"status": "error", "errorType": "bad_data", "error": "parse error"}`))
+		}
+	}))
+	defer srv.Close()
+
+	tmpl := SimpleTemplate().WithPrometheus(srv.URL + "/")
+	tmpl.prometheus.timeNow = func() time.Time { return now }
+
+	out, err := tmpl.Execute(`{{ range query "up" }}{{ .Labels.instance }}={{ .Value }} {{ end }}`, nil)
+	require.NoError(t, err)
+	require.Equal(t, "a=1 b=0.5 ", out)
+
+	out, err = tmpl.Execute(`{{ with query "scalar(1)" }}{{ (index . 0).Value }}{{ end }}`, nil)
+	require.NoError(t, err)
+	require.Equal(t, "1", out)
+
+	out, err = tmpl.Execute(`{{ range queryRange "up" "1h" "30m" }}{{ .Labels.instance }}:{{ range .Points }} {{ .Value }}{{ end }}{{ end }}`, nil)
+	require.NoError(t, err)
+	require.Equal(t, "a: 1 2 3", out)
+
+	// Failed queries render nothing, while invalid arguments fail the template.
+	out, err = tmpl.Execute(`{{ range query "up{" }}{{ .Value }}{{ else }}none{{ end }}`, nil)
+	require.NoError(t, err)
+	require.Equal(t, "none", out)
+	_, err = tmpl.Execute(`{{ queryRange "up" "1h" "0s" }}`, nil)
+	require.ErrorContains(t, err, `invalid step "0s"`)
+}
+
+func TestPrometheusQueryDisabled(t *testing.T) {
+	_, err := SimpleTemplate().Execute(`{{ query "up" }}`, nil)
+	require.ErrorContains(t, err, "Prometheus queries are disabled")
+}
@@ -14,21 +14,62 @@
 package template
 
 import (
-	"bytes"
+	"net/url"
 	"os"
 	"regexp"
 	"strings"
+	"sync"
+	"sync/atomic"
 	"text/template"
+	"time"
+	"unicode/utf8"
 
 	"github.com/go-kit/log"
 	"github.com/go-kit/log/level"
 	"github.com/pkg/errors"
+	"github.com/prometheus-community/jiralert/pkg/config"
+	"github.com/prometheus-community/jiralert/pkg/markup"
 	"golang.org/x/text/cases"
 )
 
 type Template struct {
 	tmpl   *template.Template
 	logger log.Logger
+	// strict fails executions referencing missing map keys.
+	strict bool
+	// location is the time zone of dateFormat, UTC if nil.
+	location *time.Location
+	// prometheus runs the queries of the query and queryRange functions, which fail if nil.
+	prometheus *prometheusQuerier
+	// parsed caches the templates parsed by Execute, shared with the copies of t. Loading the templates again,
+	// e.g. on reload, starts with an empty cache.
+	parsed *parsedCache
+}
+
+type parsedKey struct {
+	text       string
+	strict     bool
+	location   string
+	prometheus string
+}
+
+// parsedCache holds the templates parsed from the texts passed to Execute. The texts come from the configuration,
+// so the cache needs no eviction.
+type parsedCache struct {
+	mtx       sync.RWMutex
+	templates map[parsedKey]*parsedTemplate
+}
+
+// parsedTemplate is a parsed text, with the length of its last output. Outputs of a text tend to have similar lengths,
+// so growing the output buffer to it up front saves growing it step by step for long outputs, e.g. descriptions of
+// large alert groups.
+type parsedTemplate struct {
+	tmpl    *template.Template
+	lastLen atomic.Int64
+}
+
+func newParsedCache() *parsedCache {
+	return &parsedCache{templates: map[parsedKey]*parsedTemplate{}}
 }
 
 var funcs = template.FuncMap{
@@ -51,47 +92,226 @@ var funcs = template.FuncMap{
 	"getEnv": func(name string) string {
 		return os.Getenv(name)
 	},
+
+	// Sprig subset, see funcs.go.
+	"default": defaultValue,
+	"trunc":   trunc,
+	"trim":    strings.TrimSpace,
+	"trimPrefix": func(prefix, s string) string {
+		return strings.TrimPrefix(s, prefix)
+	},
+	"trimSuffix": func(suffix, s string) string {
+		return strings.TrimSuffix(s, suffix)
+	},
+	"replace": func(old, new, s string) string {
+		return strings.ReplaceAll(s, old, new)
+	},
+	// split returns a slice, unlike Sprig's returning a map.
+	"split": func(sep, s string) []string {
+		return strings.Split(s, sep)
+	},
+	"contains": func(substr, s string) bool {
+		return strings.Contains(s, substr)
+	},
+	"add": add,
+	"sub": func(a, b interface{}) int64 {
+		return toInt64(a) - toInt64(b)
+	},
+	"int": func(v interface{}) int {
+		return int(toInt64(v))
+	},
+	"int64":            toInt64,
+	"float64":          toFloat64,
+	"now":              time.Now,
+	"date":             date,
+	"humanizeDuration": humanizeDuration,
+	"dateFormat":       dateFormatIn(time.UTC),
+	"tz":               tz,
+	"toJson":           toJSON,
+	"fromJson":         fromJSON,
+	"mustFromJson":     mustFromJSON,
+	"b64enc":           b64enc,
+	"b64dec":           b64dec,
+
+	// Jira wiki markup, see funcs.go.
+	"jiraEscape":  markup.EscapeWiki,
+	"code":        code,
+	"noformat":    noformat,
+	"tableHeader": tableHeader,
+	"tableRow":    tableRow,
+	"kvTable":     kvTable,
+
+	// Links, see funcs.go.
+	"silenceURL":        silenceURL,
+	"alertsURL":         alertsURL,
+	"generatorQuery":    generatorQuery,
+	"rebaseURL":         rebaseURL,
+	"withQuery":         withQuery,
+	"urlJoin":           urlJoin,
+	"pathEscape":        url.PathEscape,
+	"grafanaExploreURL": grafanaExploreURL,
 }
 
-// LoadTemplate reads and parses all templates defined in the given file and constructs a jiralert.Template.
-func LoadTemplate(path string, logger log.Logger) (*Template, error) {
-	level.Debug(logger).Log("msg", "loading templates", "path", path)
-	tmpl, err := template.New("").Option("missingkey=zero").Funcs(funcs).ParseFiles(path)
-	if err != nil {
-		return nil, err
+// LoadTemplates reads and parses all templates defined in the files matching the given glob patterns and constructs
+// a jiralert.Template. Each pattern must match at least one file. The httpGet and jsonLookup functions are enabled
+// if lookups are configured, and the lookup function looks up the given maps.
+func LoadTemplates(patterns []string, lookups *config.TemplateHTTPConfig, maps map[string]*config.MapConfig, logger log.Logger) (*Template, error) {
+	var l *httpLookup
+	if lookups != nil {
+		l = newHTTPLookup(lookups, logger)
+	}
+	tmpl := template.New("").Option("missingkey=zero").Funcs(funcs).Funcs(l.funcs()).Funcs((*prometheusQuerier)(nil).funcs()).Funcs(valueMaps(maps).funcs())
+	for _, pattern := range patterns {
+		level.Debug(logger).Log("msg", "loading templates", "pattern", pattern)
+		var err error
+		if tmpl, err = tmpl.ParseGlob(pattern); err != nil {
+			return nil, err
+		}
 	}
-	return &Template{tmpl: tmpl, logger: logger}, nil
+	return &Template{tmpl: tmpl, logger: logger, parsed: newParsedCache()}, nil
 }
 
 func SimpleTemplate() *Template {
-	return &Template{logger: log.NewNopLogger(), tmpl: template.New("").Option("missingkey=zero").Funcs(funcs)}
+	return &Template{logger: log.NewNopLogger(), tmpl: template.New("").Option("missingkey=zero").Funcs(funcs).Funcs((*httpLookup)(nil).funcs()).Funcs((*prometheusQuerier)(nil).funcs()).Funcs(valueMaps(nil).funcs()), parsed: newParsedCache()}
+}
+
+// Strict returns a copy of t failing executions which reference missing map keys, e.g. misspelled labels, instead of
+// rendering them as empty strings.
+func (t *Template) Strict() *Template {
+	c := *t
+	c.strict = true
+	return &c
+}
+
+// In returns a copy of t whose dateFormat function formats times in the given location rather than UTC.
+func (t *Template) In(loc *time.Location) *Template {
+	c := *t
+	c.location = loc
+	return &c
+}
+
+// WithPrometheus returns a copy of t whose query and queryRange functions query the Prometheus server at url.
+func (t *Template) WithPrometheus(url string) *Template {
+	c := *t
+	c.prometheus = &prometheusQuerier{url: url, logger: t.logger, timeNow: time.Now}
+	return &c
 }
 
 // Execute parses the provided text (or returns it unchanged if not a Go template), associates it with the templates
 // defined in t.tmpl (so they may be referenced and used) and applies the resulting template to the specified data
-// object, returning the output as a string .
+// object, returning the output as a string. Parsed texts are cached, so each is only parsed once.
 func (t *Template) Execute(text string, data interface{}) (string, error) {
+	ret, _, err := t.ExecuteLimit(text, data, 0)
+	return ret, err
+}
+
+// ExecuteLimit is like Execute, but stops executing the template once its output reaches limit bytes, unless zero,
+// returning the output up to the limit, cut on a rune boundary, and whether it was reached. This bounds the memory used to render texts which
+// are truncated anyway, e.g. descriptions listing thousands of alerts.
+func (t *Template) ExecuteLimit(text string, data interface{}, limit int) (string, bool, error) {
 	level.Debug(t.logger).Log("msg", "executing template", "template", text)
 	if !strings.Contains(text, "{{") {
 		level.Debug(t.logger).Log("msg", "returning unchanged")
-		return text, nil
+		if limit > 0 && len(text) > limit {
+			for limit > 0 && !utf8.RuneStart(text[limit]) {
+				limit--
+			}
+			return text[:limit], true, nil
+		}
+		return text, false, nil
+	}
+
+	parsed, err := t.lookup(text)
+	if err != nil {
+		return "", false, err
+	}
+	w := &limitWriter{limit: limit}
+	size := int(parsed.lastLen.Load())
+	if limit > 0 && size > limit {
+		size = limit
+	}
+	w.buf.Grow(size)
+	err = parsed.tmpl.Execute(w, data)
+	// text/template returns the errors of the writer as is.
+	reached := err == errLimitReached
+	if err != nil && !reached {
+		return "", false, errors.Wrapf(err, "execute template %s", text)
+	}
+
+	ret := w.buf.String()
+	parsed.lastLen.Store(int64(len(ret)))
+	level.Debug(t.logger).Log("msg", "template output", "output", ret, "limit_reached", reached)
+	return ret, reached, nil
+}
+
+// errLimitReached stops executing templates whose output reached the limit of ExecuteLimit.
+var errLimitReached = errors.New("template output limit reached")
+
+// limitWriter collects the output of templates up to limit bytes, unless zero, failing the write reaching it. The
+// output is cut on a rune boundary.
+type limitWriter struct {
+	// Unlike bytes.Buffer, strings.Builder doesn't copy the output, which may be a long description, into a string.
+	buf   strings.Builder
+	limit int
+}
+
+func (w *limitWriter) Write(p []byte) (int, error) {
+	if room := w.limit - w.buf.Len(); w.limit > 0 && len(p) > room {
+		for room > 0 && !utf8.RuneStart(p[room]) {
+			room--
+		}
+		w.buf.Write(p[:room])
+		return room, errLimitReached
+	}
+	return w.buf.Write(p)
+}
+
+// lookup returns the template parsed from text, parsing it on first use.
+func (t *Template) lookup(text string) (*parsedTemplate, error) {
+	key := parsedKey{text: text, strict: t.strict}
+	if t.location != nil {
+		key.location = t.location.String()
+	}
+	if t.prometheus != nil {
+		key.prometheus = t.prometheus.url
+	}
+	t.parsed.mtx.RLock()
+	parsed, ok := t.parsed.templates[key]
+	t.parsed.mtx.RUnlock()
+	if ok {
+		return parsed, nil
+	}
+
+	tmpl, err := t.parse(text)
+	if err != nil {
+		return nil, err
 	}
+	parsed = &parsedTemplate{tmpl: tmpl}
+	t.parsed.mtx.Lock()
+	t.parsed.templates[key] = parsed
+	t.parsed.mtx.Unlock()
+	return parsed, nil
+}
 
+// parse associates text with the templates defined in t.tmpl, without modifying them.
+func (t *Template) parse(text string) (*template.Template, error) {
 	tmpl, err := t.tmpl.Clone()
 	if err != nil {
 		// There is literally no return flow in Clone that returns error.
-		return "", errors.Wrap(err, "parse clone tmpl")
+		return nil, errors.Wrap(err, "parse clone tmpl")
+	}
+	if t.strict {
+		tmpl = tmpl.Option("missingkey=error")
+	}
+	if t.location != nil {
+		tmpl = tmpl.Funcs(template.FuncMap{"dateFormat": dateFormatIn(t.location)})
+	}
+	if t.prometheus != nil {
+		tmpl = tmpl.Funcs(t.prometheus.funcs())
 	}
 	tmpl, err = tmpl.New("").Parse(text)
 	if err != nil {
-		return "", errors.Wrapf(err, "parse template %s", text)
-	}
-	var buf bytes.Buffer
-
-	if err = tmpl.Execute(&buf, data); err != nil {
-		return "", errors.Wrapf(err, "execute template %s", text)
+		return nil, errors.Wrapf(err, "parse template %s", text)
 	}
-	ret := buf.String()
-	level.Debug(t.logger).Log("msg", "template output", "output", ret)
-	return ret, nil
+	return tmpl, nil
 }
@@ -0,0 +1,269 @@
+// Copyright 2017 The Prometheus Authors
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+package template
+
+import (
+	"bytes"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/go-kit/log"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestFuncs(t *testing.T) {
+	data := map[string]interface{}{
+		"Labels":   map[string]string{"alertname": "HighLatency", "service": "api-gateway", "count": "3"},
+		"StartsAt": time.Date(2022, 3, 4, 5, 6, 7, 0, time.UTC),
+		"Empty":    "",
+		"JSON":     `{"team":"infra","tags":["a","b"]}`,
+
+		"ExternalURL":  "http://alertmanager:9093/",
+		"GeneratorURL": "http://prometheus-0:9090/graph?g0.expr=rate%28errors%5B5m%5D%29+%3E+1&g0.tab=1",
+	}
+	for _, tc := range []struct {
+		text, out string
+	}{
+		{text: `{{ .Empty | default "none" }}`, out: "none"},
+		{text: `{{ .Labels.service | default "none" }}`, out: "api-gateway"},
+		{text: `{{ .Labels.missing | default "none" }}`, out: "none"},
+		{text: `{{ .Labels.service | trunc 3 }}`, out: "api"},
+		{text: `{{ .Labels.service | trunc -7 }}`, out: "gateway"},
+		{text: `{{ .Labels.service | trunc 50 }}`, out: "api-gateway"},
+		{text: `{{ "  x " | trim }}`, out: "x"},
+		{text: `{{ .Labels.service | trimPrefix "api-" }}`, out: "gateway"},
+		{text: `{{ .Labels.service | trimSuffix "-gateway" }}`, out: "api"},
+		{text: `{{ .Labels.service | replace "-" "_" }}`, out: "api_gateway"},
+		{text: `{{ index (.Labels.service | split "-") 1 }}`, out: "gateway"},
+		{text: `{{ if .Labels.alertname | contains "Latency" }}yes{{ end }}`, out: "yes"},
+		{text: `{{ add .Labels.count 2 1.5 }}`, out: "6"},
+		{text: `{{ sub .Labels.count 1 }}`, out: "2"},
+		{text: `{{ if gt (int .Labels.count) 2 }}many{{ end }}`, out: "many"},
+		{text: `{{ float64 "1.5" }}`, out: "1.5"},
+		{text: `{{ int "nope" }}`, out: "0"},
+		{text: `{{ .StartsAt | date "2006-01-02 15:04" }}`, out: "2022-03-04 05:06"},
+		{text: `{{ 1646370367 | date "2006-01-02" }}`, out: "2022-03-04"},
+		{text: `{{ if now.After .StartsAt }}after{{ end }}`, out: "after"},
+		{text: `{{ .StartsAt | dateFormat "RFC3339" }}`, out: "2022-03-04T05:06:07Z"},
+		{text: `{{ .StartsAt | tz "Asia/Tokyo" | date "2006-01-02 15:04 MST" }}`, out: "2022-03-04 14:06 JST"},
+		{text: `{{ 1646370367 | tz "America/New_York" | dateFormat "DateTime" }}`, out: "2022-03-04 05:06:07"},
+		{text: `{{ humanizeDuration 93784 }}`, out: "1d 2h 3m 4s"},
+		{text: `{{ humanizeDuration 62 }}`, out: "1m 2s"},
+		{text: `{{ humanizeDuration 1.5 }}`, out: "1.5s"},
+		{text: `{{ humanizeDuration 0.25 }}`, out: "250ms"},
+		{text: `{{ humanizeDuration 0 }}`, out: "0s"},
+		{text: `{{ .Labels | toJson }}`, out: `{"alertname":"HighLatency","count":"3","service":"api-gateway"}`},
+		{text: `{{ (fromJson .JSON).team }} {{ index (fromJson .JSON).tags 1 }}`, out: "infra b"},
+		{text: `{{ fromJson "{" }}`, out: "<no value>"},
+		{text: `{{ (mustFromJson .JSON).team }}`, out: "infra"},
+		{text: `{{ "a/b c" | urlquery }}`, out: "a%2Fb+c"},
+		{text: `{{ "jiralert" | b64enc }}`, out: "amlyYWxlcnQ="},
+		{text: `{{ "amlyYWxlcnQ=" | b64dec }}`, out: "jiralert"},
+		{text: `{{ "up{job=\"a|b\"} * [5m]" | jiraEscape }}`, out: `up\{job="a\|b"\} \* \[5m\]`},
+		{text: `{{ "rate(x[5m])" | code }}`, out: "{code}\nrate(x[5m])\n{code}"},
+		{text: `{{ "a{code}b" | code "promql" }}`, out: "{code:promql}\na{\u200bcode}b\n{code}"},
+		{text: `{{ "*raw*" | noformat }}`, out: "{noformat}\n*raw*\n{noformat}"},
+		{text: `{{ tableHeader "Name" "Value" }}`, out: "||Name||Value||"},
+		{text: `{{ tableRow "a|b" "" "line 1\nline 2" 3 }}`, out: `|a\|b| |line 1 \\ line 2|3|`},
+		{text: `{{ kvTable "Label" "Value" .Labels }}`, out: "||Label||Value||\n|alertname|HighLatency|\n|count|3|\n|service|api\\-gateway|"},
+		{text: `{{ silenceURL .ExternalURL .Labels }}`, out: "http://alertmanager:9093/#/silences/new?filter=%7Balertname%3D%22HighLatency%22%2Ccount%3D%223%22%2Cservice%3D%22api-gateway%22%7D"},
+		{text: `{{ alertsURL "http://alertmanager:9093" .Labels }}`, out: "http://alertmanager:9093/#/alerts?filter=%7Balertname%3D%22HighLatency%22%2Ccount%3D%223%22%2Cservice%3D%22api-gateway%22%7D"},
+		{text: `{{ .GeneratorURL | generatorQuery }}`, out: "rate(errors[5m]) > 1"},
+		{text: `{{ .GeneratorURL | rebaseURL "https://example.com/prometheus/" }}`, out: "https://example.com/prometheus/graph?g0.expr=rate%28errors%5B5m%5D%29+%3E+1&g0.tab=1"},
+		{text: `{{ .GeneratorURL | withQuery "g0.range_input" "6h" }}`, out: "http://prometheus-0:9090/graph?g0.expr=rate%28errors%5B5m%5D%29+%3E+1&g0.range_input=6h&g0.tab=1"},
+		{text: `{{ urlJoin "https://runbooks.example.com/" "alerts" "High Latency/p99" }}`, out: "https://runbooks.example.com/alerts/High%20Latency%2Fp99"},
+		{text: `{{ .GeneratorURL | generatorQuery | grafanaExploreURL "https://grafana.example.com" "prometheus" }}`, out: "https://grafana.example.com/explore?left=%7B%22datasource%22%3A%22prometheus%22%2C%22queries%22%3A%5B%7B%22refId%22%3A%22A%22%2C%22expr%22%3A%22rate%28errors%5B5m%5D%29+%5Cu003e+1%22%7D%5D%2C%22range%22%3A%7B%22from%22%3A%22now-1h%22%2C%22to%22%3A%22now%22%7D%7D"},
+	} {
+		t.Run(tc.text, func(t *testing.T) {
+			out, err := SimpleTemplate().Execute(tc.text, data)
+			require.NoError(t, err)
+			require.Equal(t, tc.out, out)
+		})
+	}
+
+	_, err := SimpleTemplate().Execute(`{{ mustFromJson "{" }}`, data)
+	require.Error(t, err)
+	_, err = SimpleTemplate().Execute(`{{ .StartsAt | tz "Mars/Olympus_Mons" }}`, data)
+	require.Error(t, err)
+	_, err = SimpleTemplate().Execute(`{{ kvTable "Label" "Value" .Empty }}`, data)
+	require.Error(t, err)
+	_, err = SimpleTemplate().Execute(`{{ silenceURL .ExternalURL (stringSlice "x") }}`, data)
+	require.Error(t, err)
+}
+
+func TestLoadTemplates(t *testing.T) {
+	dir := t.TempDir()
+	require.NoError(t, os.Mkdir(filepath.Join(dir, "teams"), 0o755))
+	for name, content := range map[string]string{
+		"jiralert.tmpl":    `{{ define "jira.summary" }}[{{ template "team" . }}] {{ .Status }}{{ end }}`,
+		"teams/infra.tmpl": `{{ define "team" }}infra{{ end }}`,
+		"teams/README.md":  `{{ define "team" }}not a template{{ end }}`,
+	} {
+		require.NoError(t, os.WriteFile(filepath.Join(dir, name), []byte(content), 0o644))
+	}
+
+	tmpl, err := LoadTemplates([]string{filepath.Join(dir, "jiralert.tmpl"), filepath.Join(dir, "teams/*.tmpl")}, nil, nil, log.NewNopLogger())
+	require.NoError(t, err)
+	out, err := tmpl.Execute(`{{ template "jira.summary" . }}`, map[string]string{"Status": "firing"})
+	require.NoError(t, err)
+	require.Equal(t, "[infra] firing", out)
+
+	_, err = LoadTemplates([]string{filepath.Join(dir, "missing/*.tmpl")}, nil, nil, log.NewNopLogger())
+	require.Error(t, err)
+}
+
+func TestStrict(t *testing.T) {
+	data := map[string]interface{}{"CommonLabels": map[string]string{"severity": "critical"}}
+	tmpl := SimpleTemplate()
+
+	out, err := tmpl.Execute(`{{ .CommonLabels.sevrity }}`, data)
+	require.NoError(t, err)
+	require.Equal(t, "", out)
+
+	_, err = tmpl.Strict().Execute(`{{ .CommonLabels.sevrity }}`, data)
+	require.ErrorContains(t, err, `map has no entry for key "sevrity"`)
+	out, err = tmpl.Strict().Execute(`{{ .CommonLabels.severity }} {{ index .CommonLabels "team" | default "none" }}`, data)
+	require.NoError(t, err)
+	require.Equal(t, "critical none", out)
+}
+
+func TestIn(t *testing.T) {
+	data := map[string]interface{}{"StartsAt": time.Date(2022, 3, 4, 5, 6, 7, 0, time.UTC)}
+	tmpl := SimpleTemplate()
+	berlin, err := time.LoadLocation("Europe/Berlin")
+	require.NoError(t, err)
+
+	out, err := tmpl.In(berlin).Execute(`{{ .StartsAt | dateFormat "2006-01-02 15:04 MST" }}`, data)
+	require.NoError(t, err)
+	require.Equal(t, "2022-03-04 06:06 CET", out)
+	// The parsed template is cached per location.
+	out, err = tmpl.Execute(`{{ .StartsAt | dateFormat "2006-01-02 15:04 MST" }}`, data)
+	require.NoError(t, err)
+	require.Equal(t, "2022-03-04 05:06 UTC", out)
+	out, err = tmpl.In(berlin).Strict().Execute(`{{ .StartsAt | dateFormat "15:04" }}`, data)
+	require.NoError(t, err)
+	require.Equal(t, "06:06", out)
+}
+
+func TestExecuteCache(t *testing.T) {
+	tmpl := SimpleTemplate()
+	text := `{{ define "x" }}{{ .CommonLabels.severity }}{{ end }}{{ template "x" . }}`
+	data := map[string]interface{}{"CommonLabels": map[string]string{}}
+
+	for i := 0; i < 2; i++ {
+		out, err := tmpl.Execute(text, data)
+		require.NoError(t, err)
+		require.Equal(t, "", out)
+	}
+	require.Len(t, tmpl.parsed.templates, 1)
+
+	// The strict copy shares the cache, but not the parsed templates.
+	_, err := tmpl.Strict().Execute(text, data)
+	require.Error(t, err)
+	require.Len(t, tmpl.parsed.templates, 2)
+
+	// Templates defined by executed texts don't leak into other executions.
+	_, err = tmpl.Execute(`{{ template "x" . }}`, data)
+	require.Error(t, err)
+}
+
+// limitData counts the items rendered by templates.
+type limitData struct {
+	Items    []string
+	rendered int
+}
+
+func (d *limitData) Render(item string) string {
+	d.rendered++
+	return item + ","
+}
+
+func TestExecuteLimit(t *testing.T) {
+	tmpl := SimpleTemplate()
+	text := `{{ range .Items }}{{ $.Render . }}{{ end }}`
+
+	data := &limitData{Items: []string{"a", "b", "c", "d"}}
+	out, reached, err := tmpl.ExecuteLimit(text, data, 5)
+	require.NoError(t, err)
+	require.True(t, reached)
+	require.Equal(t, "a,b,c", out)
+	// The execution stopped at the item reaching the limit.
+	require.Equal(t, 3, data.rendered)
+
+	// Outputs of the limit length are complete.
+	data = &limitData{Items: []string{"a", "b", "c"}}
+	out, reached, err = tmpl.ExecuteLimit(text, data, 6)
+	require.NoError(t, err)
+	require.False(t, reached)
+	require.Equal(t, "a,b,c,", out)
+
+	out, reached, err = tmpl.ExecuteLimit(text, data, 0)
+	require.NoError(t, err)
+	require.False(t, reached)
+	require.Equal(t, "a,b,c,", out)
+
+	out, reached, err = tmpl.ExecuteLimit("not a template", nil, 5)
+	require.NoError(t, err)
+	require.True(t, reached)
+	require.Equal(t, "not a", out)
+
+	// Runes are not split.
+	out, reached, err = tmpl.ExecuteLimit("ééé", nil, 3)
+	require.NoError(t, err)
+	require.True(t, reached)
+	require.Equal(t, "é", out)
+	out, reached, err = tmpl.ExecuteLimit(`{{ "ééé" }}`, nil, 3)
+	require.NoError(t, err)
+	require.True(t, reached)
+	require.Equal(t, "é", out)
+
+	// Other errors still fail the execution.
+	_, _, err = tmpl.ExecuteLimit(`{{ .Missing.Field }}`, data, 5)
+	require.Error(t, err)
+}
+
+func BenchmarkExecute(b *testing.B) {
+	tmpl := SimpleTemplate()
+	text := `[{{ .Status | toUpper }}{{ if eq .Status "firing" }}:{{ len .Alerts }}{{ end }}] {{ .GroupLabels.alertname }}`
+	data := map[string]interface{}{
+		"Status":      "firing",
+		"Alerts":      []string{"a", "b"},
+		"GroupLabels": map[string]string{"alertname": "HighLatency"},
+	}
+
+	b.Run("cached", func(b *testing.B) {
+		b.ReportAllocs()
+		for i := 0; i < b.N; i++ {
+			if _, err := tmpl.Execute(text, data); err != nil {
+				b.Fatal(err)
+			}
+		}
+	})
+	// As Execute did before caching parsed templates.
+	b.Run("uncached", func(b *testing.B) {
+		b.ReportAllocs()
+		for i := 0; i < b.N; i++ {
+			parsed, err := tmpl.parse(text)
+			if err != nil {
+				b.Fatal(err)
+			}
+			var buf bytes.Buffer
+			if err := parsed.Execute(&buf, data); err != nil {
+				b.Fatal(err)
+			}
+		}
+	})
+}
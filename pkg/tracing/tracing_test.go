@@ -0,0 +1,57 @@
+// Copyright 2017 The Prometheus Authors
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+package tracing
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync/atomic"
+	"testing"
+
+	"github.com/prometheus-community/jiralert/pkg/config"
+	"github.com/stretchr/testify/require"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/propagation"
+)
+
+func TestInstall(t *testing.T) {
+	var exports int32
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		if req.URL.Path == "/v1/traces" && req.Header.Get("Authorization") == "Bearer t0k3n" {
+			atomic.AddInt32(&exports, 1)
+		}
+		w.WriteHeader(http.StatusOK)
+	}))
+	defer srv.Close()
+
+	shutdown, err := Install(&config.TracingConfig{
+		Endpoint: strings.TrimPrefix(srv.URL, "http://"),
+		Insecure: true,
+		Headers:  map[string]config.Secret{"Authorization": "Bearer t0k3n"},
+	}, "test")
+	require.NoError(t, err)
+
+	ctx, span := Tracer().Start(context.Background(), "test")
+	require.True(t, span.SpanContext().IsSampled())
+
+	// The trace context is propagated in W3C format.
+	header := http.Header{}
+	otel.GetTextMapPropagator().Inject(ctx, propagation.HeaderCarrier(header))
+	require.Contains(t, header.Get("Traceparent"), span.SpanContext().TraceID().String())
+
+	span.End()
+	require.NoError(t, shutdown(context.Background()))
+	require.Equal(t, int32(1), atomic.LoadInt32(&exports))
+}
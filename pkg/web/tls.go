@@ -0,0 +1,130 @@
+// Copyright 2017 The Prometheus Authors
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package web configures the JIRAlert HTTP server. The web configuration file follows the format of the Prometheus
+// exporter-toolkit, see https://github.com/prometheus/exporter-toolkit/blob/master/docs/web-configuration.md, of
+// which the TLS server settings are supported.
+package web
+
+import (
+	"bytes"
+	"crypto/tls"
+	"crypto/x509"
+	"os"
+	"path/filepath"
+
+	"github.com/pkg/errors"
+	"github.com/prometheus-community/jiralert/pkg/config"
+	yaml "gopkg.in/yaml.v3"
+)
+
+// Config is the web configuration.
+type Config struct {
+	TLSServerConfig *TLSServerConfig `yaml:"tls_server_config"`
+}
+
+// TLSServerConfig configures HTTPS serving, optionally with client certificate authentication.
+type TLSServerConfig struct {
+	CertFile     string `yaml:"cert_file"`
+	KeyFile      string `yaml:"key_file"`
+	ClientCAFile string `yaml:"client_ca_file"`
+	// One of NoClientCert, RequestClientCert, RequireAnyClientCert, VerifyClientCertIfGiven or
+	// RequireAndVerifyClientCert. Optional (default: RequireAndVerifyClientCert if client_ca_file is set,
+	// NoClientCert otherwise).
+	ClientAuthType string `yaml:"client_auth_type"`
+	// Minimum TLS version, one of TLS10, TLS11, TLS12 or TLS13. Optional (default: TLS12).
+	MinVersion string `yaml:"min_version"`
+}
+
+var clientAuthTypes = map[string]tls.ClientAuthType{
+	"NoClientCert":               tls.NoClientCert,
+	"RequestClientCert":          tls.RequestClientCert,
+	"RequireAnyClientCert":       tls.RequireAnyClientCert,
+	"VerifyClientCertIfGiven":    tls.VerifyClientCertIfGiven,
+	"RequireAndVerifyClientCert": tls.RequireAndVerifyClientCert,
+}
+
+// LoadConfig parses the web configuration file at path. Relative paths in it are resolved against its directory.
+func LoadConfig(path string) (*Config, error) {
+	content, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	c := &Config{}
+	dec := yaml.NewDecoder(bytes.NewReader(content))
+	dec.KnownFields(true)
+	if err := dec.Decode(c); err != nil {
+		return nil, errors.Wrap(err, "parse web config")
+	}
+
+	if tc := c.TLSServerConfig; tc != nil {
+		if tc.CertFile == "" || tc.KeyFile == "" {
+			return nil, errors.New("bad tls_server_config: cert_file and key_file are required")
+		}
+		if _, ok := clientAuthTypes[tc.ClientAuthType]; !ok && tc.ClientAuthType != "" {
+			return nil, errors.Errorf("bad tls_server_config: unknown client_auth_type %q", tc.ClientAuthType)
+		}
+		if tc.ClientCAFile == "" && (tc.ClientAuthType == "VerifyClientCertIfGiven" || tc.ClientAuthType == "RequireAndVerifyClientCert") {
+			return nil, errors.Errorf("bad tls_server_config: client_auth_type %s requires client_ca_file", tc.ClientAuthType)
+		}
+		if _, ok := config.TLSVersions[tc.MinVersion]; !ok && tc.MinVersion != "" {
+			return nil, errors.Errorf("bad tls_server_config: unknown min_version %q", tc.MinVersion)
+		}
+		dir := filepath.Dir(path)
+		for _, f := range []*string{&tc.CertFile, &tc.KeyFile, &tc.ClientCAFile} {
+			if *f != "" && !filepath.IsAbs(*f) {
+				*f = filepath.Join(dir, *f)
+			}
+		}
+	}
+	return c, nil
+}
+
+// TLSConfig returns the TLS configuration of the server. The certificate is loaded on each handshake, so that
+// renewed certificates are picked up without a restart.
+func (c *TLSServerConfig) TLSConfig() (*tls.Config, error) {
+	// Fail early on unusable certificates.
+	if _, err := tls.LoadX509KeyPair(c.CertFile, c.KeyFile); err != nil {
+		return nil, errors.Wrap(err, "load server certificate")
+	}
+	tlsConfig := &tls.Config{
+		MinVersion: tls.VersionTLS12,
+		GetCertificate: func(*tls.ClientHelloInfo) (*tls.Certificate, error) {
+			cert, err := tls.LoadX509KeyPair(c.CertFile, c.KeyFile)
+			if err != nil {
+				return nil, errors.Wrap(err, "load server certificate")
+			}
+			return &cert, nil
+		},
+	}
+	if c.MinVersion != "" {
+		tlsConfig.MinVersion = config.TLSVersions[c.MinVersion]
+	}
+
+	if c.ClientCAFile != "" {
+		b, err := os.ReadFile(c.ClientCAFile)
+		if err != nil {
+			return nil, errors.Wrap(err, "read client CA file")
+		}
+		pool := x509.NewCertPool()
+		if !pool.AppendCertsFromPEM(b) {
+			return nil, errors.Errorf("no certificates found in client CA file %s", c.ClientCAFile)
+		}
+		tlsConfig.ClientCAs = pool
+		tlsConfig.ClientAuth = tls.RequireAndVerifyClientCert
+	}
+	if c.ClientAuthType != "" {
+		tlsConfig.ClientAuth = clientAuthTypes[c.ClientAuthType]
+	}
+	return tlsConfig, nil
+}
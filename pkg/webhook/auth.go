@@ -0,0 +1,82 @@
+// Copyright 2017 The Prometheus Authors
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package webhook
+
+import (
+	"crypto/sha256"
+	"crypto/subtle"
+	"net/http"
+	"strings"
+
+	"github.com/pkg/errors"
+	"github.com/prometheus-community/jiralert/pkg/config"
+)
+
+// Authenticator checks the credentials of webhook requests.
+type Authenticator struct {
+	username, password []byte
+	bearerToken        []byte
+}
+
+// NewAuthenticator creates an Authenticator from the given (validated) configuration.
+func NewAuthenticator(c *config.WebhookAuthConfig) *Authenticator {
+	a := &Authenticator{}
+	if c.BasicAuth != nil {
+		a.username, a.password = []byte(c.BasicAuth.Username), []byte(c.BasicAuth.Password)
+	} else {
+		a.bearerToken = []byte(c.BearerToken)
+	}
+	return a
+}
+
+// Authenticate returns an error if the request does not carry the configured credentials.
+func (a *Authenticator) Authenticate(req *http.Request) error {
+	if a.bearerToken != nil {
+		auth := req.Header.Get("Authorization")
+		token := strings.TrimPrefix(auth, "Bearer ")
+		if token == auth {
+			return errors.New("missing bearer token")
+		}
+		if !secureEqual([]byte(token), a.bearerToken) {
+			return errors.New("invalid bearer token")
+		}
+		return nil
+	}
+
+	user, password, ok := req.BasicAuth()
+	if !ok {
+		return errors.New("missing basic auth credentials")
+	}
+	// Compare both, so that the duration does not tell which one is wrong.
+	userOK := secureEqual([]byte(user), a.username)
+	passwordOK := secureEqual([]byte(password), a.password)
+	if !userOK || !passwordOK {
+		return errors.New("invalid basic auth credentials")
+	}
+	return nil
+}
+
+// Challenge returns the WWW-Authenticate header value of responses to unauthenticated requests.
+func (a *Authenticator) Challenge() string {
+	if a.bearerToken != nil {
+		return "Bearer"
+	}
+	return `Basic realm="jiralert"`
+}
+
+// secureEqual compares a and b in constant time. Hashing first hides the length of the expected value.
+func secureEqual(a, b []byte) bool {
+	ha, hb := sha256.Sum256(a), sha256.Sum256(b)
+	return subtle.ConstantTimeCompare(ha[:], hb[:]) == 1
+}
@@ -0,0 +1,54 @@
+// Copyright 2017 The Prometheus Authors
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+package webhook
+
+import (
+	"net/http/httptest"
+	"testing"
+
+	"github.com/prometheus-community/jiralert/pkg/config"
+	"github.com/stretchr/testify/require"
+)
+
+func TestAuthenticator(t *testing.T) {
+	basic := NewAuthenticator(&config.WebhookAuthConfig{BasicAuth: &config.BasicAuthConfig{Username: "alertmanager", Password: "s3cr3t"}})
+	bearer := NewAuthenticator(&config.WebhookAuthConfig{BearerToken: "t0k3n"})
+
+	for _, tcase := range []struct {
+		name        string
+		auth        *Authenticator
+		header      string
+		expectedErr string
+	}{
+		{name: "valid basic auth", auth: basic, header: "Basic YWxlcnRtYW5hZ2VyOnMzY3IzdA=="},
+		{name: "wrong password", auth: basic, header: "Basic YWxlcnRtYW5hZ2VyOndyb25n", expectedErr: "invalid basic auth credentials"},
+		{name: "missing basic auth", auth: basic, expectedErr: "missing basic auth credentials"},
+		{name: "bearer instead of basic auth", auth: basic, header: "Bearer t0k3n", expectedErr: "missing basic auth credentials"},
+		{name: "valid bearer token", auth: bearer, header: "Bearer t0k3n"},
+		{name: "wrong bearer token", auth: bearer, header: "Bearer t0k3", expectedErr: "invalid bearer token"},
+		{name: "missing bearer token", auth: bearer, expectedErr: "missing bearer token"},
+	} {
+		t.Run(tcase.name, func(t *testing.T) {
+			req := httptest.NewRequest("POST", "/alert", nil)
+			if tcase.header != "" {
+				req.Header.Set("Authorization", tcase.header)
+			}
+			err := tcase.auth.Authenticate(req)
+			if tcase.expectedErr == "" {
+				require.NoError(t, err)
+				return
+			}
+			require.EqualError(t, err, tcase.expectedErr)
+		})
+	}
+}
@@ -0,0 +1,130 @@
+// Copyright 2017 The Prometheus Authors
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package webhook implements checks applied to incoming webhook requests before they reach the notify pipeline.
+package webhook
+
+import (
+	"crypto/hmac"
+	"crypto/sha1"
+	"crypto/sha256"
+	"crypto/sha512"
+	"encoding/hex"
+	"hash"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/pkg/errors"
+	"github.com/prometheus-community/jiralert/pkg/config"
+)
+
+// SignatureVerifier checks HMAC signatures of webhook payloads.
+type SignatureVerifier struct {
+	secret          []byte
+	header          string
+	algorithm       string
+	newHash         func() hash.Hash
+	timestampHeader string
+	tolerance       time.Duration
+
+	timeNow func() time.Time
+}
+
+// NewSignatureVerifier creates a SignatureVerifier from the given (validated) configuration.
+func NewSignatureVerifier(c *config.WebhookSignatureConfig) (*SignatureVerifier, error) {
+	var newHash func() hash.Hash
+	switch c.Algorithm {
+	case "sha1":
+		newHash = sha1.New
+	case "sha256":
+		newHash = sha256.New
+	case "sha512":
+		newHash = sha512.New
+	default:
+		return nil, errors.Errorf("unsupported signature algorithm %q", c.Algorithm)
+	}
+
+	v := &SignatureVerifier{
+		secret:          []byte(c.Secret),
+		header:          c.Header,
+		algorithm:       c.Algorithm,
+		newHash:         newHash,
+		timestampHeader: c.TimestampHeader,
+		timeNow:         time.Now,
+	}
+	if c.Tolerance != nil {
+		v.tolerance = time.Duration(*c.Tolerance)
+	}
+	return v, nil
+}
+
+// Verify returns an error if the signature in the request headers does not match the body, or if the signature
+// timestamp is outside the accepted tolerance.
+func (v *SignatureVerifier) Verify(header http.Header, body []byte) error {
+	sig := header.Get(v.header)
+	if sig == "" {
+		return errors.Errorf("missing signature header %s", v.header)
+	}
+	sig = strings.TrimPrefix(sig, v.algorithm+"=")
+	got, err := hex.DecodeString(sig)
+	if err != nil {
+		return errors.Wrap(err, "decode signature")
+	}
+
+	var ts string
+	if v.timestampHeader != "" {
+		ts = header.Get(v.timestampHeader)
+		if ts == "" {
+			return errors.Errorf("missing timestamp header %s", v.timestampHeader)
+		}
+		sec, err := strconv.ParseInt(ts, 10, 64)
+		if err != nil {
+			return errors.Wrap(err, "parse signature timestamp")
+		}
+		skew := v.timeNow().Sub(time.Unix(sec, 0))
+		if skew < 0 {
+			skew = -skew
+		}
+		if v.tolerance > 0 && skew > v.tolerance {
+			return errors.Errorf("signature timestamp %s outside of tolerance %s", ts, v.tolerance)
+		}
+	}
+
+	if !hmac.Equal(got, v.sum(ts, body)) {
+		return errors.New("signature mismatch")
+	}
+	return nil
+}
+
+// Sign sets the signature headers of a request with the given body, as verified by Verify, e.g. for notifications
+// forwarded to another replica sharing the secret.
+func (v *SignatureVerifier) Sign(header http.Header, body []byte) {
+	var ts string
+	if v.timestampHeader != "" {
+		ts = strconv.FormatInt(v.timeNow().Unix(), 10)
+		header.Set(v.timestampHeader, ts)
+	}
+	header.Set(v.header, v.algorithm+"="+hex.EncodeToString(v.sum(ts, body)))
+}
+
+// sum returns the HMAC of the body, prefixed with the timestamp if signatures are timestamped.
+func (v *SignatureVerifier) sum(ts string, body []byte) []byte {
+	mac := hmac.New(v.newHash, v.secret)
+	if v.timestampHeader != "" {
+		_, _ = mac.Write([]byte(ts + "."))
+	}
+	_, _ = mac.Write(body)
+	return mac.Sum(nil)
+}
@@ -0,0 +1,114 @@
+// Copyright 2017 The Prometheus Authors
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+package webhook
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"net/http"
+	"strconv"
+	"testing"
+	"time"
+
+	"github.com/prometheus-community/jiralert/pkg/config"
+	"github.com/stretchr/testify/require"
+)
+
+func sign(secret, payload string) string {
+	mac := hmac.New(sha256.New, []byte(secret))
+	_, _ = mac.Write([]byte(payload))
+	return hex.EncodeToString(mac.Sum(nil))
+}
+
+func TestSignatureVerifier(t *testing.T) {
+	now := time.Unix(1600000000, 0)
+	tolerance := config.Duration(time.Minute)
+	body := []byte(`{"receiver":"jira-ab"}`)
+
+	for _, tcase := range []struct {
+		name        string
+		conf        config.WebhookSignatureConfig
+		header      http.Header
+		expectedErr string
+	}{
+		{
+			name:   "valid signature",
+			conf:   config.WebhookSignatureConfig{Secret: "s3cr3t", Header: "X-Sig", Algorithm: "sha256"},
+			header: http.Header{"X-Sig": []string{sign("s3cr3t", string(body))}},
+		},
+		{
+			name:   "valid prefixed signature",
+			conf:   config.WebhookSignatureConfig{Secret: "s3cr3t", Header: "X-Sig", Algorithm: "sha256"},
+			header: http.Header{"X-Sig": []string{"sha256=" + sign("s3cr3t", string(body))}},
+		},
+		{
+			name:        "missing signature",
+			conf:        config.WebhookSignatureConfig{Secret: "s3cr3t", Header: "X-Sig", Algorithm: "sha256"},
+			header:      http.Header{},
+			expectedErr: "missing signature header X-Sig",
+		},
+		{
+			name:        "tampered body",
+			conf:        config.WebhookSignatureConfig{Secret: "s3cr3t", Header: "X-Sig", Algorithm: "sha256"},
+			header:      http.Header{"X-Sig": []string{sign("s3cr3t", `{"receiver":"jira-xy"}`)}},
+			expectedErr: "signature mismatch",
+		},
+		{
+			name: "valid timestamped signature",
+			conf: config.WebhookSignatureConfig{Secret: "s3cr3t", Header: "X-Sig", Algorithm: "sha256", TimestampHeader: "X-Ts", Tolerance: &tolerance},
+			header: http.Header{
+				"X-Sig": []string{sign("s3cr3t", strconv.FormatInt(now.Unix(), 10)+"."+string(body))},
+				"X-Ts":  []string{strconv.FormatInt(now.Unix(), 10)},
+			},
+		},
+		{
+			name: "replayed timestamped signature",
+			conf: config.WebhookSignatureConfig{Secret: "s3cr3t", Header: "X-Sig", Algorithm: "sha256", TimestampHeader: "X-Ts", Tolerance: &tolerance},
+			header: http.Header{
+				"X-Sig": []string{sign("s3cr3t", strconv.FormatInt(now.Add(-time.Hour).Unix(), 10)+"."+string(body))},
+				"X-Ts":  []string{strconv.FormatInt(now.Add(-time.Hour).Unix(), 10)},
+			},
+			expectedErr: "outside of tolerance",
+		},
+	} {
+		t.Run(tcase.name, func(t *testing.T) {
+			v, err := NewSignatureVerifier(&tcase.conf)
+			require.NoError(t, err)
+			v.timeNow = func() time.Time { return now }
+
+			err = v.Verify(tcase.header, body)
+			if tcase.expectedErr == "" {
+				require.NoError(t, err)
+				return
+			}
+			require.Error(t, err)
+			require.Contains(t, err.Error(), tcase.expectedErr)
+		})
+	}
+}
+
+func TestSignatureVerifierSign(t *testing.T) {
+	body := []byte(`{"receiver":"jira-ab"}`)
+	for _, conf := range []config.WebhookSignatureConfig{
+		{Secret: "s3cr3t", Header: "X-Sig", Algorithm: "sha512"},
+		{Secret: "s3cr3t", Header: "X-Sig", Algorithm: "sha256", TimestampHeader: "X-Sig-Timestamp"},
+	} {
+		v, err := NewSignatureVerifier(&conf)
+		require.NoError(t, err)
+		header := http.Header{}
+		v.Sign(header, body)
+		require.NoError(t, v.Verify(header, body))
+		require.Error(t, v.Verify(header, []byte(`{"receiver":"jira-cd"}`)))
+	}
+}